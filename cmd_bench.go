@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/bench"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runBench implements `claude-esp bench`: it replays a transcript through
+// the parser and an offscreen stream view and reports throughput and
+// allocations, for maintainers chasing regressions and users reporting
+// lag.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	width := fs.Int("width", 120, "Stream pane width")
+	height := fs.Int("height", 40, "Stream pane height")
+	maxLines := fs.Int("lines", 200, "Replay only the first N lines (0 = all; rendering is slow on large transcripts)")
+	repeat := fs.Int("n", 1, "Replay the transcript this many times")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	cpuProfile := fs.String("cpuprofile", "", "Write a CPU profile of the replay to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp bench [-width N] [-height N] [-lines N] [-n N] [-json] [-cpuprofile f] <file.jsonl | session ID>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err != nil {
+		info, ferr := watcher.FindSession(path)
+		if ferr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s is neither a file nor a session: %v\n", path, ferr)
+			return 1
+		}
+		path = info.Path
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	if *cpuProfile != "" {
+		pf, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer pf.Close()
+		if err := pprof.StartCPUProfile(pf); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	res, err := bench.Run(f, bench.Options{Width: *width, Height: *height, MaxLines: *maxLines, Repeat: *repeat})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(res)
+		return 0
+	}
+
+	fmt.Printf("claude-esp v%s bench: %s\n", version, path)
+	fmt.Printf("replayed %d lines (%.1f MB) → %d items, pane %dx%d\n\n", res.Lines, float64(res.Bytes)/(1<<20), res.Items, *width, *height)
+	fmt.Printf("parse   %10s  %10.0f lines/s  %10.0f items/s  %8.1f allocs/line  %8.1f KB/line\n",
+		res.Parse.Elapsed.Round(time.Microsecond), res.Parse.Rate(res.Lines), res.Parse.Rate(res.Items),
+		perUnit(res.Parse.Allocs, res.Lines), perUnit(res.Parse.AllocBytes, res.Lines)/1024)
+	fmt.Printf("render  %10s  %10s        %10.0f items/s  %8.1f allocs/item  %8.1f KB/item\n",
+		res.Render.Elapsed.Round(time.Millisecond), "", res.Render.Rate(res.Items),
+		perUnit(res.Render.Allocs, res.Items), perUnit(res.Render.AllocBytes, res.Items)/1024)
+	return 0
+}
+
+func perUnit(total uint64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return float64(total) / float64(n)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/doctor"
+)
+
+// runDoctor implements `claude-esp doctor`: it checks the projects
+// directory, sessions, file watching limits and a parse of recent
+// transcript lines, printing a fix for each problem. It exits non-zero
+// when a check fails outright.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	activeWindowStr := fs.String("w", "5m", "Active window to check sessions against")
+	sessions := fs.Int("sessions", 5, "How many recent sessions to sample")
+	lines := fs.Int("lines", 200, "How many lines to sample from the end of each")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp doctor [-w dur] [-sessions N] [-lines N]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	activeWindow, err := time.ParseDuration(*activeWindowStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid active window duration %q: %v\n", *activeWindowStr, err)
+		return 1
+	}
+
+	fmt.Printf("claude-esp v%s doctor\n\n", version)
+	failed := false
+	for _, r := range doctor.Run(doctor.Options{ActiveWindow: activeWindow, SampleSessions: *sessions, SampleLines: *lines}) {
+		icon := "✓"
+		switch r.Status {
+		case doctor.Warn:
+			icon = "!"
+		case doctor.Fail:
+			icon = "✗"
+			failed = true
+		}
+		fmt.Printf("%s %-19s %s\n", icon, r.Name, r.Detail)
+		for _, note := range r.Notes {
+			fmt.Printf("  %-19s %s\n", "", note)
+		}
+		if r.Fix != "" {
+			fmt.Printf("  %-19s fix: %s\n", "", r.Fix)
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/fork"
+	"github.com/phiat/claude-esp/internal/modelswitch"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runExport implements `claude-esp export`: whole sessions, main
+// transcript and subagents, written as the Markdown transcript E saves in
+// the TUI or as CSV with one row per item for spreadsheets and pandas.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "md", "Output format: md (Markdown transcript) or csv (one row per item)")
+	filterExpr := fs.String("filter", "", "Export only items matching this expression (see -filter in claude-esp -h)")
+	outPath := fs.String("o", "", "Write to this file instead of stdout")
+	applyDirs := dirFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp export [-format md|csv] [-filter expr] [-o file] <file.jsonl | session ID>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	applyDirs()
+	if fs.NArg() == 0 || (*format != "md" && *format != "csv") {
+		fs.Usage()
+		return 2
+	}
+	expr, err := filter.Parse(*filterExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	var items []parser.StreamItem
+	// A session forked from another one given shares its history; it is
+	// exported once, under the session given first.
+	forks := fork.New()
+	for _, arg := range fs.Args() {
+		info := watcher.SessionInfo{Path: arg}
+		if _, err := os.Stat(arg); err != nil {
+			if info, err = watcher.FindSession(arg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s is neither a file nor a session: %v\n", arg, err)
+				return 1
+			}
+		}
+		session, err := watcher.ReadSession(info)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		// Name tool results after their call before filtering, so that
+		// tool = Bash and the tool column cover them too.
+		toolNames := map[string]string{}
+		for _, item := range session {
+			if item.Type == parser.TypeToolInput && item.ToolID != "" {
+				toolNames[item.ToolID] = item.ToolName
+			}
+		}
+		for _, item := range modelswitch.Insert(forks.Filter(session)) {
+			if item.Type == parser.TypeToolOutput && item.ToolName == "" {
+				item.ToolName = toolNames[item.ToolID]
+			}
+			if expr.Match(item) {
+				items = append(items, item)
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp.Before(items[j].Timestamp)
+	})
+
+	var w io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" {
+		err = export.CSV(w, items)
+	} else {
+		var store *notes.Store
+		if dir, derr := config.StateDir(); derr == nil {
+			store = notes.New(filepath.Join(dir, "notes"))
+		}
+		err = export.Markdown(w, items, export.Options{Notes: store, Links: true})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/mcp"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runMCP implements `claude-esp mcp`: an MCP server on stdin/stdout exposing
+// session observability tools, so Claude (or any MCP client) can look at
+// past and ongoing sessions. Register it with e.g.
+//
+//	claude mcp add claude-esp -- claude-esp mcp
+func runMCP(args []string) int {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Config file (default config.toml in the config directory)")
+	logLevel := logLevelFlag(fs)
+	applyDirs := dirFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp mcp [-config <file>] [-log-level <level>]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	applyDirs()
+	stopLogging, err := startLogging(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer stopLogging()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	watcher.PathMap = cfg.PathMap()
+	watcher.Active = cfg.ActiveStrategy()
+	parser.ToolFormats = cfg.ToolFormatTable()
+	parser.ToolTemplates = cfg.ToolTemplates()
+	prices, _ := cfg.PricingTable() // Load already rejected invalid overrides
+
+	srv := mcp.NewServer("claude-esp", version, mcp.Tools(mcp.DiskSource{}, prices))
+	if err := srv.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
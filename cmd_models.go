@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+// runModels implements `claude-esp models [-config f] [model...]`: it prints
+// the effective pricing table (builtins plus config overrides), reports any
+// invalid overrides, and shows which entry each given model ID resolves to.
+func runModels(args []string) int {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Config file (default config.toml in the config directory)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp models [-config <file>] [model-id...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Read(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	table, errs := cfg.PricingTable()
+
+	if cfg.Path() != "" {
+		fmt.Printf("Config: %s\n\n", cfg.Path())
+	}
+	fmt.Println("USD per million tokens:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PREFIX\tINPUT\tOUTPUT\tCACHE WRITE\tCACHE READ\tSOURCE")
+	for _, e := range table.Entries() {
+		p := e.Pricing
+		fmt.Fprintf(w, "  %s\t%g\t%g\t%g\t%g\t%s\n", e.Prefix, p.Input, p.Output, p.CacheWrite, p.CacheRead, e.Source)
+	}
+	w.Flush()
+
+	if fs.NArg() > 0 {
+		fmt.Println()
+		for _, model := range fs.Args() {
+			if e, ok := table.Lookup(model); ok {
+				fmt.Printf("  %s → %s (%s)\n", model, e.Prefix, e.Source)
+				continue
+			}
+			p, _ := table.PricingFor(model)
+			fmt.Printf("  %s → unknown, estimated at $%g/$%g in/out\n", model, p.Input, p.Output)
+		}
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintln(os.Stderr)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "invalid: %v\n", err)
+		}
+		return 1
+	}
+	return 0
+}
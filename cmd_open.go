@@ -0,0 +1,79 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/crash"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/tui"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runOpen implements `claude-esp open <file.jsonl>`: the TUI on a single
+// transcript at any path, e.g. copied from another machine or unpacked
+// from a bundle. The whole file is replayed; lines appended later still
+// show up.
+func runOpen(args []string) int {
+	fs := flag.NewFlagSet("open", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Config file (default config.toml in the config directory)")
+	logLevel := logLevelFlag(fs)
+	applyDirs := dirFlags(fs)
+	fullOutput := fs.Bool("full", false, "Show items in full instead of truncating long ones (Z toggles)")
+	applyASCII := asciiFlag(fs)
+	colors := colorsFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp open [-config file] [-log-level level] [-full] [-ascii] [-colors mode] <session.jsonl>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	applyDirs()
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	stopLogging, err := startLogging(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer stopLogging()
+	defer crash.Recover()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	cfg.Terminal.Colors = cmp.Or(*colors, cfg.Terminal.Colors)
+	if err := tui.SetColors(cfg.Terminal.Colors); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	watcher.PathMap = cfg.PathMap()
+	watcher.Active = cfg.ActiveStrategy()
+	parser.ToolFormats = cfg.ToolFormatTable()
+	parser.ToolTemplates = cfg.ToolTemplates()
+
+	model := tui.NewModel(nil, false, cfg.PollInterval(), cfg.ActiveWindow(), 0, 0, cfg)
+	model.SetSessionFile(path)
+	model.SetFullOutput(*fullOutput)
+	applyASCII(model)
+	model.WatchConfig(*configPath)
+	if err := runProgram(model, cfg.Terminal); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/crash"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runPush implements `claude-esp push`: it watches sessions like serve
+// does and sends the stream to a daemon started with `serve -accept-push`
+// on another machine, which shows it labelled with this host's name. It
+// doesn't take the instance lock: a TUI on the same machine keeps
+// running.
+func runPush(args []string) int {
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	to := fs.String("to", "", "Daemon to push to, host:port or URL (required)")
+	hostname, _ := os.Hostname()
+	host := fs.String("host", hostname, "Name to label this machine's sessions with")
+	var sessionIDs stringList
+	fs.Var(&sessionIDs, "s", "Watch a specific session by ID (repeatable)")
+	sessionsFile := fs.String("sessions-file", "", "Watch the sessions listed in this file, one ID per line")
+	skipHistory := fs.Bool("n", false, "Start from newest (skip history, live only)")
+	pollMs := fs.Int("p", 500, "Poll interval in milliseconds (min 100)")
+	activeWindowStr := fs.String("w", "5m", "Active window duration (e.g. 30s, 2m, 5m)")
+	filterExpr := fs.String("filter", "", "Push only items matching this expression (see claude-esp -h)")
+	logLevel := logLevelFlag(fs)
+	applyDirs := dirFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp push -to host:port [-host name] [-s ID]... [-sessions-file f] [-n] [-filter expr] [-log-level level]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	applyDirs()
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "Error: push needs -to host:port")
+		fs.Usage()
+		return 2
+	}
+	stopLogging, err := startLogging(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer stopLogging()
+	defer crash.Recover()
+
+	activeWindow, err := time.ParseDuration(*activeWindowStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid active window duration %q: %v\n", *activeWindowStr, err)
+		return 1
+	}
+	pollInterval := max(time.Duration(*pollMs)*time.Millisecond, 100*time.Millisecond)
+	itemFilter, err := filter.Parse(*filterExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	sessions, err := sessionList(sessionIDs, *sessionsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	pusher, err := sink.Push(*to, *host)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer pusher.Close()
+
+	w, err := watcher.New(sessions, pollInterval, activeWindow, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *skipHistory {
+		w.SetSkipHistory(true)
+	}
+	w.Start()
+	defer w.Stop()
+	fmt.Fprintf(os.Stderr, "claude-esp pushing to %s as %s (Ctrl+C to stop)\n", *to, *host)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Same tool-ID dedupe as serve: the daemon trusts pushers to have done it.
+	seen := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case item := <-w.Items:
+			if key, ok := parser.DedupKey(item); ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			if itemFilter.Match(item) {
+				pusher.Publish(item)
+			}
+		case <-w.NewSession:
+		case err := <-w.Errors:
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		case warning := <-w.Warnings:
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		case fe := <-w.FileErrors:
+			if fe.Err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", fe.Reason())
+			}
+		case err := <-pusher.Errors:
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
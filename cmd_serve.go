@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/crash"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/fork"
+	"github.com/phiat/claude-esp/internal/modelswitch"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/server"
+	"github.com/phiat/claude-esp/internal/sink"
+	"github.com/phiat/claude-esp/internal/trigger"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// defaultHTTPAddr is where `claude-esp serve` listens unless told otherwise.
+// It is loopback-only: without -token-file the API has no authentication.
+const defaultHTTPAddr = "127.0.0.1:7777"
+
+// runServe implements `claude-esp serve`: it watches sessions like the TUI
+// does, without a terminal, and publishes the stream to the HTTP API and
+// any -sink outputs until interrupted. With -accept-push it also publishes
+// what other hosts send with `claude-esp push`.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("http", defaultHTTPAddr, "HTTP listen address")
+	var sessionIDs stringList
+	fs.Var(&sessionIDs, "s", "Watch a specific session by ID (repeatable)")
+	sessionsFile := fs.String("sessions-file", "", "Watch the sessions listed in this file, one ID per line")
+	skipHistory := fs.Bool("n", false, "Start from newest (skip history, live only)")
+	pollMs := fs.Int("p", 500, "Poll interval in milliseconds (min 100)")
+	activeWindowStr := fs.String("w", "5m", "Active window duration (e.g. 30s, 2m, 5m)")
+	var sinkSpecs stringList
+	fs.Var(&sinkSpecs, "sink", "Also publish to unix://<socket> or a FIFO path, ?feed=edits for edit events (repeatable)")
+	filterExpr := fs.String("filter", "", "Publish only items matching this expression (see claude-esp -h)")
+	takeover := fs.Bool("takeover", false, "If another claude-esp is watching, stop it and serve instead")
+	acceptPush := fs.Bool("accept-push", false, "Accept items from other hosts (claude-esp push) on POST /api/push")
+	tokenFile := fs.String("token-file", "", "Require a token from this file on every request (lines of \"<token> [read|admin]\")")
+	tlsCert := fs.String("tls-cert", "", "Serve HTTPS with this certificate (PEM; needs -tls-key)")
+	tlsKey := fs.String("tls-key", "", "Private key for -tls-cert (PEM)")
+	selfSigned := fs.Bool("tls-self-signed", false, "Serve HTTPS with a self-signed certificate kept in the state directory")
+	retain := fs.Duration("retain", 0, "Drop items older than this from the query history (e.g. 168h; 0 keeps the latest 10000)")
+	configPath := fs.String("config", "", "Config file for path_map and [[triggers]] (default config.toml in the config directory)")
+	logLevel := logLevelFlag(fs)
+	applyDirs := dirFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp serve [-http addr] [-s ID]... [-sessions-file f] [-n] [-filter expr] [-sink spec]... [-accept-push] [-token-file f] [-tls-cert f -tls-key f | -tls-self-signed] [-retain dur] [-config f] [-takeover] [-log-level level]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	applyDirs()
+	stopLogging, err := startLogging(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer stopLogging()
+	defer crash.Recover()
+
+	activeWindow, err := time.ParseDuration(*activeWindowStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid active window duration %q: %v\n", *activeWindowStr, err)
+		return 1
+	}
+	pollInterval := max(time.Duration(*pollMs)*time.Millisecond, 100*time.Millisecond)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	watcher.PathMap = cfg.PathMap()
+	watcher.Active = cfg.ActiveStrategy()
+	parser.ToolFormats = cfg.ToolFormatTable()
+	parser.ToolTemplates = cfg.ToolTemplates()
+	itemFilter, err := filter.Parse(*filterExpr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	sessions, err := sessionList(sessionIDs, *sessionsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	opts, fingerprint, err := serverOptions(*tokenFile, *tlsCert, *tlsKey, *selfSigned)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// Claim the directory before listening: taking over may free the port.
+	lock, _, err := claimClaudeDir("serve", *takeover)
+	if errors.Is(err, errQuit) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if lock != nil {
+		defer lock.Release()
+	}
+
+	var pubs []sink.Publisher
+	defer func() {
+		for _, pub := range pubs {
+			pub.Close()
+		}
+	}()
+	srv, err := server.Listen(*addr, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	srv.SetRetention(*retain)
+	pubs = append(pubs, srv)
+	if lock != nil {
+		lock.SetHTTP(srv.Addr(), srv.TLS())
+	}
+	for _, spec := range sinkSpecs {
+		pub, err := sink.Open(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		pubs = append(pubs, pub)
+	}
+	if specs := cfg.TriggerSpecs(); len(specs) > 0 {
+		triggers, err := trigger.New(specs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		pubs = append(pubs, triggers)
+	}
+
+	w, err := watcher.New(sessions, pollInterval, activeWindow, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if *skipHistory {
+		w.SetSkipHistory(true)
+	}
+	for _, session := range w.GetSessions() {
+		srv.SetProject(session.ID, session.ProjectPath)
+	}
+	w.Start()
+	defer w.Stop()
+	scheme := "http"
+	if srv.TLS() {
+		scheme = "https"
+	}
+	fmt.Fprintf(os.Stderr, "claude-esp serving on %s://%s (Ctrl+C to stop)\n", scheme, srv.Addr())
+	if fingerprint != "" {
+		fmt.Fprintf(os.Stderr, "certificate SHA-256: %s\n", fingerprint)
+	}
+	if len(opts.Tokens) == 0 && !isLoopback(*addr) {
+		fmt.Fprintf(os.Stderr, "warning: %s is reachable from other machines and -token-file is not set: anyone who can connect can read the stream\n", *addr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Pushed items join the loop below and are filtered and published like
+	// local ones; the pushing instance has already deduplicated them.
+	pushed := make(chan parser.StreamItem, sink.ClientBuffer)
+	if *acceptPush {
+		srv.AcceptPush(func(item parser.StreamItem) {
+			select {
+			case pushed <- item:
+			case <-ctx.Done():
+			}
+		})
+	}
+	// Same tool-ID dedupe as the stream view: a re-read transcript must not
+	// publish a tool call twice.
+	seen := make(map[string]bool)
+	switches := modelswitch.New()
+	forks := fork.New()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case item := <-pushed:
+			if itemFilter.Match(item) {
+				publishAll(pubs, item)
+			}
+		case item := <-w.Items:
+			if key, ok := parser.DedupKey(item); ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			for _, item := range forks.Add(item) {
+				if sw, ok := switches.Add(item); ok && itemFilter.Match(sw) {
+					publishAll(pubs, sw)
+				}
+				if itemFilter.Match(item) {
+					publishAll(pubs, item)
+				}
+			}
+		case s := <-w.NewSession:
+			srv.SetProject(s.SessionID, s.ProjectPath)
+		case err := <-w.Errors:
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		case warning := <-w.Warnings:
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		case fe := <-w.FileErrors:
+			if fe.Err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", fe.Reason())
+			}
+		}
+	}
+}
+
+// serverOptions builds serve's auth and TLS settings from its flags. It
+// returns the certificate's fingerprint for clients to pin when it is
+// self-signed.
+func serverOptions(tokenFile, certFile, keyFile string, selfSigned bool) (server.Options, string, error) {
+	var opts server.Options
+	if tokenFile != "" {
+		tokens, err := server.LoadTokens(tokenFile)
+		if err != nil {
+			return opts, "", err
+		}
+		opts.Tokens = tokens
+	}
+	switch {
+	case selfSigned && (certFile != "" || keyFile != ""):
+		return opts, "", errors.New("-tls-self-signed and -tls-cert/-tls-key are exclusive")
+	case selfSigned:
+		dir, err := config.StateDir()
+		if err != nil {
+			return opts, "", err
+		}
+		cert, err := server.SelfSignedCert(filepath.Join(dir, "tls"))
+		if err != nil {
+			return opts, "", fmt.Errorf("self-signed certificate: %w", err)
+		}
+		opts.TLS = server.TLSConfig(cert)
+		return opts, server.Fingerprint(cert), nil
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return opts, "", errors.New("-tls-cert and -tls-key go together")
+		}
+		cert, err := server.LoadCert(certFile, keyFile)
+		if err != nil {
+			return opts, "", err
+		}
+		opts.TLS = server.TLSConfig(cert)
+	}
+	return opts, "", nil
+}
+
+// isLoopback reports whether a listen address only accepts local
+// connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func publishAll(pubs []sink.Publisher, item parser.StreamItem) {
+	for _, pub := range pubs {
+		pub.Publish(item)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// runStatus implements `claude-esp status`: a one-shot heartbeat for each
+// recently modified session (state, last activity, running tool), as a
+// table or, with -json, for watchdogs.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print a JSON array instead of a table")
+	sessionID := fs.String("s", "", "Only this session (ID or prefix)")
+	windowStr := fs.String("w", "1h", "Include sessions modified within this duration")
+	stallStr := fs.String("stall", heartbeat.DefaultStallAfter.String(), "Quiet time mid-turn before a session counts as stalled")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp status [-json] [-s ID] [-w dur] [-stall dur]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	window, err := time.ParseDuration(*windowStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid window duration %q: %v\n", *windowStr, err)
+		return 1
+	}
+	stall, err := time.ParseDuration(*stallStr)
+	if err != nil || stall <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid stall duration %q\n", *stallStr)
+		return 1
+	}
+
+	var sessions []watcher.SessionInfo
+	if *sessionID != "" {
+		s, err := watcher.FindSession(*sessionID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		sessions = []watcher.SessionInfo{s}
+	} else if sessions, err = watcher.ListActiveSessions(window); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	tracker := heartbeat.NewTracker()
+	for _, s := range sessions {
+		items, err := watcher.ReadSession(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", s.ID, err)
+			continue
+		}
+		tracker.SetProject(s.ID, s.ProjectPath)
+		for _, item := range items {
+			tracker.Add(item)
+		}
+	}
+	beats := tracker.Snapshot(time.Now(), stall)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(beats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(beats) == 0 {
+		fmt.Printf("No sessions (none modified in last %s)\n", window)
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tSTATE\tIDLE\tTOOL\tPROJECT")
+	for _, hb := range beats {
+		tool := "-"
+		if t := hb.CurrentTool; t != nil {
+			tool = t.Name
+			if t.AgentName != "" && t.AgentName != "Main" {
+				tool += " (" + t.AgentName + ")"
+			}
+		}
+		idle := (time.Duration(hb.IdleSeconds) * time.Second).String()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", hb.SessionID[:min(12, len(hb.SessionID))], hb.State, idle, tool, truncatePath(hb.Project, 40))
+	}
+	w.Flush()
+	return 0
+}
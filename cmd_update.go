@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/phiat/claude-esp/internal/update"
+)
+
+// runUpdate implements `claude-esp update`: it replaces the running binary
+// with the latest GitHub release's, verified against the release's
+// checksums. With -check it only reports whether one is available.
+func runUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	checkOnly := fs.Bool("check", false, "Only report whether a newer release is available")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: claude-esp update [-check]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	client := update.NewClient()
+	ctx := context.Background()
+	rel, err := client.Latest(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: checking for updates: %v\n", err)
+		return 1
+	}
+	if !update.Newer(rel.Version(), version) {
+		fmt.Printf("claude-esp v%s is up to date (latest release: %s)\n", version, rel.Tag)
+		return 0
+	}
+	if *checkOnly {
+		fmt.Printf("claude-esp %s is available (you have v%s): %s\n", rel.Tag, version, rel.URL)
+		return 0
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot locate the running binary: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Updating claude-esp v%s → %s ...\n", version, rel.Tag)
+	if err := client.Install(ctx, rel, exe); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if os.IsPermission(err) {
+			fmt.Fprintf(os.Stderr, "%s is not writable; rerun with the rights to replace it, or use go install.\n", exe)
+		}
+		return 1
+	}
+	fmt.Printf("Installed %s to %s\n", rel.Tag, exe)
+	fmt.Println("A claude-esp already running switches to it with :restart.")
+	return 0
+}
@@ -0,0 +1,148 @@
+// Package bench replays a captured transcript through the parser and an
+// offscreen StreamView, measuring throughput and allocations, so parsing
+// and rendering regressions show up as numbers instead of "it feels
+// laggy".
+package bench
+
+import (
+	"bufio"
+	"io"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/tui"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// Options configures a replay.
+type Options struct {
+	// Width and Height are the stream pane's size, as in the TUI.
+	Width, Height int
+	// MaxLines replays only the first MaxLines lines; 0 replays them all.
+	// Every added item re-renders the stream, so large captures take a
+	// while.
+	MaxLines int
+	// Repeat replays the input this many times (at least once), to get
+	// stable numbers from a small capture.
+	Repeat int
+}
+
+// Phase is one measured stage.
+type Phase struct {
+	Elapsed    time.Duration
+	Allocs     uint64 // heap allocations
+	AllocBytes uint64 // bytes allocated
+}
+
+// Rate returns n per second over the phase.
+func (p Phase) Rate(n int) float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / p.Elapsed.Seconds()
+}
+
+// Result is what a replay measured.
+type Result struct {
+	Lines  int   // non-empty lines replayed
+	Bytes  int64 // their total size
+	Items  int   // stream items the parser produced
+	Parse  Phase // parser.ParseLine over every line
+	Render Phase // StreamView.AddItem and View for every item
+}
+
+// Run reads the transcript from r and replays it. The read itself is not
+// measured.
+func Run(r io.Reader, opts Options) (Result, error) {
+	var res Result
+	lines, err := readLines(r, opts.MaxLines)
+	if err != nil {
+		return res, err
+	}
+	repeat := max(opts.Repeat, 1)
+
+	// Parse. Subagent lines carry their agentId; that is enough to label
+	// items the way the watcher would for a filter to match.
+	items := make([]parser.StreamItem, 0, len(lines)*repeat)
+	res.Parse = measure(func() {
+		for range repeat {
+			for _, line := range lines {
+				parsed, _ := parser.ParseLine(line)
+				items = append(items, parsed...)
+			}
+		}
+	})
+	for _, line := range lines {
+		res.Bytes += int64(len(line))
+	}
+	res.Lines = len(lines) * repeat
+	res.Bytes *= int64(repeat)
+	res.Items = len(items)
+
+	// Repeats replay the same tool calls; keep them from being dropped as
+	// duplicates.
+	if perPass := len(items) / repeat; perPass > 0 {
+		for i := perPass; i < len(items); i++ {
+			if items[i].ToolID != "" {
+				items[i].ToolID += "#" + strconv.Itoa(i/perPass)
+			}
+		}
+	}
+	// Render with every session and agent enabled, one frame per item as
+	// when items trickle in live.
+	stream := tui.NewStreamView()
+	stream.SetSize(opts.Width, opts.Height)
+	stream.SetEnabledFilters(filters(items))
+	res.Render = measure(func() {
+		for _, item := range items {
+			stream.AddItem(item)
+			stream.View()
+		}
+	})
+	return res, nil
+}
+
+func readLines(r io.Reader, limit int) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, watcher.ScannerInitBufferSize), watcher.ScannerMaxBufferSize)
+	var lines []string
+	for scanner.Scan() && (limit <= 0 || len(lines) < limit) {
+		if len(scanner.Bytes()) > 0 {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// filters enables every session and agent that appears in items.
+func filters(items []parser.StreamItem) []tui.EnabledFilter {
+	seen := make(map[tui.EnabledFilter]bool)
+	var out []tui.EnabledFilter
+	for _, item := range items {
+		f := tui.EnabledFilter{SessionID: item.SessionID, AgentID: item.AgentID}
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// measure times fn and counts its allocations, starting from a collected
+// heap.
+func measure(fn func()) Phase {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	fn()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return Phase{
+		Elapsed:    elapsed,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+}
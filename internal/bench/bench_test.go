@@ -0,0 +1,29 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	transcript := strings.Join([]string{
+		`{"type":"assistant","sessionId":"s1","timestamp":"2026-01-01T00:00:00Z","message":{"content":[{"type":"thinking","thinking":"hmm"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}}`,
+		``,
+		`{"type":"user","sessionId":"s1","timestamp":"2026-01-01T00:00:01Z","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"a\nb"}]}}`,
+		`{"type":"assistant","sessionId":"s1","agentId":"a1","timestamp":"2026-01-01T00:00:02Z","message":{"content":[{"type":"text","text":"done"}]}}`,
+	}, "\n")
+
+	res, err := Run(strings.NewReader(transcript), Options{Width: 80, Height: 20, MaxLines: 3, Repeat: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lines != 9 || res.Items != 12 {
+		t.Errorf("lines = %d, items = %d; want 9, 12", res.Lines, res.Items)
+	}
+	if res.Bytes != int64(3*(len(transcript)-3)) {
+		t.Errorf("bytes = %d", res.Bytes)
+	}
+	if res.Parse.Allocs == 0 || res.Render.Allocs == 0 || res.Render.Rate(res.Items) <= 0 {
+		t.Errorf("nothing measured: %+v", res)
+	}
+}
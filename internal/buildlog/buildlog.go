@@ -0,0 +1,93 @@
+// Package buildlog reads compiler and linter errors out of a build's
+// output: where each one is (file, line, column) and what it says. It
+// knows the file:line:col form of go build/vet, gcc/clang, mypy, ruff and
+// most linters, rustc/cargo, tsc and eslint.
+package buildlog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem is one error or warning.
+type Problem struct {
+	Path     string // as the output wrote it, relative paths included
+	Line     int
+	Col      int // 0 if not reported
+	Severity string
+	Message  string
+	// Ref is the location as it appears in the output ("main.go:12:5",
+	// "src/a.ts(12,5)"), for highlighting it.
+	Ref string
+}
+
+// Severities.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// Result is what a recognizer read from a build's output.
+type Result struct {
+	Tool     string // "go build", "eslint", ...
+	Problems []Problem
+}
+
+// Errors counts the problems that are errors.
+func (r Result) Errors() int {
+	return r.count(Error)
+}
+
+// Warnings counts the problems that are warnings.
+func (r Result) Warnings() int {
+	return r.count(Warning)
+}
+
+func (r Result) count(severity string) int {
+	n := 0
+	for _, p := range r.Problems {
+		if p.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary is a short account of the counts: "2 errors, 1 warning".
+func (r Result) Summary() string {
+	var parts []string
+	if n := r.Errors(); n > 0 {
+		parts = append(parts, plural(n, "error"))
+	}
+	if n := r.Warnings(); n > 0 {
+		parts = append(parts, plural(n, "warning"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// A recognizer reads a Result from the output of a shell command, and
+// reports false if the output holds no problems in its format.
+type recognizer interface {
+	Recognize(command, output string) (Result, bool)
+}
+
+// recognizers are tried in order.
+var recognizers = []recognizer{rustc{}, tsc{}, eslint{}, fileLine{}}
+
+// Recognize returns the result of the first recognizer that reads one
+// from output.
+func Recognize(command, output string) (Result, bool) {
+	for _, r := range recognizers {
+		if res, ok := r.Recognize(command, output); ok && len(res.Problems) > 0 {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
@@ -0,0 +1,92 @@
+package buildlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecognize(t *testing.T) {
+	for _, tc := range []struct {
+		name, command, output string
+		want                  Result
+	}{
+		{
+			"go build", "cd app && go build ./... 2>&1",
+			"# example.com/app\n./main.go:12:5: undefined: foo\n./main.go:20:2: declared and not used: x\n",
+			Result{Tool: "go build", Problems: []Problem{
+				{Path: "./main.go", Line: 12, Col: 5, Severity: Error, Message: "undefined: foo", Ref: "./main.go:12:5"},
+				{Path: "./main.go", Line: 20, Col: 2, Severity: Error, Message: "declared and not used: x", Ref: "./main.go:20:2"},
+			}},
+		},
+		{
+			"gcc", "make",
+			"gcc -c a.c\na.c:3:10: fatal error: b.h: No such file or directory\na.c:7:1: warning: unused variable 'y'\na.c:7:1: note: declared here\n",
+			Result{Tool: "make", Problems: []Problem{
+				{Path: "a.c", Line: 3, Col: 10, Severity: Error, Message: "b.h: No such file or directory", Ref: "a.c:3:10"},
+				{Path: "a.c", Line: 7, Col: 1, Severity: Warning, Message: "unused variable 'y'", Ref: "a.c:7:1"},
+			}},
+		},
+		{
+			"mypy", "mypy src",
+			"src/app.py:12: error: Incompatible types in assignment  [assignment]\nFound 1 error in 1 file (checked 3 source files)\n",
+			Result{Tool: "mypy", Problems: []Problem{
+				{Path: "src/app.py", Line: 12, Severity: Error, Message: "Incompatible types in assignment  [assignment]", Ref: "src/app.py:12"},
+			}},
+		},
+		{
+			"rustc", "cargo build",
+			"\x1b[1m\x1b[31merror[E0308]\x1b[0m: mismatched types\n  --> src/main.rs:4:18\n   |\n4  |     let x: i32 = \"a\";\n\nwarning: unused variable: `y`\n --> src/lib.rs:2:9\n\nerror: could not compile `app` due to previous error\n",
+			Result{Tool: "cargo build", Problems: []Problem{
+				{Path: "src/main.rs", Line: 4, Col: 18, Severity: Error, Message: "mismatched types", Ref: "src/main.rs:4:18"},
+				{Path: "src/lib.rs", Line: 2, Col: 9, Severity: Warning, Message: "unused variable: `y`", Ref: "src/lib.rs:2:9"},
+			}},
+		},
+		{
+			"tsc", "npx tsc --noEmit",
+			"src/a.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.\nsrc/b.ts:3:1 - error TS2304: Cannot find name 'foo'.\n",
+			Result{Tool: "tsc", Problems: []Problem{
+				{Path: "src/a.ts", Line: 12, Col: 5, Severity: Error, Message: "TS2322: Type 'string' is not assignable to type 'number'.", Ref: "src/a.ts(12,5)"},
+				{Path: "src/b.ts", Line: 3, Col: 1, Severity: Error, Message: "TS2304: Cannot find name 'foo'.", Ref: "src/b.ts:3:1"},
+			}},
+		},
+		{
+			"eslint", "npx eslint .",
+			"\n/home/u/app/src/a.js\n  3:7   error    'x' is assigned a value but never used  no-unused-vars\n  9:1   warning  Unexpected console statement            no-console\n\n✖ 2 problems (1 error, 1 warning)\n",
+			Result{Tool: "eslint", Problems: []Problem{
+				{Path: "/home/u/app/src/a.js", Line: 3, Col: 7, Severity: Error, Message: "'x' is assigned a value but never used (no-unused-vars)", Ref: "3:7"},
+				{Path: "/home/u/app/src/a.js", Line: 9, Col: 1, Severity: Warning, Message: "Unexpected console statement (no-console)", Ref: "9:1"},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Recognize(tc.command, tc.output)
+			if !ok || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Recognize = %+v, %v\nwant %+v", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecognizeOtherOutput(t *testing.T) {
+	for _, tc := range []struct{ command, output string }{
+		{"ls", "go.mod\nmain.go\n"},
+		{"go test ./...", "--- FAIL: TestX (0.00s)\n    x_test.go:9: got 2, want 3\nFAIL\n"},
+		{"grep -rn foo .", "./main.go:12:5: foo()\n"},
+		{"cd app && rg -n --column foo", "main.go:12:5: foo()\n"},
+		{"cargo build", "warning: 2 warnings emitted\n"},
+	} {
+		if got, ok := Recognize(tc.command, tc.output); ok {
+			t.Errorf("%q: %q recognized as %+v", tc.command, tc.output, got)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	r := Result{Problems: []Problem{{Severity: Error}, {Severity: Error}, {Severity: Warning}}}
+	if got := r.Summary(); got != "2 errors, 1 warning" {
+		t.Errorf("Summary = %q", got)
+	}
+	if got := (Result{Problems: []Problem{{Severity: Warning}}}).Summary(); got != "1 warning" {
+		t.Errorf("Summary = %q", got)
+	}
+}
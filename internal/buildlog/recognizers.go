@@ -0,0 +1,162 @@
+package buildlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansi matches color escapes, which compilers print when forced to.
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// lines splits output into lines without color escapes or \r.
+func lines(output string) []string {
+	output = strings.ReplaceAll(ansi.ReplaceAllString(output, ""), "\r", "")
+	return strings.Split(output, "\n")
+}
+
+// tools are the commands a tool name is read from, most specific first.
+var tools = []string{
+	"go build", "go vet", "go test", "go run", "golangci-lint", "staticcheck",
+	"cargo clippy", "cargo build", "cargo check", "cargo test", "rustc",
+	"tsc", "eslint", "mypy", "ruff", "flake8", "pylint", "pyright",
+	"gcc", "g++", "clang", "make",
+}
+
+// toolName is the first known tool the command runs, or fallback.
+func toolName(command, fallback string) string {
+	best, at := fallback, len(command)
+	for _, t := range tools {
+		if i := strings.Index(command, t); i >= 0 && i < at {
+			best, at = t, i
+		}
+	}
+	return best
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// fileLine reads the "path:line:col: message" form shared by go build and
+// vet, gcc and clang, golangci-lint, ruff, flake8 and mypy. A line needs a
+// column or a severity to count, so grep -n output and test logs
+// ("x_test.go:9: got 2") aren't taken for errors, and searches aren't
+// read at all.
+type fileLine struct{}
+
+var (
+	fileLinePattern = regexp.MustCompile(`^\s*(([^\s:()"']+\.[A-Za-z0-9_+-]+):(\d+)(?::(\d+))?):\s*(?:(fatal error|error|warning|note|info|hint)\s*:\s*)?(.+)$`)
+	searchCommand   = regexp.MustCompile(`(^|[;&|(]\s*)(grep|egrep|fgrep|rg|ag|ack|git grep|cat|head|tail|less)\b`)
+)
+
+func (fileLine) Recognize(command, output string) (Result, bool) {
+	if searchCommand.MatchString(command) {
+		return Result{}, false
+	}
+	r := Result{Tool: toolName(command, "build")}
+	for _, line := range lines(output) {
+		m := fileLinePattern.FindStringSubmatch(line)
+		if m == nil || (m[4] == "" && m[5] == "") {
+			continue
+		}
+		severity := Error
+		switch m[5] {
+		case "warning":
+			severity = Warning
+		case "note", "info", "hint":
+			continue
+		}
+		r.Problems = append(r.Problems, Problem{
+			Path: m[2], Line: atoi(m[3]), Col: atoi(m[4]),
+			Severity: severity, Message: m[6], Ref: m[1],
+		})
+	}
+	return r, len(r.Problems) > 0
+}
+
+// rustc reads rustc's and cargo's "error[E0308]: message" headers and the
+// " --> src/main.rs:12:5" line under each.
+type rustc struct{}
+
+var (
+	rustHeader   = regexp.MustCompile(`^(error|warning)(?:\[\w+\])?: (.+)$`)
+	rustLocation = regexp.MustCompile(`^\s*--> (([^\s:]+):(\d+):(\d+))`)
+)
+
+func (rustc) Recognize(command, output string) (Result, bool) {
+	r := Result{Tool: toolName(command, "rustc")}
+	var header []string
+	for _, line := range lines(output) {
+		if m := rustHeader.FindStringSubmatch(line); m != nil {
+			header = m
+			continue
+		}
+		m := rustLocation.FindStringSubmatch(line)
+		if m == nil || header == nil {
+			continue
+		}
+		r.Problems = append(r.Problems, Problem{
+			Path: m[2], Line: atoi(m[3]), Col: atoi(m[4]),
+			Severity: header[1], Message: header[2], Ref: m[1],
+		})
+		header = nil
+	}
+	return r, len(r.Problems) > 0
+}
+
+// tsc reads "src/a.ts(12,5): error TS2322: message" and, with --pretty,
+// "src/a.ts:12:5 - error TS2322: message".
+type tsc struct{}
+
+var tscPattern = regexp.MustCompile(`^((\S+?)(?:\((\d+),(\d+)\): |:(\d+):(\d+) - ))(error|warning) (TS\d+: .+)$`)
+
+func (tsc) Recognize(command, output string) (Result, bool) {
+	r := Result{Tool: "tsc"}
+	for _, line := range lines(output) {
+		m := tscPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ref := strings.TrimSuffix(strings.TrimSuffix(m[1], ": "), " - ")
+		r.Problems = append(r.Problems, Problem{
+			Path: m[2], Line: atoi(m[3] + m[5]), Col: atoi(m[4] + m[6]),
+			Severity: m[7], Message: m[8], Ref: ref,
+		})
+	}
+	return r, len(r.Problems) > 0
+}
+
+// eslint reads the default "stylish" format: a file's path on a line of
+// its own, then "  12:5  error  message  rule" lines under it.
+type eslint struct{}
+
+var (
+	eslintFile    = regexp.MustCompile(`^(/\S.*|[A-Za-z]:\\\S.*|\S+\.[A-Za-z0-9]+)$`)
+	eslintProblem = regexp.MustCompile(`^\s+((\d+):(\d+))\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?$`)
+)
+
+func (eslint) Recognize(command, output string) (Result, bool) {
+	r := Result{Tool: "eslint"}
+	file := ""
+	for _, line := range lines(output) {
+		if m := eslintProblem.FindStringSubmatch(line); m != nil && file != "" {
+			msg := m[5]
+			if m[6] != "" {
+				msg += " (" + m[6] + ")"
+			}
+			r.Problems = append(r.Problems, Problem{
+				Path: file, Line: atoi(m[2]), Col: atoi(m[3]),
+				Severity: m[4], Message: msg, Ref: m[1],
+			})
+			continue
+		}
+		if eslintFile.MatchString(line) {
+			file = line
+		} else if strings.TrimSpace(line) == "" {
+			file = ""
+		}
+	}
+	return r, len(r.Problems) > 0
+}
@@ -0,0 +1,167 @@
+// Package clipboard copies text to the system clipboard from wherever
+// claude-esp runs. No one method covers every setup, so they are layered:
+// the desktop's own tool (pbcopy, clip.exe, wl-copy, xclip, xsel), then
+// tmux's buffer, then OSC 52, which the terminal handles and which reaches
+// the local clipboard over SSH.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/osc"
+)
+
+// Clipboard methods: the values of [terminal] clipboard.
+const (
+	Auto   = "auto"    // try what the environment has, in Order
+	Pbcopy = "pbcopy"  // macOS
+	Clip   = "clip"    // Windows and WSL (clip.exe)
+	WlCopy = "wl-copy" // Wayland
+	Xclip  = "xclip"   // X11
+	Xsel   = "xsel"    // X11
+	Tmux   = "tmux"    // tmux load-buffer -w: tmux's buffer and, through it, the terminal's clipboard
+	OSC52  = "osc52"   // the terminal, over SSH too; nothing tells whether it took the text
+)
+
+// Methods are the values of [terminal] clipboard.
+var Methods = []string{Auto, Pbcopy, Clip, WlCopy, Xclip, Xsel, Tmux, OSC52}
+
+// commands are the programs behind the methods that run one; the text
+// goes to their stdin.
+var commands = map[string][]string{
+	Pbcopy: {"pbcopy"},
+	Clip:   {"clip.exe"},
+	WlCopy: {"wl-copy"},
+	Xclip:  {"xclip", "-selection", "clipboard"},
+	Xsel:   {"xsel", "--clipboard", "--input"},
+	Tmux:   {"tmux", "load-buffer", "-w", "-"},
+}
+
+// commandTimeout bounds a clipboard program that doesn't return, such as
+// xclip with no X server to talk to.
+const commandTimeout = 2 * time.Second
+
+// Env is what Order looks at to pick methods; EnvFromOS reads it from the
+// process environment.
+type Env struct {
+	GOOS    string // runtime.GOOS
+	SSH     bool   // $SSH_TTY or $SSH_CONNECTION is set
+	Tmux    bool   // $TMUX is set
+	Wayland bool   // $WAYLAND_DISPLAY is set
+	X11     bool   // $DISPLAY is set
+	WSL     bool   // $WSL_DISTRO_NAME is set
+}
+
+// EnvFromOS returns the current process's environment.
+func EnvFromOS() Env {
+	return Env{
+		GOOS:    runtime.GOOS,
+		SSH:     os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "",
+		Tmux:    os.Getenv("TMUX") != "",
+		Wayland: os.Getenv("WAYLAND_DISPLAY") != "",
+		X11:     os.Getenv("DISPLAY") != "",
+		WSL:     os.Getenv("WSL_DISTRO_NAME") != "",
+	}
+}
+
+// Order returns the methods Auto tries in env, most direct first. Over
+// SSH the desktop tools would fill the remote machine's clipboard, so
+// only tmux and OSC 52 are tried; OSC 52 always comes last.
+func Order(env Env) []string {
+	var order []string
+	if !env.SSH {
+		switch {
+		case env.GOOS == "darwin":
+			order = append(order, Pbcopy)
+		case env.GOOS == "windows" || env.WSL:
+			order = append(order, Clip)
+		}
+		if env.Wayland {
+			order = append(order, WlCopy)
+		}
+		if env.X11 {
+			order = append(order, Xclip, Xsel)
+		}
+	}
+	if env.Tmux {
+		order = append(order, Tmux)
+	}
+	return append(order, OSC52)
+}
+
+// Clipboard copies text with the configured method, or the first of
+// Order that works.
+type Clipboard struct {
+	methods []string
+	auto    bool
+	term    io.Writer // where OSC 52 goes
+
+	lookPath func(string) (string, error)
+	run      func(argv []string, text string) error
+}
+
+// New returns a clipboard using method ("" is Auto) in env, writing OSC
+// 52 to term.
+func New(method string, env Env, term io.Writer) *Clipboard {
+	c := &Clipboard{term: term, lookPath: exec.LookPath, run: runCommand}
+	if method == "" || method == Auto {
+		c.methods, c.auto = Order(env), true
+	} else {
+		c.methods = []string{method}
+	}
+	return c
+}
+
+// Copy puts text on the clipboard and returns the method that did. With
+// Auto, a method whose program is missing or fails gives way to the next.
+func (c *Clipboard) Copy(text string) (string, error) {
+	var errs []error
+	for _, method := range c.methods {
+		if method == OSC52 {
+			if _, err := io.WriteString(c.term, osc.Clipboard(text)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", method, err))
+				continue
+			}
+			return method, nil
+		}
+		argv := commands[method]
+		if argv == nil {
+			errs = append(errs, fmt.Errorf("unknown clipboard method %q", method))
+			continue
+		}
+		if _, err := c.lookPath(argv[0]); err != nil {
+			if !c.auto {
+				errs = append(errs, fmt.Errorf("%s: %w", method, err))
+			}
+			continue
+		}
+		if err := c.run(argv, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", method, err))
+			continue
+		}
+		return method, nil
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no clipboard method available")
+	}
+	return "", errors.Join(errs...)
+}
+
+// runCommand runs a clipboard program with text on its stdin. Its output
+// isn't captured: xclip and wl-copy leave a child holding the selection,
+// which would keep a captured pipe open.
+func runCommand(argv []string, text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
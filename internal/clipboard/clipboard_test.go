@@ -0,0 +1,80 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Env
+		want []string
+	}{
+		{"macOS", Env{GOOS: "darwin"}, []string{Pbcopy, OSC52}},
+		{"Windows", Env{GOOS: "windows"}, []string{Clip, OSC52}},
+		{"WSL", Env{GOOS: "linux", WSL: true, X11: true}, []string{Clip, Xclip, Xsel, OSC52}},
+		{"Wayland with XWayland", Env{GOOS: "linux", Wayland: true, X11: true}, []string{WlCopy, Xclip, Xsel, OSC52}},
+		{"tmux on a desktop", Env{GOOS: "linux", X11: true, Tmux: true}, []string{Xclip, Xsel, Tmux, OSC52}},
+		{"SSH into a Mac", Env{GOOS: "darwin", SSH: true, X11: true}, []string{OSC52}},
+		{"tmux over SSH", Env{GOOS: "linux", SSH: true, Tmux: true}, []string{Tmux, OSC52}},
+		{"console", Env{GOOS: "linux"}, []string{OSC52}},
+	}
+	for _, tt := range tests {
+		if got := Order(tt.env); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: order = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fake returns a clipboard whose programs in installed exist, and fail if
+// also in broken; ran records what ran.
+func fake(method string, env Env, installed, broken []string, ran *[]string) (*Clipboard, *strings.Builder) {
+	var term strings.Builder
+	c := New(method, env, &term)
+	c.lookPath = func(name string) (string, error) {
+		if slices.Contains(installed, name) {
+			return "/usr/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	c.run = func(argv []string, text string) error {
+		*ran = append(*ran, argv[0])
+		if slices.Contains(broken, argv[0]) {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+	return c, &term
+}
+
+func TestCopy(t *testing.T) {
+	desktop := Env{GOOS: "linux", Wayland: true, X11: true, Tmux: true}
+
+	// wl-copy isn't installed and xclip can't reach a display: xsel does it.
+	var ran []string
+	c, term := fake(Auto, desktop, []string{"xclip", "xsel", "tmux"}, []string{"xclip"}, &ran)
+	if method, err := c.Copy("hi"); method != Xsel || err != nil {
+		t.Errorf("copy = %q, %v; want xsel", method, err)
+	}
+	if !slices.Equal(ran, []string{"xclip", "xsel"}) || term.Len() != 0 {
+		t.Errorf("ran %v, wrote %q", ran, term.String())
+	}
+
+	// Nothing installed: OSC 52.
+	ran = nil
+	c, term = fake(Auto, desktop, nil, nil, &ran)
+	if method, err := c.Copy("hi"); method != OSC52 || err != nil || term.String() != "\x1b]52;c;aGk=\x1b\\" {
+		t.Errorf("copy = %q, %v, wrote %q; want osc52", method, err, term.String())
+	}
+
+	// A configured method is the only one tried.
+	ran = nil
+	c, term = fake(WlCopy, desktop, []string{"xclip"}, nil, &ran)
+	if method, err := c.Copy("hi"); err == nil || !strings.Contains(err.Error(), "wl-copy") || len(ran) != 0 || term.Len() != 0 {
+		t.Errorf("copy with wl-copy missing = %q, %v; ran %v", method, err, ran)
+	}
+}
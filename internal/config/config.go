@@ -0,0 +1,707 @@
+// Package config loads claude-esp's optional TOML configuration file.
+//
+// Every setting has a zero-value default that matches claude-esp's
+// behaviour without a config file, so a missing file is not an error.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/phiat/claude-esp/internal/clipboard"
+	"github.com/phiat/claude-esp/internal/cost"
+	"github.com/phiat/claude-esp/internal/longrun"
+	"github.com/phiat/claude-esp/internal/loops"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/summarize"
+	"github.com/phiat/claude-esp/internal/trigger"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// FileName is the config file name inside ConfigDir().
+const FileName = "config.toml"
+
+// IndexFileName is the session metadata cache inside CacheDir().
+const IndexFileName = "sessions.json"
+
+// Config is the root of config.toml.
+type Config struct {
+	Budget   Budget   `toml:"budget"`
+	Notify   Notify   `toml:"notify"`
+	Loops    Loops    `toml:"loops"`
+	Watch    Watch    `toml:"watch"`
+	Update   Update   `toml:"update"`
+	Terminal Terminal `toml:"terminal"`
+	View     View     `toml:"view"`
+	// Accessibility configures the screen reader mode.
+	Accessibility Accessibility `toml:"accessibility"`
+	// Summarize configures the S key's summary of a session.
+	Summarize Summarize `toml:"summarize"`
+	// LongRunning flags tool calls that take too long to return.
+	LongRunning LongRunning `toml:"long_running"`
+	// Macros bind a key in the TUI to a recorded sequence of keys.
+	Macros []Macro `toml:"macros"`
+	// Triggers run a command when agents finish editing matching files.
+	Triggers []Trigger `toml:"triggers"`
+	// ToolFormats show tool calls the builtin formatting doesn't know, or
+	// override it, by tool name.
+	ToolFormats map[string]ToolFormat `toml:"tool_formats"`
+	// Pricing overrides or extends the builtin model pricing table, keyed by
+	// model prefix: [pricing."claude-opus-4-7"] input = 5 ...
+	Pricing map[string]cost.Override `toml:"pricing"`
+
+	// path is where the config was loaded from ("" if defaults only).
+	path string
+}
+
+// ToolFormat is a [tool_formats] entry: a Go template over the decoded
+// input, mcp__jira__create = "JIRA {{.input.project}}: {{.input.summary}}",
+// or a list of field templates tried in order, NotebookEdit =
+// ["{notebook_path} cell {cell_id}", "{notebook_path}"] (see
+// parser.ToolFormat).
+type ToolFormat struct {
+	Template string
+	Fields   []string
+}
+
+// UnmarshalTOML reads either form of a ToolFormat.
+func (f *ToolFormat) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		f.Template = v
+		return nil
+	case []any:
+		for _, t := range v {
+			s, ok := t.(string)
+			if !ok {
+				return fmt.Errorf("field template %v is not a string", t)
+			}
+			f.Fields = append(f.Fields, s)
+		}
+		return nil
+	}
+	return fmt.Errorf("want a template string or a list of field templates, not %v", v)
+}
+
+// Budget configures spend guardrails. Amounts are USD; 0 disables a scope.
+type Budget struct {
+	Session float64 `toml:"session"` // per-session budget
+	Daily   float64 `toml:"daily"`   // per local calendar day, across watched sessions
+	// Thresholds are the fractions of a budget at which a notification fires
+	// (once per scope). Defaults to DefaultBudgetThresholds when empty.
+	Thresholds []float64 `toml:"thresholds"`
+}
+
+// DefaultBudgetThresholds fire at 50%, 80% and 100% of a budget.
+var DefaultBudgetThresholds = []float64{0.5, 0.8, 1.0}
+
+// Notify configures the notification hook.
+type Notify struct {
+	// Command is run through the shell for every notification event. The
+	// event is passed as JSON on stdin and as ESP_* environment variables.
+	Command string `toml:"command"`
+	// NewSessions also notifies when auto-discovery finds a new session
+	// while running (kind "session").
+	NewSessions bool `toml:"new_sessions"`
+}
+
+// Loops configures stuck/looping agent detection. Unset values use
+// loops.DefaultThresholds.
+type Loops struct {
+	Disabled bool `toml:"disabled"`
+	// RepeatedCalls is how many identical tool calls (same tool, same input)
+	// among an agent's recent calls count as a loop.
+	RepeatedCalls int `toml:"repeated_calls"`
+	// RepeatedThinking is how many near-identical thinking blocks count as
+	// a loop.
+	RepeatedThinking int `toml:"repeated_thinking"`
+	// NoProgress is how long an agent may keep calling tools without a
+	// successful file edit, e.g. "20m".
+	NoProgress time.Duration `toml:"no_progress"`
+	// Churn is how many edits one file may get before the files panel flags
+	// it as churning, a sign the agent is flip-flopping between approaches.
+	Churn int `toml:"churn"`
+}
+
+// DefaultChurn is Loops.Churn when unset.
+const DefaultChurn = 5
+
+// LongRunning configures which tool calls count as running too long.
+// Unset values use longrun.DefaultLimits.
+type LongRunning struct {
+	Disabled bool `toml:"disabled"`
+	// After is how long any tool may run without a result, e.g. "10m".
+	After time.Duration `toml:"after"`
+	// Tools sets limits per tool name, e.g. Bash = "5m"; "0s" never flags
+	// the tool.
+	Tools map[string]time.Duration `toml:"tools"`
+}
+
+// Watch configures how sessions are watched. Durations are strings like
+// "250ms" or "10m"; unset values use the watcher defaults. Command-line
+// flags take precedence.
+type Watch struct {
+	// PollInterval is how often files are checked when fsnotify is
+	// unavailable. At least watcher.MinPollInterval.
+	PollInterval time.Duration `toml:"poll_interval"`
+	// ActiveWindow is how recently a session must have been modified to be
+	// discovered.
+	ActiveWindow time.Duration `toml:"active_window"`
+	// ActivityThreshold is how recently a session or agent must have
+	// written to show as active in the tree.
+	ActivityThreshold time.Duration `toml:"activity_threshold"`
+	// LowPower starts in low-power mode: slower polling and housekeeping,
+	// and no ticking while the terminal is unfocused.
+	LowPower bool `toml:"low_power"`
+	// IgnoreProjects keeps sessions of matching projects out of
+	// auto-discovery, e.g. ["*/scratch*", "*/tmp*"]. See
+	// watcher.ProjectFilter for the pattern syntax.
+	IgnoreProjects []string `toml:"ignore_projects"`
+	// PathMap maps project paths recorded in a container or through a
+	// mount to where they are on this machine, e.g.
+	// ["/workspace -> ~/code/foo"]. See watcher.ParsePathRule.
+	PathMap []string `toml:"path_map"`
+	// Active is how a session counts as active: "mtime" (the default) by
+	// how recently it was written, "work" by whether its last records show
+	// a turn under way. See watcher.ActiveWork.
+	Active string `toml:"active"`
+}
+
+// Update configures the background release check.
+type Update struct {
+	// Check looks for a newer release at startup, asking GitHub at most
+	// once a day, and mentions it in the help bar. Off by default.
+	Check bool `toml:"check"`
+}
+
+// Terminal configures what claude-esp shows outside its own window. Both
+// are off by default.
+type Terminal struct {
+	// Title sets the terminal (or tmux window) title to the most recently
+	// active session's project and state, e.g. "esp: claude-esp ⚙ running
+	// Bash", and restores the previous title on exit.
+	Title bool `toml:"title"`
+	// Notify also shows notification events as terminal notifications
+	// (OSC 9, or OSC 777 on urxvt, foot and VTE terminals). Inside tmux
+	// this needs "set -g allow-passthrough on".
+	Notify bool `toml:"notify"`
+	// Colors is how many colors to draw with: "auto" (the default) detects
+	// what the terminal supports and honours NO_COLOR; "truecolor", "256",
+	// "16" and "none" override it.
+	Colors string `toml:"colors"`
+	// Clipboard is how y and :goto copy: "auto" (the default) tries the
+	// desktop's tool (pbcopy, clip.exe, wl-copy, xclip, xsel), then tmux,
+	// then OSC 52, skipping the desktop tools over SSH; the name of one
+	// uses only that.
+	Clipboard string `toml:"clipboard"`
+}
+
+// ColorModes are the values of [terminal] colors and -colors.
+var ColorModes = []string{"auto", "truecolor", "256", "16", "none"}
+
+// Accessibility configures output for screen readers.
+type Accessibility struct {
+	// ScreenReader replaces the TUI with a linear stream of labelled plain
+	// lines, like -screen-reader.
+	ScreenReader bool `toml:"screen_reader"`
+	// Verbosity is how much of each item is read out: "brief", "normal"
+	// (the default) or "full".
+	Verbosity string `toml:"verbosity"`
+}
+
+// Verbosities are the values of [accessibility] verbosity and -verbosity.
+var Verbosities = []string{"brief", "normal", "full"}
+
+// View configures how the stream is drawn.
+type View struct {
+	// SessionGutter draws a bar in each session's color beside its items
+	// in the stream while more than one session is shown. Their separators
+	// take the color either way.
+	SessionGutter bool `toml:"session_gutter"`
+	// TypeGutter starts the stream with a column of item type glyphs
+	// (🧠 🔧 📤 💬 ⚠) and a line down each multi-line item.
+	TypeGutter bool `toml:"type_gutter"`
+	// Presets replace DefaultPresets, the views p cycles through and a
+	// lone digit or <N>p selects.
+	Presets []Preset `toml:"presets"`
+	// NewAgents is what happens to subagents that appear while running:
+	// "enabled" (the default) shows them at once, "muted" adds them
+	// disabled, and "ask" adds them disabled and blinking in the tree
+	// until + shows them. A preset may set its own.
+	NewAgents string `toml:"new_agents"`
+	// FocusFollows moves focus, and with it the help bar, to where it's
+	// needed: a key that only works in the other pane switches to it, and
+	// after a few idle seconds new items focus the stream and agents
+	// waiting for + the tree.
+	FocusFollows bool `toml:"focus_follows"`
+}
+
+// NewAgentPolicies are the values of new_agents.
+var NewAgentPolicies = []string{"enabled", "muted", "ask"}
+
+// Summarize configures the summarizer S runs over the selected session's
+// last Window of items. Nothing leaves the machine unless Command or
+// Model is set.
+type Summarize struct {
+	// Command is run through the shell with the transcript, as Markdown,
+	// on stdin; what it prints is the summary.
+	Command string `toml:"command"`
+	// Model is the Anthropic model to call instead, with the API key in
+	// $ANTHROPIC_API_KEY or the variable APIKeyEnv names.
+	Model     string `toml:"model"`
+	APIKeyEnv string `toml:"api_key_env"`
+	// Prompt replaces summarize.DefaultPrompt.
+	Prompt string `toml:"prompt"`
+	// Window is how much of the session to summarize, default 1h.
+	Window time.Duration `toml:"window"`
+	// MaxBytes caps the transcript sent, default 200000; the oldest part
+	// of the window is left out.
+	MaxBytes int           `toml:"max_bytes"`
+	Timeout  time.Duration `toml:"timeout"` // default 2m
+}
+
+// Preset is a named set of the stream's item toggles.
+type Preset struct {
+	Name string `toml:"name"`
+	// Show lists the kinds of item shown: thinking, tool_input,
+	// tool_output, text and hooks. The rest are hidden.
+	Show []string `toml:"show"`
+	// StderrOnly cuts tool and command output down to stderr and failed
+	// results.
+	StderrOnly bool `toml:"stderr_only"`
+	// NewAgents replaces [view] new_agents while the preset is picked.
+	NewAgents string `toml:"new_agents"`
+}
+
+// Macro is a key that replays a sequence of TUI keys, as recorded with Q.
+// Keys are named as the TUI's help names them ("A", "ctrl+d", "enter",
+// "space", "f2"); several characters that aren't a key name are typed as
+// text, e.g. into the tree filter. A macro replaces the key's own action.
+type Macro struct {
+	Key  string   `toml:"key"`
+	Keys []string `toml:"keys"`
+}
+
+// Trigger runs a command, or writes to a socket, once agents pause after
+// editing files matching its paths. See trigger.Matcher for the globs.
+type Trigger struct {
+	Name    string        `toml:"name"`
+	Paths   []string      `toml:"paths"`
+	Command string        `toml:"command"`
+	Socket  string        `toml:"socket"`
+	Settle  time.Duration `toml:"settle"` // default trigger.DefaultSettle
+}
+
+// PresetKinds are the values Preset.Show may list.
+var PresetKinds = []string{"thinking", "tool_input", "tool_output", "text", "hooks"}
+
+// DefaultPresets are thinking only, tools only, errors only and everything.
+var DefaultPresets = []Preset{
+	{Name: "thinking", Show: []string{"thinking"}},
+	{Name: "tools", Show: []string{"tool_input", "tool_output", "hooks"}},
+	{Name: "errors", Show: []string{"tool_output"}, StderrOnly: true},
+	{Name: "all", Show: PresetKinds},
+}
+
+// Validate rejects presets without a name or showing unknown kinds, and
+// unknown new_agents policies.
+func (v View) Validate() error {
+	if err := validateNewAgents(v.NewAgents); err != nil {
+		return err
+	}
+	for i, p := range v.Presets {
+		if p.Name == "" {
+			return fmt.Errorf("preset %d has no name", i+1)
+		}
+		for _, kind := range p.Show {
+			if !slices.Contains(PresetKinds, kind) {
+				return fmt.Errorf("preset %q: unknown kind %q (want %s)", p.Name, kind, strings.Join(PresetKinds, ", "))
+			}
+		}
+		if err := validateNewAgents(p.NewAgents); err != nil {
+			return fmt.Errorf("preset %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateNewAgents(policy string) error {
+	if policy != "" && !slices.Contains(NewAgentPolicies, policy) {
+		return fmt.Errorf("unknown new_agents %q (want %s)", policy, strings.Join(NewAgentPolicies, ", "))
+	}
+	return nil
+}
+
+// Validate rejects timings that can't be honoured. Zero means default.
+func (w Watch) Validate() error {
+	if w.PollInterval != 0 && w.PollInterval < watcher.MinPollInterval {
+		return fmt.Errorf("poll interval %s is below the minimum of %s", w.PollInterval, watcher.MinPollInterval)
+	}
+	if w.ActiveWindow < 0 {
+		return fmt.Errorf("active window %s must be > 0", w.ActiveWindow)
+	}
+	if w.ActivityThreshold < 0 {
+		return fmt.Errorf("activity threshold %s must be > 0", w.ActivityThreshold)
+	}
+	if _, err := watcher.NewProjectFilter(w.IgnoreProjects); err != nil {
+		return err
+	}
+	for _, rule := range w.PathMap {
+		if _, err := watcher.ParsePathRule(rule); err != nil {
+			return err
+		}
+	}
+	if w.Active != "" && !slices.Contains(watcher.ActiveStrategies, w.Active) {
+		return fmt.Errorf("unknown active strategy %q (want %s)", w.Active, strings.Join(watcher.ActiveStrategies, ", "))
+	}
+	return nil
+}
+
+// DefaultPath returns the default config file location.
+func DefaultPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Default returns a Config with every setting at its default.
+func Default() *Config {
+	return &Config{}
+}
+
+// Load reads and validates the config at path. An empty path means
+// DefaultPath(). A missing file yields Default() without error; a malformed
+// or invalid one is an error.
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", cfg.path, err)
+	}
+	return cfg, nil
+}
+
+// Read decodes the config at path without validating it, for tools that
+// want to report every problem rather than stop at the first.
+func Read(path string) (*Config, error) {
+	if path == "" {
+		p, err := DefaultPath()
+		if err != nil {
+			return Default(), nil
+		}
+		path = p
+	}
+
+	cfg := Default()
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Default(), nil
+		}
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	cfg.path = path
+	return cfg, nil
+}
+
+// Path returns the file the config was loaded from, or "" for defaults.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Validate rejects settings that can't be meaningfully applied.
+func (c *Config) Validate() error {
+	if c.Budget.Session < 0 || c.Budget.Daily < 0 {
+		return errors.New("budget amounts must be >= 0")
+	}
+	for _, t := range c.Budget.Thresholds {
+		if t <= 0 {
+			return fmt.Errorf("budget threshold %v must be > 0", t)
+		}
+	}
+	if c.Loops.RepeatedCalls < 0 || c.Loops.RepeatedThinking < 0 || c.Loops.NoProgress < 0 || c.Loops.Churn < 0 {
+		return errors.New("loops thresholds must be >= 0")
+	}
+	if c.LongRunning.After < 0 {
+		return errors.New("long_running: after must be >= 0")
+	}
+	for tool, d := range c.LongRunning.Tools {
+		if d < 0 {
+			return fmt.Errorf("long_running: %s must be >= 0", tool)
+		}
+	}
+	for tool, f := range c.ToolFormats {
+		if f.Template != "" {
+			if _, err := parser.ParseToolTemplate(tool, f.Template); err != nil {
+				return fmt.Errorf("tool_formats: %w", err)
+			}
+		}
+		for _, t := range f.Fields {
+			if err := parser.CheckFormat(t); err != nil {
+				return fmt.Errorf("tool_formats: %s: %w", tool, err)
+			}
+		}
+	}
+	if err := c.Watch.Validate(); err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	if err := c.View.Validate(); err != nil {
+		return fmt.Errorf("view: %w", err)
+	}
+	if m := c.Terminal.Colors; m != "" && !slices.Contains(ColorModes, m) {
+		return fmt.Errorf("terminal: unknown colors %q (want %s)", m, strings.Join(ColorModes, ", "))
+	}
+	if m := c.Terminal.Clipboard; m != "" && !slices.Contains(clipboard.Methods, m) {
+		return fmt.Errorf("terminal: unknown clipboard %q (want %s)", m, strings.Join(clipboard.Methods, ", "))
+	}
+	if v := c.Accessibility.Verbosity; v != "" && !slices.Contains(Verbosities, v) {
+		return fmt.Errorf("accessibility: unknown verbosity %q (want %s)", v, strings.Join(Verbosities, ", "))
+	}
+	if s := c.Summarize; s.Window < 0 || s.Timeout < 0 || s.MaxBytes < 0 {
+		return errors.New("summarize: window, timeout and max_bytes must be >= 0")
+	}
+	for _, spec := range c.TriggerSpecs() {
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+	}
+	for i, mac := range c.Macros {
+		if mac.Key == "" || len(mac.Keys) == 0 || slices.Contains(mac.Keys, "") {
+			return fmt.Errorf("macro %d needs a key and a list of keys", i+1)
+		}
+	}
+	if _, errs := c.PricingTable(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// PricingTable builds the model pricing table with this config's overrides.
+// Invalid overrides are reported and left out of the table.
+func (c *Config) PricingTable() (*cost.Table, []error) {
+	return cost.NewTable(c.Pricing)
+}
+
+// LoopThresholds returns the loop detection thresholds with defaults filled
+// in, or all zero (every heuristic off) when detection is disabled.
+func (c *Config) LoopThresholds() loops.Thresholds {
+	if c.Loops.Disabled {
+		return loops.Thresholds{}
+	}
+	th := loops.DefaultThresholds
+	if c.Loops.RepeatedCalls > 0 {
+		th.RepeatedCalls = c.Loops.RepeatedCalls
+	}
+	if c.Loops.RepeatedThinking > 0 {
+		th.RepeatedThinking = c.Loops.RepeatedThinking
+	}
+	if c.Loops.NoProgress > 0 {
+		th.NoProgress = c.Loops.NoProgress
+	}
+	return th
+}
+
+// ChurnEdits returns how many edits a file may get before it's flagged as
+// churning, or 0 (never flagged) when loop detection is disabled.
+func (c *Config) ChurnEdits() int {
+	if c.Loops.Disabled {
+		return 0
+	}
+	return cmp.Or(c.Loops.Churn, DefaultChurn)
+}
+
+// LongRunLimits returns how long each tool may run, with defaults filled
+// in, or no limits at all when the check is disabled.
+func (c *Config) LongRunLimits() longrun.Limits {
+	if c.LongRunning.Disabled {
+		return longrun.Limits{}
+	}
+	l := longrun.Limits{
+		Default: cmp.Or(c.LongRunning.After, longrun.DefaultLimits.Default),
+		Tools:   maps.Clone(longrun.DefaultLimits.Tools),
+	}
+	maps.Copy(l.Tools, c.LongRunning.Tools)
+	return l
+}
+
+// PollInterval returns the configured poll interval or the default.
+func (c *Config) PollInterval() time.Duration {
+	if c.Watch.PollInterval == 0 {
+		return watcher.DefaultPollInterval
+	}
+	return c.Watch.PollInterval
+}
+
+// ActiveWindow returns the configured active window or the default.
+func (c *Config) ActiveWindow() time.Duration {
+	if c.Watch.ActiveWindow == 0 {
+		return watcher.DefaultActiveWindow
+	}
+	return c.Watch.ActiveWindow
+}
+
+// ProjectFilter returns the ignore_projects filter, or nil if there is
+// none. Load has already validated the patterns.
+func (c *Config) ProjectFilter() *watcher.ProjectFilter {
+	f, _ := watcher.NewProjectFilter(c.Watch.IgnoreProjects)
+	return f
+}
+
+// ToolFormatTable returns the [tool_formats] given as field templates, for
+// parser.ToolFormats.
+func (c *Config) ToolFormatTable() map[string]parser.ToolFormat {
+	formats := make(map[string]parser.ToolFormat)
+	for tool, f := range c.ToolFormats {
+		if len(f.Fields) > 0 {
+			formats[tool] = f.Fields
+		}
+	}
+	return formats
+}
+
+// ToolTemplates returns the [tool_formats] given as Go templates, compiled
+// for parser.ToolTemplates. Load has already validated them.
+func (c *Config) ToolTemplates() map[string]*template.Template {
+	templates := make(map[string]*template.Template)
+	for tool, f := range c.ToolFormats {
+		if f.Template != "" {
+			templates[tool], _ = parser.ParseToolTemplate(tool, f.Template)
+		}
+	}
+	return templates
+}
+
+// PathMap returns the parsed path_map rules. Load has already validated
+// them.
+func (c *Config) PathMap() []watcher.PathRule {
+	var rules []watcher.PathRule
+	for _, s := range c.Watch.PathMap {
+		if r, err := watcher.ParsePathRule(s); err == nil {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// ActiveStrategy returns the configured active strategy or the default,
+// for watcher.Active.
+func (c *Config) ActiveStrategy() string {
+	if c.Watch.Active == "" {
+		return watcher.ActiveMtime
+	}
+	return c.Watch.Active
+}
+
+// ActivityThreshold returns the configured activity threshold or the default.
+func (c *Config) ActivityThreshold() time.Duration {
+	if c.Watch.ActivityThreshold == 0 {
+		return watcher.DefaultActivityThreshold
+	}
+	return c.Watch.ActivityThreshold
+}
+
+// Presets returns the configured view presets or the defaults.
+func (c *Config) Presets() []Preset {
+	if len(c.View.Presets) == 0 {
+		return DefaultPresets
+	}
+	return c.View.Presets
+}
+
+// TriggerSpecs returns the configured triggers.
+func (c *Config) TriggerSpecs() []trigger.Spec {
+	specs := make([]trigger.Spec, 0, len(c.Triggers))
+	for _, t := range c.Triggers {
+		specs = append(specs, trigger.Spec(t))
+	}
+	return specs
+}
+
+// Summarizer returns the configured summarizer, or nil if there is none.
+func (c *Config) Summarizer() *summarize.Summarizer {
+	s := c.Summarize
+	if s.Command == "" && s.Model == "" {
+		return nil
+	}
+	return &summarize.Summarizer{
+		Command:  s.Command,
+		Model:    s.Model,
+		APIKey:   os.Getenv(cmp.Or(s.APIKeyEnv, summarize.DefaultAPIKeyEnv)),
+		Prompt:   s.Prompt,
+		MaxBytes: s.MaxBytes,
+		Timeout:  s.Timeout,
+	}
+}
+
+// SummarizeWindow returns how much of a session S summarizes.
+func (c *Config) SummarizeWindow() time.Duration {
+	return cmp.Or(c.Summarize.Window, summarize.DefaultWindow)
+}
+
+// MacroKeys maps each macro's key to the keys it replays. A later macro
+// for the same key replaces an earlier one, which is how a re-recorded
+// macro, appended by AppendMacro, takes effect.
+func (c *Config) MacroKeys() map[string][]string {
+	keys := make(map[string][]string, len(c.Macros))
+	for _, mac := range c.Macros {
+		keys[mac.Key] = mac.Keys
+	}
+	return keys
+}
+
+// AppendMacro adds mac to the end of the config file at path, creating the
+// file if needed. The rest of the file, comments included, is left as is.
+func AppendMacro(path string, mac Macro) error {
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	var buf bytes.Buffer
+	if len(old) > 0 {
+		if !bytes.HasSuffix(old, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	}
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(struct {
+		Macros []Macro `toml:"macros"`
+	}{[]Macro{mac}}); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// BudgetThresholds returns the configured thresholds or the defaults.
+func (c *Config) BudgetThresholds() []float64 {
+	if len(c.Budget.Thresholds) == 0 {
+		return DefaultBudgetThresholds
+	}
+	return c.Budget.Thresholds
+}
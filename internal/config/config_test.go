@@ -0,0 +1,305 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/longrun"
+	"github.com/phiat/claude-esp/internal/loops"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestLoadMissingFileReturnsDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "nope.toml"))
+	if err != nil {
+		t.Fatalf("missing file should not error: %v", err)
+	}
+	if cfg.Budget.Session != 0 || cfg.Notify.Command != "" {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+	if cfg.Path() != "" {
+		t.Errorf("defaults should have empty Path, got %q", cfg.Path())
+	}
+}
+
+func TestLoadParsesBudgetAndNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[budget]
+session = 5.0
+daily = 25
+thresholds = [0.75, 1.0]
+
+[notify]
+command = "echo hi"
+new_sessions = true
+`), 0o644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Budget.Session != 5 || cfg.Budget.Daily != 25 {
+		t.Errorf("budget = %+v", cfg.Budget)
+	}
+	if got := cfg.BudgetThresholds(); len(got) != 2 || got[0] != 0.75 {
+		t.Errorf("thresholds = %v", got)
+	}
+	if cfg.Notify.Command != "echo hi" || !cfg.Notify.NewSessions {
+		t.Errorf("notify = %+v", cfg.Notify)
+	}
+	if cfg.Path() != path {
+		t.Errorf("Path() = %q, want %q", cfg.Path(), path)
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"syntax":      "[budget\nsession = 1",
+		"negative":    "[budget]\nsession = -1",
+		"threshold":   "[budget]\nthresholds = [0]",
+		"pricing":     "[pricing.\"claude-new\"]\ninput = 1",
+		"loops":       "[loops]\nrepeated_calls = -1",
+		"churn":       "[loops]\nchurn = -2",
+		"format":      "[tool_formats]\nDeploy = [\"{env\"]",
+		"template":    "[tool_formats]\nDeploy = \"{{.input.env\"",
+		"format type": "[tool_formats]\nDeploy = 3",
+		"duration":    "[loops]\nno_progress = \"soon\"",
+		"poll":        "[watch]\npoll_interval = \"10ms\"",
+		"window":      "[watch]\nactive_window = \"-1m\"",
+		"ignore":      "[watch]\nignore_projects = [\"\"]",
+		"path_map":    "[watch]\npath_map = [\"/workspace\"]",
+		"active":      "[watch]\nactive = \"busy\"",
+		"preset":      "[[view.presets]]\nname = \"x\"\nshow = [\"thoughts\"]",
+		"macro":       "[[macros]]\nkey = \"f2\"\nkeys = []",
+		"summarize":   "[summarize]\ncommand = \"cat\"\nmax_bytes = -1",
+		"trigger":     "[[triggers]]\nname = \"tests\"\npaths = [\"*.go\"]",
+		"long_run":    "[long_running.tools]\nBash = \"-1m\"",
+		"new_agents":  "[view]\nnew_agents = \"maybe\"",
+		"preset_new":  "[[view.presets]]\nname = \"x\"\nnew_agents = \"later\"",
+		"colors":      "[terminal]\ncolors = \"rainbow\"",
+		"clipboard":   "[terminal]\nclipboard = \"pigeon\"",
+		"verbosity":   "[accessibility]\nverbosity = \"chatty\"",
+	} {
+		path := filepath.Join(dir, name+".toml")
+		os.WriteFile(path, []byte(body), 0o644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDefaultThresholds(t *testing.T) {
+	if got := Default().BudgetThresholds(); len(got) != len(DefaultBudgetThresholds) {
+		t.Errorf("default thresholds = %v", got)
+	}
+}
+
+func TestLoadParsesPricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[pricing."claude-sonnet-4"]
+output = 12
+
+[pricing."claude-next"]
+input = 2
+output = 8
+cache_read = 0.1
+`), 0o644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, errs := cfg.PricingTable()
+	if len(errs) != 0 {
+		t.Fatalf("unexpected pricing errors: %v", errs)
+	}
+	if p, _ := table.PricingFor("claude-sonnet-4-5"); p.Output != 12 {
+		t.Errorf("sonnet output = %v, want 12", p.Output)
+	}
+	if p, ok := table.PricingFor("claude-next-1"); !ok || p.Input != 2 || p.CacheRead != 0.1 {
+		t.Errorf("claude-next pricing = %+v (ok=%v)", p, ok)
+	}
+}
+
+func TestLoopThresholds(t *testing.T) {
+	if got := Default().LoopThresholds(); got != loops.DefaultThresholds {
+		t.Errorf("default loop thresholds = %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[loops]\nrepeated_calls = 6\nno_progress = \"45m\"\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ChurnEdits() != DefaultChurn {
+		t.Errorf("default churn = %d", cfg.ChurnEdits())
+	}
+	got := cfg.LoopThresholds()
+	if got.RepeatedCalls != 6 || got.NoProgress != 45*time.Minute || got.RepeatedThinking != loops.DefaultThresholds.RepeatedThinking {
+		t.Errorf("loop thresholds = %+v", got)
+	}
+
+	cfg.Loops.Disabled = true
+	if got := cfg.LoopThresholds(); got != (loops.Thresholds{}) {
+		t.Errorf("disabled loop thresholds = %+v, want all zero", got)
+	}
+	if cfg.ChurnEdits() != 0 {
+		t.Errorf("disabled churn = %d, want 0", cfg.ChurnEdits())
+	}
+}
+
+func TestWatchTimings(t *testing.T) {
+	d := Default()
+	if d.PollInterval() != watcher.DefaultPollInterval || d.ActiveWindow() != watcher.DefaultActiveWindow ||
+		d.ActivityThreshold() != watcher.DefaultActivityThreshold {
+		t.Errorf("defaults = %v %v %v", d.PollInterval(), d.ActiveWindow(), d.ActivityThreshold())
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[watch]\npoll_interval = \"250ms\"\nactivity_threshold = \"1m\"\nlow_power = true\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PollInterval() != 250*time.Millisecond || cfg.ActivityThreshold() != time.Minute ||
+		cfg.ActiveWindow() != watcher.DefaultActiveWindow || !cfg.Watch.LowPower {
+		t.Errorf("configured = %v %v %v", cfg.PollInterval(), cfg.ActiveWindow(), cfg.ActivityThreshold())
+	}
+}
+
+func TestIgnoreProjects(t *testing.T) {
+	if Default().ProjectFilter() != nil {
+		t.Error("default config ignores projects")
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[watch]\nignore_projects = [\"*/scratch*\", \"*/tmp*\"]\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := cfg.ProjectFilter(); !f.Match("home/me/scratch-1") || f.Match("home/me/api") {
+		t.Error("ignore_projects not applied")
+	}
+}
+
+func TestPathMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[watch]\npath_map = [\"/workspace -> /home/me/code/foo\"]\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := cfg.PathMap(); len(rules) != 1 || rules[0].From != "/workspace" || rules[0].To != "/home/me/code/foo" {
+		t.Errorf("path_map = %+v", rules)
+	}
+}
+
+func TestToolFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`[tool_formats]
+mcp__jira__create = "JIRA {{.input.project}}: {{.input.summary}}"
+Deploy = ["{env} x{replicas}", "{env}"]
+`), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := cfg.ToolFormatTable(); len(f) != 1 || !slices.Equal(f["Deploy"], []string{"{env} x{replicas}", "{env}"}) {
+		t.Errorf("field templates = %q", f)
+	}
+	var b strings.Builder
+	tmpl := cfg.ToolTemplates()["mcp__jira__create"]
+	if tmpl == nil || tmpl.Execute(&b, map[string]any{"input": map[string]any{"project": "ESP", "summary": "Flaky test"}}) != nil ||
+		b.String() != "JIRA ESP: Flaky test" {
+		t.Errorf("Go template = %q", b.String())
+	}
+}
+
+func TestPresets(t *testing.T) {
+	if got := Default().Presets(); len(got) != 4 || got[3].Name != "all" {
+		t.Errorf("default presets = %+v", got)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[[view.presets]]\nname = \"failures\"\nshow = [\"tool_input\", \"tool_output\"]\nstderr_only = true\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Presets(); len(got) != 1 || got[0].Name != "failures" || len(got[0].Show) != 2 || !got[0].StderrOnly {
+		t.Errorf("presets = %+v", got)
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	if Default().Summarizer() != nil || Default().SummarizeWindow() != time.Hour {
+		t.Error("summarizer configured by default")
+	}
+	t.Setenv("ESP_TEST_KEY", "secret")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[summarize]\nmodel = \"claude-haiku-4-5\"\napi_key_env = \"ESP_TEST_KEY\"\nwindow = \"30m\"\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := cfg.Summarizer(); s == nil || s.Model != "claude-haiku-4-5" || s.APIKey != "secret" || cfg.SummarizeWindow() != 30*time.Minute {
+		t.Errorf("summarizer = %+v, window %s", s, cfg.SummarizeWindow())
+	}
+}
+
+func TestLongRunLimits(t *testing.T) {
+	if got := Default().LongRunLimits(); got.Default != longrun.DefaultLimits.Default || got.For("Bash") != longrun.DefaultLimits.For("Bash") {
+		t.Errorf("default long-running limits = %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("[long_running]\nafter = \"20m\"\n[long_running.tools]\nBash = \"2m\"\nWebFetch = \"0s\"\n"), 0o644)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.LongRunLimits()
+	if got.For("Read") != 20*time.Minute || got.For("Bash") != 2*time.Minute || got.For("WebFetch") != 0 || got.For("Task") != 0 {
+		t.Errorf("long-running limits = %+v", got)
+	}
+	if longrun.DefaultLimits.Tools["Bash"] != 5*time.Minute {
+		t.Error("config changed longrun.DefaultLimits")
+	}
+
+	cfg.LongRunning.Disabled = true
+	if got := cfg.LongRunLimits(); got.For("Bash") != 0 || got.For("Read") != 0 {
+		t.Errorf("disabled long-running limits = %+v, want all zero", got)
+	}
+}
+
+func TestAppendMacro(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte("# mine\n[view]\ntype_gutter = true"), 0o644)
+	if err := AppendMacro(path, Macro{Key: "f2", Keys: []string{"A", "O", "G"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendMacro(path, Macro{Key: "f2", Keys: []string{"/", "api", "enter"}}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.View.TypeGutter || len(cfg.Macros) != 2 {
+		t.Fatalf("after appending: %+v", cfg)
+	}
+	if got := cfg.MacroKeys()["f2"]; !slices.Equal(got, []string{"/", "api", "enter"}) {
+		t.Errorf("f2 = %q, want the later macro", got)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# mine\n") {
+		t.Errorf("file rewritten:\n%s", data)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// appName names claude-esp's directory under each XDG base directory.
+const appName = "claude-esp"
+
+// dirOverrides are the directories set with -config-dir, -state-dir and
+// -cache-dir; "" keeps the default.
+var dirOverrides struct {
+	config, state, cache string
+}
+
+// SetDirs overrides where claude-esp keeps its config, state and cache;
+// an empty argument keeps that one's default. Call it once, after
+// parsing flags.
+func SetDirs(configDir, stateDir, cacheDir string) {
+	dirOverrides.config, dirOverrides.state, dirOverrides.cache = configDir, stateDir, cacheDir
+}
+
+// ConfigDir is where config.toml lives: $XDG_CONFIG_HOME/claude-esp, by
+// default ~/.config/claude-esp.
+func ConfigDir() (string, error) {
+	return baseDir(dirOverrides.config, "CLAUDE_ESP_CONFIG_DIR", "XDG_CONFIG_HOME", ".config")
+}
+
+// StateDir is where claude-esp keeps what it remembers between runs: notes,
+// saved views and positions, the log, crash reports, the instance lock and
+// the serve certificate. $XDG_STATE_HOME/claude-esp, by default
+// ~/.local/state/claude-esp.
+func StateDir() (string, error) {
+	return baseDir(dirOverrides.state, "CLAUDE_ESP_STATE_DIR", "XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// CacheDir is where claude-esp keeps what it can rebuild, such as the
+// session index: $XDG_CACHE_HOME/claude-esp, by default
+// ~/.cache/claude-esp.
+func CacheDir() (string, error) {
+	return baseDir(dirOverrides.cache, "CLAUDE_ESP_CACHE_DIR", "XDG_CACHE_HOME", ".cache")
+}
+
+// baseDir picks a directory: the flag, else its own environment variable,
+// else CLAUDE_ESP_HOME (one directory for everything, the old layout),
+// else claude-esp under the XDG variable, else under home/fallback. XDG
+// variables that aren't absolute paths are ignored, as the spec says.
+func baseDir(flagValue, env, xdgEnv, fallback string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if d := os.Getenv(env); d != "" {
+		return d, nil
+	}
+	if d := os.Getenv("CLAUDE_ESP_HOME"); d != "" {
+		return d, nil
+	}
+	if d := os.Getenv(xdgEnv); filepath.IsAbs(d) {
+		return filepath.Join(d, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir: %w", err)
+	}
+	return filepath.Join(home, fallback, appName), nil
+}
+
+// LegacyDir is ~/.claude-esp, where claude-esp kept everything before it
+// followed the XDG base directories.
+func LegacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir: %w", err)
+	}
+	return filepath.Join(home, ".claude-esp"), nil
+}
+
+// Migrate moves what is in LegacyDir to the XDG directories: config.toml
+// to ConfigDir, the session index to CacheDir, everything else to
+// StateDir. Entries whose destination exists already stay where they are,
+// and the old directory is removed once empty. It returns the paths it
+// moved to; with CLAUDE_ESP_HOME set, or no old directory, it does
+// nothing.
+func Migrate() ([]string, error) {
+	if os.Getenv("CLAUDE_ESP_HOME") != "" {
+		return nil, nil
+	}
+	legacy, err := LegacyDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(legacy)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var moved []string
+	for _, entry := range entries {
+		dirFor := StateDir
+		switch entry.Name() {
+		case FileName:
+			dirFor = ConfigDir
+		case IndexFileName:
+			dirFor = CacheDir
+		}
+		dir, err := dirFor()
+		if err != nil {
+			return moved, err
+		}
+		if dir == legacy {
+			continue
+		}
+		to := filepath.Join(dir, entry.Name())
+		if _, err := os.Lstat(to); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return moved, err
+		}
+		if err := os.Rename(filepath.Join(legacy, entry.Name()), to); err != nil {
+			return moved, fmt.Errorf("moving %s: %w", filepath.Join(legacy, entry.Name()), err)
+		}
+		moved = append(moved, to)
+	}
+	// Only succeeds if everything moved.
+	os.Remove(legacy)
+	return moved, nil
+}
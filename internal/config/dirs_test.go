@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// clearDirEnv gives the test a home of its own and no directory overrides.
+func clearDirEnv(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, env := range []string{"CLAUDE_ESP_HOME", "CLAUDE_ESP_CONFIG_DIR", "CLAUDE_ESP_STATE_DIR", "CLAUDE_ESP_CACHE_DIR", "XDG_CONFIG_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME"} {
+		t.Setenv(env, "")
+	}
+	t.Cleanup(func() { SetDirs("", "", "") })
+	return home
+}
+
+func dirs(t *testing.T) [3]string {
+	t.Helper()
+	var got [3]string
+	for i, dir := range []func() (string, error){ConfigDir, StateDir, CacheDir} {
+		d, err := dir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[i] = d
+	}
+	return got
+}
+
+func TestDirs(t *testing.T) {
+	home := clearDirEnv(t)
+	want := [3]string{
+		filepath.Join(home, ".config", "claude-esp"),
+		filepath.Join(home, ".local", "state", "claude-esp"),
+		filepath.Join(home, ".cache", "claude-esp"),
+	}
+	if got := dirs(t); got != want {
+		t.Errorf("defaults = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	t.Setenv("XDG_STATE_HOME", "relative") // not absolute: ignored
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	want = [3]string{"/xdg/config/claude-esp", want[1], "/xdg/cache/claude-esp"}
+	if got := dirs(t); got != want {
+		t.Errorf("XDG = %q, want %q", got, want)
+	}
+
+	t.Setenv("CLAUDE_ESP_HOME", "/tmp/esp-home")
+	want = [3]string{"/tmp/esp-home", "/tmp/esp-home", "/tmp/esp-home"}
+	if got := dirs(t); got != want {
+		t.Errorf("CLAUDE_ESP_HOME = %q, want %q", got, want)
+	}
+
+	t.Setenv("CLAUDE_ESP_STATE_DIR", "/env/state")
+	SetDirs("/flag/config", "", "")
+	want = [3]string{"/flag/config", "/env/state", "/tmp/esp-home"}
+	if got := dirs(t); got != want {
+		t.Errorf("overrides = %q, want %q", got, want)
+	}
+	if path, _ := DefaultPath(); path != "/flag/config/config.toml" {
+		t.Errorf("DefaultPath() = %q", path)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	home := clearDirEnv(t)
+	legacy := filepath.Join(home, ".claude-esp")
+	for _, name := range []string{"config.toml", "sessions.json", "claude-esp.log", "notes/abc.json", "state/handoff.json"} {
+		path := filepath.Join(legacy, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A log already in the new place wins; the old one stays behind.
+	state := filepath.Join(home, ".local", "state", "claude-esp")
+	os.MkdirAll(state, 0o755)
+	os.WriteFile(filepath.Join(state, "claude-esp.log"), []byte("new"), 0o644)
+
+	moved, err := Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 4 {
+		t.Errorf("moved = %q, want 4 paths", moved)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(home, ".config", "claude-esp", "config.toml"):  "config.toml",
+		filepath.Join(home, ".cache", "claude-esp", "sessions.json"): "sessions.json",
+		filepath.Join(state, "notes", "abc.json"):                    "notes/abc.json",
+		filepath.Join(state, "state", "handoff.json"):                "state/handoff.json",
+		filepath.Join(state, "claude-esp.log"):                       "new",
+		filepath.Join(legacy, "claude-esp.log"):                      "claude-esp.log",
+	} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	// Once the leftover is gone, the next run removes the old directory.
+	os.Remove(filepath.Join(legacy, "claude-esp.log"))
+	if moved, err := Migrate(); err != nil || len(moved) != 0 {
+		t.Errorf("second Migrate() = %q, %v", moved, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("%s still there: %v", legacy, err)
+	}
+}
+
+func TestMigrateKeepsHome(t *testing.T) {
+	home := clearDirEnv(t)
+	legacy := filepath.Join(home, ".claude-esp")
+	os.MkdirAll(legacy, 0o755)
+	os.WriteFile(filepath.Join(legacy, "config.toml"), nil, 0o644)
+	t.Setenv("CLAUDE_ESP_HOME", legacy)
+	if moved, err := Migrate(); err != nil || len(moved) != 0 {
+		t.Errorf("Migrate() with CLAUDE_ESP_HOME = %q, %v", moved, err)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.toml")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package cost turns token usage into estimated USD spend.
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pricing is a model's price in USD per million tokens.
+type Pricing struct {
+	Input      float64
+	Output     float64
+	CacheWrite float64 // cache_creation_input_tokens
+	CacheRead  float64 // cache_read_input_tokens
+}
+
+// Usage is the token breakdown reported on an assistant message.
+type Usage struct {
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+}
+
+// Standard cache multipliers relative to the input price, used when a
+// config entry doesn't spell out its cache pricing.
+const (
+	CacheWriteMultiplier = 1.25
+	CacheReadMultiplier  = 0.10
+)
+
+// Source says where a table entry came from.
+type Source string
+
+const (
+	SourceBuiltin  Source = "builtin"
+	SourceOverride Source = "override" // builtin entry with config-supplied fields
+	SourceConfig   Source = "config"   // entry only present in config
+)
+
+// Entry is one row of the pricing table. Models match by prefix, like
+// parser.ContextWindowFor, so dated suffixes resolve to their family; the
+// longest matching prefix wins.
+type Entry struct {
+	Prefix  string
+	Pricing Pricing
+	Source  Source
+}
+
+// Override is a [pricing."<prefix>"] config table. Unset fields keep the
+// builtin value; for new prefixes, input and output are required and cache
+// prices default to the standard multipliers of input.
+type Override struct {
+	Input      *float64 `toml:"input"`
+	Output     *float64 `toml:"output"`
+	CacheWrite *float64 `toml:"cache_write"`
+	CacheRead  *float64 `toml:"cache_read"`
+}
+
+var (
+	opusPricing       = Pricing{Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50}
+	legacyOpusPricing = Pricing{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50}
+	sonnetPricing     = Pricing{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	haikuPricing      = Pricing{Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10}
+)
+
+// builtinPrices ships with claude-esp. Update when models ship.
+var builtinPrices = []Entry{
+	{"claude-opus-4-7", opusPricing, SourceBuiltin},
+	{"claude-opus-4-6", opusPricing, SourceBuiltin},
+	{"claude-opus-4-5", opusPricing, SourceBuiltin},
+	{"claude-opus-4", legacyOpusPricing, SourceBuiltin},
+	{"claude-sonnet-4", sonnetPricing, SourceBuiltin},
+	{"claude-haiku-4", haikuPricing, SourceBuiltin},
+	{"claude-3-7-sonnet", sonnetPricing, SourceBuiltin},
+	{"claude-3-5-sonnet", sonnetPricing, SourceBuiltin},
+	{"claude-3-5-haiku", Pricing{Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08}, SourceBuiltin},
+	{"claude-3-haiku", Pricing{Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03}, SourceBuiltin},
+	{"claude-3-opus", legacyOpusPricing, SourceBuiltin},
+}
+
+// Table is a model→pricing map: the builtins plus any config overrides.
+type Table struct {
+	entries []Entry
+}
+
+// DefaultTable returns a table with only the builtin prices.
+func DefaultTable() *Table {
+	entries := make([]Entry, len(builtinPrices))
+	copy(entries, builtinPrices)
+	return &Table{entries: entries}
+}
+
+// NewTable applies config overrides on top of the builtins. Invalid
+// overrides are skipped and reported; the returned table is always usable.
+func NewTable(overrides map[string]Override) (*Table, []error) {
+	t := DefaultTable()
+	var errs []error
+
+	prefixes := make([]string, 0, len(overrides))
+	for p := range overrides {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		if err := t.apply(prefix, overrides[prefix]); err != nil {
+			errs = append(errs, fmt.Errorf("pricing %q: %w", prefix, err))
+		}
+	}
+	return t, errs
+}
+
+func (t *Table) apply(prefix string, o Override) error {
+	if prefix == "" {
+		return fmt.Errorf("empty model prefix")
+	}
+	for _, v := range []*float64{o.Input, o.Output, o.CacheWrite, o.CacheRead} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("prices must be >= 0")
+		}
+	}
+
+	for i := range t.entries {
+		if t.entries[i].Prefix != prefix {
+			continue
+		}
+		p := &t.entries[i].Pricing
+		if o.Input != nil {
+			p.Input = *o.Input
+		}
+		if o.Output != nil {
+			p.Output = *o.Output
+		}
+		if o.CacheWrite != nil {
+			p.CacheWrite = *o.CacheWrite
+		}
+		if o.CacheRead != nil {
+			p.CacheRead = *o.CacheRead
+		}
+		t.entries[i].Source = SourceOverride
+		return nil
+	}
+
+	if o.Input == nil || o.Output == nil {
+		return fmt.Errorf("new models need both input and output prices")
+	}
+	p := Pricing{
+		Input:      *o.Input,
+		Output:     *o.Output,
+		CacheWrite: *o.Input * CacheWriteMultiplier,
+		CacheRead:  *o.Input * CacheReadMultiplier,
+	}
+	if o.CacheWrite != nil {
+		p.CacheWrite = *o.CacheWrite
+	}
+	if o.CacheRead != nil {
+		p.CacheRead = *o.CacheRead
+	}
+	t.entries = append(t.entries, Entry{Prefix: prefix, Pricing: p, Source: SourceConfig})
+	return nil
+}
+
+// Entries returns the table sorted by prefix.
+func (t *Table) Entries() []Entry {
+	out := make([]Entry, len(t.entries))
+	copy(out, t.entries)
+	sort.Slice(out, func(i, j int) bool { return out[i].Prefix < out[j].Prefix })
+	return out
+}
+
+// Lookup returns the entry whose prefix is the longest match for model.
+func (t *Table) Lookup(model string) (Entry, bool) {
+	var best Entry
+	found := false
+	for _, e := range t.entries {
+		if strings.HasPrefix(model, e.Prefix) && len(e.Prefix) > len(best.Prefix) {
+			best, found = e, true
+		}
+	}
+	return best, found
+}
+
+// PricingFor returns the pricing for a model. Unknown models fall back to
+// their family's current pricing by substring (opus/haiku, else sonnet) so a
+// new model still counts against budgets; ok is false for those guesses.
+func (t *Table) PricingFor(model string) (p Pricing, ok bool) {
+	if e, found := t.Lookup(model); found {
+		return e.Pricing, true
+	}
+	switch {
+	case strings.Contains(model, "opus"):
+		return opusPricing, false
+	case strings.Contains(model, "haiku"):
+		return haikuPricing, false
+	default:
+		return sonnetPricing, false
+	}
+}
+
+// Estimate prices usage for a model in one step.
+func (t *Table) Estimate(model string, u Usage) float64 {
+	p, _ := t.PricingFor(model)
+	return p.Cost(u)
+}
+
+// Cost returns the USD cost of usage billed at p.
+func (p Pricing) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationTokens)*p.CacheWrite +
+		float64(u.CacheReadTokens)*p.CacheRead) / 1_000_000
+}
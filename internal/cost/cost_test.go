@@ -0,0 +1,118 @@
+package cost
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPricingForKnownModels(t *testing.T) {
+	tests := []struct {
+		model string
+		input float64
+	}{
+		{"claude-opus-4-7", 5},
+		{"claude-opus-4-1-20250805", 15},
+		{"claude-sonnet-4-5-20250929", 3},
+		{"claude-haiku-4-5-20251001", 1},
+		{"claude-3-5-haiku-20241022", 0.80},
+	}
+	for _, tt := range tests {
+		p, ok := DefaultTable().PricingFor(tt.model)
+		if !ok {
+			t.Errorf("%s: expected known model", tt.model)
+		}
+		if p.Input != tt.input {
+			t.Errorf("%s: input price = %v, want %v", tt.model, p.Input, tt.input)
+		}
+	}
+}
+
+func TestPricingForUnknownFallsBackByFamily(t *testing.T) {
+	table := DefaultTable()
+	p, ok := table.PricingFor("claude-haiku-9")
+	if ok {
+		t.Error("unknown model should report ok=false")
+	}
+	if p != haikuPricing {
+		t.Errorf("unknown haiku should use haiku pricing, got %+v", p)
+	}
+	if p, _ := table.PricingFor(""); p != sonnetPricing {
+		t.Errorf("empty model should default to sonnet pricing, got %+v", p)
+	}
+}
+
+func TestCost(t *testing.T) {
+	p := Pricing{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	got := p.Cost(Usage{
+		InputTokens:         1_000_000,
+		OutputTokens:        100_000,
+		CacheCreationTokens: 200_000,
+		CacheReadTokens:     1_000_000,
+	})
+	want := 3 + 1.5 + 0.75 + 0.30
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+}
+
+func f(v float64) *float64 { return &v }
+
+func TestNewTableOverridesBuiltin(t *testing.T) {
+	table, errs := NewTable(map[string]Override{
+		"claude-sonnet-4": {Output: f(12)},
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	e, ok := table.Lookup("claude-sonnet-4-5-20250929")
+	if !ok || e.Source != SourceOverride {
+		t.Fatalf("Lookup = %+v, %v; want override entry", e, ok)
+	}
+	if e.Pricing.Input != 3 || e.Pricing.Output != 12 {
+		t.Errorf("override pricing = %+v, want input 3 (kept) and output 12", e.Pricing)
+	}
+	if DefaultTable().Estimate("claude-sonnet-4", Usage{OutputTokens: 1_000_000}) != 15 {
+		t.Error("overrides must not leak into the builtin table")
+	}
+}
+
+func TestNewTableAddsModel(t *testing.T) {
+	table, errs := NewTable(map[string]Override{
+		"claude-opus-5": {Input: f(10), Output: f(50)},
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	p, ok := table.PricingFor("claude-opus-5-20270101")
+	if !ok {
+		t.Fatal("configured model should be known")
+	}
+	want := Pricing{Input: 10, Output: 50, CacheWrite: 12.5, CacheRead: 1}
+	if p != want {
+		t.Errorf("pricing = %+v, want %+v", p, want)
+	}
+}
+
+func TestNewTableRejectsInvalid(t *testing.T) {
+	table, errs := NewTable(map[string]Override{
+		"claude-new":      {Input: f(1)},
+		"claude-sonnet-4": {Input: f(-1)},
+		"":                {Input: f(1), Output: f(2)},
+	})
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), errs)
+	}
+	if len(table.Entries()) != len(builtinPrices) {
+		t.Error("invalid overrides should leave the builtin table untouched")
+	}
+	if p, _ := table.PricingFor("claude-sonnet-4"); p.Input != 3 {
+		t.Errorf("rejected override was applied: %+v", p)
+	}
+}
+
+func TestLookupLongestPrefix(t *testing.T) {
+	e, ok := DefaultTable().Lookup("claude-opus-4-7-20260101")
+	if !ok || e.Prefix != "claude-opus-4-7" {
+		t.Errorf("Lookup = %q, want claude-opus-4-7", e.Prefix)
+	}
+}
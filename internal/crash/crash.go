@@ -0,0 +1,170 @@
+// Package crash turns a panic anywhere in claude-esp into a usable
+// terminal and a report on disk, instead of a garbled screen that needs
+// `reset`. Goroutines that could panic defer Recover; the report holds the
+// panic, its stack, the version and the metadata (never the content) of
+// the most recent stream items.
+package crash
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// recentItems is how many items' metadata a report lists.
+const recentItems = 50
+
+// item is the metadata of a stream item kept for the report.
+type item struct {
+	seen      time.Time
+	sessionID string
+	agentID   string
+	itemType  parser.StreamItemType
+	toolName  string
+	size      int
+}
+
+var (
+	mu      sync.Mutex
+	dir     string
+	version = "dev"
+	restore func()
+	recent  []item // ring, next slot at next % recentItems
+	next    int
+
+	// handling is held for good by the first panic, so a second one
+	// waits for the exit instead of interleaving its report.
+	handling sync.Mutex
+
+	exit = os.Exit
+)
+
+// Setup sets the directory reports are written under (in a crashes/
+// subdirectory; the system temp directory when never set) and the version
+// they are stamped with.
+func Setup(stateDir, ver string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dir, version = stateDir, ver
+}
+
+// SetRestore registers how to give the terminal back (the running TUI's
+// ReleaseTerminal); nil unregisters it.
+func SetRestore(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	restore = fn
+}
+
+// Record keeps a stream item's metadata for the report.
+func Record(it parser.StreamItem) {
+	mu.Lock()
+	defer mu.Unlock()
+	rec := item{
+		seen:      time.Now(),
+		sessionID: it.SessionID,
+		agentID:   it.AgentID,
+		itemType:  it.Type,
+		toolName:  it.ToolName,
+		size:      len(it.Content),
+	}
+	if len(recent) < recentItems {
+		recent = append(recent, rec)
+	} else {
+		recent[next%recentItems] = rec
+	}
+	next++
+}
+
+// Recover, deferred at the top of a goroutine, handles a panic in it: the
+// terminal is restored, a report written and its path printed, and the
+// process exits with status 2.
+func Recover() {
+	if r := recover(); r != nil {
+		handle(r, debug.Stack())
+	}
+}
+
+func handle(r any, stack []byte) {
+	handling.Lock()
+
+	mu.Lock()
+	fn := restore
+	mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+
+	slog.Error("panic", "err", r)
+	path, err := Write(r, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "claude-esp crashed: %v\n\n%s\n(could not save a crash report: %v)\n", r, stack, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "claude-esp crashed: %v\nCrash report saved to %s\n", r, path)
+	}
+	exit(2)
+}
+
+// Write saves a report for panic value r with its stack and returns the
+// file's path.
+func Write(r any, stack []byte) (string, error) {
+	mu.Lock()
+	base := dir
+	mu.Unlock()
+	if base == "" {
+		base = os.TempDir()
+	}
+	reports := filepath.Join(base, "crashes")
+	if err := os.MkdirAll(reports, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(reports, "crash-"+time.Now().Format("20060102-150405")+"-*.txt")
+	if err != nil {
+		return "", err
+	}
+	writeReport(f, r, stack)
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func writeReport(w io.Writer, r any, stack []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(w, "claude-esp %s crash report\n", version)
+	fmt.Fprintf(w, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "args: %s\n\n", strings.Join(os.Args[1:], " "))
+	fmt.Fprintf(w, "panic: %v\n\n%s\n", r, stack)
+
+	fmt.Fprintf(w, "\nrecent items (oldest first):\n")
+	if len(recent) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	start := 0
+	if len(recent) == recentItems {
+		start = next % recentItems
+	}
+	for i := range recent {
+		it := recent[(start+i)%len(recent)]
+		agent := it.agentID
+		if agent == "" {
+			agent = "main"
+		}
+		fmt.Fprintf(w, "  %s %s/%s %s", it.seen.Format("15:04:05.000"), it.sessionID, agent, it.itemType)
+		if it.toolName != "" {
+			fmt.Fprintf(w, " %s", it.toolName)
+		}
+		fmt.Fprintf(w, " (%d bytes)\n", it.size)
+	}
+}
@@ -0,0 +1,74 @@
+package crash
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func reset(t *testing.T) string {
+	t.Helper()
+	d := t.TempDir()
+	Setup(d, "v9.9.9")
+	recent, next, restore = nil, 0, nil
+	t.Cleanup(func() { Setup("", "dev"); exit = os.Exit })
+	return d
+}
+
+func TestWriteReport(t *testing.T) {
+	d := reset(t)
+	for i := range recentItems + 2 {
+		tool := ""
+		if i == recentItems+1 {
+			tool = "Bash"
+		}
+		Record(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: tool, Content: "secret"})
+	}
+
+	path, err := Write("boom", []byte("goroutine 1 [running]:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, d) {
+		t.Errorf("report at %s, want under %s", path, d)
+	}
+	data, _ := os.ReadFile(path)
+	report := string(data)
+	for _, want := range []string{"v9.9.9", "panic: boom", "goroutine 1 [running]:", "s1/main tool_input Bash (6 bytes)"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "secret") {
+		t.Error("report includes item content")
+	}
+	if n := strings.Count(report, "s1/main"); n != recentItems {
+		t.Errorf("report lists %d items, want %d", n, recentItems)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(report), "Bash (6 bytes)") {
+		t.Error("newest item is not last")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	d := reset(t)
+	restored := false
+	SetRestore(func() { restored = true })
+	code := -1
+	exit = func(c int) { code = c }
+
+	func() {
+		defer Recover()
+		panic("boom")
+	}()
+	handling.Unlock()
+
+	if !restored || code != 2 {
+		t.Errorf("restored = %v, exit code %d", restored, code)
+	}
+	if reports, _ := os.ReadDir(d + "/crashes"); len(reports) != 1 {
+		t.Errorf("%d reports written, want 1", len(reports))
+	}
+}
@@ -0,0 +1,93 @@
+// Package deeplink writes and reads claude-esp's deep links: the command
+// line that opens a session's stream at a given moment,
+//
+//	claude-esp -s 3f2a9c1e-5b7d-4e21-9a0c-8d6f1b2e4a73 -at 2025-06-01T14:03:00Z
+//
+// Exports and notifications carry them, so whoever reads one can jump
+// straight to the moment it's about. An item's permalink is exact:
+//
+//	claude-esp -s 3f2a9c1e-5b7d-4e21-9a0c-8d6f1b2e4a73 -goto 9c41d07e2b3a
+package deeplink
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// idLength is how many hex digits an ItemID has.
+const idLength = 12
+
+// ItemID is item's stable ID: a hash of what identifies it in its
+// transcript, the same on every run and machine. Tool calls and results
+// are identified by their call, since how a call shows depends on the
+// config; other items by agent, type, time and content.
+func ItemID(item parser.StreamItem) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", item.SessionID, item.AgentID, item.Type)
+	if _, ok := parser.DedupKey(item); ok {
+		io.WriteString(h, item.ToolID)
+	} else {
+		fmt.Fprintf(h, "%s\x00%s", item.Timestamp.UTC().Format(time.RFC3339Nano), item.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:idLength]
+}
+
+// ParseItemID reads -goto: an ItemID, with or without a leading "#".
+func ParseItemID(s string) (string, error) {
+	id := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+	if _, err := hex.DecodeString(id); err != nil || len(id) != idLength {
+		return "", fmt.Errorf("invalid item ID %q: want %d hex digits like 9c41d07e2b3a", s, idLength)
+	}
+	return id, nil
+}
+
+// Goto is the permalink to the item with id in sessionID.
+func Goto(sessionID, id string) string {
+	return fmt.Sprintf("claude-esp -s %s -goto %s", sessionID, id)
+}
+
+// Command is the deep link to sessionID at at, to the second, in UTC so
+// it means the same moment on any machine.
+func Command(sessionID string, at time.Time) string {
+	return fmt.Sprintf("claude-esp -s %s -at %s", sessionID, at.UTC().Truncate(time.Second).Format(time.RFC3339))
+}
+
+// localLayouts are the forms of -at without a time zone, read in local
+// time; a bare time of day is today.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+var clockLayouts = []string{"15:04:05", "15:04"}
+
+// ParseAt reads -at: an RFC 3339 time (2025-06-01T14:03:00Z), a local
+// date and time (2025-06-01T14:03, 2025-06-01 14:03:00), a local time of
+// day today (14:03), or a duration before now (-15m, -2h).
+func ParseAt(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		d, err := time.ParseDuration(s[1:])
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid -at %q: want a time like 2025-06-01T14:03:00 or a duration ago like -15m", s)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			y, mo, d := now.Date()
+			return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid -at %q: want a time like 2025-06-01T14:03:00 or a duration ago like -15m", s)
+}
@@ -0,0 +1,90 @@
+package deeplink
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestCommand(t *testing.T) {
+	at := time.Date(2025, 6, 1, 16, 3, 0, 500_000_000, time.FixedZone("CEST", 2*3600))
+	if got, want := Command("3f2a", at), "claude-esp -s 3f2a -at 2025-06-01T14:03:00Z"; got != want {
+		t.Errorf("Command = %q, want %q", got, want)
+	}
+}
+
+func TestParseAt(t *testing.T) {
+	zone := time.FixedZone("X", -5*3600)
+	now := time.Date(2025, 6, 1, 15, 0, 0, 0, zone)
+	for in, want := range map[string]time.Time{
+		"2025-06-01T14:03:00Z":      time.Date(2025, 6, 1, 14, 3, 0, 0, time.UTC),
+		"2025-06-01T14:03:00+02:00": time.Date(2025, 6, 1, 12, 3, 0, 0, time.UTC),
+		"2025-06-01T14:03:00":       time.Date(2025, 6, 1, 14, 3, 0, 0, zone),
+		"2025-05-31 09:30":          time.Date(2025, 5, 31, 9, 30, 0, 0, zone),
+		"14:03":                     time.Date(2025, 6, 1, 14, 3, 0, 0, zone),
+		"-15m":                      now.Add(-15 * time.Minute),
+		" -2h ":                     now.Add(-2 * time.Hour),
+	} {
+		got, err := ParseAt(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseAt(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-", "-0s", "15m", "2025-06-01T25:00"} {
+		if _, err := ParseAt(bad, now); err == nil {
+			t.Errorf("ParseAt(%q) should fail", bad)
+		}
+	}
+
+	at := time.Date(2025, 6, 1, 14, 3, 7, 0, time.UTC)
+	if got, err := ParseAt(Command("s", at)[len("claude-esp -s s -at "):], now); err != nil || !got.Equal(at) {
+		t.Errorf("a deep link's time doesn't round-trip: %v, %v", got, err)
+	}
+}
+
+func TestItemID(t *testing.T) {
+	at := time.Date(2025, 6, 1, 14, 3, 0, 0, time.UTC)
+	text := parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "done", Timestamp: at}
+	id := ItemID(text)
+	if len(id) != idLength {
+		t.Fatalf("ItemID = %q", id)
+	}
+	// The same item read again, here or elsewhere, has the same ID.
+	again := text
+	again.Timestamp = at.In(time.FixedZone("CEST", 2*3600))
+	again.Host = "laptop"
+	if ItemID(again) != id {
+		t.Error("ID changed with the time zone or host")
+	}
+	other := text
+	other.Content = "not done"
+	if ItemID(other) == id {
+		t.Error("different content, same ID")
+	}
+
+	// Tool items go by their call, whatever the input is shown as.
+	call := parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolID: "toolu_1", Content: "ls"}
+	shown := call
+	shown.Content = "ls -la"
+	result := call
+	result.Type = parser.TypeToolOutput
+	if ItemID(call) != ItemID(shown) || ItemID(call) == ItemID(result) {
+		t.Error("tool item IDs don't follow the call and type")
+	}
+
+	for in, want := range map[string]string{id: id, "#" + strings.ToUpper(id): id} {
+		if got, err := ParseItemID(in); err != nil || got != want {
+			t.Errorf("ParseItemID(%q) = %q, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"", "xyz", id + "0", id[:6]} {
+		if _, err := ParseItemID(bad); err == nil {
+			t.Errorf("ParseItemID(%q) should fail", bad)
+		}
+	}
+	if got, want := Goto("s1", id), "claude-esp -s s1 -goto "+id; got != want {
+		t.Errorf("Goto = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,398 @@
+// Package doctor checks the environment claude-esp depends on: Claude's
+// projects directory, its sessions and whether they can be read, file
+// watching limits and whether recent transcript lines still parse the way
+// claude-esp expects. Each problem comes with a fix, to answer "nothing
+// shows up" without a bug report.
+package doctor
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// Status is a check's outcome.
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string   // what was found
+	Notes  []string // extra findings, one per line
+	Fix    string   // what to do about a Warn or Fail
+}
+
+// Options tunes the checks. Zero values use the defaults.
+type Options struct {
+	// ActiveWindow is how recent a session must be to be watched by
+	// default (watcher.DefaultActiveWindow).
+	ActiveWindow time.Duration
+	// SampleSessions and SampleLines bound the parse check: the last
+	// SampleLines lines of the SampleSessions most recent sessions.
+	SampleSessions int
+	SampleLines    int
+}
+
+const (
+	defaultSampleSessions = 5
+	defaultSampleLines    = 200
+	// tailBytes is how much of a transcript's end is read for the sample.
+	tailBytes = 4 << 20
+)
+
+// inotifyProc is where Linux exposes the inotify limits.
+var inotifyProc = "/proc/sys/fs/inotify"
+
+// openTranscript opens a transcript for the permissions check.
+var openTranscript = os.Open
+
+// Run runs every check in order. Checks that depend on the projects
+// directory are skipped when it is missing.
+func Run(opts Options) []Result {
+	if opts.ActiveWindow <= 0 {
+		opts.ActiveWindow = watcher.DefaultActiveWindow
+	}
+	if opts.SampleSessions <= 0 {
+		opts.SampleSessions = defaultSampleSessions
+	}
+	if opts.SampleLines <= 0 {
+		opts.SampleLines = defaultSampleLines
+	}
+
+	dirResult, dir := checkProjectsDir()
+	results := []Result{dirResult}
+	if dirResult.Status == Fail {
+		return results
+	}
+	sessionsResult, sessions := checkSessions(opts.ActiveWindow)
+	results = append(results, sessionsResult)
+	if len(sessions) > 0 {
+		results = append(results, checkReadable(sessions))
+	}
+	results = append(results, checkWatching(dir, sessions, opts.ActiveWindow))
+	if len(sessions) > 0 {
+		results = append(results, checkParsing(sessions, opts.SampleSessions, opts.SampleLines))
+	}
+	return results
+}
+
+func checkProjectsDir() (Result, string) {
+	r := Result{Name: "Projects directory"}
+	dir, err := watcher.ProjectsDir()
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		r.Fix = "set CLAUDE_HOME to your Claude config directory"
+		return r, ""
+	}
+	r.Detail = dir
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		r.Status = Fail
+		r.Detail = dir + " does not exist"
+		r.Fix = "run Claude Code once so it creates it, or point CLAUDE_HOME at the directory holding projects/"
+	case err != nil:
+		r.Status, r.Detail = Fail, err.Error()
+		r.Fix = "make " + dir + " readable by this user"
+	case !info.IsDir():
+		r.Status = Fail
+		r.Detail = dir + " is not a directory"
+		r.Fix = "point CLAUDE_HOME at the directory holding projects/"
+	}
+	return r, dir
+}
+
+func checkSessions(activeWindow time.Duration) (Result, []watcher.SessionInfo) {
+	r := Result{Name: "Sessions"}
+	sessions, err := watcher.ListSessions(0)
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		return r, nil
+	}
+	if len(sessions) == 0 {
+		r.Status = Warn
+		r.Detail = "no session transcripts found"
+		r.Fix = "start a Claude Code session; it shows up as soon as it writes its transcript"
+		return r, nil
+	}
+	active := 0
+	for _, s := range sessions {
+		if time.Since(s.Modified) <= activeWindow {
+			active++
+		}
+	}
+	r.Detail = fmt.Sprintf("%d sessions, %d active in the last %s (newest: %s, %s ago)",
+		len(sessions), active, activeWindow, sessions[0].ID, time.Since(sessions[0].Modified).Round(time.Second))
+	if active == 0 {
+		r.Status = Warn
+		r.Fix = "claude-esp only picks up sessions active within the window: widen it with -w 1h, or watch one with -s <ID> (-l lists them)"
+	}
+	return r, sessions
+}
+
+// checkReadable opens every transcript, main and subagent: one that
+// exists but can't be read (root owned, written from a container under
+// another UID) shows up in the tree but never produces an item.
+func checkReadable(sessions []watcher.SessionInfo) Result {
+	r := Result{Name: "Permissions"}
+	var paths []string
+	for _, s := range sessions {
+		paths = append(paths, s.Path)
+		agents, _ := filepath.Glob(filepath.Join(strings.TrimSuffix(s.Path, ".jsonl"), "subagents", "*.jsonl"))
+		paths = append(paths, agents...)
+	}
+	var unreadable []string
+	foreign := false
+	for _, path := range paths {
+		f, err := openTranscript(path)
+		if err != nil {
+			reason := watcher.DescribeReadError(path, err)
+			unreadable = append(unreadable, reason)
+			foreign = foreign || strings.Contains(reason, "owned by uid")
+			continue
+		}
+		f.Close()
+	}
+	r.Detail = fmt.Sprintf("%d transcripts readable", len(paths))
+	if len(unreadable) == 0 {
+		return r
+	}
+	r.Status = Warn
+	r.Detail = fmt.Sprintf("%d of %d transcripts can't be read; their sessions never show items", len(unreadable), len(paths))
+	r.Notes = unreadable[:min(5, len(unreadable))]
+	if len(unreadable) > 5 {
+		r.Notes = append(r.Notes, fmt.Sprintf("… and %d more", len(unreadable)-5))
+	}
+	r.Fix = "make them readable by this user (chmod o+r, or sudo chown -R $USER on the projects directory)"
+	if foreign {
+		r.Fix = "run claude-esp as the user Claude Code runs as, or in a container match its UID (docker run --user $(id -u)); else sudo chown -R $USER the projects directory"
+	}
+	return r
+}
+
+func checkWatching(dir string, sessions []watcher.SessionInfo, activeWindow time.Duration) Result {
+	r := Result{Name: "File watching"}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.Status = Warn
+		r.Detail = fmt.Sprintf("fsnotify unavailable (%v); claude-esp falls back to polling", err)
+		if runtime.GOOS == "linux" {
+			r.Fix = "raise fs.inotify.max_user_instances (sysctl) or close other programs holding inotify instances"
+		}
+		return r
+	}
+	fsw.Close()
+	r.Detail = "fsnotify available"
+	if runtime.GOOS != "linux" {
+		return r
+	}
+
+	limit, err := readInt(filepath.Join(inotifyProc, "max_user_watches"))
+	if err != nil {
+		return r
+	}
+	// One watch per directory under projects/, plus the active sessions'
+	// transcripts.
+	needed := 0
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			needed++
+		}
+		return nil
+	})
+	for _, s := range sessions {
+		if time.Since(s.Modified) <= activeWindow {
+			needed++
+		}
+	}
+	r.Detail = fmt.Sprintf("fsnotify available; about %d inotify watches needed, limit %d per user", needed, limit)
+	// Editors, IDEs and file syncers share the same per-user limit.
+	if needed*2 > limit {
+		r.Status = Warn
+		r.Fix = fmt.Sprintf("raise the limit: sudo sysctl fs.inotify.max_user_watches=%d (persist it in /etc/sysctl.d/)", max(524288, needed*4))
+	}
+	return r
+}
+
+func readInt(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// sample tallies what the parse check found.
+type sample struct {
+	lines, items int
+	malformed    []string // file:line of lines that aren't JSON
+	untyped      []string // ... that have no "type"
+	badTime      []string // ... whose timestamp isn't RFC 3339
+	noMessage    []string // user/assistant lines without a message
+	hidden       map[string]int
+}
+
+func checkParsing(sessions []watcher.SessionInfo, nSessions, nLines int) Result {
+	r := Result{Name: "Parsing"}
+	s := sample{hidden: make(map[string]int)}
+
+	// Surface lines the parser would drop, labelled by type.
+	prev := parser.DebugAll
+	parser.DebugAll = true
+	defer func() { parser.DebugAll = prev }()
+
+	sessions = sessions[:min(nSessions, len(sessions))]
+	for _, session := range sessions {
+		if err := s.add(session.Path, nLines); err != nil {
+			r.Notes = append(r.Notes, fmt.Sprintf("%s: %v", session.Path, err))
+		}
+	}
+
+	r.Detail = fmt.Sprintf("last %d lines of %d recent sessions: %d items", s.lines, len(sessions), s.items)
+	for _, p := range []struct {
+		what  string
+		where []string
+	}{
+		{"not JSON", s.malformed},
+		{"no type field", s.untyped},
+		{"timestamp not RFC 3339", s.badTime},
+		{"user/assistant line without a message", s.noMessage},
+	} {
+		if len(p.where) > 0 {
+			r.Status = Warn
+			r.Notes = append(r.Notes, fmt.Sprintf("%d %s (first at %s)", len(p.where), p.what, p.where[0]))
+		}
+	}
+	if r.Status == Warn {
+		r.Fix = "the transcript format may have changed; please open an issue with claude-esp -v and the lines noted"
+	}
+	if len(s.hidden) > 0 {
+		r.Notes = append(r.Notes, "not shown (-D shows them): "+formatCounts(s.hidden, 6))
+	}
+	return r
+}
+
+// add parses the last n lines of a transcript. Its final line is skipped
+// when it has no newline yet: Claude may be writing it.
+func (s *sample) add(path string, n int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := max(0, info.Size()-tailBytes)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+	if offset > 0 {
+		reader.ReadString('\n') // partial line
+	}
+
+	type line struct {
+		no   int // 0 when the file was read from an offset
+		text string
+	}
+	var lines []line
+	no := 0
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			break // EOF: an unterminated line is still being written
+		}
+		if offset == 0 {
+			no++
+		}
+		lines = append(lines, line{no, strings.TrimSuffix(text, "\n")})
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+
+	name := filepath.Base(path)
+	for _, l := range lines {
+		if strings.TrimSpace(l.text) == "" {
+			continue
+		}
+		s.lines++
+		where := name
+		if l.no > 0 {
+			where += ":" + strconv.Itoa(l.no)
+		}
+		var raw struct {
+			Type      string          `json:"type"`
+			Timestamp string          `json:"timestamp"`
+			Message   json.RawMessage `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(l.text), &raw); err != nil {
+			s.malformed = append(s.malformed, where)
+			continue
+		}
+		switch {
+		case raw.Type == "":
+			s.untyped = append(s.untyped, where)
+		case (raw.Type == "user" || raw.Type == "assistant") && len(raw.Message) == 0:
+			s.noMessage = append(s.noMessage, where)
+		}
+		if raw.Timestamp != "" {
+			if _, err := time.Parse(time.RFC3339, raw.Timestamp); err != nil {
+				s.badTime = append(s.badTime, where)
+			}
+		}
+		items, _ := parser.ParseLine(l.text)
+		for _, item := range items {
+			if item.Type == parser.TypeDebug {
+				s.hidden[item.ToolName]++
+			} else {
+				s.items++
+			}
+		}
+	}
+	return nil
+}
+
+// formatCounts lists the n most frequent keys as "key ×count".
+func formatCounts(counts map[string]int, n int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	var parts []string
+	for _, k := range keys[:min(n, len(keys))] {
+		parts = append(parts, fmt.Sprintf("%s ×%d", k, counts[k]))
+	}
+	if len(keys) > n {
+		parts = append(parts, fmt.Sprintf("%d more", len(keys)-n))
+	}
+	return strings.Join(parts, ", ")
+}
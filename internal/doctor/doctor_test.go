@@ -0,0 +1,112 @@
+package doctor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestRunMissingProjectsDir(t *testing.T) {
+	t.Setenv("CLAUDE_HOME", t.TempDir())
+	results := Run(Options{})
+	if len(results) != 1 || results[0].Status != Fail || results[0].Fix == "" {
+		t.Fatalf("results = %+v, want a single failure with a fix", results)
+	}
+}
+
+func TestRunFindsSchemaSurprises(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAUDE_HOME", home)
+	project := filepath.Join(home, "projects", "-work-api")
+	os.MkdirAll(project, 0o755)
+	lines := []string{
+		`{"type":"assistant","sessionId":"s1","timestamp":"2026-01-01T00:00:00Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`,
+		`{"type":"file-history-snapshot","sessionId":"s1"}`,
+		`{"type":"file-history-snapshot","sessionId":"s1"}`,
+		`{"sessionId":"s1"}`,
+		`{"type":"user","sessionId":"s1","timestamp":"yesterday"}`,
+		`{"type":"assistant","message":{"content":[{"type":"te`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"still be`, // mid-write
+	}
+	os.WriteFile(filepath.Join(project, "s1.jsonl"), []byte(strings.Join(lines, "\n")), 0o644)
+
+	results := Run(Options{})
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5: %+v", len(results), results)
+	}
+	if r := results[1]; r.Status != OK || !strings.Contains(r.Detail, "1 sessions, 1 active") {
+		t.Errorf("sessions = %+v", r)
+	}
+	if r := results[2]; r.Status != OK || r.Detail != "1 transcripts readable" {
+		t.Errorf("permissions = %+v", r)
+	}
+
+	r := results[4]
+	if r.Status != Warn || r.Fix == "" {
+		t.Errorf("parsing status = %v, fix %q", r.Status, r.Fix)
+	}
+	notes := strings.Join(r.Notes, "\n")
+	for _, want := range []string{
+		"1 not JSON (first at s1.jsonl:6)",
+		"1 no type field (first at s1.jsonl:4)",
+		"1 timestamp not RFC 3339 (first at s1.jsonl:5)",
+		"1 user/assistant line without a message (first at s1.jsonl:5)",
+		"file-history-snapshot ×2",
+	} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("notes lack %q:\n%s", want, notes)
+		}
+	}
+	if !strings.Contains(r.Detail, "last 6 lines of 1 recent sessions: 1 items") {
+		t.Errorf("detail = %q", r.Detail)
+	}
+}
+
+func TestCheckWatchingLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("inotify limits are Linux-only")
+	}
+	proc := t.TempDir()
+	defer func(p string) { inotifyProc = p }(inotifyProc)
+	inotifyProc = proc
+	os.WriteFile(filepath.Join(proc, "max_user_watches"), []byte("4\n"), 0o644)
+
+	dir := t.TempDir()
+	for _, d := range []string{"a", "b", "c/subagents"} {
+		os.MkdirAll(filepath.Join(dir, d), 0o755)
+	}
+	r := checkWatching(dir, nil, 0)
+	if r.Status != Warn || !strings.Contains(r.Detail, "about 5 inotify watches needed, limit 4") {
+		t.Errorf("5 watches against a limit of 4: %+v", r)
+	}
+}
+
+func TestCheckReadable(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "s1.jsonl")
+	agent := filepath.Join(dir, "s1", "subagents", "agent-a1.jsonl")
+	os.MkdirAll(filepath.Dir(agent), 0o755)
+	os.WriteFile(main, nil, 0o644)
+	os.WriteFile(agent, nil, 0o644)
+	// Running as root reads anything, so deny the agent's transcript here.
+	defer func(open func(string) (*os.File, error)) { openTranscript = open }(openTranscript)
+	openTranscript = func(path string) (*os.File, error) {
+		if path == agent {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+		}
+		return os.Open(path)
+	}
+
+	r := checkReadable([]watcher.SessionInfo{{ID: "s1", Path: main}})
+	if r.Status != Warn || r.Fix == "" || !strings.Contains(r.Detail, "1 of 2 transcripts can't be read") {
+		t.Errorf("result = %+v", r)
+	}
+	if len(r.Notes) != 1 || !strings.HasPrefix(r.Notes[0], "permission denied: "+agent) {
+		t.Errorf("notes = %q", r.Notes)
+	}
+}
@@ -0,0 +1,238 @@
+// Package edits turns file-editing tool calls in the stream into file-edit
+// events, so editor integrations can reload or mark files an agent just
+// changed without parsing tool inputs themselves.
+package edits
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// maxPending bounds how many edit calls may await their result. Calls whose
+// result never arrives (interrupted turns) are evicted oldest first.
+const maxPending = 512
+
+// Range is an inclusive, 1-based line range in the edited file.
+type Range struct {
+	Start int `json:"start_line"`
+	End   int `json:"end_line"`
+}
+
+// Event is one completed file edit. It is the wire form on the edit feed,
+// one JSON object per line; field names are stable API.
+type Event struct {
+	Path      string    `json:"path"`
+	Tool      string    `json:"tool"`             // Edit, MultiEdit, Write or NotebookEdit
+	Ranges    []Range   `json:"ranges,omitempty"` // changed lines as of the edit; empty if unknown
+	SessionID string    `json:"session_id"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	AgentName string    `json:"agent_name,omitempty"`
+	ToolID    string    `json:"tool_id"`
+	Timestamp time.Time `json:"timestamp"` // when the edit completed
+}
+
+// MarshalLine encodes an event as a single NDJSON line, newline included.
+func (e Event) MarshalLine() ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// input is the union of the edit tools' input fields that matter here.
+type input struct {
+	FilePath     string `json:"file_path"`
+	NotebookPath string `json:"notebook_path"`
+	Content      string `json:"content"`
+	OldString    string `json:"old_string"`
+	NewString    string `json:"new_string"`
+	ReplaceAll   bool   `json:"replace_all"`
+	Edits        []struct {
+		NewString  string `json:"new_string"`
+		ReplaceAll bool   `json:"replace_all"`
+	} `json:"edits"`
+}
+
+// pending is an edit call waiting for its result.
+type pending struct {
+	tool  string
+	input input
+}
+
+// Tracker pairs edit tool calls with their results. Feed it every stream
+// item in order; it is not safe for concurrent use.
+type Tracker struct {
+	pending map[string]pending // by ToolID
+	order   []string           // ToolIDs oldest first, for eviction
+	// ReadFile reads the edited file to locate the changed lines. It
+	// defaults to os.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{pending: make(map[string]pending), ReadFile: os.ReadFile}
+}
+
+// IsEditTool reports whether a tool name is one of the file-editing tools.
+func IsEditTool(name string) bool {
+	switch name {
+	case "Edit", "MultiEdit", "Write", "NotebookEdit":
+		return true
+	}
+	return false
+}
+
+// Add records item and returns the edit it completes, if any. An edit is
+// reported when its tool result arrives without an error.
+func (t *Tracker) Add(item parser.StreamItem) (Event, bool) {
+	switch item.Type {
+	case parser.TypeToolInput:
+		if !IsEditTool(item.ToolName) || item.ToolID == "" {
+			return Event{}, false
+		}
+		var in input
+		if err := json.Unmarshal(item.ToolInput, &in); err != nil || in.path() == "" {
+			return Event{}, false
+		}
+		t.remember(item.ToolID, pending{tool: item.ToolName, input: in})
+	case parser.TypeToolOutput:
+		p, ok := t.pending[item.ToolID]
+		if !ok {
+			return Event{}, false
+		}
+		t.forget(item.ToolID)
+		if item.IsError {
+			return Event{}, false
+		}
+		return Event{
+			Path:      p.input.path(),
+			Tool:      p.tool,
+			Ranges:    t.ranges(p),
+			SessionID: item.SessionID,
+			AgentID:   item.AgentID,
+			AgentName: item.AgentName,
+			ToolID:    item.ToolID,
+			Timestamp: item.Timestamp,
+		}, true
+	}
+	return Event{}, false
+}
+
+func (t *Tracker) remember(id string, p pending) {
+	if _, ok := t.pending[id]; !ok {
+		t.order = append(t.order, id)
+	}
+	t.pending[id] = p
+	for len(t.order) > maxPending {
+		delete(t.pending, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+func (t *Tracker) forget(id string) {
+	delete(t.pending, id)
+	for i, o := range t.order {
+		if o == id {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (in input) path() string {
+	if in.FilePath != "" {
+		return in.FilePath
+	}
+	return in.NotebookPath
+}
+
+// ranges works out which lines an edit touched. Write covers the whole new
+// file. Edit and MultiEdit are located by finding the replacement text in
+// the file as it is now, which is best effort: the file may have changed
+// again since, and a replacement that also occurs earlier in the file is
+// reported at that earlier spot. Deletions and notebook cells have no line
+// range.
+func (t *Tracker) ranges(p pending) []Range {
+	switch p.tool {
+	case "Write":
+		if n := lineCount(p.input.Content); n > 0 {
+			return []Range{{Start: 1, End: n}}
+		}
+		return nil
+	case "Edit", "MultiEdit":
+	default:
+		return nil
+	}
+	data, err := t.ReadFile(p.input.FilePath)
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+	var out []Range
+	if p.tool == "Edit" {
+		out = locate(content, p.input.NewString, p.input.ReplaceAll)
+	} else {
+		for _, e := range p.input.Edits {
+			out = append(out, locate(content, e.NewString, e.ReplaceAll)...)
+		}
+	}
+	return merge(out)
+}
+
+// locate returns the line ranges of the first occurrence of s in content,
+// or of every occurrence when all is set.
+func locate(content, s string, all bool) []Range {
+	if s == "" {
+		return nil
+	}
+	var out []Range
+	span := lineCount(s)
+	offset := 0
+	for {
+		i := strings.Index(content[offset:], s)
+		if i < 0 {
+			break
+		}
+		start := strings.Count(content[:offset+i], "\n") + 1
+		out = append(out, Range{Start: start, End: start + span - 1})
+		if !all {
+			break
+		}
+		offset += i + len(s)
+	}
+	return out
+}
+
+// merge sorts ranges and joins overlapping or adjacent ones.
+func merge(rs []Range) []Range {
+	if len(rs) < 2 {
+		return rs
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	out := rs[:1]
+	for _, r := range rs[1:] {
+		last := &out[len(out)-1]
+		if r.Start <= last.End+1 {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// lineCount counts the lines s spans; a trailing newline does not start a
+// new line.
+func lineCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
@@ -0,0 +1,130 @@
+package edits
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+var ts = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func call(tool, id, input string) parser.StreamItem {
+	return parser.StreamItem{
+		Type:      parser.TypeToolInput,
+		SessionID: "s1",
+		AgentID:   "a1",
+		AgentName: "Agent-a1",
+		ToolName:  tool,
+		ToolID:    id,
+		ToolInput: json.RawMessage(input),
+		Timestamp: ts,
+	}
+}
+
+func result(id string, isError bool) parser.StreamItem {
+	return parser.StreamItem{
+		Type:      parser.TypeToolOutput,
+		SessionID: "s1",
+		AgentID:   "a1",
+		AgentName: "Agent-a1",
+		ToolID:    id,
+		IsError:   isError,
+		Timestamp: ts.Add(time.Second),
+	}
+}
+
+// fakeFile makes the tracker read content for any path.
+func fakeFile(tr *Tracker, content string) {
+	tr.ReadFile = func(string) ([]byte, error) { return []byte(content), nil }
+}
+
+func TestWriteCoversWholeFile(t *testing.T) {
+	tr := NewTracker()
+	if _, ok := tr.Add(call("Write", "t1", `{"file_path":"/p/a.go","content":"a\nb\nc\n"}`)); ok {
+		t.Fatal("the call alone must not report an edit")
+	}
+	ev, ok := tr.Add(result("t1", false))
+	if !ok {
+		t.Fatal("expected an edit on the result")
+	}
+	want := Event{
+		Path: "/p/a.go", Tool: "Write", Ranges: []Range{{1, 3}},
+		SessionID: "s1", AgentID: "a1", AgentName: "Agent-a1", ToolID: "t1",
+		Timestamp: ts.Add(time.Second),
+	}
+	if !reflect.DeepEqual(ev, want) {
+		t.Errorf("got %+v, want %+v", ev, want)
+	}
+	if _, ok := tr.Add(result("t1", false)); ok {
+		t.Error("a repeated result must not report the edit twice")
+	}
+}
+
+func TestEditLocatesReplacement(t *testing.T) {
+	tr := NewTracker()
+	fakeFile(tr, "one\ntwo\nNEW\nLINES\nfive\nNEW\n")
+	tr.Add(call("Edit", "t1", `{"file_path":"/p/a.go","old_string":"x","new_string":"NEW\nLINES"}`))
+	ev, _ := tr.Add(result("t1", false))
+	if want := []Range{{3, 4}}; !reflect.DeepEqual(ev.Ranges, want) {
+		t.Errorf("Edit ranges = %v, want %v", ev.Ranges, want)
+	}
+
+	tr.Add(call("Edit", "t2", `{"file_path":"/p/a.go","old_string":"x","new_string":"NEW","replace_all":true}`))
+	ev, _ = tr.Add(result("t2", false))
+	if want := []Range{{3, 3}, {6, 6}}; !reflect.DeepEqual(ev.Ranges, want) {
+		t.Errorf("replace_all ranges = %v, want %v", ev.Ranges, want)
+	}
+}
+
+func TestMultiEditMergesRanges(t *testing.T) {
+	tr := NewTracker()
+	fakeFile(tr, "a\nb\nc\nd\ne\n")
+	tr.Add(call("MultiEdit", "t1", `{"file_path":"/p/a.go","edits":[{"new_string":"d"},{"new_string":"a\nb"},{"new_string":"c"}]}`))
+	ev, _ := tr.Add(result("t1", false))
+	if want := []Range{{1, 4}}; !reflect.DeepEqual(ev.Ranges, want) {
+		t.Errorf("ranges = %v, want %v", ev.Ranges, want)
+	}
+}
+
+func TestNoRangeWhenUnknown(t *testing.T) {
+	tr := NewTracker()
+	tr.ReadFile = func(string) ([]byte, error) { return nil, errors.New("gone") }
+	tr.Add(call("Edit", "t1", `{"file_path":"/p/a.go","old_string":"x","new_string":"y"}`))
+	ev, ok := tr.Add(result("t1", false))
+	if !ok || ev.Ranges != nil {
+		t.Errorf("unreadable file: got %+v ok=%v, want an edit without ranges", ev, ok)
+	}
+
+	tr.Add(call("NotebookEdit", "t2", `{"notebook_path":"/p/n.ipynb","new_source":"x"}`))
+	ev, ok = tr.Add(result("t2", false))
+	if !ok || ev.Path != "/p/n.ipynb" || ev.Ranges != nil {
+		t.Errorf("notebook: got %+v ok=%v", ev, ok)
+	}
+}
+
+func TestIgnoresFailuresAndOtherTools(t *testing.T) {
+	tr := NewTracker()
+	tr.Add(call("Edit", "t1", `{"file_path":"/p/a.go","old_string":"x","new_string":"y"}`))
+	if _, ok := tr.Add(result("t1", true)); ok {
+		t.Error("a failed edit must not be reported")
+	}
+	tr.Add(call("Bash", "t2", `{"command":"rm -rf /p"}`))
+	if _, ok := tr.Add(result("t2", false)); ok {
+		t.Error("non-edit tools must not be reported")
+	}
+}
+
+func TestPendingIsBounded(t *testing.T) {
+	tr := NewTracker()
+	for i := range maxPending + 10 {
+		tr.Add(call("Write", fmt.Sprintf("t%d", i), `{"file_path":"/p/a","content":"x"}`))
+	}
+	if len(tr.pending) != maxPending || len(tr.order) != maxPending {
+		t.Errorf("pending = %d/%d, want %d", len(tr.pending), len(tr.order), maxPending)
+	}
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Verbosity is how much of each item Announce reads out.
+type Verbosity string
+
+const (
+	Brief  Verbosity = "brief"  // the label and the start of the first line
+	Normal Verbosity = "normal" // the label and up to NormalLines lines
+	Full   Verbosity = "full"   // the label and every line
+)
+
+// NormalLines is how many content lines Normal announces.
+const NormalLines = 10
+
+// briefWidth is how much of its first line Brief announces.
+const briefWidth = 120
+
+// escapes are ANSI escape sequences in tool output, which a screen reader
+// would spell out.
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// Announce renders an item for a screen reader: a label that says what it
+// is, who it's from and when, then its content, without symbols or
+// layout. session, when set, names the item's session in the label, for
+// when several are watched.
+//
+//	Tool call Bash from Main at 12:03:45:
+//	  ls -la
+func Announce(item parser.StreamItem, v Verbosity, session string) []string {
+	agent := item.AgentName
+	if agent == "" {
+		agent = "Main"
+	}
+	label := announceKind(item) + " from " + agent
+	if session != "" {
+		label += " in " + session
+	}
+	label += " at " + item.Timestamp.Local().Format("15:04:05")
+
+	content := strings.TrimRight(escapes.ReplaceAllString(item.Content, ""), "\n")
+	if strings.TrimSpace(content) == "" {
+		return []string{label}
+	}
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = strings.ReplaceAll(strings.TrimRight(l, "\r"), "\t", "  ")
+	}
+	if v == Brief {
+		var first string
+		for _, l := range lines {
+			if first = strings.TrimSpace(l); first != "" {
+				break
+			}
+		}
+		return []string{label + ": " + runewidth.Truncate(first, briefWidth, "...")}
+	}
+	more := 0
+	if v != Full && len(lines) > NormalLines {
+		more = len(lines) - NormalLines
+		lines = lines[:NormalLines]
+	}
+	out := []string{label + ":"}
+	for _, l := range lines {
+		out = append(out, "  "+l)
+	}
+	if more > 0 {
+		out = append(out, fmt.Sprintf("  (%d more lines)", more))
+	}
+	return out
+}
+
+// announceKind names an item's type in words.
+func announceKind(item parser.StreamItem) string {
+	switch item.Type {
+	case parser.TypeThinking:
+		return "Thinking"
+	case parser.TypeToolInput:
+		return "Tool call " + item.ToolName
+	case parser.TypeToolOutput:
+		tool := cmp.Or(item.ToolName, "Tool")
+		switch {
+		case item.ExitCode != 0:
+			return fmt.Sprintf("%s failed with exit code %d", tool, item.ExitCode)
+		case item.IsError:
+			return tool + " failed"
+		case parser.IsAgentReport(item):
+			return "Agent report"
+		}
+		return tool + " result"
+	case parser.TypeText:
+		return "Message"
+	case parser.TypeHookOutput:
+		if item.HookStatus != "" {
+			return "Hook " + item.HookStatus
+		}
+		return "Hook output"
+	case parser.TypeDiagnostics:
+		return "Diagnostics"
+	case parser.TypeCommand:
+		return "Command"
+	case parser.TypeAPIError:
+		return "API error"
+	case parser.TypeTurnMarker:
+		return "Turn ended"
+	case parser.TypeCompactMarker:
+		return "Conversation compacted"
+	case parser.TypePRLink:
+		return "Pull request"
+	case parser.TypeSessionTitle:
+		return "Session title"
+	case parser.TypeModelSwitch:
+		return "Model switch"
+	case parser.TypeInterrupt:
+		return "Interrupted"
+	case parser.TypeFork:
+		return "Forked"
+	case parser.TypeProgress:
+		return "Progress"
+	case parser.TypeUnknown:
+		return "Unreadable line"
+	case parser.TypeDebug:
+		return "Debug"
+	}
+	return strings.ReplaceAll(string(item.Type), "_", " ")
+}
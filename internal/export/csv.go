@@ -0,0 +1,62 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// CSVHeader names the columns CSV writes.
+var CSVHeader = []string{
+	"timestamp", "session", "agent_id", "agent", "type", "tool", "duration_ms",
+	"input_tokens", "output_tokens", "cache_read_tokens", "cache_creation_tokens",
+	"size", "is_error", "id",
+}
+
+// CSV writes one row per item, for spreadsheets and dataframes rather than
+// reading: times are UTC RFC 3339 with milliseconds, size is the content
+// length in bytes, and a failed Bash result counts as an error. Tool
+// results get their tool's name from the matching tool_use; id is the
+// item's permalink ID (see deeplink.ItemID).
+func CSV(w io.Writer, items []parser.StreamItem) error {
+	toolNames := map[string]string{}
+	for _, item := range items {
+		if item.Type == parser.TypeToolInput && item.ToolID != "" {
+			toolNames[item.ToolID] = item.ToolName
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(CSVHeader)
+	for _, item := range items {
+		tool := item.ToolName
+		if tool == "" && item.Type == parser.TypeToolOutput {
+			tool = toolNames[item.ToolID]
+		}
+		ts := ""
+		if !item.Timestamp.IsZero() {
+			ts = item.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+		}
+		cw.Write([]string{
+			ts,
+			item.SessionID,
+			item.AgentID,
+			item.AgentName,
+			string(item.Type),
+			tool,
+			strconv.FormatInt(item.DurationMs, 10),
+			strconv.FormatInt(item.InputTokens, 10),
+			strconv.FormatInt(item.OutputTokens, 10),
+			strconv.FormatInt(item.CacheReadTokens, 10),
+			strconv.FormatInt(item.CacheCreationTokens, 10),
+			strconv.Itoa(len(item.Content)),
+			strconv.FormatBool(item.IsError || item.ExitCode != 0),
+			deeplink.ItemID(item),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
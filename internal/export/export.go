@@ -0,0 +1,227 @@
+// Package export renders stream items as a Markdown transcript, optionally
+// with the user's notes, for sharing or postmortems, or as CSV rows for
+// analysis.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Options controls what goes into an export.
+type Options struct {
+	Title string       // document heading; "" derives one from the items
+	Notes *notes.Store // session and item notes to include; nil = none
+	// Links adds the deep link (see deeplink) that reopens the stream at
+	// each session's first item, and every noted item's permalink.
+	Links bool
+}
+
+// Markdown writes items as a Markdown document. Items are written in the
+// order given; each session's note (if any) is emitted before its first
+// item.
+func Markdown(w io.Writer, items []parser.StreamItem, opts Options) error {
+	var b strings.Builder
+
+	title := opts.Title
+	if title == "" {
+		title = defaultTitle(items)
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if len(items) > 0 {
+		first, last := items[0].Timestamp, items[len(items)-1].Timestamp
+		if !first.IsZero() {
+			fmt.Fprintf(&b, "_%s → %s · %d items_\n\n",
+				first.Local().Format("2006-01-02 15:04:05"), last.Local().Format("15:04:05"), len(items))
+		}
+	}
+
+	// Tool results don't carry the tool name; borrow it (and where the
+	// tool ran) from the tool_use.
+	toolNames, toolCwds := map[string]string{}, map[string]string{}
+	for _, item := range items {
+		if item.Type == parser.TypeToolInput && item.ToolID != "" {
+			toolNames[item.ToolID] = item.ToolName
+			toolCwds[item.ToolID] = item.Cwd
+		}
+	}
+
+	seenSession := map[string]bool{}
+	for _, item := range items {
+		if item.Type == parser.TypeToolOutput && item.ToolName == "" {
+			item.ToolName = toolNames[item.ToolID]
+		}
+		if !seenSession[item.SessionID] {
+			seenSession[item.SessionID] = true
+			if note := opts.Notes.Session(item.SessionID); note != "" {
+				fmt.Fprintf(&b, "> ✎ **Session %s:** %s\n\n", shortID(item.SessionID), note)
+			}
+			if opts.Links && !item.Timestamp.IsZero() {
+				fmt.Fprintf(&b, "_Open here: `%s`_\n\n", deeplink.Command(item.SessionID, item.Timestamp))
+			}
+		}
+		writeItem(&b, item)
+		if item.Type == parser.TypeToolOutput && item.ToolName == "Bash" {
+			if ran := toolCwds[item.ToolID]; ran != "" && item.Cwd != "" && item.Cwd != ran {
+				fmt.Fprintf(&b, "_cwd → `%s`_\n\n", item.Cwd)
+			}
+		}
+		if note := opts.Notes.Item(item); note != "" {
+			fmt.Fprintf(&b, "> ✎ %s\n", strings.ReplaceAll(note, "\n", "\n> "))
+			if opts.Links {
+				fmt.Fprintf(&b, ">\n> `%s`\n", deeplink.Goto(item.SessionID, deeplink.ItemID(item)))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeItem(b *strings.Builder, item parser.StreamItem) {
+	ts := ""
+	if !item.Timestamp.IsZero() {
+		ts = item.Timestamp.Local().Format("15:04:05") + " · "
+	}
+	agent := item.AgentName
+	if agent == "" {
+		agent = "Main"
+	}
+
+	switch item.Type {
+	case parser.TypeTurnMarker:
+		fmt.Fprintf(b, "---\n_%sturn ended (%s)_\n\n", ts, time.Duration(item.DurationMs)*time.Millisecond)
+		return
+	case parser.TypeCompactMarker, parser.TypePRLink:
+		fmt.Fprintf(b, "---\n_%s%s_\n\n", ts, item.Content)
+		return
+	case parser.TypeModelSwitch:
+		fmt.Fprintf(b, "---\n_%s%s: %s_\n\n", ts, agent, item.Content)
+		return
+	case parser.TypeInterrupt:
+		fmt.Fprintf(b, "---\n_%s%s: ⛔ %s_\n\n", ts, agent, item.Content)
+		return
+	case parser.TypeFork:
+		fmt.Fprintf(b, "---\n_%s%s: ⑂ %s_\n\n", ts, agent, item.Content)
+		return
+	case parser.TypeThinkingConfig, parser.TypeProgress:
+		return
+	}
+
+	fmt.Fprintf(b, "### %s%s » %s `#%s`\n\n", ts, agent, heading(item), deeplink.ItemID(item))
+	switch item.Type {
+	case parser.TypeThinking:
+		fmt.Fprintf(b, "> %s\n\n", strings.ReplaceAll(item.Content, "\n", "\n> "))
+	case parser.TypeText:
+		fmt.Fprintf(b, "%s\n\n", item.Content)
+	default:
+		if item.Content != "" {
+			fmt.Fprintf(b, "%s\n%s\n%s\n\n", fence(item.Content), item.Content, fence(item.Content))
+		}
+	}
+}
+
+func heading(item parser.StreamItem) string {
+	switch item.Type {
+	case parser.TypeThinking:
+		return "Thinking"
+	case parser.TypeToolInput:
+		if item.ToolName == "Bash" && item.Cwd != "" {
+			return fmt.Sprintf("Tool: Bash (in `%s`)", item.Cwd)
+		}
+		return "Tool: " + item.ToolName
+	case parser.TypeToolOutput:
+		label := "Result"
+		switch {
+		case parser.IsAgentReport(item):
+			label = "Agent report"
+		case item.ToolName != "":
+			label = item.ToolName + " result"
+		}
+		if item.DurationMs > 0 {
+			label += fmt.Sprintf(" (%s)", time.Duration(item.DurationMs)*time.Millisecond)
+		}
+		if item.ExitCode != 0 {
+			label += fmt.Sprintf(" ❌ exit %d", item.ExitCode)
+		}
+		return label
+	case parser.TypeText:
+		return "Response"
+	case parser.TypeHookOutput:
+		label := strings.TrimSpace("Hook " + item.ToolName + " " + item.HookStatus)
+		if item.ExitCode != 0 {
+			label += fmt.Sprintf(" ❌ exit %d", item.ExitCode)
+		}
+		return label
+	case parser.TypeDiagnostics:
+		return strings.TrimSpace("Diagnostics " + item.ToolName)
+	case parser.TypeUnknown:
+		return "Unreadable line (" + item.ToolName + ")"
+	case parser.TypeAPIError:
+		return "⛔ API " + item.ToolName
+	case parser.TypeCommand:
+		if item.ToolName == "" {
+			return "Command output"
+		}
+		return "Command " + item.ToolName
+	default:
+		return string(item.Type)
+	}
+}
+
+// fence returns a code fence longer than any backtick run in content, so
+// tool output containing ``` doesn't terminate the block early.
+func fence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func defaultTitle(items []parser.StreamItem) string {
+	sessions := map[string]bool{}
+	var id string
+	for _, item := range items {
+		if !sessions[item.SessionID] {
+			sessions[item.SessionID] = true
+			id = item.SessionID
+		}
+	}
+	if len(sessions) == 1 {
+		return "claude-esp session " + shortID(id)
+	}
+	return fmt.Sprintf("claude-esp export (%d sessions)", len(sessions))
+}
+
+func shortID(id string) string {
+	return id[:min(8, len(id))]
+}
+
+// FileName suggests a file name for an export of items taken at now:
+// claude-esp-<session>-<yyyymmdd-hhmmss>.md.
+func FileName(items []parser.StreamItem, now time.Time) string {
+	id := "multi"
+	if len(items) > 0 {
+		id = shortID(items[0].SessionID)
+		for _, item := range items {
+			if item.SessionID != items[0].SessionID {
+				id = "multi"
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("claude-esp-%s-%s.md", id, now.Format("20060102-150405"))
+}
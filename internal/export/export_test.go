@@ -0,0 +1,139 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestMarkdown(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	items := []parser.StreamItem{
+		{Type: parser.TypeThinking, SessionID: "abcdef123456", AgentName: "Main", Content: "plan\nsteps", Timestamp: t0},
+		{Type: parser.TypeToolInput, SessionID: "abcdef123456", AgentName: "Main", ToolName: "Bash", ToolID: "t1", Content: "cd web", Cwd: "/src/app", Timestamp: t0},
+		{Type: parser.TypeToolOutput, SessionID: "abcdef123456", AgentName: "Main", ToolID: "t1", Content: "```go\nx\n```", Cwd: "/src/app/web", Timestamp: t0},
+		{Type: parser.TypeToolOutput, SessionID: "abcdef123456", AgentName: "Main", ToolName: "Bash", ToolID: "t2", Content: "Exit code 1", IsError: true, ExitCode: 1, Timestamp: t0},
+	}
+	store := notes.New(t.TempDir())
+	store.SetSession("abcdef123456", "postmortem")
+	store.SetItem(items[1], "wrong directory")
+
+	var b strings.Builder
+	if err := Markdown(&b, items, Options{Notes: store}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# claude-esp session abcdef12",
+		"> ✎ **Session abcdef12:** postmortem",
+		"> plan\n> steps",
+		"Main » Tool: Bash (in `/src/app`)",
+		"> ✎ wrong directory",
+		"Main » Bash result",
+		"````\n```go\nx\n```\n````",
+		"_cwd → `/src/app/web`_",
+		"Main » Bash result ❌ exit 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "-at ") {
+		t.Error("deep links without Options.Links")
+	}
+
+	b.Reset()
+	Markdown(&b, items, Options{Notes: store, Links: true})
+	out = b.String()
+	link := "claude-esp -s abcdef123456 -at 2025-01-01T12:00:00Z"
+	permalink := "claude-esp -s abcdef123456 -goto " + deeplink.ItemID(items[1])
+	for _, want := range []string{"_Open here: `" + link + "`_", "> ✎ wrong directory\n>\n> `" + permalink + "`\n\n", "Main » Tool: Bash (in `/src/app`) `#" + deeplink.ItemID(items[1]) + "`\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	one := []parser.StreamItem{{SessionID: "abcdef123456"}, {SessionID: "abcdef123456"}}
+	if got := FileName(one, now); got != "claude-esp-abcdef12-20250102-030405.md" {
+		t.Errorf("FileName = %q", got)
+	}
+	two := append(one, parser.StreamItem{SessionID: "zzz"})
+	if got := FileName(two, now); got != "claude-esp-multi-20250102-030405.md" {
+		t.Errorf("FileName = %q", got)
+	}
+}
+
+func TestPlainLines(t *testing.T) {
+	item := parser.StreamItem{
+		Type:      parser.TypeToolInput,
+		SessionID: "abcdef123456",
+		AgentName: "Explore",
+		ToolName:  "Bash",
+		Content:   "ls\npwd\n",
+		Timestamp: time.Date(2025, 1, 1, 12, 0, 1, 0, time.Local),
+	}
+	got := PlainLines(item)
+	want := []string{
+		"12:00:01 abcdef12 Explore tool_input Bash: ls",
+		"12:00:01 abcdef12 Explore tool_input Bash: pwd",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PlainLines = %q, want %q", got, want)
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 3, 45, 0, time.Local)
+	thinking := parser.StreamItem{Type: parser.TypeThinking, Content: "\x1b[1mplan\x1b[0m\n\tsteps\n", Timestamp: t0}
+	if got, want := Announce(thinking, Normal, ""), []string{"Thinking from Main at 12:03:45:", "  plan", "    steps"}; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Announce(thinking) = %q, want %q", got, want)
+	}
+
+	failed := parser.StreamItem{Type: parser.TypeToolOutput, AgentName: "Explore", ToolName: "Bash", IsError: true, ExitCode: 2, Content: "\nno such file\nmore", Timestamp: t0}
+	if got, want := Announce(failed, Brief, "api"), []string{"Bash failed with exit code 2 from Explore in api at 12:03:45: no such file"}; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Announce(failed, Brief) = %q, want %q", got, want)
+	}
+
+	long := parser.StreamItem{Type: parser.TypeText, Content: strings.Repeat("line\n", NormalLines+3), Timestamp: t0}
+	if got := Announce(long, Normal, ""); len(got) != NormalLines+2 || got[len(got)-1] != "  (3 more lines)" {
+		t.Errorf("Announce(long, Normal) = %q", got)
+	}
+	if got := Announce(long, Full, ""); len(got) != NormalLines+4 {
+		t.Errorf("Announce(long, Full) has %d lines, want %d", len(got), NormalLines+4)
+	}
+
+	marker := parser.StreamItem{Type: parser.TypeCompactMarker, Timestamp: t0}
+	if got := Announce(marker, Full, ""); len(got) != 1 || got[0] != "Conversation compacted from Main at 12:03:45" {
+		t.Errorf("Announce(marker) = %q", got)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 500e6, time.UTC)
+	items := []parser.StreamItem{
+		{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "hi, \"there\"", OutputTokens: 12, InputTokens: 3, Timestamp: t0},
+		{Type: parser.TypeToolInput, SessionID: "s1", AgentID: "a1", AgentName: "Explore", ToolName: "Bash", ToolID: "t1", Content: "false", Timestamp: t0},
+		{Type: parser.TypeToolOutput, SessionID: "s1", AgentID: "a1", AgentName: "Explore", ToolID: "t1", ExitCode: 1, DurationMs: 40, Timestamp: t0},
+	}
+	var b strings.Builder
+	if err := CSV(&b, items); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"timestamp,session,agent_id,agent,type,tool,duration_ms,input_tokens,output_tokens,cache_read_tokens,cache_creation_tokens,size,is_error,id",
+		"2025-01-01T12:00:00.500Z,s1,,Main,text,,0,3,12,0,0,11,false," + deeplink.ItemID(items[0]),
+		"2025-01-01T12:00:00.500Z,s1,a1,Explore,tool_input,Bash,0,0,0,0,0,5,false," + deeplink.ItemID(items[1]),
+		"2025-01-01T12:00:00.500Z,s1,a1,Explore,tool_output,Bash,40,0,0,0,0,0,true," + deeplink.ItemID(items[2]),
+		"",
+	}, "\n")
+	if b.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", b.String(), want)
+	}
+}
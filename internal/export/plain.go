@@ -0,0 +1,36 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// PlainLines renders an item as grep-friendly plain text: one line per
+// content line, each prefixed with time, session, agent and kind so every
+// line stands on its own.
+//
+//	12:00:01 0b773376 Main tool_input Bash: ls -la
+func PlainLines(item parser.StreamItem) []string {
+	kind := string(item.Type)
+	if item.ToolName != "" {
+		kind += " " + item.ToolName + ":"
+	}
+	agent := item.AgentName
+	if agent == "" {
+		agent = "Main"
+	}
+	prefix := fmt.Sprintf("%s %s %s %s",
+		item.Timestamp.Local().Format("15:04:05"), shortID(item.SessionID), agent, kind)
+
+	content := strings.TrimRight(item.Content, "\n")
+	if content == "" {
+		return []string{prefix}
+	}
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		lines[i] = prefix + " " + strings.TrimRight(l, "\r")
+	}
+	return lines
+}
@@ -0,0 +1,367 @@
+// Package filter parses and evaluates -filter expressions, which select
+// stream items by their fields:
+//
+//	type in (tool_input, tool_output) and tool != Read and agent != main
+//	error = true or content ~ "panic:"
+//	not (session = 3f2a and type = thinking)
+//
+// A comparison is a field, an operator and a value. Operators are = and
+// != (equal, ignoring case), ~ and !~ (contains, ignoring case), and
+// [not] in (v1, v2, ...). Session IDs compare by prefix, so a short ID
+// is enough. Values are bare words or "quoted strings". Comparisons
+// combine with not, and, or (binding in that order) and parentheses.
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Fields lists the fields an expression can compare, for help text.
+var Fields = []string{"type", "tool", "agent", "agent_id", "session", "model", "cwd", "host", "content", "error"}
+
+// fields reads each field of an item.
+var fields = map[string]func(*parser.StreamItem) string{
+	"type":     func(it *parser.StreamItem) string { return string(it.Type) },
+	"tool":     func(it *parser.StreamItem) string { return it.ToolName },
+	"agent":    func(it *parser.StreamItem) string { return it.AgentName },
+	"agent_id": func(it *parser.StreamItem) string { return it.AgentID },
+	"session":  func(it *parser.StreamItem) string { return it.SessionID },
+	"model":    func(it *parser.StreamItem) string { return it.Model },
+	"cwd":      func(it *parser.StreamItem) string { return it.Cwd },
+	"host":     func(it *parser.StreamItem) string { return it.Host },
+	"content":  func(it *parser.StreamItem) string { return it.Content },
+	"error": func(it *parser.StreamItem) string {
+		if it.IsError || it.ExitCode != 0 {
+			return "true"
+		}
+		return "false"
+	},
+}
+
+// types are the values a type comparison may use.
+var types = []parser.StreamItemType{
+	parser.TypeThinking, parser.TypeToolInput, parser.TypeToolOutput, parser.TypeText,
+	parser.TypeTurnMarker, parser.TypeCompactMarker, parser.TypeHookOutput, parser.TypeDiagnostics,
+	parser.TypePRLink, parser.TypeDebug, parser.TypeSessionTitle, parser.TypeCommand, parser.TypeUnknown,
+	parser.TypeAPIError, parser.TypeThinkingConfig, parser.TypeModelSwitch, parser.TypeProgress,
+	parser.TypeInterrupt, parser.TypeFork,
+}
+
+// Types lists the values a type comparison may use, for completion.
+func Types() []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return names
+}
+
+// Expr is a parsed filter expression. A nil *Expr matches every item.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse compiles an expression. An empty one yields nil.
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parse{toks: toks}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// Match reports whether item satisfies the expression.
+func (e *Expr) Match(item parser.StreamItem) bool {
+	if e == nil {
+		return true
+	}
+	return e.root.match(&item)
+}
+
+// String returns the expression as given.
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.src
+}
+
+type node interface {
+	match(*parser.StreamItem) bool
+}
+
+type andNode struct{ l, r node }
+type orNode struct{ l, r node }
+type notNode struct{ n node }
+
+func (n andNode) match(it *parser.StreamItem) bool { return n.l.match(it) && n.r.match(it) }
+func (n orNode) match(it *parser.StreamItem) bool  { return n.l.match(it) || n.r.match(it) }
+func (n notNode) match(it *parser.StreamItem) bool { return !n.n.match(it) }
+
+// cmpNode compares a field with one or more values: equal to any of them,
+// or containing it for ~.
+type cmpNode struct {
+	field    string
+	get      func(*parser.StreamItem) string
+	contains bool
+	negate   bool
+	values   []string // lowercased
+}
+
+func (n cmpNode) match(it *parser.StreamItem) bool {
+	v := strings.ToLower(n.get(it))
+	var hit bool
+	switch {
+	case n.contains:
+		hit = strings.Contains(v, n.values[0])
+	case n.field == "session":
+		hit = slices.ContainsFunc(n.values, func(want string) bool { return want != "" && strings.HasPrefix(v, want) })
+	default:
+		hit = slices.Contains(n.values, v)
+	}
+	return hit != n.negate
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokOp // = == != ~ !~
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ",", i})
+			i++
+		case c == '=' || c == '~' || c == '!':
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || src[i+1] == '~') && c != '~' {
+				op += string(src[i+1])
+			}
+			if op == "!" || op == "=~" {
+				return nil, fmt.Errorf("filter: unknown operator %q at column %d", op, i+1)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		case c == '"':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("filter: unterminated string at column %d", i+1)
+			}
+			toks = append(toks, token{tokString, b.String(), i})
+			i = j + 1
+		default:
+			j := i
+			for j < len(src) && !strings.ContainsRune(" \t\n(),=~!\"", rune(src[j])) {
+				j++
+			}
+			toks = append(toks, token{tokWord, src[i:j], i})
+			i = j
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// parse is a recursive-descent parser over the tokens.
+type parse struct {
+	toks []token
+	i    int
+}
+
+func (p *parse) peek() token { return p.toks[p.i] }
+func (p *parse) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the bare word kw, consuming
+// it if so.
+func (p *parse) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokWord && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parse) errorf(t token, format string, args ...any) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf("filter: "+format+" at end", args...)
+	}
+	return fmt.Errorf("filter: "+format+" at column %d", append(args, t.pos+1)...)
+}
+
+func (p *parse) or() (node, error) {
+	l, err := p.and()
+	for err == nil && p.keyword("or") {
+		var r node
+		if r, err = p.and(); err == nil {
+			l = orNode{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *parse) and() (node, error) {
+	l, err := p.not()
+	for err == nil && p.keyword("and") {
+		var r node
+		if r, err = p.not(); err == nil {
+			l = andNode{l, r}
+		}
+	}
+	return l, err
+}
+
+func (p *parse) not() (node, error) {
+	if p.keyword("not") {
+		n, err := p.not()
+		return notNode{n}, err
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, p.errorf(t, "expected )")
+		}
+		return n, nil
+	}
+	return p.comparison()
+}
+
+func (p *parse) comparison() (node, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return nil, p.errorf(t, "expected a field (%s)", strings.Join(Fields, ", "))
+	}
+	name := strings.ToLower(t.text)
+	get, ok := fields[name]
+	if !ok {
+		return nil, p.errorf(t, "unknown field %q (want %s)", t.text, strings.Join(Fields, ", "))
+	}
+	n := cmpNode{field: name, get: get}
+
+	if p.keyword("not") {
+		if !p.keyword("in") {
+			return nil, p.errorf(p.peek(), "expected in after not")
+		}
+		n.negate = true
+		return p.list(n)
+	}
+	if p.keyword("in") {
+		return p.list(n)
+	}
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, p.errorf(op, "expected =, !=, ~, !~ or in after %s", name)
+	}
+	n.negate = op.text[0] == '!'
+	n.contains = strings.HasSuffix(op.text, "~")
+	v, err := p.value(n.field, !n.contains)
+	if err != nil {
+		return nil, err
+	}
+	n.values = []string{v}
+	return n, nil
+}
+
+// list parses "(v1, v2, ...)" into n's values.
+func (p *parse) list(n cmpNode) (node, error) {
+	if t := p.next(); t.kind != tokLParen {
+		return nil, p.errorf(t, "expected ( after in")
+	}
+	for {
+		v, err := p.value(n.field, true)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, v)
+		t := p.next()
+		if t.kind == tokRParen {
+			return n, nil
+		}
+		if t.kind != tokComma {
+			return nil, p.errorf(t, "expected , or )")
+		}
+	}
+}
+
+// value parses one value for field. Unless it is a substring, a type
+// must be a known one and error true or false.
+func (p *parse) value(field string, whole bool) (string, error) {
+	t := p.next()
+	if t.kind != tokWord && t.kind != tokString {
+		return "", p.errorf(t, "expected a value")
+	}
+	v := strings.ToLower(t.text)
+	if !whole {
+		return v, nil
+	}
+	switch field {
+	case "type":
+		if !slices.Contains(types, parser.StreamItemType(v)) {
+			names := make([]string, len(types))
+			for i, typ := range types {
+				names[i] = string(typ)
+			}
+			return "", p.errorf(t, "unknown type %q (want %s)", t.text, strings.Join(names, ", "))
+		}
+	case "error":
+		if v != "true" && v != "false" {
+			return "", p.errorf(t, "error is true or false, not %q", t.text)
+		}
+	}
+	return v, nil
+}
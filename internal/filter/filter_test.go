@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestMatch(t *testing.T) {
+	read := parser.StreamItem{Type: parser.TypeToolInput, ToolName: "Read", AgentName: "Main", SessionID: "3f2a9c"}
+	bash := parser.StreamItem{Type: parser.TypeToolOutput, ToolName: "Bash", AgentName: "Explore", AgentID: "abc123", SessionID: "3f2a9c", ExitCode: 2, Content: "panic: boom"}
+	think := parser.StreamItem{Type: parser.TypeThinking, AgentName: "Main", SessionID: "77aa01", Content: "Hmm"}
+
+	tests := []struct {
+		expr string
+		want [3]bool // read, bash, think
+	}{
+		{"", [3]bool{true, true, true}},
+		{"type in (tool_input, tool_output) and tool != Read and agent != main", [3]bool{false, true, false}},
+		{"type = thinking or error = true", [3]bool{false, true, true}},
+		{`content ~ "PANIC:"`, [3]bool{false, true, false}},
+		{"content !~ panic", [3]bool{true, false, true}},
+		{"session = 3f2a", [3]bool{true, true, false}},
+		{"session not in (3f2a)", [3]bool{false, false, true}},
+		{"not (session = 3f2a and type = tool_input)", [3]bool{false, true, true}},
+		{"agent_id == abc123", [3]bool{false, true, false}},
+		{"type ~ tool and not error = true", [3]bool{true, false, false}},
+		{"tool = Read or tool = Bash and agent = explore", [3]bool{true, true, false}}, // and binds tighter
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		for i, item := range []parser.StreamItem{read, bash, think} {
+			if got := e.Match(item); got != tt.want[i] {
+				t.Errorf("%q on item %d = %v, want %v", tt.expr, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"colour = red":        "unknown field",
+		"type = tool":         "unknown type",
+		"error = maybe":       "true or false",
+		"type in (text":       "expected , or )",
+		"(type = text":        "expected )",
+		"type = text and":     "expected a field",
+		"tool Read":           "expected =",
+		`content ~ "open`:     "unterminated string",
+		"tool = Read tool":    "unexpected",
+		"tool ! Read":         "unknown operator",
+		"session not (abc)":   "expected in",
+		"type = text or or x": "column 16",
+	}
+	for expr, want := range tests {
+		if _, err := Parse(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package fork notices sessions that share history. Resuming a session
+// with claude --resume can fork it: the new transcript starts with a copy
+// of the old one's lines, uuids and all, and both may go on being written.
+// Watching both would show that history twice, so a Detector drops the
+// copies and emits a TypeFork item where the fork goes its own way.
+package fork
+
+import (
+	"fmt"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// shortID is how many characters of a session ID a fork item names.
+const shortID = 8
+
+// fork is what a Detector knows of a session that copied another's
+// lines.
+type fork struct {
+	from   string // the session it copied from
+	copied int    // items dropped as copies since the last fork item
+}
+
+// Detector tells copied lines by their uuid: the first session seen with
+// a uuid owns it. It is not safe for concurrent use.
+type Detector struct {
+	owners map[string]string // line uuid → session
+	forks  map[string]*fork  // by session ID
+}
+
+// New creates a detector.
+func New() *Detector {
+	return &Detector{
+		owners: make(map[string]string),
+		forks:  make(map[string]*fork),
+	}
+}
+
+// Add returns the items to show for item: none when it is a copy of a
+// line another session already showed, and a TypeFork item before it when
+// it is its session's first own item after copies. Items without a uuid
+// (synthesized ones, pushed ones from older versions) pass through.
+func (d *Detector) Add(item parser.StreamItem) []parser.StreamItem {
+	if item.UUID == "" {
+		return []parser.StreamItem{item}
+	}
+	owner, ok := d.owners[item.UUID]
+	if !ok {
+		d.owners[item.UUID] = item.SessionID
+		owner = item.SessionID
+	}
+	f := d.forks[item.SessionID]
+	if owner != item.SessionID {
+		if f == nil || f.from != owner {
+			f = &fork{from: owner}
+			d.forks[item.SessionID] = f
+		}
+		f.copied++
+		return nil
+	}
+	if f == nil || f.copied == 0 {
+		return []parser.StreamItem{item}
+	}
+	marker := parser.StreamItem{
+		Type:      parser.TypeFork,
+		SessionID: item.SessionID,
+		AgentID:   item.AgentID,
+		AgentName: item.AgentName,
+		Timestamp: item.Timestamp,
+		Content:   fmt.Sprintf("forked from session %s: %s of shared history shown there", f.from[:min(shortID, len(f.from))], items(f.copied)),
+		Host:      item.Host,
+	}
+	f.copied = 0
+	return []parser.StreamItem{marker, item}
+}
+
+// Filter runs items, in order, through Add, for transcripts read whole
+// (exports).
+func (d *Detector) Filter(items []parser.StreamItem) []parser.StreamItem {
+	out := make([]parser.StreamItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, d.Add(item)...)
+	}
+	return out
+}
+
+// items counts n items.
+func items(n int) string {
+	if n == 1 {
+		return "1 item"
+	}
+	return fmt.Sprintf("%d items", n)
+}
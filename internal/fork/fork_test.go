@@ -0,0 +1,63 @@
+package fork
+
+import (
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func line(session, uuid, content string) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeText, SessionID: session, AgentName: "Main", UUID: uuid, Content: content}
+}
+
+func TestDetector(t *testing.T) {
+	d := New()
+	var shown []string
+	add := func(item parser.StreamItem) {
+		for _, it := range d.Add(item) {
+			shown = append(shown, it.SessionID+":"+it.Content)
+		}
+	}
+	// The parent's history, then the fork's copy of it and its own reply.
+	add(line("parent-session", "u1", "hello"))
+	add(line("parent-session", "u2", "hi"))
+	add(line("fork", "u1", "hello"))
+	add(line("fork", "u2", "hi"))
+	add(line("fork", "u3", "fork goes on"))
+	add(line("fork", "u4", "and on"))
+	// Both go on being written; synthesized items have no uuid.
+	add(line("parent-session", "u5", "parent goes on"))
+	add(line("fork", "", "switch"))
+
+	want := []string{
+		"parent-session:hello",
+		"parent-session:hi",
+		"fork:forked from session parent-s: 2 items of shared history shown there",
+		"fork:fork goes on",
+		"fork:and on",
+		"parent-session:parent goes on",
+		"fork:switch",
+	}
+	if len(shown) != len(want) {
+		t.Fatalf("shown %q, want %q", shown, want)
+	}
+	for i := range want {
+		if shown[i] != want[i] {
+			t.Errorf("%d: %q, want %q", i, shown[i], want[i])
+		}
+	}
+
+	// A line that yields several items keeps them all.
+	if got := d.Add(line("fork", "u4", "second block")); len(got) != 1 {
+		t.Errorf("second item of a fork's own line: %d items", len(got))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	d := New()
+	parent := d.Filter([]parser.StreamItem{line("p", "u1", "hello"), line("p", "u2", "hi")})
+	forked := d.Filter([]parser.StreamItem{line("f", "u1", "hello"), line("f", "u2", "hi"), line("f", "u3", "own")})
+	if len(parent) != 2 || len(forked) != 2 || forked[0].Type != parser.TypeFork || forked[1].Content != "own" {
+		t.Errorf("parent %d items, fork %+v", len(parent), forked)
+	}
+}
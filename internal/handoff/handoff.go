@@ -0,0 +1,81 @@
+// Package handoff carries a TUI's stream across a restart: the process
+// that restarts writes where it had read each transcript and the items it
+// was showing, and the new one, exec'd in its place, picks them up instead
+// of reading the transcripts again. The view itself comes back through
+// package uistate like after any quit.
+package handoff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// FileName is the handoff file, kept in the state directory.
+const FileName = "handoff.json"
+
+// EnvVar names the handoff file for the restarted process. Only a process
+// started with it set reads a handoff, so a file left behind by a failed
+// restart is never picked up by a later, unrelated run.
+const EnvVar = "CLAUDE_ESP_HANDOFF"
+
+// version is bumped when State changes meaning; a handoff of another
+// version is ignored.
+const version = 1
+
+// MaxAge is how old a handoff may be when it is taken: a restart takes
+// moments, so anything older is from a restart that didn't happen.
+const MaxAge = time.Minute
+
+// State is what crosses the restart.
+type State struct {
+	Version int       `json:"version"`
+	Written time.Time `json:"written"`
+	// Positions maps each transcript to the offset read up to.
+	Positions map[string]int64 `json:"positions"`
+	// Items are the stream's items, oldest first, followed by the latest
+	// session title of each session.
+	Items []parser.StreamItem `json:"items"`
+}
+
+// Save writes st to path.
+func Save(path string, st State) error {
+	st.Version, st.Written = version, time.Now()
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Take reads the handoff at path and removes it, so it is used once.
+func Take(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return State{}, err
+	}
+	os.Remove(path)
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return State{}, fmt.Errorf("handoff %s: %w", path, err)
+	}
+	switch {
+	case st.Version != version:
+		return State{}, fmt.Errorf("handoff %s: version %d, want %d", path, st.Version, version)
+	case time.Since(st.Written) > MaxAge:
+		return State{}, errors.New("handoff " + path + " is stale")
+	}
+	return st, nil
+}
@@ -0,0 +1,56 @@
+package handoff
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestSaveTake(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+	want := State{
+		Positions: map[string]int64{"/p/s1.jsonl": 1234},
+		Items: []parser.StreamItem{
+			{Type: parser.TypeThinking, SessionID: "s1", Content: "hmm"},
+			{Type: parser.TypeSessionTitle, SessionID: "s1", Content: "Fix the tests"},
+		},
+	}
+	if err := Save(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Take(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Positions, want.Positions) || len(got.Items) != 2 || got.Items[1].Content != "Fix the tests" {
+		t.Errorf("Take = %+v", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("handoff not removed by Take")
+	}
+	if _, err := Take(path); err == nil {
+		t.Error("second Take succeeded")
+	}
+}
+
+func TestTakeRejects(t *testing.T) {
+	for name, st := range map[string]State{
+		"stale":         {Version: version, Written: time.Now().Add(-2 * MaxAge)},
+		"other version": {Version: version + 1, Written: time.Now()},
+	} {
+		path := filepath.Join(t.TempDir(), FileName)
+		data, _ := json.Marshal(st)
+		os.WriteFile(path, data, 0o600)
+		if _, err := Take(path); err == nil {
+			t.Errorf("%s: Take succeeded", name)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s: handoff not removed", name)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package heartbeat derives a per-session liveness summary from the stream:
+// when a session last did something, whether it is mid-turn, and which
+// tool it is waiting on. External watchdogs poll it to restart or alert on
+// stuck agents.
+package heartbeat
+
+import (
+	"sort"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// DefaultStallAfter is how long a session may go without activity mid-turn
+// before it counts as stalled.
+const DefaultStallAfter = 5 * time.Minute
+
+// State is a session's liveness.
+type State string
+
+const (
+	StateWorking State = "working" // mid-turn, with recent activity
+	StateIdle    State = "idle"    // turn finished; waiting for the user
+	StateStalled State = "stalled" // mid-turn, but quiet for the stall threshold
+)
+
+// Tool is a tool call that has started and not yet returned.
+type Tool struct {
+	Name      string    `json:"name"`
+	ToolID    string    `json:"tool_id"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	AgentName string    `json:"agent_name,omitempty"`
+	Started   time.Time `json:"started"`
+	Input     string    `json:"input,omitempty"` // formatted input, truncated
+}
+
+// Heartbeat is one session's summary. Field names are stable API.
+type Heartbeat struct {
+	SessionID    string    `json:"session_id"`
+	Project      string    `json:"project,omitempty"`
+	State        State     `json:"state"`
+	LastActivity time.Time `json:"last_activity"`
+	IdleSeconds  int64     `json:"idle_seconds"`           // since LastActivity
+	CurrentTool  *Tool     `json:"current_tool,omitempty"` // most recently started running tool
+	RunningTools int       `json:"running_tools"`
+}
+
+// maxInput caps Tool.Input.
+const maxInput = 120
+
+type session struct {
+	last    time.Time
+	inTurn  bool
+	running map[string]Tool // by ToolID
+}
+
+// Tracker accumulates stream items into heartbeats. It is not safe for
+// concurrent use.
+type Tracker struct {
+	sessions map[string]*session
+	projects map[string]string
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{sessions: make(map[string]*session), projects: make(map[string]string)}
+}
+
+// SetProject records the project path reported for a session.
+func (t *Tracker) SetProject(sessionID, project string) {
+	t.projects[sessionID] = project
+}
+
+// Add records an item's activity.
+func (t *Tracker) Add(item parser.StreamItem) {
+	if item.SessionID == "" || item.Type == parser.TypeSessionTitle || item.Type == parser.TypeDebug || item.Type == parser.TypeUnknown {
+		return
+	}
+	s := t.sessions[item.SessionID]
+	if s == nil {
+		s = &session{running: make(map[string]Tool)}
+		t.sessions[item.SessionID] = s
+	}
+	if item.Timestamp.After(s.last) {
+		s.last = item.Timestamp
+	}
+
+	switch item.Type {
+	case parser.TypeTurnMarker, parser.TypeInterrupt:
+		// Only the main agent's turn end (or interrupt) means the session
+		// is waiting on the user; anything still "running" was abandoned
+		// with the turn.
+		if item.AgentID == "" {
+			s.inTurn = false
+			clear(s.running)
+		}
+		return
+	case parser.TypeToolInput:
+		if item.ToolID != "" {
+			input := []rune(item.Content)
+			if len(input) > maxInput {
+				input = append(input[:maxInput-1], '…')
+			}
+			s.running[item.ToolID] = Tool{
+				Name:      item.ToolName,
+				ToolID:    item.ToolID,
+				AgentID:   item.AgentID,
+				AgentName: item.AgentName,
+				Started:   item.Timestamp,
+				Input:     string(input),
+			}
+		}
+	case parser.TypeToolOutput:
+		delete(s.running, item.ToolID)
+	}
+	s.inTurn = true
+}
+
+// Snapshot returns every session's heartbeat as of now, most recently
+// active first. A session counts as stalled once it has been quiet for
+// stallAfter while mid-turn.
+func (t *Tracker) Snapshot(now time.Time, stallAfter time.Duration) []Heartbeat {
+	out := make([]Heartbeat, 0, len(t.sessions))
+	for id, s := range t.sessions {
+		hb := Heartbeat{
+			SessionID:    id,
+			Project:      t.projects[id],
+			LastActivity: s.last,
+			IdleSeconds:  int64(max(now.Sub(s.last), 0) / time.Second),
+			RunningTools: len(s.running),
+		}
+		for _, tool := range s.running {
+			if hb.CurrentTool == nil || tool.Started.After(hb.CurrentTool.Started) {
+				hb.CurrentTool = &tool
+			}
+		}
+		switch {
+		case !s.inTurn:
+			hb.State = StateIdle
+		case now.Sub(s.last) >= stallAfter:
+			hb.State = StateStalled
+		default:
+			hb.State = StateWorking
+		}
+		out = append(out, hb)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastActivity.After(out[j].LastActivity)
+	})
+	return out
+}
@@ -0,0 +1,83 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+var t0 = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func item(typ parser.StreamItemType, at time.Duration, toolID, agentID string) parser.StreamItem {
+	return parser.StreamItem{
+		Type:      typ,
+		SessionID: "s1",
+		AgentID:   agentID,
+		ToolID:    toolID,
+		ToolName:  "Bash",
+		Timestamp: t0.Add(at),
+	}
+}
+
+func TestStates(t *testing.T) {
+	tr := NewTracker()
+	tr.Add(item(parser.TypeThinking, 0, "", ""))
+	tr.Add(item(parser.TypeToolInput, time.Second, "t1", ""))
+
+	hb := tr.Snapshot(t0.Add(time.Minute), DefaultStallAfter)[0]
+	if hb.State != StateWorking || hb.RunningTools != 1 || hb.CurrentTool.ToolID != "t1" {
+		t.Errorf("mid-tool: %+v", hb)
+	}
+	if hb.IdleSeconds != 59 {
+		t.Errorf("IdleSeconds = %d, want 59", hb.IdleSeconds)
+	}
+
+	hb = tr.Snapshot(t0.Add(10*time.Minute), DefaultStallAfter)[0]
+	if hb.State != StateStalled {
+		t.Errorf("quiet mid-turn for 10m: state = %s, want stalled", hb.State)
+	}
+
+	tr.Add(item(parser.TypeToolOutput, 2*time.Second, "t1", ""))
+	tr.Add(item(parser.TypeTurnMarker, 3*time.Second, "", ""))
+	hb = tr.Snapshot(t0.Add(time.Hour), DefaultStallAfter)[0]
+	if hb.State != StateIdle || hb.CurrentTool != nil || hb.RunningTools != 0 {
+		t.Errorf("after turn end: %+v", hb)
+	}
+}
+
+func TestCurrentToolIsNewest(t *testing.T) {
+	tr := NewTracker()
+	tr.Add(item(parser.TypeToolInput, 0, "task", ""))
+	sub := item(parser.TypeToolInput, time.Second, "bash", "a1")
+	sub.AgentName = "Explore"
+	tr.Add(sub)
+	// A subagent's turn end doesn't end the session's turn.
+	tr.Add(item(parser.TypeTurnMarker, 2*time.Second, "", "a1"))
+
+	hb := tr.Snapshot(t0.Add(3*time.Second), DefaultStallAfter)[0]
+	if hb.State != StateWorking || hb.RunningTools != 2 {
+		t.Errorf("state = %s running = %d, want working with 2", hb.State, hb.RunningTools)
+	}
+	if hb.CurrentTool == nil || hb.CurrentTool.ToolID != "bash" || hb.CurrentTool.AgentName != "Explore" {
+		t.Errorf("CurrentTool = %+v, want the subagent's bash call", hb.CurrentTool)
+	}
+}
+
+func TestSnapshotOrderAndProject(t *testing.T) {
+	tr := NewTracker()
+	old := item(parser.TypeText, 0, "", "")
+	old.SessionID = "old"
+	tr.Add(old)
+	tr.Add(item(parser.TypeText, time.Minute, "", ""))
+	tr.SetProject("s1", "/src/app")
+	tr.Add(parser.StreamItem{Type: parser.TypeSessionTitle, SessionID: "ignored", Timestamp: t0})
+
+	beats := tr.Snapshot(t0.Add(time.Hour), DefaultStallAfter)
+	if len(beats) != 2 || beats[0].SessionID != "s1" || beats[1].SessionID != "old" {
+		t.Fatalf("beats = %+v, want s1 then old", beats)
+	}
+	if beats[0].Project != "/src/app" {
+		t.Errorf("Project = %q", beats[0].Project)
+	}
+}
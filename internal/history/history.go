@@ -0,0 +1,92 @@
+// Package history remembers what was entered at the TUI's prompts (the
+// command palette, the tree filter, pager searches) between runs, so up
+// and ctrl+p can bring it back. Each prompt has its own list, most recent
+// last.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// FileName is the history file's name in the state directory.
+const FileName = "history.json"
+
+// Max is how many entries each prompt keeps; older ones are dropped.
+const Max = 200
+
+// Store reads and writes one history file. It is loaded on first use. A
+// nil *Store is valid and remembers nothing.
+type Store struct {
+	path   string
+	mu     sync.Mutex
+	lines  map[string][]string
+	loaded bool
+}
+
+// New returns the store kept at path. The directory is created on first
+// write.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// Lines returns prompt's history, oldest first.
+func (s *Store) Lines(prompt string) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return slices.Clone(s.lines[prompt])
+}
+
+// Add records line as prompt's most recent entry, dropping an earlier
+// copy of it, and saves the file.
+func (s *Store) Add(prompt, line string) error {
+	if s == nil || line == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	lines := slices.DeleteFunc(s.lines[prompt], func(l string) bool { return l == line })
+	lines = append(lines, line)
+	if len(lines) > Max {
+		lines = lines[len(lines)-Max:]
+	}
+	s.lines[prompt] = lines
+	return s.save()
+}
+
+// load reads the file once; a missing or unreadable one starts empty.
+func (s *Store) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.lines = make(map[string][]string)
+	if data, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(data, &s.lines)
+	}
+}
+
+// save writes the file atomically.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.lines, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,42 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+	s := New(path)
+	if got := s.Lines("palette"); got != nil {
+		t.Fatalf("new store has %q", got)
+	}
+	for _, line := range []string{"ascii on", "queues on", "ascii on", ""} {
+		if err := s.Add("palette", line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Add("tree", "api")
+
+	reopened := New(path)
+	if got, want := reopened.Lines("palette"), []string{"queues on", "ascii on"}; !slices.Equal(got, want) {
+		t.Errorf("palette = %q, want %q", got, want)
+	}
+	if got := reopened.Lines("tree"); !slices.Equal(got, []string{"api"}) {
+		t.Errorf("tree = %q", got)
+	}
+
+	for i := range Max + 5 {
+		s.Add("search", fmt.Sprint(i))
+	}
+	if got := s.Lines("search"); len(got) != Max || got[0] != "5" {
+		t.Errorf("kept %d entries from %q, want %d from 5", len(got), got[0], Max)
+	}
+
+	var none *Store
+	if err := none.Add("palette", "x"); err != nil || none.Lines("palette") != nil {
+		t.Error("a nil store should remember nothing")
+	}
+}
@@ -0,0 +1,166 @@
+// Package instance keeps two claude-esp processes from polling the same
+// Claude directory. The first one holds a lock file that says who it is;
+// a later one learns the holder from it and can take over, attach to the
+// holder's HTTP stream, or run alongside it anyway.
+package instance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Info describes the process holding a lock.
+type Info struct {
+	PID       int       `json:"pid"`
+	Mode      string    `json:"mode"`           // "tui" or "serve"
+	HTTP      string    `json:"http,omitempty"` // stream API address, if serving one
+	TLS       bool      `json:"tls,omitempty"`  // the stream API speaks HTTPS
+	ClaudeDir string    `json:"claude_dir"`
+	Started   time.Time `json:"started"`
+}
+
+// URL returns the base URL of the holder's HTTP API, or "" if it has none.
+// A wildcard listen address is reached over loopback.
+func (i Info) URL() string {
+	if i.HTTP == "" {
+		return ""
+	}
+	host, port, err := net.SplitHostPort(i.HTTP)
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	scheme := "http://"
+	if i.TLS {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(host, port)
+}
+
+// HeldError is returned by Acquire when another process holds the lock.
+type HeldError struct {
+	Holder Info
+}
+
+func (e *HeldError) Error() string {
+	h := e.Holder
+	if h.PID == 0 {
+		return fmt.Sprintf("%s is already watched by another claude-esp", h.ClaudeDir)
+	}
+	return fmt.Sprintf("%s is already watched by claude-esp %s (pid %d)", h.ClaudeDir, h.Mode, h.PID)
+}
+
+// errLocked is what lockFile returns when someone else has the lock.
+var errLocked = errors.New("locked")
+
+// Lock is a held instance lock. The operating system drops it if the
+// process dies, so a crash never leaves a stale one behind.
+type Lock struct {
+	f    *os.File
+	info Info
+}
+
+// Path returns the lock file for claudeDir under the state directory dir:
+// one per Claude directory, so instances watching different ones (e.g.
+// with CLAUDE_HOME) don't conflict.
+func Path(dir, claudeDir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(claudeDir)))
+	return filepath.Join(dir, "run", hex.EncodeToString(sum[:6])+".lock")
+}
+
+// Acquire takes the lock for info.ClaudeDir and records info in it. If
+// another process holds it, the error is a *HeldError naming that process.
+func Acquire(dir string, info Info) (*Lock, error) {
+	path := Path(dir, info.ClaudeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, errLocked) {
+			holder, _ := Read(path)
+			holder.ClaudeDir = info.ClaudeDir
+			return nil, &HeldError{Holder: holder}
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	l := &Lock{f: f, info: info}
+	if err := l.write(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Takeover stops the process holding the lock and acquires it in its
+// place, waiting up to timeout for the holder to exit.
+func Takeover(dir string, info Info, holder Info, timeout time.Duration) (*Lock, error) {
+	if holder.PID <= 0 {
+		return nil, errors.New("cannot take over: the other instance's pid is unknown")
+	}
+	if err := terminate(holder.PID); err != nil {
+		return nil, fmt.Errorf("stopping pid %d: %w", holder.PID, err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := Acquire(dir, info)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return l, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("pid %d did not exit within %s", holder.PID, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Read returns the holder recorded in the lock file at path. The file is
+// readable whether or not it is locked.
+func Read(path string) (Info, error) {
+	var info Info
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// SetHTTP records the address the holder's stream API ended up on, once
+// it is listening, and whether it speaks HTTPS.
+func (l *Lock) SetHTTP(addr string, tls bool) error {
+	l.info.HTTP, l.info.TLS = addr, tls
+	return l.write()
+}
+
+// Release gives the lock up.
+func (l *Lock) Release() {
+	l.f.Truncate(0)
+	l.f.Close()
+}
+
+func (l *Lock) write() error {
+	data, err := json.Marshal(l.info)
+	if err != nil {
+		return err
+	}
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = l.f.WriteAt(data, 0)
+	return err
+}
@@ -0,0 +1,32 @@
+package instance
+
+import "testing"
+
+func TestInfoURL(t *testing.T) {
+	tests := []struct{ addr, want string }{
+		{"", ""},
+		{"127.0.0.1:7777", "http://127.0.0.1:7777"},
+		{":7777", "http://127.0.0.1:7777"},
+		{"0.0.0.0:8080", "http://127.0.0.1:8080"},
+		{"[::]:8080", "http://127.0.0.1:8080"},
+		{"[::1]:8080", "http://[::1]:8080"},
+		{"nonsense", ""},
+	}
+	for _, tt := range tests {
+		if got := (Info{HTTP: tt.addr}).URL(); got != tt.want {
+			t.Errorf("URL for %q = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+	if got := (Info{HTTP: "[::]:7777", TLS: true}).URL(); got != "https://127.0.0.1:7777" {
+		t.Errorf("TLS URL = %q", got)
+	}
+}
+
+func TestPathPerClaudeDir(t *testing.T) {
+	if Path("/state", "/a/projects") == Path("/state", "/b/projects") {
+		t.Error("different Claude directories share a lock")
+	}
+	if Path("/state", "/a/projects/") != Path("/state", "/a/projects") {
+		t.Error("trailing slash changes the lock")
+	}
+}
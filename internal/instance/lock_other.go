@@ -0,0 +1,17 @@
+//go:build !unix
+
+package instance
+
+import (
+	"errors"
+	"os"
+)
+
+// Without flock every Acquire succeeds: duplicate instances go undetected.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func terminate(pid int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on f without blocking.
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errLocked
+	}
+	return err
+}
+
+// terminate asks pid to exit the way Ctrl+C in its terminal would.
+func terminate(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
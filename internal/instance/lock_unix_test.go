@@ -0,0 +1,42 @@
+//go:build unix
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestAcquireHeld(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Acquire(dir, Info{PID: os.Getpid(), Mode: "serve", ClaudeDir: "/p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SetHTTP("127.0.0.1:7777", false); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Acquire(dir, Info{PID: 1, Mode: "tui", ClaudeDir: "/p"})
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("second Acquire: %v, want a HeldError", err)
+	}
+	if h := held.Holder; h.PID != os.Getpid() || h.Mode != "serve" || h.URL() != "http://127.0.0.1:7777" {
+		t.Errorf("holder = %+v", h)
+	}
+
+	if other, err := Acquire(dir, Info{ClaudeDir: "/q"}); err != nil {
+		t.Errorf("lock for another directory: %v", err)
+	} else {
+		other.Release()
+	}
+
+	first.Release()
+	second, err := Acquire(dir, Info{PID: 1, Mode: "tui", ClaudeDir: "/p"})
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	second.Release()
+}
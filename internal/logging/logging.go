@@ -0,0 +1,142 @@
+// Package logging writes claude-esp's own diagnostics (watch errors,
+// unparseable lines, inotify limits, failing hooks) to a size-rotated file
+// in its state directory, so problems in the field can be looked into
+// after the fact. Everything logs through log/slog's default logger.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// FileName is the log file in the state directory.
+	FileName = "claude-esp.log"
+	// MaxSize is how large the log grows before it is rotated.
+	MaxSize = 5 << 20
+	// Backups is how many rotated logs are kept (claude-esp.log.1 ...).
+	Backups = 3
+)
+
+// LevelOff disables logging.
+const LevelOff = slog.Level(100)
+
+// ParseLevel parses a -log-level value: debug, info, warn, error or off.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "none":
+		return LevelOff, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want debug, info, warn, error or off)", s)
+}
+
+// Open makes the default slog logger write records at level and above to
+// FileName in dir, creating dir if needed. The returned Closer flushes and
+// closes the file. With LevelOff nothing is written.
+func Open(dir string, level slog.Level) (io.Closer, error) {
+	if level >= LevelOff {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return io.NopCloser(nil), nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := NewRotatingFile(filepath.Join(dir, FileName), MaxSize, Backups)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})))
+	return f, nil
+}
+
+// RotatingFile is an append-only file that is renamed to path.1 (shifting
+// older ones up to path.<backups>) before a write would take it past
+// maxSize. It is safe for concurrent use.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewRotatingFile opens path for appending.
+func NewRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it doesn't fit.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.backups > 0 {
+		os.Rename(r.path, r.path+".1")
+	} else {
+		os.Remove(r.path)
+	}
+	return r.open()
+}
+
+// Close closes the file; later writes fail.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
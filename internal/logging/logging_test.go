@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.log")
+	r, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.Close()
+
+	for name, want := range map[string]string{
+		"x.log":   "dddddd\n",
+		"x.log.1": "cccccc\n",
+		"x.log.2": "bbbbbb\n",
+	} {
+		got, _ := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("kept more backups than asked")
+	}
+}
+
+func TestOpenFiltersByLevel(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	dir := t.TempDir()
+	level, err := ParseLevel("warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Open(dir, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.Info("hidden")
+	slog.Warn("inotify watch limit reached", "path", "/x")
+	c.Close()
+
+	got, _ := os.ReadFile(filepath.Join(dir, FileName))
+	if strings.Contains(string(got), "hidden") || !strings.Contains(string(got), `level=WARN msg="inotify watch limit reached" path=/x`) {
+		t.Errorf("log = %q", got)
+	}
+
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("bad level accepted")
+	}
+}
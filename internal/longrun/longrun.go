@@ -0,0 +1,172 @@
+// Package longrun flags tool calls that have gone without a result for
+// longer than their tool should take: a Bash command waiting on a prompt
+// or a hung server, a fetch that never returns. The agent waits on them
+// silently, so nothing else in the stream shows it.
+package longrun
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Limits says how long each tool may run. A zero limit never flags.
+type Limits struct {
+	Default time.Duration            // tools not in Tools
+	Tools   map[string]time.Duration // by tool name
+}
+
+// DefaultLimits flag Bash after 5 minutes and other tools after 10.
+// Subagents (Task, Agent) legitimately run for long and aren't flagged.
+var DefaultLimits = Limits{
+	Default: 10 * time.Minute,
+	Tools:   map[string]time.Duration{"Bash": 5 * time.Minute, "Task": 0, "Agent": 0},
+}
+
+// For returns tool's limit.
+func (l Limits) For(tool string) time.Duration {
+	if d, ok := l.Tools[tool]; ok {
+		return d
+	}
+	return l.Default
+}
+
+// maxAge is when a call is given up on: its session most likely died
+// without writing the result.
+const maxAge = 6 * time.Hour
+
+// maxInput caps Call.Input.
+const maxInput = 120
+
+// Call is a tool call without a result.
+type Call struct {
+	SessionID string
+	AgentID   string
+	AgentName string
+	Tool      string
+	ToolID    string
+	Input     string // formatted input, truncated
+	Started   time.Time
+	Limit     time.Duration
+}
+
+// Overdue is when the call went over its limit.
+func (c Call) Overdue() time.Time {
+	return c.Started.Add(c.Limit)
+}
+
+// Describe is a one-line account of the call as of now, e.g.
+// "Bash running 7m: npm run dev".
+func (c Call) Describe(now time.Time) string {
+	s := c.Tool + " running " + minutes(now.Sub(c.Started))
+	if c.Input != "" {
+		s += ": " + c.Input
+	}
+	return s
+}
+
+// minutes prints d as "7m" or "1h05m".
+func minutes(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", m/60, m%60)
+}
+
+type callKey struct{ session, toolID string }
+
+// Tracker follows open tool calls. It is not safe for concurrent use.
+type Tracker struct {
+	limits  Limits
+	open    map[callKey]*Call
+	alerted map[callKey]bool
+}
+
+// NewTracker creates a tracker with the given limits.
+func NewTracker(l Limits) *Tracker {
+	return &Tracker{limits: l, open: make(map[callKey]*Call), alerted: make(map[callKey]bool)}
+}
+
+// SetLimits changes the limits, for calls already open too.
+func (t *Tracker) SetLimits(l Limits) {
+	t.limits = l
+	for _, c := range t.open {
+		c.Limit = l.For(c.Tool)
+	}
+}
+
+// Add opens a call on its tool_use and closes it on its result. The end
+// of the main agent's turn closes the session's calls: whatever was left
+// running was abandoned with it.
+func (t *Tracker) Add(item parser.StreamItem) {
+	switch item.Type {
+	case parser.TypeToolInput:
+		if item.ToolID == "" {
+			return
+		}
+		input := []rune(item.Content)
+		if len(input) > maxInput {
+			input = append(input[:maxInput-1], '…')
+		}
+		t.open[callKey{item.SessionID, item.ToolID}] = &Call{
+			SessionID: item.SessionID,
+			AgentID:   item.AgentID,
+			AgentName: item.AgentName,
+			Tool:      item.ToolName,
+			ToolID:    item.ToolID,
+			Input:     string(input),
+			Started:   item.Timestamp,
+			Limit:     t.limits.For(item.ToolName),
+		}
+	case parser.TypeToolOutput:
+		t.close(callKey{item.SessionID, item.ToolID})
+	case parser.TypeTurnMarker, parser.TypeInterrupt:
+		if item.AgentID != "" {
+			return
+		}
+		for k := range t.open {
+			if k.session == item.SessionID {
+				t.close(k)
+			}
+		}
+	}
+}
+
+func (t *Tracker) close(k callKey) {
+	delete(t.open, k)
+	delete(t.alerted, k)
+}
+
+// Check returns the calls that went over their limit since the last
+// check, each once.
+func (t *Tracker) Check(now time.Time) []Call {
+	var out []Call
+	for _, c := range t.Overdue(now) {
+		k := callKey{c.SessionID, c.ToolID}
+		if !t.alerted[k] {
+			t.alerted[k] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Overdue returns every call over its limit as of now, longest running
+// first.
+func (t *Tracker) Overdue(now time.Time) []Call {
+	var out []Call
+	for k, c := range t.open {
+		if now.Sub(c.Started) > maxAge {
+			t.close(k)
+			continue
+		}
+		if c.Limit > 0 && now.After(c.Overdue()) {
+			out = append(out, *c)
+		}
+	}
+	slices.SortFunc(out, func(a, b Call) int { return a.Started.Compare(b.Started) })
+	return out
+}
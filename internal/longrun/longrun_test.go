@@ -0,0 +1,94 @@
+package longrun
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func call(session, agent, tool, id, input string, at time.Time) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeToolInput, SessionID: session, AgentID: agent, ToolName: tool, ToolID: id, Content: input, Timestamp: at}
+}
+
+func result(session, id string) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeToolOutput, SessionID: session, ToolID: id}
+}
+
+func TestLimits(t *testing.T) {
+	for tool, want := range map[string]time.Duration{"Bash": 5 * time.Minute, "Read": 10 * time.Minute, "Task": 0} {
+		if got := DefaultLimits.For(tool); got != want {
+			t.Errorf("%s: %s, want %s", tool, got, want)
+		}
+	}
+}
+
+func TestCheckAlertsOnce(t *testing.T) {
+	tr := NewTracker(DefaultLimits)
+	tr.Add(call("s1", "", "Bash", "b1", "npm run dev", start))
+	tr.Add(call("s1", "a1", "Read", "r1", "main.go", start))
+	tr.Add(call("s1", "", "Task", "t1", "explore", start))
+
+	if got := tr.Check(start.Add(4 * time.Minute)); len(got) != 0 {
+		t.Fatalf("alerts before the limit: %+v", got)
+	}
+	got := tr.Check(start.Add(6 * time.Minute))
+	if len(got) != 1 || got[0].ToolID != "b1" || got[0].Limit != 5*time.Minute {
+		t.Fatalf("alerts at 6m = %+v, want b1", got)
+	}
+	if d := got[0].Describe(start.Add(7 * time.Minute)); d != "Bash running 7m: npm run dev" {
+		t.Errorf("Describe = %q", d)
+	}
+	if got := tr.Check(start.Add(7 * time.Minute)); len(got) != 0 {
+		t.Errorf("b1 alerted twice: %+v", got)
+	}
+	got = tr.Check(start.Add(11 * time.Minute))
+	if len(got) != 1 || got[0].ToolID != "r1" || got[0].AgentID != "a1" {
+		t.Errorf("alerts at 11m = %+v, want r1", got)
+	}
+	if over := tr.Overdue(start.Add(11 * time.Minute)); len(over) != 2 {
+		t.Errorf("overdue = %+v, want b1 and r1 (Task is never flagged)", over)
+	}
+	if over := tr.Overdue(start.Add(maxAge + time.Minute)); len(over) != 0 {
+		t.Errorf("calls older than maxAge still overdue: %+v", over)
+	}
+}
+
+func TestResultsAndTurnsClose(t *testing.T) {
+	tr := NewTracker(DefaultLimits)
+	tr.Add(call("s1", "", "Bash", "b1", "sleep 600", start))
+	tr.Add(call("s1", "a1", "Bash", "b2", "sleep 600", start))
+	tr.Add(call("s2", "", "Bash", "b3", "sleep 600", start))
+	tr.Add(result("s1", "b1"))
+	// A subagent's turn ending doesn't close the session's calls.
+	tr.Add(parser.StreamItem{Type: parser.TypeTurnMarker, SessionID: "s1", AgentID: "a1"})
+
+	over := tr.Overdue(start.Add(time.Hour))
+	if len(over) != 2 || over[0].ToolID == "b1" || over[1].ToolID == "b1" {
+		t.Fatalf("overdue = %+v, want b2 and b3", over)
+	}
+	tr.Add(parser.StreamItem{Type: parser.TypeTurnMarker, SessionID: "s1"})
+	if over := tr.Overdue(start.Add(time.Hour)); len(over) != 1 || over[0].ToolID != "b3" {
+		t.Errorf("overdue after s1's turn = %+v, want b3", over)
+	}
+	// Interrupting the main agent ends its turn too.
+	tr.Add(parser.StreamItem{Type: parser.TypeInterrupt, SessionID: "s2"})
+	if over := tr.Overdue(start.Add(time.Hour)); len(over) != 0 {
+		t.Errorf("overdue after s2's interrupt = %+v", over)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	tr := NewTracker(DefaultLimits)
+	tr.Add(call("s1", "", "Bash", "b1", "make", start))
+	tr.SetLimits(Limits{Default: time.Minute})
+	if over := tr.Overdue(start.Add(2 * time.Minute)); len(over) != 1 || over[0].Limit != time.Minute {
+		t.Errorf("overdue after SetLimits = %+v", over)
+	}
+	tr.SetLimits(Limits{})
+	if over := tr.Overdue(start.Add(time.Hour)); len(over) != 0 {
+		t.Errorf("zero limits still flag: %+v", over)
+	}
+}
@@ -0,0 +1,256 @@
+// Package loops flags agents that look stuck: calling the same tool with
+// the same input over and over, thinking the same thoughts, or working for
+// a long time without changing any file.
+package loops
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Kind names a loop heuristic. Values are stable: they appear in
+// notification events.
+type Kind string
+
+const (
+	KindRepeatedCall     Kind = "repeated_call"
+	KindRepeatedThinking Kind = "repeated_thinking"
+	KindNoProgress       Kind = "no_progress"
+)
+
+// Thresholds tune the heuristics. A zero field disables its heuristic.
+type Thresholds struct {
+	// RepeatedCalls flags a tool call whose exact input appears this many
+	// times among the agent's last callWindow calls.
+	RepeatedCalls int
+	// RepeatedThinking flags this many near-identical thinking blocks
+	// among the agent's last thinkingWindow blocks.
+	RepeatedThinking int
+	// NoProgress flags an agent that has made at least noProgressCalls
+	// tool calls over this long without a successful file edit.
+	NoProgress time.Duration
+}
+
+// DefaultThresholds are used for settings left unset in the config.
+var DefaultThresholds = Thresholds{
+	RepeatedCalls:    4,
+	RepeatedThinking: 3,
+	NoProgress:       20 * time.Minute,
+}
+
+const (
+	callWindow      = 20
+	thinkingWindow  = 6
+	noProgressCalls = 30
+	// similarThinking is the word-trigram Jaccard similarity at which two
+	// thinking blocks count as near-identical.
+	similarThinking = 0.8
+	// minThinkingWords skips short blocks ("Let me check the tests.") that
+	// legitimately repeat.
+	minThinkingWords = 12
+	detailLen        = 60
+)
+
+// Alert is one detected loop.
+type Alert struct {
+	Kind      Kind
+	SessionID string
+	AgentID   string
+	AgentName string
+	Detail    string // human-readable, e.g. `Bash ×4 with identical input: npm test`
+	Time      time.Time
+}
+
+type agentKey struct{ session, agent string }
+
+type agentState struct {
+	calls    []string          // signatures of recent tool calls, oldest first
+	fired    map[string]bool   // signatures already alerted on
+	thinking []map[string]bool // trigram sets of recent substantial thinking blocks
+
+	pendingEdits map[string]bool // ToolIDs of edit calls awaiting a result
+	lastChange   time.Time       // last successful edit, or first activity
+	callsSince   int             // tool calls since lastChange
+	stalled      bool            // no-progress alert already fired
+
+	warning string // latest alert detail; "" once the agent makes progress
+}
+
+// Detector runs the heuristics over the stream. It is not safe for
+// concurrent use.
+type Detector struct {
+	th     Thresholds
+	agents map[agentKey]*agentState
+}
+
+// NewDetector creates a detector with the given thresholds.
+func NewDetector(th Thresholds) *Detector {
+	return &Detector{th: th, agents: make(map[agentKey]*agentState)}
+}
+
+// SetThresholds changes the thresholds of a running detector. Counts
+// already gathered are kept.
+func (d *Detector) SetThresholds(th Thresholds) {
+	d.th = th
+}
+
+// Add feeds an item and returns any loops it completes. Each repeated call
+// signature alerts once; repeated thinking re-arms after alerting; no
+// progress re-arms after the next successful edit.
+func (d *Detector) Add(item parser.StreamItem) []Alert {
+	if item.SessionID == "" {
+		return nil
+	}
+	if (item.Type == parser.TypeTurnMarker || item.Type == parser.TypeInterrupt) && item.AgentID == "" {
+		// The turn is over, so whatever loop there was has ended.
+		for k, a := range d.agents {
+			if k.session == item.SessionID {
+				a.warning = ""
+			}
+		}
+		return nil
+	}
+
+	k := agentKey{item.SessionID, item.AgentID}
+	a := d.agents[k]
+	if a == nil {
+		a = &agentState{fired: make(map[string]bool), pendingEdits: make(map[string]bool), lastChange: item.Timestamp}
+		d.agents[k] = a
+	}
+
+	var alerts []Alert
+	alert := func(kind Kind, detail string) {
+		a.warning = detail
+		alerts = append(alerts, Alert{
+			Kind:      kind,
+			SessionID: item.SessionID,
+			AgentID:   item.AgentID,
+			AgentName: item.AgentName,
+			Detail:    detail,
+			Time:      item.Timestamp,
+		})
+	}
+
+	switch item.Type {
+	case parser.TypeToolInput:
+		if edits.IsEditTool(item.ToolName) {
+			a.pendingEdits[item.ToolID] = true
+		}
+		if n := d.th.RepeatedCalls; n > 0 {
+			sig := callSignature(item)
+			a.calls = append(a.calls, sig)
+			if len(a.calls) > callWindow {
+				a.calls = a.calls[1:]
+			}
+			count := 0
+			for _, c := range a.calls {
+				if c == sig {
+					count++
+				}
+			}
+			if count >= n && !a.fired[sig] {
+				a.fired[sig] = true
+				alert(KindRepeatedCall, fmt.Sprintf("%s ×%d with identical input: %s", item.ToolName, count, clip(item.Content)))
+			}
+		}
+		a.callsSince++
+		if d.th.NoProgress > 0 && !a.stalled && a.callsSince >= noProgressCalls &&
+			item.Timestamp.Sub(a.lastChange) >= d.th.NoProgress {
+			a.stalled = true
+			alert(KindNoProgress, fmt.Sprintf("%d tool calls over %s without a file change",
+				a.callsSince, item.Timestamp.Sub(a.lastChange).Round(time.Minute)))
+		}
+	case parser.TypeToolOutput:
+		if a.pendingEdits[item.ToolID] {
+			delete(a.pendingEdits, item.ToolID)
+			if !item.IsError {
+				a.lastChange = item.Timestamp
+				a.callsSince = 0
+				a.stalled = false
+				a.warning = ""
+			}
+		}
+	case parser.TypeThinking:
+		if n := d.th.RepeatedThinking; n > 0 {
+			set := trigrams(item.Content)
+			if set == nil {
+				break
+			}
+			similar := 1
+			for _, prev := range a.thinking {
+				if jaccard(set, prev) >= similarThinking {
+					similar++
+				}
+			}
+			a.thinking = append(a.thinking, set)
+			if len(a.thinking) > thinkingWindow {
+				a.thinking = a.thinking[1:]
+			}
+			if similar >= n {
+				a.thinking = nil // re-arm: the next alert needs n fresh repeats
+				alert(KindRepeatedThinking, fmt.Sprintf("%d near-identical thinking blocks: %s", similar, clip(item.Content)))
+			}
+		}
+	}
+	return alerts
+}
+
+// Warnings returns the current warning per agent ID ("" for Main) in a
+// session; agents without one are omitted.
+func (d *Detector) Warnings(sessionID string) map[string]string {
+	out := make(map[string]string)
+	for k, a := range d.agents {
+		if k.session == sessionID && a.warning != "" {
+			out[k.agent] = a.warning
+		}
+	}
+	return out
+}
+
+func callSignature(item parser.StreamItem) string {
+	input := string(item.ToolInput)
+	if input == "" {
+		input = item.Content
+	}
+	return item.ToolName + "\x00" + input
+}
+
+// trigrams returns the set of word trigrams in s, or nil if s is too short
+// to compare meaningfully.
+func trigrams(s string) map[string]bool {
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) < minThinkingWords {
+		return nil
+	}
+	set := make(map[string]bool, len(words))
+	for i := 0; i+2 < len(words); i++ {
+		set[words[i]+" "+words[i+1]+" "+words[i+2]] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	inter := 0
+	for k := range a {
+		if b[k] {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+func clip(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > detailLen {
+		return string(r[:detailLen-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+package loops
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+var t0 = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func call(at time.Duration, id, tool, input string) parser.StreamItem {
+	return parser.StreamItem{
+		Type:      parser.TypeToolInput,
+		SessionID: "s1",
+		ToolName:  tool,
+		ToolID:    id,
+		ToolInput: json.RawMessage(input),
+		Timestamp: t0.Add(at),
+	}
+}
+
+func thinking(at time.Duration, text string) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeThinking, SessionID: "s1", Content: text, Timestamp: t0.Add(at)}
+}
+
+func TestRepeatedCall(t *testing.T) {
+	d := NewDetector(DefaultThresholds)
+	var alerts []Alert
+	for i := range 6 {
+		alerts = append(alerts, d.Add(call(time.Duration(i)*time.Second, "", "Bash", `{"command":"npm test"}`))...)
+		alerts = append(alerts, d.Add(call(time.Duration(i)*time.Second, "", "Bash", `{"command":"ls"}`))...)
+		if i < 3 && len(alerts) > 0 {
+			t.Fatalf("alert after %d repeats: %+v", i+1, alerts)
+		}
+	}
+	// Both signatures reach the threshold, each alerting exactly once.
+	if len(alerts) != 2 || alerts[0].Kind != KindRepeatedCall {
+		t.Fatalf("alerts = %+v, want one per signature", alerts)
+	}
+	if !strings.HasPrefix(alerts[0].Detail, "Bash ×4") {
+		t.Errorf("Detail = %q", alerts[0].Detail)
+	}
+	if w := d.Warnings("s1"); w[""] == "" {
+		t.Errorf("Warnings = %v, want one for Main", w)
+	}
+}
+
+func TestRepeatedThinking(t *testing.T) {
+	d := NewDetector(DefaultThresholds)
+	text := "I should run the test suite again to see whether the failure in the parser package is fixed now"
+	n := 0
+	for i := range 6 {
+		n += len(d.Add(thinking(time.Duration(i)*time.Second, text)))
+	}
+	if n != 2 {
+		t.Errorf("got %d alerts over 6 identical blocks, want 2 (re-armed after each)", n)
+	}
+	if a := d.Add(thinking(time.Minute, "Let me check.")); a != nil {
+		t.Errorf("short block alerted: %+v", a)
+	}
+}
+
+func TestNoProgress(t *testing.T) {
+	d := NewDetector(DefaultThresholds)
+	fired := func(from, to int, step time.Duration) int {
+		n := 0
+		for i := from; i < to; i++ {
+			n += len(d.Add(call(time.Duration(i)*step, "", "Read", `{"file_path":"/f`+string(rune('a'+i%26))+`"}`)))
+		}
+		return n
+	}
+	if n := fired(0, 40, time.Second); n != 0 {
+		t.Fatalf("fast burst alerted %d times", n)
+	}
+	if n := fired(40, 80, time.Minute); n != 1 {
+		t.Fatalf("40 calls over 40m: %d alerts, want 1", n)
+	}
+
+	edit := call(80*time.Minute, "e1", "Edit", `{"file_path":"/f"}`)
+	d.Add(edit)
+	out := edit
+	out.Type, out.ToolInput = parser.TypeToolOutput, nil
+	d.Add(out)
+	if w := d.Warnings("s1"); len(w) != 0 {
+		t.Errorf("warning survived a successful edit: %v", w)
+	}
+	if n := fired(81, 85, time.Minute); n != 0 {
+		t.Errorf("alerted right after progress")
+	}
+}
+
+func TestTurnMarkerClearsWarnings(t *testing.T) {
+	d := NewDetector(DefaultThresholds)
+	for i := range 4 {
+		sub := call(time.Duration(i)*time.Second, "", "Grep", `{"pattern":"x"}`)
+		sub.AgentID = "a1"
+		d.Add(sub)
+	}
+	if w := d.Warnings("s1"); w["a1"] == "" {
+		t.Fatalf("Warnings = %v, want one for a1", w)
+	}
+	d.Add(parser.StreamItem{Type: parser.TypeTurnMarker, SessionID: "s1", Timestamp: t0.Add(time.Minute)})
+	if w := d.Warnings("s1"); len(w) != 0 {
+		t.Errorf("Warnings after turn end = %v", w)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	d := NewDetector(Thresholds{})
+	for i := range 50 {
+		if a := d.Add(call(time.Duration(i)*time.Minute, "", "Bash", `{"command":"x"}`)); a != nil {
+			t.Fatalf("disabled detector alerted: %+v", a)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package mcp is a minimal Model Context Protocol server: JSON-RPC 2.0 over
+// stdio, one message per line, offering tools only. It is just enough for
+// MCP clients (Claude Code included) to call claude-esp's session tools.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// ProtocolVersion is the MCP revision this server implements. Clients asking
+// for another supported revision get that one back.
+const ProtocolVersion = "2025-06-18"
+
+var supportedVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessage bounds a single incoming message.
+const maxMessage = 4 * 1024 * 1024
+
+// Tool is one callable tool. Handler gets the raw arguments object and
+// returns text for the model; an error is reported to the model as a tool
+// error, not a protocol error, so it can correct its call.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema json.RawMessage // JSON Schema for the arguments object
+	Handler     func(args json.RawMessage) (string, error)
+}
+
+// Server answers MCP requests with a fixed set of tools.
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+
+	mu  sync.Mutex // serializes writes
+	enc *json.Encoder
+}
+
+// NewServer creates a server that identifies itself as name/version.
+func NewServer(name, version string, tools []Tool) *Server {
+	return &Server{name: name, version: version, tools: tools}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from r and writes responses to w until r is
+// exhausted. Notifications (requests without an ID) get no response.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.enc = json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessage)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, &rpcError{codeParseError, "parse error: " + err.Error()})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if req.ID != nil {
+				s.reply(req.ID, nil, &rpcError{codeInvalidRequest, "invalid request"})
+			}
+			continue
+		}
+		result, rerr := s.handle(req)
+		if req.ID == nil {
+			continue
+		}
+		s.reply(req.ID, result, rerr)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rerr *rpcError) {
+	resp := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		resp.Result = result
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(resp)
+}
+
+func (s *Server) handle(req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &p)
+		version := ProtocolVersion
+		if slices.Contains(supportedVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		type toolInfo struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			InputSchema json.RawMessage `json:"inputSchema"`
+		}
+		tools := make([]toolInfo, len(s.tools))
+		for i, t := range s.tools {
+			tools[i] = toolInfo{t.Name, t.Description, t.InputSchema}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid params: " + err.Error()}
+		}
+		i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == p.Name })
+		if i < 0 {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", p.Name)}
+		}
+		if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+			p.Arguments = json.RawMessage("{}")
+		}
+		text, err := s.tools[i].Handler(p.Arguments)
+		isError := err != nil
+		if isError {
+			text = err.Error()
+		}
+		return map[string]any{
+			"content": []map[string]string{{"type": "text", "text": text}},
+			"isError": isError,
+		}, nil
+	}
+	if req.ID == nil {
+		return nil, nil // unknown notifications (e.g. notifications/initialized) are fine
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// roundTrip feeds requests to a server with one echo tool and returns the
+// decoded responses.
+func roundTrip(t *testing.T, requests ...string) []map[string]any {
+	t.Helper()
+	echo := Tool{
+		Name:        "echo",
+		InputSchema: json.RawMessage(`{"type":"object"}`),
+		Handler: func(args json.RawMessage) (string, error) {
+			var a struct{ Fail bool }
+			json.Unmarshal(args, &a)
+			if a.Fail {
+				return "", errors.New("boom")
+			}
+			return string(args), nil
+		},
+	}
+	var out bytes.Buffer
+	srv := NewServer("test", "1.0", []Tool{echo})
+	if err := srv.Serve(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resps []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r map[string]any
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestInitializeNegotiatesVersion(t *testing.T) {
+	resps := roundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+	)
+	if len(resps) != 2 {
+		t.Fatalf("got %d responses, want 2 (notifications get none)", len(resps))
+	}
+	for i, want := range []string{"2024-11-05", ProtocolVersion} {
+		result := resps[i]["result"].(map[string]any)
+		if got := result["protocolVersion"]; got != want {
+			t.Errorf("response %d: protocolVersion = %v, want %s", i, got, want)
+		}
+	}
+}
+
+func TestToolsListAndCall(t *testing.T) {
+	resps := roundTrip(t,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"x":1}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"Fail":true}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope"}}`,
+	)
+	tools := resps[0]["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["name"] != "echo" {
+		t.Errorf("tools/list = %v", tools)
+	}
+
+	ok := resps[1]["result"].(map[string]any)
+	if text := ok["content"].([]any)[0].(map[string]any)["text"]; text != `{"x":1}` || ok["isError"] != false {
+		t.Errorf("call result = %v", ok)
+	}
+	failed := resps[2]["result"].(map[string]any)
+	if failed["isError"] != true {
+		t.Errorf("a handler error should be a tool error, got %v", resps[2])
+	}
+	if _, ok := resps[3]["error"]; !ok {
+		t.Errorf("an unknown tool should be a protocol error, got %v", resps[3])
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	resps := roundTrip(t,
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":7,"method":"ping"}`,
+	)
+	codes := []float64{codeParseError, codeMethodNotFound}
+	for i, want := range codes {
+		e, ok := resps[i]["error"].(map[string]any)
+		if !ok || e["code"] != want {
+			t.Errorf("response %d = %v, want error %v", i, resps[i], want)
+		}
+	}
+	if resps[1]["id"] != "a" {
+		t.Errorf("string IDs must be echoed, got %v", resps[1]["id"])
+	}
+	if _, ok := resps[2]["result"]; !ok {
+		t.Errorf("ping = %v, want an empty result", resps[2])
+	}
+}
@@ -0,0 +1,386 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/cost"
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// Defaults and caps for tool arguments. Tool output lands in a model's
+// context, so everything is bounded.
+const (
+	defaultSessions  = 20
+	defaultActivity  = 40
+	defaultMatches   = 50
+	maxResults       = 500
+	maxContentRunes  = 400 // per item in get_recent_activity
+	searchedSessions = 20  // sessions search_history scans without session_id
+)
+
+// Source is where the tools read sessions from.
+type Source interface {
+	// Sessions returns up to limit sessions (0 = all), most recent first.
+	Sessions(limit int) ([]watcher.SessionInfo, error)
+	// Items returns every item of a session, oldest first.
+	Items(s watcher.SessionInfo) ([]parser.StreamItem, error)
+}
+
+// DiskSource reads Claude Code's transcripts under CLAUDE_HOME.
+type DiskSource struct{}
+
+func (DiskSource) Sessions(limit int) ([]watcher.SessionInfo, error) {
+	return watcher.ListSessions(limit)
+}
+
+func (DiskSource) Items(s watcher.SessionInfo) ([]parser.StreamItem, error) {
+	return watcher.ReadSession(s)
+}
+
+// Tools returns claude-esp's session observability tools.
+func Tools(src Source, prices *cost.Table) []Tool {
+	t := &tools{src: src, prices: prices}
+	return []Tool{
+		{
+			Name:        "list_sessions",
+			Description: "List recent Claude Code sessions on this machine, most recent first, with project path, last activity and whether they are active.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+				`"limit":{"type":"integer","description":"Max sessions (default 20)"},` +
+				`"active_within":{"type":"string","description":"Only sessions modified within this duration, e.g. 10m or 2h"}}}`),
+			Handler: t.listSessions,
+		},
+		{
+			Name:        "get_recent_activity",
+			Description: "Show the most recent stream items (thinking, tool calls, tool output, text) of a session, including its subagents, one line per content line.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+				`"session_id":{"type":"string","description":"Session ID or prefix (default: most recent session)"},` +
+				`"limit":{"type":"integer","description":"Max items (default 40)"},` +
+				`"types":{"type":"array","items":{"type":"string"},"description":"Only these item types, e.g. thinking, tool_input, tool_output, text"},` +
+				`"agent":{"type":"string","description":"Only this agent: Main, an agent name, or an agent ID prefix"}}}`),
+			Handler: t.recentActivity,
+		},
+		{
+			Name:        "search_history",
+			Description: "Case-insensitive text search over session transcripts (thinking, tool calls and output, text). Returns matching lines with time, session, agent and item type.",
+			InputSchema: json.RawMessage(`{"type":"object","required":["query"],"properties":{` +
+				`"query":{"type":"string","description":"Text to find"},` +
+				`"session_id":{"type":"string","description":"Session ID or prefix (default: the 20 most recent sessions)"},` +
+				`"type":{"type":"string","description":"Only this item type, e.g. tool_input"},` +
+				`"limit":{"type":"integer","description":"Max matching lines (default 50)"}}}`),
+			Handler: t.searchHistory,
+		},
+		{
+			Name:        "get_session_stats",
+			Description: "Summarize a session: duration, token usage and estimated cost per agent, tool call counts, tool errors and subagent Tasks spawned.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+				`"session_id":{"type":"string","description":"Session ID or prefix (default: most recent session)"}}}`),
+			Handler: t.sessionStats,
+		},
+	}
+}
+
+type tools struct {
+	src    Source
+	prices *cost.Table
+}
+
+// clamp applies a default and the global cap to a limit argument.
+func clamp(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return min(n, maxResults)
+}
+
+// session resolves an ID prefix, or the most recent session if prefix is "".
+func (t *tools) session(prefix string) (watcher.SessionInfo, error) {
+	sessions, err := t.src.Sessions(0)
+	if err != nil {
+		return watcher.SessionInfo{}, err
+	}
+	for _, s := range sessions {
+		if strings.HasPrefix(s.ID, prefix) {
+			return s, nil
+		}
+	}
+	if prefix == "" {
+		return watcher.SessionInfo{}, errors.New("no sessions found")
+	}
+	return watcher.SessionInfo{}, fmt.Errorf("session %s not found", prefix)
+}
+
+func (t *tools) listSessions(raw json.RawMessage) (string, error) {
+	var args struct {
+		Limit        int    `json:"limit"`
+		ActiveWithin string `json:"active_within"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	var within time.Duration
+	if args.ActiveWithin != "" {
+		d, err := time.ParseDuration(args.ActiveWithin)
+		if err != nil {
+			return "", fmt.Errorf("active_within: %w", err)
+		}
+		within = d
+	}
+	sessions, err := t.src.Sessions(0)
+	if err != nil {
+		return "", err
+	}
+	type entry struct {
+		ID       string    `json:"id"`
+		Project  string    `json:"project"`
+		Modified time.Time `json:"modified"`
+		Active   bool      `json:"active"`
+	}
+	out := []entry{}
+	for _, s := range sessions {
+		if within > 0 && time.Since(s.Modified) > within {
+			continue
+		}
+		out = append(out, entry{s.ID, s.ProjectPath, s.Modified, s.IsActive})
+		if len(out) == clamp(args.Limit, defaultSessions) {
+			break
+		}
+	}
+	return marshal(out)
+}
+
+func (t *tools) recentActivity(raw json.RawMessage) (string, error) {
+	var args struct {
+		SessionID string   `json:"session_id"`
+		Limit     int      `json:"limit"`
+		Types     []string `json:"types"`
+		Agent     string   `json:"agent"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	s, err := t.session(args.SessionID)
+	if err != nil {
+		return "", err
+	}
+	items, err := t.src.Items(s)
+	if err != nil {
+		return "", err
+	}
+	var picked []parser.StreamItem
+	for _, it := range items {
+		if len(args.Types) > 0 && !slices.Contains(args.Types, string(it.Type)) {
+			continue
+		}
+		if args.Agent != "" && !matchAgent(it, args.Agent) {
+			continue
+		}
+		picked = append(picked, it)
+	}
+	if n := clamp(args.Limit, defaultActivity); len(picked) > n {
+		picked = picked[len(picked)-n:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Session %s (%s), last activity %s\n", s.ID, s.ProjectPath, s.Modified.Format(time.RFC3339))
+	if len(picked) == 0 {
+		b.WriteString("No matching activity.\n")
+	}
+	for _, it := range picked {
+		it.Content = truncateRunes(it.Content, maxContentRunes)
+		for _, line := range export.PlainLines(it) {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func matchAgent(it parser.StreamItem, agent string) bool {
+	if strings.EqualFold(agent, "main") {
+		return it.AgentID == ""
+	}
+	return strings.EqualFold(it.AgentName, agent) ||
+		(it.AgentID != "" && strings.HasPrefix(it.AgentID, agent))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + fmt.Sprintf("… [%d more chars]", len(r)-n)
+}
+
+func (t *tools) searchHistory(raw json.RawMessage) (string, error) {
+	var args struct {
+		Query     string `json:"query"`
+		SessionID string `json:"session_id"`
+		Type      string `json:"type"`
+		Limit     int    `json:"limit"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return "", errors.New("query is required")
+	}
+	var sessions []watcher.SessionInfo
+	if args.SessionID != "" {
+		s, err := t.session(args.SessionID)
+		if err != nil {
+			return "", err
+		}
+		sessions = []watcher.SessionInfo{s}
+	} else {
+		var err error
+		if sessions, err = t.src.Sessions(searchedSessions); err != nil {
+			return "", err
+		}
+	}
+
+	limit := clamp(args.Limit, defaultMatches)
+	query := strings.ToLower(args.Query)
+	var matches []string
+search:
+	for _, s := range sessions {
+		items, err := t.src.Items(s)
+		if err != nil {
+			continue
+		}
+		for _, it := range items {
+			if args.Type != "" && string(it.Type) != args.Type {
+				continue
+			}
+			if !strings.Contains(strings.ToLower(it.Content), query) {
+				continue
+			}
+			for _, line := range export.PlainLines(it) {
+				if !strings.Contains(strings.ToLower(line), query) {
+					continue
+				}
+				matches = append(matches, line)
+				if len(matches) == limit {
+					break search
+				}
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("No matches for %q in %d session(s).", args.Query, len(sessions)), nil
+	}
+	return strings.Join(matches, "\n") + "\n", nil
+}
+
+type agentStats struct {
+	Name                string  `json:"name"`
+	ID                  string  `json:"id,omitempty"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+	ToolCalls           int     `json:"tool_calls"`
+}
+
+type sessionStats struct {
+	SessionID    string         `json:"session_id"`
+	Project      string         `json:"project"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Duration     string         `json:"duration"`
+	Items        int            `json:"items"`
+	CostUSD      float64        `json:"cost_usd"`
+	Agents       []*agentStats  `json:"agents"`
+	Tools        map[string]int `json:"tools"`
+	ToolErrors   int            `json:"tool_errors"`
+	TasksSpawned int            `json:"tasks_spawned"`
+	Models       []string       `json:"models,omitempty"`
+}
+
+func (t *tools) sessionStats(raw json.RawMessage) (string, error) {
+	var args struct {
+		SessionID string `json:"session_id"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	s, err := t.session(args.SessionID)
+	if err != nil {
+		return "", err
+	}
+	items, err := t.src.Items(s)
+	if err != nil {
+		return "", err
+	}
+
+	st := sessionStats{SessionID: s.ID, Project: s.ProjectPath, Items: len(items), Tools: map[string]int{}}
+	agents := map[string]*agentStats{}
+	for _, it := range items {
+		if !it.Timestamp.IsZero() {
+			if st.Start.IsZero() || it.Timestamp.Before(st.Start) {
+				st.Start = it.Timestamp
+			}
+			if it.Timestamp.After(st.End) {
+				st.End = it.Timestamp
+			}
+		}
+		a := agents[it.AgentID]
+		if a == nil {
+			a = &agentStats{Name: it.AgentName, ID: it.AgentID}
+			if a.Name == "" {
+				a.Name = "Main"
+			}
+			agents[it.AgentID] = a
+			st.Agents = append(st.Agents, a)
+		}
+		a.InputTokens += it.InputTokens
+		a.OutputTokens += it.OutputTokens
+		a.CacheCreationTokens += it.CacheCreationTokens
+		a.CacheReadTokens += it.CacheReadTokens
+		usd := t.prices.Estimate(it.Model, cost.Usage{
+			InputTokens:         it.InputTokens,
+			OutputTokens:        it.OutputTokens,
+			CacheCreationTokens: it.CacheCreationTokens,
+			CacheReadTokens:     it.CacheReadTokens,
+		})
+		a.CostUSD += usd
+		st.CostUSD += usd
+		if it.Model != "" && !slices.Contains(st.Models, it.Model) {
+			st.Models = append(st.Models, it.Model)
+		}
+		switch it.Type {
+		case parser.TypeToolInput:
+			a.ToolCalls++
+			st.Tools[it.ToolName]++
+			if it.ToolName == "Task" || it.ToolName == "Agent" {
+				st.TasksSpawned++
+			}
+		case parser.TypeToolOutput:
+			if it.IsError {
+				st.ToolErrors++
+			}
+		}
+	}
+	if !st.Start.IsZero() {
+		st.Duration = st.End.Sub(st.Start).Round(time.Second).String()
+	}
+	sort.SliceStable(st.Agents, func(i, j int) bool {
+		return st.Agents[i].CostUSD > st.Agents[j].CostUSD
+	})
+	return marshal(st)
+}
+
+func marshal(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/cost"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+var t0 = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeSource map[string][]parser.StreamItem
+
+func (f fakeSource) Sessions(limit int) ([]watcher.SessionInfo, error) {
+	// "s1-newer" is the most recent.
+	out := []watcher.SessionInfo{
+		{ID: "s1-newer", ProjectPath: "/src/app", Modified: time.Now()},
+		{ID: "s2-older", ProjectPath: "/src/lib", Modified: time.Now().Add(-48 * time.Hour)},
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (f fakeSource) Items(s watcher.SessionInfo) ([]parser.StreamItem, error) {
+	return f[s.ID], nil
+}
+
+func testTools() map[string]Tool {
+	src := fakeSource{
+		"s1-newer": {
+			{Type: parser.TypeThinking, SessionID: "s1-newer", AgentName: "Main", Timestamp: t0, Content: "plan the fix",
+				Model: "claude-sonnet-4-5", InputTokens: 1000, OutputTokens: 100},
+			{Type: parser.TypeToolInput, SessionID: "s1-newer", AgentName: "Main", Timestamp: t0.Add(time.Second), ToolName: "Task", ToolID: "t1", Content: "explore"},
+			{Type: parser.TypeToolInput, SessionID: "s1-newer", AgentID: "abc123", AgentName: "Explore", Timestamp: t0.Add(2 * time.Second), ToolName: "Bash", ToolID: "t2", Content: "go test ./..."},
+			{Type: parser.TypeToolOutput, SessionID: "s1-newer", AgentID: "abc123", AgentName: "Explore", Timestamp: t0.Add(3 * time.Second), ToolID: "t2", Content: "FAIL\nok", IsError: true},
+		},
+		"s2-older": {
+			{Type: parser.TypeText, SessionID: "s2-older", AgentName: "Main", Timestamp: t0, Content: "the old FAIL is fixed"},
+		},
+	}
+	byName := map[string]Tool{}
+	for _, tool := range Tools(src, cost.DefaultTable()) {
+		byName[tool.Name] = tool
+	}
+	return byName
+}
+
+func call(t *testing.T, name, args string) string {
+	t.Helper()
+	out, err := testTools()[name].Handler(json.RawMessage(args))
+	if err != nil {
+		t.Fatalf("%s(%s): %v", name, args, err)
+	}
+	return out
+}
+
+func TestToolSchemasAreValidJSON(t *testing.T) {
+	for name, tool := range testTools() {
+		if !json.Valid(tool.InputSchema) {
+			t.Errorf("%s: invalid input schema", name)
+		}
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	var got []struct{ ID string }
+	json.Unmarshal([]byte(call(t, "list_sessions", `{"active_within":"1h"}`)), &got)
+	if len(got) != 1 || got[0].ID != "s1-newer" {
+		t.Errorf("active_within=1h: got %+v", got)
+	}
+	if _, err := testTools()["list_sessions"].Handler(json.RawMessage(`{"active_within":"soon"}`)); err == nil {
+		t.Error("expected an error for a bad duration")
+	}
+}
+
+func TestRecentActivity(t *testing.T) {
+	out := call(t, "get_recent_activity", `{"limit":2}`)
+	if !strings.Contains(out, "s1-newer") || strings.Contains(out, "plan the fix") {
+		t.Errorf("limit 2 should show only the last two items of the newest session:\n%s", out)
+	}
+	out = call(t, "get_recent_activity", `{"agent":"main","types":["tool_input"]}`)
+	if !strings.Contains(out, "Task: explore") || strings.Contains(out, "go test") {
+		t.Errorf("agent/type filters not applied:\n%s", out)
+	}
+	out = call(t, "get_recent_activity", `{"session_id":"s2"}`)
+	if !strings.Contains(out, "the old FAIL is fixed") {
+		t.Errorf("session prefix not resolved:\n%s", out)
+	}
+}
+
+func TestSearchHistory(t *testing.T) {
+	out := call(t, "search_history", `{"query":"fail"}`)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want one matching line per session, got:\n%s", out)
+	}
+	if strings.Contains(out, " ok") {
+		t.Errorf("non-matching lines of a matching item should be left out:\n%s", out)
+	}
+	out = call(t, "search_history", `{"query":"fail","type":"text"}`)
+	if strings.Count(out, "\n") != 1 || !strings.Contains(out, "old FAIL") {
+		t.Errorf("type filter not applied:\n%s", out)
+	}
+	if _, err := testTools()["search_history"].Handler(json.RawMessage(`{}`)); err == nil {
+		t.Error("expected an error without a query")
+	}
+}
+
+func TestSessionStats(t *testing.T) {
+	var st sessionStats
+	if err := json.Unmarshal([]byte(call(t, "get_session_stats", `{}`)), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.SessionID != "s1-newer" || st.Duration != "3s" || st.Items != 4 {
+		t.Errorf("summary = %+v", st)
+	}
+	if st.Tools["Task"] != 1 || st.Tools["Bash"] != 1 || st.ToolErrors != 1 || st.TasksSpawned != 1 {
+		t.Errorf("tool counts = %v errors=%d tasks=%d", st.Tools, st.ToolErrors, st.TasksSpawned)
+	}
+	if len(st.Agents) != 2 || st.Agents[0].Name != "Main" || st.Agents[0].CostUSD <= 0 {
+		t.Errorf("agents = %+v", st.Agents)
+	}
+}
@@ -0,0 +1,87 @@
+// Package modelswitch notices when an agent's model or thinking settings
+// change mid-conversation (/model, a fallback model, "ultrathink"), which
+// changes how it behaves and what it costs, and emits a TypeModelSwitch
+// item saying so.
+package modelswitch
+
+import (
+	"fmt"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// What changed, the ToolName of TypeModelSwitch items.
+const (
+	Model    = "model"
+	Thinking = "thinking"
+)
+
+type agentKey struct{ session, agent string }
+
+type settings struct{ model, thinking string }
+
+// Detector follows each agent's model and thinking settings. It is not
+// safe for concurrent use.
+type Detector struct {
+	agents map[agentKey]*settings
+}
+
+// New creates a detector.
+func New() *Detector {
+	return &Detector{agents: make(map[agentKey]*settings)}
+}
+
+// Add records item's model (assistant messages) or thinking settings
+// (TypeThinkingConfig) and returns a TypeModelSwitch item when they differ
+// from the agent's previous ones. The first of each is not a switch.
+func (d *Detector) Add(item parser.StreamItem) (parser.StreamItem, bool) {
+	var what, value string
+	switch {
+	case item.Type == parser.TypeThinkingConfig:
+		what, value = Thinking, item.Content
+	case item.Model != "":
+		what, value = Model, item.Model
+	default:
+		return parser.StreamItem{}, false
+	}
+	k := agentKey{item.SessionID, item.AgentID}
+	s := d.agents[k]
+	if s == nil {
+		s = &settings{}
+		d.agents[k] = s
+	}
+	prev := &s.model
+	if what == Thinking {
+		prev = &s.thinking
+	}
+	old := *prev
+	*prev = value
+	if old == "" || old == value {
+		return parser.StreamItem{}, false
+	}
+	return parser.StreamItem{
+		Type:      parser.TypeModelSwitch,
+		SessionID: item.SessionID,
+		AgentID:   item.AgentID,
+		AgentName: item.AgentName,
+		Timestamp: item.Timestamp,
+		ToolName:  what,
+		Content:   fmt.Sprintf("%s %s → %s", what, old, value),
+		Host:      item.Host,
+	}, true
+}
+
+// Insert returns items with a TypeModelSwitch item before each item that
+// switched its agent's settings, for transcripts read whole (exports).
+// Items must be in order.
+func Insert(items []parser.StreamItem) []parser.StreamItem {
+	d := New()
+	out := make([]parser.StreamItem, 0, len(items))
+	for _, item := range items {
+		if sw, ok := d.Add(item); ok {
+			out = append(out, sw)
+		}
+		out = append(out, item)
+	}
+	return out
+}
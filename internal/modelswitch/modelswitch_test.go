@@ -0,0 +1,53 @@
+package modelswitch
+
+import (
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func reply(session, agent, model string) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeText, SessionID: session, AgentID: agent, AgentName: "Main", Model: model}
+}
+
+func thinking(session, setting string) parser.StreamItem {
+	return parser.StreamItem{Type: parser.TypeThinkingConfig, SessionID: session, Content: setting}
+}
+
+func TestDetector(t *testing.T) {
+	d := New()
+	for i, tc := range []struct {
+		item parser.StreamItem
+		want string // "" = no switch
+	}{
+		{reply("s1", "", "claude-sonnet-4-5"), ""},
+		{parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1"}, ""},
+		{reply("s1", "", "claude-sonnet-4-5"), ""},
+		{reply("s1", "a1", "claude-haiku-4-5"), ""}, // a subagent's own model
+		{reply("s2", "", "claude-opus-4-7"), ""},
+		{reply("s1", "", "claude-opus-4-7"), "model claude-sonnet-4-5 → claude-opus-4-7"},
+		{thinking("s1", "high"), ""},
+		{thinking("s1", "high"), ""},
+		{thinking("s1", "off"), "thinking high → off"},
+		{reply("s1", "a1", "claude-haiku-4-5"), ""},
+	} {
+		sw, ok := d.Add(tc.item)
+		if ok != (tc.want != "") || sw.Content != tc.want {
+			t.Errorf("%d: switch %v %q, want %q", i, ok, sw.Content, tc.want)
+			continue
+		}
+		if ok && (sw.Type != parser.TypeModelSwitch || sw.SessionID != tc.item.SessionID) {
+			t.Errorf("%d: switch item %+v", i, sw)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	items := Insert([]parser.StreamItem{
+		reply("s1", "", "claude-sonnet-4-5"),
+		reply("s1", "", "claude-opus-4-7"),
+	})
+	if len(items) != 3 || items[1].Type != parser.TypeModelSwitch || items[1].ToolName != Model {
+		t.Errorf("items = %+v", items)
+	}
+}
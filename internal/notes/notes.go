@@ -0,0 +1,178 @@
+// Package notes stores freeform annotations on sessions and stream items in
+// per-session sidecar files, so a reviewed session keeps its notes across
+// runs without touching Claude Code's own transcripts.
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Note is one annotation.
+type Note struct {
+	Text    string    `json:"text"`
+	Updated time.Time `json:"updated"`
+}
+
+// File is the sidecar for one session: <dir>/<session-id>.json.
+type File struct {
+	SessionID string          `json:"session_id"`
+	Session   *Note           `json:"session,omitempty"` // note on the session as a whole
+	Items     map[string]Note `json:"items,omitempty"`   // keyed by ItemKey
+}
+
+// Store reads and writes sidecar files under one directory. Files are loaded
+// lazily and cached. A nil *Store is valid and stores nothing.
+type Store struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*File
+}
+
+// New returns a store rooted at dir. The directory is created on first write.
+func New(dir string) *Store {
+	return &Store{dir: dir, files: make(map[string]*File)}
+}
+
+// ItemKey identifies a stream item stably across runs. Tool items are keyed
+// by their tool_use ID; everything else by agent, timestamp and type, which
+// is unique for all practical purposes within a transcript.
+func ItemKey(item parser.StreamItem) string {
+	if item.ToolID != "" {
+		return fmt.Sprintf("%s:%s", item.Type, item.ToolID)
+	}
+	agent := item.AgentID
+	if agent == "" {
+		agent = "main"
+	}
+	return fmt.Sprintf("%s@%s:%s", agent, item.Timestamp.UTC().Format(time.RFC3339Nano), item.Type)
+}
+
+// Session returns the session note, or "".
+func (s *Store) Session(sessionID string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.load(sessionID); f.Session != nil {
+		return f.Session.Text
+	}
+	return ""
+}
+
+// Item returns the note on item, or "".
+func (s *Store) Item(item parser.StreamItem) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(item.SessionID).Items[ItemKey(item)].Text
+}
+
+// SetSession sets (or, with blank text, clears) the session note and saves.
+func (s *Store) SetSession(sessionID, text string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.load(sessionID)
+	if text = strings.TrimSpace(text); text == "" {
+		f.Session = nil
+	} else {
+		f.Session = &Note{Text: text, Updated: time.Now()}
+	}
+	return s.save(f)
+}
+
+// SetItem sets (or, with blank text, clears) the note on item and saves.
+func (s *Store) SetItem(item parser.StreamItem, text string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.load(item.SessionID)
+	key := ItemKey(item)
+	if text = strings.TrimSpace(text); text == "" {
+		delete(f.Items, key)
+	} else {
+		if f.Items == nil {
+			f.Items = make(map[string]Note)
+		}
+		f.Items[key] = Note{Text: text, Updated: time.Now()}
+	}
+	return s.save(f)
+}
+
+// File returns a copy of the session's sidecar contents (for exports).
+func (s *Store) File(sessionID string) File {
+	if s == nil {
+		return File{SessionID: sessionID}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := *s.load(sessionID)
+	items := make(map[string]Note, len(f.Items))
+	for k, v := range f.Items {
+		items[k] = v
+	}
+	f.Items = items
+	return f
+}
+
+func (s *Store) path(sessionID string) string {
+	// Session IDs are UUIDs; guard against anything path-like regardless.
+	return filepath.Join(s.dir, filepath.Base(sessionID)+".json")
+}
+
+// load returns the cached file, reading it from disk on first use. A missing
+// or unreadable sidecar yields an empty file. Caller holds s.mu.
+func (s *Store) load(sessionID string) *File {
+	if f, ok := s.files[sessionID]; ok {
+		return f
+	}
+	f := &File{SessionID: sessionID}
+	if data, err := os.ReadFile(s.path(sessionID)); err == nil {
+		if err := json.Unmarshal(data, f); err != nil {
+			f = &File{SessionID: sessionID}
+		}
+	}
+	s.files[sessionID] = f
+	return f
+}
+
+// save writes f atomically, removing the sidecar once it holds no notes.
+// Caller holds s.mu.
+func (s *Store) save(f *File) error {
+	path := s.path(f.SessionID)
+	if f.Session == nil && len(f.Items) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create notes dir: %w", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
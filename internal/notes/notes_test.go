@@ -0,0 +1,73 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestItemKeyStable(t *testing.T) {
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 500, time.FixedZone("x", 3600))
+	text := parser.StreamItem{Type: parser.TypeText, SessionID: "s", Timestamp: ts}
+	if got, want := ItemKey(text), "main@2025-01-01T11:00:00.0000005Z:text"; got != want {
+		t.Errorf("ItemKey = %q, want %q", got, want)
+	}
+	tool := parser.StreamItem{Type: parser.TypeToolInput, AgentID: "a1", ToolID: "toolu_1", Timestamp: ts}
+	if got, want := ItemKey(tool), "tool_input:toolu_1"; got != want {
+		t.Errorf("ItemKey = %q, want %q", got, want)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "notes")
+	item := parser.StreamItem{Type: parser.TypeThinking, SessionID: "s1", Timestamp: time.Now()}
+
+	s := New(dir)
+	if err := s.SetItem(item, "  this is where it went wrong "); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSession("s1", "auth refactor postmortem"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh store reads the sidecar back from disk.
+	s2 := New(dir)
+	if got := s2.Item(item); got != "this is where it went wrong" {
+		t.Errorf("Item = %q", got)
+	}
+	if got := s2.Session("s1"); got != "auth refactor postmortem" {
+		t.Errorf("Session = %q", got)
+	}
+	if f := s2.File("s1"); len(f.Items) != 1 || f.Session == nil {
+		t.Errorf("File = %+v", f)
+	}
+}
+
+func TestStoreClearingRemovesSidecar(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	s.SetSession("s1", "note")
+	path := filepath.Join(dir, "s1.json")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("sidecar not written: %v", err)
+	}
+	if err := s.SetSession("s1", " "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("empty sidecar should be removed, stat err = %v", err)
+	}
+}
+
+func TestNilStoreIsNoop(t *testing.T) {
+	var s *Store
+	if s.Session("x") != "" || s.Item(parser.StreamItem{}) != "" {
+		t.Error("nil store should return no notes")
+	}
+	if err := s.SetSession("x", "y"); err != nil {
+		t.Error(err)
+	}
+}
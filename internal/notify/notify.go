@@ -0,0 +1,117 @@
+// Package notify runs the user's notification hook for claude-esp events
+// (budget thresholds and the like), and can also show them as terminal
+// notifications.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/osc"
+	"github.com/phiat/claude-esp/internal/shell"
+)
+
+// HookTimeout bounds how long a single hook invocation may run.
+const HookTimeout = 30 * time.Second
+
+// Event is one notification. Kind is a stable machine-readable name
+// ("budget", "loop", "long_running", "session", ...); Title/Message are
+// human-readable. Link, filled in by Send for events about a session, is
+// the deeplink.Command that opens it at Time.
+type Event struct {
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	SessionID string    `json:"session_id,omitempty"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	Time      time.Time `json:"time"`
+	Link      string    `json:"link,omitempty"`
+}
+
+// Notifier dispatches events to a shell command and, if set, the terminal.
+// A Notifier with neither is a no-op, so callers never need to nil-check.
+type Notifier struct {
+	command string
+
+	mu   sync.Mutex // serializes terminal writes
+	term io.Writer  // nil = no terminal notifications
+	env  osc.Env
+}
+
+// New creates a Notifier for the given shell command ("" disables it).
+func New(command string) *Notifier {
+	return &Notifier{command: command}
+}
+
+// SetTerminal also shows every event as a terminal notification (OSC 9 or
+// OSC 777, picked from env) written to w.
+func (n *Notifier) SetTerminal(w io.Writer, env osc.Env) {
+	n.term, n.env = w, env
+}
+
+// Enabled reports whether a hook command or terminal is configured.
+func (n *Notifier) Enabled() bool {
+	return n != nil && (n.command != "" || n.term != nil)
+}
+
+// Send writes the terminal notification, then runs the hook asynchronously.
+// The event is written to the command's stdin as JSON and exposed as
+// ESP_EVENT, ESP_TITLE, ESP_MESSAGE, ESP_SESSION, ESP_AGENT and ESP_LINK.
+// Failures are ignored: a broken hook must never disturb the TUI.
+func (n *Notifier) Send(ev Event) {
+	if !n.Enabled() {
+		return
+	}
+	ev = fill(ev)
+	if n.term != nil {
+		n.mu.Lock()
+		_, _ = io.WriteString(n.term, osc.Notification(n.env, ev.Title, ev.Message))
+		n.mu.Unlock()
+	}
+	if n.command == "" {
+		return
+	}
+	go func() {
+		if err := n.run(ev); err != nil {
+			slog.Warn("notification hook failed", "kind", ev.Kind, "err", err)
+		}
+	}()
+}
+
+// fill sets an event's Time to now and its Link, when they're unset.
+func fill(ev Event) Event {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	if ev.Link == "" && ev.SessionID != "" {
+		ev.Link = deeplink.Command(ev.SessionID, ev.Time)
+	}
+	return ev
+}
+
+func (n *Notifier) run(ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HookTimeout)
+	defer cancel()
+	cmd := shell.Command(ctx, n.command)
+	cmd.Env = append(os.Environ(),
+		"ESP_EVENT="+ev.Kind,
+		"ESP_TITLE="+ev.Title,
+		"ESP_MESSAGE="+ev.Message,
+		"ESP_SESSION="+ev.SessionID,
+		"ESP_AGENT="+ev.AgentID,
+		"ESP_LINK="+ev.Link,
+	)
+	cmd.Stdin = bytes.NewReader(payload)
+	return cmd.Run()
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/osc"
+)
+
+func TestDisabledNotifierIsNoop(t *testing.T) {
+	var nilNotifier *Notifier
+	if nilNotifier.Enabled() {
+		t.Error("nil notifier should be disabled")
+	}
+	nilNotifier.Send(Event{Kind: "x"}) // must not panic
+	if New("").Enabled() {
+		t.Error("empty command should be disabled")
+	}
+}
+
+func TestRunPassesEnvAndStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	envOut := filepath.Join(dir, "env")
+	stdinOut := filepath.Join(dir, "stdin")
+	n := New(`printf '%s|%s|%s' "$ESP_EVENT" "$ESP_MESSAGE" "$ESP_LINK" > ` + envOut + `; cat > ` + stdinOut)
+
+	at := time.Date(2025, 6, 1, 14, 3, 0, 0, time.UTC)
+	if err := n.run(fill(Event{Kind: "budget", Message: "80% used", SessionID: "s1", Time: at})); err != nil {
+		t.Fatal(err)
+	}
+
+	env, _ := os.ReadFile(envOut)
+	if string(env) != "budget|80% used|claude-esp -s s1 -at 2025-06-01T14:03:00Z" {
+		t.Errorf("env = %q", env)
+	}
+	var ev Event
+	data, _ := os.ReadFile(stdinOut)
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatalf("stdin not JSON: %v (%q)", err, data)
+	}
+	if ev.SessionID != "s1" {
+		t.Errorf("stdin event = %+v", ev)
+	}
+}
+
+func TestSendWritesTerminalNotification(t *testing.T) {
+	var buf strings.Builder
+	n := New("")
+	n.SetTerminal(&buf, osc.Env{Term: "xterm-256color"})
+	if !n.Enabled() {
+		t.Fatal("terminal-only notifier should be enabled")
+	}
+	n.Send(Event{Kind: "loop", Title: "claude-esp: Main may be looping", Message: "Bash x5"})
+	if want := "\x1b]9;claude-esp: Main may be looping: Bash x5\x1b\\"; buf.String() != want {
+		t.Errorf("wrote %q, want %q", buf.String(), want)
+	}
+}
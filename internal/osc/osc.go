@@ -0,0 +1,87 @@
+// Package osc builds the terminal escape sequences claude-esp uses beyond
+// drawing: desktop notifications (OSC 9 and OSC 777), setting the
+// clipboard (OSC 52) and the xterm window title stack. Inside tmux,
+// notifications are wrapped for passthrough.
+package osc
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+)
+
+const (
+	// PushTitle saves the window title on the terminal's title stack;
+	// PopTitle restores it. Terminals without a stack ignore both.
+	PushTitle = "\x1b[22;0t"
+	PopTitle  = "\x1b[23;0t"
+)
+
+// Env is what Notification looks at to pick a sequence; EnvFromOS reads
+// it from the process environment.
+type Env struct {
+	Term        string // $TERM
+	TermProgram string // $TERM_PROGRAM
+	VTE         bool   // $VTE_VERSION is set (GNOME Terminal, Tilix, ...)
+	Tmux        bool   // $TMUX is set
+}
+
+// EnvFromOS returns the current process's terminal environment.
+func EnvFromOS() Env {
+	return Env{
+		Term:        os.Getenv("TERM"),
+		TermProgram: os.Getenv("TERM_PROGRAM"),
+		VTE:         os.Getenv("VTE_VERSION") != "",
+		Tmux:        os.Getenv("TMUX") != "",
+	}
+}
+
+// Notification returns the sequence that shows a desktop notification.
+// urxvt, foot and VTE-based terminals understand OSC 777 with a separate
+// title; everything else that supports notifications at all (iTerm2,
+// WezTerm, kitty, Ghostty, Windows Terminal, ...) takes OSC 9 with a
+// single message. Terminals that support neither ignore it.
+func Notification(env Env, title, body string) string {
+	title, body = clean(title), clean(body)
+	var seq string
+	if env.VTE || strings.HasPrefix(env.Term, "rxvt") || strings.HasPrefix(env.Term, "foot") {
+		seq = "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body + "\x1b\\"
+	} else {
+		msg := body
+		if title != "" {
+			msg = title + ": " + body
+		}
+		seq = "\x1b]9;" + msg + "\x1b\\"
+	}
+	if env.Tmux {
+		return tmuxPassthrough(seq)
+	}
+	return seq
+}
+
+// Clipboard returns the sequence that puts text on the clipboard of the
+// terminal it reaches, even over SSH. tmux takes it itself with "set -g
+// set-clipboard on"; the text is base64, so it needs no cleaning.
+func Clipboard(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x1b\\"
+}
+
+// tmuxPassthrough wraps seq so tmux hands it to the outer terminal (with
+// "set -g allow-passthrough on").
+func tmuxPassthrough(seq string) string {
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// clean drops control characters, which would end the sequence early or
+// inject others.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			if r == '\n' || r == '\t' {
+				return ' '
+			}
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,34 @@
+package osc
+
+import "testing"
+
+func TestNotification(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Env
+		want string
+	}{
+		{"iTerm2", Env{TermProgram: "iTerm.app", Term: "xterm-256color"}, "\x1b]9;Budget: 80% of $5.00\x1b\\"},
+		{"urxvt", Env{Term: "rxvt-unicode-256color"}, "\x1b]777;notify;Budget;80% of $5.00\x1b\\"},
+		{"VTE", Env{Term: "xterm-256color", VTE: true}, "\x1b]777;notify;Budget;80% of $5.00\x1b\\"},
+		{"tmux", Env{Term: "tmux-256color", Tmux: true}, "\x1bPtmux;\x1b\x1b]9;Budget: 80% of $5.00\x1b\x1b\\\x1b\\"},
+	}
+	for _, tt := range tests {
+		if got := Notification(tt.env, "Budget", "80% of $5.00"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNotificationStripsControls(t *testing.T) {
+	got := Notification(Env{}, "", "rm -rf\x07\x1b]2;pwned\x1b\\ done\nnext")
+	if want := "\x1b]9;rm -rf]2;pwned\\ done next\x1b\\"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestClipboard(t *testing.T) {
+	if got, want := Clipboard("hi\x1b"), "\x1b]52;c;aGkb\x1b\\"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// API error kinds, the ToolName of TypeAPIError items.
+const (
+	APIRateLimited = "rate limited" // 429, or the account's usage limit
+	APIOverloaded  = "overloaded"   // 529
+	APIFailed      = "error"        // anything else: 5xx, connection errors, ...
+)
+
+// apiErrorDetail is the error on system.api_error lines: the HTTP status
+// and the API's error body, nested as {"error":{"type":"error","error":
+// {"type":"overloaded_error","message":"Overloaded"}}}, or for connection
+// failures a message and a cause.
+type apiErrorDetail struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Error   *apiErrorBody `json:"error"`
+	Cause   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"cause"`
+}
+
+type apiErrorBody struct {
+	Type    string        `json:"type"`
+	Message string        `json:"message"`
+	Error   *apiErrorBody `json:"error"`
+}
+
+// innermost returns the deepest error type and message in the body.
+func (b *apiErrorBody) innermost() (typ, msg string) {
+	for ; b != nil; b = b.Error {
+		if b.Type != "" && b.Type != "error" {
+			typ = b.Type
+		}
+		if b.Message != "" {
+			msg = b.Message
+		}
+	}
+	return typ, msg
+}
+
+// apiErrorKind classifies an error by status, error type and text.
+func apiErrorKind(status int, typ, text string) string {
+	lower := strings.ToLower(typ + " " + text)
+	switch {
+	case status == 429 || strings.Contains(lower, "rate_limit") || strings.Contains(lower, "rate limit") ||
+		strings.Contains(lower, "usage limit"):
+		return APIRateLimited
+	case status == 529 || strings.Contains(lower, "overloaded"):
+		return APIOverloaded
+	}
+	return APIFailed
+}
+
+// parseAPIError turns a system.api_error line, written each time Claude
+// Code retries a failed API request, into a TypeAPIError item:
+// "529 overloaded_error: Overloaded · retry 2/10 in 1.2s".
+func parseAPIError(raw RawMessage, timestamp time.Time) []StreamItem {
+	var d apiErrorDetail
+	_ = json.Unmarshal(raw.Error, &d)
+	typ, msg := d.Error.innermost()
+	msg = cmp.Or(msg, d.Message)
+	if d.Cause != nil {
+		if msg == "" {
+			msg = d.Cause.Message
+		}
+		if d.Cause.Code != "" {
+			msg = strings.TrimSpace(msg + " (" + d.Cause.Code + ")")
+		}
+	}
+
+	var parts []string
+	if d.Status != 0 {
+		parts = append(parts, strconv.Itoa(d.Status))
+	}
+	switch {
+	case typ != "" && msg != "":
+		parts = append(parts, typ+": "+msg)
+	case typ != "" || msg != "":
+		parts = append(parts, typ+msg)
+	default:
+		parts = append(parts, "request failed")
+	}
+	content := strings.Join(parts, " ")
+	var retryAt time.Time
+	if raw.RetryAttempt > 0 {
+		retry := fmt.Sprintf("retry %d", raw.RetryAttempt)
+		if raw.MaxRetries > 0 {
+			retry += fmt.Sprintf("/%d", raw.MaxRetries)
+		}
+		if raw.RetryInMs > 0 {
+			wait := time.Duration(raw.RetryInMs) * time.Millisecond
+			retry += " in " + wait.Round(100*time.Millisecond).String()
+			retryAt = timestamp.Add(wait)
+		}
+		content += " · " + retry
+	}
+	return []StreamItem{{
+		Type:      TypeAPIError,
+		SessionID: raw.SessionID,
+		AgentID:   raw.AgentID,
+		AgentName: agentDisplayName(raw.AgentID),
+		Timestamp: timestamp,
+		ToolName:  apiErrorKind(d.Status, typ, msg),
+		Content:   content,
+		RetryAt:   retryAt,
+	}}
+}
+
+// apiErrorTextPattern matches the text of the message Claude Code writes
+// when it gives up on a request: "API Error: 529 {"type":"error",...}".
+var apiErrorTextPattern = regexp.MustCompile(`^API Error: (\d{3}) (\{.*\})\s*$`)
+
+// usageLimitPattern matches "Claude AI usage limit reached|1760000000",
+// the Unix time being when the limit resets.
+var usageLimitPattern = regexp.MustCompile(`^(.*\S)\s*\|(\d{9,})$`)
+
+// parseAPIErrorMessage turns the synthetic assistant message Claude Code
+// writes in place of a reply when retries ran out (isApiErrorMessage) into
+// a TypeAPIError item, or a TypeInterrupt item if the request was aborted.
+func parseAPIErrorMessage(raw RawMessage, timestamp time.Time) []StreamItem {
+	var msg AssistantMessage
+	if err := json.Unmarshal(raw.Message, &msg); err != nil {
+		return nil
+	}
+	var text []string
+	for _, block := range msg.Content {
+		if block.Type == "text" && block.Text != "" {
+			text = append(text, block.Text)
+		}
+	}
+	content := strings.TrimSpace(strings.Join(text, "\n"))
+	if isAborted(content) {
+		return []StreamItem{newInterrupt(raw, timestamp, InterruptAborted)}
+	}
+	status, typ := 0, ""
+	if m := apiErrorTextPattern.FindStringSubmatch(content); m != nil {
+		var body apiErrorBody
+		if json.Unmarshal([]byte(m[2]), &body) == nil {
+			var detail string
+			typ, detail = body.innermost()
+			status, _ = strconv.Atoi(m[1])
+			content = strings.TrimSpace(m[1] + " " + strings.Trim(typ+": "+detail, ": "))
+		}
+	} else if m := usageLimitPattern.FindStringSubmatch(content); m != nil {
+		if sec, err := strconv.ParseInt(m[2], 10, 64); err == nil {
+			content = fmt.Sprintf("%s (resets %s)", m[1], time.Unix(sec, 0).Local().Format("Jan 2 15:04"))
+		}
+	}
+	// Newer versions also name the failure in a top-level "error" string.
+	var errType string
+	_ = json.Unmarshal(raw.Error, &errType)
+	if content == "" {
+		content = cmp.Or(errType, "request failed")
+	}
+	return []StreamItem{{
+		Type:      TypeAPIError,
+		SessionID: raw.SessionID,
+		AgentID:   raw.AgentID,
+		AgentName: agentDisplayName(raw.AgentID),
+		Timestamp: timestamp,
+		ToolName:  apiErrorKind(status, typ+" "+errType, content),
+		Content:   content,
+	}}
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine_APIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		wantKind    string
+		wantContent string
+		wantRetry   time.Duration // after the line's timestamp; 0 = no RetryAt
+	}{
+		{
+			"overloaded retry",
+			`{"type":"system","subtype":"api_error","level":"error","error":{"status":529,"headers":{},"requestID":"req_1","error":{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}},"retryInMs":1180.5,"retryAttempt":2,"maxRetries":10,"timestamp":"2025-01-01T12:00:00Z","sessionId":"abc"}`,
+			APIOverloaded, "529 overloaded_error: Overloaded · retry 2/10 in 1.2s", 1180 * time.Millisecond,
+		},
+		{
+			"rate limited retry",
+			`{"type":"system","subtype":"api_error","error":{"status":429,"error":{"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your per-minute rate limit"}}},"retryInMs":30000,"retryAttempt":1,"maxRetries":10,"timestamp":"2025-01-01T12:00:00Z","sessionId":"abc"}`,
+			APIRateLimited, "429 rate_limit_error: Number of request tokens has exceeded your per-minute rate limit · retry 1/10 in 30s", 30 * time.Second,
+		},
+		{
+			"connection error",
+			`{"type":"system","subtype":"api_error","error":{"message":"Connection error.","cause":{"code":"ECONNRESET"}},"retryAttempt":3,"timestamp":"2025-01-01T12:00:00Z","sessionId":"abc"}`,
+			APIFailed, "Connection error. (ECONNRESET) · retry 3", 0,
+		},
+		{
+			"retries ran out",
+			`{"type":"assistant","isApiErrorMessage":true,"timestamp":"2025-01-01T12:00:00Z","sessionId":"abc","message":{"role":"assistant","model":"<synthetic>","content":[{"type":"text","text":"API Error: 529 {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}"}]}}`,
+			APIOverloaded, "529 overloaded_error: Overloaded", 0,
+		},
+		{
+			"usage limit",
+			`{"type":"assistant","isApiErrorMessage":true,"error":"rate_limit","timestamp":"2025-01-01T12:00:00Z","sessionId":"abc","message":{"role":"assistant","model":"<synthetic>","content":[{"type":"text","text":"Claude AI usage limit reached|1735740000"}]}}`,
+			APIRateLimited, "Claude AI usage limit reached (resets ", 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := ParseLine(tc.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != 1 || items[0].Type != TypeAPIError {
+				t.Fatalf("expected 1 api_error item, got %+v", items)
+			}
+			if items[0].ToolName != tc.wantKind {
+				t.Errorf("kind = %q, want %q", items[0].ToolName, tc.wantKind)
+			}
+			if !strings.HasPrefix(items[0].Content, tc.wantContent) {
+				t.Errorf("content = %q, want %q", items[0].Content, tc.wantContent)
+			}
+			var wantAt time.Time
+			if tc.wantRetry > 0 {
+				wantAt = items[0].Timestamp.Add(tc.wantRetry)
+			}
+			if !items[0].RetryAt.Equal(wantAt) {
+				t.Errorf("retry at %v, want %v", items[0].RetryAt, wantAt)
+			}
+			if items[0].SessionID != "abc" || items[0].AgentName != "Main" {
+				t.Errorf("session %q, agent %q", items[0].SessionID, items[0].AgentName)
+			}
+		})
+	}
+}
+
+func TestParseLine_ErrorFieldOnOtherLines(t *testing.T) {
+	// A string "error" on an ordinary line must not break its parsing.
+	line := `{"type":"assistant","error":"unknown","timestamp":"2025-01-01T12:00:00Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`
+	items, err := ParseLine(line)
+	if err != nil || len(items) != 1 || items[0].Type != TypeText {
+		t.Fatalf("items = %+v, err %v", items, err)
+	}
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+)
+
+const (
+	// MaxLineSize is the longest transcript line a Decoder parses (10MB).
+	// Lines can carry base64-encoded images (screenshots). A longer line
+	// is cut to this size, so it reads as malformed JSON.
+	MaxLineSize = 10 * 1024 * 1024
+
+	// decoderBufferSize is the Decoder's read buffer; longer lines are
+	// assembled from several reads.
+	decoderBufferSize = 64 * 1024
+)
+
+// Decoder reads a JSONL transcript from an io.Reader and parses it line by
+// line into stream items. It tracks how far it has got in bytes and lines,
+// so whoever tails a growing file, a pipe or a remote stream can resume
+// exactly where it stopped.
+type Decoder struct {
+	r      *bufio.Reader
+	follow bool
+	err    error
+
+	offset  int64 // bytes of complete lines consumed
+	lines   int   // complete lines consumed
+	skipped int   // lines that weren't JSON
+
+	// The line being read. When following, a final line with no newline
+	// yet stays here until the rest of it arrives.
+	buf     []byte
+	pending int64 // bytes read for buf, newline included
+	tooLong bool
+}
+
+// NewDecoder returns a Decoder reading from r. Like ParseLine it honors
+// DebugAll and Lenient.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReaderSize(r, decoderBufferSize)}
+}
+
+// Follow makes the Decoder treat the input as still being written: a final
+// line without a newline is held back rather than parsed, and Offset stays
+// at its start. Calling All again once r has more data carries on with it.
+func (d *Decoder) Follow() {
+	d.follow = true
+}
+
+// All returns an iterator over the items of every remaining line. It stops
+// at the end of the input or at a read error, which Err then reports.
+func (d *Decoder) All() iter.Seq[StreamItem] {
+	return func(yield func(StreamItem) bool) {
+		for {
+			line, ok := d.readLine()
+			if !ok {
+				return
+			}
+			items, _ := ParseLine(string(line))
+			if (len(items) == 0 || items[0].Type == TypeUnknown) && len(bytes.TrimSpace(line)) > 0 && !json.Valid(line) {
+				d.skipped++
+			}
+			for _, item := range items {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Err returns the first read error other than io.EOF.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Offset returns the number of bytes consumed from r, counting complete
+// lines only. Seek a file to its starting offset plus Offset to resume.
+func (d *Decoder) Offset() int64 {
+	return d.offset
+}
+
+// Line returns the number of lines consumed from r.
+func (d *Decoder) Line() int {
+	return d.lines
+}
+
+// Skipped returns the number of lines so far that weren't valid JSON:
+// interrupted writes and lines cut at MaxLineSize. In Lenient mode these
+// still produce a TypeUnknown item.
+func (d *Decoder) Skipped() int {
+	return d.skipped
+}
+
+// readLine returns the next line without its line ending. It returns false
+// at the end of the input, or of its complete lines when following.
+func (d *Decoder) readLine() ([]byte, bool) {
+	if d.err != nil {
+		return nil, false
+	}
+	for {
+		chunk, err := d.r.ReadSlice('\n')
+		d.pending += int64(len(chunk))
+		if room := MaxLineSize - len(d.buf); len(chunk) > room {
+			d.buf = append(d.buf, chunk[:room]...)
+			d.tooLong = true
+		} else {
+			d.buf = append(d.buf, chunk...)
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			if d.pending == 0 || d.follow {
+				return nil, false
+			}
+		default:
+			d.err = err
+			return nil, false
+		}
+
+		line := d.buf
+		if !d.tooLong {
+			line = bytes.TrimSuffix(line, []byte("\n"))
+			line = bytes.TrimSuffix(line, []byte("\r"))
+		}
+		d.offset += d.pending
+		d.lines++
+		d.buf, d.pending, d.tooLong = d.buf[:0], 0, false
+		if cap(d.buf) > decoderBufferSize {
+			d.buf = nil // don't pin a huge line's buffer
+		}
+		return line, true
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const thinkingLine = `{"type":"assistant","timestamp":"2025-01-01T12:00:00Z","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"}]}}`
+
+func collect(d *Decoder) []StreamItem {
+	return slices.Collect(d.All())
+}
+
+func TestDecoder(t *testing.T) {
+	setLenient(t, false)
+	input := thinkingLine + "\r\n\nnot json\n" + thinkingLine
+	d := NewDecoder(strings.NewReader(input))
+	items := collect(d)
+	if len(items) != 2 || items[0].Content != "hmm" {
+		t.Fatalf("items = %+v", items)
+	}
+	if d.Offset() != int64(len(input)) || d.Line() != 4 || d.Skipped() != 1 || d.Err() != nil {
+		t.Errorf("offset %d, line %d, skipped %d, err %v", d.Offset(), d.Line(), d.Skipped(), d.Err())
+	}
+}
+
+func TestDecoder_FollowHoldsPartialLine(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(thinkingLine + "\n" + thinkingLine[:40])
+	d := NewDecoder(&buf)
+	d.Follow()
+	if items := collect(d); len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if want := int64(len(thinkingLine) + 1); d.Offset() != want || d.Line() != 1 {
+		t.Errorf("offset %d, line %d; want %d, 1", d.Offset(), d.Line(), want)
+	}
+
+	// The rest of the line arrives.
+	buf.WriteString(thinkingLine[40:] + "\n")
+	if items := collect(d); len(items) != 1 || items[0].Content != "hmm" {
+		t.Fatalf("items = %+v", items)
+	}
+	if d.Offset() != int64(2*len(thinkingLine)+2) || d.Line() != 2 || d.Skipped() != 0 {
+		t.Errorf("offset %d, line %d, skipped %d", d.Offset(), d.Line(), d.Skipped())
+	}
+}
+
+func TestDecoder_LongLine(t *testing.T) {
+	setLenient(t, true)
+	long := `{"type":"user","message":{"content":"` + strings.Repeat("x", MaxLineSize) + `"}}`
+	d := NewDecoder(strings.NewReader(long + "\n" + thinkingLine + "\n"))
+	items := collect(d)
+	if len(items) != 2 || items[0].Type != TypeUnknown || items[1].Type != TypeThinking {
+		t.Fatalf("got %d items", len(items))
+	}
+	if d.Skipped() != 1 || d.Line() != 2 || d.Offset() != int64(len(long)+len(thinkingLine)+2) {
+		t.Errorf("offset %d, line %d, skipped %d", d.Offset(), d.Line(), d.Skipped())
+	}
+}
+
+func TestDecoder_ReadError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader(thinkingLine+"\n"), errReader{})
+	d := NewDecoder(r)
+	if items := collect(d); len(items) != 1 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if d.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("err = %v", d.Err())
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ToolFormat is how a tool call's input is shown: templates tried in
+// order, the first whose fields are all present and non-empty winning.
+// A field is written {name}, or {a.b} and {a.0} for one nested in an
+// object or array; an array field shows its length. "{{" is a literal
+// brace.
+//
+//	NotebookEdit = ["{notebook_path} cell {cell_id}", "{notebook_path}"]
+type ToolFormat []string
+
+// ToolFormats are extra or overriding formats by tool name, from the
+// config's [tool_formats]. Set it once at startup, like DebugAll.
+var ToolFormats map[string]ToolFormat
+
+// ToolTemplates are [tool_formats] given as Go templates, by tool name. A
+// template runs on {"tool": name, "input": the decoded input}, as in
+// "JIRA {{.input.project}}: {{.input.summary}}", and comes before
+// ToolFormats. Set it once at startup, like DebugAll.
+var ToolTemplates map[string]*template.Template
+
+// ParseToolTemplate compiles a Go template for ToolTemplates. A key the
+// input lacks is an error when it runs, so the call falls back to its
+// usual format instead of showing "<no value>".
+func ParseToolTemplate(tool, text string) (*template.Template, error) {
+	return template.New(tool).Option("missingkey=error").Parse(text)
+}
+
+// formatTemplate shows inputRaw with tool's Go template, if it has one
+// that runs and writes something.
+func formatTemplate(tool string, inputRaw json.RawMessage) (string, bool) {
+	t := ToolTemplates[tool]
+	if t == nil {
+		return "", false
+	}
+	var input any
+	if json.Unmarshal(inputRaw, &input) != nil {
+		return "", false
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, map[string]any{"tool": tool, "input": input}); err != nil || strings.TrimSpace(b.String()) == "" {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// builtinFormats cover tools that need no more than fields put in a line;
+// formatToolInput handles the rest itself.
+var builtinFormats = map[string]ToolFormat{
+	"NotebookEdit":         {"{notebook_path} cell {cell_id} ({edit_mode})", "{notebook_path} cell {cell_id}", "{notebook_path} ({edit_mode})", "{notebook_path}"},
+	"NotebookRead":         {"{notebook_path}"},
+	"MultiEdit":            {"{file_path} ({edits} edits)", "{file_path}"},
+	"LS":                   {"{path}"},
+	"KillShell":            {"shell {shell_id}"},
+	"KillBash":             {"shell {shell_id}"},
+	"BashOutput":           {"shell {bash_id} /{filter}/", "shell {bash_id}"},
+	"TaskOutput":           {"task {task_id}"},
+	"TaskGet":              {"task {taskId}"},
+	"TaskList":             {"(all tasks)"},
+	"TodoWrite":            {"{todos} todos"},
+	"AskUserQuestion":      {"{questions.0.question}"},
+	"SlashCommand":         {"{command}"},
+	"ListMcpResourcesTool": {"{server}", "(all servers)"},
+	"ReadMcpResourceTool":  {"{server} {uri}", "{uri}"},
+	"CronDelete":           {"{id}"},
+	"CronList":             {"(all jobs)"},
+	"EnterWorktree":        {"{name}", "(new worktree)"},
+	"ExitWorktree":         {"(exit worktree)"},
+	"LSP":                  {"{operation} {filePath}:{line}", "{operation} {filePath}"},
+}
+
+// CheckFormat reports a template whose braces don't pair up.
+func CheckFormat(template string) error {
+	_, err := expand(template, nil)
+	return err
+}
+
+// format shows inputRaw with the first template that applies.
+func (f ToolFormat) format(inputRaw json.RawMessage) (string, bool) {
+	if len(f) == 0 {
+		return "", false
+	}
+	var input any
+	if json.Unmarshal(inputRaw, &input) != nil || input == nil {
+		return "", false
+	}
+	for _, template := range f {
+		if s, err := expand(template, input); err == nil {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// errMissing is a template field the input lacks.
+var errMissing = errors.New("missing field")
+
+// expand fills template's fields from input; with a nil input it only
+// checks the template.
+func expand(template string, input any) (string, error) {
+	var b strings.Builder
+	for rest := template; rest != ""; {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:i])
+		if strings.HasPrefix(rest[i:], "{{") || strings.HasPrefix(rest[i:], "}}") {
+			b.WriteByte(rest[i])
+			rest = rest[i+2:]
+			continue
+		}
+		end := strings.IndexByte(rest[i:], '}')
+		if rest[i] == '}' || end < 0 {
+			return "", fmt.Errorf("unpaired brace in %q", template)
+		}
+		name := rest[i+1 : i+end]
+		if name == "" || strings.ContainsRune(name, '{') {
+			return "", fmt.Errorf("bad field {%s} in %q", name, template)
+		}
+		rest = rest[i+end+1:]
+		if input == nil {
+			continue
+		}
+		v, ok := field(input, name)
+		if !ok {
+			return "", errMissing
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
+
+// field looks up a dotted field path in a decoded JSON value and shows
+// it: strings as they are, numbers and booleans as JSON writes them, and
+// arrays as their length. Empty strings, nulls and objects don't count.
+func field(v any, path string) (string, bool) {
+	for key := range strings.SplitSeq(path, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(x) {
+				return "", false
+			}
+			v = x[i]
+		default:
+			return "", false
+		}
+	}
+	switch x := v.(type) {
+	case string:
+		return x, x != ""
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case []any:
+		return strconv.Itoa(len(x)), true
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+	"text/template"
+)
+
+func TestBuiltinFormats(t *testing.T) {
+	tests := []struct {
+		tool, input, want string
+	}{
+		{"NotebookEdit", `{"notebook_path":"/n.ipynb","cell_id":"c3","edit_mode":"insert","new_source":"x"}`, "/n.ipynb cell c3 (insert)"},
+		{"NotebookEdit", `{"notebook_path":"/n.ipynb","new_source":"x"}`, "/n.ipynb"},
+		{"KillShell", `{"shell_id":"bash_2"}`, "shell bash_2"},
+		{"TaskOutput", `{"task_id":"b7x","block":true,"timeout":30000}`, "task b7x"},
+		{"BashOutput", `{"bash_id":"bash_1","filter":"FAIL"}`, "shell bash_1 /FAIL/"},
+		{"MultiEdit", `{"file_path":"/a.go","edits":[{},{},{}]}`, "/a.go (3 edits)"},
+		{"AskUserQuestion", `{"questions":[{"question":"Which database?","options":[]}]}`, "Which database?"},
+		{"LSP", `{"operation":"hover","filePath":"/a.go","line":12,"character":4}`, "hover /a.go:12"},
+		{"TaskList", `{}`, "(all tasks)"},
+	}
+	for _, tt := range tests {
+		if got := formatToolInput(tt.tool, json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToolFormats(t *testing.T) {
+	prev := ToolFormats
+	t.Cleanup(func() { ToolFormats = prev })
+	ToolFormats = map[string]ToolFormat{
+		"Deploy": {"{env} ({{{replicas}}} pods)", "{env}"},
+		"Read":   {"{file_path}:{offset}", "{file_path}"},
+	}
+	for _, tt := range []struct{ tool, input, want string }{
+		{"Deploy", `{"env":"prod","replicas":3}`, "prod ({3} pods)"},
+		{"Deploy", `{"env":"prod","replicas":null}`, "prod"},
+		{"Deploy", `{"region":"eu"}`, `{"region":"eu"}`}, // no template applies: raw
+		{"Read", `{"file_path":"/a.go","offset":40}`, "/a.go:40"},
+	} {
+		if got := formatToolInput(tt.tool, json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"{env", "env}", "{}", "{a{b}"} {
+		if CheckFormat(bad) == nil {
+			t.Errorf("CheckFormat(%q) should fail", bad)
+		}
+	}
+	if err := CheckFormat("{{literal}} {env}"); err != nil {
+		t.Errorf("CheckFormat: %v", err)
+	}
+}
+
+func TestToolTemplates(t *testing.T) {
+	prev := ToolTemplates
+	t.Cleanup(func() { ToolTemplates = prev })
+	jira, err := ParseToolTemplate("mcp__jira__create", "JIRA {{.input.project}}: {{.input.summary}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, _ := ParseToolTemplate("Read", "{{.tool}} → {{.input.file_path}}")
+	ToolTemplates = map[string]*template.Template{"mcp__jira__create": jira, "Read": read}
+
+	for _, tt := range []struct{ tool, input, want string }{
+		{"mcp__jira__create", `{"project":"ESP","summary":"Flaky test","priority":"high"}`, "JIRA ESP: Flaky test"},
+		{"mcp__jira__create", `{"project":"ESP"}`, `{"project":"ESP"}`}, // missing key: the usual format
+		{"Read", `{"file_path":"/a.go"}`, "Read → /a.go"},               // before the builtin
+	} {
+		if got := formatToolInput(tt.tool, json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+	if _, err := ParseToolTemplate("x", "{{.input.project"); err == nil {
+		t.Error("an unclosed action should fail to parse")
+	}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"cmp"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Hook outcomes, in StreamItem.HookStatus. A hook that ran and succeeded
+// has none.
+const (
+	HookFired     = "fired"     // the hook started (a hook_progress line)
+	HookFailed    = "failed"    // it exited non-zero or couldn't run; the call went on
+	HookBlocked   = "blocked"   // it blocked the call or prompt it ran for
+	HookCancelled = "cancelled" // it was cancelled before finishing
+	HookStopped   = "stopped"   // it stopped the conversation from continuing
+)
+
+// HookBlockingError is what a blocking hook said, on hook_blocking_error
+// attachments.
+type HookBlockingError struct {
+	BlockingError string `json:"blockingError"`
+	Command       string `json:"command"`
+}
+
+// hookItem turns a hook attachment (hook_success, hook_non_blocking_error,
+// hook_blocking_error, ...) into a TypeHookOutput item, or reports false
+// for other attachments. ToolID is the call the hook ran for, if any.
+func hookItem(raw RawMessage, timestamp time.Time) (StreamItem, bool) {
+	a := raw.Attachment
+	item := StreamItem{
+		Type:       TypeHookOutput,
+		SessionID:  raw.SessionID,
+		AgentID:    raw.AgentID,
+		AgentName:  agentDisplayName(raw.AgentID),
+		Timestamp:  timestamp,
+		ToolName:   cmp.Or(a.HookName, a.HookEvent),
+		ToolID:     a.ToolUseID,
+		DurationMs: a.DurationMs,
+	}
+	switch a.Type {
+	case "hook_success":
+		item.Content = cmp.Or(a.Stdout, contentString(a.Content))
+		item.Stderr = a.Stderr
+	case "hook_non_blocking_error":
+		item.HookStatus, item.IsError, item.ExitCode = HookFailed, true, a.ExitCode
+		item.Content = strings.TrimRight(cmp.Or(a.Stderr, a.Stdout), "\n")
+		item.Stderr = a.Stderr
+	case "hook_error_during_execution":
+		item.HookStatus, item.IsError = HookFailed, true
+		item.Content = contentString(a.Content)
+	case "hook_blocking_error":
+		item.HookStatus, item.IsError = HookBlocked, true
+		if a.BlockingError != nil {
+			item.Content = a.BlockingError.BlockingError
+		}
+	case "hook_cancelled":
+		item.HookStatus = HookCancelled
+	case "hook_stopped_continuation":
+		item.HookStatus = HookStopped
+		item.Content = a.Message
+	default:
+		return StreamItem{}, false
+	}
+	return item, true
+}
+
+// hookFired is the TypeHookOutput item for a hook_progress line: the hook
+// has started, running Content.
+func hookFired(raw RawMessage, data ProgressData, timestamp time.Time) []StreamItem {
+	name := cmp.Or(data.HookName, data.HookEvent)
+	if name == "" {
+		return nil
+	}
+	return []StreamItem{{
+		Type:       TypeHookOutput,
+		SessionID:  raw.SessionID,
+		AgentID:    raw.AgentID,
+		AgentName:  agentDisplayName(raw.AgentID),
+		Timestamp:  timestamp,
+		ToolName:   name,
+		ToolID:     raw.ParentToolUseID,
+		Content:    data.Command,
+		HookStatus: HookFired,
+	}}
+}
+
+// contentString is an attachment's content when it is a string, "" when
+// it's anything else.
+func contentString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseHooks(t *testing.T) {
+	tests := []struct {
+		name, line string
+		want       StreamItem
+	}{
+		{
+			name: "fired",
+			line: `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","parentToolUseID":"toolu_e","toolUseID":"toolu_e","data":{"type":"hook_progress","hookEvent":"PostToolUse","hookName":"PostToolUse:Edit","command":"gofmt -l ."}}`,
+			want: StreamItem{ToolName: "PostToolUse:Edit", ToolID: "toolu_e", Content: "gofmt -l .", HookStatus: HookFired},
+		},
+		{
+			name: "success",
+			line: `{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"hook_success","content":"context","hookName":"UserPromptSubmit","hookEvent":"UserPromptSubmit","toolUseID":"u1","stdout":"","stderr":"","exitCode":0,"command":"./ctx.sh","durationMs":40}}`,
+			want: StreamItem{ToolName: "UserPromptSubmit", ToolID: "u1", Content: "context", DurationMs: 40},
+		},
+		{
+			name: "non-blocking error",
+			line: `{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"hook_non_blocking_error","hookName":"PostToolUse:Edit","hookEvent":"PostToolUse","toolUseID":"toolu_e","stderr":"lint: 2 problems\n","stdout":"","exitCode":1}}`,
+			want: StreamItem{ToolName: "PostToolUse:Edit", ToolID: "toolu_e", Content: "lint: 2 problems", Stderr: "lint: 2 problems\n", IsError: true, ExitCode: 1, HookStatus: HookFailed},
+		},
+		{
+			name: "blocking error",
+			line: `{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"hook_blocking_error","hookName":"PreToolUse:Bash","hookEvent":"PreToolUse","toolUseID":"toolu_b","blockingError":{"blockingError":"rm -rf is not allowed","command":"./guard.sh"}}}`,
+			want: StreamItem{ToolName: "PreToolUse:Bash", ToolID: "toolu_b", Content: "rm -rf is not allowed", IsError: true, HookStatus: HookBlocked},
+		},
+		{
+			name: "error during execution",
+			line: `{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"hook_error_during_execution","content":"spawn ./guard.sh ENOENT","hookName":"PreToolUse:Bash","hookEvent":"PreToolUse","toolUseID":"toolu_b"}}`,
+			want: StreamItem{ToolName: "PreToolUse:Bash", ToolID: "toolu_b", Content: "spawn ./guard.sh ENOENT", IsError: true, HookStatus: HookFailed},
+		},
+		{
+			name: "cancelled",
+			line: `{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"hook_cancelled","hookName":"Stop","hookEvent":"Stop"}}`,
+			want: StreamItem{ToolName: "Stop", HookStatus: HookCancelled},
+		},
+	}
+	for _, tt := range tests {
+		items, err := ParseLine(tt.line)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(items) != 1 || items[0].Type != TypeHookOutput || items[0].SessionID != "s1" || items[0].AgentName != "Main" {
+			t.Fatalf("%s: got %+v", tt.name, items)
+		}
+		got := items[0]
+		if _, ok := DedupKey(got); ok {
+			t.Errorf("%s: hook items are deduplicated by their call", tt.name)
+		}
+		if got.ToolName != tt.want.ToolName || got.ToolID != tt.want.ToolID || got.Content != tt.want.Content ||
+			got.Stderr != tt.want.Stderr || got.IsError != tt.want.IsError || got.ExitCode != tt.want.ExitCode ||
+			got.DurationMs != tt.want.DurationMs || got.HookStatus != tt.want.HookStatus {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Attachments that aren't about hooks stay dropped.
+	items, _ := ParseLine(`{"type":"attachment","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","attachment":{"type":"task_reminder","content":[]}}`)
+	if len(items) != 0 {
+		t.Errorf("task_reminder: got %+v", items)
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+// Interrupt kinds, the ToolName of TypeInterrupt items.
+const (
+	InterruptTurn    = "turn"     // Esc while the model was responding
+	InterruptToolUse = "tool use" // Esc while a tool ran or waited for permission
+	InterruptAborted = "aborted"  // the API request was aborted
+)
+
+// interruptPrefix starts the user message Claude Code writes when Esc
+// stops a turn: "[Request interrupted by user]", or "[Request interrupted
+// by user for tool use]" when a tool call was running or waiting.
+const interruptPrefix = "[Request interrupted by user"
+
+// IsInterrupt reports whether the text of a user message is the one
+// written when Esc stopped the turn.
+func IsInterrupt(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), interruptPrefix)
+}
+
+// interruptItem recognises an interrupt in the text of a user message.
+func interruptItem(raw RawMessage, timestamp time.Time, text string) (StreamItem, bool) {
+	if !IsInterrupt(text) {
+		return StreamItem{}, false
+	}
+	kind := InterruptTurn
+	if strings.Contains(text, "tool use") {
+		kind = InterruptToolUse
+	}
+	return newInterrupt(raw, timestamp, kind), true
+}
+
+// isAborted reports whether the text of an API error message says the
+// request was aborted: Claude Code stopped it, nothing failed.
+func isAborted(text string) bool {
+	return strings.Contains(strings.ToLower(text), "request was aborted")
+}
+
+// interruptText describes each kind of interrupt, in Content.
+var interruptText = map[string]string{
+	InterruptTurn:    "interrupted by user",
+	InterruptToolUse: "interrupted by user during tool use",
+	InterruptAborted: "request aborted",
+}
+
+func newInterrupt(raw RawMessage, timestamp time.Time, kind string) StreamItem {
+	return StreamItem{
+		Type:      TypeInterrupt,
+		SessionID: raw.SessionID,
+		AgentID:   raw.AgentID,
+		AgentName: agentDisplayName(raw.AgentID),
+		Timestamp: timestamp,
+		ToolName:  kind,
+		Content:   interruptText[kind],
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseInterrupt(t *testing.T) {
+	tests := []struct {
+		name, line, want string
+	}{
+		{
+			name: "turn",
+			line: `{"type":"user","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user]"}]}}`,
+			want: InterruptTurn,
+		},
+		{
+			name: "string content",
+			line: `{"type":"user","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":"[Request interrupted by user]"}}`,
+			want: InterruptTurn,
+		},
+		{
+			name: "tool use",
+			line: `{"type":"user","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user for tool use]"}]}}`,
+			want: InterruptToolUse,
+		},
+		{
+			name: "aborted",
+			line: `{"type":"assistant","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","isApiErrorMessage":true,"message":{"role":"assistant","model":"<synthetic>","content":[{"type":"text","text":"API Error: Request was aborted."}]}}`,
+			want: InterruptAborted,
+		},
+	}
+	for _, tt := range tests {
+		items, err := ParseLine(tt.line)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(items) != 1 || items[0].Type != TypeInterrupt || items[0].ToolName != tt.want || items[0].Content == "" || items[0].AgentName != "Main" {
+			t.Errorf("%s: got %+v, want a %q interrupt", tt.name, items, tt.want)
+		}
+	}
+
+	// A rejected call's result comes first, then the interrupt.
+	items, _ := ParseLine(`{"type":"user","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[` +
+		`{"type":"tool_result","tool_use_id":"toolu_1","is_error":true,"content":"The user doesn't want to proceed with this tool use."},` +
+		`{"type":"text","text":"[Request interrupted by user for tool use]"}]}}`)
+	if len(items) != 2 || items[0].Type != TypeToolOutput || items[1].Type != TypeInterrupt {
+		t.Errorf("rejected call: got %+v", items)
+	}
+
+	// Prompts mentioning an interrupt aren't one.
+	items, _ = ParseLine(`{"type":"user","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":"why did you stop? I saw [Request interrupted by user]"}}`)
+	if len(items) != 0 {
+		t.Errorf("prompt: got %+v", items)
+	}
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// Lenient, when true, makes ParseLine emit a TypeUnknown item for lines it
+// can't make sense of instead of dropping them: lines that aren't JSON (an
+// interrupted write, a line cut short at the scanner's limit) and known
+// line types whose message has an unexpected shape. Lines of types the
+// parser doesn't know are DebugAll's business. Like DebugAll, set this
+// once at startup.
+var Lenient bool
+
+// salvagePattern finds complete string fields in a line that isn't valid
+// JSON. Top-level fields come before "message" in Claude Code's
+// transcripts, so the first "type" is the line's.
+var salvagePattern = regexp.MustCompile(`"(type|subtype|sessionId|agentId|timestamp|name)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// salvage extracts what it can of a malformed line's top-level fields,
+// and the first "name" (a tool call's) if any.
+func salvage(line string) (raw RawMessage, toolName string) {
+	for _, m := range salvagePattern.FindAllStringSubmatch(line, -1) {
+		var v string
+		if json.Unmarshal([]byte(`"`+m[2]+`"`), &v) != nil {
+			continue
+		}
+		var field *string
+		switch m[1] {
+		case "type":
+			field = &raw.Type
+		case "subtype":
+			field = &raw.Subtype
+		case "sessionId":
+			field = &raw.SessionID
+		case "agentId":
+			field = &raw.AgentID
+		case "timestamp":
+			field = &raw.Timestamp
+		case "name":
+			field = &toolName
+		}
+		if *field == "" {
+			*field = v
+		}
+	}
+	return raw, toolName
+}
+
+// malformedItem is the TypeUnknown item for a line that isn't JSON.
+func malformedItem(line string) StreamItem {
+	raw, toolName := salvage(line)
+	timestamp, err := time.Parse(time.RFC3339, raw.Timestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	reason := "malformed JSON"
+	if toolName != "" {
+		reason = PrettyToolName(toolName) + ", " + reason
+	}
+	return unknownItem(raw, line, reason, timestamp)
+}
+
+// unknownItem builds a TypeUnknown item. ToolName says what was wrong,
+// prefixed with the line's type when known; Content is an excerpt of the
+// raw line.
+func unknownItem(raw RawMessage, line, reason string, timestamp time.Time) StreamItem {
+	label := reason
+	if raw.Type != "" {
+		label = raw.Type + ": " + reason
+	}
+	return StreamItem{
+		Type:      TypeUnknown,
+		SessionID: raw.SessionID,
+		AgentID:   raw.AgentID,
+		AgentName: agentDisplayName(raw.AgentID),
+		Timestamp: timestamp,
+		ToolName:  label,
+		Content:   excerpt(line, debugPreviewLen),
+	}
+}
+
+// shapeError says why a user or assistant line's message can't be read,
+// or returns "" if it can.
+func shapeError(raw RawMessage) string {
+	if len(raw.Message) == 0 {
+		return "no message"
+	}
+	switch raw.Type {
+	case "assistant":
+		var msg AssistantMessage
+		if json.Unmarshal(raw.Message, &msg) != nil {
+			return "unexpected message shape"
+		}
+	case "user":
+		var msg struct {
+			Content json.RawMessage `json:"content"`
+		}
+		if json.Unmarshal(raw.Message, &msg) != nil {
+			return "unexpected message shape"
+		}
+		var results []ToolResult
+		var text string
+		if json.Unmarshal(msg.Content, &results) != nil && json.Unmarshal(msg.Content, &text) != nil {
+			return "unexpected message shape"
+		}
+	}
+	return ""
+}
+
+// excerpt cuts s to at most n bytes without splitting a UTF-8 sequence.
+func excerpt(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+type corpusEntry struct {
+	name string
+	want string // item types, space-separated; "none" for no items
+	line string
+}
+
+// loadCorpus reads testdata/corpus.txt.
+func loadCorpus(tb testing.TB) []corpusEntry {
+	tb.Helper()
+	f, err := os.Open("testdata/corpus.txt")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	var entries []corpusEntry
+	var e corpusEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		text := sc.Text()
+		switch {
+		case strings.HasPrefix(text, "# name: "):
+			e = corpusEntry{name: strings.TrimPrefix(text, "# name: ")}
+		case strings.HasPrefix(text, "# want: "):
+			e.want = strings.TrimPrefix(text, "# want: ")
+		case text == "" || strings.HasPrefix(text, "#"):
+		default:
+			e.line = text
+			entries = append(entries, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return entries
+}
+
+func setLenient(tb testing.TB, on bool) {
+	prev := Lenient
+	Lenient = on
+	tb.Cleanup(func() { Lenient = prev })
+}
+
+func TestParseLine_Corpus(t *testing.T) {
+	setLenient(t, true)
+	for _, e := range loadCorpus(t) {
+		t.Run(e.name, func(t *testing.T) {
+			items, err := ParseLine(e.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, string(item.Type))
+			}
+			if len(got) == 0 {
+				got = []string{"none"}
+			}
+			if g := strings.Join(got, " "); g != e.want {
+				t.Errorf("item types = %q, want %q", g, e.want)
+			}
+		})
+	}
+}
+
+func TestParseLine_LenientSalvagesFields(t *testing.T) {
+	setLenient(t, true)
+	line := `{"type":"assistant","agentId":"a4f2c9e","sessionId":"s1","timestamp":"2026-03-02T14:05:11Z","message":{"content":[{"type":"tool_use","id":"t1","name":"mcp__github__get_issue","input":{"owner":"ac`
+	items, _ := ParseLine(line)
+	if len(items) != 1 {
+		t.Fatalf("got %d items", len(items))
+	}
+	item := items[0]
+	if item.Type != TypeUnknown || item.SessionID != "s1" || item.AgentID != "a4f2c9e" || item.AgentName != "Agent-a4f2c9e" {
+		t.Errorf("item = %+v", item)
+	}
+	if item.Timestamp.Format("15:04:05") != "14:05:11" {
+		t.Errorf("timestamp = %v", item.Timestamp)
+	}
+	if item.ToolName != "assistant: mcp:get_issue, malformed JSON" {
+		t.Errorf("label = %q", item.ToolName)
+	}
+	if item.Content != line {
+		t.Errorf("content = %q, want the raw line", item.Content)
+	}
+}
+
+func TestParseLine_StrictDropsUnreadable(t *testing.T) {
+	setLenient(t, false)
+	for _, e := range loadCorpus(t) {
+		if !strings.Contains(e.want, "unknown") {
+			continue
+		}
+		if items, _ := ParseLine(e.line); len(items) != 0 {
+			t.Errorf("%s: strict mode returned %d items", e.name, len(items))
+		}
+	}
+}
+
+// FuzzParseLine checks that no line panics the parser, and that in
+// lenient mode every line that isn't JSON becomes exactly one bounded
+// TypeUnknown item. The corpus and cut-short copies of it (interrupted
+// writes) seed it.
+func FuzzParseLine(f *testing.F) {
+	for _, e := range loadCorpus(f) {
+		f.Add(e.line)
+		for _, cut := range []int{1, len(e.line) / 3, len(e.line) / 2, len(e.line) - 1} {
+			f.Add(e.line[:cut])
+		}
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		Lenient = false
+		strict, _ := ParseLine(line)
+		Lenient = true
+		defer func() { Lenient = false }()
+		items, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine returned an error: %v", err)
+		}
+		if strings.TrimSpace(line) == "" || json.Valid([]byte(line)) {
+			return
+		}
+		if len(strict) != 0 {
+			t.Errorf("strict mode returned %d items for invalid JSON", len(strict))
+		}
+		if len(items) != 1 || items[0].Type != TypeUnknown {
+			t.Fatalf("lenient mode returned %+v, want one unknown item", items)
+		}
+		content := items[0].Content
+		if len(content) > debugPreviewLen+len("…") {
+			t.Errorf("excerpt is %d bytes", len(content))
+		}
+		if utf8.ValidString(line) && !utf8.ValidString(content) {
+			t.Errorf("excerpt split a UTF-8 sequence: %q", content)
+		}
+	})
+}
@@ -3,6 +3,9 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,16 +20,31 @@ const (
 	TypeText          StreamItemType = "text"
 	TypeTurnMarker    StreamItemType = "turn_marker"    // turn boundary + duration (system.turn_duration)
 	TypeCompactMarker StreamItemType = "compact_marker" // conversation compaction boundary (system.compact_boundary)
-	TypeHookOutput    StreamItemType = "hook_output"    // hook execution result (attachment.hook_success)
+	TypeHookOutput    StreamItemType = "hook_output"    // a hook starting (hook_progress) or its outcome (attachment.hook_*); see HookStatus
 	TypeDiagnostics   StreamItemType = "diagnostics"    // post-edit LSP diagnostics (attachment.diagnostics)
 	TypePRLink        StreamItemType = "pr_link"        // PR creation event (type=pr-link)
 	TypeDebug         StreamItemType = "debug"          // raw line type/subtype (only emitted when DebugAll is on)
 	TypeSessionTitle  StreamItemType = "session_title"  // session label update (agent-name / custom-title)
+	TypeCommand       StreamItemType = "command"        // slash command or ! shell command typed by the user, or its local output
+	TypeUnknown       StreamItemType = "unknown"        // line the parser couldn't read: malformed or unexpected shape (only emitted when Lenient is on)
+	TypeAPIError      StreamItemType = "api_error"      // failed API request: a retry (system.api_error) or the message written when retries ran out
+	TypeProgress      StreamItemType = "progress"       // a running tool call's latest output or how far along it is (type=progress); shown on the call, not as an item
+	TypeInterrupt     StreamItemType = "interrupt"      // the user stopped the turn with Esc, or the request was aborted; see the Interrupt kinds
+
+	// Model and thinking settings changes (see internal/modelswitch).
+	TypeThinkingConfig StreamItemType = "thinking_config" // thinking settings a prompt was sent with (thinkingMetadata); not shown
+	TypeModelSwitch    StreamItemType = "model_switch"    // an agent's model or thinking settings changed (emitted by modelswitch, not the parser)
+
+	// TypeFork marks where a session forked from another (claude --resume)
+	// goes its own way; the history it copied isn't shown again (emitted by
+	// internal/fork, not the parser).
+	TypeFork StreamItemType = "fork"
 
 	// AgentIDDisplayLength is how many chars of agent ID to show in display name
 	AgentIDDisplayLength = 7
 
-	// debugPreviewLen caps the raw-line preview shown in TypeDebug items.
+	// debugPreviewLen caps the raw-line preview shown in TypeDebug and
+	// TypeUnknown items.
 	debugPreviewLen = 240
 )
 
@@ -53,14 +71,26 @@ type StreamItem struct {
 	AgentName           string // human-readable name derived from agent type or ID
 	Timestamp           time.Time
 	Content             string
-	ToolName            string // for tool_input/tool_output
-	ToolID              string // to correlate input with output
-	DurationMs          int64  // tool execution duration in ms (0 = not available)
-	InputTokens         int64  // usage.input_tokens from assistant messages
-	OutputTokens        int64  // usage.output_tokens from assistant messages
-	CacheCreationTokens int64  // usage.cache_creation_input_tokens
-	CacheReadTokens     int64  // usage.cache_read_input_tokens
-	Model               string // message.model from assistant messages (e.g. "claude-opus-4-7")
+	ToolName            string          // for tool_input/tool_output
+	ToolID              string          // to correlate input with output
+	DurationMs          int64           // tool execution duration in ms (0 = not available)
+	InputTokens         int64           // usage.input_tokens from assistant messages
+	OutputTokens        int64           // usage.output_tokens from assistant messages
+	CacheCreationTokens int64           // usage.cache_creation_input_tokens
+	CacheReadTokens     int64           // usage.cache_read_input_tokens
+	Model               string          // message.model from assistant messages (e.g. "claude-opus-4-7")
+	SpawnedAgentID      string          // Task/Agent tool_output: ID of the subagent that ran the task
+	ToolInput           json.RawMessage // tool_input: the raw tool_use input, unformatted
+	IsError             bool            // tool_output: the tool reported an error
+	ExitCode            int             // Bash tool_output: the command's exit status when it failed (0 = succeeded or not reported)
+	Stderr              string          // Bash tool_output, ! command output: the stderr part of Content ("" if none or not reported separately)
+	Artifacts           []string        // tool_input/tool_output: files under ~/.claude referenced (saved large output, shell snapshots)
+	Cwd                 string          // Claude Code's working directory when the line was written ("" if not recorded)
+	Host                string          // machine that pushed the item to a daemon ("" = this one)
+	Progress            float64         // progress: fraction of the call done, 0 to 1 (0 = not reported)
+	HookStatus          string          // hook_output: HookFired, HookFailed, HookBlocked, ... ("" = ran and succeeded)
+	UUID                string          // uuid of the transcript line the item came from ("" if none); a forked session's copied lines keep theirs
+	RetryAt             time.Time       // api_error: when the failed request goes again (zero = not retried, or not said)
 }
 
 // RawMessage represents a line from the JSONL file
@@ -70,6 +100,8 @@ type RawMessage struct {
 	AgentID       string          `json:"agentId,omitempty"`
 	SessionID     string          `json:"sessionId"`
 	Timestamp     string          `json:"timestamp"`
+	Cwd           string          `json:"cwd,omitempty"`
+	UUID          string          `json:"uuid,omitempty"`
 	DurationMs    int64           `json:"durationMs,omitempty"`
 	MessageCount  int             `json:"messageCount,omitempty"`
 	Message       json.RawMessage `json:"message"`
@@ -82,10 +114,46 @@ type RawMessage struct {
 	CompactMetadata *CompactMetadata `json:"compactMetadata,omitempty"`
 	// Attachment carries hook output / diagnostics / etc on type="attachment" lines.
 	Attachment *Attachment `json:"attachment,omitempty"`
+	// Data and ParentToolUseID are set on type="progress" lines: what a
+	// running tool call reported, and which call it is.
+	Data            json.RawMessage `json:"data,omitempty"`
+	ParentToolUseID string          `json:"parentToolUseID,omitempty"`
 	// PR link fields (type=pr-link).
 	PRNumber     int    `json:"prNumber,omitempty"`
 	PRURL        string `json:"prUrl,omitempty"`
 	PRRepository string `json:"prRepository,omitempty"`
+	// API error fields: system.api_error lines carry the error and the
+	// retry schedule; the message written when retries run out is marked
+	// isApiErrorMessage.
+	Error             json.RawMessage `json:"error,omitempty"`
+	RetryInMs         float64         `json:"retryInMs,omitempty"`
+	RetryAttempt      int             `json:"retryAttempt,omitempty"`
+	MaxRetries        int             `json:"maxRetries,omitempty"`
+	IsAPIErrorMessage bool            `json:"isApiErrorMessage,omitempty"`
+	// ThinkingMetadata is the thinking setting on the user's prompt lines.
+	ThinkingMetadata *ThinkingMetadata `json:"thinkingMetadata,omitempty"`
+}
+
+// ThinkingMetadata is how much thinking a prompt asked for: a level set by
+// keywords ("think hard") or the settings (older versions), or a token
+// budget.
+type ThinkingMetadata struct {
+	Level             string `json:"level,omitempty"`
+	Disabled          bool   `json:"disabled,omitempty"`
+	MaxThinkingTokens int64  `json:"maxThinkingTokens,omitempty"`
+}
+
+// String describes the setting: "off", "high", "31999 tokens".
+func (t ThinkingMetadata) String() string {
+	switch {
+	case t.Disabled:
+		return "off"
+	case t.MaxThinkingTokens > 0:
+		return fmt.Sprintf("%d tokens", t.MaxThinkingTokens)
+	case t.Level != "":
+		return t.Level
+	}
+	return "default"
 }
 
 // CompactMetadata describes a conversation-compaction event.
@@ -100,13 +168,17 @@ type Attachment struct {
 	Type      string `json:"type"`
 	HookName  string `json:"hookName,omitempty"`
 	HookEvent string `json:"hookEvent,omitempty"`
-	// Content is omitted because subtypes disagree on its shape
-	// (hook_success: string; task_reminder: array). Use Stdout for hooks.
-	Stdout     string `json:"stdout,omitempty"`
-	Stderr     string `json:"stderr,omitempty"`
-	Command    string `json:"command,omitempty"`
-	ExitCode   int    `json:"exitCode,omitempty"`
-	DurationMs int64  `json:"durationMs,omitempty"`
+	ToolUseID string `json:"toolUseID,omitempty"` // the call a tool hook ran for
+	// Content is left raw because subtypes disagree on its shape
+	// (hook_success: string; task_reminder: array); see contentString.
+	Content       json.RawMessage    `json:"content,omitempty"`
+	Message       string             `json:"message,omitempty"` // hook_stopped_continuation
+	BlockingError *HookBlockingError `json:"blockingError,omitempty"`
+	Stdout        string             `json:"stdout,omitempty"`
+	Stderr        string             `json:"stderr,omitempty"`
+	Command       string             `json:"command,omitempty"`
+	ExitCode      int                `json:"exitCode,omitempty"`
+	DurationMs    int64              `json:"durationMs,omitempty"`
 	// Diagnostics fields (attachment.type=diagnostics)
 	Files []DiagnosticFile `json:"files,omitempty"`
 }
@@ -128,6 +200,41 @@ type Diagnostic struct {
 // RawToolUseResult represents the toolUseResult field on user messages
 type RawToolUseResult struct {
 	DurationMs int64 `json:"durationMs"`
+	// AgentID is set on Task/Agent results: the subagent that ran the task.
+	AgentID string `json:"agentId,omitempty"`
+	// Stderr is set on Bash results (Content holds stdout and stderr). A
+	// failed command's toolUseResult is a plain error string instead.
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// DedupKey is what a tool call's input or output is recognised by when a
+// transcript is read again: its call and type. Items that repeat for one
+// call, like progress updates and the hooks run for it, have none.
+func DedupKey(item StreamItem) (string, bool) {
+	if item.ToolID == "" || item.Type == TypeProgress || item.Type == TypeHookOutput {
+		return "", false
+	}
+	return item.ToolID + ":" + string(item.Type), true
+}
+
+// IsAgentReport reports whether item is a subagent's final report: the
+// result of the Task/Agent call that ran it.
+func IsAgentReport(item StreamItem) bool {
+	return item.Type == TypeToolOutput && item.SpawnedAgentID != ""
+}
+
+// exitCodePattern matches how a failed Bash result starts: "Exit code 2"
+// (older versions: "Error: Exit code 2").
+var exitCodePattern = regexp.MustCompile(`^(?:Error: )?Exit code (\d+)\b`)
+
+// exitCode returns the exit status a failed Bash result reports, or 0.
+func exitCode(content string) int {
+	m := exitCodePattern.FindStringSubmatch(content)
+	if m == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(m[1])
+	return code
 }
 
 // AssistantMessage represents the message field for assistant responses
@@ -168,6 +275,7 @@ type ToolResult struct {
 	ToolUseID string          `json:"tool_use_id"`
 	Content   json.RawMessage `json:"content"`
 	IsError   bool            `json:"is_error"`
+	Text      string          `json:"text,omitempty"` // text blocks (type=text) sent beside results
 }
 
 // ToolInput represents the input field for various tools
@@ -188,6 +296,10 @@ type ToolInput struct {
 	TaskID       string `json:"taskId,omitempty"`
 	TaskIDSnake  string `json:"task_id,omitempty"`
 	Cron         string `json:"cron,omitempty"`
+	// Task/Agent: which kind of subagent to launch, and the model it runs
+	// on when not its default.
+	SubagentType string `json:"subagent_type,omitempty"`
+	Model        string `json:"model,omitempty"`
 }
 
 // ParseLine parses a single JSONL line and returns stream items
@@ -201,6 +313,9 @@ func ParseLine(line string) ([]StreamItem, error) {
 		// Gracefully skip malformed/truncated lines (e.g. base64 images
 		// that exceeded the scanner buffer). A single bad line shouldn't
 		// crash the app.
+		if Lenient {
+			return []StreamItem{malformedItem(line)}, nil
+		}
 		return nil, nil
 	}
 
@@ -213,9 +328,32 @@ func ParseLine(line string) ([]StreamItem, error) {
 
 	switch raw.Type {
 	case "assistant":
+		if raw.IsAPIErrorMessage {
+			items = parseAPIErrorMessage(raw, timestamp)
+			break
+		}
 		items = parseAssistantMessage(raw, timestamp)
+		if Lenient && len(items) == 0 {
+			if reason := shapeError(raw); reason != "" {
+				items = []StreamItem{unknownItem(raw, line, reason, timestamp)}
+			}
+		}
 	case "user":
 		items = parseUserMessage(raw, timestamp)
+		if Lenient && len(items) == 0 {
+			if reason := shapeError(raw); reason != "" {
+				items = []StreamItem{unknownItem(raw, line, reason, timestamp)}
+			}
+		}
+		if raw.ThinkingMetadata != nil {
+			items = append(items, StreamItem{
+				Type:      TypeThinkingConfig,
+				AgentID:   raw.AgentID,
+				AgentName: agentDisplayName(raw.AgentID),
+				Timestamp: timestamp,
+				Content:   raw.ThinkingMetadata.String(),
+			})
+		}
 	case "system":
 		items = parseSystemMessage(raw, timestamp)
 		if DebugAll && len(items) == 0 {
@@ -232,12 +370,24 @@ func ParseLine(line string) ([]StreamItem, error) {
 		}
 	case "pr-link":
 		items = parsePRLink(raw, timestamp)
+	case "progress":
+		items = parseProgress(raw, timestamp)
+		if DebugAll && len(items) == 0 {
+			items = []StreamItem{debugItem(raw, line, timestamp)}
+		}
 	default:
 		if DebugAll {
 			items = []StreamItem{debugItem(raw, line, timestamp)}
 		}
 	}
 
+	// A Bash call's line records the directory it runs in; its result's
+	// line records where the shell was left, so the two differ when the
+	// command cd'd (or Claude Code reset the shell's directory).
+	for i := range items {
+		items[i].Cwd = raw.Cwd
+		items[i].UUID = raw.UUID
+	}
 	return items, nil
 }
 
@@ -253,10 +403,7 @@ func debugItem(raw RawMessage, line string, timestamp time.Time) StreamItem {
 	case raw.Type == "attachment" && raw.Attachment != nil && raw.Attachment.Type != "":
 		label = "attachment." + raw.Attachment.Type
 	}
-	preview := line
-	if len(preview) > debugPreviewLen {
-		preview = preview[:debugPreviewLen] + "…"
-	}
+	preview := excerpt(line, debugPreviewLen)
 	agentName := agentDisplayName(raw.AgentID)
 	return StreamItem{
 		Type:      TypeDebug,
@@ -269,30 +416,18 @@ func debugItem(raw RawMessage, line string, timestamp time.Time) StreamItem {
 	}
 }
 
-// parseAttachment dispatches on attachment.type. Surfaces hook_success and
+// parseAttachment dispatches on attachment.type. Surfaces hook outcomes and
 // diagnostics; every other subtype is intentionally dropped (the DebugAll
 // flag will surface the rest as TypeDebug items).
 func parseAttachment(raw RawMessage, timestamp time.Time) []StreamItem {
 	if raw.Attachment == nil {
 		return nil
 	}
-	agentName := agentDisplayName(raw.AgentID)
-
-	switch raw.Attachment.Type {
-	case "hook_success":
-		body := raw.Attachment.Stdout
-		return []StreamItem{{
-			Type:       TypeHookOutput,
-			SessionID:  raw.SessionID,
-			AgentID:    raw.AgentID,
-			AgentName:  agentName,
-			Timestamp:  timestamp,
-			ToolName:   raw.Attachment.HookName,
-			Content:    body,
-			DurationMs: raw.Attachment.DurationMs,
-		}}
-	case "diagnostics":
-		return diagnosticsItems(raw, timestamp, agentName)
+	if item, ok := hookItem(raw, timestamp); ok {
+		return []StreamItem{item}
+	}
+	if raw.Attachment.Type == "diagnostics" {
+		return diagnosticsItems(raw, timestamp, agentDisplayName(raw.AgentID))
 	}
 	return nil
 }
@@ -401,6 +536,7 @@ func parseSessionTitle(raw RawMessage, timestamp time.Time, title string) []Stre
 // parseSystemMessage handles system-type JSONL lines. Surfaces:
 //   - subtype=turn_duration → TypeTurnMarker (turn ended + duration)
 //   - subtype=compact_boundary → TypeCompactMarker (auto/manual compaction with preTokens)
+//   - subtype=api_error → TypeAPIError (failed API request, being retried)
 //
 // Other subtypes are intentionally dropped.
 func parseSystemMessage(raw RawMessage, timestamp time.Time) []StreamItem {
@@ -426,6 +562,8 @@ func parseSystemMessage(raw RawMessage, timestamp time.Time) []StreamItem {
 			Timestamp: timestamp,
 			Content:   content,
 		}}
+	case "api_error":
+		return parseAPIError(raw, timestamp)
 	}
 	return nil
 }
@@ -519,6 +657,8 @@ func parseAssistantMessage(raw RawMessage, timestamp time.Time) []StreamItem {
 				Content:   content,
 				ToolName:  PrettyToolName(block.Name),
 				ToolID:    block.ID,
+				ToolInput: block.Input,
+				Artifacts: artifactPaths(string(block.Input)),
 			})
 		}
 	}
@@ -543,15 +683,29 @@ func parseUserMessage(raw RawMessage, timestamp time.Time) []StreamItem {
 	if err := json.Unmarshal(raw.Message, &struct {
 		Content *[]ToolResult `json:"content"`
 	}{Content: &results}); err != nil {
-		return nil
+		// A plain-text message: a prompt (dropped) or a command.
+		var text string
+		if json.Unmarshal(raw.Message, &struct {
+			Content *string `json:"content"`
+		}{Content: &text}) != nil {
+			return nil
+		}
+		if item, ok := interruptItem(raw, timestamp, text); ok {
+			return []StreamItem{item}
+		}
+		return parseCommand(raw, timestamp, text)
 	}
 
-	// Parse toolUseResult for duration
+	// Parse toolUseResult for duration, the spawned subagent (Task) and
+	// Bash's stderr
 	var durationMs int64
+	var spawnedAgentID, stderr string
 	if len(raw.ToolUseResult) > 0 {
 		var tur RawToolUseResult
 		if err := json.Unmarshal(raw.ToolUseResult, &tur); err == nil {
 			durationMs = tur.DurationMs
+			spawnedAgentID = tur.AgentID
+			stderr = strings.TrimRight(tur.Stderr, "\n")
 		}
 	}
 
@@ -559,15 +713,31 @@ func parseUserMessage(raw RawMessage, timestamp time.Time) []StreamItem {
 	agentName := agentDisplayName(raw.AgentID)
 
 	for _, result := range results {
+		if result.Type == "text" {
+			if item, ok := interruptItem(raw, timestamp, result.Text); ok {
+				items = append(items, item)
+			}
+			continue
+		}
 		if result.Type == "tool_result" {
+			content := extractToolResultContent(result.Content)
+			code := 0
+			if result.IsError {
+				code = exitCode(content)
+			}
 			items = append(items, StreamItem{
-				Type:       TypeToolOutput,
-				AgentID:    raw.AgentID,
-				AgentName:  agentName,
-				Timestamp:  timestamp,
-				Content:    extractToolResultContent(result.Content),
-				ToolID:     result.ToolUseID,
-				DurationMs: durationMs,
+				Type:           TypeToolOutput,
+				AgentID:        raw.AgentID,
+				AgentName:      agentName,
+				Timestamp:      timestamp,
+				Content:        content,
+				ToolID:         result.ToolUseID,
+				DurationMs:     durationMs,
+				SpawnedAgentID: spawnedAgentID,
+				IsError:        result.IsError,
+				ExitCode:       code,
+				Stderr:         stderr,
+				Artifacts:      artifactPaths(content),
 			})
 		}
 	}
@@ -575,6 +745,70 @@ func parseUserMessage(raw RawMessage, timestamp time.Time) []StreamItem {
 	return items
 }
 
+// parseCommand turns the user-side records of a command into a TypeCommand
+// item: "<command-name>/review</command-name>" with its args, a local
+// command's "<local-command-stdout>", or a "!" shell command's
+// "<bash-input>" and "<bash-stdout>"/"<bash-stderr>". ToolName is the
+// command ("/review", "! make test") or "" for output; other text (prompts)
+// yields nothing.
+func parseCommand(raw RawMessage, timestamp time.Time, text string) []StreamItem {
+	item := StreamItem{
+		Type:      TypeCommand,
+		AgentID:   raw.AgentID,
+		AgentName: agentDisplayName(raw.AgentID),
+		Timestamp: timestamp,
+	}
+	if name, ok := tagContent(text, "command-name"); ok {
+		item.ToolName = name
+		item.Content, _ = tagContent(text, "command-args")
+	} else if out, ok := tagContent(text, "local-command-stdout"); ok {
+		item.Content = out
+	} else if cmd, ok := tagContent(text, "bash-input"); ok {
+		item.ToolName = "! " + cmd
+	} else if out, ok := tagContent(text, "bash-stdout"); ok {
+		stderr, _ := tagContent(text, "bash-stderr")
+		item.Content = strings.TrimSpace(out + "\n" + stderr)
+		item.Stderr = stderr
+	} else {
+		return nil
+	}
+	item.Artifacts = artifactPaths(text)
+	return []StreamItem{item}
+}
+
+// tagContent returns the trimmed text between <tag> and </tag>.
+func tagContent(text, tag string) (string, bool) {
+	_, rest, ok := strings.Cut(text, "<"+tag+">")
+	if !ok {
+		return "", false
+	}
+	inner, _, ok := strings.Cut(rest, "</"+tag+">")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(inner), true
+}
+
+// artifactPattern matches paths of files Claude Code keeps beside the
+// transcript: outputs too large to inline (tool-results/) and the shell
+// environment snapshots Bash runs in (shell-snapshots/).
+var artifactPattern = regexp.MustCompile(`[^\s"'<>()\[\]\\]*/\.claude/(?:projects/[^\s"'<>()\\]+/tool-results|shell-snapshots)/[^\s"'<>()\[\]\\]+`)
+
+// artifactPaths returns the distinct artifact paths mentioned in text.
+func artifactPaths(text string) []string {
+	if !strings.Contains(text, ".claude/") {
+		return nil
+	}
+	var paths []string
+	for _, p := range artifactPattern.FindAllString(text, -1) {
+		p = strings.TrimRight(p, ".,;:`")
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
 // extractToolResultContent handles both string and array-of-blocks content.
 // Built-in tools return a plain string; MCP tools return [{"type":"text","text":"..."}].
 func extractToolResultContent(raw json.RawMessage) string {
@@ -613,6 +847,12 @@ func formatToolInput(toolName string, inputRaw json.RawMessage) string {
 		// Return raw JSON if we can't parse the input
 		return string(inputRaw)
 	}
+	if s, ok := formatTemplate(toolName, inputRaw); ok {
+		return s
+	}
+	if s, ok := ToolFormats[toolName].format(inputRaw); ok {
+		return s
+	}
 
 	switch toolName {
 	case "Bash":
@@ -642,10 +882,7 @@ func formatToolInput(toolName string, inputRaw json.RawMessage) string {
 		return input.Query
 	case "Task", "Agent":
 		// "Task" is the legacy name; "Agent" is current (Claude Code 2.x).
-		if input.Description != "" {
-			return input.Description
-		}
-		return input.Prompt
+		return formatTaskInput(input)
 	case "Skill":
 		if input.Args != "" {
 			return fmt.Sprintf("%s — %s", input.Skill, input.Args)
@@ -680,10 +917,44 @@ func formatToolInput(toolName string, inputRaw json.RawMessage) string {
 		}
 		return string(inputRaw)
 	default:
+		if s, ok := builtinFormats[toolName].format(inputRaw); ok {
+			return s
+		}
 		return string(inputRaw)
 	}
 }
 
+// formatTaskInput shows a Task/Agent call as its description, then the
+// kind of agent it launches and the model, then the prompt:
+//
+//	audit deps
+//	  agent: Explore · model: haiku
+//	  prompt: check every module's dependencies
+//
+// Without a description, the prompt comes first instead.
+func formatTaskInput(input ToolInput) string {
+	var lines []string
+	if input.Description != "" {
+		lines = append(lines, input.Description)
+	} else {
+		lines = append(lines, input.Prompt)
+	}
+	var kind []string
+	if input.SubagentType != "" {
+		kind = append(kind, "agent: "+input.SubagentType)
+	}
+	if input.Model != "" {
+		kind = append(kind, "model: "+input.Model)
+	}
+	if len(kind) > 0 {
+		lines = append(lines, "  "+strings.Join(kind, " · "))
+	}
+	if input.Description != "" && input.Prompt != "" {
+		lines = append(lines, "  prompt: "+strings.ReplaceAll(strings.TrimSpace(input.Prompt), "\n", "\n    "))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // PrettyToolName returns a display-friendly version of a tool name.
 // Long MCP names like mcp__plugin_context7_context7__query-docs are shortened
 // to mcp:query-docs; other names are returned unchanged.
@@ -154,6 +154,9 @@ func TestParseLine_AssistantToolUse(t *testing.T) {
 			if !strings.Contains(item.Content, tt.wantSub) {
 				t.Errorf("content = %q, want substring %q", item.Content, tt.wantSub)
 			}
+			if string(item.ToolInput) != string(inputJSON) {
+				t.Errorf("ToolInput = %s, want the raw input %s", item.ToolInput, inputJSON)
+			}
 		})
 	}
 }
@@ -179,6 +182,83 @@ func TestParseLine_UserToolResult(t *testing.T) {
 	}
 }
 
+func TestParseLine_Cwd(t *testing.T) {
+	call := `{"type":"assistant","timestamp":"2025-01-01T12:00:00Z","cwd":"/src/app","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_cd","name":"Bash","input":{"command":"cd web && npm test"}}]}}`
+	result := `{"type":"user","timestamp":"2025-01-01T12:00:05Z","cwd":"/src/app/web","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_cd","content":"ok"}]}}`
+	for line, want := range map[string]string{call: "/src/app", result: "/src/app/web"} {
+		items, err := ParseLine(line)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("got %d items, err %v", len(items), err)
+		}
+		if items[0].Cwd != want {
+			t.Errorf("%s: Cwd = %q, want %q", items[0].Type, items[0].Cwd, want)
+		}
+	}
+}
+
+func TestParseLine_UUID(t *testing.T) {
+	line := `{"type":"assistant","uuid":"4f1c","timestamp":"2025-01-01T12:00:00Z","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hm"},{"type":"text","text":"done"}]}}`
+	items, err := ParseLine(line)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("got %d items, err %v", len(items), err)
+	}
+	for _, item := range items {
+		if item.UUID != "4f1c" {
+			t.Errorf("%s: UUID = %q, want the line's", item.Type, item.UUID)
+		}
+	}
+}
+
+func TestParseLine_BashExitStatus(t *testing.T) {
+	tests := []struct {
+		name, line string
+		code       int
+		stderr     string
+	}{
+		{"failed", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","toolUseResult":"Error: Exit code 2\nno such file","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"Exit code 2\nno such file","is_error":true}]}}`, 2, ""},
+		{"older format", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"Error: Exit code 127\nsh: foo: not found","is_error":true}]}}`, 127, ""},
+		{"succeeded with stderr", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","toolUseResult":{"stdout":"ok","stderr":"warning: deprecated\n","interrupted":false},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok\nwarning: deprecated"}]}}`, 0, "warning: deprecated"},
+		{"output mentioning an exit code", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"Exit code 3 means retry"}]}}`, 0, ""},
+	}
+	for _, tt := range tests {
+		items, err := ParseLine(tt.line)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("%s: got %d items, err %v", tt.name, len(items), err)
+		}
+		if items[0].ExitCode != tt.code || items[0].Stderr != tt.stderr {
+			t.Errorf("%s: exit %d, stderr %q; want %d, %q", tt.name, items[0].ExitCode, items[0].Stderr, tt.code, tt.stderr)
+		}
+	}
+}
+
+func TestParseLine_TaskResultCarriesAgentID(t *testing.T) {
+	line := `{"type":"user","timestamp":"2025-01-01T12:00:00Z","toolUseResult":{"status":"completed","agentId":"a1b2c3","totalDurationMs":900},"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_task","content":"done"}]}}`
+	items, err := ParseLine(line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if items[0].SpawnedAgentID != "a1b2c3" {
+		t.Errorf("SpawnedAgentID = %q, want %q", items[0].SpawnedAgentID, "a1b2c3")
+	}
+	if !IsAgentReport(items[0]) {
+		t.Error("a Task result with an agentId isn't an agent report")
+	}
+}
+
+func TestParseLine_ToolResultError(t *testing.T) {
+	line := `{"type":"user","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_e","content":"String to replace not found","is_error":true}]}}`
+	items, err := ParseLine(line)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || !items[0].IsError {
+		t.Errorf("items = %+v, want one tool_output with IsError", items)
+	}
+}
+
 func TestParseLine_MCPToolResult(t *testing.T) {
 	// MCP tools return content as an array of content blocks, not a plain string
 	line := `{"type":"user","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_mcp1","content":[{"type":"text","text":"MCP result here"}]}]}}`
@@ -497,6 +577,9 @@ func TestFormatToolInput(t *testing.T) {
 		{"Agent with desc", "Agent", `{"description":"audit deps","prompt":"check all deps"}`, "audit deps"},
 		{"Agent prompt fallback", "Agent", `{"prompt":"do a thing"}`, "do a thing"},
 		{"Task legacy alias", "Task", `{"description":"legacy task"}`, "legacy task"},
+		{"Agent type and model", "Agent", `{"description":"audit deps","subagent_type":"Explore","model":"haiku","prompt":"check all deps"}`,
+			"audit deps\n  agent: Explore · model: haiku\n  prompt: check all deps"},
+		{"Agent multi-line prompt", "Agent", `{"description":"audit deps","prompt":"check:\n- go.mod\n"}`, "  prompt: check:\n    - go.mod"},
 		{"Skill with args", "Skill", `{"skill":"beads:create","args":"--title x"}`, "beads:create — --title x"},
 		{"Skill no args", "Skill", `{"skill":"beads:list"}`, "beads:list"},
 		{"ToolSearch", "ToolSearch", `{"query":"select:Read","max_results":1}`, "select:Read"},
@@ -731,3 +814,71 @@ func TestParseLine_UserMessageHasNoTokens(t *testing.T) {
 		t.Errorf("OutputTokens = %d, want 0 (user messages don't have usage)", item.OutputTokens)
 	}
 }
+
+func TestParseLine_Commands(t *testing.T) {
+	user := func(text string) string {
+		b, _ := json.Marshal(text)
+		return `{"type":"user","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":` + string(b) + `}}`
+	}
+	tests := []struct {
+		text, name, content string
+	}{
+		{"<command-message>review is running…</command-message>\n<command-name>/review</command-name>\n<command-args>123</command-args>", "/review", "123"},
+		{"<local-command-stdout>Total cost: $0.12</local-command-stdout>", "", "Total cost: $0.12"},
+		{"<bash-input>make test</bash-input>", "! make test", ""},
+		{"<bash-stdout>ok</bash-stdout><bash-stderr>warn</bash-stderr>", "", "ok\nwarn"},
+	}
+	for _, tt := range tests {
+		items, _ := ParseLine(user(tt.text))
+		if len(items) != 1 || items[0].Type != TypeCommand {
+			t.Errorf("%q: got %+v, want one command item", tt.text, items)
+			continue
+		}
+		if items[0].ToolName != tt.name || items[0].Content != tt.content {
+			t.Errorf("%q: name %q content %q", tt.text, items[0].ToolName, items[0].Content)
+		}
+	}
+
+	if items, _ := ParseLine(user("please fix the bug")); len(items) != 0 {
+		t.Errorf("plain prompt produced %+v", items)
+	}
+}
+
+func TestParseLine_ToolResultArtifacts(t *testing.T) {
+	saved := "/home/u/.claude/projects/-home-u-api/s1/tool-results/toolu_01.txt"
+	line := `{"type":"user","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_01","content":"Output too large (52KB). Full output saved to: ` + saved + `.\n\nPreview: ..."}]}}`
+	items, _ := ParseLine(line)
+	if len(items) != 1 || len(items[0].Artifacts) != 1 || items[0].Artifacts[0] != saved {
+		t.Fatalf("artifacts = %+v", items)
+	}
+
+	snapshot := "/home/u/.claude/shell-snapshots/snapshot-bash-1700000000-abc.sh"
+	line = `{"type":"assistant","timestamp":"2026-01-15T10:00:00Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t2","name":"Read","input":{"file_path":"` + snapshot + `"}}]}}`
+	items, _ = ParseLine(line)
+	if len(items) != 1 || len(items[0].Artifacts) != 1 || items[0].Artifacts[0] != snapshot {
+		t.Errorf("artifacts = %+v", items)
+	}
+}
+
+func TestParseLine_ThinkingMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"level", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","thinkingMetadata":{"level":"high","disabled":false,"triggers":[]},"message":{"role":"user","content":"ultrathink about it"}}`, "high"},
+		{"disabled", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","thinkingMetadata":{"level":"none","disabled":true},"message":{"role":"user","content":"go"}}`, "off"},
+		{"budget", `{"type":"user","timestamp":"2025-01-01T12:00:00Z","thinkingMetadata":{"maxThinkingTokens":31999},"message":{"role":"user","content":"go"}}`, "31999 tokens"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := ParseLine(tc.line)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != 1 || items[0].Type != TypeThinkingConfig || items[0].Content != tc.want {
+				t.Fatalf("items = %+v, want one thinking_config %q", items, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProgressData is the data of a type="progress" line, written while a tool
+// call runs: a Bash command's latest output, an MCP tool's progress
+// notifications, a web search's query and result count, a hook starting.
+// Progress of subagents is left out; their own lines tell it.
+type ProgressData struct {
+	Type string `json:"type"` // bash_progress, mcp_progress, query_update, ...
+	// bash_progress: the last lines written so far, and for how long.
+	Output             string  `json:"output,omitempty"`
+	ElapsedTimeSeconds float64 `json:"elapsedTimeSeconds,omitempty"`
+	// mcp_progress: the server's notifications/progress.
+	Progress        float64 `json:"progress,omitempty"`
+	Total           float64 `json:"total,omitempty"`
+	ProgressMessage string  `json:"progressMessage,omitempty"`
+	// query_update, search_results_received: a web search.
+	Query       string `json:"query,omitempty"`
+	ResultCount int    `json:"resultCount,omitempty"`
+	// hook_progress: a hook started, and the command it runs.
+	HookEvent string `json:"hookEvent,omitempty"`
+	HookName  string `json:"hookName,omitempty"`
+	Command   string `json:"command,omitempty"`
+}
+
+// parseProgress emits a TypeProgress item for the tool call a progress
+// line is about (ToolID), with what it said in Content and, when the tool
+// reports it, the fraction done in Progress. A hook starting is a
+// TypeHookOutput item instead. Lines saying nothing worth showing yield
+// none.
+func parseProgress(raw RawMessage, timestamp time.Time) []StreamItem {
+	var data ProgressData
+	if json.Unmarshal(raw.Data, &data) != nil {
+		return nil
+	}
+	if data.Type == "hook_progress" {
+		return hookFired(raw, data, timestamp)
+	}
+	if raw.ParentToolUseID == "" {
+		return nil
+	}
+	item := StreamItem{
+		Type:       TypeProgress,
+		SessionID:  raw.SessionID,
+		AgentID:    raw.AgentID,
+		AgentName:  agentDisplayName(raw.AgentID),
+		Timestamp:  timestamp,
+		ToolName:   data.Type,
+		ToolID:     raw.ParentToolUseID,
+		DurationMs: int64(data.ElapsedTimeSeconds * 1000),
+	}
+	switch data.Type {
+	case "bash_progress":
+		item.Content = strings.TrimRight(data.Output, "\n")
+	case "mcp_progress":
+		item.Content = data.ProgressMessage
+		if data.Total > 0 {
+			item.Progress = min(1, max(0, data.Progress/data.Total))
+		}
+	case "query_update":
+		item.Content = "searching: " + data.Query
+	case "search_results_received":
+		item.Content = fmt.Sprintf("%d results for %s", data.ResultCount, data.Query)
+	default:
+		return nil
+	}
+	if item.Content == "" && item.Progress == 0 && item.DurationMs == 0 {
+		return nil
+	}
+	return []StreamItem{item}
+}
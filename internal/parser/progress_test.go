@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		name, line string
+		content    string
+		progress   float64
+		durationMs int64
+		none       bool
+	}{
+		{
+			name:       "bash",
+			line:       `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","parentToolUseID":"toolu_b","toolUseID":"bash-progress-2","data":{"type":"bash_progress","output":"ok  pkg/a\nok  pkg/b\n","fullOutput":"...","elapsedTimeSeconds":4,"totalLines":12}}`,
+			content:    "ok  pkg/a\nok  pkg/b",
+			durationMs: 4000,
+		},
+		{
+			name:     "mcp",
+			line:     `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","parentToolUseID":"toolu_m","data":{"type":"mcp_progress","status":"progress","progress":3,"total":4,"progressMessage":"indexing"}}`,
+			content:  "indexing",
+			progress: 0.75,
+		},
+		{
+			name:    "search",
+			line:    `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","parentToolUseID":"toolu_w","data":{"type":"search_results_received","resultCount":10,"query":"go 1.25"}}`,
+			content: "10 results for go 1.25",
+		},
+		{
+			name: "subagent",
+			line: `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","parentToolUseID":"toolu_t","data":{"type":"agent_progress","prompt":"audit the api"}}`,
+			none: true,
+		},
+		{
+			name: "no call",
+			line: `{"type":"progress","sessionId":"s1","timestamp":"2025-01-01T12:00:00Z","data":{"type":"bash_progress","output":"x"}}`,
+			none: true,
+		},
+	}
+	for _, tt := range tests {
+		items, err := ParseLine(tt.line)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.none {
+			if len(items) != 0 {
+				t.Errorf("%s: got %+v, want nothing", tt.name, items)
+			}
+			continue
+		}
+		if len(items) != 1 || items[0].Type != TypeProgress || items[0].ToolID == "" || items[0].SessionID != "s1" {
+			t.Fatalf("%s: got %+v", tt.name, items)
+		}
+		if _, ok := DedupKey(items[0]); ok {
+			t.Errorf("%s: progress updates are deduplicated", tt.name)
+		}
+		if it := items[0]; it.Content != tt.content || it.Progress != tt.progress || it.DurationMs != tt.durationMs {
+			t.Errorf("%s: content %q, progress %v, duration %d", tt.name, it.Content, it.Progress, it.DurationMs)
+		}
+	}
+}
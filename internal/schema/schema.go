@@ -0,0 +1,22 @@
+// Package schema publishes the JSON Schema for everything claude-esp writes
+// for programs: stream items, edit events, heartbeats and notifications.
+// The Go types behind them are sink.Item, edits.Event, heartbeat.Heartbeat
+// and notify.Event; a test keeps the schema and their JSON tags in step.
+package schema
+
+import _ "embed"
+
+// Version is the schema version. It changes when a field is removed or
+// renamed; new optional fields don't change it.
+const Version = 1
+
+// Header is the HTTP response header carrying Version.
+const Header = "X-Claude-Esp-Schema"
+
+//go:embed events.schema.json
+var doc []byte
+
+// JSON returns the schema document.
+func JSON() []byte {
+	return doc
+}
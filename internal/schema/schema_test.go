@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/notify"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+	"github.com/phiat/claude-esp/internal/trigger"
+)
+
+type def struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+func TestSchemaMatchesTypes(t *testing.T) {
+	var s struct {
+		ID   string         `json:"$id"`
+		Defs map[string]def `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSON(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s.ID, "/v1/") {
+		t.Errorf("$id %q doesn't carry version %d", s.ID, Version)
+	}
+	for name, typ := range map[string]any{
+		"item":          sink.Item{},
+		"edit":          edits.Event{},
+		"range":         edits.Range{},
+		"heartbeat":     heartbeat.Heartbeat{},
+		"tool":          heartbeat.Tool{},
+		"notification":  notify.Event{},
+		"trigger_event": trigger.Event{},
+	} {
+		d, ok := s.Defs[name]
+		if !ok {
+			t.Errorf("no $defs/%s", name)
+			continue
+		}
+		var fields, required []string
+		rt := reflect.TypeOf(typ)
+		for i := range rt.NumField() {
+			tag, opts, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, tag)
+			if opts != "omitempty" && opts != "omitzero" {
+				required = append(required, tag)
+			}
+			if _, ok := d.Properties[tag]; !ok {
+				t.Errorf("%s: field %q missing from the schema", name, tag)
+			}
+		}
+		for prop := range d.Properties {
+			if !slices.Contains(fields, prop) {
+				t.Errorf("%s: schema property %q is not a field of %s", name, prop, rt)
+			}
+		}
+		slices.Sort(required)
+		slices.Sort(d.Required)
+		if !slices.Equal(required, d.Required) {
+			t.Errorf("%s: required = %v, want the fields without omitempty or omitzero %v", name, d.Required, required)
+		}
+	}
+}
+
+// The schema lists every item type, each one -filter accepts.
+func TestItemTypes(t *testing.T) {
+	var s struct {
+		Defs struct {
+			Item struct {
+				Properties struct {
+					Type struct {
+						Enum []string `json:"enum"`
+					} `json:"type"`
+				} `json:"properties"`
+			} `json:"item"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSON(), &s); err != nil {
+		t.Fatal(err)
+	}
+	enum := s.Defs.Item.Properties.Type.Enum
+	for _, typ := range enum {
+		if _, err := filter.Parse("type = " + typ); err != nil {
+			t.Errorf("schema type %q: %v", typ, err)
+		}
+	}
+	for _, typ := range []parser.StreamItemType{
+		parser.TypeThinking, parser.TypeToolInput, parser.TypeToolOutput, parser.TypeText,
+		parser.TypeTurnMarker, parser.TypeCompactMarker, parser.TypeHookOutput, parser.TypeDiagnostics,
+		parser.TypePRLink, parser.TypeDebug, parser.TypeSessionTitle, parser.TypeCommand, parser.TypeUnknown,
+	} {
+		if !slices.Contains(enum, string(typ)) {
+			t.Errorf("schema lacks item type %q", typ)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Scope is what a token allows.
+type Scope int
+
+const (
+	// ScopeRead allows every GET endpoint.
+	ScopeRead Scope = iota
+	// ScopeAdmin also allows the endpoints that change something, such as
+	// POST /api/push.
+	ScopeAdmin
+)
+
+func (s Scope) String() string {
+	if s == ScopeAdmin {
+		return "admin"
+	}
+	return "read"
+}
+
+// minTokenLength keeps guessable tokens out of token files.
+const minTokenLength = 16
+
+// Token is a bearer token and its scope.
+type Token struct {
+	Value string
+	Scope Scope
+}
+
+// LoadTokens reads a token file: one token per line, optionally followed
+// by its scope (read, the default, or admin). Blank lines and lines
+// starting with # are ignored.
+//
+//	# dashboards
+//	3f9a0c1e7b2d4e5f8a9b  read
+//	77bc41d09e2f3a6b5c8d  admin
+func LoadTokens(path string) ([]Token, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var tokens []Token
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		tok := Token{Value: fields[0]}
+		if len(tok.Value) < minTokenLength {
+			return nil, fmt.Errorf("%s:%d: token shorter than %d characters", path, n, minTokenLength)
+		}
+		switch {
+		case len(fields) == 1 || fields[1] == "read":
+		case len(fields) == 2 && fields[1] == "admin":
+			tok.Scope = ScopeAdmin
+		default:
+			return nil, fmt.Errorf("%s:%d: want \"<token> [read|admin]\"", path, n)
+		}
+		tokens = append(tokens, tok)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s: no tokens", path)
+	}
+	return tokens, nil
+}
+
+// authorize checks r's token against the server's, answering 401 or 403
+// itself when it falls short. /healthz stays open for probes. The token
+// comes as "Authorization: Bearer <token>", or ?token= for clients that
+// can't set headers, such as a browser's EventSource.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if len(s.tokens) == 0 || r.URL.Path == "/healthz" {
+		return true
+	}
+	need := ScopeRead
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		need = ScopeAdmin
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		given = r.URL.Query().Get("token")
+	}
+	scope, found := Scope(0), false
+	for _, tok := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(tok.Value)) == 1 {
+			scope, found = tok.Scope, true
+		}
+	}
+	switch {
+	case !found:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="claude-esp"`)
+		http.Error(w, "missing or unknown token", http.StatusUnauthorized)
+		return false
+	case scope < need:
+		http.Error(w, fmt.Sprintf("this needs a token with %s scope", need), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+// AcceptPush enables POST /api/push?host=<name>, where other instances
+// (claude-esp push) send their items as NDJSON. Each item is labelled
+// with the pushing host and passed to fn, which decides what to publish;
+// fn runs on the request's goroutine, so a slow one slows that pusher
+// down. Without AcceptPush the endpoint refuses pushes.
+func (s *Server) AcceptPush(fn func(parser.StreamItem)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accept = fn
+}
+
+func (s *Server) push(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	accept := s.accept
+	s.mu.Unlock()
+	if accept == nil {
+		http.Error(w, "this server does not accept pushed items (start it with serve -accept-push)", http.StatusForbidden)
+		return
+	}
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		http.Error(w, "missing host", http.StatusBadRequest)
+		return
+	}
+	dec := json.NewDecoder(r.Body)
+	for {
+		var it sink.Item
+		if err := dec.Decode(&it); err != nil {
+			if errors.Is(err, io.EOF) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			http.Error(w, fmt.Sprintf("bad item: %v", err), http.StatusBadRequest)
+			return
+		}
+		item := it.StreamItem()
+		if item.Host == "" { // relayed items keep their original host
+			item.Host = host
+		}
+		accept(item)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	itemfilter "github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+// HistoryItems is how many of the latest items the query endpoints keep.
+// The history lives in memory and starts empty with each run (plus
+// whatever history the watcher replays at startup). SetRetention can
+// limit it further by age.
+const HistoryItems = 10000
+
+// Query defaults and bounds for /api/query's limit.
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// record is an item in the query history. Seq numbers items as they were
+// published and is the pagination cursor.
+type record struct {
+	seq  int64
+	item parser.StreamItem
+}
+
+// QueryPage is a page of /api/query results. Next is the after= value for
+// the following page; 0 means this is the last one.
+type QueryPage struct {
+	Items []sink.Item `json:"items"`
+	Next  int64       `json:"next,omitempty"`
+}
+
+// AggregateRow is one group of /api/query/aggregate.
+type AggregateRow struct {
+	Key          string `json:"key"`
+	Count        int    `json:"count"`
+	Errors       int    `json:"errors"`
+	DurationMs   int64  `json:"duration_ms"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+}
+
+// groupKeys are what /api/query/aggregate can group by.
+var groupKeys = map[string]func(*parser.StreamItem) string{
+	"type":    func(it *parser.StreamItem) string { return string(it.Type) },
+	"tool":    func(it *parser.StreamItem) string { return it.ToolName },
+	"agent":   func(it *parser.StreamItem) string { return it.AgentName },
+	"session": func(it *parser.StreamItem) string { return it.SessionID },
+	"model":   func(it *parser.StreamItem) string { return it.Model },
+	"host":    func(it *parser.StreamItem) string { return it.Host },
+}
+
+// remember adds item to the query history. Tool results are named after
+// their call, so tool = Bash finds both. Caller holds s.mu.
+func (s *Server) remember(item parser.StreamItem) {
+	switch item.Type {
+	case parser.TypeToolInput:
+		if item.ToolID != "" {
+			s.toolNames[item.ToolID] = item.ToolName
+		}
+	case parser.TypeToolOutput:
+		if name, ok := s.toolNames[item.ToolID]; ok {
+			if item.ToolName == "" {
+				item.ToolName = name
+			}
+			delete(s.toolNames, item.ToolID)
+		}
+	}
+	s.seq++
+	s.bytes += itemBytes(item)
+	s.history = append(s.history, record{seq: s.seq, item: item})
+	if len(s.history) > HistoryItems {
+		// Reslicing is cheap; append copies only the live records when
+		// it grows the array.
+		s.drop(s.history[0].item)
+		s.history[0] = record{}
+		s.history = s.history[1:]
+	}
+}
+
+// query holds the parameters common to the query endpoints.
+type query struct {
+	expr         *itemfilter.Expr
+	session      string
+	since, until time.Time
+}
+
+func parseQuery(r *http.Request, now time.Time) (query, error) {
+	v := r.URL.Query()
+	var q query
+	var err error
+	if q.expr, err = itemfilter.Parse(v.Get("filter")); err != nil {
+		return q, err
+	}
+	q.session = v.Get("session")
+	if q.since, err = parseInstant(v.Get("since"), now); err != nil {
+		return q, fmt.Errorf("since: %w", err)
+	}
+	if q.until, err = parseInstant(v.Get("until"), now); err != nil {
+		return q, fmt.Errorf("until: %w", err)
+	}
+	return q, nil
+}
+
+// parseInstant reads a time as RFC 3339 or as a duration before now
+// ("15m"). "" is the zero time, meaning no bound.
+func parseInstant(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want an RFC 3339 time or a duration like 15m, got %q", s)
+	}
+	return t, nil
+}
+
+// everything reports whether q selects every item, so that the totals of
+// dropped items belong in its aggregates.
+func (q query) everything() bool {
+	return q.expr == nil && q.session == "" && q.since.IsZero() && q.until.IsZero()
+}
+
+func (q query) matches(it parser.StreamItem) bool {
+	return (q.session == "" || it.SessionID == q.session) &&
+		(q.since.IsZero() || !it.Timestamp.Before(q.since)) &&
+		(q.until.IsZero() || it.Timestamp.Before(q.until)) &&
+		q.expr.Match(it)
+}
+
+// matching returns the history records q selects. Caller holds s.mu.
+func (s *Server) matching(q query) []record {
+	var out []record
+	for _, rec := range s.history {
+		if q.matches(rec.item) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func (s *Server) queryItems(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, after := defaultQueryLimit, int64(0)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, maxQueryLimit)
+	}
+	if v := r.URL.Query().Get("after"); v != "" {
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid after %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	recs := s.matching(q)
+	s.mu.Unlock()
+	page := QueryPage{Items: []sink.Item{}}
+	var last int64
+	for _, rec := range recs {
+		if rec.seq <= after {
+			continue
+		}
+		if len(page.Items) == limit {
+			page.Next = last // there is more
+			break
+		}
+		page.Items = append(page.Items, sink.NewItem(rec.item))
+		last = rec.seq
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (s *Server) aggregate(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	by := r.URL.Query().Get("by")
+	key, ok := groupKeys[by]
+	if !ok && by != "" {
+		http.Error(w, fmt.Sprintf("invalid by %q: want type, tool, agent, session, model or host", by), http.StatusBadRequest)
+		return
+	}
+	groups := map[string]*AggregateRow{}
+	s.mu.Lock()
+	if q.everything() {
+		for k, total := range s.totals[by] {
+			row := *total
+			groups[k] = &row
+		}
+	}
+	for _, rec := range s.matching(q) {
+		k := ""
+		if key != nil {
+			k = key(&rec.item)
+		}
+		row := groups[k]
+		if row == nil {
+			row = &AggregateRow{Key: k}
+			groups[k] = row
+		}
+		row.add(rec.item)
+	}
+	s.mu.Unlock()
+	rows := make([]AggregateRow, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b AggregateRow) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Key, b.Key))
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
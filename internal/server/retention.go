@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// compactInterval is how often the query history is checked against the
+// retention period, so an idle server drops old items too.
+const compactInterval = time.Minute
+
+// HistoryStats is the body of /api/query/stats.
+type HistoryStats struct {
+	Items         int       `json:"items"`
+	Bytes         int64     `json:"bytes"` // content held, roughly the history's memory
+	Oldest        time.Time `json:"oldest,omitzero"`
+	Dropped       int64     `json:"dropped"` // items aged or pushed out, still in the totals
+	RetainSeconds int64     `json:"retain_seconds,omitempty"`
+}
+
+// SetRetention drops items older than d from the query history; 0 keeps
+// them until HistoryItems newer ones push them out. Dropped items still
+// count in aggregates that select everything, so totals cover the whole
+// run.
+func (s *Server) SetRetention(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retain = max(d, 0)
+	s.compact(time.Now())
+}
+
+// compactLoop applies the retention period until the server closes.
+func (s *Server) compactLoop() {
+	t := time.NewTicker(compactInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-t.C:
+			s.mu.Lock()
+			s.compact(now)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// compact drops items past the retention period or beyond HistoryItems,
+// folding them into the totals, and gives back memory a shrunken history
+// no longer needs. Caller holds s.mu.
+func (s *Server) compact(now time.Time) {
+	extra := len(s.history) - HistoryItems
+	var cutoff time.Time
+	if s.retain > 0 {
+		cutoff = now.Add(-s.retain)
+	}
+	kept := s.history[:0]
+	for i, rec := range s.history {
+		if i < extra || rec.item.Timestamp.Before(cutoff) {
+			s.drop(rec.item)
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	clear(s.history[len(kept):])
+	s.history = kept
+	if cap(s.history) > 2*len(s.history)+HistoryItems/10 {
+		s.history = append([]record(nil), s.history...)
+	}
+}
+
+// drop folds an item leaving the history into the totals, and forgets the
+// name of a tool call whose result never came. Caller holds s.mu.
+func (s *Server) drop(item parser.StreamItem) {
+	if item.Type == parser.TypeToolInput {
+		delete(s.toolNames, item.ToolID)
+	}
+	s.dropped++
+	s.bytes -= itemBytes(item)
+	addTo(s.totals, "", "", item)
+	for by, key := range groupKeys {
+		addTo(s.totals, by, key(&item), item)
+	}
+}
+
+// addTo adds item to the row for key in groups[by].
+func addTo(groups map[string]map[string]*AggregateRow, by, key string, item parser.StreamItem) {
+	rows := groups[by]
+	if rows == nil {
+		rows = make(map[string]*AggregateRow)
+		groups[by] = rows
+	}
+	row := rows[key]
+	if row == nil {
+		row = &AggregateRow{Key: key}
+		rows[key] = row
+	}
+	row.add(item)
+}
+
+func (row *AggregateRow) add(item parser.StreamItem) {
+	row.Count++
+	if item.IsError || item.ExitCode != 0 {
+		row.Errors++
+	}
+	row.DurationMs += item.DurationMs
+	row.InputTokens += item.InputTokens
+	row.OutputTokens += item.OutputTokens
+}
+
+// itemBytes is what an item holds beyond its fixed fields.
+func itemBytes(item parser.StreamItem) int64 {
+	return int64(len(item.Content) + len(item.ToolName) + len(item.ToolID) + len(item.AgentName) + len(item.Cwd))
+}
+
+func (s *Server) historyStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	stats := HistoryStats{
+		Items:         len(s.history),
+		Bytes:         s.bytes,
+		Dropped:       s.dropped,
+		RetainSeconds: int64(s.retain / time.Second),
+	}
+	for _, rec := range s.history {
+		if stats.Oldest.IsZero() || rec.item.Timestamp.Before(stats.Oldest) {
+			stats.Oldest = rec.item.Timestamp
+		}
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,337 @@
+// Package server serves the live stream over HTTP as NDJSON or
+// server-sent events, for consumers that can't read a unix socket such as
+// editor plugins.
+package server
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/schema"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+// RecentEdits is how many edit events /api/edits/recent keeps.
+const RecentEdits = 100
+
+// filter selects what a subscriber receives. Empty fields match anything.
+type filter struct {
+	session string
+	path    string // edit feed: path prefix
+}
+
+func (f filter) matches(session, path string) bool {
+	return (f.session == "" || f.session == session) &&
+		(f.path == "" || strings.HasPrefix(path, f.path))
+}
+
+type subscriber struct {
+	feed   string // sink.FeedItems or sink.FeedEdits
+	filter filter
+	ch     chan []byte
+}
+
+// Server is an HTTP endpoint for the live stream. It implements
+// sink.Publisher; like the other sinks, subscribers only see what is
+// published after they connect and a slow one misses lines rather than
+// stalling the rest.
+//
+//	GET /healthz              liveness probe
+//	GET /api/items            stream items
+//	GET /api/edits            file-edit events
+//	GET /api/edits/recent     the last RecentEdits edit events as a JSON array
+//	GET /api/status           per-session heartbeats as a JSON array
+//	GET /api/query            past items matching a -filter expression, paged
+//	GET /api/query/aggregate  counts, errors, time and tokens per group
+//	GET /api/query/stats      size of the query history
+//	POST /api/push            items from other hosts, once AcceptPush is set
+//	GET /api/schema           the JSON Schema of all of the above
+//
+// Every response carries the schema version in schema.Header.
+// Streams are NDJSON, or server-sent events when the request accepts
+// text/event-stream. ?session= limits any endpoint to one session and
+// ?path= limits the edit endpoints to paths under a prefix. ?stall=<dur>
+// sets the status endpoint's stall threshold (default
+// heartbeat.DefaultStallAfter). The query endpoints also take ?filter=,
+// ?since= and ?until= (RFC 3339 or a duration ago); see query.go.
+type Server struct {
+	srv      *http.Server
+	listener net.Listener
+	tracker  *edits.Tracker
+	tokens   []Token
+	tls      bool
+
+	mu     sync.Mutex
+	beats  *heartbeat.Tracker
+	subs   map[*subscriber]struct{}
+	recent []edits.Event
+	closed bool
+	accept func(parser.StreamItem) // POST /api/push; see push.go
+
+	// Query history; see query.go and retention.go.
+	history   []record
+	seq       int64
+	toolNames map[string]string // tool_use ID → name, until its result or its call leaves the history
+	retain    time.Duration
+	totals    map[string]map[string]*AggregateRow // by → key → dropped items
+	dropped   int64
+	bytes     int64
+	done      chan struct{} // closed by Close, stops compactLoop
+}
+
+// Options secures a server. The zero value serves plain HTTP to anyone,
+// which is only safe on a loopback address.
+type Options struct {
+	// Tokens, if any, are required on every request but /healthz; see
+	// LoadTokens and authorize.
+	Tokens []Token
+	// TLS, if set, serves HTTPS; see TLSConfig.
+	TLS *tls.Config
+}
+
+// Listen starts serving on addr (host:port; port 0 picks a free one).
+func Listen(addr string, opts Options) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("http %s: %w", addr, err)
+	}
+	if opts.TLS != nil {
+		l = tls.NewListener(l, opts.TLS)
+	}
+	s := &Server{
+		listener:  l,
+		tokens:    opts.Tokens,
+		tls:       opts.TLS != nil,
+		tracker:   edits.NewTracker(),
+		beats:     heartbeat.NewTracker(),
+		subs:      make(map[*subscriber]struct{}),
+		toolNames: make(map[string]string),
+		totals:    make(map[string]map[string]*AggregateRow),
+		done:      make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /api/items", func(w http.ResponseWriter, r *http.Request) {
+		s.stream(w, r, sink.FeedItems)
+	})
+	mux.HandleFunc("GET /api/edits", func(w http.ResponseWriter, r *http.Request) {
+		s.stream(w, r, sink.FeedEdits)
+	})
+	mux.HandleFunc("GET /api/edits/recent", s.recentEdits)
+	mux.HandleFunc("GET /api/status", s.status)
+	mux.HandleFunc("GET /api/query", s.queryItems)
+	mux.HandleFunc("GET /api/query/aggregate", s.aggregate)
+	mux.HandleFunc("GET /api/query/stats", s.historyStats)
+	mux.HandleFunc("POST /api/push", s.push)
+	mux.HandleFunc("GET /api/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(schema.JSON())
+	})
+	version := strconv.Itoa(schema.Version)
+	s.srv = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(schema.Header, version)
+		if s.authorize(w, r) {
+			mux.ServeHTTP(w, r)
+		}
+	})}
+	go s.srv.Serve(l)
+	go s.compactLoop()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// TLS reports whether the server speaks HTTPS.
+func (s *Server) TLS() bool {
+	return s.tls
+}
+
+// Publish sends item to item subscribers and, if it completes a file edit,
+// the edit to edit subscribers.
+func (s *Server) Publish(item parser.StreamItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.beats.Add(item)
+	if item.Host != "" && item.Cwd != "" {
+		s.beats.SetProject(item.SessionID, item.Host+":"+item.Cwd)
+	}
+	s.remember(item)
+	if line, err := sink.MarshalLine(item); err == nil {
+		s.broadcast(sink.FeedItems, item.SessionID, "", line)
+	}
+	ev, ok := s.tracker.Add(item)
+	if !ok {
+		return
+	}
+	s.recent = append(s.recent, ev)
+	if len(s.recent) > RecentEdits {
+		s.recent = s.recent[len(s.recent)-RecentEdits:]
+	}
+	if line, err := ev.MarshalLine(); err == nil {
+		s.broadcast(sink.FeedEdits, ev.SessionID, ev.Path, line)
+	}
+}
+
+// SetProject records a session's project path for /api/status.
+func (s *Server) SetProject(sessionID, project string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beats.SetProject(sessionID, project)
+}
+
+// broadcast must be called with s.mu held.
+func (s *Server) broadcast(feed, session, path string, line []byte) {
+	for sub := range s.subs {
+		if sub.feed != feed || !sub.filter.matches(session, path) {
+			continue
+		}
+		select {
+		case sub.ch <- line:
+		default: // slow consumer: drop rather than block the TUI
+		}
+	}
+}
+
+// Close disconnects every subscriber and stops the server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+	s.mu.Unlock()
+	if err := s.srv.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) subscribe(feed string, f filter) *subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	sub := &subscriber{feed: feed, filter: f, ch: make(chan []byte, sink.ClientBuffer)}
+	s.subs[sub] = struct{}{}
+	return sub
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
+
+func requestFilter(r *http.Request) filter {
+	q := r.URL.Query()
+	return filter{session: q.Get("session"), path: q.Get("path")}
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, feed string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sub := s.subscribe(feed, requestFilter(r))
+	if sub == nil {
+		http.Error(w, "server closing", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribe(sub)
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case line, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if sse {
+				line = []byte("data: " + strings.TrimSuffix(string(line), "\n") + "\n\n")
+			}
+			if _, err := w.Write(line); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) recentEdits(w http.ResponseWriter, r *http.Request) {
+	f := requestFilter(r)
+	s.mu.Lock()
+	out := make([]edits.Event, 0, len(s.recent))
+	for _, ev := range s.recent {
+		if f.matches(ev.SessionID, ev.Path) {
+			out = append(out, ev)
+		}
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	stall := heartbeat.DefaultStallAfter
+	if v := r.URL.Query().Get("stall"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid stall duration %q", v), http.StatusBadRequest)
+			return
+		}
+		stall = d
+	}
+	session := r.URL.Query().Get("session")
+	s.mu.Lock()
+	beats := s.beats.Snapshot(time.Now(), stall)
+	s.mu.Unlock()
+	out := beats[:0]
+	for _, hb := range beats {
+		if session == "" || hb.SessionID == session {
+			out = append(out, hb)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
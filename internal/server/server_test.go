@@ -0,0 +1,485 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/schema"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+func write(session, id, path string) (parser.StreamItem, parser.StreamItem) {
+	in := parser.StreamItem{
+		Type:      parser.TypeToolInput,
+		SessionID: session,
+		ToolName:  "Write",
+		ToolID:    id,
+		ToolInput: json.RawMessage(`{"file_path":"` + path + `","content":"x\n"}`),
+		Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	out := in
+	out.Type, out.ToolInput = parser.TypeToolOutput, nil
+	return in, out
+}
+
+func listen(t *testing.T) *Server {
+	t.Helper()
+	s, err := Listen("127.0.0.1:0", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// subscribeHTTP opens a stream and waits until the server has registered it.
+func subscribeHTTP(t *testing.T, s *Server, path, accept string) *bufio.Reader {
+	t.Helper()
+	s.mu.Lock()
+	before := len(s.subs)
+	s.mu.Unlock()
+	req, _ := http.NewRequest("GET", "http://"+s.Addr()+path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		n := len(s.subs)
+		s.mu.Unlock()
+		if n > before {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func readLine(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := r.ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a line")
+		return ""
+	}
+}
+
+func TestEditStreamFilters(t *testing.T) {
+	s := listen(t)
+	r := subscribeHTTP(t, s, "/api/edits?session=s1&path=/src/", "")
+
+	for _, it := range []struct{ session, id, path string }{
+		{"s2", "t1", "/src/other.go"}, // wrong session
+		{"s1", "t2", "/tmp/scratch"},  // outside the path prefix
+		{"s1", "t3", "/src/main.go"},
+	} {
+		in, out := write(it.session, it.id, it.path)
+		s.Publish(in)
+		s.Publish(out)
+	}
+
+	var ev edits.Event
+	if err := json.Unmarshal([]byte(readLine(t, r)), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.ToolID != "t3" || ev.Path != "/src/main.go" {
+		t.Errorf("got %+v, want the t3 edit of /src/main.go", ev)
+	}
+}
+
+func TestItemStreamSSE(t *testing.T) {
+	s := listen(t)
+	r := subscribeHTTP(t, s, "/api/items", "text/event-stream")
+	in, _ := write("s1", "t1", "/a")
+	s.Publish(in)
+
+	line := readLine(t, r)
+	data, ok := strings.CutPrefix(line, "data: ")
+	if !ok {
+		t.Fatalf("want an SSE data line, got %q", line)
+	}
+	var got sink.Item
+	if err := json.Unmarshal([]byte(data), &got); err != nil || got.ToolID != "t1" {
+		t.Errorf("got %q (err %v)", data, err)
+	}
+}
+
+func TestRecentEdits(t *testing.T) {
+	s := listen(t)
+	for i, path := range []string{"/a", "/b"} {
+		in, out := write("s1", string(rune('1'+i)), path)
+		s.Publish(in)
+		s.Publish(out)
+	}
+	resp, err := http.Get("http://" + s.Addr() + "/api/edits/recent?path=/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var got []edits.Event
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Path != "/b" {
+		t.Errorf("recent = %s", body)
+	}
+}
+
+func TestCloseEndsStreams(t *testing.T) {
+	s := listen(t)
+	r := subscribeHTTP(t, s, "/api/items", "")
+	s.Close()
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.ReadString('\n')
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the stream to end")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after Close")
+	}
+	s.Publish(parser.StreamItem{}) // must not panic after Close
+}
+
+func TestStatus(t *testing.T) {
+	s := listen(t)
+	in, _ := write("s1", "t1", "/a")
+	in.Timestamp = time.Now()
+	s.Publish(in)
+	s.SetProject("s1", "/src/app")
+
+	resp, err := http.Get("http://" + s.Addr() + "/api/status?session=s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got []heartbeat.Heartbeat
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].State != heartbeat.StateWorking || got[0].CurrentTool == nil || got[0].Project != "/src/app" {
+		t.Errorf("status = %+v", got)
+	}
+
+	resp, err = http.Get("http://" + s.Addr() + "/api/status?stall=soon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad stall: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s := listen(t)
+	resp, err := http.Get("http://" + s.Addr() + "/api/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != string(schema.JSON()) {
+		t.Errorf("GET /api/schema served %d bytes, want the schema", len(body))
+	}
+	if v := resp.Header.Get(schema.Header); v != "1" {
+		t.Errorf("%s = %q, want 1", schema.Header, v)
+	}
+}
+
+func getJSON(t *testing.T, s *Server, path string, v any) int {
+	t.Helper()
+	resp, err := http.Get("http://" + s.Addr() + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestQuery(t *testing.T) {
+	s := listen(t)
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		id := fmt.Sprintf("t%d", i)
+		in := parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: id, Timestamp: t0.Add(time.Duration(i) * time.Minute)}
+		out := parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolID: id, DurationMs: 10, Timestamp: in.Timestamp}
+		if i%2 == 1 {
+			out.IsError = true
+		}
+		s.Publish(in)
+		s.Publish(out)
+	}
+	s.Publish(parser.StreamItem{Type: parser.TypeText, SessionID: "s2", OutputTokens: 7, Timestamp: t0})
+
+	// Failed Bash results since 12:01, two at a time.
+	path := "/api/query?filter=" + url.QueryEscape("tool = Bash and error = true") + "&since=2025-01-01T12:01:00Z&limit=1"
+	var page QueryPage
+	if code := getJSON(t, s, path, &page); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(page.Items) != 1 || page.Items[0].ToolID != "t1" || page.Next == 0 {
+		t.Fatalf("first page = %+v", page)
+	}
+	var next QueryPage
+	getJSON(t, s, path+"&after="+strconv.FormatInt(page.Next, 10), &next)
+	if len(next.Items) != 1 || next.Items[0].ToolID != "t3" || next.Next != 0 {
+		t.Errorf("last page = %+v", next)
+	}
+
+	var rows []AggregateRow
+	getJSON(t, s, "/api/query/aggregate?by=type", &rows)
+	want := []AggregateRow{
+		{Key: "tool_input", Count: 5},
+		{Key: "tool_output", Count: 5, Errors: 2, DurationMs: 50},
+		{Key: "text", Count: 1, OutputTokens: 7},
+	}
+	if !slices.Equal(rows, want) {
+		t.Errorf("aggregate = %+v, want %+v", rows, want)
+	}
+
+	for _, bad := range []string{"/api/query?filter=tool+%3D", "/api/query?since=yesterday", "/api/query?limit=0", "/api/query/aggregate?by=color"} {
+		if code := getJSON(t, s, bad, &rows); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, code)
+		}
+	}
+}
+
+func TestToolNamesLeaveWithTheirCall(t *testing.T) {
+	s := listen(t)
+	now := time.Now()
+	// Calls whose results never come, e.g. from a session that ended.
+	for i := range 3 {
+		s.Publish(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: fmt.Sprintf("t%d", i), Timestamp: now.Add(-2 * time.Hour)})
+	}
+	s.Publish(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Read", ToolID: "t3", Timestamp: now})
+	s.SetRetention(time.Hour)
+
+	s.mu.Lock()
+	n := len(s.toolNames)
+	s.mu.Unlock()
+	if n != 1 {
+		t.Errorf("%d tool names kept, want only the call still in the history", n)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	s := listen(t)
+	now := time.Now()
+	for i, age := range []time.Duration{3 * time.Hour, 2 * time.Hour, time.Minute} {
+		s.Publish(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", Content: "hello", OutputTokens: int64(i + 1), Timestamp: now.Add(-age)})
+	}
+	s.SetRetention(time.Hour)
+
+	var stats HistoryStats
+	getJSON(t, s, "/api/query/stats", &stats)
+	if stats.Items != 1 || stats.Dropped != 2 || stats.Bytes != int64(len("hello")) || stats.RetainSeconds != 3600 {
+		t.Errorf("stats = %+v", stats)
+	}
+	var page QueryPage
+	getJSON(t, s, "/api/query", &page)
+	if len(page.Items) != 1 || page.Items[0].OutputTokens != 3 {
+		t.Errorf("query = %+v, want the newest item", page)
+	}
+
+	// Dropped items still count when the aggregate selects everything.
+	var rows []AggregateRow
+	getJSON(t, s, "/api/query/aggregate?by=session", &rows)
+	if want := []AggregateRow{{Key: "s1", Count: 3, OutputTokens: 6}}; !slices.Equal(rows, want) {
+		t.Errorf("all-time aggregate = %+v, want %+v", rows, want)
+	}
+	getJSON(t, s, "/api/query/aggregate?since=2h", &rows)
+	if want := []AggregateRow{{Count: 1, OutputTokens: 3}}; !slices.Equal(rows, want) {
+		t.Errorf("aggregate since 2h = %+v, want %+v", rows, want)
+	}
+}
+
+func TestPush(t *testing.T) {
+	s := listen(t)
+	resp, err := http.Post("http://"+s.Addr()+"/api/push?host=ci-1", "application/x-ndjson", strings.NewReader("{}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("push without AcceptPush: status %d, want 403", resp.StatusCode)
+	}
+
+	got := make(chan parser.StreamItem, 2)
+	s.AcceptPush(func(item parser.StreamItem) { got <- item })
+	p, err := sink.Push(s.Addr(), "ci-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.Publish(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", Content: "hi"})
+	p.Publish(parser.StreamItem{Type: parser.TypeText, SessionID: "s2", Content: "relayed", Host: "ci-2"})
+	for _, want := range []string{"ci-1", "ci-2"} {
+		select {
+		case item := <-got:
+			if item.Host != want {
+				t.Errorf("pushed %q from host %q, want %q", item.Content, item.Host, want)
+			}
+		case err := <-p.Errors:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("nothing pushed")
+		}
+	}
+
+	if _, err := sink.Push("", "ci-1"); err == nil {
+		t.Error("Push accepted an empty address")
+	}
+}
+
+func TestAuth(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/tokens"
+	os.WriteFile(path, []byte("# test tokens\nreadreadreadread0001\nadminadminadmin0001 admin\n"), 0o600)
+	tokens, err := LoadTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"short\n", "readreadreadread0001 write\n", "# nothing\n"} {
+		os.WriteFile(path, []byte(bad), 0o600)
+		if _, err := LoadTokens(path); err == nil {
+			t.Errorf("LoadTokens accepted %q", bad)
+		}
+	}
+
+	s, err := Listen("127.0.0.1:0", Options{Tokens: tokens})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.AcceptPush(func(parser.StreamItem) {})
+	do := func(method, path, token string) int {
+		req, _ := http.NewRequest(method, "http://"+s.Addr()+path, strings.NewReader(""))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for _, tt := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/healthz", "", http.StatusOK},
+		{"GET", "/api/status", "", http.StatusUnauthorized},
+		{"GET", "/api/status", "wrongwrongwrong0001", http.StatusUnauthorized},
+		{"GET", "/api/status", "readreadreadread0001", http.StatusOK},
+		{"GET", "/api/status?token=readreadreadread0001", "", http.StatusOK},
+		{"POST", "/api/push?host=h", "readreadreadread0001", http.StatusForbidden},
+		{"POST", "/api/push?host=h", "adminadminadmin0001", http.StatusNoContent},
+		{"GET", "/api/status", "adminadminadmin0001", http.StatusOK},
+	} {
+		if got := do(tt.method, tt.path, tt.token); got != tt.want {
+			t.Errorf("%s %s with %q: status %d, want %d", tt.method, tt.path, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestSelfSignedTLS(t *testing.T) {
+	dir := t.TempDir()
+	cert, err := SelfSignedCert(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := SelfSignedCert(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint(again) != Fingerprint(cert) {
+		t.Error("the certificate changed between runs")
+	}
+	s, err := Listen("127.0.0.1:0", Options{TLS: TLSConfig(cert), Tokens: []Token{{Value: "readreadreadread0001"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	t.Setenv(sink.TokenEnv, "readreadreadread0001")
+	t.Setenv(sink.PinEnv, strings.ToUpper(Fingerprint(cert)))
+	resp, err := sink.HTTPClient().Get("https://" + s.Addr() + "/api/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("pinned, with token: status %d", resp.StatusCode)
+	}
+	t.Setenv(sink.PinEnv, strings.Repeat("0", 64))
+	if _, err := sink.HTTPClient().Get("https://" + s.Addr() + "/api/status"); err == nil {
+		t.Error("a certificate with another fingerprint was accepted")
+	}
+}
+
+// The query responses are in the event schema, field for field.
+func TestQuerySchema(t *testing.T) {
+	var doc struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(schema.JSON(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for name, typ := range map[string]any{"query_page": QueryPage{}, "aggregate_row": AggregateRow{}, "history_stats": HistoryStats{}} {
+		var fields []string
+		rt := reflect.TypeOf(typ)
+		for i := range rt.NumField() {
+			tag, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, tag)
+		}
+		var props []string
+		for p := range doc.Defs[name].Properties {
+			props = append(props, p)
+		}
+		slices.Sort(fields)
+		slices.Sort(props)
+		if !slices.Equal(fields, props) {
+			t.Errorf("%s: schema properties %v, fields %v", name, props, fields)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package share mirrors the rendered TUI to read-only viewers over TCP, so
+// a pair can watch the same live view with `telnet host port` without
+// access to the host's terminal or tmux.
+package share
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxViewers caps concurrent viewers; further connections are told so
+	// and closed.
+	MaxViewers = 8
+	// frameInterval rate-limits redraws per viewer.
+	frameInterval = 100 * time.Millisecond
+	// writeTimeout drops a viewer whose connection stops draining.
+	writeTimeout = 5 * time.Second
+)
+
+// Telnet negotiation: the server echoes (so the client stops echoing typed
+// keys over the view) and suppresses go-ahead (character mode).
+var telnetHello = []byte{255, 251, 1, 255, 251, 3} // IAC WILL ECHO, IAC WILL SGA
+
+// Server accepts viewers and sends each the latest frame whenever it
+// changes. Viewer input is read and discarded: viewers cannot control the
+// TUI.
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	frame   string
+	version uint64
+	viewers map[*viewer]struct{}
+	closed  bool
+}
+
+type viewer struct {
+	conn net.Conn
+	wake chan struct{} // a new frame is available
+}
+
+// Listen starts accepting viewers on addr (e.g. ":2222" or "127.0.0.1:0").
+func Listen(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("share %s: %w", addr, err)
+	}
+	s := &Server{listener: l, viewers: make(map[*viewer]struct{})}
+	go s.accept()
+	return s, nil
+}
+
+// Addr returns the address viewers connect to.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Viewers returns the number of connected viewers.
+func (s *Server) Viewers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.viewers)
+}
+
+// Frame sets the current rendered view. It is cheap to call on every
+// render: unchanged frames are ignored and viewers are only woken, never
+// written to, from here.
+func (s *Server) Frame(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if frame == s.frame {
+		return
+	}
+	s.frame = frame
+	s.version++
+	for v := range s.viewers {
+		select {
+		case v.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close disconnects every viewer and stops listening.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for v := range s.viewers {
+		v.conn.Close()
+	}
+	s.mu.Unlock()
+	return s.listener.Close()
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		if len(s.viewers) >= MaxViewers {
+			s.mu.Unlock()
+			conn.Write([]byte("claude-esp: too many viewers\r\n"))
+			conn.Close()
+			continue
+		}
+		v := &viewer{conn: conn, wake: make(chan struct{}, 1)}
+		s.viewers[v] = struct{}{}
+		s.mu.Unlock()
+		v.wake <- struct{}{} // draw the current frame straight away
+		go s.serve(v)
+	}
+}
+
+func (s *Server) serve(v *viewer) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.viewers, v)
+		s.mu.Unlock()
+		v.conn.Close()
+	}()
+
+	// Discard input; a read error means the viewer left.
+	gone := make(chan struct{})
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			if _, err := v.conn.Read(buf); err != nil {
+				close(gone)
+				return
+			}
+		}
+	}()
+
+	if !v.write(telnetHello) || !v.write([]byte("\x1b[?25l\x1b[2J")) { // hide cursor, clear
+		return
+	}
+	var sent uint64
+	for {
+		select {
+		case <-gone:
+			return
+		case <-v.wake:
+		}
+		s.mu.Lock()
+		frame, version := s.frame, s.version
+		s.mu.Unlock()
+		if version == sent {
+			continue
+		}
+		if !v.write([]byte(render(frame))) {
+			return
+		}
+		sent = version
+		// Coalesce bursts of frames into one redraw per interval.
+		select {
+		case <-gone:
+			return
+		case <-time.After(frameInterval):
+		}
+	}
+}
+
+func (v *viewer) write(b []byte) bool {
+	v.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := v.conn.Write(b)
+	return err == nil
+}
+
+// render turns a frame into a redraw in place: home the cursor, draw each
+// line clearing its tail, then clear anything below. Lines end in CRLF as a
+// network terminal expects.
+func render(frame string) string {
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	lines := strings.Split(frame, "\n")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\x1b[J")
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package share
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readUntil reads from conn until the accumulated output contains want.
+func readUntil(t *testing.T, conn net.Conn, want string) string {
+	t.Helper()
+	var got bytes.Buffer
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for !strings.Contains(got.String(), want) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("waiting for %q: %v (got %q)", want, err, got.String())
+		}
+		got.Write(buf[:n])
+	}
+	return got.String()
+}
+
+func TestViewerSeesFrames(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Frame("header\nfirst frame")
+
+	conn, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	out := readUntil(t, conn, "first frame")
+	if !strings.HasPrefix(out, string(telnetHello)) {
+		t.Error("viewer should get the telnet negotiation first")
+	}
+	if !strings.Contains(out, "header\x1b[K\r\nfirst frame") {
+		t.Errorf("frame lines should be redrawn in place with CRLF, got %q", out)
+	}
+
+	// Typing is ignored; new frames keep coming.
+	conn.Write([]byte("q\r\n"))
+	s.Frame("header\nsecond frame")
+	readUntil(t, conn, "second frame")
+	if s.Viewers() != 1 {
+		t.Errorf("Viewers() = %d, want 1", s.Viewers())
+	}
+}
+
+func TestViewerLimitAndClose(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conns []net.Conn
+	for range MaxViewers {
+		c, err := net.Dial("tcp", s.Addr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		conns = append(conns, c)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Viewers() < MaxViewers && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	extra, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+	readUntil(t, extra, "too many viewers")
+
+	s.Close()
+	conns[0].SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 4096)
+	for {
+		_, err := conns[0].Read(buf)
+		if err == nil {
+			continue
+		}
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			t.Error("viewer was not disconnected by Close")
+		}
+		break
+	}
+}
@@ -0,0 +1,18 @@
+// Package shell runs the command strings users configure (hooks, -pipe,
+// triggers, the summarizer) in the platform shell.
+package shell
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// Command wraps a command string in the platform shell: sh -c, or cmd /C
+// on Windows. ctx kills it as exec.CommandContext does.
+func Command(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
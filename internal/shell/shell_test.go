@@ -0,0 +1,26 @@
+package shell
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out, err := Command(context.Background(), "echo $((1 + 2)) | tr 3 x").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "x" {
+		t.Errorf("output %q, err %v", out, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Command(ctx, "sleep 5").Run(); err == nil || time.Since(start) > 3*time.Second {
+		t.Errorf("cancelled command: err %v after %s", err, time.Since(start))
+	}
+}
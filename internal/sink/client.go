@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Environment variables for reaching a secured claude-esp serve, read by
+// HTTPClient.
+const (
+	TokenEnv = "CLAUDE_ESP_TOKEN"       // bearer token (serve -token-file)
+	PinEnv   = "CLAUDE_ESP_CERT_SHA256" // certificate fingerprint (serve -tls-self-signed)
+)
+
+// HTTPClient returns the client for another claude-esp's HTTP API, as
+// used by push and -attach. It sends $CLAUDE_ESP_TOKEN as a bearer token.
+// With $CLAUDE_ESP_CERT_SHA256 set it accepts exactly the certificate
+// with that fingerprint, as printed by serve -tls-self-signed, instead of
+// one signed by a trusted CA.
+func HTTPClient() *http.Client {
+	token, pin := os.Getenv(TokenEnv), normalizePin(os.Getenv(PinEnv))
+	if token == "" && pin == "" {
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if pin != "" {
+		transport.TLSClientConfig = &tls.Config{
+			// Verification is the pin check below, not the CA chain.
+			InsecureSkipVerify: true,
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				if len(cs.PeerCertificates) == 0 {
+					return errors.New("server sent no certificate")
+				}
+				sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+				if hex.EncodeToString(sum[:]) != pin {
+					return errors.New("server certificate doesn't match " + PinEnv)
+				}
+				return nil
+			},
+		}
+	}
+	return &http.Client{Transport: &bearer{token: token, next: transport}}
+}
+
+// normalizePin accepts a fingerprint with or without colons, in any case.
+func normalizePin(s string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+}
+
+// bearer adds a token to every request.
+type bearer struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (b *bearer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if b.token != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	return b.next.RoundTrip(r)
+}
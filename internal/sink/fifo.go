@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// fifoRetry is how often a FIFO sink checks for a reader.
+const fifoRetry = 500 * time.Millisecond
+
+// FIFO writes NDJSON items into a named pipe. Items published while no
+// reader has the pipe open are dropped, so a consumer sees the live stream
+// from the moment it attaches; readers may come and go.
+type FIFO struct {
+	path  string
+	lines chan []byte
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// OpenFIFO creates the named pipe at path if needed and starts writing to
+// it whenever a reader is attached.
+func OpenFIFO(path string) (*FIFO, error) {
+	if err := ensureFIFO(path); err != nil {
+		return nil, err
+	}
+	f := &FIFO{
+		path:  path,
+		lines: make(chan []byte, ClientBuffer),
+		done:  make(chan struct{}),
+	}
+	f.wg.Add(1)
+	go f.run()
+	return f, nil
+}
+
+// Publish queues item for the current reader, if any.
+func (f *FIFO) Publish(item parser.StreamItem) {
+	if line, err := MarshalLine(item); err == nil {
+		f.writeLine(line)
+	}
+}
+
+func (f *FIFO) writeLine(line []byte) {
+	select {
+	case f.lines <- line:
+	default:
+	}
+}
+
+// Close stops writing. The FIFO itself is left in place for the next run.
+func (f *FIFO) Close() error {
+	close(f.done)
+	f.wg.Wait()
+	return nil
+}
+
+func (f *FIFO) run() {
+	defer f.wg.Done()
+	for {
+		w, err := openFIFOWriter(f.path)
+		if err != nil {
+			// No reader yet: discard what queued meanwhile and retry.
+			select {
+			case <-f.done:
+				return
+			case <-time.After(fifoRetry):
+			}
+			f.drain()
+			continue
+		}
+		for open := true; open; {
+			select {
+			case line := <-f.lines:
+				if _, err := w.Write(line); err != nil {
+					open = false // reader went away (EPIPE)
+				}
+			case <-f.done:
+				w.Close()
+				return
+			}
+		}
+		w.Close()
+	}
+}
+
+func (f *FIFO) drain() {
+	for {
+		select {
+		case <-f.lines:
+		default:
+			return
+		}
+	}
+}
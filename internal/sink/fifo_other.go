@@ -0,0 +1,18 @@
+//go:build !unix
+
+package sink
+
+import (
+	"errors"
+	"io"
+)
+
+var errNoFIFO = errors.New("FIFO sinks need a unix system; use unix:// instead")
+
+func ensureFIFO(path string) error {
+	return errNoFIFO
+}
+
+func openFIFOWriter(path string) (io.WriteCloser, error) {
+	return nil, errNoFIFO
+}
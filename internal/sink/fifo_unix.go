@@ -0,0 +1,32 @@
+//go:build unix
+
+package sink
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// ensureFIFO creates a named pipe at path unless one already exists.
+func ensureFIFO(path string) error {
+	fi, err := os.Stat(path)
+	if err == nil {
+		if fi.Mode()&fs.ModeNamedPipe == 0 {
+			return fmt.Errorf("sink %s: exists and is not a FIFO", path)
+		}
+		return nil
+	}
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		return fmt.Errorf("sink %s: %w", path, err)
+	}
+	return nil
+}
+
+// openFIFOWriter opens the pipe for writing without blocking; it fails with
+// ENXIO while no reader has the pipe open.
+func openFIFOWriter(path string) (io.WriteCloser, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+}
@@ -0,0 +1,112 @@
+package sink
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Item is the wire form of a stream item: one JSON object per line (NDJSON)
+// on every machine-readable output. Field names are stable API.
+type Item struct {
+	Type                string    `json:"type"`
+	ID                  string    `json:"id,omitempty"`
+	SessionID           string    `json:"session_id"`
+	AgentID             string    `json:"agent_id,omitempty"`
+	AgentName           string    `json:"agent_name,omitempty"`
+	Timestamp           time.Time `json:"timestamp"`
+	Content             string    `json:"content,omitempty"`
+	ToolName            string    `json:"tool_name,omitempty"`
+	ToolID              string    `json:"tool_id,omitempty"`
+	DurationMs          int64     `json:"duration_ms,omitempty"`
+	InputTokens         int64     `json:"input_tokens,omitempty"`
+	OutputTokens        int64     `json:"output_tokens,omitempty"`
+	CacheCreationTokens int64     `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int64     `json:"cache_read_tokens,omitempty"`
+	Model               string    `json:"model,omitempty"`
+	SpawnedAgentID      string    `json:"spawned_agent_id,omitempty"`
+	IsError             bool      `json:"is_error,omitempty"`
+	ExitCode            int       `json:"exit_code,omitempty"`
+	Stderr              string    `json:"stderr,omitempty"`
+	Cwd                 string    `json:"cwd,omitempty"`
+	Host                string    `json:"host,omitempty"`
+	Progress            float64   `json:"progress,omitempty"`
+	HookStatus          string    `json:"hook_status,omitempty"`
+	UUID                string    `json:"uuid,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitzero"`
+}
+
+// NewItem converts a parsed stream item to its wire form.
+func NewItem(it parser.StreamItem) Item {
+	return Item{
+		Type:                string(it.Type),
+		ID:                  deeplink.ItemID(it),
+		SessionID:           it.SessionID,
+		AgentID:             it.AgentID,
+		AgentName:           it.AgentName,
+		Timestamp:           it.Timestamp,
+		Content:             it.Content,
+		ToolName:            it.ToolName,
+		ToolID:              it.ToolID,
+		DurationMs:          it.DurationMs,
+		InputTokens:         it.InputTokens,
+		OutputTokens:        it.OutputTokens,
+		CacheCreationTokens: it.CacheCreationTokens,
+		CacheReadTokens:     it.CacheReadTokens,
+		Model:               it.Model,
+		SpawnedAgentID:      it.SpawnedAgentID,
+		IsError:             it.IsError,
+		ExitCode:            it.ExitCode,
+		Stderr:              it.Stderr,
+		Cwd:                 it.Cwd,
+		Host:                it.Host,
+		Progress:            it.Progress,
+		HookStatus:          it.HookStatus,
+		UUID:                it.UUID,
+		RetryAt:             it.RetryAt,
+	}
+}
+
+// StreamItem converts an item read back from the wire, e.g. another
+// instance's HTTP stream, to a stream item. The raw tool input and
+// artifact list aren't part of the wire form and stay empty; the ID is
+// derived from the rest again.
+func (it Item) StreamItem() parser.StreamItem {
+	return parser.StreamItem{
+		Type:                parser.StreamItemType(it.Type),
+		SessionID:           it.SessionID,
+		AgentID:             it.AgentID,
+		AgentName:           it.AgentName,
+		Timestamp:           it.Timestamp,
+		Content:             it.Content,
+		ToolName:            it.ToolName,
+		ToolID:              it.ToolID,
+		DurationMs:          it.DurationMs,
+		InputTokens:         it.InputTokens,
+		OutputTokens:        it.OutputTokens,
+		CacheCreationTokens: it.CacheCreationTokens,
+		CacheReadTokens:     it.CacheReadTokens,
+		Model:               it.Model,
+		SpawnedAgentID:      it.SpawnedAgentID,
+		IsError:             it.IsError,
+		ExitCode:            it.ExitCode,
+		Stderr:              it.Stderr,
+		Cwd:                 it.Cwd,
+		Host:                it.Host,
+		Progress:            it.Progress,
+		HookStatus:          it.HookStatus,
+		UUID:                it.UUID,
+		RetryAt:             it.RetryAt,
+	}
+}
+
+// MarshalLine encodes an item as a single NDJSON line, newline included.
+func MarshalLine(it parser.StreamItem) ([]byte, error) {
+	data, err := json.Marshal(NewItem(it))
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,272 @@
+// Package sink forwards the live stream to consumers outside the TUI.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/shell"
+)
+
+const (
+	// PipeBuffer is how many lines may queue for the pipe command before
+	// new lines are dropped; a stalled consumer must never block the TUI.
+	PipeBuffer = 1024
+	// pipeMinBackoff / pipeMaxBackoff bound the delay before restarting a
+	// command that exited. The delay doubles on each quick exit and resets
+	// once the command has stayed up for pipeHealthy.
+	pipeMinBackoff = 500 * time.Millisecond
+	pipeMaxBackoff = 30 * time.Second
+	pipeHealthy    = 10 * time.Second
+)
+
+// PipeStatus is a snapshot of the pipe command for display.
+type PipeStatus struct {
+	Running  bool
+	Restarts int
+	Dropped  int    // lines dropped because the buffer was full
+	LastErr  string // why the command last exited, "" if it hasn't
+	LastOut  string // the command's most recent output line
+}
+
+// String renders the status for the footer, e.g. "pipe ✓ 3 matches".
+func (s PipeStatus) String() string {
+	var b strings.Builder
+	if s.Running {
+		b.WriteString("pipe ✓")
+	} else {
+		b.WriteString("pipe ✗")
+		if s.LastErr != "" {
+			b.WriteString(" " + s.LastErr)
+		}
+	}
+	if s.Restarts > 0 {
+		fmt.Fprintf(&b, " ↻%d", s.Restarts)
+	}
+	if s.Dropped > 0 {
+		fmt.Fprintf(&b, " dropped %d", s.Dropped)
+	}
+	if s.LastOut != "" {
+		b.WriteString(" " + s.LastOut)
+	}
+	return b.String()
+}
+
+// Pipe runs a shell command and writes lines to its stdin, restarting the
+// command whenever it exits. Its stdout and stderr are not shown (the TUI
+// owns the terminal); the last line of output is kept for the status.
+type Pipe struct {
+	command string
+	lines   chan string
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	status PipeStatus
+}
+
+// NewPipe creates a pipe for a shell command. Call Start to run it.
+func NewPipe(command string) *Pipe {
+	return &Pipe{
+		command: command,
+		lines:   make(chan string, PipeBuffer),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start launches the command supervisor in the background.
+func (p *Pipe) Start() {
+	p.wg.Add(1)
+	go p.supervise()
+}
+
+// Write queues a line (without trailing newline). It never blocks.
+func (p *Pipe) Write(line string) {
+	select {
+	case p.lines <- line:
+	default:
+		p.mu.Lock()
+		p.status.Dropped++
+		p.mu.Unlock()
+	}
+}
+
+// Status returns the current status.
+func (p *Pipe) Status() PipeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// Stop closes the command's stdin, lets it finish briefly, and stops
+// restarting it.
+func (p *Pipe) Stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+func (p *Pipe) supervise() {
+	defer p.wg.Done()
+	backoff := pipeMinBackoff
+	for {
+		started := time.Now()
+		err := p.runOnce()
+
+		p.mu.Lock()
+		p.status.Running = false
+		if err != nil {
+			p.status.LastErr = err.Error()
+		} else {
+			p.status.LastErr = "exited"
+		}
+		p.mu.Unlock()
+
+		if time.Since(started) >= pipeHealthy {
+			backoff = pipeMinBackoff
+		}
+		select {
+		case <-p.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, pipeMaxBackoff)
+
+		p.mu.Lock()
+		p.status.Restarts++
+		p.mu.Unlock()
+	}
+}
+
+// runOnce runs the command until it exits or Stop is called.
+func (p *Pipe) runOnce() error {
+	cmd := shell.Command(context.Background(), p.command)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// A non-*os.File writer makes exec copy output in its own goroutine,
+	// which Wait drains before returning. WaitDelay stops a grandchild that
+	// holds the pipe open from stalling Wait.
+	out := &lastLine{p: p}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.status.Running = true
+	p.status.LastErr = ""
+	p.mu.Unlock()
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// stop closes stdin and gives the command a moment to exit on its own.
+	stop := func() error {
+		stdin.Close()
+		select {
+		case err := <-exited:
+			return err
+		case <-time.After(2 * time.Second):
+			_ = cmd.Process.Kill()
+			return <-exited
+		}
+	}
+
+	// Lines are written from their own goroutine: a command that stays
+	// alive without reading blocks the write, and that must not keep the
+	// supervisor (and so Stop) waiting with it.
+	writeFailed := make(chan struct{})
+	drain := make(chan struct{})
+	quit := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		write := func(line string) bool {
+			if _, err := io.WriteString(stdin, line+"\n"); err != nil {
+				// The command closed its stdin; the line is lost.
+				close(writeFailed)
+				return false
+			}
+			return true
+		}
+		for {
+			select {
+			case line := <-p.lines:
+				if !write(line) {
+					return
+				}
+			case <-drain:
+				// Flush what's already queued, then stop.
+				for {
+					select {
+					case line := <-p.lines:
+						if !write(line) {
+							return
+						}
+					default:
+						return
+					}
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-writeFailed:
+		// Restart rather than queueing behind a deaf process.
+		return stop()
+	case err := <-exited:
+		close(quit)
+		stdin.Close() // fails a write still in flight
+		<-writerDone
+		return err
+	case <-p.done:
+		close(drain)
+		select {
+		case <-writerDone:
+		case <-time.After(2 * time.Second):
+			// The command isn't reading; give up on the flush.
+			stdin.Close()
+			_ = cmd.Process.Kill()
+			<-writerDone
+		}
+		return stop()
+	}
+}
+
+// lastLine records the command's most recent non-blank output line.
+type lastLine struct {
+	p       *Pipe
+	partial []byte
+}
+
+func (w *lastLine) Write(b []byte) (int, error) {
+	w.partial = append(w.partial, b...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.partial[:i])); line != "" {
+			w.p.mu.Lock()
+			w.p.status.LastOut = line
+			w.p.mu.Unlock()
+		}
+		w.partial = w.partial[i+1:]
+	}
+	// Keep a runaway unterminated line from growing without bound.
+	if len(w.partial) > 4096 {
+		w.partial = w.partial[len(w.partial)-4096:]
+	}
+	return len(b), nil
+}
@@ -0,0 +1,95 @@
+package sink
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestPipeForwardsLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	p := NewPipe("grep --line-buffered ERROR > " + out)
+	p.Start()
+	waitFor(t, "command start", func() bool { return p.Status().Running })
+
+	p.Write("12:00:00 s1 Main text ok")
+	p.Write("12:00:01 s1 Main tool_output ERROR boom")
+	p.Stop()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "12:00:01 s1 Main tool_output ERROR boom" {
+		t.Errorf("piped output = %q", got)
+	}
+}
+
+func TestPipeRestartsExitedCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	p := NewPipe("echo started; exit 3")
+	p.Start()
+	defer p.Stop()
+	waitFor(t, "a restart", func() bool { return p.Status().Restarts >= 1 })
+
+	st := p.Status()
+	if st.LastOut != "started" {
+		t.Errorf("LastOut = %q, want started", st.LastOut)
+	}
+	if !strings.Contains(st.String(), "↻") {
+		t.Errorf("status %q should show restarts", st.String())
+	}
+}
+
+func TestPipeWriteNeverBlocks(t *testing.T) {
+	p := NewPipe("true") // never started: nothing drains the buffer
+	for i := 0; i < PipeBuffer+5; i++ {
+		p.Write("x")
+	}
+	if got := p.Status().Dropped; got != 5 {
+		t.Errorf("Dropped = %d, want 5", got)
+	}
+}
+
+func TestPipeStopWithDeafCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	p := NewPipe("sleep 30") // alive, but never reads stdin
+	p.Start()
+	waitFor(t, "command start", func() bool { return p.Status().Running })
+
+	line := strings.Repeat("x", 1023)
+	for i := 0; i < PipeBuffer; i++ {
+		p.Write(line)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop blocked on a command that doesn't read its stdin")
+	}
+}
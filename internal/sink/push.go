@@ -0,0 +1,137 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// pushRetry is how long a Pusher waits before reconnecting after losing
+// the daemon.
+const pushRetry = 2 * time.Second
+
+// Pusher sends items to a claude-esp daemon's POST /api/push, as one
+// long-running request per connection, labelled with this machine's host
+// name. Like the other sinks it never blocks: while the daemon is slow or
+// unreachable up to ClientBuffer lines queue and further ones are dropped.
+// A lost connection is retried until Close.
+type Pusher struct {
+	url    string
+	lines  chan []byte
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// Errors reports failed connections; unread ones are dropped.
+	Errors chan error
+}
+
+// Push starts pushing to the daemon at to, given as host:port or a URL,
+// as host.
+func Push(to, host string) (*Pusher, error) {
+	base := to
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("cannot push to %q: want host:port or a URL like http://10.0.0.5:7777", to)
+	}
+	if host == "" {
+		return nil, errors.New("push: empty host name")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pusher{
+		url:    strings.TrimRight(base, "/") + "/api/push?host=" + url.QueryEscape(host),
+		lines:  make(chan []byte, ClientBuffer),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		Errors: make(chan error, 16),
+	}
+	go p.run()
+	return p, nil
+}
+
+// Publish queues item for the daemon.
+func (p *Pusher) Publish(item parser.StreamItem) {
+	line, err := MarshalLine(item)
+	if err != nil {
+		return
+	}
+	select {
+	case p.lines <- line:
+	default: // daemon slow or away: drop rather than block
+	}
+}
+
+// Close stops pushing; queued lines are discarded.
+func (p *Pusher) Close() error {
+	p.cancel()
+	<-p.done
+	return nil
+}
+
+func (p *Pusher) run() {
+	defer close(p.done)
+	for {
+		err := p.push()
+		if p.ctx.Err() != nil {
+			return
+		}
+		select {
+		case p.Errors <- fmt.Errorf("pushing to %s: %w", p.url, err):
+		default:
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(pushRetry):
+		}
+	}
+}
+
+// push streams queued lines over one request until it fails.
+func (p *Pusher) push() error {
+	body, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodPost, p.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	ended := make(chan error, 1)
+	go func() {
+		resp, err := HTTPClient().Do(req)
+		if err == nil {
+			// The daemon only answers when it is done with us.
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			resp.Body.Close()
+			err = fmt.Errorf("POST /api/push: %s", resp.Status)
+			if len(msg) > 0 {
+				err = fmt.Errorf("POST /api/push: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+			}
+		}
+		body.CloseWithError(err)
+		ended <- err
+	}()
+	for {
+		select {
+		case line := <-p.lines:
+			if _, err := pw.Write(line); err != nil {
+				return <-ended
+			}
+		case err := <-ended:
+			return err
+		case <-p.ctx.Done():
+			pw.Close()
+			return <-ended
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// ClientBuffer is how many lines may queue per consumer before new lines
+// are dropped for that consumer.
+const ClientBuffer = 256
+
+// Publisher receives stream items for an external consumer. Publish must
+// never block the caller.
+type Publisher interface {
+	Publish(item parser.StreamItem)
+	Close() error
+}
+
+// Feeds a sink can carry, chosen with a ?feed= suffix on its spec.
+const (
+	FeedItems = "items" // every stream item (the default)
+	FeedEdits = "edits" // file-edit events only, see package edits
+)
+
+// lineWriter is a transport that broadcasts raw NDJSON lines.
+type lineWriter interface {
+	Publisher
+	writeLine(line []byte)
+}
+
+// editFeed publishes file-edit events instead of stream items.
+type editFeed struct {
+	out     lineWriter
+	tracker *edits.Tracker
+}
+
+func (f *editFeed) Publish(item parser.StreamItem) {
+	ev, ok := f.tracker.Add(item)
+	if !ok {
+		return
+	}
+	if line, err := ev.MarshalLine(); err == nil {
+		f.out.writeLine(line)
+	}
+}
+
+func (f *editFeed) Close() error { return f.out.Close() }
+
+// Open creates a publisher from a -sink spec:
+//
+//	unix:///tmp/esp.sock   listen on a unix socket; every client gets NDJSON
+//	fifo:///tmp/esp.fifo   write NDJSON into a named pipe (created if missing)
+//	/tmp/esp.fifo          same as fifo://
+//
+// Appending ?feed=edits publishes file-edit events instead of stream items.
+func Open(spec string) (Publisher, error) {
+	spec, query, _ := strings.Cut(spec, "?")
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("sink %q: %w", spec, err)
+	}
+	feed := q.Get("feed")
+	if feed != "" && feed != FeedItems && feed != FeedEdits {
+		return nil, fmt.Errorf("sink %q: unknown feed %q (want %s or %s)", spec, feed, FeedItems, FeedEdits)
+	}
+	out, err := openTransport(spec)
+	if err != nil {
+		return nil, err
+	}
+	if feed == FeedEdits {
+		return &editFeed{out: out, tracker: edits.NewTracker()}, nil
+	}
+	return out, nil
+}
+
+func openTransport(spec string) (lineWriter, error) {
+	switch {
+	case strings.HasPrefix(spec, "unix://"):
+		path := strings.TrimPrefix(spec, "unix://")
+		if path == "" {
+			return nil, errors.New("sink unix://: missing socket path")
+		}
+		return ListenSocket(path)
+	case strings.HasPrefix(spec, "fifo://"):
+		return OpenFIFO(strings.TrimPrefix(spec, "fifo://"))
+	case strings.Contains(spec, "://"):
+		return nil, fmt.Errorf("sink %q: unsupported scheme (want unix:// or fifo://)", spec)
+	case spec == "":
+		return nil, errors.New("empty sink spec")
+	default:
+		return OpenFIFO(spec)
+	}
+}
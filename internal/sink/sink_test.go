@@ -0,0 +1,192 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func testItem(content string) parser.StreamItem {
+	return parser.StreamItem{
+		Type:      parser.TypeToolInput,
+		SessionID: "s1",
+		AgentName: "Main",
+		ToolName:  "Bash",
+		ToolID:    "toolu_1",
+		Content:   content,
+		Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestMarshalLine(t *testing.T) {
+	line, err := MarshalLine(testItem("ls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line[len(line)-1] != '\n' {
+		t.Error("NDJSON line must end in a newline")
+	}
+	var got Item
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "tool_input" || got.ToolName != "Bash" || got.Content != "ls" {
+		t.Errorf("round trip = %+v", got)
+	}
+	if back := got.StreamItem(); back.Type != parser.TypeToolInput || back.ToolID != "toolu_1" || !back.Timestamp.Equal(testItem("").Timestamp) {
+		t.Errorf("back to a stream item = %+v", back)
+	}
+}
+
+func TestOpenRejectsUnknownScheme(t *testing.T) {
+	if _, err := Open("tcp://localhost:1"); err == nil {
+		t.Error("expected an error for tcp://")
+	}
+	if _, err := Open("unix://"); err == nil {
+		t.Error("expected an error for an empty socket path")
+	}
+	if _, err := Open("unix:///tmp/x.sock?feed=bogus"); err == nil {
+		t.Error("expected an error for an unknown feed")
+	}
+}
+
+func TestSocketEditFeed(t *testing.T) {
+	dir, err := os.MkdirTemp("", "esp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "e.sock")
+
+	pub, err := Open("unix://" + path + "?feed=edits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+	sock := pub.(*editFeed).out.(*Socket)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for sock.Clients() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Plain items are not published on an edit feed; the Write is, once
+	// its result arrives.
+	pub.Publish(testItem("ls"))
+	write := testItem("")
+	write.ToolName, write.ToolID = "Write", "toolu_2"
+	write.ToolInput = json.RawMessage(`{"file_path":"/tmp/a.go","content":"package a\n"}`)
+	pub.Publish(write)
+	result := write
+	result.Type, result.ToolInput = parser.TypeToolOutput, nil
+	pub.Publish(result)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got edits.Event
+	if err := json.Unmarshal(line, &got); err != nil || got.Path != "/tmp/a.go" || got.Tool != "Write" {
+		t.Errorf("got %s (err %v)", line, err)
+	}
+}
+
+func TestSocketBroadcast(t *testing.T) {
+	// Keep the path short: unix socket paths are limited to ~104 bytes.
+	dir, err := os.MkdirTemp("", "esp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "s.sock")
+
+	pub, err := Open("unix://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := pub.(*Socket)
+	defer sock.Close()
+
+	if _, err := ListenSocket(path); err == nil {
+		t.Error("a second listener on a live socket should fail")
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for sock.Clients() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sock.Publish(testItem("hello"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Item
+	if err := json.Unmarshal(line, &got); err != nil || got.Content != "hello" {
+		t.Errorf("got %s (err %v)", line, err)
+	}
+
+	sock.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Close should remove the socket file")
+	}
+}
+
+func TestFIFOSink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no FIFOs on windows")
+	}
+	path := filepath.Join(t.TempDir(), "esp.fifo")
+	pub, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+
+	// Opening for read blocks until the writer side opens, like a consumer.
+	r, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	lines := make(chan []byte, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadBytes('\n')
+		lines <- line
+	}()
+	// The writer attaches asynchronously; keep publishing until it lands.
+	for i := 0; i < 100; i++ {
+		pub.Publish(testItem("via fifo"))
+		select {
+		case line := <-lines:
+			var got Item
+			if err := json.Unmarshal(line, &got); err != nil || got.Content != "via fifo" {
+				t.Fatalf("got %s (err %v)", line, err)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	t.Fatal("no line received through the FIFO")
+}
@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Socket broadcasts NDJSON items to every client connected to a unix
+// socket. Clients only receive items published after they connect; a client
+// that falls ClientBuffer lines behind misses lines rather than stalling
+// the others.
+type Socket struct {
+	path     string
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	closed  bool
+}
+
+// ListenSocket listens on a unix socket at path. A stale socket file left
+// by a previous run is replaced; one still in use is an error.
+func ListenSocket(path string) (*Socket, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("sink %s: exists and is not a socket", path)
+		}
+		if c, err := net.DialTimeout("unix", path, 200*time.Millisecond); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("sink %s: socket already in use", path)
+		}
+		os.Remove(path)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("sink %s: %w", path, err)
+	}
+	s := &Socket{path: path, listener: l, clients: make(map[chan []byte]struct{})}
+	go s.accept()
+	return s, nil
+}
+
+func (s *Socket) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		ch := make(chan []byte, ClientBuffer)
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.clients[ch] = struct{}{}
+		s.mu.Unlock()
+		go s.serve(conn, ch)
+	}
+}
+
+func (s *Socket) serve(conn net.Conn, ch chan []byte) {
+	defer conn.Close()
+	defer s.remove(ch)
+	// Detect the client hanging up even while nothing is being published.
+	gone := make(chan struct{})
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(gone)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := conn.Write(line); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+func (s *Socket) remove(ch chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[ch]; ok {
+		delete(s.clients, ch)
+		close(ch)
+	}
+}
+
+// Clients returns the number of connected consumers.
+func (s *Socket) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Publish sends item to every connected client.
+func (s *Socket) Publish(item parser.StreamItem) {
+	if line, err := MarshalLine(item); err == nil {
+		s.writeLine(line)
+	}
+}
+
+// WriteLine sends one NDJSON line, newline included, to every connected
+// client, for packages with events of their own.
+func (s *Socket) WriteLine(line []byte) {
+	s.writeLine(line)
+}
+
+func (s *Socket) writeLine(line []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- line:
+		default: // slow consumer: drop rather than block the TUI
+		}
+	}
+}
+
+// Close stops listening, disconnects clients and removes the socket file.
+func (s *Socket) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for ch := range s.clients {
+		delete(s.clients, ch)
+		close(ch)
+	}
+	s.mu.Unlock()
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
@@ -0,0 +1,193 @@
+// Package summarize hands a stretch of a session to a summarizer the user
+// configured, a command or the Anthropic API, and returns what it wrote.
+// claude-esp never sends anything anywhere unless one is configured.
+package summarize
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/shell"
+)
+
+// Defaults for the settings left unset.
+const (
+	DefaultWindow    = time.Hour
+	DefaultTimeout   = 2 * time.Minute
+	DefaultMaxBytes  = 200_000
+	DefaultAPIKeyEnv = "ANTHROPIC_API_KEY"
+	DefaultPrompt    = "Below is a transcript of a coding agent's session: its thinking, tool calls and results, and replies. " +
+		"Summarize it for a developer catching up: what it set out to do, what it changed, what it ran and how that went, " +
+		"what is left or went wrong. Be brief and concrete; use short bullet points."
+)
+
+// APIURL is the Anthropic Messages API endpoint.
+const APIURL = "https://api.anthropic.com/v1/messages"
+
+// apiVersion is the anthropic-version header the request is written for.
+const apiVersion = "2023-06-01"
+
+// Summarizer sends transcripts to Command, or to the Anthropic API with
+// Model when there is no command.
+type Summarizer struct {
+	// Command is run through the shell with the transcript, as Markdown,
+	// on stdin; what it prints is the summary.
+	Command string
+	// Model is the Anthropic model to call when Command is empty.
+	Model string
+	// APIKey authenticates the API call.
+	APIKey string
+	// Prompt is the API's system prompt, and ESP_PROMPT for Command.
+	Prompt string
+	// MaxBytes caps the transcript; the oldest part is left out.
+	MaxBytes int
+	Timeout  time.Duration
+
+	URL    string       // APIURL unless testing
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// Request is one session window to summarize.
+type Request struct {
+	SessionID string
+	Project   string // for the heading and ESP_PROJECT
+	Items     []parser.StreamItem
+}
+
+// Transcript renders req's items as the Markdown a summarizer reads,
+// keeping the newest max bytes at a line boundary.
+func Transcript(req Request, max int) []byte {
+	var b bytes.Buffer
+	title := "Session " + req.SessionID
+	if req.Project != "" {
+		title = req.Project + " · " + title
+	}
+	export.Markdown(&b, req.Items, export.Options{Title: title})
+	data := b.Bytes()
+	if max <= 0 || len(data) <= max {
+		return data
+	}
+	data = data[len(data)-max:]
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	return append([]byte("_[earlier part of the window left out]_\n\n"), data...)
+}
+
+// Summarize returns the summary of req.
+func (s *Summarizer) Summarize(ctx context.Context, req Request) (string, error) {
+	if s.Command == "" && s.Model == "" {
+		return "", errors.New("no summarizer configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(s.Timeout, DefaultTimeout))
+	defer cancel()
+	transcript := Transcript(req, cmp.Or(s.MaxBytes, DefaultMaxBytes))
+	var (
+		summary string
+		err     error
+	)
+	if s.Command != "" {
+		summary, err = s.runCommand(ctx, req, transcript)
+	} else {
+		summary, err = s.callAPI(ctx, transcript)
+	}
+	if err != nil {
+		return "", err
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", errors.New("the summarizer returned nothing")
+	}
+	return summary, nil
+}
+
+func (s *Summarizer) prompt() string {
+	return cmp.Or(s.Prompt, DefaultPrompt)
+}
+
+// runCommand runs Command with the transcript on stdin and ESP_SESSION,
+// ESP_PROJECT and ESP_PROMPT set.
+func (s *Summarizer) runCommand(ctx context.Context, req Request, transcript []byte) (string, error) {
+	cmd := shell.Command(ctx, s.Command)
+	cmd.Env = append(os.Environ(),
+		"ESP_SESSION="+req.SessionID,
+		"ESP_PROJECT="+req.Project,
+		"ESP_PROMPT="+s.prompt(),
+	)
+	cmd.Stdin = bytes.NewReader(transcript)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			lines := strings.Split(msg, "\n")
+			return "", fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// callAPI asks Model for the summary through the Messages API.
+func (s *Summarizer) callAPI(ctx context.Context, transcript []byte) (string, error) {
+	if s.APIKey == "" {
+		return "", errors.New("no API key")
+	}
+	body, err := json.Marshal(map[string]any{
+		"model":      s.Model,
+		"max_tokens": 1024,
+		"system":     s.prompt(),
+		"messages":   []map[string]string{{"role": "user", "content": string(transcript)}},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cmp.Or(s.URL, APIURL), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", s.APIKey)
+	req.Header.Set("Anthropic-Version", apiVersion)
+	resp, err := cmp.Or(s.Client, http.DefaultClient).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var reply struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&reply); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("bad API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if reply.Error.Message != "" {
+			return "", fmt.Errorf("API: %s: %s", resp.Status, reply.Error.Message)
+		}
+		return "", fmt.Errorf("API: %s", resp.Status)
+	}
+	var text strings.Builder
+	for _, c := range reply.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String(), nil
+}
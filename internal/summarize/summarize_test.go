@@ -0,0 +1,85 @@
+package summarize
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func request() Request {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	return Request{SessionID: "s1", Project: "api", Items: []parser.StreamItem{
+		{Type: parser.TypeThinking, SessionID: "s1", AgentName: "Main", Content: "Fix the flaky test first.", Timestamp: at},
+		{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "Done: the test is fixed.", Timestamp: at.Add(time.Minute)},
+	}}
+}
+
+func TestCommand(t *testing.T) {
+	s := &Summarizer{Command: `grep -c . >/dev/null && echo "$ESP_PROJECT $ESP_SESSION: fixed the flaky test"`}
+	got, err := s.Summarize(context.Background(), request())
+	if err != nil || got != "api s1: fixed the flaky test" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	s.Command = "echo nope >&2; exit 3"
+	if _, err := s.Summarize(context.Background(), request()); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("failing command: %v", err)
+	}
+	s.Command = "true"
+	if _, err := s.Summarize(context.Background(), request()); err == nil {
+		t.Error("empty summary accepted")
+	}
+}
+
+func TestAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model    string `json:"model"`
+			System   string `json:"system"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+		if body.Model != "claude-haiku-4-5" || body.System != DefaultPrompt || len(body.Messages) != 1 || !strings.Contains(body.Messages[0].Content, "flaky test") {
+			t.Errorf("request = %+v", body)
+		}
+		w.Write([]byte(`{"content":[{"type":"text","text":"- Fixed "},{"type":"text","text":"the flaky test."}]}`))
+	}))
+	defer srv.Close()
+
+	s := &Summarizer{Model: "claude-haiku-4-5", APIKey: "key", URL: srv.URL}
+	if got, err := s.Summarize(context.Background(), request()); err != nil || got != "- Fixed the flaky test." {
+		t.Errorf("got %q, %v", got, err)
+	}
+	s.APIKey = "wrong"
+	if _, err := s.Summarize(context.Background(), request()); err == nil || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("bad key: %v", err)
+	}
+	s.APIKey = ""
+	if _, err := s.Summarize(context.Background(), request()); err == nil {
+		t.Error("no key: no error")
+	}
+}
+
+func TestTranscriptKeepsTheNewest(t *testing.T) {
+	full := string(Transcript(request(), 0))
+	if !strings.Contains(full, "# api · Session s1") || !strings.Contains(full, "flaky test first") {
+		t.Fatalf("transcript:\n%s", full)
+	}
+	cut := string(Transcript(request(), 60))
+	if !strings.HasPrefix(cut, "_[earlier part of the window left out]_") || strings.Contains(cut, "flaky test first") || !strings.Contains(cut, "test is fixed") {
+		t.Errorf("cut transcript:\n%s", cut)
+	}
+}
@@ -0,0 +1,167 @@
+package testrun
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansi matches color escapes, which runners print when forced to.
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// counts adds up "<n> <word>" pairs in s under the word they are counted
+// as: words maps a runner's word ("passed", "errors") to "passed",
+// "failed" or "skipped", and other words are ignored.
+func counts(s string, words map[string]string) (Result, bool) {
+	var r Result
+	found := false
+	for _, m := range countPattern.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.Atoi(m[1])
+		switch words[m[2]] {
+		case "passed":
+			r.Passed += n
+		case "failed":
+			r.Failed += n
+		case "skipped":
+			r.Skipped += n
+		default:
+			continue
+		}
+		found = true
+	}
+	return r, found
+}
+
+var countPattern = regexp.MustCompile(`(\d+) ([a-z]+)`)
+
+// goTest reads go test and gotestsum: test counts from -v output or
+// gotestsum's DONE line, otherwise package counts from the ok/FAIL lines.
+type goTest struct{}
+
+var (
+	goTestLine    = regexp.MustCompile(`(?m)^--- (PASS|FAIL|SKIP): `)
+	goPackageLine = regexp.MustCompile(`(?m)^(ok|FAIL)\s+\S+\s+(\(cached\)|[\d.]+s|\[.*\])`)
+	gotestsumDone = regexp.MustCompile(`(?m)^DONE (\d+) tests?((?:, \d+ [a-z]+)*)`)
+)
+
+func (goTest) Recognize(command, output string) (Result, bool) {
+	output = ansi.ReplaceAllString(output, "")
+	if m := gotestsumDone.FindStringSubmatch(output); m != nil {
+		r, _ := counts(m[2], map[string]string{"skipped": "skipped", "failure": "failed", "failures": "failed", "error": "failed", "errors": "failed"})
+		total, _ := strconv.Atoi(m[1])
+		r.Passed = max(0, total-r.Failed-r.Skipped)
+		r.Runner, r.Unit = "gotestsum", "tests"
+		return r, true
+	}
+	r := Result{Runner: "go test", Unit: "tests"}
+	for _, m := range goTestLine.FindAllStringSubmatch(output, -1) {
+		switch m[1] {
+		case "PASS":
+			r.Passed++
+		case "FAIL":
+			r.Failed++
+		case "SKIP":
+			r.Skipped++
+		}
+	}
+	if r.Passed > 0 {
+		return r, true
+	}
+	// Without -v only failing tests are listed: count packages.
+	packages := Result{Runner: "go test", Unit: "packages"}
+	for _, m := range goPackageLine.FindAllStringSubmatch(output, -1) {
+		if m[1] == "ok" {
+			packages.Passed++
+		} else {
+			packages.Failed++
+		}
+	}
+	if packages.Passed+packages.Failed > 0 {
+		return packages, true
+	}
+	if r.Failed+r.Skipped > 0 && strings.Contains(command, "go test") {
+		return r, true
+	}
+	return Result{}, false
+}
+
+// pytest reads the closing line: "==== 1 failed, 40 passed in 1.20s ====",
+// or "40 passed in 0.12s" with -q.
+type pytest struct{}
+
+var pytestSummary = regexp.MustCompile(`(?m)^=*\s*(\d+ (?:passed|failed|error|errors|skipped|xfailed|xpassed|deselected|warnings?)\b.*?) in [\d.]+s\b`)
+
+func (pytest) Recognize(command, output string) (Result, bool) {
+	output = ansi.ReplaceAllString(output, "")
+	m := pytestSummary.FindAllStringSubmatch(output, -1)
+	if m == nil {
+		return Result{}, false
+	}
+	r, ok := counts(m[len(m)-1][1], map[string]string{
+		"passed": "passed", "xpassed": "passed", "xfailed": "passed",
+		"failed": "failed", "error": "failed", "errors": "failed",
+		"skipped": "skipped",
+	})
+	r.Runner, r.Unit = "pytest", "tests"
+	return r, ok
+}
+
+// jest reads jest's "Tests: 1 failed, 40 passed, 41 total" and vitest's
+// "Tests  1 failed | 40 passed (41)".
+type jest struct{}
+
+var (
+	jestTests   = regexp.MustCompile(`(?m)^Tests:\s+(.*\d+ total)`)
+	vitestTests = regexp.MustCompile(`(?m)^\s*Tests\s+(\d+ [a-z]+.*)\(\d+\)`)
+	jestWords   = map[string]string{"passed": "passed", "failed": "failed", "skipped": "skipped", "todo": "skipped", "pending": "skipped"}
+)
+
+func (jest) Recognize(command, output string) (Result, bool) {
+	output = ansi.ReplaceAllString(output, "")
+	runner := "jest"
+	m := jestTests.FindAllStringSubmatch(output, -1)
+	if m == nil {
+		runner = "vitest"
+		m = vitestTests.FindAllStringSubmatch(output, -1)
+	}
+	if m == nil {
+		return Result{}, false
+	}
+	r, ok := counts(m[len(m)-1][1], jestWords)
+	r.Runner, r.Unit = runner, "tests"
+	return r, ok
+}
+
+// cargo reads cargo test's "test result: ok. 42 passed; 0 failed; 1
+// ignored; ..." lines, one per test binary, and cargo nextest's
+// "Summary [...] 42 tests run: 41 passed, 1 failed, 0 skipped".
+type cargo struct{}
+
+var (
+	cargoResult    = regexp.MustCompile(`(?m)^test result: (?:ok|FAILED)\. (.*)`)
+	nextestSummary = regexp.MustCompile(`(?m)^\s*Summary \[.*?\]\s+\d+ tests? run: (.*)`)
+	cargoWords     = map[string]string{"passed": "passed", "failed": "failed", "ignored": "skipped", "skipped": "skipped"}
+)
+
+func (cargo) Recognize(command, output string) (Result, bool) {
+	output = ansi.ReplaceAllString(output, "")
+	if m := nextestSummary.FindStringSubmatch(output); m != nil {
+		r, ok := counts(m[1], cargoWords)
+		r.Runner, r.Unit = "cargo nextest", "tests"
+		return r, ok
+	}
+	var total Result
+	found := false
+	for _, m := range cargoResult.FindAllStringSubmatch(output, -1) {
+		r, ok := counts(m[1], cargoWords)
+		if !ok {
+			continue
+		}
+		total.Passed += r.Passed
+		total.Failed += r.Failed
+		total.Skipped += r.Skipped
+		found = true
+	}
+	total.Runner, total.Unit = "cargo test", "tests"
+	return total, found
+}
@@ -0,0 +1,65 @@
+// Package testrun reads test results out of the output of a test run:
+// which runner it was and how many tests passed, failed and were skipped.
+// It knows go test, pytest, jest and vitest, and cargo test.
+package testrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Result is what a recognizer read from a test run.
+type Result struct {
+	Runner  string // "go test", "pytest", ...
+	Passed  int
+	Failed  int // failures and errors
+	Skipped int
+	// Unit is what was counted: "tests", or "packages" when go test
+	// reported packages only.
+	Unit string
+}
+
+// OK reports whether the run passed.
+func (r Result) OK() bool {
+	return r.Failed == 0
+}
+
+// Summary is a short account of the counts, failures first:
+// "2 failed, 40 passed, 1 skipped".
+func (r Result) Summary() string {
+	var parts []string
+	if r.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", r.Failed))
+	}
+	if r.Passed > 0 || r.Failed == 0 {
+		parts = append(parts, fmt.Sprintf("%d passed", r.Passed))
+	}
+	if r.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", r.Skipped))
+	}
+	s := strings.Join(parts, ", ")
+	if r.Unit != "" && r.Unit != "tests" {
+		s += " (" + r.Unit + ")"
+	}
+	return s
+}
+
+// A recognizer reads a Result from the output of a shell command, and
+// reports false if the output isn't a run of its runner.
+type recognizer interface {
+	Recognize(command, output string) (Result, bool)
+}
+
+// recognizers are tried in order.
+var recognizers = []recognizer{goTest{}, pytest{}, jest{}, cargo{}}
+
+// Recognize returns the result of the first recognizer that reads one
+// from output.
+func Recognize(command, output string) (Result, bool) {
+	for _, r := range recognizers {
+		if res, ok := r.Recognize(command, output); ok {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
@@ -0,0 +1,90 @@
+package testrun
+
+import "testing"
+
+func TestRecognize(t *testing.T) {
+	for _, tc := range []struct {
+		name, command, output string
+		want                  Result
+	}{
+		{
+			"go test packages", "go test ./...",
+			"ok  \tgithub.com/x/a\t0.012s\n?   \tgithub.com/x/b\t[no test files]\n--- FAIL: TestX (0.00s)\n    x_test.go:9: boom\nFAIL\nFAIL\tgithub.com/x/c\t0.020s\nok  \tgithub.com/x/d\t(cached)\nFAIL\tgithub.com/x/e [build failed]\nFAIL\n",
+			Result{Runner: "go test", Passed: 2, Failed: 2, Unit: "packages"},
+		},
+		{
+			"go test -v", "go test -v ./x",
+			"=== RUN   TestA\n--- PASS: TestA (0.00s)\n=== RUN   TestB\n    --- PASS: TestB/sub (0.00s)\n--- FAIL: TestB (0.00s)\n--- SKIP: TestC (0.00s)\nFAIL\nFAIL\tgithub.com/x/x\t0.01s\n",
+			Result{Runner: "go test", Passed: 1, Failed: 1, Skipped: 1, Unit: "tests"},
+		},
+		{
+			"gotestsum", "gotestsum",
+			"✓  pkg (1ms)\n\nDONE 42 tests, 2 skipped, 1 failure in 3.2s\n",
+			Result{Runner: "gotestsum", Passed: 39, Failed: 1, Skipped: 2, Unit: "tests"},
+		},
+		{
+			"pytest", "python -m pytest",
+			"tests/test_a.py ..F.\n=========== short test summary info ===========\nFAILED tests/test_a.py::test_c\n====== 1 failed, 40 passed, 2 skipped, 3 warnings in 1.23s ======\n",
+			Result{Runner: "pytest", Passed: 40, Failed: 1, Skipped: 2, Unit: "tests"},
+		},
+		{
+			"pytest -q", "pytest -q",
+			"........\n8 passed in 0.12s\n",
+			Result{Runner: "pytest", Passed: 8, Unit: "tests"},
+		},
+		{
+			"jest", "npx jest",
+			"PASS src/a.test.js\nFAIL src/b.test.js\n\nTest Suites: 1 failed, 1 passed, 2 total\nTests:       1 failed, 1 skipped, 40 passed, 42 total\nTime:        2.1 s\n",
+			Result{Runner: "jest", Passed: 40, Failed: 1, Skipped: 1, Unit: "tests"},
+		},
+		{
+			"vitest", "npm test",
+			"\x1b[32m ✓\x1b[39m src/a.test.ts (3)\n\n Test Files  1 passed (1)\n      Tests  \x1b[32m3 passed\x1b[39m (3)\n   Start at  12:00:00\n",
+			Result{Runner: "vitest", Passed: 3, Unit: "tests"},
+		},
+		{
+			"cargo test", "cargo test",
+			"running 3 tests\ntest a ... ok\ntest result: ok. 3 passed; 0 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.00s\n\nrunning 2 tests\ntest result: FAILED. 1 passed; 1 failed; 0 ignored; 0 measured; 0 filtered out\n",
+			Result{Runner: "cargo test", Passed: 4, Failed: 1, Skipped: 1, Unit: "tests"},
+		},
+		{
+			"cargo nextest", "cargo nextest run",
+			"        PASS [   0.004s] crate tests::a\n------------\n     Summary [   0.010s] 5 tests run: 4 passed, 1 failed, 2 skipped\n",
+			Result{Runner: "cargo nextest", Passed: 4, Failed: 1, Skipped: 2, Unit: "tests"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Recognize(tc.command, tc.output)
+			if !ok || got != tc.want {
+				t.Errorf("Recognize = %+v, %v; want %+v", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecognizeOtherOutput(t *testing.T) {
+	for _, output := range []string{
+		"",
+		"total 8\ndrwxr-xr-x  2 user user 4096 Jan  1 12:00 .\n",
+		"ok\n",
+		"Tests: none\n",
+		"--- FAIL: TestX (0.00s)\n", // go test output printed by cat
+	} {
+		if got, ok := Recognize("cat log.txt", output); ok {
+			t.Errorf("%q recognized as %+v", output, got)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	for r, want := range map[Result]string{
+		{Passed: 40, Failed: 2, Skipped: 1, Unit: "tests"}: "2 failed, 40 passed, 1 skipped",
+		{Passed: 3, Unit: "packages"}:                      "3 passed (packages)",
+		{Failed: 1, Unit: "tests"}:                         "1 failed",
+		{}:                                                 "0 passed",
+	} {
+		if got := r.Summary(); got != want {
+			t.Errorf("%+v: %q, want %q", r, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package trigger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher matches edited files against a trigger's globs. A glob without a
+// / matches file names anywhere ("*.go", "*_test.py"). One with a /
+// matches the whole path: from the root if it starts with / or ~/, else
+// from the session's working directory ("src/**/*.ts"). * and ? don't
+// cross a /; ** matches any number of directories.
+type Matcher struct {
+	globs []glob
+}
+
+type glob struct {
+	re   *regexp.Regexp
+	kind int // globName, globAbs or globRel
+}
+
+const (
+	globName = iota
+	globAbs
+	globRel
+)
+
+// NewMatcher compiles patterns.
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("empty glob")
+		}
+		g := glob{kind: globName}
+		switch {
+		case strings.HasPrefix(p, "~/"):
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("glob %q: %w", p, err)
+			}
+			p, g.kind = filepath.ToSlash(home)+p[1:], globAbs
+		case strings.HasPrefix(p, "/"):
+			g.kind = globAbs
+		case strings.Contains(p, "/"):
+			g.kind = globRel
+		}
+		re, err := regexp.Compile(globRegexp(p))
+		if err != nil {
+			return nil, fmt.Errorf("bad glob %q: %w", p, err)
+		}
+		g.re = re
+		m.globs = append(m.globs, g)
+	}
+	return m, nil
+}
+
+func globRegexp(p string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Match reports whether path, edited in a session working in cwd, matches
+// any glob.
+func (m *Matcher) Match(path, cwd string) bool {
+	path = filepath.ToSlash(path)
+	for _, g := range m.globs {
+		var s string
+		switch g.kind {
+		case globName:
+			s = filepath.Base(path)
+		case globAbs:
+			s = path
+		case globRel:
+			if cwd == "" {
+				continue
+			}
+			rel, err := filepath.Rel(cwd, filepath.FromSlash(path))
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			s = filepath.ToSlash(rel)
+		}
+		if g.re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
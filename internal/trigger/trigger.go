@@ -0,0 +1,237 @@
+// Package trigger runs commands when agents finish editing files, in the
+// spirit of watchexec: once edits to files matching a trigger's globs stop
+// for a moment, it runs the trigger's command (tests, a linter) and sends
+// an event to its socket, instead of waiting for the agent to run them.
+package trigger
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/shell"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+// DefaultSettle is how long edits must pause before a trigger fires.
+const DefaultSettle = 2 * time.Second
+
+// Spec is one trigger.
+type Spec struct {
+	Name  string
+	Paths []string // globs, see Matcher
+	// Command is run through the shell when the trigger fires.
+	Command string
+	// Socket is a unix socket path; every client gets one Event line per
+	// firing.
+	Socket string
+	// Settle is how long edits must pause; 0 means DefaultSettle.
+	Settle time.Duration
+}
+
+// Validate checks that s names its files and does something with them.
+func (s Spec) Validate() error {
+	if s.Name == "" {
+		return errors.New("trigger has no name")
+	}
+	if len(s.Paths) == 0 {
+		return fmt.Errorf("trigger %q has no paths", s.Name)
+	}
+	if s.Command == "" && s.Socket == "" {
+		return fmt.Errorf("trigger %q needs a command or a socket", s.Name)
+	}
+	if s.Settle < 0 {
+		return fmt.Errorf("trigger %q: settle %s must be > 0", s.Name, s.Settle)
+	}
+	if _, err := NewMatcher(s.Paths); err != nil {
+		return fmt.Errorf("trigger %q: %w", s.Name, err)
+	}
+	return nil
+}
+
+// Event is one firing, the JSON on the command's stdin and on the socket.
+// Field names are stable API.
+type Event struct {
+	Trigger   string    `json:"trigger"`
+	Files     []string  `json:"files"` // edited files that matched, sorted
+	SessionID string    `json:"session_id"`
+	Cwd       string    `json:"cwd,omitempty"` // the session's working directory, where the command runs
+	Time      time.Time `json:"time"`
+}
+
+// MarshalLine encodes an event as a single NDJSON line, newline included.
+func (e Event) MarshalLine() ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Runner fires triggers from the stream. It is a sink.Publisher: feed it
+// every stream item. Only edits made after it started count, so replayed
+// history doesn't run anything, and neither do edits pushed from other
+// machines, whose files aren't here.
+type Runner struct {
+	tracker *edits.Tracker
+	since   time.Time
+
+	mu       sync.Mutex // protects batches in triggers, and closed
+	triggers []*trigger
+	closed   bool
+}
+
+type trigger struct {
+	spec    Spec
+	match   *Matcher
+	socket  *sink.Socket
+	batches map[batchKey]*batch
+	running sync.Mutex // one command at a time; later firings wait
+}
+
+// batchKey groups edits by session and directory: each fires on its own.
+type batchKey struct {
+	session, cwd string
+}
+
+// batch collects edits until they settle.
+type batch struct {
+	files []string
+	timer *time.Timer
+}
+
+// New starts a runner for specs, listening on their sockets.
+func New(specs []Spec) (*Runner, error) {
+	r := &Runner{tracker: edits.NewTracker(), since: time.Now()}
+	for _, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			r.Close()
+			return nil, err
+		}
+		t := &trigger{spec: spec, batches: make(map[batchKey]*batch)}
+		t.match, _ = NewMatcher(spec.Paths)
+		if spec.Socket != "" {
+			sock, err := sink.ListenSocket(spec.Socket)
+			if err != nil {
+				r.Close()
+				return nil, fmt.Errorf("trigger %q: %w", spec.Name, err)
+			}
+			t.socket = sock
+		}
+		r.triggers = append(r.triggers, t)
+	}
+	return r, nil
+}
+
+// Publish notes the edit item completes, if any, in every trigger whose
+// globs match, restarting the trigger's settle timer.
+func (r *Runner) Publish(item parser.StreamItem) {
+	ev, ok := r.tracker.Add(item)
+	if !ok || item.Host != "" || ev.Timestamp.Before(r.since) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	for _, t := range r.triggers {
+		if !t.match.Match(ev.Path, item.Cwd) {
+			continue
+		}
+		key := batchKey{ev.SessionID, item.Cwd}
+		settle := cmp.Or(t.spec.Settle, DefaultSettle)
+		b, ok := t.batches[key]
+		if !ok {
+			b = &batch{}
+			b.timer = time.AfterFunc(settle, func() { r.fire(t, key, b) })
+			t.batches[key] = b
+		} else {
+			b.timer.Reset(settle)
+		}
+		if !slices.Contains(b.files, ev.Path) {
+			b.files = append(b.files, ev.Path)
+		}
+	}
+}
+
+// fire sends b's event and runs the command. A timer that went off while
+// Publish was resetting it finds its batch gone and does nothing.
+func (r *Runner) fire(t *trigger, key batchKey, b *batch) {
+	r.mu.Lock()
+	if r.closed || t.batches[key] != b {
+		r.mu.Unlock()
+		return
+	}
+	delete(t.batches, key)
+	r.mu.Unlock()
+
+	ev := Event{Trigger: t.spec.Name, Files: slices.Sorted(slices.Values(b.files)), SessionID: key.session, Cwd: key.cwd, Time: time.Now()}
+	line, err := ev.MarshalLine()
+	if err != nil {
+		return
+	}
+	if t.socket != nil {
+		t.socket.WriteLine(line)
+	}
+	if t.spec.Command == "" {
+		return
+	}
+	t.running.Lock()
+	defer t.running.Unlock()
+	slog.Info("trigger fired", "trigger", t.spec.Name, "files", len(ev.Files), "session", ev.SessionID)
+	if err := run(t.spec.Command, ev, line); err != nil {
+		slog.Warn("trigger command failed", "trigger", t.spec.Name, "err", err)
+	}
+}
+
+// run runs command in ev's directory with ev on stdin and as ESP_TRIGGER,
+// ESP_FILES (one per line), ESP_SESSION and ESP_CWD. Its output goes to
+// the log at debug level.
+func run(command string, ev Event, payload []byte) error {
+	cmd := shell.Command(context.Background(), command)
+	if info, err := os.Stat(ev.Cwd); err == nil && info.IsDir() {
+		cmd.Dir = ev.Cwd
+	}
+	var files bytes.Buffer
+	for _, f := range ev.Files {
+		files.WriteString(f + "\n")
+	}
+	cmd.Env = append(os.Environ(),
+		"ESP_TRIGGER="+ev.Trigger,
+		"ESP_FILES="+files.String(),
+		"ESP_SESSION="+ev.SessionID,
+		"ESP_CWD="+ev.Cwd,
+	)
+	cmd.Stdin = bytes.NewReader(payload)
+	out, err := cmd.CombinedOutput()
+	slog.Debug("trigger output", "trigger", ev.Trigger, "output", string(out))
+	return err
+}
+
+// Close stops pending triggers and closes the sockets. Commands already
+// running finish on their own.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, t := range r.triggers {
+		for _, b := range t.batches {
+			b.timer.Stop()
+		}
+		if t.socket != nil {
+			t.socket.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package trigger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher([]string{"*.go", "web/**/*.ts", "/etc/app/*.conf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path, cwd string
+		want      bool
+	}{
+		{"/src/api/main.go", "/src", true},
+		{"/src/api/main.go.orig", "/src", false},
+		{"/src/web/app.ts", "/src", true},
+		{"/src/web/lib/deep/x.ts", "/src", true},
+		{"/src/webx/app.ts", "/src", false},
+		{"/other/web/app.ts", "/src", false},
+		{"/src/web/app.ts", "", false},
+		{"/etc/app/main.conf", "/src", true},
+		{"/etc/app/sub/main.conf", "/src", false},
+	} {
+		if got := m.Match(tc.path, tc.cwd); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.path, tc.cwd, got, tc.want)
+		}
+	}
+	if _, err := NewMatcher([]string{" "}); err == nil {
+		t.Error("empty glob accepted")
+	}
+}
+
+func edit(id, path, cwd string, at time.Time) []parser.StreamItem {
+	input, _ := json.Marshal(map[string]string{"file_path": path, "content": "x"})
+	return []parser.StreamItem{
+		{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Write", ToolID: id, ToolInput: input, Timestamp: at, Cwd: cwd},
+		{Type: parser.TypeToolOutput, SessionID: "s1", ToolID: id, Timestamp: at, Cwd: cwd},
+	}
+}
+
+func TestRunnerFiresOnceEditsSettle(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "fired.json")
+	r, err := New([]Spec{{
+		Name:    "tests",
+		Paths:   []string{"*.go"},
+		Command: "cat >> fired.json",
+		Settle:  50 * time.Millisecond,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, item := range edit("old", filepath.Join(dir, "old.go"), dir, time.Now().Add(-time.Hour)) {
+		r.Publish(item) // history: ignored
+	}
+	now := time.Now()
+	for i, name := range []string{"b.go", "a.go", "README.md", "a.go"} {
+		for _, item := range edit(string(rune('a'+i)), filepath.Join(dir, name), dir, now) {
+			r.Publish(item)
+		}
+	}
+
+	var ev Event
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(out)
+		if err == nil && json.Unmarshal(data, &ev) == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("trigger didn't fire (%v)", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	want := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+	if ev.Trigger != "tests" || ev.SessionID != "s1" || ev.Cwd != dir || len(ev.Files) != 2 || ev.Files[0] != want[0] || ev.Files[1] != want[1] {
+		t.Errorf("event = %+v", ev)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if data, _ := os.ReadFile(out); json.Valid(data) == false {
+		t.Errorf("fired more than once:\n%s", data)
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	for name, spec := range map[string]Spec{
+		"no name":   {Paths: []string{"*.go"}, Command: "true"},
+		"no paths":  {Name: "x", Command: "true"},
+		"no action": {Name: "x", Paths: []string{"*.go"}},
+		"settle":    {Name: "x", Paths: []string{"*.go"}, Command: "true", Settle: -time.Second},
+	} {
+		if spec.Validate() == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// apiThrottleWindow is how recent a session's last API error must be for
+// the header to show the session as throttled.
+const apiThrottleWindow = 5 * time.Minute
+
+// apiErrors counts the API errors in the watched sessions and remembers,
+// per session, the last one not yet followed by model output: slow
+// progress there is the API's doing, not the agent's.
+type apiErrors struct {
+	total     int
+	throttled map[string]parser.StreamItem // by session
+	now       func() time.Time
+}
+
+func newAPIErrors() *apiErrors {
+	return &apiErrors{throttled: make(map[string]parser.StreamItem), now: time.Now}
+}
+
+// Add counts an API error, or clears its session's throttling when the
+// model answers again.
+func (a *apiErrors) Add(item parser.StreamItem) {
+	switch item.Type {
+	case parser.TypeAPIError:
+		a.total++
+		a.throttled[item.SessionID] = item
+	case parser.TypeThinking, parser.TypeText, parser.TypeToolInput, parser.TypeTurnMarker, parser.TypeInterrupt:
+		delete(a.throttled, item.SessionID)
+	}
+}
+
+// current returns the latest API error of a session that is still waiting
+// on the API.
+func (a *apiErrors) current() (parser.StreamItem, bool) {
+	var latest parser.StreamItem
+	for _, item := range a.throttled {
+		if item.Timestamp.After(latest.Timestamp) {
+			latest = item
+		}
+	}
+	if latest.Type == "" || a.now().Sub(latest.Timestamp) > apiThrottleWindow {
+		return latest, false
+	}
+	return latest, true
+}
+
+// Header returns the header's API error counter ("⛔ 3 API errors"), led
+// by what is happening while a session waits on the API, and whether it
+// should be shown as a banner. While Claude Code waits out a retry the
+// banner counts down to it ("resuming in 42s"). It is "" before the first
+// error.
+func (a *apiErrors) Header() (string, bool) {
+	if a.total == 0 {
+		return "", false
+	}
+	count := fmt.Sprintf("%d API errors", a.total)
+	if a.total == 1 {
+		count = "1 API error"
+	}
+	item, ok := a.current()
+	if !ok {
+		return apiErrorIcon + " " + count, false
+	}
+	if wait := item.RetryAt.Sub(a.now()); wait > 0 {
+		return fmt.Sprintf("%s API %s (%s) · resuming in %s · %s", apiErrorIcon, item.ToolName, item.AgentName, formatCountdown(wait), count), true
+	}
+	return fmt.Sprintf("%s API %s %s (%s) · %s", apiErrorIcon, item.ToolName, recapAgo(a.now(), item.Timestamp), item.AgentName, count), true
+}
+
+// formatCountdown shows the time left to a retry in whole seconds, rounded
+// up so it never reads 0s early: 42s, 3m05s.
+func formatCountdown(d time.Duration) string {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 60 {
+		return fmt.Sprintf("%ds", s)
+	}
+	return fmt.Sprintf("%dm%02ds", s/60, s%60)
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestAPIErrors(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := newAPIErrors()
+	a.now = func() time.Time { return now }
+
+	if text, _ := a.Header(); text != "" {
+		t.Errorf("header before any error: %q", text)
+	}
+	apiError := func(session, kind string, ago time.Duration) parser.StreamItem {
+		return parser.StreamItem{Type: parser.TypeAPIError, SessionID: session, AgentName: "Main", ToolName: kind, Timestamp: now.Add(-ago)}
+	}
+	a.Add(apiError("s1", parser.APIOverloaded, 3*time.Minute))
+	a.Add(apiError("s2", parser.APIRateLimited, 40*time.Second))
+	text, throttled := a.Header()
+	if !throttled || text != "⛔ API rate limited 40s ago (Main) · 2 API errors" {
+		t.Errorf("header = %q, throttled %v", text, throttled)
+	}
+
+	// The model answering in s2 leaves s1's overload as the current one.
+	a.Add(parser.StreamItem{Type: parser.TypeThinking, SessionID: "s2", Timestamp: now})
+	if text, _ := a.Header(); !strings.HasPrefix(text, "⛔ API overloaded 3m ago") {
+		t.Errorf("header after s2 recovered = %q", text)
+	}
+	// Tool results don't mean the API is back.
+	a.Add(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", Timestamp: now})
+	if _, throttled := a.Header(); !throttled {
+		t.Error("tool output cleared s1's throttling")
+	}
+
+	// A retry still to come counts down to it, then the error shows its age.
+	retry := apiError("s1", parser.APIRateLimited, 10*time.Second)
+	retry.RetryAt = now.Add(32 * time.Second)
+	a.Add(retry)
+	if text, throttled := a.Header(); !throttled || text != "⛔ API rate limited (Main) · resuming in 32s · 3 API errors" {
+		t.Errorf("header while waiting to retry = %q, throttled %v", text, throttled)
+	}
+	now = now.Add(31500 * time.Millisecond)
+	if text, _ := a.Header(); !strings.Contains(text, "resuming in 1s") {
+		t.Errorf("header half a second before the retry = %q", text)
+	}
+	now = now.Add(time.Second)
+	if text, _ := a.Header(); !strings.HasPrefix(text, "⛔ API rate limited 42s ago") {
+		t.Errorf("header once the retry is due = %q", text)
+	}
+
+	now = now.Add(apiThrottleWindow)
+	if text, throttled := a.Header(); throttled || text != "⛔ 3 API errors" {
+		t.Errorf("header after the window = %q, throttled %v", text, throttled)
+	}
+}
+
+func TestRenderAPIError(t *testing.T) {
+	s := NewStreamView()
+	item := parser.StreamItem{Type: parser.TypeAPIError, SessionID: "s1", AgentName: "Main", ToolName: parser.APIRateLimited, Content: "429 rate_limit_error: slow down · retry 1/10 in 30s"}
+	out := s.renderItem(item, 100)
+	for _, want := range []string{"⛔ API rate limited", "429 rate_limit_error: slow down · retry 1/10 in 30s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered API error lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// asciiGlyphs are the ASCII stand-ins for the emoji, box drawing and other
+// symbols claude-esp draws, for terminals and fonts that can't show them.
+var asciiGlyphs = map[string]string{
+	// Borders, separators and the tree.
+	"╭": "+", "╮": "+", "╰": "+", "╯": "+", "┌": "+", "┐": "+", "└": "`", "┘": "+",
+	"┏": "+", "┓": "+", "┗": "+", "┛": "+",
+	"├": "|", "┤": "|", "┬": "+", "┴": "+", "┼": "+", "╵": "'",
+	"─": "-", "━": "-", "═": "=", "│": "|", "┃": "|", "║": "|",
+	"▌": "|", "▎": "|", "▸": ">", "▾": "v",
+	// Bars and sparklines.
+	"▁": "_", "▂": ".", "▃": ",", "▄": "-", "▅": "=", "▆": "+", "▇": "*", "█": "#",
+	"▓": "#", "▰": "#", "▱": "-",
+	// Punctuation.
+	"·": ".", "…": ".", "—": "-", "→": ">", "↓": "v", "≈": "~", "≤": "<", "×": "x",
+	"»": ">", "‼": "!", "⏎": "~",
+	// Marks.
+	"✓": "+", "✗": "x", "⚠": "!", "✎": "#", "✦": "*", "⇄": "=", "⑂": "Y", "★": "*", "●": "*", "◐": "o",
+	"☐": "o", "☑": "x", "❯": ">", "⏱": "@", "⊘": "/", "⚙": "*", "👁": "o",
+	// Item types and panels.
+	"🧠": "~~", "🔧": ">_", "📤": "<=", "💬": "''", "🪝": "J:", "🔍": "?>", "⛔": "!!",
+	"📁": "[]", "📂": "[]", "📋": "[=", "📎": "&&", "🤖": "@@", "🧪": "T:", "📨": "R:",
+	"💤": "zz", "🔕": "z-", "⏳": "..", "⏩": ">>", "⏪": "<<",
+}
+
+// asciiReplacer swaps each of asciiGlyphs for its stand-in, padded or cut
+// to the glyph's width so the layout measured with the glyph still holds.
+var asciiReplacer = func() *strings.Replacer {
+	var pairs []string
+	for glyph, ascii := range asciiGlyphs {
+		w := lipgloss.Width(glyph)
+		pairs = append(pairs, glyph, fmt.Sprintf("%-*.*s", w, w, ascii))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// toASCII is frame with claude-esp's symbols in ASCII. Other text, such as
+// a transcript in another script, is left alone.
+func toASCII(frame string) string {
+	return asciiReplacer.Replace(frame)
+}
+
+// limitedTerm matches $TERM values of terminals without Unicode symbols: the
+// Linux console, DEC VTs and their emulators, and dumb terminals.
+var limitedTerm = regexp.MustCompile(`^(linux|dumb|ansi|cons25|vt\d+)(-|$)`)
+
+// DetectASCII reports whether the terminal described by getenv likely
+// can't show emoji and box drawing: a limitedTerm, or a locale whose
+// character set isn't UTF-8. Without a locale it assumes UTF-8, as
+// terminals that don't set one (Windows, many containers) usually are.
+func DetectASCII(getenv func(string) string) bool {
+	if limitedTerm.MatchString(getenv("TERM")) {
+		return true
+	}
+	locale := strings.ToLower(cmp.Or(getenv("LC_ALL"), getenv("LC_CTYPE"), getenv("LANG")))
+	return locale != "" && !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8")
+}
+
+// SetASCII draws everything in ASCII, for terminals DetectASCII flags or
+// -ascii.
+func (m *Model) SetASCII(on bool) {
+	m.ascii = on
+}
+
+// setASCII is ":ascii", switching ASCII drawing on or off.
+func (m *Model) setASCII(arg string) (string, error) {
+	switch arg {
+	case "on":
+		m.ascii = true
+	case "off":
+		m.ascii = false
+	case "toggle":
+		m.ascii = !m.ascii
+	case "":
+	default:
+		return "", fmt.Errorf("want on, off or toggle, got %q", arg)
+	}
+	if m.ascii {
+		return "ascii on", nil
+	}
+	return "ascii off", nil
+}
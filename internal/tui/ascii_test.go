@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestDetectASCII(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want bool
+	}{
+		{map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"}, false},
+		{map[string]string{"TERM": "xterm-256color"}, false},
+		{map[string]string{"TERM": "linux", "LANG": "en_US.UTF-8"}, true},
+		{map[string]string{"TERM": "vt100"}, true},
+		{map[string]string{"TERM": "vt220-am"}, true},
+		{map[string]string{"TERM": "vte-256color"}, false},
+		{map[string]string{"TERM": "xterm", "LANG": "en_US.ISO-8859-1"}, true},
+		{map[string]string{"TERM": "xterm", "LC_ALL": "C", "LANG": "en_US.UTF-8"}, true},
+		{map[string]string{"TERM": "xterm", "LC_CTYPE": "de_DE.utf8", "LANG": "C"}, false},
+	} {
+		if got := DetectASCII(func(k string) string { return tc.env[k] }); got != tc.want {
+			t.Errorf("DetectASCII(%v) = %v, want %v", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestASCIIView(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeThinking, SessionID: "s1", Content: "hmm"},
+		{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", Content: "ls"},
+		{Type: parser.TypeText, SessionID: "s1", Content: "Grüße, 世界 ✓"},
+	})
+	m.runPalette("ascii on")
+	if !m.ascii || m.status != "ascii on" {
+		t.Fatalf("ascii on: %v, status %q", m.ascii, m.status)
+	}
+	view := m.View()
+	m.ascii = false
+	unicode := strings.Split(m.View(), "\n")
+	m.ascii = true
+	for glyph := range asciiGlyphs {
+		if strings.Contains(view, glyph) {
+			t.Errorf("%q left in the ASCII view", glyph)
+		}
+	}
+	if !strings.Contains(view, "Grüße, 世界 +") {
+		t.Error("text in other scripts should be left alone")
+	}
+	for i, line := range strings.Split(view, "\n") {
+		if i < len(unicode) && lipgloss.Width(line) != lipgloss.Width(unicode[i]) {
+			t.Errorf("line %d is %d wide, was %d:\n%s\n%s", i, lipgloss.Width(line), lipgloss.Width(unicode[i]), line, unicode[i])
+		}
+	}
+
+	m.runPalette("ascii toggle")
+	if !strings.Contains(m.View(), "╭") {
+		t.Error("ascii toggle didn't bring the borders back")
+	}
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// SetAt opens the stream at t, for -at: as history arrives the first item
+// from t on is selected at the top of the view, until a key is pressed or
+// live items start arriving. With nothing that recent the stream follows
+// the newest items as usual. The watcher replays all of the history for
+// it, however long.
+func (m *Model) SetAt(t time.Time) {
+	m.at, m.atSince = t, time.Now()
+}
+
+// SetGoto opens the stream at the item with id (see deeplink.ItemID), for
+// -goto: like -at, but at that exact item, and the watcher likewise
+// replays all of the history. If live items start arriving without it
+// having shown up, the help bar says so.
+func (m *Model) SetGoto(id string) {
+	m.gotoID, m.atSince = id, time.Now()
+}
+
+// seekAt moves the stream to -at's moment or -goto's item after a batch
+// of items, which may have brought history from before the item selected
+// so far.
+func (m *Model) seekAt(batch []parser.StreamItem) {
+	if m.at.IsZero() && m.gotoID == "" {
+		return
+	}
+	if !m.lastKey.IsZero() {
+		m.at, m.gotoID = time.Time{}, ""
+		return
+	}
+	found := false
+	if m.gotoID != "" {
+		found = m.stream.SelectID(m.gotoID)
+	} else {
+		m.stream.SelectAt(m.at)
+	}
+	for _, item := range batch {
+		if item.Timestamp.After(m.atSince) {
+			if m.gotoID != "" && !found {
+				m.status = fmt.Sprintf("item #%s isn't in the stream", m.gotoID)
+			}
+			m.at, m.gotoID = time.Time{}, ""
+			return
+		}
+	}
+}
+
+// gotoItem is the goto palette command: with an ID it selects that item,
+// and without one it copies the selected item's permalink.
+func (m *Model) gotoItem(arg string) (string, error) {
+	if arg == "" {
+		item, ok := m.stream.SelectedItem()
+		if !ok {
+			return "", errors.New("select an item with J/K to copy its permalink")
+		}
+		link := deeplink.Goto(item.SessionID, deeplink.ItemID(item))
+		m.afterPrompt = m.copyText(link, "copied "+link)
+		return "copying " + link + "…", nil
+	}
+	id, err := deeplink.ParseItemID(arg)
+	if err != nil {
+		return "", err
+	}
+	if !m.stream.SelectID(id) {
+		return "", fmt.Errorf("item #%s isn't in the stream", id)
+	}
+	return "#" + id, nil
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/clipboard"
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestAt(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	t0 := time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)
+	history := func(agent string, minutes ...int) streamItemsMsg {
+		var batch streamItemsMsg
+		for _, minute := range minutes {
+			batch = append(batch, parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: agent, Content: "line\nline\nline", Timestamp: t0.Add(time.Duration(minute) * time.Minute)})
+		}
+		return batch
+	}
+	selected := func() time.Time {
+		item, _ := m.stream.SelectedItem()
+		return item.Timestamp
+	}
+
+	m.SetAt(t0.Add(3 * time.Minute))
+	m.Update(history("", 0, 1, 2, 3, 4, 5, 6, 7, 8, 9))
+	if got := selected(); !got.Equal(t0.Add(3*time.Minute)) || m.stream.IsAutoScrollEnabled() {
+		t.Fatalf("selected %v (auto-scroll %v), want the 14:03 item", got, m.stream.IsAutoScrollEnabled())
+	}
+	if m.stream.viewport.YOffset == 0 {
+		t.Error("the 14:03 item wasn't scrolled to the top")
+	}
+
+	// A subagent's history arrives after the main one: the first item from
+	// 14:03 on in the stream's order is still the main one.
+	m.Update(history("Explore", 2, 4))
+	if got := selected(); !got.Equal(t0.Add(3 * time.Minute)) {
+		t.Errorf("selected %v after more history", got)
+	}
+
+	// Live items end the seeking.
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Timestamp: time.Now()}})
+	if !m.at.IsZero() {
+		t.Error("still seeking once live items arrived")
+	}
+}
+
+func TestGoto(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	t0 := time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC)
+	var history streamItemsMsg
+	for i := range 10 {
+		history = append(history, parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: fmt.Sprintf("step %d\nline\nline", i), Timestamp: t0.Add(time.Duration(i) * time.Minute)})
+	}
+	target := history[4]
+
+	m.SetGoto(deeplink.ItemID(target))
+	m.Update(history)
+	if got, _ := m.stream.SelectedItem(); got.Content != target.Content || m.stream.viewport.YOffset == 0 {
+		t.Fatalf("selected %q at line %d, want step 4 at the top", got.Content, m.stream.viewport.YOffset)
+	}
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Content: "live", Timestamp: time.Now()}})
+	if m.gotoID != "" || m.status != "" {
+		t.Errorf("still seeking (%q) or complaining (%q) once live items arrived", m.gotoID, m.status)
+	}
+
+	// An ID that never shows up is reported once live items arrive.
+	m.SetGoto("0123456789ab")
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Content: "more", Timestamp: time.Now()}})
+	if !strings.Contains(m.status, "#0123456789ab isn't in the stream") {
+		t.Errorf("status = %q", m.status)
+	}
+
+	// :goto selects an item while running.
+	m.runPalette("goto #" + deeplink.ItemID(history[1]))
+	if got, _ := m.stream.SelectedItem(); got.Content != history[1].Content {
+		t.Errorf(":goto selected %q (status %q)", got.Content, m.status)
+	}
+	m.runPalette("goto nope")
+	if !strings.Contains(m.status, "invalid item ID") {
+		t.Errorf("status = %q", m.status)
+	}
+
+	// Without an ID it copies the permalink once the prompt has closed.
+	var term strings.Builder
+	m.clipboard = clipboard.New(clipboard.OSC52, clipboard.Env{}, &term)
+	m.openPrompt(":", "goto", m.runPalette)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if term.Len() != 0 || cmd == nil {
+		t.Fatalf(":goto copied inside Update (wrote %q) or left nothing to run", term.String())
+	}
+	m.Update(cmd())
+	if !strings.HasPrefix(term.String(), "\x1b]52;c;") || !strings.HasSuffix(m.status, "(osc52)") {
+		t.Errorf(":goto: status %q, wrote %q", m.status, term.String())
+	}
+}
+
+// longSession writes a session under a fresh CLAUDE_HOME with more lines
+// than the watcher auto-skips, one a minute from t0, and returns the
+// items they become.
+func longSession(t *testing.T, t0 time.Time) []parser.StreamItem {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("CLAUDE_HOME", home)
+	dir := filepath.Join(home, "projects", "-tmp-long")
+	os.MkdirAll(dir, 0o755)
+	const sessionID = "6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b"
+	var b strings.Builder
+	var items []parser.StreamItem
+	for i := range watcher.AutoSkipLineThreshold + 50 {
+		at := t0.Add(time.Duration(i) * time.Minute)
+		fmt.Fprintf(&b, `{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"thinking","thinking":"step %d"}]}}`+"\n", at.Format(time.RFC3339), i)
+		items = append(items, parser.StreamItem{Type: parser.TypeThinking, SessionID: sessionID, Content: fmt.Sprintf("step %d", i), Timestamp: at})
+	}
+	os.WriteFile(filepath.Join(dir, sessionID+".jsonl"), []byte(b.String()), 0o644)
+	return items
+}
+
+// watchHistory starts m's watcher and feeds it the n history items.
+func watchHistory(t *testing.T, m *Model, n int) {
+	t.Helper()
+	m.Update(m.initWatcher()())
+	t.Cleanup(m.watcher.Stop)
+	var batch streamItemsMsg
+	timeout := time.After(5 * time.Second)
+	for len(batch) < n {
+		select {
+		case item := <-m.watcher.Items:
+			batch = append(batch, item)
+		case <-timeout:
+			t.Fatalf("got %d of %d history items", len(batch), n)
+		}
+	}
+	m.Update(batch)
+}
+
+func TestAtReplaysLongHistory(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	items := longSession(t, time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC))
+	m.SetAt(items[20].Timestamp)
+	watchHistory(t, m, len(items))
+	if got, _ := m.stream.SelectedItem(); got.Content != "step 20" {
+		t.Errorf("-at selected %q, want step 20", got.Content)
+	}
+}
+
+func TestGotoReplaysLongHistory(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	items := longSession(t, time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC))
+	m.SetGoto(deeplink.ItemID(items[5]))
+	watchHistory(t, m, len(items))
+	if got, _ := m.stream.SelectedItem(); got.Content != "step 5" {
+		t.Errorf("-goto selected %q (status %q), want step 5", got.Content, m.status)
+	}
+}
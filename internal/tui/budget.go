@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/cost"
+	"github.com/phiat/claude-esp/internal/notify"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// budgetBarWidth is the number of cells in the header budget bar.
+const budgetBarWidth = 8
+
+// budgetTracker accumulates estimated spend per session and per local day
+// and reports budget thresholds as they are crossed. Each threshold fires
+// at most once per scope (session ID, or calendar day).
+type budgetTracker struct {
+	budget      config.Budget
+	thresholds  []float64
+	prices      *cost.Table
+	total       float64
+	sessionCost map[string]float64
+	dailyCost   float64
+	day         string          // local date dailyCost belongs to (2006-01-02)
+	fired       map[string]bool // "<scope>@<threshold>"
+	now         func() time.Time
+}
+
+func newBudgetTracker(cfg *config.Config) *budgetTracker {
+	b := &budgetTracker{
+		sessionCost: make(map[string]float64),
+		fired:       make(map[string]bool),
+		now:         time.Now,
+	}
+	b.configure(cfg)
+	return b
+}
+
+// configure takes budgets, thresholds and prices from cfg, keeping what
+// has been spent and which thresholds already fired.
+func (b *budgetTracker) configure(cfg *config.Config) {
+	b.budget = cfg.Budget
+	b.thresholds = cfg.BudgetThresholds()
+	// Load already rejected invalid overrides; a partially valid table is
+	// still the best estimate available.
+	b.prices, _ = cfg.PricingTable()
+}
+
+// Add records an item's usage and returns notification events for any
+// budget threshold it pushes past. Usage older than loopNotifyWindow
+// still marks thresholds fired, but returns no events.
+func (b *budgetTracker) Add(item parser.StreamItem) []notify.Event {
+	usd := b.prices.Estimate(item.Model, cost.Usage{
+		InputTokens:         item.InputTokens,
+		OutputTokens:        item.OutputTokens,
+		CacheCreationTokens: item.CacheCreationTokens,
+		CacheReadTokens:     item.CacheReadTokens,
+	})
+	if usd <= 0 {
+		return nil
+	}
+
+	b.total += usd
+	b.sessionCost[item.SessionID] += usd
+
+	today := b.now().Local().Format("2006-01-02")
+	if b.day != today {
+		b.day = today
+		b.dailyCost = 0
+	}
+	// Only usage from today counts against the daily budget; replayed
+	// history from earlier days still shows up in the session totals.
+	if item.Timestamp.Local().Format("2006-01-02") == today {
+		b.dailyCost += usd
+	}
+
+	var events []notify.Event
+	if b.budget.Session > 0 {
+		events = append(events, b.check("session:"+item.SessionID, b.sessionCost[item.SessionID], b.budget.Session,
+			fmt.Sprintf("session %s", truncate(item.SessionID, 12)), item.SessionID)...)
+	}
+	if b.budget.Daily > 0 {
+		events = append(events, b.check("daily:"+today, b.dailyCost, b.budget.Daily, "today", "")...)
+	}
+	// Replayed history crosses thresholds it crossed on earlier runs too:
+	// mark them fired, but only notify about fresh usage.
+	if b.now().Sub(item.Timestamp) >= loopNotifyWindow {
+		return nil
+	}
+	return events
+}
+
+func (b *budgetTracker) check(scope string, spent, budget float64, label, sessionID string) []notify.Event {
+	var events []notify.Event
+	for _, t := range b.thresholds {
+		key := fmt.Sprintf("%s@%g", scope, t)
+		if spent < budget*t || b.fired[key] {
+			continue
+		}
+		b.fired[key] = true
+		events = append(events, notify.Event{
+			Kind:      "budget",
+			Title:     fmt.Sprintf("claude-esp: %.0f%% of budget", t*100),
+			Message:   fmt.Sprintf("%s spent $%.2f of $%.2f budget (%.0f%%)", label, spent, budget, spent/budget*100),
+			SessionID: sessionID,
+			Time:      b.now(),
+		})
+	}
+	return events
+}
+
+// maxSession returns the session spend closest to (or furthest over) budget.
+func (b *budgetTracker) maxSession() float64 {
+	var worst float64
+	for _, c := range b.sessionCost {
+		if c > worst {
+			worst = c
+		}
+	}
+	return worst
+}
+
+// Total is the estimated spend across everything seen this run.
+func (b *budgetTracker) Total() float64 {
+	return b.total
+}
+
+// HeaderBars renders one "label $spent/$budget ▰▰▱▱ NN%" bar per configured
+// scope, or "" when no budget is configured.
+func (b *budgetTracker) HeaderBars() string {
+	var parts []string
+	if b.budget.Session > 0 {
+		parts = append(parts, renderBudgetBar("sess", b.maxSession(), b.budget.Session))
+	}
+	if b.budget.Daily > 0 {
+		daily := b.dailyCost
+		if b.day != b.now().Local().Format("2006-01-02") {
+			daily = 0
+		}
+		parts = append(parts, renderBudgetBar("day", daily, b.budget.Daily))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// renderBudgetBar returns plain text: the header applies one style to the
+// whole line, and an embedded ANSI reset would drop its background for the
+// rest of the bar. Over-threshold scopes get a trailing warning marker.
+func renderBudgetBar(label string, spent, budget float64) string {
+	ratio := spent / budget
+	filled := int(ratio * budgetBarWidth)
+	filled = max(0, min(budgetBarWidth, filled))
+	bar := strings.Repeat("▰", filled) + strings.Repeat("▱", budgetBarWidth-filled)
+	text := fmt.Sprintf("%s $%.2f/$%.2f %s %.0f%%", label, spent, budget, bar, ratio*100)
+	switch {
+	case ratio >= 1:
+		text += " ‼"
+	case ratio >= 0.8:
+		text += " !"
+	}
+	return text
+}
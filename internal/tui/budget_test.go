@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestBudgetTracker_FiresEachThresholdOnce(t *testing.T) {
+	cfg := config.Default()
+	cfg.Budget.Session = 1.50
+	b := newBudgetTracker(cfg)
+	now := time.Now()
+
+	// $0.75 → crosses 50%
+	evs := b.Add(newTestItem(parser.TypeText, "s1", "", "", withTime(now), withTokens(50_000)))
+	if len(evs) != 1 || !strings.Contains(evs[0].Title, "50%") {
+		t.Fatalf("expected 50%% event, got %+v", evs)
+	}
+	// another $0.75 → crosses 80% and 100% at once
+	evs = b.Add(newTestItem(parser.TypeText, "s1", "", "", withTime(now), withTokens(50_000)))
+	if len(evs) != 2 {
+		t.Fatalf("expected 80%% and 100%% events, got %+v", evs)
+	}
+	// already past every threshold → nothing new
+	if evs = b.Add(newTestItem(parser.TypeText, "s1", "", "", withTime(now), withTokens(50_000))); len(evs) != 0 {
+		t.Errorf("thresholds should fire once, got %+v", evs)
+	}
+	// a different session has its own scope
+	if evs = b.Add(newTestItem(parser.TypeText, "s2", "", "", withTime(now), withTokens(50_000))); len(evs) != 1 {
+		t.Errorf("second session should fire its own 50%% event, got %+v", evs)
+	}
+}
+
+func TestBudgetTracker_DailyIgnoresOlderHistory(t *testing.T) {
+	cfg := config.Default()
+	cfg.Budget.Daily = 1
+	b := newBudgetTracker(cfg)
+
+	if evs := b.Add(newTestItem(parser.TypeText, "s1", "", "", withTime(time.Now().Add(-72*time.Hour)), withTokens(1_000_000))); len(evs) != 0 {
+		t.Errorf("usage from earlier days should not count against today, got %+v", evs)
+	}
+	if b.Total() < 14.9 {
+		t.Errorf("history should still count toward the running total, got %v", b.Total())
+	}
+	if evs := b.Add(newTestItem(parser.TypeText, "s1", "", "", withTokens(70_000))); len(evs) == 0 {
+		t.Error("today's usage should cross the daily 50% threshold")
+	}
+}
+
+func TestBudgetTracker_HistoryDoesNotNotify(t *testing.T) {
+	cfg := config.Default()
+	cfg.Budget.Session = 1.50
+	b := newBudgetTracker(cfg)
+
+	// Replayed from an earlier run: $0.75 crosses 50% without notifying.
+	if evs := b.Add(newTestItem(parser.TypeText, "s1", "", "", withTime(time.Now().Add(-time.Hour)), withTokens(50_000))); len(evs) != 0 {
+		t.Errorf("replayed history should not notify, got %+v", evs)
+	}
+	// Live usage notifies only for the thresholds it crosses itself.
+	evs := b.Add(newTestItem(parser.TypeText, "s1", "", "", withTokens(50_000)))
+	if len(evs) != 2 || strings.Contains(evs[0].Title, "50%") {
+		t.Errorf("expected only the 80%% and 100%% events, got %+v", evs)
+	}
+}
+
+func TestBudgetTracker_HeaderBars(t *testing.T) {
+	b := newBudgetTracker(config.Default())
+	if got := b.HeaderBars(); got != "" {
+		t.Errorf("no budget configured → no bars, got %q", got)
+	}
+
+	cfg := config.Default()
+	cfg.Budget.Session = 1
+	b = newBudgetTracker(cfg)
+	b.Add(newTestItem(parser.TypeText, "s1", "", "", withTokens(100_000))) // $1.50
+	got := b.HeaderBars()
+	if !strings.Contains(got, "$1.50/$1.00") || !strings.Contains(got, "▰▰▰▰▰▰▰▰") || !strings.Contains(got, "‼") {
+		t.Errorf("unexpected bar %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+// colorFallbacks are the 256- and 16-color stand-ins for the palette's
+// truecolor shades. Picked by hand: the nearest match by distance turns
+// several of them into the same gray, or the wrong hue, on 16 colors.
+var colorFallbacks = map[string][2]string{
+	"#7C3AED": {"92", "5"},   // purple
+	"#A78BFA": {"141", "13"}, // violet
+	"#818CF8": {"105", "12"}, // indigo
+	"#C7D2FE": {"189", "12"}, // light indigo
+	"#60A5FA": {"75", "12"},  // blue
+	"#93C5FD": {"153", "12"}, // light blue
+	"#06B6D4": {"37", "6"},   // cyan
+	"#22D3EE": {"44", "14"},  // bright cyan
+	"#67E8F9": {"123", "14"}, // light cyan
+	"#10B981": {"36", "2"},   // green
+	"#34D399": {"78", "10"},  // bright green
+	"#6EE7B7": {"79", "10"},  // light green
+	"#F59E0B": {"214", "3"},  // amber
+	"#FBBF24": {"220", "11"}, // yellow
+	"#FCD34D": {"221", "11"}, // light yellow
+	"#FB923C": {"209", "3"},  // orange
+	"#EF4444": {"203", "1"},  // red
+	"#F87171": {"210", "9"},  // light red
+	"#FCA5A5": {"217", "9"},  // pale red
+	"#F472B6": {"211", "5"},  // pink
+	"#F9FAFB": {"231", "15"}, // white
+	"#D1D5DB": {"252", "7"},  // light gray
+	"#9CA3AF": {"248", "7"},  // gray
+	"#6B7280": {"243", "8"},  // dim gray
+	"#374151": {"238", "8"},  // slate
+	"#1F2937": {"235", "0"},  // dark gray
+}
+
+// hexColor is a palette color: hex on truecolor terminals, its
+// colorFallbacks on 256 and 16 colors.
+func hexColor(hex string) lipgloss.TerminalColor {
+	fallback, ok := colorFallbacks[hex]
+	if !ok {
+		return lipgloss.Color(hex)
+	}
+	return lipgloss.CompleteColor{TrueColor: hex, ANSI256: fallback[0], ANSI: fallback[1]}
+}
+
+// SetColors picks how many colors to draw with, one of config.ColorModes:
+// "auto" (or "") detects what the terminal supports, honouring NO_COLOR
+// and CLICOLOR_FORCE, the others force one. Without colors the tree's
+// selection is drawn in reverse video, as its background can't show it.
+func SetColors(mode string) error {
+	var profile termenv.Profile
+	switch mode {
+	case "", "auto":
+		profile = termenv.NewOutput(os.Stdout).EnvColorProfile()
+	case "truecolor":
+		profile = termenv.TrueColor
+	case "256":
+		profile = termenv.ANSI256
+	case "16":
+		profile = termenv.ANSI
+	case "none":
+		profile = termenv.Ascii
+	default:
+		return fmt.Errorf("unknown colors %q (want %s)", mode, strings.Join(config.ColorModes, ", "))
+	}
+	lipgloss.SetColorProfile(profile)
+	treeSelectedStyle = treeSelectedStyle.Reverse(profile == termenv.Ascii)
+	return nil
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSetColors(t *testing.T) {
+	profile, selected := lipgloss.ColorProfile(), treeSelectedStyle
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(profile)
+		treeSelectedStyle = selected
+	})
+
+	for mode, want := range map[string]string{
+		"truecolor": "38;2;124;58;237",
+		"256":       "38;5;92",
+		"16":        "35",
+	} {
+		if err := SetColors(mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := thinkingStyle.Render("x"); !strings.Contains(got, want) {
+			t.Errorf("%s: thinking = %q, want %q in it", mode, got, want)
+		}
+		if treeSelectedStyle.GetReverse() {
+			t.Errorf("%s: selection in reverse video", mode)
+		}
+	}
+
+	// Every palette color has its own stand-in on 16 colors, and sessions
+	// stay told apart.
+	SetColors("16")
+	seen := make(map[string]bool)
+	for _, c := range sessionColors {
+		got := lipgloss.NewStyle().Foreground(c).Render("x")
+		if seen[got] {
+			t.Errorf("two sessions share %q on 16 colors", got)
+		}
+		seen[got] = true
+	}
+
+	if err := SetColors("none"); err != nil {
+		t.Fatal(err)
+	}
+	if got := thinkingStyle.Render("x"); strings.Contains(got, "38;") || strings.Contains(got, "[35") {
+		t.Errorf("none: thinking = %q, still colored", got)
+	}
+	if !treeSelectedStyle.GetReverse() {
+		t.Error("none: the tree selection needs reverse video")
+	}
+
+	if err := SetColors("rainbow"); err == nil {
+		t.Error("SetColors(rainbow) should fail")
+	}
+}
+
+func TestPaletteFallbacks(t *testing.T) {
+	for hex, fallback := range colorFallbacks {
+		if _, ok := hexColor(hex).(lipgloss.CompleteColor); !ok || fallback[0] == "" || fallback[1] == "" {
+			t.Errorf("%s has no fallback", hex)
+		}
+	}
+	for _, c := range sessionColors {
+		if _, ok := c.(lipgloss.CompleteColor); !ok {
+			t.Errorf("session color %v has no fallback", c)
+		}
+	}
+}
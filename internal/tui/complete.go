@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/filter"
+)
+
+// completionNames are the names prompts complete from: tools seen in the
+// stream, and agents and sessions in the tree.
+type completionNames struct {
+	tools, agents, agentIDs, sessions, sessionIDs []string
+}
+
+func (m *Model) completionNames() completionNames {
+	var n completionNames
+	for _, item := range m.stream.items {
+		if item.ToolName != "" && !slices.Contains(n.tools, item.ToolName) {
+			n.tools = append(n.tools, item.ToolName)
+		}
+	}
+	for _, session := range m.tree.Root.Children {
+		n.sessions = append(n.sessions, session.Name)
+		n.sessionIDs = append(n.sessionIDs, session.ID)
+		for _, child := range session.Children {
+			switch child.Type {
+			case NodeTypeMain:
+				n.agents = append(n.agents, child.Name)
+			case NodeTypeAgent:
+				n.agents = append(n.agents, child.Name)
+				n.agentIDs = append(n.agentIDs, child.ID)
+			}
+		}
+	}
+	slices.Sort(n.tools)
+	return n
+}
+
+// remember gives the open prompt the history kept under kind and records
+// what is submitted there.
+func (m *Model) remember(kind string) {
+	p := m.prompt
+	p.SetHistory(m.history.Lines(kind))
+	submit := p.onSubmit
+	p.onSubmit = func(value string) {
+		if err := m.history.Add(kind, strings.TrimSpace(value)); err != nil {
+			slog.Warn("prompt history not saved", "err", err)
+		}
+		submit(value)
+	}
+}
+
+// completeTreeFilter completes the tree filter's query with session and
+// agent names.
+func (m *Model) completeTreeFilter(string) []string {
+	n := m.completionNames()
+	return append(n.sessions, n.agents...)
+}
+
+// completeSearch completes a pager search with tool and agent names.
+func (m *Model) completeSearch(string) []string {
+	n := m.completionNames()
+	return append(n.tools, n.agents...)
+}
+
+// completePalette completes a palette line: the command, then its
+// argument.
+func (m *Model) completePalette(before string) []string {
+	name, rest, ok := strings.Cut(strings.TrimLeft(before, " "), " ")
+	if !ok {
+		names := []string{"help"}
+		for _, c := range paletteCommands {
+			names = append(names, c.name+" ")
+		}
+		return names
+	}
+	switch name {
+	case "filter":
+		return m.completeFilter(rest)
+	case "unignore":
+		return append([]string{"all"}, m.ignored...)
+	}
+	for _, c := range paletteCommands {
+		if c.name == name && c.args != "" && !strings.HasPrefix(c.args, "<") {
+			return strings.Split(c.args, "|")
+		}
+	}
+	return nil
+}
+
+// filterValue matches the end of a filter expression where a value of
+// the field it captures comes next: after an operator, or in an in list.
+var filterValue = regexp.MustCompile(`(?i)(\w+)\s*(?:!?=|!?~|(?:not\s+)?in\s*\((?:[^()]*,)?)\s*"?$`)
+
+// completeFilter completes a filter expression: the values a field can
+// take after its operator, and otherwise fields and keywords.
+func (m *Model) completeFilter(before string) []string {
+	sub := filterValue.FindStringSubmatch(before)
+	if sub == nil {
+		return append(slices.Clone(filter.Fields), "and", "or", "not", "in")
+	}
+	n := m.completionNames()
+	switch strings.ToLower(sub[1]) {
+	case "type":
+		return filter.Types()
+	case "tool":
+		return n.tools
+	case "agent":
+		return n.agents
+	case "agent_id":
+		return n.agentIDs
+	case "session":
+		return n.sessionIDs
+	case "error":
+		return []string{"true", "false"}
+	}
+	return nil
+}
+
+// setFilter is ":filter", replacing the -filter expression; with no
+// expression it shows every item again.
+func (m *Model) setFilter(arg string) (string, error) {
+	e, err := filter.Parse(arg)
+	if err != nil {
+		return "", err
+	}
+	m.SetFilter(e)
+	if e == nil {
+		return "filter off", nil
+	}
+	return fmt.Sprintf("filter %s", e), nil
+}
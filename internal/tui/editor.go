@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"cmp"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorDoneMsg reports that the editor started by openInEditor exited.
+type editorDoneMsg struct{ err error }
+
+// openInEditor opens path in $VISUAL or $EDITOR. ExecProcess leaves the
+// alternate screen and hands the terminal to the editor, then restores both
+// when it exits.
+func (m *Model) openInEditor(path string) tea.Cmd {
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editorDoneMsg{err}
+	})
+}
+
+// openInEditorAt opens path in $VISUAL or $EDITOR at line and column.
+func (m *Model) openInEditorAt(path string, line, col int) tea.Cmd {
+	return tea.ExecProcess(editorLineCommand(path, line, col), func(err error) tea.Msg {
+		return editorDoneMsg{err}
+	})
+}
+
+func editorCommand(path string) *exec.Cmd {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", cmp.Or(editor, "notepad"), path)
+	}
+	// Through the shell so EDITOR="code --wait" works; the path is $1, so
+	// it needs no quoting.
+	return exec.Command("sh", "-c", cmp.Or(editor, "vi")+` "$1"`, "sh", path)
+}
+
+// editorLineCommand is editorCommand at a line and column, in the form the
+// editor takes: --goto for VS Code and its forks, path:line:col for the
+// editors that read it, and +line, which vi, vim, nvim, nano, emacs and
+// micro all take, otherwise. Editors on Windows open the file only.
+func editorLineCommand(path string, line, col int) *exec.Cmd {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"))
+	if runtime.GOOS == "windows" {
+		return editorCommand(path)
+	}
+	args := `+"$2" "$1"`
+	switch editorName(editor) {
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		args = `--goto "$1:$2:$3"`
+	case "subl", "hx", "helix", "zed":
+		args = `"$1:$2:$3"`
+	}
+	return exec.Command("sh", "-c", cmp.Or(editor, "vi")+" "+args, "sh", path, strconv.Itoa(line), strconv.Itoa(max(1, col)))
+}
+
+// editorName is the program an $EDITOR value runs: "code" for
+// "/usr/bin/code --wait".
+func editorName(editor string) string {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return "vi"
+	}
+	return filepath.Base(fields[0])
+}
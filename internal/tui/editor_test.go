@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEditorCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	cmd := editorCommand("my export.md")
+	want := []string{"sh", "-c", `code --wait "$1"`, "sh", "my export.md"}
+	if !slices.Equal(cmd.Args, want) {
+		t.Errorf("args = %q, want %q", cmd.Args, want)
+	}
+
+	t.Setenv("EDITOR", "")
+	if cmd := editorCommand("x.md"); cmd.Args[2] != `vi "$1"` {
+		t.Errorf("fallback = %q", cmd.Args[2])
+	}
+}
+
+func TestCtrlZSuspendsEvenInPrompt(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.openPalette()
+	cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if cmd == nil {
+		t.Fatal("ctrl+z returned no command")
+	}
+	if _, ok := cmd().(tea.SuspendMsg); !ok {
+		t.Error("ctrl+z did not suspend")
+	}
+	if m.prompt == nil {
+		t.Error("suspending closed the prompt")
+	}
+}
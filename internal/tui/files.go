@@ -0,0 +1,264 @@
+package tui
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// filesIcon leads the files-touched panel.
+const filesIcon = "📁"
+
+// heatHalfLife is how long it takes a file's heat to halve without edits.
+const heatHalfLife = 15 * time.Minute
+
+// touchedFile is a file an agent of a session read or edited.
+type touchedFile struct {
+	sessionID string
+	path      string
+	cwd       string
+	reads     int
+	edits     int
+	agents    []string // IDs of the agents that touched it
+	agentName string   // of the last one
+	at        time.Time
+	heat      float64   // edits, each decayed by heatHalfLife since it was made, as of heatAt
+	heatAt    time.Time // of the latest edit
+}
+
+// edited adds an edit made at at to the file's heat. An edit older than
+// the latest one only adds what is left of it by then.
+func (t *touchedFile) edited(at time.Time) {
+	if at.Before(t.heatAt) {
+		t.heat += decay(t.heatAt.Sub(at))
+		return
+	}
+	t.heat = t.heat*decay(at.Sub(t.heatAt)) + 1
+	t.heatAt = at
+}
+
+// heatNow is the file's heat at now: recent edits count for more.
+func (t *touchedFile) heatNow(now time.Time) float64 {
+	return t.heat * decay(max(0, now.Sub(t.heatAt)))
+}
+
+// decay is what is left of one edit after d.
+func decay(d time.Duration) float64 {
+	return math.Exp2(-float64(d) / float64(heatHalfLife))
+}
+
+// FilesView lists the files the enabled agents read or edited, hottest
+// (most and most recently edited) first, with a cursor (J/K) for opening
+// one (e). Files edited more than churn times are flagged.
+type FilesView struct {
+	pane
+	files    []*touchedFile
+	selected int
+	churn    int // edits a file may get before it's flagged; 0 never flags
+	now      func() time.Time
+}
+
+// NewFilesView creates an empty files-touched panel flagging files edited
+// more than churn times.
+func NewFilesView(churn int) *FilesView {
+	return &FilesView{churn: churn, now: time.Now}
+}
+
+// SetChurn sets how many edits a file may get before it's flagged as
+// churning; 0 turns the flag off.
+func (f *FilesView) SetChurn(edits int) {
+	f.churn = edits
+}
+
+// churning reports whether t was edited so often that the agent is likely
+// flip-flopping on it.
+func (f *FilesView) churning(t *touchedFile) bool {
+	return f.churn > 0 && t.edits > f.churn
+}
+
+// AddItem records the file a Read or file-editing tool call names.
+func (f *FilesView) AddItem(item parser.StreamItem) {
+	if item.Type != parser.TypeToolInput || (item.ToolName != "Read" && !edits.IsEditTool(item.ToolName)) {
+		return
+	}
+	var in struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	}
+	json.Unmarshal(item.ToolInput, &in)
+	path := in.FilePath
+	if path == "" {
+		path = in.NotebookPath
+	}
+	if path == "" {
+		return
+	}
+	path = filepath.Clean(path)
+	i := slices.IndexFunc(f.files, func(t *touchedFile) bool { return t.sessionID == item.SessionID && t.path == path })
+	if i < 0 {
+		f.files = append(f.files, &touchedFile{sessionID: item.SessionID, path: path})
+		i = len(f.files) - 1
+	}
+	t := f.files[i]
+	if item.ToolName == "Read" {
+		t.reads++
+	} else {
+		t.edits++
+		t.edited(cmp.Or(item.Timestamp, f.now()))
+	}
+	if !slices.Contains(t.agents, item.AgentID) {
+		t.agents = append(t.agents, item.AgentID)
+	}
+	t.cwd, t.agentName, t.at = item.Cwd, item.AgentName, item.Timestamp
+}
+
+// shown returns the files an enabled agent touched, hottest first; files
+// equally hot (such as those only read) are latest first.
+func (f *FilesView) shown() []*touchedFile {
+	files := slices.DeleteFunc(slices.Clone(f.files), func(t *touchedFile) bool {
+		return !slices.ContainsFunc(t.agents, func(agentID string) bool {
+			return f.enabled(t.sessionID, agentID)
+		})
+	})
+	now := f.now()
+	slices.SortStableFunc(files, func(a, b *touchedFile) int {
+		return cmp.Or(cmp.Compare(b.heatNow(now), a.heatNow(now)), b.at.Compare(a.at))
+	})
+	return files
+}
+
+// Move moves the cursor by delta rows.
+func (f *FilesView) Move(delta int) {
+	f.selected = max(0, min(len(f.shown())-1, f.selected+delta))
+}
+
+// Selected returns the path under the cursor.
+func (f *FilesView) Selected() (string, bool) {
+	files := f.shown()
+	if len(files) == 0 {
+		return "", false
+	}
+	return files[min(f.selected, len(files)-1)].path, true
+}
+
+// Reveal puts the cursor on path, preferring sessionID's entry, and
+// reports false if no enabled agent touched it.
+func (f *FilesView) Reveal(sessionID, path string) bool {
+	files := f.shown()
+	path = filepath.Clean(path)
+	i := slices.IndexFunc(files, func(t *touchedFile) bool { return t.sessionID == sessionID && t.path == path })
+	if i < 0 {
+		i = slices.IndexFunc(files, func(t *touchedFile) bool { return t.path == path })
+	}
+	if i < 0 {
+		return false
+	}
+	f.selected = i
+	return true
+}
+
+// View renders one row per file: a bar of its heat, its path (relative to
+// where the agent was), how often it was edited and read, whether that is
+// churn, and who touched it last, when.
+func (f *FilesView) View() string {
+	innerWidth := max(1, f.width-4)
+	innerHeight := max(1, f.height-2)
+	fit := func(line string) string {
+		return runewidth.FillRight(runewidth.Truncate(line, innerWidth, "…"), innerWidth)
+	}
+	now := f.now()
+
+	files := f.shown()
+	var hottest float64
+	churning := 0
+	for _, t := range files {
+		hottest = max(hottest, t.heatNow(now))
+		if f.churning(t) {
+			churning++
+		}
+	}
+	header := fmt.Sprintf("%s Files touched  %d", filesIcon, len(files))
+	if churning > 0 {
+		header += fmt.Sprintf(" · %s %d churning", loopIcon, churning)
+	}
+	lines := []string{statsHeaderStyle.Render(fit(header)), ""}
+	if len(files) == 0 {
+		lines = append(lines, mutedStyle.Render(fit("  No files read or edited yet.")))
+		return padLines(lines, innerHeight)
+	}
+	selected := min(f.selected, len(files)-1)
+	rows := max(1, innerHeight-len(lines))
+	start := max(0, selected-rows+1)
+	for i, t := range files[start:min(len(files), start+rows)] {
+		var touches []string
+		if t.edits > 0 {
+			touches = append(touches, countLabel(t.edits, "edit"))
+		}
+		if t.reads > 0 {
+			touches = append(touches, countLabel(t.reads, "read"))
+		}
+		touched := strings.Join(touches, ", ")
+		if f.churning(t) {
+			touched += fmt.Sprintf("  %s churn", loopIcon)
+		}
+		line := fit(fmt.Sprintf("  %s %s  %s · %s · %s", heatBar(t.heatNow(now), hottest), recapPath(t.path, t.cwd), touched, cmp.Or(t.agentName, "Main"), recapAgo(now, t.at)))
+		switch {
+		case start+i == selected:
+			line = treeSelectedStyle.Render(line)
+		case f.churning(t):
+			line = loopStyle.Render(line)
+		case t.edits > 0:
+			line = toolInputContentStyle.Render(line)
+		default:
+			line = mutedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return padLines(lines, innerHeight)
+}
+
+// heatBar draws heat scaled to the hottest file's, blank for a file never
+// edited.
+func heatBar(heat, hottest float64) string {
+	if heat <= 0 || hottest <= 0 {
+		return " "
+	}
+	level := int(math.Ceil(heat / hottest * float64(len(sparkLevels))))
+	return string(sparkLevels[max(1, min(level, len(sparkLevels)))-1])
+}
+
+// countLabel is "1 edit" or "3 edits".
+func countLabel(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// toggleFiles shows or hides the files panel. Shown with a path under the
+// stream's path cursor (l), it puts the panel's cursor on that file.
+func (m *Model) toggleFiles() {
+	show := !m.showFiles
+	m.hidePanels()
+	m.showFiles = show
+	if !show {
+		return
+	}
+	link, dirs, ok := m.stream.CurrentLink()
+	if !ok {
+		return
+	}
+	item, _ := m.stream.SelectedItem()
+	if !m.files.Reveal(item.SessionID, linkPath(link.path, dirs)) {
+		m.status = link.path + " wasn't read or edited by a shown agent"
+	}
+}
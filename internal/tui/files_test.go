@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestFilesHeatAndChurn(t *testing.T) {
+	now := time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC)
+	f := NewFilesView(3)
+	f.now = func() time.Time { return now }
+	f.SetSize(100, 12)
+	f.SetEnabledFilters([]EnabledFilter{{"s1", ""}})
+	touch := func(tool, path string, ago time.Duration) {
+		input, _ := json.Marshal(map[string]string{"file_path": path})
+		f.AddItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: tool, ToolInput: input, Cwd: "/work", Timestamp: now.Add(-ago)})
+	}
+	// old.go was edited most, but an hour ago; new.go twice just now.
+	for range 4 {
+		touch("Edit", "/work/old.go", time.Hour)
+	}
+	touch("Edit", "/work/new.go", 2*time.Minute)
+	touch("Write", "/work/new.go", time.Minute)
+	touch("Read", "/work/read.go", 0)
+
+	var order []string
+	for _, file := range f.shown() {
+		order = append(order, file.path)
+	}
+	if got := strings.Join(order, " "); got != "/work/new.go /work/old.go /work/read.go" {
+		t.Fatalf("order = %s, want hottest first and read-only files last", got)
+	}
+
+	// An edit arriving out of order counts for what is left of it.
+	hot := f.shown()[0].heatNow(now)
+	touch("Edit", "/work/new.go", 3*time.Minute)
+	if got := f.shown()[0].heatNow(now); got <= hot || got >= hot+1 {
+		t.Errorf("heat after an older edit = %.2f, was %.2f", got, hot)
+	}
+
+	view := f.View()
+	if !strings.Contains(view, "1 churning") || !strings.Contains(view, "4 edits  ⚠ churn") {
+		t.Errorf("old.go's 4 edits aren't flagged as churn:\n%s", view)
+	}
+	if strings.Contains(view, "3 edits  ⚠") {
+		t.Errorf("new.go's 3 edits are flagged as churn:\n%s", view)
+	}
+	f.SetChurn(0)
+	if strings.Contains(f.View(), "churn") {
+		t.Errorf("churn flagged with the flag off")
+	}
+}
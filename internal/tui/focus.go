@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Pane titles, set into each pane's top border.
+const (
+	treeTitle   = "Sessions"
+	streamTitle = "Stream"
+)
+
+// focusIdle is how long the keyboard must have been idle before
+// focus_follows moves focus on its own, so it never pulls a pane out from
+// under someone typing.
+const focusIdle = 5 * time.Second
+
+// streamKeys and treeKeys only do something in their own pane; with
+// focus_follows on, pressing one in the other pane moves focus there first.
+var (
+	streamKeys = map[string]bool{"J": true, "K": true, "l": true, "f": true, "m": true, "z": true}
+	treeKeys   = map[string]bool{"M": true, "/": true, "s": true, "d": true, "D": true}
+)
+
+// paneBorder is the border of a pane: thick and in the primary color when
+// it has focus, rounded and muted otherwise.
+func paneBorder(base lipgloss.Style, focused bool) lipgloss.Style {
+	if focused {
+		return base.Border(lipgloss.ThickBorder()).BorderForeground(primaryColor)
+	}
+	return base
+}
+
+// titledTopBorder draws border's top edge width cells wide with title set
+// into it near the left corner: a chip in the primary color on the focused
+// pane, muted text on the other.
+func titledTopBorder(border lipgloss.Style, width int, title string, focused bool) string {
+	b := border.GetBorderStyle()
+	line := lipgloss.NewStyle().Foreground(border.GetBorderTopForeground())
+	label := paneTitleStyle.Render(title)
+	if focused {
+		label = focusedPaneTitleStyle.Render(title)
+	}
+	fill := width - 2 - lipgloss.Width(label) - 3 // one dash and a space before the title, one space after
+	if fill < 1 {
+		return line.Render(b.TopLeft + strings.Repeat(b.Top, max(0, width-2)) + b.TopRight)
+	}
+	return line.Render(b.TopLeft+b.Top+" ") +
+		label +
+		line.Render(" "+strings.Repeat(b.Top, fill)+b.TopRight)
+}
+
+// titledPane renders content in border with title set into its top edge.
+func titledPane(border lipgloss.Style, width, height int, title string, focused bool, content string) string {
+	pane := border.BorderTop(false).Width(width).Height(height).Render(content)
+	return titledTopBorder(border, width+2, title, focused) + "\n" + pane
+}
+
+// focusForKey is focus_follows for keys: a key that only works in the
+// other pane moves focus there before it's handled.
+func (m *Model) focusForKey(key string) {
+	if !m.focusFollows {
+		return
+	}
+	switch {
+	case m.focus == FocusTree && streamKeys[key]:
+		m.focus = FocusStream
+	case m.focus == FocusStream && m.showTree && treeKeys[key]:
+		m.focus = FocusTree
+	}
+}
+
+// followActivity is focus_follows for activity: once the keyboard has been
+// idle for focusIdle, focus moves to the tree, on the first of them, while
+// agents wait for +, and otherwise to the stream as items arrive.
+func (m *Model) followActivity(now time.Time) {
+	if !m.focusFollows || m.prompt != nil || m.pager != nil || now.Sub(m.lastKey) < focusIdle {
+		return
+	}
+	if m.showTree && m.tree.Asking() > 0 {
+		if m.focus != FocusTree {
+			m.focus = FocusTree
+			m.tree.SelectAsking()
+		}
+		return
+	}
+	m.focus = FocusStream
+}
+
+// SelectAsking moves the cursor to the first agent waiting for +.
+func (t *TreeView) SelectAsking() {
+	for i, node := range t.nodes {
+		if node.Asking {
+			t.MoveTo(i)
+			return
+		}
+	}
+}
+
+// setFocusFollows is ":focus-follows", switching focus_follows on or off.
+func (m *Model) setFocusFollows(arg string) (string, error) {
+	switch arg {
+	case "on":
+		m.focusFollows = true
+	case "off":
+		m.focusFollows = false
+	case "toggle":
+		m.focusFollows = !m.focusFollows
+	case "":
+	default:
+		return "", fmt.Errorf("want on, off or toggle, got %q", arg)
+	}
+	if m.focusFollows {
+		return "focus-follows on", nil
+	}
+	return "focus-follows off", nil
+}
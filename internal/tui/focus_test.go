@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestPaneTitles(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	titles := func() (top, below string) {
+		lines := strings.Split(m.View(), "\n")
+		for i, line := range lines[:len(lines)-1] {
+			if strings.Contains(line, treeTitle) {
+				return line, lines[i+1]
+			}
+		}
+		t.Fatal("no pane titles")
+		return
+	}
+	top, below := titles()
+	if !strings.Contains(top, streamTitle) || !strings.Contains(top, "┏") || strings.Index(top, "┏") > strings.Index(top, "╭") {
+		t.Fatalf("want a thick titled tree border beside a rounded titled stream one, got %q", top)
+	}
+	if lipgloss.Width(top) != lipgloss.Width(below) {
+		t.Errorf("title line is %d wide, the panes %d", lipgloss.Width(top), lipgloss.Width(below))
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if top, _ := titles(); strings.Index(top, "╭") > strings.Index(top, "┏") {
+		t.Errorf("tab didn't move the thick border to the stream: %q", top)
+	}
+}
+
+func TestFocusFollows(t *testing.T) {
+	m := treeModel(t)
+	m.Update(key("J"))
+	if m.focus != FocusTree {
+		t.Fatal("J moved focus with focus_follows off")
+	}
+
+	m.runPalette("focus-follows on")
+	if !m.focusFollows || m.status != "focus-follows on" {
+		t.Fatalf("focus-follows on: %v, status %q", m.focusFollows, m.status)
+	}
+	m.Update(key("J"))
+	if m.focus != FocusStream {
+		t.Fatal("J in the tree didn't focus the stream")
+	}
+	m.Update(key("s"))
+	if m.focus != FocusTree {
+		t.Fatal("s in the stream didn't focus the tree")
+	}
+
+	// Items arriving while typing leave focus alone...
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Content: "hi"}})
+	if m.focus != FocusTree {
+		t.Fatal("items took focus right after a key")
+	}
+	// ...but take it once the keyboard is idle.
+	m.lastKey = time.Now().Add(-focusIdle)
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Content: "hi"}})
+	if m.focus != FocusStream {
+		t.Fatal("items didn't focus the stream")
+	}
+
+	// An agent waiting for + takes the tree, selected.
+	m.newAgents = "ask"
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a2", AgentType: "reviewer"})
+	if node := m.tree.GetSelectedNode(); m.focus != FocusTree || node == nil || !node.Asking {
+		t.Fatalf("a waiting agent didn't focus the tree on it (focus %v)", m.focus)
+	}
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", Content: "hi"}})
+	if m.focus != FocusTree {
+		t.Fatal("items took focus from an agent waiting for +")
+	}
+}
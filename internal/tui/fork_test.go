@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestForkedSession(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 50})
+	at := time.Date(2025, 6, 1, 15, 4, 0, 0, time.Local)
+	text := func(session, uuid, content string, tokens int64) parser.StreamItem {
+		return parser.StreamItem{Type: parser.TypeText, SessionID: session, AgentName: "Main", UUID: uuid, Content: content, OutputTokens: tokens, Timestamp: at}
+	}
+	// s2 was resumed from s1 as a fork: it starts with s1's lines.
+	m.Update(streamItemsMsg{
+		text("s1", "u1", "first answer", 100),
+		text("s2", "u1", "first answer", 100),
+		text("s2", "u2", "the fork's own answer", 50),
+	})
+
+	var shown []string
+	for _, item := range m.stream.Items() {
+		shown = append(shown, item.SessionID+":"+item.Content)
+	}
+	want := "s1:first answer | s2:forked from session s1: 1 item of shared history shown there | s2:the fork's own answer"
+	if got := strings.Join(shown, " | "); got != want {
+		t.Errorf("stream = %s\nwant %s", got, want)
+	}
+	if m.totalOutputTokens != 150 {
+		t.Errorf("output tokens = %d, copied history counted again", m.totalOutputTokens)
+	}
+
+	marker := m.stream.Items()[1]
+	if out := m.stream.renderItem(marker, 120); !strings.Contains(out, forkIcon+" forked from session s1") {
+		t.Errorf("fork marker renders as %q", out)
+	}
+	var node *TreeNode
+	for i, n := range m.tree.nodes {
+		if n.Type == NodeTypeSession && n.ID == "s2" {
+			node = n
+			m.tree.MoveTo(i)
+		}
+	}
+	if node == nil || node.Forked != "forked from session s1: 1 item of shared history shown there at 15:04" {
+		t.Fatalf("s2 not marked forked: %+v", node)
+	}
+	if help := m.renderHelp(); !strings.HasPrefix(help, forkIcon+" forked from session s1") {
+		t.Errorf("help bar = %q", help)
+	}
+	if !strings.Contains(m.tree.View(), forkIcon) {
+		t.Errorf("tree has no %s badge:\n%s", forkIcon, m.tree.View())
+	}
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// highlightsIcon marks the highlights view (*) in the header.
+const highlightsIcon = "★"
+
+// ToggleHighlights switches between the whole stream and its highlights.
+func (s *StreamView) ToggleHighlights() {
+	s.SetHighlights(!s.highlights)
+}
+
+// SetHighlights shows only the high-signal items, or everything the
+// toggles allow again. While on, the item toggles don't apply.
+func (s *StreamView) SetHighlights(on bool) {
+	s.highlights = on
+	s.updateContent()
+}
+
+// IsHighlights reports whether only highlights are shown.
+func (s *StreamView) IsHighlights() bool {
+	return s.highlights
+}
+
+// noteNarration remembers each agent's latest text, and that it was
+// narration rather than a final response once the agent calls a tool.
+func (s *StreamView) noteNarration(item parser.StreamItem) {
+	agent := item.SessionID + "/" + item.AgentID
+	switch item.Type {
+	case parser.TypeText:
+		s.lastText[agent] = notes.ItemKey(item)
+	case parser.TypeToolInput:
+		if key, ok := s.lastText[agent]; ok {
+			s.narrated[key] = true
+			delete(s.lastText, agent)
+		}
+	}
+}
+
+// isHighlight reports whether item belongs in the highlights: commands
+// the user typed, the main conversation's final responses, errors, file
+// edits, and Task calls with their reports. Prompts themselves never
+// reach the stream.
+func (s *StreamView) isHighlight(item parser.StreamItem) bool {
+	switch item.Type {
+	case parser.TypeCommand:
+		return item.ToolName != ""
+	case parser.TypeText:
+		return item.AgentID == "" && !s.narrated[notes.ItemKey(item)]
+	case parser.TypeAPIError:
+		return true
+	case parser.TypeToolInput:
+		return edits.IsEditTool(item.ToolName) || isTaskTool(item.ToolName)
+	case parser.TypeToolOutput:
+		return item.IsError || parser.IsAgentReport(item)
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestHighlights(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.focus = FocusStream
+	at := time.Date(2025, 6, 1, 15, 4, 0, 0, time.Local)
+	n := 0
+	item := func(it parser.StreamItem) parser.StreamItem {
+		n++
+		it.SessionID, it.Timestamp = "s1", at.Add(time.Duration(n)*time.Second)
+		if it.AgentID == "" {
+			it.AgentName = "Main"
+		}
+		return it
+	}
+	m.Update(streamItemsMsg{
+		item(parser.StreamItem{Type: parser.TypeCommand, ToolName: "/review"}),
+		item(parser.StreamItem{Type: parser.TypeCommand, Content: "local output"}),
+		item(parser.StreamItem{Type: parser.TypeThinking, Content: "pondering"}),
+		item(parser.StreamItem{Type: parser.TypeText, Content: "Let me look around."}),
+		item(parser.StreamItem{Type: parser.TypeToolInput, ToolName: "Read", ToolID: "r1"}),
+		item(parser.StreamItem{Type: parser.TypeToolOutput, ToolID: "r1", Content: "package main"}),
+		item(parser.StreamItem{Type: parser.TypeToolInput, ToolName: "Edit", ToolID: "e1"}),
+		item(parser.StreamItem{Type: parser.TypeToolOutput, ToolID: "e1", Content: "updated"}),
+		item(parser.StreamItem{Type: parser.TypeToolInput, ToolName: "Bash", ToolID: "b1"}),
+		item(parser.StreamItem{Type: parser.TypeToolOutput, ToolID: "b1", Content: "Exit code 1", IsError: true, ExitCode: 1}),
+		item(parser.StreamItem{Type: parser.TypeToolInput, ToolName: "Task", ToolID: "t1"}),
+		item(parser.StreamItem{Type: parser.TypeText, AgentID: "a1", AgentName: "Explore", Content: "subagent notes"}),
+		item(parser.StreamItem{Type: parser.TypeToolOutput, ToolID: "t1", SpawnedAgentID: "a1", Content: "report"}),
+		item(parser.StreamItem{Type: parser.TypeText, Content: "All done."}),
+	})
+	all := len(m.stream.VisibleItems())
+
+	m.Update(key("*"))
+	var got []string
+	for _, it := range m.stream.VisibleItems() {
+		got = append(got, string(it.Type)+":"+it.ToolName+it.Content)
+	}
+	want := []string{
+		"command:/review",
+		"tool_input:Edit",
+		"tool_output:Exit code 1",
+		"tool_input:Task",
+		"tool_output:report",
+		"text:All done.",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("highlights =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if help := m.renderHelp(); !strings.Contains(help, "highlights") {
+		t.Errorf("help bar = %q", help)
+	}
+	if header := m.renderHeader(); !strings.Contains(header, "Highlights") {
+		t.Errorf("header doesn't show highlights: %q", header)
+	}
+
+	// The toggles don't apply, and the view is remembered.
+	m.Update(key("x"))
+	if last := m.stream.VisibleItems(); len(last) != len(want) {
+		t.Errorf("x hid items in highlights: %d shown", len(last))
+	}
+	if !m.viewState().Highlights {
+		t.Error("highlights not saved in the view state")
+	}
+
+	m.Update(key("*"))
+	m.Update(key("x"))
+	if got := len(m.stream.VisibleItems()); got != all {
+		t.Errorf("* again shows %d items, want all %d", got, all)
+	}
+}
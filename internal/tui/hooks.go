@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// hookLabel heads a hook item: the hook, how it went and for how long,
+// as in "🪝 Hook PreToolUse:Bash blocked" or "🪝 Hook Stop failed exit 1".
+func hookLabel(item parser.StreamItem) string {
+	label := hookIcon + " Hook"
+	if item.ToolName != "" {
+		label += " " + item.ToolName
+	}
+	if item.HookStatus != "" {
+		label += " " + item.HookStatus
+	}
+	if item.ExitCode != 0 {
+		label += fmt.Sprintf(" exit %d", item.ExitCode)
+	}
+	if item.DurationMs > 0 {
+		label += " " + formatDuration(item.DurationMs)
+	}
+	return label
+}
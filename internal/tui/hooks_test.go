@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestHookItems(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 50})
+	m.focus = FocusStream
+	now := time.Now()
+	fired := parser.StreamItem{Type: parser.TypeHookOutput, SessionID: "s1", AgentName: "Main", ToolName: "PreToolUse:Bash", ToolID: "t1",
+		Content: "./guard.sh", HookStatus: parser.HookFired, Timestamp: now}
+	blocked := parser.StreamItem{Type: parser.TypeHookOutput, SessionID: "s1", AgentName: "Main", ToolName: "PreToolUse:Bash", ToolID: "t1",
+		Content: "rm -rf is not allowed", IsError: true, HookStatus: parser.HookBlocked, Timestamp: now}
+	failed := parser.StreamItem{Type: parser.TypeHookOutput, SessionID: "s1", AgentName: "Main", ToolName: "Stop",
+		Content: "lint: 2 problems", IsError: true, ExitCode: 1, HookStatus: parser.HookFailed, DurationMs: 1500, Timestamp: now}
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeToolInput, SessionID: "s1", AgentName: "Main", ToolName: "Bash", ToolID: "t1", Content: "rm -rf /", Timestamp: now},
+		fired, blocked, failed,
+	})
+
+	for item, want := range map[*parser.StreamItem][]string{
+		&fired:   {hookIcon + " Hook PreToolUse:Bash fired", "$ ./guard.sh"},
+		&blocked: {hookIcon + " Hook PreToolUse:Bash blocked", "rm -rf is not allowed"},
+		&failed:  {hookIcon + " Hook Stop failed exit 1 (1.5s)", "lint: 2 problems"},
+	} {
+		out := m.stream.renderItem(*item, 120)
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("hook item doesn't show %q:\n%s", w, out)
+			}
+		}
+	}
+
+	if got := len(m.stream.VisibleItems()); got != 4 {
+		t.Fatalf("%d items visible, want the call and 3 hook items", got)
+	}
+	m.Update(key("H"))
+	if m.stream.IsHooksEnabled() || len(m.stream.VisibleItems()) != 1 {
+		t.Errorf("H didn't hide the hook items")
+	}
+	if !m.viewState().HideHooks {
+		t.Errorf("hidden hooks aren't saved in the view state")
+	}
+	m.Update(key("H"))
+	if !m.stream.IsHooksEnabled() {
+		t.Errorf("H didn't show the hook items again")
+	}
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// interruptIcon marks an interrupted turn in the stream and the tree.
+const interruptIcon = "⛔"
+
+// SetInterrupted marks a Main/Agent node as stopped by an interrupt,
+// described by note, or clears the mark with note "".
+func (t *TreeView) SetInterrupted(sessionID, agentID, note string) {
+	for _, session := range t.Root.Children {
+		if session.Type != NodeTypeSession || session.ID != sessionID {
+			continue
+		}
+		for _, child := range session.Children {
+			if (agentID == "" && child.Type == NodeTypeMain) || (agentID != "" && child.Type == NodeTypeAgent && child.ID == agentID) {
+				child.Interrupted = note
+				return
+			}
+		}
+		return
+	}
+}
+
+// trackInterrupt keeps an agent's interrupted mark until it gets going
+// again: it thinks, calls a tool or answers.
+func (m *Model) trackInterrupt(item parser.StreamItem) {
+	switch item.Type {
+	case parser.TypeInterrupt:
+		m.tree.SetInterrupted(item.SessionID, item.AgentID, item.Content+" at "+item.Timestamp.Local().Format("15:04"))
+	case parser.TypeThinking, parser.TypeToolInput, parser.TypeText:
+		m.tree.SetInterrupted(item.SessionID, item.AgentID, "")
+	}
+}
+
+// renderInterrupt is the banner an interrupt leaves in the stream, so it
+// doesn't just stop.
+func renderInterrupt(item parser.StreamItem) string {
+	return apiErrorBannerStyle.Render(interruptIcon + " " + item.Content)
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestInterrupt(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 50})
+	at := time.Date(2025, 6, 1, 15, 4, 0, 0, time.Local)
+	interrupt := parser.StreamItem{Type: parser.TypeInterrupt, SessionID: "s1", AgentName: "Main", ToolName: parser.InterruptToolUse,
+		Content: "interrupted by user during tool use", Timestamp: at}
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeToolInput, SessionID: "s1", AgentName: "Main", ToolName: "Bash", ToolID: "t1", Content: "make deploy", Timestamp: at},
+		interrupt,
+	})
+
+	if out := m.stream.renderItem(interrupt, 120); !strings.Contains(out, interruptIcon+" interrupted by user during tool use") {
+		t.Errorf("stream doesn't show the interrupt:\n%s", out)
+	}
+	node := m.tree.findAgentNode("s1", "")
+	if node == nil || node.Interrupted != "interrupted by user during tool use at 15:04" {
+		t.Fatalf("main node not marked interrupted: %+v", node)
+	}
+	for i := 0; m.tree.GetSelectedNode() != node; i++ {
+		m.tree.MoveTo(i)
+	}
+	if help := m.renderHelp(); !strings.HasPrefix(help, interruptIcon+" interrupted by user during tool use at 15:04 │ ") {
+		t.Errorf("help bar = %q", help)
+	}
+	if !strings.Contains(m.tree.View(), interruptIcon) {
+		t.Errorf("tree has no %s badge:\n%s", interruptIcon, m.tree.View())
+	}
+
+	// Prompted again, the agent gets going and the mark goes.
+	m.Update(streamItemsMsg{{Type: parser.TypeThinking, SessionID: "s1", AgentName: "Main", Content: "deploy later", Timestamp: at.Add(time.Minute)}})
+	if node.Interrupted != "" {
+		t.Errorf("mark kept after the agent resumed: %q", node.Interrupted)
+	}
+}
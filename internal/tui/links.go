@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// maxLinks bounds how many paths of one item l steps through.
+const maxLinks = 100
+
+// pathLink is a path-like string in an item's output.
+type pathLink struct {
+	ref  string // as written: "internal/tui/model.go:120:4"
+	path string
+	line int // 0 if none
+	col  int
+}
+
+var (
+	// linkLocation is a trailing ":line" or ":line:col".
+	linkLocation = regexp.MustCompile(`:(\d+)(?::(\d+))?$`)
+	// linkExtension is a file extension on a path's last element.
+	linkExtension = regexp.MustCompile(`\.[A-Za-z][A-Za-z0-9]{0,9}$`)
+	// linkChars are the characters a linked path may hold.
+	linkChars = regexp.MustCompile(`^[\w./~@+-]+$`)
+)
+
+// pathLinks finds the path-like strings in text: anything rooted (/, ~/,
+// ./, ../), or with a directory and a file extension, optionally followed
+// by :line[:col]. URLs, fractions and "and/or" aren't paths.
+func pathLinks(text string) []pathLink {
+	var links []pathLink
+	seen := make(map[string]bool)
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune(" \t\n\r\"'`()[]{}<>,;|=", r)
+	})
+	for _, field := range fields {
+		ref := strings.TrimRight(field, ".:")
+		if seen[ref] || strings.Contains(ref, "://") {
+			continue
+		}
+		path, line, col := ref, 0, 0
+		if m := linkLocation.FindStringSubmatch(ref); m != nil {
+			path = strings.TrimSuffix(ref, m[0])
+			line, _ = strconv.Atoi(m[1])
+			col, _ = strconv.Atoi(m[2])
+		}
+		rooted := strings.HasPrefix(path, "/") || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+		nested := strings.Contains(path, "/") && linkExtension.MatchString(path)
+		if !linkChars.MatchString(path) || !(rooted && len(path) > 2 || nested) || strings.HasSuffix(path, "/") {
+			continue
+		}
+		seen[ref] = true
+		links = append(links, pathLink{ref: ref, path: path, line: line, col: col})
+		if len(links) == maxLinks {
+			break
+		}
+	}
+	return links
+}
+
+// hasLinks reports whether the item's output is one l steps through.
+func hasLinks(item parser.StreamItem) bool {
+	return item.Type == parser.TypeToolOutput || item.Type == parser.TypeCommand
+}
+
+// selectedLinks returns the paths in the selected item's output.
+func (s *StreamView) selectedLinks() []pathLink {
+	item, ok := s.SelectedItem()
+	if !ok || !hasLinks(item) {
+		return nil
+	}
+	return pathLinks(item.Content)
+}
+
+// NextLink moves the path cursor to the next path in the selected item's
+// output, wrapping around, and reports false if it has none.
+func (s *StreamView) NextLink() bool {
+	links := s.selectedLinks()
+	if len(links) == 0 {
+		return false
+	}
+	item, _ := s.SelectedItem()
+	key := notes.ItemKey(item)
+	if s.linkKey != key {
+		s.linkKey, s.link = key, -1
+	}
+	s.link = (s.link + 1) % len(links)
+	s.updateContent()
+	return true
+}
+
+// CurrentLink returns the path under the path cursor and the directories
+// a relative one may be under, as SelectedProblems does.
+func (s *StreamView) CurrentLink() (pathLink, []string, bool) {
+	item, ok := s.SelectedItem()
+	if !ok || s.linkKey != notes.ItemKey(item) {
+		return pathLink{}, nil, false
+	}
+	links := s.selectedLinks()
+	if s.link < 0 || s.link >= len(links) {
+		return pathLink{}, nil, false
+	}
+	return links[s.link], s.itemDirs(item), true
+}
+
+// itemRefs returns the refs to highlight in item's output, and the one
+// under the path cursor: build error locations, and while the item is
+// selected its paths.
+func (s *StreamView) itemRefs(item parser.StreamItem) (refs []string, current string) {
+	if res, ok := s.problems[item.ToolID]; ok && item.Type == parser.TypeToolOutput {
+		for _, p := range res.Problems {
+			refs = append(refs, p.Ref)
+		}
+	}
+	if sel, ok := s.SelectedItem(); ok && hasLinks(item) && notes.ItemKey(sel) == notes.ItemKey(item) {
+		for _, l := range pathLinks(item.Content) {
+			refs = append(refs, l.ref)
+		}
+		if l, _, ok := s.CurrentLink(); ok {
+			current = l.ref
+		}
+	}
+	return refs, current
+}
+
+// linkPath makes a linked path absolute: ~ expanded, a relative one under
+// the first of dirs that has it, else under the first dir.
+func linkPath(path string, dirs []string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(path) || len(dirs) == 0 {
+		return filepath.Clean(path)
+	}
+	if found, err := resolveProblemPath(path, dirs); err == nil {
+		return found
+	}
+	return filepath.Join(dirs[0], path)
+}
+
+// openSelected is e: it opens the file under the files panel's cursor,
+// else the path under the stream's path cursor at its line, else the
+// selected result's nth build error.
+func (m *Model) openSelected(n int) tea.Cmd {
+	if m.showFiles {
+		path, ok := m.files.Selected()
+		if !ok {
+			m.status = "no files touched yet"
+			return nil
+		}
+		return m.openInEditor(path)
+	}
+	link, dirs, ok := m.stream.CurrentLink()
+	if !ok {
+		return m.openProblem(n)
+	}
+	path := linkPath(link.path, dirs)
+	if _, err := os.Stat(path); err != nil {
+		m.status = link.path + " not found"
+		return nil
+	}
+	if link.line == 0 {
+		return m.openInEditor(path)
+	}
+	return m.openInEditorAt(path, link.line, link.col)
+}
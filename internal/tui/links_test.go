@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestPathLinks(t *testing.T) {
+	text := "M  internal/tui/model.go\nsee ./main.go:12:5, (/etc/hosts) and ~/notes/todo.md.\n" +
+		"https://example.com/a.html and/or 3/4 done; v1.2 main.go dir/ internal/tui/model.go\n"
+	var got []string
+	for _, l := range pathLinks(text) {
+		got = append(got, l.ref)
+	}
+	want := []string{"internal/tui/model.go", "./main.go:12:5", "/etc/hosts", "~/notes/todo.md"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("refs = %q, want %q", got, want)
+	}
+	if l := pathLinks("./main.go:12:5")[0]; l.path != "./main.go" || l.line != 12 || l.col != 5 {
+		t.Errorf("location = %+v", l)
+	}
+}
+
+func TestFilesPanelAndLinks(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("package a\n"), 0o644)
+	}
+
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.tree.AddSession("s1", "home/user/app")
+	m.syncFilters()
+	now := time.Now()
+	call := func(id, tool string, in map[string]string, at time.Time) {
+		input, _ := json.Marshal(in)
+		m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: tool, ToolID: id, ToolInput: input, Cwd: dir, Timestamp: at})
+	}
+	call("r1", "Read", map[string]string{"file_path": filepath.Join(dir, "a.go")}, now.Add(-3*time.Minute))
+	call("e1", "Edit", map[string]string{"file_path": filepath.Join(dir, "b.go")}, now.Add(-2*time.Minute))
+	call("e2", "Edit", map[string]string{"file_path": filepath.Join(dir, "b.go")}, now.Add(-time.Minute))
+	call("g1", "Bash", map[string]string{"command": "git status --short"}, now)
+	m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolName: "Bash", ToolID: "g1", Cwd: dir, Timestamp: now,
+		Content: " M b.go\n?? a.go:3 nothing\n M ./a.go:3\n"})
+
+	press := func(keys string) tea.Cmd {
+		var cmd tea.Cmd
+		for _, r := range keys {
+			cmd = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return cmd
+	}
+	m.focus = FocusStream
+	press("KK")
+	if press("l"); !strings.Contains(m.status, "select an output") {
+		t.Errorf("l on a Bash call: status %q", m.status)
+	}
+	press("J")
+	press("l")
+	link, _, ok := m.stream.CurrentLink()
+	if !ok || link.ref != "./a.go:3" {
+		t.Fatalf("l: link %+v, %v", link, ok)
+	}
+	if cmd := press("e"); cmd == nil {
+		t.Errorf("e on a link returned no command: status %q", m.status)
+	}
+
+	press("F")
+	if !m.showFiles {
+		t.Fatal("F didn't open the files panel")
+	}
+	if path, _ := m.files.Selected(); path != filepath.Join(dir, "a.go") {
+		t.Errorf("F didn't reveal a.go: cursor on %q", path)
+	}
+	view := m.streamPaneView()
+	for _, want := range []string{filesIcon + " Files touched  2", "b.go  2 edits · Main", "a.go  1 read · Main"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("panel lacks %q:\n%s", want, view)
+		}
+	}
+	press("K")
+	if path, _ := m.files.Selected(); path != filepath.Join(dir, "b.go") {
+		t.Errorf("K: cursor on %q", path)
+	}
+	if cmd := press("e"); cmd == nil {
+		t.Error("e in the files panel returned no command")
+	}
+	press("F")
+	if m.showFiles {
+		t.Error("F didn't close the files panel")
+	}
+}
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/longrun"
+	"github.com/phiat/claude-esp/internal/notify"
+)
+
+// longRunIcon marks agents waiting on a tool call that runs too long.
+const longRunIcon = "⏱"
+
+// LongRunView lists the enabled agents' tool calls that have gone without
+// a result for longer than their limit, longest running first.
+type LongRunView struct {
+	pane
+	tracker *longrun.Tracker
+	now     func() time.Time
+}
+
+// NewLongRunView creates a view of tracker's overdue calls.
+func NewLongRunView(tracker *longrun.Tracker) *LongRunView {
+	return &LongRunView{tracker: tracker, now: time.Now}
+}
+
+// overdue returns the enabled agents' overdue calls.
+func (l *LongRunView) overdue(now time.Time) []longrun.Call {
+	return slices.DeleteFunc(l.tracker.Overdue(now), func(c longrun.Call) bool {
+		return !l.enabled(c.SessionID, c.AgentID)
+	})
+}
+
+// View renders one entry per call: the tool, how long it has run against
+// its limit and who is waiting on it, then its input.
+func (l *LongRunView) View() string {
+	innerWidth := max(1, l.width-4)
+	innerHeight := max(1, l.height-2)
+	fit := func(line string) string { return runewidth.Truncate(line, innerWidth, "…") }
+	now := l.now()
+
+	calls := l.overdue(now)
+	lines := []string{statsHeaderStyle.Render(fit(fmt.Sprintf("%s Long-running tool calls  %d", longRunIcon, len(calls))))}
+	for _, c := range calls {
+		who := c.AgentName
+		if who == "" {
+			who = "Main"
+		}
+		lines = append(lines, "",
+			loopStyle.Render(fit(fmt.Sprintf("  %s running %s (limit %s)", c.Tool, now.Sub(c.Started).Round(time.Second), formatWindow(c.Limit)))),
+			fit(fmt.Sprintf("    %s · session %s · since %s", who, truncate(c.SessionID, 12), c.Started.Local().Format("15:04:05"))))
+		if c.Input != "" {
+			lines = append(lines, mutedStyle.Render(fit("    "+c.Input)))
+		}
+	}
+	if len(calls) == 0 {
+		lines = append(lines, "", mutedStyle.Render(fit("  No tool call is running past its limit.")))
+	}
+	return padLines(lines, innerHeight)
+}
+
+// checkLongRunning marks agents waiting on overdue calls in the tree and
+// notifies about calls that just went over their limit.
+func (m *Model) checkLongRunning() {
+	now := time.Now()
+	for _, c := range m.longrun.Check(now) {
+		// Calls left open in replayed history went over long ago: badge
+		// them, but only notify about fresh ones.
+		if now.Sub(c.Overdue()) < loopNotifyWindow {
+			m.notifier.Send(longRunEvent(c, now))
+		}
+	}
+	waiting := make(map[EnabledFilter]string)
+	for _, c := range m.longrun.Overdue(now) {
+		key := EnabledFilter{c.SessionID, c.AgentID}
+		if _, ok := waiting[key]; !ok {
+			waiting[key] = c.Describe(now)
+		}
+	}
+	m.tree.SetLongRunning(waiting)
+}
+
+// longRunEvent turns an overdue call into a "long_running" notification.
+func longRunEvent(c longrun.Call, now time.Time) notify.Event {
+	who := c.AgentName
+	if who == "" {
+		who = "Main"
+	}
+	return notify.Event{
+		Kind:      "long_running",
+		Title:     fmt.Sprintf("claude-esp: %s is waiting on %s", who, c.Tool),
+		Message:   fmt.Sprintf("%s (limit %s) in session %s", c.Describe(now), formatWindow(c.Limit), truncate(c.SessionID, 12)),
+		SessionID: c.SessionID,
+		AgentID:   c.AgentID,
+		Time:      now,
+	}
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestLongRunning(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.tree.AddSession("s1", "home/user/app")
+	m.tree.AddAgent("s1", "a1", "")
+	m.syncFilters()
+
+	started := time.Now().Add(-7 * time.Minute)
+	m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: "b1", Content: "npm run dev", Timestamp: started})
+	m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", AgentID: "a1", ToolName: "Bash", ToolID: "b2", Content: "ls", Timestamp: started})
+	m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", AgentID: "a1", ToolID: "b2", Timestamp: started})
+	m.checkLongRunning()
+
+	main, agent := m.tree.Root.Children[0].Children[0], m.tree.Root.Children[0].Children[1]
+	if main.LongRunning != "Bash running 7m: npm run dev" || agent.LongRunning != "" {
+		t.Errorf("badges: main %q, agent %q", main.LongRunning, agent.LongRunning)
+	}
+	if !strings.Contains(m.tree.View(), longRunIcon) {
+		t.Error("tree lacks the long-running badge")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	if !m.showLongRun {
+		t.Fatal("L didn't open the long-running list")
+	}
+	view := m.streamPaneView()
+	for _, want := range []string{"Long-running tool calls  1", "Bash running 7m0s (limit 5m)", "npm run dev"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("list lacks %q:\n%s", want, view)
+		}
+	}
+
+	m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolID: "b1", Timestamp: time.Now()})
+	m.checkLongRunning()
+	if main.LongRunning != "" || !strings.Contains(m.streamPaneView(), "No tool call is running past its limit.") {
+		t.Errorf("result didn't clear the call: badge %q", main.LongRunning)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m.showLongRun || !m.showRecap {
+		t.Errorf("r: long-running %v, recap %v", m.showLongRun, m.showRecap)
+	}
+}
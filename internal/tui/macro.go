@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+// recordKey starts and stops recording a macro. Once recording stops, the
+// next key pressed is the one the macro is bound to; esc discards it.
+const recordKey = "Q"
+
+// keyTypes maps bubbletea's key names back to the keys, for replaying
+// macros. Special keys have small negative types and control keys small
+// positive ones; -100 is well past the last special key.
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{"space": tea.KeySpace}
+	for kt := tea.KeyType(-100); kt <= tea.KeyDelete; kt++ {
+		if kt == tea.KeyRunes || kt == tea.KeySpace {
+			continue
+		}
+		if name := (tea.Key{Type: kt}).String(); name != "" {
+			types[name] = kt
+		}
+	}
+	return types
+}()
+
+// keyName is how a key is stored in a macro: as bubbletea names it, but
+// with space spelled out.
+func keyName(msg tea.KeyMsg) string {
+	if msg.Type == tea.KeySpace {
+		return "space"
+	}
+	return msg.String()
+}
+
+// parseKey turns a stored key name back into a key press. Anything that
+// isn't a key name is typed as text.
+func parseKey(name string) tea.KeyMsg {
+	if kt, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: kt}
+	}
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		msg := parseKey(rest)
+		msg.Alt = true
+		return msg
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// macroKey records, binds and runs macros. It reports whether it took
+// msg; keys it only records still go on to their own action.
+func (m *Model) macroKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	key := keyName(msg)
+	switch {
+	case m.replaying:
+		return nil, false
+	case m.unbound != nil:
+		keys := m.unbound
+		m.unbound = nil
+		if key == "esc" || key == recordKey {
+			m.status = "macro discarded"
+			return nil, true
+		}
+		m.bindMacro(key, keys)
+		return nil, true
+	case key == recordKey && m.prompt == nil:
+		if !m.recording {
+			m.recording, m.recorded = true, nil
+			return nil, true
+		}
+		m.recording = false
+		if len(m.recorded) == 0 {
+			m.status = "nothing recorded"
+			return nil, true
+		}
+		m.unbound, m.recorded = m.recorded, nil
+		m.status = fmt.Sprintf("press the key to bind these %d keys to (esc discards)", len(m.unbound))
+		return nil, true
+	case m.recording:
+		if msg.Paste {
+			m.recorded = append(m.recorded, string(msg.Runes))
+		} else {
+			m.recorded = append(m.recorded, key)
+		}
+		return nil, false
+	}
+	if keys, ok := m.macros[key]; ok && m.prompt == nil {
+		return m.runMacro(key, keys), true
+	}
+	return nil, false
+}
+
+// bindMacro binds keys to key for this run and appends it to the config
+// file, where it replaces any earlier macro for key.
+func (m *Model) bindMacro(key string, keys []string) {
+	m.macros[key] = keys
+	if m.configPath == "" {
+		m.status = fmt.Sprintf("%s runs the macro (not saved: no config file)", key)
+		return
+	}
+	if err := config.AppendMacro(m.configPath, config.Macro{Key: key, Keys: keys}); err != nil {
+		m.status = fmt.Sprintf("%s runs the macro (not saved: %v)", key, err)
+		return
+	}
+	m.status = fmt.Sprintf("%s runs the macro, saved to %s", key, m.configPath)
+}
+
+// runMacro replays keys as if they were typed. Macros don't run other
+// macros, and a key that quits ends the replay.
+func (m *Model) runMacro(key string, keys []string) tea.Cmd {
+	m.replaying = true
+	defer func() { m.replaying = false }()
+	var cmds []tea.Cmd
+	for _, name := range keys {
+		cmds = append(cmds, m.handleKey(parseKey(name)))
+		if m.quitting {
+			break
+		}
+	}
+	if m.status == "" {
+		m.status = fmt.Sprintf("macro %s (%d keys)", key, len(keys))
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+func TestParseKey(t *testing.T) {
+	for _, name := range []string{"A", "ctrl+d", "enter", "esc", "f2", "alt+x", "shift+tab", "api"} {
+		if got := parseKey(name).String(); got != name {
+			t.Errorf("parseKey(%q) = %q", name, got)
+		}
+	}
+	if msg := parseKey("space"); msg.Type != tea.KeySpace || keyName(msg) != "space" {
+		t.Errorf("space = %+v", msg)
+	}
+}
+
+func TestRecordMacro(t *testing.T) {
+	m := treeModel(t)
+	m.configPath = filepath.Join(t.TempDir(), "config.toml")
+	m.focus = FocusStream
+	for _, k := range []string{"Q", "t", "O", "Q"} {
+		m.Update(key(k))
+	}
+	if m.stream.IsThinkingEnabled() || !m.stream.IsStderrOnly() {
+		t.Fatal("recorded keys didn't take effect while recording")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyF2})
+
+	cfg, err := config.Load(m.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MacroKeys()["f2"]; len(got) != 2 || got[0] != "t" || got[1] != "O" {
+		t.Fatalf("saved macro = %q", got)
+	}
+
+	m.stream.SetToggles(true, true, true, true, true)
+	m.stream.SetStderrOnly(false)
+	m.Update(tea.KeyMsg{Type: tea.KeyF2})
+	if m.stream.IsThinkingEnabled() || !m.stream.IsStderrOnly() {
+		t.Error("f2 didn't replay the macro")
+	}
+}
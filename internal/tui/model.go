@@ -1,14 +1,37 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/phiat/claude-esp/internal/clipboard"
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/crash"
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/fork"
+	"github.com/phiat/claude-esp/internal/handoff"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/history"
+	"github.com/phiat/claude-esp/internal/longrun"
+	"github.com/phiat/claude-esp/internal/loops"
+	"github.com/phiat/claude-esp/internal/modelswitch"
+	"github.com/phiat/claude-esp/internal/notes"
+	"github.com/phiat/claude-esp/internal/notify"
+	"github.com/phiat/claude-esp/internal/osc"
 	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/share"
+	"github.com/phiat/claude-esp/internal/sink"
+	"github.com/phiat/claude-esp/internal/summarize"
+	"github.com/phiat/claude-esp/internal/uistate"
+	"github.com/phiat/claude-esp/internal/update"
 	"github.com/phiat/claude-esp/internal/watcher"
 )
 
@@ -24,16 +47,45 @@ const (
 type Model struct {
 	tree               *TreeView
 	stream             *StreamView
+	timeline           *TimelineView
+	stats              *StatsView
+	recap              *RecapView
+	longRun            *LongRunView
+	tests              *TestsView
+	files              *FilesView
 	watcher            *watcher.Watcher
 	focus              Focus
 	showTree           bool
+	showTimeline       bool // stream pane shows the timeline instead of items
+	showStats          bool // stream pane shows per-agent stats instead of items
+	showRecap          bool // stream pane shows the recap of the last minutes instead of items
+	showLongRun        bool // stream pane lists long-running tool calls instead of items
+	showTests          bool // stream pane shows each session's latest test runs instead of items
+	showFiles          bool // stream pane lists the files agents read or edited instead of items
 	width              int
 	height             int
 	treeWidth          int
-	sessionID          string
+	sessionIDs         []string // -s; empty = all active sessions
+	sessionFile        string   // claude-esp open: a transcript at any path
+	attach             string   // -attach: another instance's stream URL
 	skipHistory        bool
 	pollInterval       time.Duration
 	activeWindow       time.Duration
+	activityThreshold  time.Duration // how recent a write shows a node as active
+	lowPower           bool          // see power.go
+	showQueues         bool          // debug footer with the watcher's queue counters; see queues.go
+	ascii              bool          // draw symbols in ASCII; see ascii.go
+	focusFollows       bool          // [view] focus_follows; see focus.go
+	lastKey            time.Time     // when the last key was pressed
+	at, atSince        time.Time     // -at's moment, and when it was set; see at.go
+	gotoID             string        // -goto's item, until it is found or live items arrive; see at.go
+	drains             drainStats    // batches taken off the watcher's Items
+	blurred            bool          // terminal reported focus loss
+	ticking            bool          // a tick is scheduled
+	count              int           // pending count prefix ("5j"); see motion.go
+	pendingG           bool          // first g of gg typed
+	wait               *watcherWait  // the outstanding waitWatcher, if any
+	lastActivityCheck  time.Time
 	maxSessions        int
 	collapseAfter      time.Duration // 0 = disabled
 	err                error
@@ -42,24 +94,234 @@ type Model struct {
 	totalOutputTokens  int64
 	totalCacheCreation int64
 	totalCacheRead     int64
+	spans              map[string]*sessionSpan // items' time span per session; see sessioninfo.go
+	latestSession      string                  // session of the latest item
+	budget             *budgetTracker
+	notifier           *notify.Notifier
+	clipboard          *clipboard.Clipboard // y and :goto copy with it; [terminal] clipboard
+	notifyNewSessions  bool                 // [notify] new_sessions
+	loops              *loops.Detector
+	longrun            *longrun.Tracker
+	apiErrors          *apiErrors
+	switches           *modelswitch.Detector
+	forks              *fork.Detector
+	notes              *notes.Store
+	history            *history.Store // what was entered at prompts; see complete.go
+	stateDir           string         // where views are saved; "" = not saved
+	uiState            *uistate.Store // saved view; see state.go
+	saved              uistate.State  // view restored at startup
+	pipe               *sink.Pipe     // --pipe command; nil = off
+	sinks              []sink.Publisher
+	share              *share.Server          // --share viewers; nil = off
+	prompt             *prompt                // open text prompt; receives all keys
+	afterPrompt        tea.Cmd                // work a prompt's submit started, run once it closes
+	pager              *pager                 // open file or item viewer; replaces the stream pane
+	undoStack          []undoEntry            // see undo.go
+	ignore             *watcher.ProjectFilter // ignore_projects
+	ignored            []string               // sessions hidden for good (D)
+	presets            []config.Preset        // view presets; see preset.go
+	preset             int                    // last preset applied, from 1; 0 = none
+	newAgents          string                 // [view] new_agents; see newagents.go
+	status             string                 // one-shot message shown in the help bar
+	version            string                 // running version to check for updates; "" = don't
+	newRelease         string                 // newer release found by the update check
+	lastBatch          time.Time              // when the last batch of items arrived; see storm.go
+	fastForward        int                    // size of the last big batch; 0 = keeping up
+	waitPaused         bool                   // low power: waiting out minRenderInterval before the next wait
+	beats              *heartbeat.Tracker     // session states for the terminal title; nil = [terminal] title off
+	title              string                 // terminal title last set; see title.go
+	configPath         string                 // config file reloaded while running; "" = no hot reload
+	configStamp        configStamp            // version of configPath last loaded
+	configChecked      time.Time              // last check of configPath
+	fileConfig         *config.Config         // config as last read from configPath, before flags
+	configErr          string                 // why the changed config file can't be applied; see reload.go
+	watchWarning       string                 // latest watcher warning, kept in the help bar
+
+	// Macros; see macro.go.
+	macros    map[string][]string // key → keys it replays, from [[macros]]
+	recording bool                // Q pressed: keys are being recorded
+	recorded  []string            // keys recorded so far
+	unbound   []string            // recorded macro waiting for its key
+	replaying bool                // a macro is running
+
+	// Restart; see restart.go.
+	titles      map[string]parser.StreamItem // latest session-title item per session
+	handoff     *handoff.State               // stream handed over by the process this one replaced
+	restartPath string                       // handoff written by restart; main execs the new binary
+
+	// Summaries; see summarize.go.
+	summarizer      *summarize.Summarizer // from [summarize]; nil = none configured
+	summarizeWindow time.Duration         // how much of a session S sends
+	summarizing     bool                  // a summary is on its way
 }
 
 // NewModel creates a new TUI model. If collapseAfter > 0, sessions inactive
 // for that duration will auto-collapse in the tree (and be hidden from the
-// stream). See tree.Toggle / Solo for the interactive counterpart.
-func NewModel(sessionID string, skipHistory bool, pollInterval time.Duration, activeWindow time.Duration, maxSessions int, collapseAfter time.Duration) *Model {
+// stream). See tree.Toggle / Solo for the interactive counterpart. cfg may
+// be nil, meaning all config defaults.
+func NewModel(sessionIDs []string, skipHistory bool, pollInterval time.Duration, activeWindow time.Duration, maxSessions int, collapseAfter time.Duration, cfg *config.Config) *Model {
+	if cfg == nil {
+		cfg = config.Default()
+	}
+	prices, _ := cfg.PricingTable()
+	// Notes, prompt history and the saved view live in the state
+	// directory; without a home dir they're disabled.
+	var noteStore *notes.Store
+	var prompts *history.Store
+	var stateDir string
+	if dir, err := config.StateDir(); err == nil {
+		noteStore = notes.New(filepath.Join(dir, "notes"))
+		prompts = history.New(filepath.Join(dir, history.FileName))
+		stateDir = filepath.Join(dir, "state")
+	}
+	stream := NewStreamView()
+	stream.SetNotes(noteStore)
+	stream.gutter = cfg.View.SessionGutter
+	stream.typeGutter = cfg.View.TypeGutter
+	notifier := notify.New(cfg.Notify.Command)
+	if cfg.Terminal.Notify {
+		notifier.SetTerminal(Terminal, osc.EnvFromOS())
+	}
+	var beats *heartbeat.Tracker
+	if cfg.Terminal.Title {
+		beats = heartbeat.NewTracker()
+	}
+	longRuns := longrun.NewTracker(cfg.LongRunLimits())
 	return &Model{
-		tree:          NewTreeView(),
-		stream:        NewStreamView(),
-		focus:         FocusStream,
-		showTree:      true,
-		treeWidth:     30,
-		sessionID:     sessionID,
-		skipHistory:   skipHistory,
-		pollInterval:  pollInterval,
-		activeWindow:  activeWindow,
-		maxSessions:   maxSessions,
-		collapseAfter: collapseAfter,
+		tree:              NewTreeView(),
+		stream:            stream,
+		timeline:          NewTimelineView(),
+		stats:             NewStatsView(prices),
+		recap:             NewRecapView(),
+		longRun:           NewLongRunView(longRuns),
+		tests:             NewTestsView(),
+		files:             NewFilesView(cfg.ChurnEdits()),
+		longrun:           longRuns,
+		apiErrors:         newAPIErrors(),
+		switches:          modelswitch.New(),
+		forks:             fork.New(),
+		focus:             FocusStream,
+		showTree:          true,
+		treeWidth:         30,
+		sessionIDs:        sessionIDs,
+		skipHistory:       skipHistory,
+		pollInterval:      pollInterval,
+		activeWindow:      activeWindow,
+		activityThreshold: cfg.ActivityThreshold(),
+		lowPower:          cfg.Watch.LowPower,
+		maxSessions:       maxSessions,
+		collapseAfter:     collapseAfter,
+		budget:            newBudgetTracker(cfg),
+		notifier:          notifier,
+		clipboard:         clipboard.New(cfg.Terminal.Clipboard, clipboard.EnvFromOS(), Terminal),
+		notifyNewSessions: cfg.Notify.NewSessions,
+		loops:             loops.NewDetector(cfg.LoopThresholds()),
+		ignore:            cfg.ProjectFilter(),
+		presets:           cfg.Presets(),
+		newAgents:         cfg.View.NewAgents,
+		focusFollows:      cfg.View.FocusFollows,
+		notes:             noteStore,
+		history:           prompts,
+		stateDir:          stateDir,
+		beats:             beats,
+		titles:            make(map[string]parser.StreamItem),
+		spans:             make(map[string]*sessionSpan),
+		macros:            cfg.MacroKeys(),
+		summarizer:        cfg.Summarizer(),
+		summarizeWindow:   cfg.SummarizeWindow(),
+	}
+}
+
+// SetSessionFile shows the session transcript at path, which may live
+// anywhere, instead of watching ~/.claude/projects (claude-esp open).
+func (m *Model) SetSessionFile(path string) {
+	m.sessionFile = path
+}
+
+// SetFilter limits the stream, and everything taken from it (pipe,
+// export), to items matching e.
+func (m *Model) SetFilter(e *filter.Expr) {
+	m.stream.SetFilter(e)
+}
+
+// SetFullOutput starts with every item shown untruncated, for reading
+// through a session rather than watching it live.
+func (m *Model) SetFullOutput(on bool) {
+	m.stream.SetFullOutput(on)
+}
+
+// SetAttach shows the stream another claude-esp serves at url (its -http
+// address) instead of reading the transcripts again.
+func (m *Model) SetAttach(url string) {
+	m.attach = url
+}
+
+// CheckForUpdates looks for a release newer than version in the
+// background at startup (asking GitHub at most once a day) and shows it in
+// the help bar.
+func (m *Model) CheckForUpdates(version string) {
+	m.version = version
+}
+
+// SetPipe forwards every item that passes the stream filters, as plain
+// text, to p. The caller owns p's lifecycle (Start/Stop).
+func (m *Model) SetPipe(p *sink.Pipe) {
+	m.pipe = p
+}
+
+// SetShare mirrors every rendered frame to read-only viewers. The caller
+// owns s and closes it after the program exits.
+func (m *Model) SetShare(s *share.Server) {
+	m.share = s
+}
+
+// AddSink publishes every new item (unfiltered) to p. The caller owns p
+// and closes it after the program exits.
+func (m *Model) AddSink(p sink.Publisher) {
+	m.sinks = append(m.sinks, p)
+}
+
+// newSessionFlash is how long a session discovered while running shows
+// its blinking "new" badge.
+const newSessionFlash = 10 * time.Second
+
+// newSessionEvent is the "session" notification for a discovered session.
+func newSessionEvent(msg newSessionMsg) notify.Event {
+	project := msg.ProjectPath
+	if project == "" {
+		project = "unknown project"
+	}
+	return notify.Event{
+		Kind:      "session",
+		Title:     "claude-esp: new session in " + filepath.Base(project),
+		Message:   fmt.Sprintf("Session %s started in %s", truncate(msg.SessionID, 12), project),
+		SessionID: msg.SessionID,
+	}
+}
+
+// loopNotifyWindow is how recent a loop, long-run or budget alert must be
+// to notify; older ones come from replayed history.
+const loopNotifyWindow = 10 * time.Minute
+
+// loopEvent turns a loop alert into a "loop" notification.
+func loopEvent(a loops.Alert) notify.Event {
+	who := a.AgentName
+	if who == "" {
+		who = "Main"
+	}
+	return notify.Event{
+		Kind:      "loop",
+		Title:     fmt.Sprintf("claude-esp: %s may be looping", who),
+		Message:   fmt.Sprintf("%s (%s) in session %s", a.Detail, a.Kind, truncate(a.SessionID, 12)),
+		SessionID: a.SessionID,
+		AgentID:   a.AgentID,
+		Time:      a.Time,
+	}
+}
+
+func (m *Model) publish(item parser.StreamItem) {
+	for _, s := range m.sinks {
+		s.Publish(item)
 	}
 }
 
@@ -70,54 +332,136 @@ type (
 	newAgentMsg          watcher.NewAgentMsg
 	newSessionMsg        watcher.NewSessionMsg
 	newBackgroundTaskMsg watcher.NewBackgroundTaskMsg
+	todosMsg             watcher.TodosMsg
 	errMsg               error
+	watcherWarningMsg    string
+	fileErrorMsg         watcher.FileErrorMsg
+	newReleaseMsg        string
 	watcherReadyMsg      struct{}
 )
 
 // Init initializes the model
 func (m *Model) Init() tea.Cmd {
+	m.restoreView()
+	m.ticking = true
 	return tea.Batch(
 		m.initWatcher(),
 		m.tick(),
+		m.checkUpdate(),
 	)
 }
 
+func (m *Model) checkUpdate() tea.Cmd {
+	if m.version == "" || m.stateDir == "" {
+		return nil
+	}
+	current, dir := m.version, filepath.Dir(m.stateDir)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		latest, err := update.NewClient().CheckDaily(ctx, dir, current)
+		if err != nil {
+			slog.Info("update check failed", "err", err)
+			return nil
+		}
+		if latest == "" {
+			return nil
+		}
+		slog.Info("update available", "version", latest)
+		return newReleaseMsg(latest)
+	}
+}
+
 func (m *Model) initWatcher() tea.Cmd {
 	return func() tea.Msg {
-		w, err := watcher.New(m.sessionID, m.pollInterval, m.activeWindow, m.maxSessions)
+		var w *watcher.Watcher
+		var err error
+		if m.sessionFile != "" {
+			w, err = watcher.OpenFile(m.sessionFile, m.effectivePollInterval())
+		} else if m.attach != "" {
+			w, err = watcher.Attach(m.attach)
+		} else {
+			w, err = watcher.New(m.sessionIDs, m.effectivePollInterval(), m.activeWindow, m.maxSessions)
+		}
 		if err != nil {
+			slog.Error("cannot start watcher", "err", err)
 			return errMsg(err)
 		}
 		m.watcher = w
 
+		// Ignores only apply to auto-discovery; listed sessions always show.
+		if len(m.sessionIDs) == 0 && m.sessionFile == "" {
+			w.SetProjectFilter(m.ignore)
+			for _, id := range m.ignored {
+				w.RemoveSession(id)
+			}
+		}
+
 		// Configure skip history before starting
 		if m.skipHistory {
 			w.SetSkipHistory(true)
 		}
+		// -at and -goto may point anywhere in the history.
+		if !m.at.IsZero() || m.gotoID != "" {
+			w.SetFullHistory(true)
+		}
+		if m.handoff != nil {
+			w.ResumeAt(m.handoff.Positions)
+		}
 
 		// Add all sessions and their agents to the tree
 		for _, session := range w.GetSessions() {
 			m.tree.AddSession(session.ID, session.ProjectPath)
+			m.tree.SetSessionHost(session.ID, session.Host)
+			m.tree.SetSessionNote(session.ID, m.notes.Session(session.ID) != "")
+			m.restoreSession(session.ID)
 			for agentID := range session.Subagents {
 				agentType := session.SubagentTypes[agentID]
 				m.tree.AddAgent(session.ID, agentID, agentType)
+				m.restoreAgent(session.ID, agentID)
 			}
 		}
 
 		// Start watching
+		slog.Info("watching", "sessions", len(w.GetSessions()), "fsnotify", w.UsingFsnotify(), "file", m.sessionFile, "attach", m.attach)
 		w.Start()
 		return watcherReadyMsg{}
 	}
 }
 
+// guard runs cmd, and the commands of a batch it returns, under
+// crash.Recover. Bubble Tea recovers command panics itself, but without
+// leaving a report.
+func guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer crash.Recover()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i := range batch {
+				batch[i] = guard(batch[i])
+			}
+		}
+		return msg
+	}
+}
+
 func (m *Model) tick() tea.Cmd {
-	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
+	interval := normalTick
+	if m.lowPower {
+		interval = lowPowerTick
+	}
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
 // Update handles messages
-func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m *Model) Update(msg tea.Msg) (_ tea.Model, cmd tea.Cmd) {
+	defer crash.Recover()
+	defer func() { cmd = guard(cmd) }()
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -126,88 +470,270 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		m.saveView()
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.prompt != nil {
+			m.prompt.SetWidth(m.width)
+		}
 		m.updateLayout()
 
 	case tickMsg:
-		cmds = append(cmds, m.tick())
-		cmds = append(cmds, m.pollWatcher())
-		m.updateActivityStatus()
-
-	case streamItemMsg:
-		item := parser.StreamItem(msg)
-		// Session-title items update the tree label, not the stream.
-		if item.Type == parser.TypeSessionTitle {
-			m.tree.SetSessionTitle(item.SessionID, item.Content)
-			break
-		}
-		// Accumulate token usage (includes history — shows total session cost)
-		if item.InputTokens > 0 {
-			m.totalInputTokens += item.InputTokens
-		}
-		if item.OutputTokens > 0 {
-			m.totalOutputTokens += item.OutputTokens
+		m.ticking = false
+		m.catchUp()
+		if m.pager != nil {
+			m.pager.Refresh()
 		}
-		if item.CacheCreationTokens > 0 {
-			m.totalCacheCreation += item.CacheCreationTokens
+		if !m.lowPower {
+			cmds = append(cmds, m.pollWatcher())
+			m.updateActivityStatus()
+		} else if time.Since(m.lastActivityCheck) >= lowPowerActivityRefresh {
+			m.updateActivityStatus()
 		}
-		if item.CacheReadTokens > 0 {
-			m.totalCacheRead += item.CacheReadTokens
+		m.checkLongRunning()
+		m.wakeSnoozed()
+		cmds = append(cmds, m.checkConfig())
+
+	case configReloadMsg:
+		m.reloadConfig(msg)
+
+	case summaryMsg:
+		m.showSummary(msg)
+
+	case copiedMsg:
+		m.status = msg.status
+
+	case presetKeyMsg:
+		m.firePresetKey(msg)
+
+	case watcherMsg:
+		// A cancelled wait's message was handled by cancelWait.
+		if msg.from == m.wait {
+			m.wait = nil
+			_, cmd := m.Update(msg.msg)
+			cmds = append(cmds, cmd)
 		}
-		// Per-agent context size: latest snapshot, not a sum. The prompt
-		// size for a turn is input + cache_creation + cache_read; output
-		// tokens don't fill the context window.
-		if item.Model != "" {
-			ctx := item.InputTokens + item.CacheCreationTokens + item.CacheReadTokens
-			if ctx > 0 {
-				m.tree.UpdateContext(item.SessionID, item.AgentID, ctx, parser.ContextWindowFor(item.Model))
-			}
+
+	case tea.ResumeMsg:
+		// The terminal may have been resized while we were stopped.
+		cmds = append(cmds, tea.WindowSize())
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("editor: %v", msg.err)
 		}
-		m.stream.AddItem(item)
-		m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+		cmds = append(cmds, tea.WindowSize())
+
+	case tea.FocusMsg:
+		m.blurred = false
+
+	case tea.BlurMsg:
+		m.blurred = true
+
+	case streamItemMsg:
+		m.addItem(parser.StreamItem(msg))
+		m.seekAt([]parser.StreamItem{parser.StreamItem(msg)})
+
+	case streamItemsMsg:
+		m.drains.add(msg)
+		m.addItems(msg)
+		m.seekAt(msg)
+		m.followActivity(time.Now())
+
+	case rearmWaitMsg:
+		m.waitPaused = false
 
 	case newAgentMsg:
-		m.tree.AddAgent(msg.SessionID, msg.AgentID, msg.AgentType)
-		m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+		if m.tree.AddAgent(msg.SessionID, msg.AgentID, msg.AgentType) {
+			m.applyNewAgentPolicy(msg.SessionID, msg.AgentID)
+			m.followActivity(time.Now())
+		}
+		m.restoreAgent(msg.SessionID, msg.AgentID)
+		m.syncFilters()
 
 	case newSessionMsg:
 		m.tree.AddSession(msg.SessionID, msg.ProjectPath)
-		m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+		m.tree.SetSessionHost(msg.SessionID, msg.Host)
+		m.tree.SetSessionNote(msg.SessionID, m.notes.Session(msg.SessionID) != "")
+		m.restoreSession(msg.SessionID)
+		m.tree.Flash(msg.SessionID, time.Now().Add(newSessionFlash))
+		if m.notifyNewSessions {
+			m.notifier.Send(newSessionEvent(msg))
+		}
+		m.syncFilters()
 
 	case newBackgroundTaskMsg:
 		m.tree.AddBackgroundTask(msg.SessionID, msg.ParentAgentID, msg.ToolID, msg.ToolName, msg.OutputPath, msg.IsComplete)
 
+	case todosMsg:
+		m.tree.SetTodos(msg.SessionID, msg.AgentID, msg.Todos)
+		m.recap.SetTodos(msg.SessionID, msg.AgentID, msg.Todos)
+
 	case errMsg:
 		m.err = msg
 
+	case watcherWarningMsg:
+		m.watchWarning = string(msg)
+
+	case fileErrorMsg:
+		m.tree.SetReadError(msg.SessionID, msg.AgentID, watcher.FileErrorMsg(msg).Reason())
+
 	case watcherReadyMsg:
+		m.resumeHandoff()
 		// Initial sync of enabled filters
-		m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+		m.syncFilters()
+
+	case newReleaseMsg:
+		m.newRelease = string(msg)
 	}
 
+	cmds = append(cmds, m.updateTitle(), m.schedule())
 	return m, tea.Batch(cmds...)
 }
 
+// addItem takes in one watcher item: usage, budget and loop tracking, the
+// tree, and every pane and sink.
+func (m *Model) addItem(item parser.StreamItem) {
+	// A forked session's copy of another's history isn't shown twice; a
+	// marker goes where it takes its own way.
+	if item.Type == parser.TypeFork {
+		m.tree.SetForked(item.SessionID, item.Content+" at "+item.Timestamp.Local().Format("15:04"))
+	} else {
+		shown := m.forks.Add(item)
+		if len(shown) == 0 {
+			return
+		}
+		for _, marker := range shown[:len(shown)-1] {
+			m.addItem(marker)
+		}
+	}
+	// Session-title items update the tree label, not the stream.
+	if item.Type == parser.TypeSessionTitle {
+		m.tree.SetSessionTitle(item.SessionID, item.Content)
+		m.titles[item.SessionID] = item
+		m.publish(item)
+		return
+	}
+	// A model or thinking change goes in the stream before the item that
+	// shows it; thinking settings themselves are only tracked. An attached
+	// TUI gets the changes from the instance it attaches to.
+	if item.Type == parser.TypeModelSwitch {
+		m.tree.SetSwitched(item.SessionID, item.AgentID, item.Content+" at "+item.Timestamp.Local().Format("15:04"))
+	} else if sw, ok := m.switches.Add(item); ok && m.attach == "" {
+		m.addItem(sw)
+	}
+	if item.Type == parser.TypeThinkingConfig {
+		m.publish(item)
+		return
+	}
+	// Progress updates the running call it's about in place.
+	if item.Type == parser.TypeProgress {
+		m.stream.SetProgress(item)
+		m.publish(item)
+		return
+	}
+	// Accumulate token usage (includes history — shows total session cost)
+	if item.InputTokens > 0 {
+		m.totalInputTokens += item.InputTokens
+	}
+	if item.OutputTokens > 0 {
+		m.totalOutputTokens += item.OutputTokens
+	}
+	if item.CacheCreationTokens > 0 {
+		m.totalCacheCreation += item.CacheCreationTokens
+	}
+	if item.CacheReadTokens > 0 {
+		m.totalCacheRead += item.CacheReadTokens
+	}
+	m.tree.RecordActivity(item.SessionID, item.Timestamp)
+	m.trackSpan(item)
+	if m.beats != nil {
+		m.beats.Add(item)
+	}
+	for _, ev := range m.budget.Add(item) {
+		m.notifier.Send(ev)
+	}
+	for _, alert := range m.loops.Add(item) {
+		// Replayed history can hold long-finished loops: badge them,
+		// but only notify about fresh ones.
+		if time.Since(alert.Time) < loopNotifyWindow {
+			m.notifier.Send(loopEvent(alert))
+		}
+	}
+	m.tree.SetWarnings(item.SessionID, m.loops.Warnings(item.SessionID))
+	m.longrun.Add(item)
+	m.apiErrors.Add(item)
+	m.trackInterrupt(item)
+	// Per-agent context size: latest snapshot, not a sum. The prompt
+	// size for a turn is input + cache_creation + cache_read; output
+	// tokens don't fill the context window.
+	if item.Model != "" {
+		ctx := item.InputTokens + item.CacheCreationTokens + item.CacheReadTokens
+		if ctx > 0 {
+			m.tree.UpdateContext(item.SessionID, item.AgentID, ctx, parser.ContextWindowFor(item.Model))
+		}
+	}
+	if m.stream.AddItem(item) {
+		m.publish(item)
+		if m.pipe != nil && m.stream.IsVisible(item) {
+			for _, line := range export.PlainLines(item) {
+				m.pipe.Write(line)
+			}
+		}
+	}
+	if parser.IsAgentReport(item) {
+		m.tree.SetReport(item.SessionID, item.SpawnedAgentID, item.Content)
+	}
+	for _, path := range item.Artifacts {
+		m.tree.AddArtifact(item.SessionID, item.AgentID, path)
+	}
+	m.timeline.AddItem(item)
+	m.stats.AddItem(item)
+	m.recap.AddItem(item)
+	m.tests.AddItem(item)
+	m.files.AddItem(item)
+	m.syncFilters()
+}
+
+// syncFilters pushes the tree's enabled session/agent set to every pane
+// that filters on it.
+func (m *Model) syncFilters() {
+	filters := m.tree.GetEnabledFilters()
+	m.stream.SetEnabledFilters(filters)
+	m.timeline.SetEnabledFilters(filters)
+	m.stats.SetEnabledFilters(filters)
+	m.recap.SetEnabledFilters(filters)
+	m.longRun.SetEnabledFilters(filters)
+	m.tests.SetEnabledFilters(filters)
+	m.files.SetEnabledFilters(filters)
+	m.stream.SetSessionColors(m.tree.SessionColors())
+}
+
 func (m *Model) pollWatcher() tea.Cmd {
-	if m.watcher == nil {
+	if m.watcher == nil || m.wait != nil {
 		return nil
 	}
 
 	return func() tea.Msg {
 		select {
 		case item := <-m.watcher.Items:
-			return streamItemMsg(item)
+			return drainItems(m.watcher.Items, item)
 		case agent := <-m.watcher.NewAgent:
 			return newAgentMsg(agent)
 		case session := <-m.watcher.NewSession:
 			return newSessionMsg(session)
 		case task := <-m.watcher.NewBackgroundTask:
 			return newBackgroundTaskMsg(task)
+		case todos := <-m.watcher.Todos:
+			return todosMsg(todos)
 		case err := <-m.watcher.Errors:
 			return errMsg(err)
+		case warning := <-m.watcher.Warnings:
+			return watcherWarningMsg(warning)
+		case fe := <-m.watcher.FileErrors:
+			return fileErrorMsg(fe)
 		default:
 			return nil
 		}
@@ -215,6 +741,36 @@ func (m *Model) pollWatcher() tea.Cmd {
 }
 
 func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
+	// Suspend works everywhere, prompt included, like in a shell.
+	if msg.String() == "ctrl+z" {
+		return tea.Suspend
+	}
+	if cmd, ok := m.macroKey(msg); ok {
+		return cmd
+	}
+	if m.prompt != nil {
+		done, cmd := m.prompt.Update(msg)
+		if done {
+			m.prompt = nil
+			m.updateLayout()
+			cmd = tea.Batch(cmd, m.afterPrompt)
+			m.afterPrompt = nil
+		}
+		if m.quitting {
+			return tea.Quit
+		}
+		return cmd
+	}
+	m.status = ""
+	m.lastKey = time.Now()
+	if m.pager != nil && m.pagerKey(msg.String()) {
+		return nil
+	}
+	m.focusForKey(msg.String())
+	if cmd, ok := m.handleMotion(msg.String()); ok {
+		return cmd
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		m.quitting = true
@@ -243,55 +799,161 @@ func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
 	case "o":
 		m.stream.ToggleToolOutput()
 
+	case "O":
+		m.stream.ToggleStderrOnly()
+
+	case "*":
+		m.stream.ToggleHighlights()
+
 	case "a":
 		m.stream.ToggleAutoScroll()
 
-	case "j", "down":
+	case "v":
+		show := !m.showTimeline
+		m.hidePanels()
+		m.showTimeline = show
+
+	case "$":
+		show := !m.showStats
+		m.hidePanels()
+		m.showStats = show
+
+	case "r":
+		show := !m.showRecap
+		m.hidePanels()
+		m.showRecap = show
+
+	case "L":
+		show := !m.showLongRun
+		m.hidePanels()
+		m.showLongRun = show
+
+	case "T":
+		show := !m.showTests
+		m.hidePanels()
+		m.showTests = show
+
+	case "F":
+		m.toggleFiles()
+
+	case "M":
 		if m.focus == FocusTree {
-			m.tree.MoveDown()
-		} else {
-			m.stream.ScrollDown(3)
+			m.toggleSnooze()
+		}
+
+	case "+":
+		m.showAskingAgents()
+
+	case "l":
+		if m.focus == FocusStream && !m.stream.NextLink() {
+			m.status = "select an output with file paths (J/K) first"
 		}
 
-	case "k", "up":
+	case "/":
 		if m.focus == FocusTree {
-			m.tree.MoveUp()
-		} else {
-			m.stream.ScrollUp(3)
+			m.openTreeFilter()
 		}
 
+	case "esc":
+		// In the tree, esc first clears a "/" filter.
+		if m.focus == FocusTree && m.tree.Filter() != "" {
+			m.tree.SetFilter("")
+			m.syncFilters()
+			break
+		}
+		// Back to live first, then leave a followed Task thread; the
+		// last esc clears the cursor.
+		if !m.stream.ScrubLive() && !m.stream.Unfollow() {
+			m.stream.ClearSelection()
+		}
+
+	case "f":
+		if m.focus == FocusStream && !m.stream.FollowSelectedTask() {
+			m.status = "select a Task call or result (J/K) to follow it"
+		}
+
+	case "n":
+		m.openNotePrompt()
+
+	case ":":
+		m.openPalette()
+
+	case "m":
+		if m.focus == FocusStream && !m.stream.ToggleMark() {
+			m.status = "select an item with J/K first"
+		}
+
+	case "z":
+		if m.focus == FocusStream && !m.stream.ToggleExpanded() {
+			m.status = "select an item with J/K first"
+		}
+
+	case "Z":
+		m.stream.SetFullOutput(!m.stream.FullOutput())
+
+	case "E":
+		m.exportItems()
+
+	case "ctrl+e":
+		if name := m.exportItems(); name != "" {
+			return m.openInEditor(name)
+		}
+
+	case "y":
+		return m.copyItems()
+
 	case " ", "enter":
-		if m.focus == FocusTree {
-			// For background tasks, Enter loads the output
-			if node := m.tree.GetSelectedNode(); node != nil && node.Type == NodeTypeBackgroundTask {
-				m.loadBackgroundTaskOutput(node)
+		if m.focus == FocusStream && msg.String() == "enter" && m.stream.Unseen() > 0 {
+			// The "↓ N new" chip
+			m.stream.JumpToBottom()
+		} else if m.focus == FocusStream && msg.String() == "enter" {
+			m.openItemDetail()
+		} else if m.focus == FocusTree {
+			// For background tasks and artifacts, Enter pages the file
+			if node := m.tree.GetSelectedNode(); node != nil && (node.Type == NodeTypeBackgroundTask || node.Type == NodeTypeArtifact) {
+				m.openNodeFile(node)
+			} else if node != nil && node.Type == NodeTypeTodos {
+				m.showTodos(node)
+			} else if node != nil && node.Report != "" && msg.String() == "enter" {
+				m.openReport(node)
 			} else {
 				// For other nodes, toggle enabled state
-				m.tree.Toggle()
-				m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+				m.withUndo("toggle", m.tree.Toggle)
 			}
 		}
 
-	case "g":
-		// Go to top
-		m.stream.ScrollUp(9999)
-
-	case "G":
-		// Go to bottom and enable auto-scroll
-		m.stream.ScrollDown(9999)
-		if !m.stream.IsAutoScrollEnabled() {
-			m.stream.ToggleAutoScroll()
-		}
+	case "H":
+		m.stream.ToggleHooks()
 
 	case "x":
-		m.stream.ToggleText()
+		// In the tree, x removes the session like d.
+		if m.focus == FocusTree {
+			m.removeSelectedSession(false)
+		} else {
+			m.stream.ToggleText()
+		}
 
 	case "s":
 		if m.focus == FocusTree {
-			m.tree.Solo()
-			m.stream.SetEnabledFilters(m.tree.GetEnabledFilters())
+			m.withUndo("solo", m.tree.Solo)
+		}
+
+	case "d":
+		if m.focus == FocusTree {
+			m.removeSelectedSession(false)
 		}
 
+	case "D":
+		if m.focus == FocusTree {
+			m.removeSelectedSession(true)
+		}
+
+	case "u":
+		m.undo()
+
+	case "S":
+		return m.summarizeSession()
+
 	case "A":
 		// Toggle auto-discovery of new sessions
 		if m.watcher != nil {
@@ -302,13 +964,140 @@ func (m *Model) handleKey(msg tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
+// openNotePrompt edits the note on the selected stream item (stream focus)
+// or on the selected session (tree focus).
+func (m *Model) openNotePrompt() {
+	if m.focus == FocusTree {
+		sessionID := m.tree.GetSelectedSession()
+		if sessionID == "" {
+			return
+		}
+		m.openPrompt("Session note: ", m.notes.Session(sessionID), func(text string) {
+			if err := m.notes.SetSession(sessionID, text); err != nil {
+				m.status = fmt.Sprintf("note not saved: %v", err)
+				return
+			}
+			m.tree.SetSessionNote(sessionID, m.notes.Session(sessionID) != "")
+		})
+		return
+	}
+
+	item, ok := m.stream.SelectedItem()
+	if !ok {
+		m.status = "select an item with J/K first"
+		return
+	}
+	m.openPrompt("Note: ", m.notes.Item(item), func(text string) {
+		if err := m.notes.SetItem(item, text); err != nil {
+			m.status = fmt.Sprintf("note not saved: %v", err)
+			return
+		}
+		m.stream.Refresh()
+	})
+}
+
+// openTreeFilter narrows the tree as the query is typed; enter keeps the
+// filter and esc restores the previous one.
+func (m *Model) openTreeFilter() {
+	previous := m.tree.Filter()
+	apply := func(query string) {
+		m.tree.SetFilter(query)
+		m.syncFilters()
+	}
+	m.openPrompt("/", previous, apply)
+	m.prompt.onChange = apply
+	m.prompt.onCancel = func() { apply(previous) }
+	m.prompt.SetCompletion(m.completeTreeFilter)
+	m.remember("tree")
+}
+
+// exportTargets returns the marked range, or every visible item without one.
+func (m *Model) exportTargets() (items []parser.StreamItem, what string) {
+	if items := m.stream.RangeItems(); items != nil {
+		return items, "range"
+	}
+	return m.stream.VisibleItems(), "stream"
+}
+
+// exportItems writes the marked range (or the whole visible stream) as
+// Markdown into the current directory.
+// exportItems writes the export targets to a Markdown file in the working
+// directory and returns its name, or "" if nothing was written.
+func (m *Model) exportItems() string {
+	items, what := m.exportTargets()
+	if len(items) == 0 {
+		m.status = "nothing to export"
+		return ""
+	}
+	name := export.FileName(items, time.Now())
+	f, err := os.Create(name)
+	if err != nil {
+		m.status = fmt.Sprintf("export failed: %v", err)
+		return ""
+	}
+	err = export.Markdown(f, items, export.Options{Notes: m.notes, Links: true})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("export failed: %v", err)
+		return ""
+	}
+	m.status = fmt.Sprintf("exported %s (%d items) to %s", what, len(items), name)
+	return name
+}
+
+// copyItems copies the marked range, or the selected item, to the system
+// clipboard as Markdown.
+func (m *Model) copyItems() tea.Cmd {
+	items := m.stream.RangeItems()
+	if items == nil {
+		item, ok := m.stream.SelectedItem()
+		if !ok {
+			m.status = "select an item (J/K) or mark a range (m) first"
+			return nil
+		}
+		items = []parser.StreamItem{item}
+	}
+	var b strings.Builder
+	if err := export.Markdown(&b, items, export.Options{Notes: m.notes, Links: true}); err != nil {
+		m.status = fmt.Sprintf("copy failed: %v", err)
+		return nil
+	}
+	return m.copyText(b.String(), fmt.Sprintf("copied %d items", len(items)))
+}
+
+// copiedMsg reports how a clipboard copy went.
+type copiedMsg struct{ status string }
+
+// copyText copies text in the background: with Auto the clipboard may
+// try several programs, each allowed seconds to answer, before one
+// works. done, followed by the method, is the status once it has.
+func (m *Model) copyText(text, done string) tea.Cmd {
+	m.status = "copying…"
+	c := m.clipboard
+	return func() tea.Msg {
+		method, err := c.Copy(text)
+		if err != nil {
+			return copiedMsg{fmt.Sprintf("copy failed: %v", err)}
+		}
+		return copiedMsg{fmt.Sprintf("%s (%s)", done, method)}
+	}
+}
+
+func (m *Model) openPrompt(label, initial string, onSubmit func(string)) {
+	m.prompt = newPrompt(label, initial, onSubmit)
+	m.prompt.SetWidth(m.width)
+	m.updateLayout()
+}
+
 func (m *Model) updateActivityStatus() {
 	if m.watcher == nil {
 		return
 	}
-	// Check activity within last 30 seconds. Gather infos once so the collapse
-	// policy sees the same snapshot.
-	infos := m.watcher.GetActivityInfo(30 * time.Second)
+	m.lastActivityCheck = time.Now()
+	// Gather infos once so the collapse policy sees the same snapshot.
+	infos := m.watcher.GetActivityInfo(m.activityThreshold)
 	for _, info := range infos {
 		m.tree.UpdateActivity(info.SessionID, info.AgentID, info.IsActive)
 	}
@@ -340,7 +1129,7 @@ func (m *Model) applyCollapsePolicy(infos []watcher.ActivityInfo) {
 			continue
 		}
 
-		sessionActive := now.Sub(lastMod) < 30*time.Second
+		sessionActive := now.Sub(lastMod) < m.activityThreshold
 		if sessionActive {
 			// Woke up: clear any prior pin so the next sleep cycle auto-collapses.
 			if node.Pinned {
@@ -355,41 +1144,37 @@ func (m *Model) applyCollapsePolicy(infos []watcher.ActivityInfo) {
 	}
 }
 
-func (m *Model) loadBackgroundTaskOutput(node *TreeNode) {
-	if node.OutputPath == "" {
-		return
-	}
-
-	// Read the file content
-	content, err := os.ReadFile(node.OutputPath)
-	if err != nil {
-		// Show error in stream
-		m.stream.AddItem(parser.StreamItem{
-			Type:      parser.TypeToolOutput,
-			SessionID: node.SessionID,
-			AgentID:   node.ParentAgentID,
-			Content:   fmt.Sprintf("Error reading task output: %v", err),
-			Timestamp: time.Now(),
-		})
-		return
+// expandHome resolves a leading "~/", as in artifact paths Claude quoted.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
 	}
+	return path
+}
 
-	// Create a stream item for the background task output
-	statusIcon := "⏳"
-	if node.IsComplete {
-		statusIcon = "✓"
+// showTodos adds a Todos node's list to the stream, one item per line.
+func (m *Model) showTodos(node *TreeNode) {
+	var b strings.Builder
+	for _, item := range node.Todos {
+		mark := "☐"
+		switch item.Status {
+		case "completed":
+			mark = "☑"
+		case "in_progress":
+			mark = "◐"
+		}
+		fmt.Fprintf(&b, "%s %s\n", mark, item.Content)
 	}
-
 	m.stream.AddItem(parser.StreamItem{
 		Type:      parser.TypeToolOutput,
 		SessionID: node.SessionID,
 		AgentID:   node.ParentAgentID,
-		ToolName:  fmt.Sprintf("%s %s", statusIcon, node.Name),
-		Content:   string(content),
+		ToolName:  "📋 " + node.Name,
+		Content:   strings.TrimSuffix(b.String(), "\n"),
 		Timestamp: time.Now(),
 	})
-
-	// Scroll to bottom to see the new output
 	m.stream.ScrollDown(9999)
 }
 
@@ -430,6 +1215,9 @@ func (m *Model) wrappedRows(s string) int {
 func (m *Model) chromeHeight() int {
 	headerRows := m.wrappedRows(m.renderHeader())
 	helpRows := m.wrappedRows(m.renderHelp())
+	if m.showQueues {
+		helpRows++
+	}
 	return headerRows + helpRows + 2
 }
 
@@ -454,13 +1242,65 @@ func (m *Model) updateLayout() {
 	if m.showTree {
 		m.tree.SetSize(m.treeWidth, contentHeight)
 		m.stream.SetSize(m.width-m.treeWidth-5, contentHeight) // -5 for borders/padding/gap
+		m.timeline.SetSize(m.width-m.treeWidth-5, contentHeight)
+		m.stats.SetSize(m.width-m.treeWidth-5, contentHeight)
+		m.recap.SetSize(m.width-m.treeWidth-5, contentHeight)
+		m.longRun.SetSize(m.width-m.treeWidth-5, contentHeight)
+		m.tests.SetSize(m.width-m.treeWidth-5, contentHeight)
+		m.files.SetSize(m.width-m.treeWidth-5, contentHeight)
+		if m.pager != nil {
+			m.pager.SetSize(m.width-m.treeWidth-5, contentHeight)
+		}
 	} else {
 		m.stream.SetSize(m.width-2, contentHeight)
+		m.timeline.SetSize(m.width-2, contentHeight)
+		m.stats.SetSize(m.width-2, contentHeight)
+		m.recap.SetSize(m.width-2, contentHeight)
+		m.longRun.SetSize(m.width-2, contentHeight)
+		m.tests.SetSize(m.width-2, contentHeight)
+		m.files.SetSize(m.width-2, contentHeight)
+		if m.pager != nil {
+			m.pager.SetSize(m.width-2, contentHeight)
+		}
 	}
 }
 
+// hidePanels switches the stream pane back to the stream.
+func (m *Model) hidePanels() {
+	m.showTimeline, m.showStats, m.showRecap, m.showLongRun, m.showTests, m.showFiles = false, false, false, false, false, false
+}
+
+// panelShown reports whether a view replaces the stream in its pane.
+func (m *Model) panelShown() bool {
+	return m.showTimeline || m.showStats || m.showRecap || m.showLongRun || m.showTests || m.showFiles
+}
+
+// streamPaneView returns the content of the right-hand pane: the item
+// stream, the open pager, or the timeline / stats / recap / long-running /
+// tests / files view when one is toggled on.
+func (m *Model) streamPaneView() string {
+	switch {
+	case m.pager != nil:
+		return m.pager.View()
+	case m.showTimeline:
+		return m.timeline.View()
+	case m.showStats:
+		return m.stats.View()
+	case m.showRecap:
+		return m.recap.View()
+	case m.showLongRun:
+		return m.longRun.View()
+	case m.showTests:
+		return m.tests.View()
+	case m.showFiles:
+		return m.files.View()
+	}
+	return m.stream.View()
+}
+
 // View renders the UI
 func (m *Model) View() string {
+	defer crash.Recover()
 	if m.quitting {
 		return "Goodbye!\n"
 	}
@@ -494,8 +1334,19 @@ func (m *Model) View() string {
 	// Help bar
 	b.WriteString("\n")
 	b.WriteString(m.renderHelp())
+	if m.showQueues {
+		b.WriteString("\n")
+		b.WriteString(m.renderQueues())
+	}
 
-	return b.String()
+	frame := b.String()
+	if m.ascii {
+		frame = toASCII(frame)
+	}
+	if m.share != nil {
+		m.share.Frame(frame)
+	}
+	return frame
 }
 
 func (m *Model) renderHeader() string {
@@ -503,19 +1354,30 @@ func (m *Model) renderHeader() string {
 	thinking := m.renderToggle("Thinking", m.stream.IsThinkingEnabled(), "t")
 	toolInput := m.renderToggle("Tools", m.stream.IsToolInputEnabled(), "i")
 	toolOutput := m.renderToggle("Output", m.stream.IsToolOutputEnabled(), "o")
+	if m.stream.IsStderrOnly() {
+		toolOutput = m.renderToggle("Stderr", m.stream.IsToolOutputEnabled(), "O")
+	}
 	textToggle := m.renderToggle("Text", m.stream.IsTextEnabled(), "x")
+	hooks := m.renderToggle("Hooks", m.stream.IsHooksEnabled(), "H")
 	autoScroll := m.renderToggle("Scroll", m.stream.IsAutoScrollEnabled(), "a")
 	treeToggle := m.renderToggle("Tree", m.showTree, "h")
 
-	toggles := fmt.Sprintf("%s  %s  %s  %s  %s  %s",
-		thinking, toolInput, toolOutput, textToggle, autoScroll, treeToggle)
+	toggles := fmt.Sprintf("%s  %s  %s  %s  %s  %s  %s",
+		thinking, toolInput, toolOutput, textToggle, hooks, autoScroll, treeToggle)
+	if m.stream.IsHighlights() {
+		// The item toggles don't apply to the highlights.
+		toggles = fmt.Sprintf("%s  %s  %s",
+			m.renderToggle(highlightsIcon+"Highlights", true, "*"), autoScroll, treeToggle)
+	}
 
 	// Session count and auto-discovery status
 	sessionInfo := ""
 	if m.watcher != nil {
 		sessions := m.watcher.GetSessions()
 		autoDisc := ""
-		if !m.watcher.IsAutoDiscoveryEnabled() {
+		if m.watcher.SessionFile() != "" {
+			autoDisc = " [file]"
+		} else if !m.watcher.IsAutoDiscoveryEnabled() {
 			autoDisc = " [paused]"
 		}
 		if len(sessions) == 0 {
@@ -541,15 +1403,27 @@ func (m *Model) renderHeader() string {
 				formatTokenCount(m.totalCacheCreation),
 				formatTokenCount(m.totalCacheRead))
 		}
+		tokenInfo += fmt.Sprintf(" ≈ $%.2f", m.budget.Total())
+	}
+	if bars := m.budget.HeaderBars(); bars != "" {
+		tokenInfo += "  │ " + bars
 	}
 
 	// Build header - use plain text and apply headerStyle uniformly (like Rust version)
 	// Don't use Width() as it causes truncation on narrow terminals
 	headerText := fmt.Sprintf("%s  │  %s", toggles, sessionInfo)
+	if info := m.sessionInfo(); info != "" {
+		headerText += "  │ " + info
+	}
 	if tokenInfo != "" {
 		headerText += "  " + tokenInfo
 	}
 	header := headerStyle.Render(headerText)
+	if text, throttled := m.apiErrors.Header(); throttled {
+		header += apiErrorBannerStyle.Render(text)
+	} else if text != "" {
+		header += headerStyle.Render("│ " + text)
+	}
 
 	return header
 }
@@ -580,42 +1454,160 @@ func (m *Model) renderWithTree() string {
 	innerHeight := m.contentInnerHeight()
 
 	// Tree pane
-	treeBorder := treeBorderStyle
-	if m.focus == FocusTree {
-		treeBorder = treeBorder.BorderForeground(primaryColor)
-	}
-	treePane := treeBorder.
-		Width(m.treeWidth).
-		Height(innerHeight).
-		Render(m.tree.View())
+	treeFocused := m.focus == FocusTree
+	treePane := titledPane(paneBorder(treeBorderStyle, treeFocused), m.treeWidth, innerHeight, treeTitle, treeFocused, m.tree.View())
 
 	// Stream pane
-	streamBorder := streamBorderStyle
-	if m.focus == FocusStream {
-		streamBorder = streamBorder.BorderForeground(primaryColor)
-	}
-	streamPane := streamBorder.
-		Width(m.width - m.treeWidth - 5).
-		Height(innerHeight).
-		Render(m.stream.View())
+	streamFocused := m.focus == FocusStream
+	streamPane := m.renderStreamPane(paneBorder(streamBorderStyle, streamFocused), m.width-m.treeWidth-5, innerHeight, streamTitle, streamFocused)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, treePane, " ", streamPane)
 }
 
 func (m *Model) renderStreamOnly() string {
 	streamBorder := streamBorderStyle.BorderForeground(primaryColor)
-	return streamBorder.
-		Width(m.width - 2).
-		Height(m.contentInnerHeight()).
-		Render(m.stream.View())
+	return m.renderStreamPane(streamBorder, m.width-2, m.contentInnerHeight(), "", true)
+}
+
+// renderStreamPane renders the right-hand pane with title, if any, set
+// into its top border and the stream's scroll position into its bottom
+// border, preceded by a "↓ N new" chip while items are arriving below the
+// view.
+func (m *Model) renderStreamPane(border lipgloss.Style, width, height int, title string, focused bool) string {
+	var labels []string
+	if m.pager != nil {
+		labels = append(labels, mutedStyle.Render("["+m.pager.Position()+"]"))
+	} else if !m.panelShown() {
+		if m.fastForward > 0 {
+			labels = append(labels, fastForwardStyle.Render(fmt.Sprintf("⏩ fast-forwarding… %d at once", m.fastForward)))
+		}
+		if chip, ok := m.scrubChip(); ok {
+			labels = append(labels, chip)
+		}
+		if n := m.stream.Unseen(); n > 0 {
+			labels = append(labels, newItemsChipStyle.Render(fmt.Sprintf("↓ %d new · enter/G", n)))
+		}
+		if pos := m.stream.Position(); pos != "" {
+			labels = append(labels, mutedStyle.Render("["+pos+"]"))
+		}
+	}
+	body := border.BorderTop(title == "").BorderBottom(len(labels) == 0)
+	pane := body.Width(width).Height(height).Render(m.streamPaneView())
+	if title != "" {
+		pane = titledTopBorder(border, width+2, title, focused) + "\n" + pane
+	}
+	if len(labels) > 0 {
+		pane += "\n" + labeledBottomBorder(border, width+2, strings.Join(labels, " "))
+	}
+	return pane
+}
+
+// labeledBottomBorder draws border's bottom edge width cells wide with
+// the (already styled) label right-aligned in it, or a plain border if the
+// label doesn't fit.
+func labeledBottomBorder(border lipgloss.Style, width int, label string) string {
+	b := border.GetBorderStyle()
+	line := lipgloss.NewStyle().Foreground(border.GetBorderBottomForeground())
+	fill := width - 2 - lipgloss.Width(label) - 3 // " label " plus one dash before the corner
+	if fill < 1 {
+		return line.Render(b.BottomLeft + strings.Repeat(b.Bottom, max(0, width-2)) + b.BottomRight)
+	}
+	return line.Render(b.BottomLeft+strings.Repeat(b.Bottom, fill)+" ") +
+		label +
+		line.Render(" "+b.Bottom+b.BottomRight)
 }
 
 func (m *Model) renderHelp() string {
+	if m.prompt != nil {
+		return m.prompt.View()
+	}
 	var help string
-	if m.focus == FocusTree {
-		help = "j/k: navigate │ space: toggle │ s: solo │ A: auto-discover │ q: quit"
+	if m.pager != nil {
+		help = "j/k: scroll │ ^d/^u: half page │ g/G: top/bottom │ /: search │ n/N: next/prev match │ F: follow │ q/esc: close"
+	} else if m.focus == FocusTree {
+		help = "j/k: navigate │ space: toggle │ s: solo │ d/D: remove/hide for good │ u: undo │ /: filter │ n: note │ A: auto-discover │ :: commands │ q: quit"
+		if f := m.tree.Filter(); f != "" {
+			help = "/" + f + " │ esc: clear │ " + help
+		}
+		if note := m.notes.Session(m.tree.GetSelectedSession()); note != "" {
+			help = noteIcon + " " + note + " │ " + help
+		}
+		if node := m.tree.GetSelectedNode(); node != nil && node.ReadError != "" {
+			help = readErrorHelp(node.ReadError) + help
+		} else if node != nil && node.Warning != "" {
+			help = loopIcon + " " + node.Warning + " │ " + help
+		} else if node != nil && node.LongRunning != "" {
+			help = longRunIcon + " " + node.LongRunning + " │ L: list │ " + help
+		} else if node != nil && !node.SnoozedUntil.IsZero() {
+			help = snoozeIcon + " snoozed until " + node.SnoozedUntil.Format("15:04") + " │ M: wake now │ " + help
+		} else if node != nil && node.Interrupted != "" {
+			help = interruptIcon + " " + node.Interrupted + " │ " + help
+		} else if node != nil && node.Report != "" {
+			help = reportHelp(node.Report) + help
+		} else if node != nil && node.Switched != "" {
+			help = switchIcon + " " + node.Switched + " │ " + help
+		} else if node != nil && node.Forked != "" {
+			help = forkIcon + " " + node.Forked + " │ " + help
+		} else if node != nil && node.Type == NodeTypeTodos {
+			if current := node.Todos.Current(); current != "" {
+				help = "◐ " + current + " │ enter: list │ " + help
+			}
+		}
 	} else {
-		help = "j/k: scroll │ g/G: top/bottom │ A: auto-discover │ tab: tree │ q: quit"
+		help = "j/k: scroll │ J/K: select │ 1-4/p: preset │ f: follow Task │ m: mark │ E: export │ y: copy │ n: note │ ^d/^u: half page │ gg/G: top/bottom │ v: timeline │ $: stats │ r: recap │ tab: tree │ q: quit"
+		if link, _, ok := m.stream.CurrentLink(); ok {
+			help = fmt.Sprintf("%s │ l: next path │ e: open │ F: reveal in files │ ", truncate(link.ref, 40)) + help
+		} else if res, _, ok := m.stream.SelectedProblems(); ok {
+			help = fmt.Sprintf("%s %s │ e/<N>e: open in editor │ l: paths │ ", res.Tool, res.Summary()) + help
+		}
+	}
+	if n := m.tree.Asking(); n > 0 {
+		help = askingHelp(n) + help
+	}
+	if m.stream.IsHighlights() {
+		help = "highlights │ *: everything │ " + help
+	}
+	if _, _, _, ok := m.stream.Scrubbed(); ok {
+		help = "scrubbing │ [/]: step back/forward │ esc/G: live │ " + help
+	}
+	if label, ok := m.stream.Following(); ok {
+		help = fmt.Sprintf("following Task %q │ esc: back │ ", truncate(label, 30)) + help
+	}
+	if m.pipe != nil {
+		help = truncate(m.pipe.Status().String(), 40) + " │ " + help
+	}
+	if m.share != nil {
+		help = fmt.Sprintf("shared 👁 %d", m.share.Viewers()) + " │ " + help
+	}
+	if e := m.stream.filter; e != nil {
+		help = "filter: " + truncate(e.String(), 40) + " │ " + help
+	}
+	if m.stream.FullOutput() {
+		help = "full output (Z) │ " + help
+	}
+	if m.attach != "" {
+		help = "attached ⇄ " + strings.TrimPrefix(m.attach, "http://") + " │ " + help
+	}
+	if m.lowPower {
+		help = "low power │ " + help
+	}
+	if m.newRelease != "" {
+		help = fmt.Sprintf("v%s available: claude-esp update │ ", m.newRelease) + help
+	}
+	if m.watchWarning != "" {
+		help = "⚠ " + m.watchWarning + " │ " + help
+	}
+	if m.configErr != "" {
+		help = "⚠ " + m.configErr + " │ " + help
+	}
+	if m.status != "" {
+		help = m.status + " │ " + help
+	}
+	if m.recording {
+		help = fmt.Sprintf("● recording macro, %d keys (Q stops)", len(m.recorded)) + " │ " + help
+	}
+	if p := m.motionPrefix(); p != "" {
+		help = p + " │ " + help
 	}
 	return helpStyle.Render(help)
 }
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/clipboard"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestNewItemsChip(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	m.tree.AddSession("s1", "/p")
+	m.syncFilters()
+	add := func(text string) {
+		m.Update(streamItemMsg(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: text, Timestamp: time.Now()}))
+	}
+	for i := range 20 {
+		add(fmt.Sprint("old ", i))
+	}
+	m.stream.ScrollUp(10)
+	add("new 1")
+	add("new 2")
+	if !strings.Contains(m.View(), "↓ 2 new") {
+		t.Fatal("no chip while items arrive below the view")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.stream.Unseen() != 0 || !m.stream.IsAutoScrollEnabled() {
+		t.Errorf("enter on the chip: unseen %d, auto-scroll %v", m.stream.Unseen(), m.stream.IsAutoScrollEnabled())
+	}
+	if strings.Contains(m.View(), "new ·") {
+		t.Error("chip still shown after jumping to the bottom")
+	}
+}
+
+func TestTreeFilterPrompt(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.tree.AddSession("s1", "home/user/claude-esp")
+	m.tree.AddSession("s2", "home/user/webapp")
+	m.focus = FocusTree
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("web")})
+	if len(m.tree.nodes) != 2 {
+		t.Fatalf("filter not applied while typing: %d nodes", len(m.tree.nodes))
+	}
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.tree.Filter() != "" || len(m.tree.nodes) != 4 {
+		t.Errorf("esc in the prompt should restore the previous filter, got %q", m.tree.Filter())
+	}
+
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("web")})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.tree.Filter() != "web" {
+		t.Fatalf("enter should keep the filter, got %q", m.tree.Filter())
+	}
+	m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.tree.Filter() != "" {
+		t.Error("esc in the tree should clear the filter")
+	}
+}
+
+func TestNewSessionFlashesAndNotifies(t *testing.T) {
+	m := treeModel(t)
+	if !m.tree.Root.Children[1].FlashUntil.After(time.Now()) {
+		t.Error("discovered session not flashing")
+	}
+	ev := newSessionEvent(newSessionMsg{SessionID: "s2", ProjectPath: "/work/web"})
+	if ev.Kind != "session" || ev.SessionID != "s2" || !strings.Contains(ev.Title, "web") {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestTodosNode(t *testing.T) {
+	m := treeModel(t)
+	m.Update(todosMsg{SessionID: "s1", Todos: watcher.Todos{
+		{Content: "Fix bug", Status: "in_progress", ActiveForm: "Fixing bug"},
+		{Content: "Ship", Status: "pending"},
+	}})
+	m.tree.MoveTo(2) // s1 > Main > Todos
+	if node := m.tree.GetSelectedNode(); node == nil || node.Type != NodeTypeTodos {
+		t.Fatalf("selected %+v, want the Todos node", node)
+	}
+	if !strings.Contains(m.renderHelp(), "Fixing bug") {
+		t.Error("help bar doesn't show the item in progress")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	items := m.stream.items
+	if len(items) == 0 || !strings.Contains(items[len(items)-1].Content, "◐ Fix bug\n☐ Ship") {
+		t.Errorf("enter didn't list the todos: %+v", items)
+	}
+}
+
+func TestCopyItems(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.focus = FocusStream
+	var term strings.Builder
+	m.clipboard = clipboard.New(clipboard.OSC52, clipboard.Env{}, &term)
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "hello", Timestamp: time.Now()}})
+
+	m.Update(key("y"))
+	if term.Len() != 0 || !strings.Contains(m.status, "select an item") {
+		t.Errorf("y with nothing selected: status %q, wrote %q", m.status, term.String())
+	}
+	m.Update(key("K"))
+	_, cmd := m.Update(key("y"))
+	if term.Len() != 0 || m.status != "copying…" {
+		t.Errorf("y copied inside Update: status %q, wrote %q", m.status, term.String())
+	}
+	m.Update(cmd())
+	if !strings.HasPrefix(term.String(), "\x1b]52;c;") || m.status != "copied 1 items (osc52)" {
+		t.Errorf("y: status %q, wrote %q", m.status, term.String())
+	}
+}
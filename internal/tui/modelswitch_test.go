@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestModelSwitch(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.tree.AddSession("s1", "home/user/app")
+	m.syncFilters()
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	m.addItem(parser.StreamItem{Type: parser.TypeThinkingConfig, SessionID: "s1", AgentName: "Main", Content: "high", Timestamp: at})
+	m.addItem(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "a", Model: "claude-sonnet-4-5", Timestamp: at})
+	m.addItem(parser.StreamItem{Type: parser.TypeThinkingConfig, SessionID: "s1", AgentName: "Main", Content: "off", Timestamp: at})
+	m.addItem(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "b", Model: "claude-opus-4-7", Timestamp: at})
+
+	var kinds []string
+	for _, item := range m.stream.Items() {
+		kinds = append(kinds, string(item.Type)+":"+item.Content)
+	}
+	want := []string{"text:a", "model_switch:thinking high → off", "model_switch:model claude-sonnet-4-5 → claude-opus-4-7", "text:b"}
+	if strings.Join(kinds, ", ") != strings.Join(want, ", ") {
+		t.Errorf("stream = %v, want %v", kinds, want)
+	}
+
+	main := m.tree.Root.Children[0].Children[0]
+	if main.Switched != "model claude-sonnet-4-5 → claude-opus-4-7 at 12:00" {
+		t.Errorf("badge text = %q", main.Switched)
+	}
+	if !strings.Contains(m.tree.View(), switchIcon) {
+		t.Error("tree lacks the switch badge")
+	}
+	if out := m.stream.renderItem(m.stream.Items()[2], 100); !strings.Contains(out, "⇄ Main: model claude-sonnet-4-5 → claude-opus-4-7") {
+		t.Errorf("switch marker = %q", out)
+	}
+}
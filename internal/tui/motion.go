@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps count prefixes ("5j") so a held key can't overflow.
+const maxCount = 9999
+
+// streamScrollLines is how far j/k scroll the stream.
+const streamScrollLines = 3
+
+// handleMotion handles vi-style navigation in the focused pane: j/k,
+// J/K, ctrl+d/u (half page), ctrl+f/b (full page), gg/G, each taking an
+// optional count prefix. With a count, gg and G go to that line (stream)
+// or row (tree). [ and ] scrub the stream back and forward by count
+// items. A lone digit that no motion follows picks that view preset (see
+// presetKey); p, which picks one by count, and e, which opens the
+// count'th build error, live here for the count too. With the
+// files panel shown, J/K move its cursor. It reports
+// whether key was consumed, with e's editor command; any other key drops
+// a pending count.
+func (m *Model) handleMotion(key string) (tea.Cmd, bool) {
+	if m.pendingG {
+		m.pendingG = false
+		if key == "g" {
+			m.gotoStart(m.takeCount())
+			return nil, true
+		}
+	}
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || m.count > 0) {
+		first := m.count == 0
+		m.count = min(m.count*10+int(key[0]-'0'), maxCount)
+		if first && m.count <= len(m.presets) {
+			return m.presetKey(m.count), true
+		}
+		return nil, true
+	}
+	if key == "g" {
+		m.pendingG = true
+		return nil, true
+	}
+
+	count := m.takeCount()
+	n := max(1, count)
+	tree := m.focus == FocusTree
+	switch key {
+	case "j", "down":
+		m.move(tree, n, streamScrollLines*n)
+	case "k", "up":
+		m.move(tree, -n, -streamScrollLines*n)
+	case "ctrl+d":
+		m.move(tree, n*m.tree.PageSize()/2, n*m.stream.PageSize()/2)
+	case "ctrl+u":
+		m.move(tree, -n*m.tree.PageSize()/2, -n*m.stream.PageSize()/2)
+	case "ctrl+f", "pgdown":
+		m.move(tree, n*m.tree.PageSize(), n*m.stream.PageSize())
+	case "ctrl+b", "pgup":
+		m.move(tree, -n*m.tree.PageSize(), -n*m.stream.PageSize())
+	case "J", "K":
+		if tree {
+			return nil, true
+		}
+		if m.showFiles {
+			if key == "K" {
+				n = -n
+			}
+			m.files.Move(n)
+			return nil, true
+		}
+		for range n {
+			if key == "J" {
+				m.stream.SelectNext()
+			} else {
+				m.stream.SelectPrev()
+			}
+		}
+	case "[", "]":
+		if key == "]" {
+			n = -n
+		}
+		m.stream.Scrub(n)
+	case "p":
+		m.applyPreset(count)
+	case "e":
+		if tree {
+			return nil, true
+		}
+		return m.openSelected(count), true
+	case "G":
+		switch {
+		case count > 0:
+			m.gotoStart(count)
+		case tree:
+			m.tree.MoveTo(len(m.tree.nodes) - 1)
+		default:
+			// Bottom, resuming auto-scroll
+			m.stream.JumpToBottom()
+		}
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// move moves the tree cursor by rows or scrolls the stream by lines,
+// depending on focus.
+func (m *Model) move(tree bool, rows, lines int) {
+	switch {
+	case tree:
+		m.tree.MoveBy(rows)
+	case lines > 0:
+		m.stream.ScrollDown(lines)
+	case lines < 0:
+		m.stream.ScrollUp(-lines)
+	}
+}
+
+// gotoStart handles gg: the top, or with a count that line or row.
+func (m *Model) gotoStart(count int) {
+	if m.focus == FocusTree {
+		m.tree.MoveTo(max(0, count-1))
+		return
+	}
+	if count > 0 {
+		m.stream.GotoLine(count)
+		return
+	}
+	m.stream.ScrollUp(m.stream.viewport.TotalLineCount())
+}
+
+func (m *Model) takeCount() int {
+	n := m.count
+	m.count = 0
+	return n
+}
+
+// motionPrefix is the count and/or g typed so far, for the help bar.
+func (m *Model) motionPrefix() string {
+	p := ""
+	if m.count > 0 {
+		p = strconv.Itoa(m.count)
+	}
+	if m.pendingG {
+		p += "g"
+	}
+	return p
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func keys(m *Model, seq ...string) {
+	for _, k := range seq {
+		var msg tea.KeyMsg
+		switch k {
+		case "ctrl+d":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlD}
+		case "ctrl+u":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+		case "ctrl+f":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlF}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m.handleKey(msg)
+	}
+}
+
+func motionModel(t *testing.T) *Model {
+	t.Helper()
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	for i := range 5 {
+		m.tree.AddSession(fmt.Sprint("s", i), "/p")
+	}
+	m.syncFilters()
+	for i := range 50 {
+		m.stream.AddItem(parser.StreamItem{Type: parser.TypeText, SessionID: "s0", AgentName: "Main", Content: fmt.Sprint(i), Timestamp: time.Now()})
+	}
+	return m
+}
+
+func TestStreamMotions(t *testing.T) {
+	m := motionModel(t)
+	vp := &m.stream.viewport
+	half := m.stream.PageSize() / 2
+
+	keys(m, "g", "g")
+	if vp.YOffset != 0 || m.stream.IsAutoScrollEnabled() {
+		t.Fatalf("gg: offset %d, auto-scroll %v", vp.YOffset, m.stream.IsAutoScrollEnabled())
+	}
+	keys(m, "5", "j")
+	if vp.YOffset != 5*streamScrollLines {
+		t.Errorf("5j: offset %d, want %d", vp.YOffset, 5*streamScrollLines)
+	}
+	keys(m, "ctrl+d")
+	if vp.YOffset != 5*streamScrollLines+half {
+		t.Errorf("ctrl+d: offset %d", vp.YOffset)
+	}
+	keys(m, "2", "ctrl+u")
+	if vp.YOffset != 5*streamScrollLines+half-2*m.stream.PageSize()/2 {
+		t.Errorf("2 ctrl+u: offset %d", vp.YOffset)
+	}
+	keys(m, "1", "0", "G")
+	if vp.YOffset != 9 {
+		t.Errorf("10G: offset %d, want line 10 at the top", vp.YOffset)
+	}
+	// A count is dropped by any other key.
+	keys(m, "7", "t", "j")
+	if vp.YOffset != 9+streamScrollLines {
+		t.Errorf("count survived an unrelated key: offset %d", vp.YOffset)
+	}
+	keys(m, "G")
+	if !vp.AtBottom() || !m.stream.IsAutoScrollEnabled() {
+		t.Error("G should jump to the bottom and resume auto-scroll")
+	}
+}
+
+func TestTreeMotions(t *testing.T) {
+	m := motionModel(t)
+	m.focus = FocusTree
+	keys(m, "3", "j")
+	if m.tree.cursor != 3 {
+		t.Errorf("3j: cursor %d", m.tree.cursor)
+	}
+	keys(m, "g", "g")
+	if m.tree.cursor != 0 {
+		t.Errorf("gg: cursor %d", m.tree.cursor)
+	}
+	keys(m, "G")
+	if m.tree.cursor != len(m.tree.nodes)-1 {
+		t.Errorf("G: cursor %d", m.tree.cursor)
+	}
+	keys(m, "2", "G")
+	if m.tree.cursor != 1 {
+		t.Errorf("2G: cursor %d, want row 2", m.tree.cursor)
+	}
+	keys(m, "ctrl+f")
+	if m.tree.cursor != len(m.tree.nodes)-1 {
+		t.Errorf("ctrl+f should clamp at the last row, cursor %d", m.tree.cursor)
+	}
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// newAgentPolicy is new_agents for the picked preset, else [view]'s.
+func (m *Model) newAgentPolicy() string {
+	if m.preset > 0 && m.preset <= len(m.presets) && m.presets[m.preset-1].NewAgents != "" {
+		return m.presets[m.preset-1].NewAgents
+	}
+	return cmp.Or(m.newAgents, "enabled")
+}
+
+// applyNewAgentPolicy mutes a subagent that appeared while running, or
+// holds it for a keypress, as new_agents says. (The agents found at
+// startup are added by Init, not through newAgentMsg.)
+func (m *Model) applyNewAgentPolicy(sessionID, agentID string) {
+	switch m.newAgentPolicy() {
+	case "muted":
+		m.tree.SetEnabled(sessionID, agentID, false)
+	case "ask":
+		m.tree.Ask(sessionID, agentID)
+	}
+}
+
+// showAskingAgents is +: it shows every agent held by new_agents = "ask".
+func (m *Model) showAskingAgents() {
+	var names []string
+	m.withUndo("show new agents", func() { names = m.tree.ShowAsking() })
+	if len(names) == 0 {
+		m.status = "no new agents waiting"
+		return
+	}
+	m.status = "showing " + strings.Join(names, ", ")
+}
+
+// Ask disables a new agent and marks it as waiting for + (or space).
+func (t *TreeView) Ask(sessionID, agentID string) {
+	if node := t.findAgentNode(sessionID, agentID); node != nil {
+		node.Enabled, node.Asking = false, true
+	}
+}
+
+// ShowAsking enables the agents waiting for + and returns their names.
+func (t *TreeView) ShowAsking() []string {
+	var names []string
+	for _, session := range t.Root.Children {
+		for _, child := range session.Children {
+			if child.Asking {
+				child.Enabled, child.Asking = true, false
+				names = append(names, child.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Asking counts the agents waiting for +.
+func (t *TreeView) Asking() int {
+	n := 0
+	for _, session := range t.Root.Children {
+		for _, child := range session.Children {
+			if child.Asking {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// askingHelp is the help bar's note of agents waiting for +.
+func askingHelp(n int) string {
+	if n == 1 {
+		return "1 new agent waiting │ +: show │ "
+	}
+	return fmt.Sprintf("%d new agents waiting │ +: show │ ", n)
+}
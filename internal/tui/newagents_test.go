@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+func TestNewAgentsAsk(t *testing.T) {
+	m := treeModel(t)
+	m.newAgents = "ask"
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a2", AgentType: "reviewer"})
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a2", AgentType: "reviewer"}) // seen again
+	if m.tree.IsEnabled("s1", "a2") || m.tree.Asking() != 1 {
+		t.Fatal("ask didn't hold the new agent")
+	}
+	if !m.tree.IsEnabled("s1", "a1") {
+		t.Error("ask touched an agent already there")
+	}
+	if help := m.renderHelp(); !strings.Contains(help, "1 new agent waiting") {
+		t.Errorf("help lacks the waiting agent: %q", help)
+	}
+	if st := m.viewState(); len(st.Disabled) != 0 {
+		t.Errorf("waiting agent was saved: %v", st.Disabled)
+	}
+
+	m.Update(key("+"))
+	if !m.tree.IsEnabled("s1", "a2") || m.tree.Asking() != 0 || m.status != "showing reviewer" {
+		t.Errorf("+ didn't show the agent: %q", m.status)
+	}
+	m.Update(key("u"))
+	if m.tree.IsEnabled("s1", "a2") || m.tree.Asking() != 1 {
+		t.Error("undo didn't hold the agent again")
+	}
+}
+
+func TestNewAgentsMutedAndPresets(t *testing.T) {
+	m := treeModel(t)
+	m.newAgents = "muted"
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a2"})
+	if m.tree.IsEnabled("s1", "a2") || m.tree.Asking() != 0 {
+		t.Error("muted agent enabled or waiting")
+	}
+
+	m.presets = []config.Preset{{Name: "everything", Show: config.PresetKinds, NewAgents: "enabled"}}
+	m.applyPreset(1)
+	if !strings.Contains(m.status, "new agents enabled") {
+		t.Errorf("preset status %q", m.status)
+	}
+	m.Update(newAgentMsg{SessionID: "s2", AgentID: "b1"})
+	if !m.tree.IsEnabled("s2", "b1") {
+		t.Error("preset's new_agents not used")
+	}
+}
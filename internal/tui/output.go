@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"os"
+	"sync"
+)
+
+// Terminal is where the TUI draws; pass it to tea.WithOutput. The escape
+// sequences the model writes itself, OSC 52 copies and OSC 9
+// notifications, go through it too, and it takes one write at a time, so
+// they land between frames rather than inside one.
+var Terminal = &Output{f: os.Stdout}
+
+// Output serializes writes to a terminal file. Read, Close and Fd let
+// bubbletea still see it as the terminal.
+type Output struct {
+	f  *os.File
+	mu sync.Mutex
+}
+
+func (o *Output) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Write(b)
+}
+
+func (o *Output) Read(b []byte) (int, error) { return o.f.Read(b) }
+func (o *Output) Close() error               { return o.f.Close() }
+func (o *Output) Fd() uintptr                { return o.f.Fd() }
@@ -0,0 +1,482 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// pagerChunk is how much is read at a time while indexing.
+	pagerChunk = 64 * 1024
+	// pagerIndexStride is how many lines apart the line index records
+	// offsets; reaching a line reads at most this many lines past one.
+	pagerIndexStride = 256
+	// pagerMaxLine is how much of a line is kept for display and search;
+	// the rest of a very long line (minified JSON, base64) is skipped.
+	pagerMaxLine = 4096
+)
+
+// pagerMatchStyle highlights search matches.
+var pagerMatchStyle = lipgloss.NewStyle().Foreground(bgColor).Background(warningColor)
+
+// pager shows a file, or an item's full text, a screen at a time without
+// loading it: tool-results files can be tens of MB. Lines are read on
+// demand through a sparse index of line offsets built as far as the view
+// has gone; search streams through the file. A file pager can follow the
+// file as it grows, like less +F.
+type pager struct {
+	title string
+	r     io.ReaderAt
+	file  *os.File // nil for in-memory text
+	size  int64
+
+	index    []int64 // index[i] is the offset of line i*pagerIndexStride
+	indexed  int64   // bytes scanned into the index so far
+	newlines int     // '\n' in the indexed bytes
+	lastByte byte    // last indexed byte
+
+	top    int
+	follow bool
+	query  string
+	match  int // line of the current match, -1 for none
+
+	width  int
+	height int
+}
+
+// newFilePager opens path for paging. Close releases it.
+func newFilePager(title, path string) (*pager, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	return &pager{title: title, r: f, file: f, size: info.Size(), index: []int64{0}, match: -1}, nil
+}
+
+// newTextPager pages text already in memory, e.g. an item's content.
+func newTextPager(title, text string) *pager {
+	return &pager{title: title, r: strings.NewReader(text), size: int64(len(text)), index: []int64{0}, match: -1}
+}
+
+// Close releases the pager's file.
+func (p *pager) Close() {
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+func (p *pager) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// bodyHeight is the number of content rows, below the title.
+func (p *pager) bodyHeight() int {
+	return max(1, p.height-3)
+}
+
+// indexTo scans until the start of line n is known or the end is reached.
+func (p *pager) indexTo(n int) {
+	var buf []byte
+	for p.newlines < n && p.indexed < p.size {
+		if buf == nil {
+			buf = make([]byte, pagerChunk)
+		}
+		k, _ := p.r.ReadAt(buf[:min(int64(len(buf)), p.size-p.indexed)], p.indexed)
+		if k == 0 {
+			return
+		}
+		for i, c := range buf[:k] {
+			if c == '\n' {
+				p.newlines++
+				if p.newlines%pagerIndexStride == 0 {
+					p.index = append(p.index, p.indexed+int64(i)+1)
+				}
+			}
+		}
+		p.lastByte = buf[k-1]
+		p.indexed += int64(k)
+	}
+}
+
+// lineCount returns the number of lines indexed so far; it is the total
+// once the index reaches the end.
+func (p *pager) lineCount() int {
+	if p.indexed > 0 && p.lastByte != '\n' {
+		return p.newlines + 1
+	}
+	return p.newlines
+}
+
+// complete reports whether the whole file is indexed.
+func (p *pager) complete() bool {
+	return p.indexed >= p.size
+}
+
+// scan calls fn for each line from line from on, until fn returns false or
+// the end is reached.
+func (p *pager) scan(from int, fn func(n int, line string) bool) {
+	p.indexTo(from)
+	mark := from / pagerIndexStride
+	if mark >= len(p.index) {
+		return
+	}
+	off := p.index[mark]
+	br := bufio.NewReaderSize(io.NewSectionReader(p.r, off, p.size-off), pagerChunk)
+	for n := mark * pagerIndexStride; ; n++ {
+		line, ok := readPagerLine(br)
+		if !ok {
+			return
+		}
+		if n >= from && !fn(n, line) {
+			return
+		}
+	}
+}
+
+// readPagerLine reads one line, keeping at most pagerMaxLine bytes of it.
+func readPagerLine(br *bufio.Reader) (string, bool) {
+	var line []byte
+	read := false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if room := pagerMaxLine - len(line); room > 0 {
+			line = append(line, chunk[:min(len(chunk), room)]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && !read {
+			return "", false
+		}
+		return strings.TrimRight(string(line), "\r\n"), true
+	}
+}
+
+// lines returns up to n lines starting at line from.
+func (p *pager) lines(from, n int) []string {
+	var out []string
+	p.scan(from, func(_ int, line string) bool {
+		out = append(out, line)
+		return len(out) < n
+	})
+	return out
+}
+
+// maxTop is the last top line that still fills the view. Until the index
+// reaches the end it is a lower bound.
+func (p *pager) maxTop() int {
+	return max(0, p.lineCount()-p.bodyHeight())
+}
+
+// ScrollBy moves the view by delta lines. Scrolling up stops following.
+func (p *pager) ScrollBy(delta int) {
+	if delta < 0 {
+		p.follow = false
+	}
+	top := max(0, p.top+delta)
+	p.indexTo(top + p.bodyHeight())
+	p.top = min(top, p.maxTop())
+}
+
+// GotoTop shows the first page and stops following.
+func (p *pager) GotoTop() {
+	p.follow = false
+	p.top = 0
+}
+
+// GotoBottom shows the last page; it indexes the whole file.
+func (p *pager) GotoBottom() {
+	p.indexTo(math.MaxInt)
+	p.top = p.maxTop()
+}
+
+// ToggleFollow turns follow mode on (jumping to the end) or off. Text
+// pagers can't follow.
+func (p *pager) ToggleFollow() bool {
+	if p.file == nil {
+		return false
+	}
+	p.follow = !p.follow
+	if p.follow {
+		p.GotoBottom()
+	}
+	return true
+}
+
+// Refresh picks up a file that grew (or was truncated) since it was
+// opened, and keeps the end in view while following.
+func (p *pager) Refresh() {
+	if p.file == nil {
+		return
+	}
+	info, err := p.file.Stat()
+	if err != nil {
+		return
+	}
+	switch size := info.Size(); {
+	case size < p.size:
+		// Rewritten: start the index over.
+		p.size = size
+		p.index, p.indexed, p.newlines, p.lastByte = []int64{0}, 0, 0, 0
+		p.top = min(p.top, p.maxTop())
+	case size > p.size:
+		p.size = size
+	}
+	if p.follow {
+		p.GotoBottom()
+	}
+}
+
+// Search finds query (case-insensitively) from the top line on, wrapping
+// around, and scrolls to it. It reports whether there was a match.
+func (p *pager) Search(query string) bool {
+	p.query = query
+	p.match = -1
+	if query == "" {
+		return false
+	}
+	return p.findFrom(p.top, 1)
+}
+
+// Next moves to the next match (dir 1) or the previous one (dir -1).
+func (p *pager) Next(dir int) bool {
+	if p.query == "" {
+		return false
+	}
+	from := p.top
+	if p.match >= 0 {
+		from = p.match + dir
+	}
+	return p.findFrom(from, dir)
+}
+
+func (p *pager) findFrom(from, dir int) bool {
+	q := strings.ToLower(p.query)
+	hit := func(line string) bool { return strings.Contains(strings.ToLower(line), q) }
+	found := -1
+	if dir > 0 {
+		p.scan(max(0, from), func(n int, line string) bool {
+			if hit(line) {
+				found = n
+			}
+			return found < 0
+		})
+		if found < 0 {
+			p.scan(0, func(n int, line string) bool {
+				if n >= from {
+					return false
+				}
+				if hit(line) {
+					found = n
+				}
+				return found < 0
+			})
+		}
+	} else {
+		// Backwards: the last match before from, else the last one after.
+		last := -1
+		p.scan(0, func(n int, line string) bool {
+			if hit(line) {
+				if n <= from {
+					found = n
+				}
+				last = n
+			}
+			return true
+		})
+		if found < 0 {
+			found = last
+		}
+	}
+	if found < 0 {
+		return false
+	}
+	p.follow = false
+	p.match = found
+	// A third of the way down, so the context above shows.
+	p.top = max(0, found-p.bodyHeight()/3)
+	p.indexTo(p.top + p.bodyHeight())
+	p.top = min(p.top, p.maxTop())
+	return true
+}
+
+// Position describes where the view is, e.g. "lines 1-40 of 12034" or
+// "lines 1-40 of 5000+" while the end hasn't been indexed.
+func (p *pager) Position() string {
+	total := fmt.Sprintf("%d", p.lineCount())
+	if !p.complete() {
+		total += "+"
+	}
+	last := min(p.top+p.bodyHeight(), p.lineCount())
+	pos := fmt.Sprintf("lines %d-%d of %s", min(p.top+1, last), last, total)
+	if p.follow {
+		pos += " · following"
+	}
+	return pos
+}
+
+func (p *pager) View() string {
+	innerWidth := max(1, p.width-4)
+	innerHeight := max(1, p.height-2)
+
+	lines := []string{statsHeaderStyle.Render(runewidth.Truncate(p.title, innerWidth, "…"))}
+	q := strings.ToLower(p.query)
+	for _, line := range p.lines(p.top, p.bodyHeight()) {
+		// Tool output can carry tabs and terminal escapes; neither may
+		// leak into the layout.
+		line = runewidth.Truncate(strings.ReplaceAll(stripAnsi(line), "\t", "    "), innerWidth, "…")
+		lines = append(lines, highlightMatches(line, q))
+	}
+	if p.size == 0 {
+		lines = append(lines, mutedStyle.Render("(empty)"))
+	}
+	return padLines(lines, innerHeight)
+}
+
+// highlightMatches marks case-insensitive occurrences of q in line. Lines
+// whose lowercase form changes length (rare scripts) are left as is.
+func highlightMatches(line, q string) string {
+	if q == "" {
+		return line
+	}
+	lower := strings.ToLower(line)
+	if len(lower) != len(line) || !strings.Contains(lower, q) {
+		return line
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, q)
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		b.WriteString(pagerMatchStyle.Render(line[i : i+len(q)]))
+		line, lower = line[i+len(q):], lower[i+len(q):]
+	}
+}
+
+// openPager shows p in place of the stream pane.
+func (m *Model) openPager(p *pager) {
+	m.closePager()
+	m.pager = p
+	m.focus = FocusStream
+	m.updateLayout()
+}
+
+func (m *Model) closePager() {
+	if m.pager != nil {
+		m.pager.Close()
+		m.pager = nil
+	}
+}
+
+// openNodeFile pages a background task's output or an artifact.
+func (m *Model) openNodeFile(node *TreeNode) {
+	if node.OutputPath == "" {
+		return
+	}
+	icon := "⏳"
+	switch {
+	case node.Type == NodeTypeArtifact:
+		icon = "📎"
+	case node.IsComplete:
+		icon = "✓"
+	}
+	p, err := newFilePager(icon+" "+node.Name, expandHome(node.OutputPath))
+	if err != nil {
+		m.status = fmt.Sprintf("open: %v", err)
+		return
+	}
+	m.openPager(p)
+}
+
+// openItemDetail pages the selected item in full: the saved file when its
+// output was too large to inline, its whole content otherwise.
+func (m *Model) openItemDetail() {
+	item, ok := m.stream.SelectedItem()
+	if !ok {
+		m.status = "select an item (J/K) to view it in full"
+		return
+	}
+	title := item.AgentName + " » " + string(item.Type)
+	if item.ToolName != "" {
+		title = item.AgentName + " » " + item.ToolName
+	}
+	for _, path := range item.Artifacts {
+		if strings.Contains(path, "/tool-results/") {
+			if p, err := newFilePager(title, expandHome(path)); err == nil {
+				m.openPager(p)
+				return
+			}
+		}
+	}
+	m.openPager(newTextPager(title, item.Content))
+}
+
+// pagerKey handles a key while the pager is open; other keys fall through
+// to the usual bindings.
+func (m *Model) pagerKey(key string) bool {
+	p := m.pager
+	switch key {
+	case "q", "esc":
+		m.closePager()
+	case "j", "down":
+		p.ScrollBy(1)
+	case "k", "up":
+		p.ScrollBy(-1)
+	case "ctrl+d":
+		p.ScrollBy(p.bodyHeight() / 2)
+	case "ctrl+u":
+		p.ScrollBy(-p.bodyHeight() / 2)
+	case "ctrl+f", "pgdown", " ":
+		p.ScrollBy(p.bodyHeight())
+	case "ctrl+b", "pgup", "b":
+		p.ScrollBy(-p.bodyHeight())
+	case "g", "home":
+		p.GotoTop()
+	case "G", "end":
+		p.GotoBottom()
+	case "F":
+		if !p.ToggleFollow() {
+			m.status = "only files can be followed"
+		}
+	case "/":
+		m.openPrompt("/", p.query, func(query string) {
+			if m.pager != nil && !m.pager.Search(query) && query != "" {
+				m.status = fmt.Sprintf("not found: %s", query)
+			}
+		})
+		m.prompt.SetCompletion(m.completeSearch)
+		m.remember("search")
+	case "n", "N":
+		dir := 1
+		if key == "N" {
+			dir = -1
+		}
+		if !p.Next(dir) {
+			m.status = "no matches"
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLines(t *testing.T, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "toolu_01.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPagerReadsLazily(t *testing.T) {
+	path := writeLines(t, 10000)
+	p, err := newFilePager("out", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.SetSize(40, 13) // 10 body rows
+
+	if got := p.lines(0, 2); len(got) != 2 || got[1] != "line 1" {
+		t.Fatalf("lines(0, 2) = %q", got)
+	}
+	if p.complete() {
+		t.Error("first page indexed the whole file")
+	}
+	if got := p.lines(5000, 1); len(got) != 1 || got[0] != "line 5000" {
+		t.Errorf("lines(5000, 1) = %q", got)
+	}
+
+	p.GotoBottom()
+	if p.top != 9990 || p.Position() != "lines 9991-10000 of 10000" {
+		t.Errorf("bottom: top %d, %s", p.top, p.Position())
+	}
+	p.ScrollBy(5)
+	if p.top != 9990 {
+		t.Errorf("scrolled past the end: top %d", p.top)
+	}
+}
+
+func TestPagerSearch(t *testing.T) {
+	p := newTextPager("item", "alpha\nBeta\ngamma\nbeta again\n")
+	p.SetSize(40, 5)
+	if !p.Search("beta") || p.match != 1 {
+		t.Fatalf("match = %d", p.match)
+	}
+	if !p.Next(1) || p.match != 3 {
+		t.Errorf("next: match = %d", p.match)
+	}
+	if !p.Next(1) || p.match != 1 {
+		t.Errorf("next didn't wrap: match = %d", p.match)
+	}
+	if !p.Next(-1) || p.match != 3 {
+		t.Errorf("prev didn't wrap: match = %d", p.match)
+	}
+	if p.Search("delta") {
+		t.Error("found a missing string")
+	}
+}
+
+func TestPagerFollow(t *testing.T) {
+	path := writeLines(t, 20)
+	p, err := newFilePager("out", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.SetSize(40, 8) // 5 body rows
+	if !p.ToggleFollow() || p.top != 15 {
+		t.Fatalf("follow: top %d", p.top)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("line 20\nline 21\n")
+	f.Close()
+	p.Refresh()
+	if p.top != 17 || !strings.Contains(p.View(), "line 21") {
+		t.Errorf("didn't follow the new lines: top %d", p.top)
+	}
+
+	if newTextPager("item", "x").ToggleFollow() {
+		t.Error("text pager followed")
+	}
+}
+
+func TestPagerLongLines(t *testing.T) {
+	p := newTextPager("item", strings.Repeat("x", 3*pagerChunk)+"\nnext\n")
+	if got := p.lines(0, 2); len(got) != 2 || len(got[0]) != pagerMaxLine || got[1] != "next" {
+		t.Errorf("long line: %d lines, first %d bytes", len(got), len(got[0]))
+	}
+}
+
+func TestPagerOpensFromTree(t *testing.T) {
+	m := treeModel(t)
+	path := writeLines(t, 100)
+	m.tree.AddBackgroundTask("s1", "", "toolu_01", "Bash: make", path, true)
+	m.tree.MoveTo(2) // s1 > Main > task
+	m.Update(key(" "))
+	if m.pager == nil || m.focus != FocusStream {
+		t.Fatal("enter on a background task didn't open the pager")
+	}
+	m.Update(key("G"))
+	if m.pager.top == 0 {
+		t.Error("G not handled by the pager")
+	}
+	m.Update(key("q"))
+	if m.pager != nil || m.quitting {
+		t.Error("q should close the pager, not quit")
+	}
+}
@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+// paletteCommand is a command run from the ':' palette as "name [arg]".
+// Without an argument a command reports its current value.
+type paletteCommand struct {
+	name string
+	args string // argument hint for "help"
+	run  func(m *Model, arg string) (status string, err error)
+}
+
+var paletteCommands = []paletteCommand{
+	{"poll-interval", "<dur>", (*Model).setPollInterval},
+	{"active-window", "<dur>", (*Model).setActiveWindow},
+	{"activity-threshold", "<dur>", (*Model).setActivityThreshold},
+	{"recap", "<dur>", (*Model).setRecapWindow},
+	{"low-power", "on|off|toggle", (*Model).setLowPowerMode},
+	{"type-gutter", "on|off|toggle", (*Model).setTypeGutter},
+	{"queues", "on|off|toggle", (*Model).setQueues},
+	{"ascii", "on|off|toggle", (*Model).setASCII},
+	{"filter", "<expr>", (*Model).setFilter},
+	{"focus-follows", "on|off|toggle", (*Model).setFocusFollows},
+	{"unignore", "all|<id>", (*Model).unignore},
+	{"snooze", "<dur>", (*Model).snooze},
+	{"goto", "<id>", (*Model).gotoItem},
+	{"restart", "", (*Model).restart},
+}
+
+func (m *Model) openPalette() {
+	m.openPrompt(":", "", m.runPalette)
+	m.prompt.SetCompletion(m.completePalette)
+	m.remember("palette")
+}
+
+// runPalette executes one palette line, reporting the outcome in the help
+// bar.
+func (m *Model) runPalette(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "":
+		return
+	case "help", "?":
+		var usage []string
+		for _, c := range paletteCommands {
+			usage = append(usage, c.name+" "+c.args)
+		}
+		m.status = strings.Join(usage, " │ ")
+		return
+	}
+	for _, c := range paletteCommands {
+		if c.name != name {
+			continue
+		}
+		status, err := c.run(m, arg)
+		if err != nil {
+			m.status = fmt.Sprintf("%s: %v", name, err)
+			return
+		}
+		m.status = status
+		return
+	}
+	m.status = fmt.Sprintf("unknown command %q (try help)", name)
+}
+
+func parsePaletteDuration(arg string) (time.Duration, error) {
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("want a positive duration like 30s or 5m, got %q", arg)
+	}
+	return d, nil
+}
+
+func (m *Model) setPollInterval(arg string) (string, error) {
+	if arg != "" {
+		d, err := parsePaletteDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		if err := (config.Watch{PollInterval: d}).Validate(); err != nil {
+			return "", err
+		}
+		m.pollInterval = d
+		if m.watcher != nil {
+			m.watcher.SetPollInterval(m.effectivePollInterval())
+		}
+	}
+	status := "poll-interval " + m.pollInterval.String()
+	if m.lowPower {
+		status += fmt.Sprintf(" (%s in low-power mode)", m.effectivePollInterval())
+	}
+	if m.watcher != nil && m.watcher.UsingFsnotify() {
+		status += " (unused: fsnotify is active)"
+	}
+	return status, nil
+}
+
+func (m *Model) setActiveWindow(arg string) (string, error) {
+	if arg != "" {
+		d, err := parsePaletteDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		m.activeWindow = d
+		if m.watcher != nil {
+			m.watcher.SetActiveWindow(d)
+		}
+	}
+	return "active-window " + m.activeWindow.String(), nil
+}
+
+func (m *Model) setActivityThreshold(arg string) (string, error) {
+	if arg != "" {
+		d, err := parsePaletteDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		m.activityThreshold = d
+		m.updateActivityStatus()
+	}
+	return "activity-threshold " + m.activityThreshold.String(), nil
+}
+
+// setRecapWindow sets how far back the recap looks and shows it.
+func (m *Model) setRecapWindow(arg string) (string, error) {
+	if arg != "" {
+		d, err := parsePaletteDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		if err := m.recap.SetWindow(d); err != nil {
+			return "", err
+		}
+	}
+	m.hidePanels()
+	m.showRecap = true
+	return "recap " + formatWindow(m.recap.Window()), nil
+}
+
+func (m *Model) setLowPowerMode(arg string) (string, error) {
+	switch arg {
+	case "on":
+		m.setLowPower(true)
+	case "off":
+		m.setLowPower(false)
+	case "toggle":
+		m.setLowPower(!m.lowPower)
+	case "":
+	default:
+		return "", fmt.Errorf("want on, off or toggle, got %q", arg)
+	}
+	if m.lowPower {
+		return "low-power on", nil
+	}
+	return "low-power off", nil
+}
+
+// setTypeGutter shows or hides the stream's column of item type glyphs.
+func (m *Model) setTypeGutter(arg string) (string, error) {
+	switch arg {
+	case "on":
+		m.stream.SetTypeGutter(true)
+	case "off":
+		m.stream.SetTypeGutter(false)
+	case "toggle":
+		m.stream.SetTypeGutter(!m.stream.TypeGutter())
+	case "":
+	default:
+		return "", fmt.Errorf("want on, off or toggle, got %q", arg)
+	}
+	if m.stream.TypeGutter() {
+		return "type-gutter on", nil
+	}
+	return "type-gutter off", nil
+}
+
+// unignore lets sessions hidden with D be discovered again. Without an
+// argument it lists them.
+func (m *Model) unignore(arg string) (string, error) {
+	if arg == "" {
+		if len(m.ignored) == 0 {
+			return "no hidden sessions", nil
+		}
+		ids := make([]string, len(m.ignored))
+		for i, id := range m.ignored {
+			ids[i] = truncate(id, 8)
+		}
+		return "hidden: " + strings.Join(ids, " "), nil
+	}
+	var kept, freed []string
+	for _, id := range m.ignored {
+		if arg == "all" || strings.HasPrefix(id, arg) {
+			freed = append(freed, id)
+		} else {
+			kept = append(kept, id)
+		}
+	}
+	if len(freed) == 0 {
+		return "", fmt.Errorf("no hidden session matches %q", arg)
+	}
+	m.ignored = kept
+	if m.watcher != nil {
+		for _, id := range freed {
+			m.watcher.ForgetRemoved(id)
+		}
+	}
+	return fmt.Sprintf("unignored %d sessions; they show up again when next active", len(freed)), nil
+}
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaletteSetsTimings(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+
+	m.runPalette("activity-threshold 1m")
+	if m.activityThreshold != time.Minute || m.status != "activity-threshold 1m0s" {
+		t.Errorf("threshold = %v, status %q", m.activityThreshold, m.status)
+	}
+	m.runPalette("  active-window   10m ")
+	if m.activeWindow != 10*time.Minute {
+		t.Errorf("activeWindow = %v", m.activeWindow)
+	}
+	m.runPalette("poll-interval")
+	if m.status != "poll-interval 500ms" {
+		t.Errorf("bare command should report the value, got %q", m.status)
+	}
+}
+
+func TestPaletteRejectsBadInput(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+
+	for _, line := range []string{"poll-interval 10ms", "poll-interval soon", "active-window -1m"} {
+		m.runPalette(line)
+		if !strings.HasPrefix(m.status, strings.Fields(line)[0]+": ") {
+			t.Errorf("%q: status %q, want an error", line, m.status)
+		}
+	}
+	if m.pollInterval != 500*time.Millisecond || m.activeWindow != 5*time.Minute {
+		t.Errorf("rejected input changed settings: %v %v", m.pollInterval, m.activeWindow)
+	}
+	m.runPalette("bogus")
+	if !strings.Contains(m.status, "unknown command") {
+		t.Errorf("status = %q", m.status)
+	}
+}
@@ -0,0 +1,29 @@
+package tui
+
+import "slices"
+
+// pane is what the side panes (timeline, stats, recap, long runs, tests,
+// files) share: their size and the agents they cover, both set by the
+// model's layout.
+type pane struct {
+	width          int
+	height         int
+	enabledFilters []EnabledFilter
+}
+
+// SetSize sets the pane's outer size: like StreamView's, it includes the
+// border, which the pane draws inside.
+func (p *pane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// SetEnabledFilters restricts the pane to the agents enabled in the tree.
+func (p *pane) SetEnabledFilters(filters []EnabledFilter) {
+	p.enabledFilters = filters
+}
+
+// enabled reports whether the agent is enabled in the tree.
+func (p *pane) enabled(sessionID, agentID string) bool {
+	return slices.Contains(p.enabledFilters, EnabledFilter{sessionID, agentID})
+}
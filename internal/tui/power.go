@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Low-power mode cuts wakeups: the watcher polls less often, items are
+// received by a blocking wait instead of the 100ms tick, activity stat()s
+// run on a slow cadence, and while the terminal reports it is unfocused the
+// tick stops altogether. The blocking wait stays armed throughout, so a new
+// event is shown as soon as it arrives.
+const (
+	normalTick              = 100 * time.Millisecond
+	lowPowerTick            = time.Second
+	lowPowerPollInterval    = 2 * time.Second
+	lowPowerActivityRefresh = 10 * time.Second
+)
+
+// watcherMsg wraps a message received by waitWatcher.
+type watcherMsg struct {
+	from *watcherWait
+	msg  tea.Msg
+}
+
+// watcherWait is an outstanding waitWatcher. What it receives is kept in
+// msg as well as returned, so cancelWait can take it back even after the
+// program has queued it.
+type watcherWait struct {
+	stop chan struct{}
+	done chan struct{}
+	msg  tea.Msg
+}
+
+// waitWatcher blocks until the watcher has something to report. At most one
+// wait is outstanding.
+func (m *Model) waitWatcher() tea.Cmd {
+	if m.watcher == nil || m.wait != nil {
+		return nil
+	}
+	wt := &watcherWait{stop: make(chan struct{}), done: make(chan struct{})}
+	m.wait = wt
+	w := m.watcher
+	return func() tea.Msg {
+		defer close(wt.done)
+		select {
+		case item := <-w.Items:
+			wt.msg = drainItems(w.Items, item)
+		case agent := <-w.NewAgent:
+			wt.msg = newAgentMsg(agent)
+		case session := <-w.NewSession:
+			wt.msg = newSessionMsg(session)
+		case task := <-w.NewBackgroundTask:
+			wt.msg = newBackgroundTaskMsg(task)
+		case todos := <-w.Todos:
+			wt.msg = todosMsg(todos)
+		case err := <-w.Errors:
+			wt.msg = errMsg(err)
+		case warning := <-w.Warnings:
+			wt.msg = watcherWarningMsg(warning)
+		case fe := <-w.FileErrors:
+			wt.msg = fileErrorMsg(fe)
+		case <-wt.stop:
+			return nil
+		}
+		return watcherMsg{from: wt, msg: wt.msg}
+	}
+}
+
+// cancelWait ends the outstanding wait, if any, and adds the items it
+// took off the watcher; its watcherMsg, if already queued, is ignored.
+// Anything else it took is dropped: restart, its caller, is quitting.
+func (m *Model) cancelWait() {
+	wt := m.wait
+	if wt == nil {
+		return
+	}
+	m.wait = nil
+	close(wt.stop)
+	<-wt.done
+	if batch, ok := wt.msg.(streamItemsMsg); ok {
+		m.addItems(batch)
+	}
+}
+
+// effectivePollInterval is the watcher poll interval for the current mode.
+func (m *Model) effectivePollInterval() time.Duration {
+	if m.lowPower {
+		return max(m.pollInterval, lowPowerPollInterval)
+	}
+	return m.pollInterval
+}
+
+func (m *Model) setLowPower(on bool) {
+	m.lowPower = on
+	if m.watcher != nil {
+		m.watcher.SetPollInterval(m.effectivePollInterval())
+	}
+}
+
+// schedule re-arms the tick and the blocking wait as the mode requires. It
+// runs after every Update, so mode and focus changes take effect from
+// whatever message caused them.
+func (m *Model) schedule() tea.Cmd {
+	var cmds []tea.Cmd
+	if !m.ticking && !(m.lowPower && m.blurred) {
+		m.ticking = true
+		cmds = append(cmds, m.tick())
+	}
+	if m.lowPower {
+		cmds = append(cmds, m.rearmWait())
+	}
+	return tea.Batch(cmds...)
+}
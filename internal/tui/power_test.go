@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+func lowPowerModel(t *testing.T) *Model {
+	t.Helper()
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	cfg := config.Default()
+	cfg.Watch.LowPower = true
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, cfg)
+	m.Init()
+	return m
+}
+
+func TestLowPowerPausesWhileBlurred(t *testing.T) {
+	m := lowPowerModel(t)
+	if got := m.effectivePollInterval(); got != lowPowerPollInterval {
+		t.Errorf("poll interval = %v, want %v", got, lowPowerPollInterval)
+	}
+
+	m.Update(tea.BlurMsg{})
+	m.Update(tickMsg(time.Now()))
+	if m.ticking {
+		t.Error("still ticking while blurred in low-power mode")
+	}
+	m.Update(tea.FocusMsg{})
+	if !m.ticking {
+		t.Error("focus did not resume ticking")
+	}
+}
+
+func TestLeavingLowPowerResumesTicking(t *testing.T) {
+	m := lowPowerModel(t)
+	m.Update(tea.BlurMsg{})
+	m.Update(tickMsg(time.Now()))
+
+	m.runPalette("low-power off")
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}) // any message reschedules
+	if !m.ticking {
+		t.Error("turning low-power off while blurred should resume ticking")
+	}
+	if got := m.effectivePollInterval(); got != 500*time.Millisecond {
+		t.Errorf("poll interval = %v, want the configured 500ms", got)
+	}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// presetDelay is how long a lone digit waits for the rest of a count or
+// a motion ("3j") before it picks the view preset of that number.
+const presetDelay = 500 * time.Millisecond
+
+// presetKeyMsg fires presetDelay after digit n was typed at key time at.
+type presetKeyMsg struct {
+	n  int
+	at time.Time
+}
+
+// presetKey arms the preset pick for digit n, typed as the first key of
+// a count: 1–4 pick the default presets.
+func (m *Model) presetKey(n int) tea.Cmd {
+	at := m.lastKey
+	return tea.Tick(presetDelay, func(time.Time) tea.Msg { return presetKeyMsg{n, at} })
+}
+
+// firePresetKey picks the preset if no key came after its digit; one
+// that did made the digit part of a count.
+func (m *Model) firePresetKey(msg presetKeyMsg) {
+	if !m.lastKey.Equal(msg.at) || m.count != msg.n {
+		return
+	}
+	m.takeCount()
+	m.applyPreset(msg.n)
+}
+
+// applyPreset sets the stream's item toggles to view preset n (from 1),
+// or with n = 0 to the preset after the last one applied. Presets come
+// from [view] presets in the config, or config.DefaultPresets.
+func (m *Model) applyPreset(n int) {
+	if len(m.presets) == 0 {
+		return
+	}
+	if n == 0 {
+		n = m.preset%len(m.presets) + 1
+	}
+	if n > len(m.presets) {
+		m.status = fmt.Sprintf("no preset %d (there are %d)", n, len(m.presets))
+		return
+	}
+	p := m.presets[n-1]
+	m.stream.Hold()
+	m.stream.SetToggles(
+		slices.Contains(p.Show, "thinking"),
+		slices.Contains(p.Show, "tool_input"),
+		slices.Contains(p.Show, "tool_output"),
+		slices.Contains(p.Show, "text"),
+		slices.Contains(p.Show, "hooks"),
+	)
+	m.stream.SetStderrOnly(p.StderrOnly)
+	m.stream.SetHighlights(false)
+	m.stream.Release()
+	m.preset = n
+	m.status = fmt.Sprintf("preset %d/%d: %s", n, len(m.presets), p.Name)
+	if p.NewAgents != "" {
+		m.status += " · new agents " + p.NewAgents
+	}
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+func TestApplyPreset(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+
+	toggles := func() [6]bool {
+		s := m.stream
+		return [6]bool{s.IsThinkingEnabled(), s.IsToolInputEnabled(), s.IsToolOutputEnabled(), s.IsTextEnabled(), s.IsHooksEnabled(), s.IsStderrOnly()}
+	}
+
+	m.Update(key("3"))
+	m.Update(key("p"))
+	if got, want := toggles(), [6]bool{false, false, true, false, false, true}; got != want {
+		t.Errorf("3p (errors): toggles = %v, want %v", got, want)
+	}
+	m.Update(key("p"))
+	if got, want := toggles(), [6]bool{true, true, true, true, true, false}; got != want {
+		t.Errorf("p after 3 (all): toggles = %v, want %v", got, want)
+	}
+	m.Update(key("p"))
+	if got, want := toggles(), [6]bool{true, false, false, false, false, false}; got != want {
+		t.Errorf("p wraps to 1 (thinking): toggles = %v, want %v", got, want)
+	}
+
+	m.Update(key("9"))
+	m.Update(key("p"))
+	if m.preset != 1 || m.status != "no preset 9 (there are 4)" {
+		t.Errorf("9p: preset %d, status %q", m.preset, m.status)
+	}
+
+	m.presets = []config.Preset{{Name: "text", Show: []string{"text"}}}
+	m.preset = 0
+	m.Update(key("p"))
+	if got, want := toggles(), [6]bool{false, false, false, true, false, false}; got != want || m.status != "preset 1/1: text" {
+		t.Errorf("configured preset: toggles = %v, status %q", got, m.status)
+	}
+}
+
+func TestDigitPicksPreset(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+
+	// A lone 2, once no motion follows it, picks preset 2.
+	if _, cmd := m.Update(key("2")); cmd == nil {
+		t.Fatal("2 armed no preset pick")
+	}
+	m.Update(presetKeyMsg{2, m.lastKey})
+	if m.preset != 2 || m.count != 0 {
+		t.Errorf("2: preset %d, count %d", m.preset, m.count)
+	}
+
+	// 3j is a count: the pick armed by the 3 is dropped.
+	m.Update(key("3"))
+	armed := presetKeyMsg{3, m.lastKey}
+	m.Update(key("j"))
+	m.Update(armed)
+	if m.preset != 2 {
+		t.Errorf("3j picked preset %d", m.preset)
+	}
+
+	// Digits beyond the presets are only counts.
+	if _, cmd := m.Update(key("7")); cmd != nil {
+		t.Error("7 armed a pick with 4 presets")
+	}
+}
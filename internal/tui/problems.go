@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/phiat/claude-esp/internal/buildlog"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// problemsBadge is the colored chip after a Bash result's header:
+// "✗ go build 2 errors, 1 warning".
+func problemsBadge(r buildlog.Result) string {
+	style := buildErrorStyle
+	if r.Errors() == 0 {
+		style = buildWarningStyle
+	}
+	return style.Render(fmt.Sprintf("✗ %s %s", r.Tool, r.Summary()))
+}
+
+// highlightRefs renders text in style with refs (build error locations
+// like "main.go:12:5", paths) in linkStyle, and current, the one under the
+// path cursor, in currentLinkStyle. Each line is rendered on its own so
+// the styles don't nest.
+func highlightRefs(text string, style lipgloss.Style, refs []string, current string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var b strings.Builder
+		for line != "" {
+			at, ref := -1, ""
+			for _, r := range refs {
+				j := strings.Index(line, r)
+				if j >= 0 && (at < 0 || j < at || j == at && len(r) > len(ref)) {
+					at, ref = j, r
+				}
+			}
+			if at < 0 {
+				b.WriteString(style.Render(line))
+				break
+			}
+			if at > 0 {
+				b.WriteString(style.Render(line[:at]))
+			}
+			if ref == current {
+				b.WriteString(currentLinkStyle.Render(ref))
+			} else {
+				b.WriteString(linkStyle.Render(ref))
+			}
+			line = line[at+len(ref):]
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SelectedProblems returns the build errors read from the selected item
+// and the directories their paths may be relative to: where the command
+// ran, then where it left the shell.
+func (s *StreamView) SelectedProblems() (buildlog.Result, []string, bool) {
+	item, ok := s.SelectedItem()
+	if !ok {
+		return buildlog.Result{}, nil, false
+	}
+	res, ok := s.problems[item.ToolID]
+	if !ok || item.Type != parser.TypeToolOutput {
+		return buildlog.Result{}, nil, false
+	}
+	return res, s.itemDirs(item), true
+}
+
+// itemDirs returns the directories relative paths in a result may be
+// under: where its call ran, then where it left the shell.
+func (s *StreamView) itemDirs(item parser.StreamItem) []string {
+	var dirs []string
+	for _, other := range s.items {
+		if item.ToolID != "" && other.Type == parser.TypeToolInput && other.ToolID == item.ToolID && other.Cwd != "" {
+			dirs = append(dirs, other.Cwd)
+			break
+		}
+	}
+	if item.Cwd != "" && !slices.Contains(dirs, item.Cwd) {
+		dirs = append(dirs, item.Cwd)
+	}
+	return dirs
+}
+
+// openProblem opens the nth (1-based; 0 is the first) build error of the
+// selected item in $EDITOR at its line.
+func (m *Model) openProblem(n int) tea.Cmd {
+	res, dirs, ok := m.stream.SelectedProblems()
+	if !ok {
+		m.status = "select a result with build errors (J/K) to open them"
+		return nil
+	}
+	n = max(1, n)
+	if n > len(res.Problems) {
+		m.status = fmt.Sprintf("%s has %d problems", res.Tool, len(res.Problems))
+		return nil
+	}
+	p := res.Problems[n-1]
+	path, err := resolveProblemPath(p.Path, dirs)
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	m.status = fmt.Sprintf("%s (%d/%d): %s", p.Ref, n, len(res.Problems), p.Message)
+	return m.openInEditorAt(path, p.Line, p.Col)
+}
+
+// resolveProblemPath finds a problem's file: as is when absolute, else
+// under the first of dirs that has it.
+func resolveProblemPath(path string, dirs []string) (string, error) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) && len(dirs) > 0 {
+		candidates = candidates[:0]
+		for _, dir := range dirs {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found", path)
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestBuildProblems(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644)
+
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.tree.AddSession("s1", "home/user/app")
+	m.syncFilters()
+	input, _ := json.Marshal(map[string]string{"command": "go build ./..."})
+	now := time.Now()
+	m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: "b1", ToolInput: input, Content: "go build ./...", Cwd: dir, Timestamp: now})
+	m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolName: "Bash", ToolID: "b1", ExitCode: 1, Cwd: dir, Timestamp: now,
+		Content: "# app\n./main.go:12:5: undefined: foo\n./gone.go:3:1: syntax error\n"})
+
+	if view := m.stream.View(); !strings.Contains(view, "✗ go build 2 errors") || !strings.Contains(view, "./main.go:12:5") {
+		t.Errorf("stream lacks the badge or location:\n%s", view)
+	}
+
+	press := func(keys string) tea.Cmd {
+		var cmd tea.Cmd
+		for _, r := range keys {
+			cmd = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return cmd
+	}
+	m.focus = FocusStream
+	if cmd := press("e"); cmd != nil || !strings.Contains(m.status, "select a result") {
+		t.Errorf("e without a selection: status %q", m.status)
+	}
+	press("K")
+	if cmd := press("e"); cmd == nil || m.status != "./main.go:12:5 (1/2): undefined: foo" {
+		t.Errorf("e: status %q", m.status)
+	}
+	if cmd := press("2e"); cmd != nil || m.status != "./gone.go not found" {
+		t.Errorf("2e: status %q", m.status)
+	}
+	if cmd := press("3e"); cmd != nil || m.status != "go build has 2 problems" {
+		t.Errorf("3e: status %q", m.status)
+	}
+}
+
+func TestEditorLineCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv("VISUAL", "")
+	for editor, want := range map[string]string{
+		"":                     `vi +"$2" "$1"`,
+		"nvim":                 `nvim +"$2" "$1"`,
+		"/usr/bin/code --wait": `/usr/bin/code --wait --goto "$1:$2:$3"`,
+		"hx":                   `hx "$1:$2:$3"`,
+	} {
+		t.Setenv("EDITOR", editor)
+		cmd := editorLineCommand("a b.go", 12, 0)
+		want := []string{"sh", "-c", want, "sh", "a b.go", "12", "1"}
+		if !slices.Equal(cmd.Args, want) {
+			t.Errorf("%q: args = %q, want %q", editor, cmd.Args, want)
+		}
+	}
+}
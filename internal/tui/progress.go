@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+const (
+	// progressIcon leads a running call's progress line.
+	progressIcon = "⏳"
+	// progressLines is how many of its latest output lines a running call
+	// shows.
+	progressLines = 3
+	// progressBarWidth is the width of the percent-done bar.
+	progressBarWidth = 10
+)
+
+// SetProgress records the latest progress of a running tool call, shown
+// under the call until its result arrives. Progress for a call that has
+// already returned is stale and dropped.
+func (s *StreamView) SetProgress(item parser.StreamItem) {
+	if item.ToolID == "" || s.seenToolIDs[item.ToolID+":"+string(parser.TypeToolOutput)] {
+		return
+	}
+	s.progress[item.ToolID] = item
+	s.updateContent()
+}
+
+// renderProgress is a running call's progress: how far along it is and
+// for how long it has run, then its latest output lines.
+func renderProgress(p parser.StreamItem, width int) string {
+	status := []string{progressIcon + " running"}
+	if p.Progress > 0 {
+		filled := int(p.Progress*progressBarWidth + 0.5)
+		status = append(status, fmt.Sprintf("%s %d%%",
+			strings.Repeat("▰", filled)+strings.Repeat("▱", progressBarWidth-filled), int(p.Progress*100)))
+	}
+	if p.DurationMs > 0 {
+		status = append(status, (time.Duration(p.DurationMs) * time.Millisecond).Round(time.Second).String())
+	}
+	lines := []string{toolOutputStyle.Render(runewidth.Truncate(strings.Join(status, " · "), width, "…"))}
+	output := strings.Split(strings.TrimRight(p.Content, "\n"), "\n")
+	if p.Content == "" {
+		output = nil
+	}
+	for _, line := range output[max(0, len(output)-progressLines):] {
+		lines = append(lines, mutedStyle.Render(runewidth.Truncate("  "+line, width, "…")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestToolProgress(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 50})
+	now := time.Now()
+	call := parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", AgentName: "Main", ToolName: "Bash", ToolID: "t1", Content: "go test ./...", Timestamp: now}
+	progress := func(content string, fraction float64) parser.StreamItem {
+		return parser.StreamItem{Type: parser.TypeProgress, SessionID: "s1", AgentName: "Main", ToolID: "t1", ToolName: "bash_progress",
+			Content: content, Progress: fraction, DurationMs: 12_000, Timestamp: now}
+	}
+	m.Update(streamItemsMsg{call, progress("ok  a\nok  b\nok  c\nok  d\n", 0.5)})
+
+	if len(m.stream.items) != 1 {
+		t.Fatalf("progress added as a stream item: %d items", len(m.stream.items))
+	}
+	out := m.stream.renderItem(call, 120)
+	for _, want := range []string{progressIcon + " running", "▰▰▰▰▰▱▱▱▱▱ 50%", "12s", "ok  d"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("running call doesn't show %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "ok  a") {
+		t.Errorf("running call shows more than its latest %d lines:\n%s", progressLines, out)
+	}
+
+	// A later update replaces the earlier one.
+	m.Update(streamItemsMsg{progress("ok  e", 0)})
+	if out := m.stream.renderItem(call, 120); !strings.Contains(out, "ok  e") || strings.Contains(out, "ok  d") || strings.Contains(out, "%") {
+		t.Errorf("later progress didn't replace the earlier:\n%s", out)
+	}
+
+	// The result clears it, and progress arriving late is dropped.
+	m.Update(streamItemsMsg{{Type: parser.TypeToolOutput, SessionID: "s1", AgentName: "Main", ToolID: "t1", Content: "PASS", Timestamp: now}})
+	m.Update(streamItemsMsg{progress("late", 0)})
+	if out := m.stream.renderItem(call, 120); strings.Contains(out, progressIcon) {
+		t.Errorf("finished call still shows progress:\n%s", out)
+	}
+}
@@ -0,0 +1,235 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// prompt is a one-line text input shown in place of the help bar. While it
+// is open it receives every key; enter submits, esc cancels. Editing keys
+// are emacs's (ctrl+a/e/b/f, alt+b/f, ctrl+w/k/u kill and ctrl+y yanks
+// back); up/down or ctrl+p/n recall earlier entries, and tab completes the
+// word before the cursor.
+type prompt struct {
+	input    textinput.Model
+	width    int
+	onSubmit func(value string)
+	onChange func(value string) // optional: called as the value is edited
+	onCancel func()             // optional: called on esc
+
+	history []string // earlier entries, oldest first; see SetHistory
+	recall  int      // entry shown while browsing; len(history) is the draft
+	draft   string   // what was typed before browsing
+
+	complete  func(before string) []string // candidates for the word after before; see SetCompletion
+	matches   []string                     // candidates of an ambiguous tab, shown after the input
+	match     int                          // which of matches tab put in, -1 for none yet
+	wordStart int                          // where the word being completed starts, in runes
+
+	killed []rune // last text killed, for ctrl+y
+}
+
+func newPrompt(label, initial string, onSubmit func(value string)) *prompt {
+	ti := textinput.New()
+	ti.Prompt = label
+	ti.PromptStyle = selectedItemStyle
+	ti.SetValue(initial)
+	ti.CursorEnd()
+	ti.Focus()
+	return &prompt{input: ti, onSubmit: onSubmit}
+}
+
+// Update feeds a key to the prompt. done reports that the prompt closed
+// (submitted or cancelled) and should be dismissed.
+func (p *prompt) Update(msg tea.KeyMsg) (done bool, cmd tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		p.onSubmit(p.input.Value())
+		return true, nil
+	case tea.KeyEsc, tea.KeyCtrlC:
+		if p.onCancel != nil {
+			p.onCancel()
+		}
+		return true, nil
+	}
+	before := p.input.Value()
+	key := msg.String()
+	if key != "tab" {
+		p.matches = nil
+	}
+	switch key {
+	case "tab":
+		p.completeWord()
+	case "up", "ctrl+p":
+		p.recallEntry(-1)
+	case "down", "ctrl+n":
+		p.recallEntry(1)
+	case "ctrl+y":
+		p.yank()
+	default:
+		p.input, cmd = p.input.Update(msg)
+		switch key {
+		case "ctrl+w", "ctrl+k", "ctrl+u", "alt+d", "alt+backspace", "alt+delete":
+			if cut := removed([]rune(before), []rune(p.input.Value())); len(cut) > 0 {
+				p.killed = cut
+			}
+		}
+	}
+	if p.onChange != nil && p.input.Value() != before {
+		p.onChange(p.input.Value())
+	}
+	return false, cmd
+}
+
+// SetHistory gives the prompt earlier entries, oldest first, to recall.
+func (p *prompt) SetHistory(lines []string) {
+	p.history = lines
+	p.recall = len(lines)
+}
+
+// recallEntry moves dir entries through the history, keeping what was
+// typed to come back to past the newest.
+func (p *prompt) recallEntry(dir int) {
+	to := p.recall + dir
+	if to < 0 || to > len(p.history) {
+		return
+	}
+	if p.recall == len(p.history) {
+		p.draft = p.input.Value()
+	}
+	p.recall = to
+	if to == len(p.history) {
+		p.input.SetValue(p.draft)
+	} else {
+		p.input.SetValue(p.history[to])
+	}
+	p.input.CursorEnd()
+}
+
+// SetCompletion makes tab complete the word before the cursor from
+// candidates, which is given the text before that word for context.
+func (p *prompt) SetCompletion(candidates func(before string) []string) {
+	p.complete = candidates
+}
+
+// completeWord is tab: the word before the cursor is extended as far as
+// its candidates agree, and when they don't, they're listed and further
+// tabs cycle through them.
+func (p *prompt) completeWord() {
+	if p.complete == nil {
+		return
+	}
+	if len(p.matches) > 1 {
+		p.match = (p.match + 1) % len(p.matches)
+		p.replaceWord(p.matches[p.match])
+		return
+	}
+	value, pos := []rune(p.input.Value()), p.input.Position()
+	start := pos
+	for start > 0 && !wordBreak(value[start-1]) {
+		start--
+	}
+	word := strings.ToLower(string(value[start:pos]))
+	var matches []string
+	for _, c := range p.complete(string(value[:start])) {
+		if strings.HasPrefix(strings.ToLower(c), word) && !slices.Contains(matches, c) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	p.wordStart = start
+	common := matches[0]
+	for _, c := range matches[1:] {
+		common = commonPrefix(common, c)
+	}
+	if len([]rune(common)) > len([]rune(word)) || len(matches) == 1 {
+		p.replaceWord(common)
+	}
+	if len(matches) > 1 {
+		p.matches, p.match = matches, -1
+	}
+}
+
+// replaceWord puts s in place of the word being completed.
+func (p *prompt) replaceWord(s string) {
+	value, pos := []rune(p.input.Value()), p.input.Position()
+	word := []rune(s)
+	p.input.SetValue(string(value[:p.wordStart]) + s + string(value[pos:]))
+	p.input.SetCursor(p.wordStart + len(word))
+}
+
+// yank is ctrl+y, inserting the last killed text at the cursor.
+func (p *prompt) yank() {
+	if len(p.killed) == 0 {
+		return
+	}
+	value, pos := []rune(p.input.Value()), p.input.Position()
+	p.input.SetValue(string(value[:pos]) + string(p.killed) + string(value[pos:]))
+	p.input.SetCursor(pos + len(p.killed))
+}
+
+// removed is the run of runes deleted from before to leave after.
+func removed(before, after []rune) []rune {
+	if len(after) >= len(before) {
+		return nil
+	}
+	i := 0
+	for i < len(after) && before[i] == after[i] {
+		i++
+	}
+	return before[i : i+len(before)-len(after)]
+}
+
+// wordBreak reports whether r ends a word for completion: spaces, and the
+// punctuation of filter expressions.
+func wordBreak(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`(),=~!"`, r)
+}
+
+// commonPrefix is the longest prefix of a that b shares, ignoring case.
+func commonPrefix(a, b string) string {
+	ar, br := []rune(a), []rune(b)
+	n := 0
+	for n < len(ar) && n < len(br) && unicode.ToLower(ar[n]) == unicode.ToLower(br[n]) {
+		n++
+	}
+	return string(ar[:n])
+}
+
+// SetWidth fits the input to the terminal width.
+func (p *prompt) SetWidth(width int) {
+	p.width = width
+	p.input.Width = max(1, width-lipgloss.Width(p.input.Prompt)-1)
+}
+
+// View is the input, followed by the candidates of an ambiguous tab in
+// up to half the width.
+func (p *prompt) View() string {
+	if len(p.matches) < 2 {
+		return p.input.View()
+	}
+	var list []string
+	room := max(1, p.width/2)
+	for i, c := range p.matches {
+		if room -= lipgloss.Width(c) + 2; room < 0 {
+			list = append(list, mutedStyle.Render("…"))
+			break
+		}
+		if i == p.match {
+			list = append(list, selectedItemStyle.Render(c))
+		} else {
+			list = append(list, mutedStyle.Render(c))
+		}
+	}
+	shown := strings.Join(list, "  ")
+	input := p.input
+	input.Width = max(1, p.width-lipgloss.Width(input.Prompt)-lipgloss.Width(shown)-3)
+	return input.View() + "  " + shown
+}
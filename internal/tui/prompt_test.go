@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestPromptHistory(t *testing.T) {
+	m := treeModel(t)
+	for _, line := range []string{"queues on", "ascii on"} {
+		m.Update(key(":"))
+		m.Update(key(line))
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if got := m.history.Lines("palette"); !slices.Equal(got, []string{"queues on", "ascii on"}) {
+		t.Fatalf("palette history = %q", got)
+	}
+
+	m.Update(key(":"))
+	m.Update(key("low"))
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if v := m.prompt.input.Value(); v != "ascii on" {
+		t.Fatalf("up = %q, want the last entry", v)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP}) // past the oldest: stays
+	if v := m.prompt.input.Value(); v != "queues on" {
+		t.Fatalf("ctrl+p ctrl+p = %q", v)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if v := m.prompt.input.Value(); v != "low" {
+		t.Fatalf("down past the newest = %q, want what was typed", v)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Each prompt has its own history.
+	m.openTreeFilter()
+	m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if v := m.prompt.input.Value(); v != "" {
+		t.Errorf("tree filter recalled %q from the palette", v)
+	}
+}
+
+func TestPromptCompletion(t *testing.T) {
+	m := treeModel(t)
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: "t1"},
+		{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Read", ToolID: "t2"},
+		{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "mcp__github", ToolID: "t3"},
+	})
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	m.Update(key(":"))
+	m.Update(key("foc"))
+	m.Update(tab)
+	if v := m.prompt.input.Value(); v != "focus-follows " {
+		t.Fatalf("command = %q", v)
+	}
+	m.Update(key("to"))
+	m.Update(tab)
+	if v := m.prompt.input.Value(); v != "focus-follows toggle" {
+		t.Fatalf("argument = %q", v)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	m.Update(key(":"))
+	m.Update(key("filter ty"))
+	m.Update(tab)
+	m.Update(key(" = tool_"))
+	m.Update(tab) // tool_input, tool_output: listed, no further
+	if v := m.prompt.input.Value(); v != "filter type = tool_" || len(m.prompt.matches) != 2 {
+		t.Fatalf("ambiguous tab = %q, matches %q", v, m.prompt.matches)
+	}
+	m.Update(tab)
+	m.Update(tab) // cycles
+	if v := m.prompt.input.Value(); v != "filter type = tool_output" {
+		t.Fatalf("cycling = %q", v)
+	}
+	m.Update(key(" and tool in (Bash, r"))
+	m.Update(tab)
+	if v := m.prompt.input.Value(); v != "filter type = tool_output and tool in (Bash, Read" {
+		t.Fatalf("tool = %q", v)
+	}
+	m.Update(key(")"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.status != "filter type = tool_output and tool in (Bash, Read)" || m.stream.filter == nil {
+		t.Fatalf("status %q", m.status)
+	}
+
+	m.openTreeFilter()
+	m.Update(key("Agent"))
+	m.Update(tab)
+	if v := m.prompt.input.Value(); v != "Agent-a1" {
+		t.Errorf("tree filter = %q", v)
+	}
+}
+
+func TestPromptKillYank(t *testing.T) {
+	m := treeModel(t)
+	m.Update(key(":"))
+	m.Update(key("ascii on"))
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	if v := m.prompt.input.Value(); v != "ascii " {
+		t.Fatalf("ctrl+w = %q", v)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if v := m.prompt.input.Value(); v != "onascii " {
+		t.Errorf("ctrl+a ctrl+y = %q", v)
+	}
+}
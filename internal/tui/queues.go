@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// drainStats counts the batches pollWatcher and waitWatcher took off the
+// watcher's Items channel.
+type drainStats struct {
+	batches, items, largest int
+}
+
+func (d *drainStats) add(batch streamItemsMsg) {
+	d.batches++
+	d.items += len(batch)
+	d.largest = max(d.largest, len(batch))
+}
+
+// renderQueues is the debug footer (":queues on"): what went through the
+// watcher's Items and Errors channels, and how the drain took items off.
+// It turns red once anything was dropped.
+//
+//	items 1204 queued · 1201 delivered · 3/100 waiting · 0 dropped │ errors … │ drain 340 batches, 1201 items, largest 57
+func (m *Model) renderQueues() string {
+	if m.watcher == nil {
+		return helpStyle.Render("queues: no watcher")
+	}
+	items, errs := m.watcher.Queues()
+	queue := func(name string, q watcher.QueueStats) string {
+		s := fmt.Sprintf("%s %d queued · %d delivered · %d/%d waiting", name, q.Queued, q.Delivered, q.Depth, q.Cap)
+		if q.Waited > 0 {
+			s += fmt.Sprintf(" · full %d×", q.Waited)
+		}
+		return s + fmt.Sprintf(" · %d dropped", q.Dropped)
+	}
+	line := fmt.Sprintf("%s │ %s │ drain %d batches, %d items, largest %d",
+		queue("items", items), queue("errors", errs), m.drains.batches, m.drains.items, m.drains.largest)
+	if m.width > 0 {
+		line = runewidth.Truncate(line, m.width, "…")
+	}
+	if items.Dropped+errs.Dropped > 0 {
+		return droppedStyle.Render(line)
+	}
+	return helpStyle.Render(line)
+}
+
+// setQueues shows or hides the debug footer with the watcher's queue
+// counters.
+func (m *Model) setQueues(arg string) (string, error) {
+	switch arg {
+	case "on":
+		m.showQueues = true
+	case "off":
+		m.showQueues = false
+	case "toggle":
+		m.showQueues = !m.showQueues
+	case "":
+	default:
+		return "", fmt.Errorf("want on, off or toggle, got %q", arg)
+	}
+	m.updateLayout()
+	if m.showQueues {
+		return "queues on", nil
+	}
+	return "queues off", nil
+}
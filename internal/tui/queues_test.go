@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestQueuesFooter(t *testing.T) {
+	m := treeModel(t)
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	os.WriteFile(path, nil, 0o644)
+	w, err := watcher.OpenFile(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.watcher = w
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	before := m.contentInnerHeight()
+
+	m.runPalette("queues on")
+	if !m.showQueues || m.contentInnerHeight() != before-1 {
+		t.Fatalf("queues on: shown %v, content %d rows (was %d)", m.showQueues, m.contentInnerHeight(), before)
+	}
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1"}, {Type: parser.TypeText, SessionID: "s1"}})
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s1"}})
+	footer := m.renderQueues()
+	for _, want := range []string{"items 0 queued", "0 dropped", "drain 2 batches, 3 items, largest 2"} {
+		if !strings.Contains(footer, want) {
+			t.Errorf("footer lacks %q: %s", want, footer)
+		}
+	}
+	if !strings.Contains(m.View(), "drain 2 batches") {
+		t.Error("footer not in the view")
+	}
+
+	m.runPalette("queues toggle")
+	if m.showQueues || strings.Contains(m.View(), "drain 2 batches") {
+		t.Error("queues toggle didn't hide the footer")
+	}
+}
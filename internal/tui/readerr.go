@@ -0,0 +1,32 @@
+package tui
+
+// readErrorIcon leads a transcript's read error in the tree.
+const readErrorIcon = "⊘"
+
+// SetReadError sets why a Main/Agent node's transcript can't be read, or
+// clears it with "". An agent not in the tree yet gets it when added.
+func (t *TreeView) SetReadError(sessionID, agentID, reason string) {
+	node := t.findAgentNode(sessionID, agentID)
+	if node == nil {
+		if t.pendingReadErrors == nil {
+			t.pendingReadErrors = make(map[EnabledFilter]string)
+		}
+		t.pendingReadErrors[EnabledFilter{sessionID, agentID}] = reason
+		return
+	}
+	node.ReadError = reason
+}
+
+func (t *TreeView) applyPendingReadError(node *TreeNode) {
+	key := EnabledFilter{node.SessionID, node.ID}
+	if reason, ok := t.pendingReadErrors[key]; ok {
+		delete(t.pendingReadErrors, key)
+		node.ReadError = reason
+	}
+}
+
+// readErrorHelp is the help bar's note on a selected node whose transcript
+// can't be read.
+func readErrorHelp(reason string) string {
+	return readErrorIcon + " " + reason + " │ claude-esp doctor explains │ "
+}
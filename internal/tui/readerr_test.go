@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestReadErrorInTree(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	path := "/home/me/.claude/projects/-work-api/s1/subagents/agent-a2.jsonl"
+	denied := watcher.FileErrorMsg{SessionID: "s1", AgentID: "a2", Path: path, Err: &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}}
+
+	// Reported before the agent is in the tree.
+	m.Update(fileErrorMsg(denied))
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a2"})
+	node := m.tree.findAgentNode("s1", "a2")
+	if node == nil || node.ReadError != "permission denied: "+path {
+		t.Fatalf("agent read error = %+v", node)
+	}
+	if !strings.Contains(m.tree.View(), readErrorIcon+" permiss") {
+		t.Errorf("tree lacks the read error:\n%s", m.tree.View())
+	}
+	for i := range 10 {
+		if m.tree.MoveTo(i); m.tree.GetSelectedNode() == node {
+			break
+		}
+	}
+	if help := m.renderHelp(); !strings.Contains(help, "claude-esp doctor") {
+		t.Errorf("help lacks the doctor hint: %q", help)
+	}
+
+	denied.Err = nil
+	m.Update(fileErrorMsg(denied))
+	if node.ReadError != "" {
+		t.Error("read error not cleared once readable")
+	}
+}
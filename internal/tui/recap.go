@@ -0,0 +1,376 @@
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/edits"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/testrun"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// DefaultRecapWindow is how far back the recap looks unless changed with
+// the recap palette command, up to recapMaxWindow.
+const (
+	DefaultRecapWindow = 15 * time.Minute
+	recapMaxWindow     = 2 * time.Hour
+)
+
+// How many entries each recap section lists; the rest are counted.
+const (
+	recapMaxFiles    = 8
+	recapMaxCommands = 5
+	recapMaxTests    = 3
+	recapMaxErrors   = 5
+)
+
+// testCommand matches shell commands that run a test suite.
+var testCommand = regexp.MustCompile(`(^|[\s;&|(/])(go test|gotestsum|pytest|py\.test|python3? -m (pytest|unittest)|cargo (test|nextest)|(npm|pnpm|yarn|bun)( run)? test|jest|vitest|mocha|rspec|mix test|(\./)?gradlew? test|mvn( \S+)* test|dotnet test|phpunit|ctest|tox|make( \S+)* (test|check))(\s|$)`)
+
+type recapKind int
+
+const (
+	recapEdit recapKind = iota
+	recapCommand
+	recapError
+)
+
+// recapEvent is one thing that happened, as the recap tells it.
+type recapEvent struct {
+	kind      recapKind
+	at        time.Time
+	sessionID string
+	agentID   string
+	cwd       string
+	path      string // recapEdit: the file
+	tool      string // recapError: the tool that failed
+	command   string // recapCommand: the shell command
+	test      bool   // recapCommand: it runs tests
+	tests     string // recapCommand: the test counts, if recognized
+	failed    bool   // recapCommand: it exited non-zero
+	exitCode  int
+	message   string // recapError: the first line of the error
+}
+
+// recapCall is a tool call waiting for its result.
+type recapCall struct {
+	tool    string
+	command string
+	at      time.Time
+}
+
+// RecapView summarises the last few minutes of the enabled agents in plain
+// terms, for catching up after stepping away: files edited, commands and
+// test runs with their outcome, errors, and where each todo list stands.
+// It is built from the stream alone, by simple rules.
+type RecapView struct {
+	pane
+	window time.Duration
+	events []recapEvent // oldest first, at most recapMaxWindow old
+	calls  map[string]recapCall
+	edits  *edits.Tracker
+	todos  map[EnabledFilter]watcher.Todos
+	names  map[EnabledFilter]string // agent names, for the todo lines
+	now    func() time.Time
+}
+
+// NewRecapView creates an empty recap of the last DefaultRecapWindow.
+func NewRecapView() *RecapView {
+	tracker := edits.NewTracker()
+	// The recap only needs the path, not the changed lines.
+	tracker.ReadFile = func(string) ([]byte, error) { return nil, errors.ErrUnsupported }
+	return &RecapView{
+		window: DefaultRecapWindow,
+		calls:  make(map[string]recapCall),
+		edits:  tracker,
+		todos:  make(map[EnabledFilter]watcher.Todos),
+		names:  make(map[EnabledFilter]string),
+		now:    time.Now,
+	}
+}
+
+// Window returns how far back the recap looks.
+func (r *RecapView) Window() time.Duration {
+	return r.window
+}
+
+// SetWindow changes how far back the recap looks.
+func (r *RecapView) SetWindow(d time.Duration) error {
+	if d <= 0 || d > recapMaxWindow {
+		return fmt.Errorf("want a duration up to %s, got %s", recapMaxWindow, d)
+	}
+	r.window = d
+	return nil
+}
+
+// SetTodos records an agent's current todo list.
+func (r *RecapView) SetTodos(sessionID, agentID string, todos watcher.Todos) {
+	key := EnabledFilter{sessionID, agentID}
+	if len(todos) == 0 {
+		delete(r.todos, key)
+		return
+	}
+	r.todos[key] = todos
+}
+
+// AddItem notes what item adds to the recap: a completed edit, a shell
+// command's outcome, or a failed tool call.
+func (r *RecapView) AddItem(item parser.StreamItem) {
+	if item.AgentName != "" {
+		r.names[EnabledFilter{item.SessionID, item.AgentID}] = item.AgentName
+	}
+	if ev, ok := r.edits.Add(item); ok {
+		r.add(recapEvent{kind: recapEdit, at: ev.Timestamp, sessionID: ev.SessionID, agentID: ev.AgentID, cwd: item.Cwd, path: ev.Path})
+	}
+	switch item.Type {
+	case parser.TypeToolInput:
+		if item.ToolID == "" {
+			return
+		}
+		call := recapCall{tool: item.ToolName, at: item.Timestamp}
+		if item.ToolName == "Bash" {
+			call.command = bashCommand(item)
+		}
+		r.calls[item.ToolID] = call
+	case parser.TypeToolOutput:
+		call, ok := r.calls[item.ToolID]
+		if !ok {
+			return
+		}
+		delete(r.calls, item.ToolID)
+		failed := item.IsError || item.ExitCode != 0
+		base := recapEvent{at: item.Timestamp, sessionID: item.SessionID, agentID: item.AgentID, cwd: item.Cwd, exitCode: item.ExitCode}
+		if call.command != "" {
+			ev := base
+			ev.kind, ev.command, ev.test, ev.failed = recapCommand, call.command, testCommand.MatchString(call.command), failed
+			if res, ok := testrun.Recognize(call.command, item.Content); ok {
+				ev.test, ev.tests = true, res.Summary()
+			}
+			r.add(ev)
+		}
+		if failed {
+			ev := base
+			ev.kind, ev.tool, ev.message = recapError, call.tool, cmp.Or(firstLine(item.Stderr), firstLine(item.Content))
+			r.add(ev)
+		}
+	}
+}
+
+// firstLine returns the first non-blank line of s, trimmed.
+func firstLine(s string) string {
+	for line := range strings.Lines(s) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// add appends ev, dropping events too old for any window, and calls whose
+// result never came.
+func (r *RecapView) add(ev recapEvent) {
+	r.events = append(r.events, ev)
+	cutoff := ev.at.Add(-recapMaxWindow)
+	if i := slices.IndexFunc(r.events, func(e recapEvent) bool { return !e.at.Before(cutoff) }); i > 0 {
+		r.events = slices.Delete(r.events, 0, i)
+	}
+	if len(r.calls) > 1000 {
+		for id, call := range r.calls {
+			if call.at.Before(cutoff) {
+				delete(r.calls, id)
+			}
+		}
+	}
+}
+
+// recent returns the events of enabled agents within the window, oldest
+// first.
+func (r *RecapView) recent(now time.Time) []recapEvent {
+	since := now.Add(-r.window)
+	var out []recapEvent
+	for _, ev := range r.events {
+		if !ev.at.Before(since) && r.enabled(ev.sessionID, ev.agentID) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// View renders the recap, newest entries first within each section.
+func (r *RecapView) View() string {
+	innerWidth := max(1, r.width-4)
+	innerHeight := max(1, r.height-2)
+	fit := func(line string) string { return runewidth.Truncate(line, innerWidth, "…") }
+	now := r.now()
+	events := r.recent(now)
+
+	var files []recapEvent // latest edit of each file
+	edited := map[string]int{}
+	var commands, tests, errs []recapEvent
+	failedCommands, failedTests := 0, 0
+	for _, ev := range slices.Backward(events) {
+		switch ev.kind {
+		case recapEdit:
+			if edited[ev.path] == 0 {
+				files = append(files, ev)
+			}
+			edited[ev.path]++
+		case recapCommand:
+			commands = append(commands, ev)
+			if ev.failed {
+				failedCommands++
+			}
+			if ev.test {
+				tests = append(tests, ev)
+				if ev.failed {
+					failedTests++
+				}
+			}
+		case recapError:
+			errs = append(errs, ev)
+		}
+	}
+
+	lines := []string{statsHeaderStyle.Render(fit(fmt.Sprintf("Recap of the last %s", formatWindow(r.window))))}
+	section := func(title string) {
+		lines = append(lines, "", statsHeaderStyle.Render(fit(title)))
+	}
+	more := func(n int) {
+		if n > 0 {
+			lines = append(lines, mutedStyle.Render(fit(fmt.Sprintf("  … and %d more", n))))
+		}
+	}
+
+	if len(events) == 0 {
+		lines = append(lines, mutedStyle.Render(fit("  No edits, commands or errors.")))
+	}
+	if len(files) > 0 {
+		section(fmt.Sprintf("Files edited  %d", len(files)))
+		for _, ev := range files[:min(len(files), recapMaxFiles)] {
+			row := "  ✎ " + recapPath(ev.path, ev.cwd)
+			if n := edited[ev.path]; n > 1 {
+				row += fmt.Sprintf(" ×%d", n)
+			}
+			lines = append(lines, fit(row+"  "+recapAgo(now, ev.at)))
+		}
+		more(len(files) - recapMaxFiles)
+	}
+	if len(tests) > 0 {
+		section(fmt.Sprintf("Tests  %d run, %d passed, %d failed", len(tests), len(tests)-failedTests, failedTests))
+		for _, ev := range tests[:min(len(tests), recapMaxTests)] {
+			lines = append(lines, recapCommandRow(now, ev, fit))
+		}
+		more(len(tests) - recapMaxTests)
+	}
+	if len(commands) > 0 {
+		section(fmt.Sprintf("Commands  %d run, %d failed", len(commands), failedCommands))
+		for _, ev := range commands[:min(len(commands), recapMaxCommands)] {
+			lines = append(lines, recapCommandRow(now, ev, fit))
+		}
+		more(len(commands) - recapMaxCommands)
+	}
+	if len(errs) > 0 {
+		section(fmt.Sprintf("Errors  %d", len(errs)))
+		for _, ev := range errs[:min(len(errs), recapMaxErrors)] {
+			row := "  ✗ " + ev.tool
+			if ev.exitCode != 0 {
+				row += fmt.Sprintf(" exit %d", ev.exitCode)
+			}
+			if ev.message != "" {
+				row += ": " + ev.message
+			}
+			lines = append(lines, failedOutputStyle.Render(fit(row+"  "+recapAgo(now, ev.at))))
+		}
+		more(len(errs) - recapMaxErrors)
+	}
+	if todos := r.todoLines(); len(todos) > 0 {
+		section("Todos")
+		for _, line := range todos {
+			lines = append(lines, fit(line))
+		}
+	}
+	return padLines(lines, innerHeight)
+}
+
+// todoLines describes each enabled agent's todo list, Main first.
+func (r *RecapView) todoLines() []string {
+	var lines []string
+	for _, f := range r.enabledFilters {
+		todos, ok := r.todos[f]
+		if !ok {
+			continue
+		}
+		name := cmp.Or(r.names[f], "Main")
+		row := fmt.Sprintf("  %s %d/%d", name, todos.Done(), len(todos))
+		if current := todos.Current(); current != "" {
+			row += " · ◐ " + current
+		} else if todos.Done() == len(todos) {
+			row += " · all done"
+		}
+		lines = append(lines, row)
+	}
+	return lines
+}
+
+// recapCommandRow is a command with its outcome and age, cut to fit and
+// then styled.
+func recapCommandRow(now time.Time, ev recapEvent, fit func(string) string) string {
+	command := strings.ReplaceAll(ev.command, "\n", " ⏎ ")
+	if ev.tests != "" {
+		command += "  " + ev.tests
+	}
+	if !ev.failed {
+		return fit("  ✓ " + command + "  " + recapAgo(now, ev.at))
+	}
+	status := "failed"
+	if ev.exitCode != 0 {
+		status = fmt.Sprintf("exit %d", ev.exitCode)
+	}
+	return failedOutputStyle.Render(fit("  ✗ " + command + "  " + status + "  " + recapAgo(now, ev.at)))
+}
+
+// recapPath shows path relative to the session's directory when it is
+// inside it, else with the home directory abbreviated.
+func recapPath(path, cwd string) string {
+	if cwd != "" {
+		if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsLocal(rel) {
+			return rel
+		}
+	}
+	return shortDir(path)
+}
+
+// recapAgo is how long before now t was, roughly.
+func recapAgo(now, t time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm ago", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatWindow prints a window without the zero units time.Duration
+// prints ("15m", not "15m0s").
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// recapItems returns a tool call and its result, at.
+func recapItems(agentID, tool, id string, input map[string]string, at time.Time, result parser.StreamItem) []parser.StreamItem {
+	raw, _ := json.Marshal(input)
+	call := parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", AgentID: agentID, ToolName: tool, ToolID: id, ToolInput: raw, Timestamp: at, Cwd: "/src/app"}
+	result.Type, result.SessionID, result.AgentID, result.ToolID, result.Timestamp, result.Cwd = parser.TypeToolOutput, "s1", agentID, id, at, "/src/app"
+	return []parser.StreamItem{call, result}
+}
+
+func TestRecap(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRecapView()
+	r.now = func() time.Time { return now }
+	r.SetSize(120, 40)
+	r.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}})
+
+	var items []parser.StreamItem
+	add := func(agentID, tool, id string, input map[string]string, ago time.Duration, result parser.StreamItem) {
+		items = append(items, recapItems(agentID, tool, id, input, now.Add(-ago), result)...)
+	}
+	add("", "Write", "w0", map[string]string{"file_path": "/src/app/old.go", "content": "x"}, time.Hour, parser.StreamItem{})
+	add("", "Edit", "e1", map[string]string{"file_path": "/src/app/main.go", "old_string": "a", "new_string": "b"}, 5*time.Minute, parser.StreamItem{})
+	add("", "Edit", "e2", map[string]string{"file_path": "/src/app/main.go", "old_string": "b", "new_string": "c"}, 4*time.Minute, parser.StreamItem{})
+	add("", "Write", "e3", map[string]string{"file_path": "/etc/app.conf", "content": "x"}, 4*time.Minute, parser.StreamItem{IsError: true, Content: "permission denied"})
+	add("", "Bash", "b1", map[string]string{"command": "go test ./..."}, 3*time.Minute, parser.StreamItem{ExitCode: 1, Content: "--- FAIL: TestX\nFAIL", Stderr: "\nbuild failed\n"})
+	add("", "Bash", "b2", map[string]string{"command": "cd web && npm run test"}, 2*time.Minute, parser.StreamItem{})
+	add("", "Bash", "b3", map[string]string{"command": "git status"}, time.Minute, parser.StreamItem{})
+	add("a1", "Bash", "b4", map[string]string{"command": "rm -rf /"}, time.Minute, parser.StreamItem{})
+	for _, item := range items {
+		r.AddItem(item)
+	}
+	r.SetTodos("s1", "", watcher.Todos{
+		{Content: "Write tests", Status: "completed"},
+		{Content: "Fix build", Status: "in_progress", ActiveForm: "Fixing the build"},
+	})
+
+	view := r.View()
+	for _, want := range []string{
+		"Recap of the last 15m",
+		"Files edited  1", "main.go ×2  4m ago",
+		"Tests  2 run, 1 passed, 1 failed", "✗ go test ./...  1 failed  exit 1  3m ago", "✓ cd web && npm run test",
+		"Commands  3 run, 1 failed", "✓ git status  1m ago",
+		"Errors  2", "✗ Bash exit 1: build failed", "✗ Write: permission denied",
+		"Main 1/2 · ◐ Fixing the build",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("recap lacks %q:\n%s", want, view)
+		}
+	}
+	for _, unwanted := range []string{"old.go", "rm -rf", "app.conf"} {
+		if strings.Contains(view, unwanted) {
+			t.Errorf("recap shows %q (outside the window, a disabled agent, or a failed edit):\n%s", unwanted, view)
+		}
+	}
+
+	if err := r.SetWindow(2 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if view := r.View(); !strings.Contains(view, "Recap of the last 2h") || !strings.Contains(view, "old.go") {
+		t.Errorf("widened recap:\n%s", view)
+	}
+	if err := r.SetWindow(3 * time.Hour); err == nil {
+		t.Error("window past recapMaxWindow accepted")
+	}
+}
+
+func TestTestCommand(t *testing.T) {
+	for cmd, want := range map[string]bool{
+		"go test ./...":                        true,
+		"cd api && go test -run TestX ./x":     true,
+		"python -m pytest -q":                  true,
+		"npm test":                             true,
+		"pnpm run test -- --watch=false":       true,
+		"cargo nextest run":                    true,
+		"make -j4 check":                       true,
+		"./node_modules/.bin/vitest run":       true,
+		"go build ./...":                       false,
+		"echo testing":                         false,
+		"cat pytest.ini":                       false,
+		"npm run testing-library-setup --help": false,
+	} {
+		if got := testCommand.MatchString(cmd); got != want {
+			t.Errorf("%q: %v, want %v", cmd, got, want)
+		}
+	}
+}
+
+func TestRecapKeyAndPalette(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+
+	press := func(keys ...string) {
+		for _, k := range keys {
+			m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+	press("$", "r")
+	if !m.showRecap || m.showStats {
+		t.Errorf("r: recap %v, stats %v", m.showRecap, m.showStats)
+	}
+	press("r")
+	m.runPalette("recap 1h")
+	if !m.showRecap || m.recap.Window() != time.Hour || m.status != "recap 1h" {
+		t.Errorf("recap 1h: shown %v, window %v, status %q", m.showRecap, m.recap.Window(), m.status)
+	}
+	m.runPalette("recap 5h")
+	if !strings.HasPrefix(m.status, "recap: ") || m.recap.Window() != time.Hour {
+		t.Errorf("recap 5h: status %q, window %v", m.status, m.recap.Window())
+	}
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/clipboard"
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+	"github.com/phiat/claude-esp/internal/notify"
+	"github.com/phiat/claude-esp/internal/osc"
+)
+
+// configCheckInterval is how often the config file is checked for changes.
+// Checks ride on the tick, so they pause with it.
+const configCheckInterval = time.Second
+
+// configStamp identifies a version of the config file; the zero value
+// means there is no file.
+type configStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// configReloadMsg reports a changed config file: the new config, or why
+// it can't be used.
+type configReloadMsg struct {
+	stamp configStamp
+	cfg   *config.Config
+	err   error
+}
+
+// WatchConfig applies changes to the config file at path (the default
+// location if "") while running. Settings given as flags stay in effect
+// until the file changes that same setting.
+func (m *Model) WatchConfig(path string) {
+	if path == "" {
+		p, err := config.DefaultPath()
+		if err != nil {
+			return
+		}
+		path = p
+	}
+	m.configPath = path
+	m.configStamp = statConfig(path)
+	m.fileConfig = config.Default()
+	if cfg, err := config.Load(path); err == nil {
+		m.fileConfig = cfg
+	}
+}
+
+func statConfig(path string) configStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return configStamp{}
+	}
+	return configStamp{mod: fi.ModTime(), size: fi.Size()}
+}
+
+// checkConfig loads the config file if it changed since the last check.
+func (m *Model) checkConfig() tea.Cmd {
+	if m.configPath == "" || time.Since(m.configChecked) < configCheckInterval {
+		return nil
+	}
+	m.configChecked = time.Now()
+	path, last := m.configPath, m.configStamp
+	return func() tea.Msg {
+		stamp := statConfig(path)
+		if stamp == last {
+			return nil
+		}
+		cfg, err := config.Load(path)
+		return configReloadMsg{stamp: stamp, cfg: cfg, err: err}
+	}
+}
+
+// reloadConfig applies a reloaded config. An invalid file leaves every
+// setting as it was and stays reported in the help bar until fixed.
+func (m *Model) reloadConfig(msg configReloadMsg) {
+	m.configStamp = msg.stamp
+	if msg.err != nil {
+		m.configErr = msg.err.Error()
+		return
+	}
+	m.configErr = ""
+	old, cfg := m.fileConfig, msg.cfg
+	m.fileConfig = cfg
+
+	prices, _ := cfg.PricingTable()
+	m.budget.configure(cfg)
+	m.stats.prices = prices
+	m.loops.SetThresholds(cfg.LoopThresholds())
+	m.longrun.SetLimits(cfg.LongRunLimits())
+	m.files.SetChurn(cfg.ChurnEdits())
+	m.presets = cfg.Presets()
+	m.preset = min(m.preset, len(m.presets))
+	m.newAgents = cfg.View.NewAgents
+	m.macros = cfg.MacroKeys()
+	m.summarizer, m.summarizeWindow = cfg.Summarizer(), cfg.SummarizeWindow()
+
+	m.notifier = notify.New(cfg.Notify.Command)
+	if cfg.Terminal.Notify {
+		m.notifier.SetTerminal(Terminal, osc.EnvFromOS())
+	}
+	m.notifyNewSessions = cfg.Notify.NewSessions
+	if cfg.View.SessionGutter != old.View.SessionGutter {
+		m.stream.SetSessionGutter(cfg.View.SessionGutter)
+	}
+	if cfg.View.FocusFollows != old.View.FocusFollows {
+		m.focusFollows = cfg.View.FocusFollows
+	}
+	if cfg.View.TypeGutter != old.View.TypeGutter {
+		m.stream.SetTypeGutter(cfg.View.TypeGutter)
+	}
+	if cfg.Terminal.Clipboard != old.Terminal.Clipboard {
+		m.clipboard = clipboard.New(cfg.Terminal.Clipboard, clipboard.EnvFromOS(), Terminal)
+	}
+	if cfg.Terminal.Colors != old.Terminal.Colors {
+		SetColors(cfg.Terminal.Colors)
+		m.stream.updateContent()
+	}
+	if !cfg.Terminal.Title {
+		m.beats = nil
+	} else if m.beats == nil {
+		m.beats = heartbeat.NewTracker()
+	}
+
+	// Timings go through the palette setters, which validate and apply
+	// them the same way.
+	if cfg.Watch.PollInterval != old.Watch.PollInterval {
+		m.setPollInterval(cfg.PollInterval().String())
+	}
+	if cfg.Watch.ActiveWindow != old.Watch.ActiveWindow {
+		m.setActiveWindow(cfg.ActiveWindow().String())
+	}
+	if cfg.Watch.ActivityThreshold != old.Watch.ActivityThreshold {
+		m.setActivityThreshold(cfg.ActivityThreshold().String())
+	}
+	if cfg.Watch.LowPower != old.Watch.LowPower {
+		m.setLowPower(cfg.Watch.LowPower)
+	}
+	if !slices.Equal(cfg.Watch.IgnoreProjects, old.Watch.IgnoreProjects) {
+		m.setProjectFilter(cfg)
+	}
+	m.status = "config reloaded"
+}
+
+// setProjectFilter applies changed ignore_projects patterns: sessions of
+// newly ignored projects go, and newly unignored ones can be discovered
+// again. Like at startup, they only apply to auto-discovery.
+func (m *Model) setProjectFilter(cfg *config.Config) {
+	m.ignore = cfg.ProjectFilter()
+	if len(m.sessionIDs) > 0 || m.sessionFile != "" {
+		return
+	}
+	if m.watcher != nil {
+		m.watcher.SetProjectFilter(m.ignore)
+	}
+	var drop []string
+	for _, node := range m.tree.Root.Children {
+		if node.Type == NodeTypeSession && m.ignore.Match(node.ProjectPath) {
+			drop = append(drop, node.ID)
+		}
+	}
+	for _, id := range drop {
+		m.tree.RemoveSession(id)
+	}
+	m.syncFilters()
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/config"
+)
+
+func TestConfigHotReload(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), config.FileName)
+	os.WriteFile(path, []byte("[watch]\nactive_window = \"5m\"\n"), 0o644)
+	cfg, _ := config.Load(path)
+	cfg.Watch.ActiveWindow = 2 * time.Minute // as if from -w
+	m := NewModel(nil, false, 500*time.Millisecond, cfg.ActiveWindow(), 0, 0, cfg)
+	m.WatchConfig(path)
+	m.Update(tea.WindowSizeMsg{Width: 160, Height: 20})
+	m.tree.AddSession("s1", "home/me/api")
+	m.tree.AddSession("s2", "home/me/scratch-1")
+
+	reload := func(body string) {
+		t.Helper()
+		os.WriteFile(path, []byte(body), 0o644)
+		m.configChecked = time.Time{}
+		cmd := m.checkConfig()
+		if cmd == nil {
+			t.Fatal("config not checked")
+		}
+		msg, ok := cmd().(configReloadMsg)
+		if !ok {
+			t.Fatal("change not noticed")
+		}
+		m.Update(msg)
+	}
+
+	reload("[watch]\nactive_window = \"5m\"\nignore_projects = [\"*/scratch*\"]\n[budget]\nsession = 1.0\n")
+	if m.budget.budget.Session != 1.0 {
+		t.Errorf("session budget = %v after reload", m.budget.budget.Session)
+	}
+	if m.activeWindow != 2*time.Minute {
+		t.Errorf("active window = %s; the flag should win while the file's value is unchanged", m.activeWindow)
+	}
+	if m.tree.SessionProject("s2") != "" || m.tree.SessionProject("s1") == "" {
+		t.Error("ignore_projects not applied to sessions already shown")
+	}
+	if !strings.Contains(m.View(), "config reloaded") {
+		t.Error("reload not reported")
+	}
+
+	// An invalid file changes nothing and says why until it's fixed.
+	reload("[budget]\nsession = -1\n")
+	if m.budget.budget.Session != 1.0 || !strings.Contains(m.View(), "budget") {
+		t.Errorf("invalid config: budget %v, help bar %q", m.budget.budget.Session, m.View())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if m.configErr == "" {
+		t.Error("config error cleared by a key press")
+	}
+	reload("[watch]\nactive_window = \"10m\"\n")
+	if m.configErr != "" || m.activeWindow != 10*time.Minute {
+		t.Errorf("after fixing: error %q, active window %s", m.configErr, m.activeWindow)
+	}
+
+	// Unchanged file: nothing to reload.
+	m.configChecked = time.Time{}
+	if msg := m.checkConfig()(); msg != nil {
+		t.Errorf("unchanged file reloaded: %v", msg)
+	}
+}
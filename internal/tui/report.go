@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// SetReport attaches a subagent's final report to its Agent node. An agent
+// not in the tree yet gets it when added.
+func (t *TreeView) SetReport(sessionID, agentID, report string) {
+	node := t.findAgentNode(sessionID, agentID)
+	if node == nil {
+		if t.pendingReports == nil {
+			t.pendingReports = make(map[EnabledFilter]string)
+		}
+		t.pendingReports[EnabledFilter{sessionID, agentID}] = report
+		return
+	}
+	node.Report = report
+}
+
+func (t *TreeView) applyPendingReport(node *TreeNode) {
+	key := EnabledFilter{node.SessionID, node.ID}
+	if report, ok := t.pendingReports[key]; ok {
+		delete(t.pendingReports, key)
+		node.Report = report
+	}
+}
+
+// reportLabel is an agent report's header in the stream: whose it is and
+// how long the subagent ran.
+func reportLabel(item parser.StreamItem) string {
+	id := item.SpawnedAgentID
+	label := fmt.Sprintf("%s Agent report · Agent-%s", reportIcon, id[:min(AgentIDDisplayLength, len(id))])
+	if item.DurationMs > 0 {
+		label += " " + formatDuration(item.DurationMs)
+	}
+	return label
+}
+
+// reportHelp is the help bar's note on a selected agent that has
+// reported: the report's first line.
+func reportHelp(report string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(report), "\n")
+	return fmt.Sprintf("%s %s │ enter: read report │ ", reportIcon, truncate(first, 60))
+}
+
+// openReport pages the selected agent's final report.
+func (m *Model) openReport(node *TreeNode) {
+	m.openPager(newTextPager(node.Name+" » report", node.Report))
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestAgentReport(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 50})
+	var report []string
+	for i := range MaxLinesPerItem + 10 {
+		report = append(report, fmt.Sprintf("finding %d", i+1))
+	}
+	now := time.Now()
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeToolInput, SessionID: "s1", AgentName: "Main", ToolName: "Task", ToolID: "t1", Content: "audit the api", Timestamp: now},
+		{Type: parser.TypeToolOutput, SessionID: "s1", AgentName: "Main", ToolID: "t1", SpawnedAgentID: "a1", DurationMs: 90_000,
+			Content: strings.Join(report, "\n"), Timestamp: now},
+	})
+
+	node := m.tree.findAgentNode("s1", "a1")
+	if node == nil || !strings.HasPrefix(node.Report, "finding 1\n") {
+		t.Fatalf("report not attached to the agent node")
+	}
+	for i := 0; m.tree.GetSelectedNode() != node; i++ {
+		m.tree.MoveTo(i)
+	}
+	if help := m.renderHelp(); !strings.Contains(help, reportIcon+" finding 1 │ enter: read report") {
+		t.Errorf("help bar = %q", help)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.pager == nil || m.pager.title != node.Name+" » report" {
+		t.Fatal("enter on a reported agent didn't page its report")
+	}
+	m.Update(key("q"))
+	if !node.Enabled {
+		t.Error("enter toggled the agent instead of reading its report")
+	}
+
+	item := m.stream.items[1]
+	rendered := m.stream.renderItem(item, 80)
+	last := fmt.Sprintf("finding %d", len(report))
+	if !strings.Contains(rendered, "Agent report · Agent-a1 (1.5m)") || !strings.Contains(rendered, last) {
+		t.Errorf("report isn't shown whole under its own header:\n%s", rendered)
+	}
+	m.stream.selected = 1
+	m.stream.ToggleExpanded()
+	if rendered := m.stream.renderItem(item, 80); strings.Contains(rendered, last) || !strings.Contains(rendered, "more lines") {
+		t.Errorf("z didn't collapse the report:\n%s", rendered)
+	}
+}
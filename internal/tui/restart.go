@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/phiat/claude-esp/internal/handoff"
+)
+
+// restart is the "restart" palette command: it quits so main can exec the
+// binary on disk in this process's place, e.g. after an upgrade. The new
+// process continues each transcript where this one stopped reading and
+// shows the items this one was showing, so nothing is read twice or lost;
+// the view comes back like after any quit.
+func (m *Model) restart(arg string) (string, error) {
+	if arg != "" {
+		return "", errors.New("takes no argument")
+	}
+	if m.stateDir == "" {
+		return "", errors.New("no state directory to hand the stream over in")
+	}
+	if m.watcher == nil {
+		return "", errors.New("not watching yet")
+	}
+	m.watcher.Stop()
+	positions := m.watcher.Positions()
+	// Items already sent but not yet shown are behind the positions: take
+	// back what a low-power wait is holding, then what is still queued,
+	// so they are handed over too.
+	m.cancelWait()
+	m.drainWatcher()
+	var st handoff.State
+	// An attached stream is replayed by the instance it comes from.
+	if m.attach == "" {
+		st.Positions = positions
+		st.Items = m.stream.Items()
+		for _, id := range slices.Sorted(maps.Keys(m.titles)) {
+			st.Items = append(st.Items, m.titles[id])
+		}
+	}
+	path := filepath.Join(m.stateDir, handoff.FileName)
+	if err := handoff.Save(path, st); err != nil {
+		// The watcher is stopped either way; quit rather than sit idle.
+		m.quitting = true
+		return "", fmt.Errorf("%w; quitting without a handoff", err)
+	}
+	m.restartPath = path
+	m.quitting = true
+	return "restarting", nil
+}
+
+// drainWatcher adds whatever the stopped watcher had queued.
+func (m *Model) drainWatcher() {
+	var batch streamItemsMsg
+	for {
+		select {
+		case item := <-m.watcher.Items:
+			batch = append(batch, item)
+		default:
+			if len(batch) > 0 {
+				m.addItems(batch)
+			}
+			return
+		}
+	}
+}
+
+// RestartPath returns the handoff written by the restart command, or ""
+// if the program quit for any other reason. main execs the new binary
+// with it.
+func (m *Model) RestartPath() string {
+	return m.restartPath
+}
+
+// SetHandoff continues the stream handed over by the process this one
+// replaced. Call before the program starts.
+func (m *Model) SetHandoff(st handoff.State) {
+	m.handoff = &st
+}
+
+// resumeHandoff replays the handed-over items once the watcher runs.
+// Like replayed history, they go through every pane and sink again.
+func (m *Model) resumeHandoff() {
+	if m.handoff == nil {
+		return
+	}
+	items := m.handoff.Items
+	m.handoff = nil
+	m.stream.Hold()
+	for _, item := range items {
+		m.addItem(item)
+	}
+	m.stream.Release()
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/handoff"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+func TestRestartHandsOver(t *testing.T) {
+	m := treeModel(t)
+	if _, err := m.restart(""); err == nil {
+		t.Error("restart before the watcher started succeeded")
+	}
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	os.WriteFile(path, nil, 0o644)
+	w, err := watcher.OpenFile(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.watcher = w
+	m.addItem(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", Content: "done"})
+	m.addItem(parser.StreamItem{Type: parser.TypeSessionTitle, SessionID: "s1", Content: "Fix the tests"})
+
+	m.runPalette("restart")
+	if !m.quitting || m.RestartPath() == "" {
+		t.Fatalf("restart: quitting %v, path %q, status %q", m.quitting, m.RestartPath(), m.status)
+	}
+	st, err := handoff.Take(m.RestartPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := treeModel(t)
+	next.SetHandoff(st)
+	next.Update(watcherReadyMsg{})
+	if items := next.stream.Items(); len(items) != 1 || items[0].Content != "done" {
+		t.Errorf("stream after handoff = %+v", items)
+	}
+	if name := next.tree.Root.Children[0].Name; name != "Fix the tests" {
+		t.Errorf("session name after handoff = %q", name)
+	}
+}
+
+func TestRestartTakesBackWaitedItems(t *testing.T) {
+	m := treeModel(t)
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	os.WriteFile(path, nil, 0o644)
+	w, err := watcher.OpenFile(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.watcher = w
+
+	// A low-power wait takes an item off the watcher, but its message
+	// hasn't reached Update when :restart runs.
+	queued := make(chan tea.Msg, 1)
+	cmd := m.waitWatcher()
+	go func() { queued <- cmd() }()
+	w.Items <- parser.StreamItem{Type: parser.TypeText, SessionID: "s1", Content: "in flight"}
+	msg := <-queued
+
+	m.runPalette("restart")
+	m.Update(msg)
+	if items := m.stream.Items(); len(items) != 1 {
+		t.Errorf("stream = %+v, want the waited item once", items)
+	}
+	st, err := handoff.Take(m.RestartPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Items) != 1 || st.Items[0].Content != "in flight" {
+		t.Errorf("handed over %+v", st.Items)
+	}
+}
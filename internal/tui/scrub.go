@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// scrubIcon marks the stream frozen at an earlier point ([ and ]).
+const scrubIcon = "⏪"
+
+// scrubSliderWidth is how many cells the scrub slider takes.
+const scrubSliderWidth = 16
+
+// Scrub steps the stream n visible items back in time, or forward for a
+// negative n, and freezes it there while new items keep arriving behind
+// the cut. Stepping forward past the newest item goes back to live. It
+// reports whether the stream is scrubbed afterwards.
+func (s *StreamView) Scrub(n int) bool {
+	var visible []int
+	shown := 0
+	for i, item := range s.items {
+		if !s.isVisible(item) {
+			continue
+		}
+		visible = append(visible, i)
+		if s.scrub < 0 || i < s.scrub {
+			shown = len(visible)
+		}
+	}
+	if len(visible) == 0 {
+		return false
+	}
+	pos := shown - n
+	if pos >= len(visible) {
+		s.ScrubLive()
+		return false
+	}
+	s.scrub = visible[max(1, pos)-1] + 1
+	if s.selected >= s.scrub {
+		s.selected = -1
+	}
+	if s.mark >= s.scrub {
+		s.mark = -1
+	}
+	s.updateContent()
+	s.viewport.GotoBottom()
+	return true
+}
+
+// ScrubLive unfreezes a scrubbed stream, showing the newest items again
+// with auto-scroll on. It reports false if the stream was live already.
+func (s *StreamView) ScrubLive() bool {
+	if s.scrub < 0 {
+		return false
+	}
+	s.JumpToBottom()
+	return true
+}
+
+// Scrubbed describes the point the stream is frozen at: the last item
+// shown's time, how many visible items are shown and how many there are
+// in all. ok is false while the stream is live.
+func (s *StreamView) Scrubbed() (at time.Time, shown, total int, ok bool) {
+	if s.scrub < 0 {
+		return time.Time{}, 0, 0, false
+	}
+	for i, item := range s.items {
+		if !s.isVisible(item) {
+			continue
+		}
+		total++
+		if i < s.scrub {
+			shown++
+			at = item.Timestamp
+		}
+	}
+	return at, shown, total, true
+}
+
+// shownItems is the part of the stream that is rendered: everything, or
+// up to the scrub point.
+func (s *StreamView) shownItems() int {
+	if s.scrub < 0 {
+		return len(s.items)
+	}
+	return min(s.scrub, len(s.items))
+}
+
+// scrubChip is the stream's bottom-border label while it is scrubbed: the
+// time frozen at, a slider and how far behind live it is.
+func (m *Model) scrubChip() (string, bool) {
+	at, shown, total, ok := m.stream.Scrubbed()
+	if !ok {
+		return "", false
+	}
+	knob := 0
+	if total > 1 {
+		knob = (shown - 1) * (scrubSliderWidth - 1) / (total - 1)
+	}
+	slider := strings.Repeat("▰", knob) + "●" + strings.Repeat("▱", scrubSliderWidth-1-knob)
+	when := "--:--:--"
+	if !at.IsZero() {
+		when = at.Local().Format("15:04:05")
+	}
+	return scrubStyle.Render(fmt.Sprintf("%s %s %s %d/%d · %d newer · ]/G: live", scrubIcon, when, slider, shown, total, total-shown)), true
+}
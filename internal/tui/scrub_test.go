@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestScrub(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.focus = FocusStream
+	at := time.Date(2025, 6, 1, 15, 4, 0, 0, time.Local)
+	text := func(i int) parser.StreamItem {
+		return parser.StreamItem{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: fmt.Sprintf("reply %d", i), Timestamp: at.Add(time.Duration(i) * time.Second)}
+	}
+	for i := range 5 {
+		m.Update(streamItemsMsg{text(i)})
+	}
+
+	m.Update(key("["))
+	m.Update(key("["))
+	if got := len(m.stream.VisibleItems()); got != 3 {
+		t.Fatalf("two steps back show %d items, want 3", got)
+	}
+	chip, ok := m.scrubChip()
+	if !ok || !strings.Contains(chip, "15:04:02") || !strings.Contains(chip, "3/5 · 2 newer") {
+		t.Errorf("scrub chip = %q", chip)
+	}
+	if help := m.renderHelp(); !strings.Contains(help, "scrubbing") {
+		t.Errorf("help bar = %q", help)
+	}
+
+	// Live items keep arriving behind the frozen view.
+	m.Update(streamItemsMsg{text(5)})
+	if got := len(m.stream.VisibleItems()); got != 3 {
+		t.Errorf("a new item moved the scrubbed view: %d items", got)
+	}
+	if _, shown, total, _ := m.stream.Scrubbed(); shown != 3 || total != 6 {
+		t.Errorf("scrubbed at %d/%d, want 3/6", shown, total)
+	}
+	if strings.Contains(m.stream.View(), "reply 5") {
+		t.Error("new item rendered while scrubbed")
+	}
+
+	// A count steps further; it stops at the first item.
+	m.Update(key("9"))
+	m.Update(key("["))
+	if got := len(m.stream.VisibleItems()); got != 1 {
+		t.Errorf("9[ shows %d items, want 1", got)
+	}
+	m.Update(key("2"))
+	m.Update(key("]"))
+	if got := len(m.stream.VisibleItems()); got != 3 {
+		t.Errorf("2] shows %d items, want 3", got)
+	}
+
+	// Stepping past the newest item, or esc, snaps back to live.
+	m.Update(key("9"))
+	m.Update(key("]"))
+	if _, _, _, ok := m.stream.Scrubbed(); ok || len(m.stream.VisibleItems()) != 6 {
+		t.Errorf("9] didn't go back to live: %d items", len(m.stream.VisibleItems()))
+	}
+	m.Update(key("["))
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, _, _, ok := m.stream.Scrubbed(); ok || !m.stream.IsAutoScrollEnabled() {
+		t.Error("esc didn't go back to live")
+	}
+}
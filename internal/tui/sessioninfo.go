@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// sessionSpan is how far a session's items reach: the first and last
+// timestamps seen and how many messages came between.
+type sessionSpan struct {
+	first, last time.Time
+	messages    int
+}
+
+// isMessage reports whether item is part of the conversation itself, as
+// opposed to markers, hooks and other events around it.
+func isMessage(item parser.StreamItem) bool {
+	switch item.Type {
+	case parser.TypeThinking, parser.TypeToolInput, parser.TypeToolOutput, parser.TypeText, parser.TypeCommand:
+		return true
+	}
+	return false
+}
+
+// trackSpan extends item's session span to cover it.
+func (m *Model) trackSpan(item parser.StreamItem) {
+	if item.SessionID == "" || item.Timestamp.IsZero() {
+		return
+	}
+	m.latestSession = item.SessionID
+	span := m.spans[item.SessionID]
+	if span == nil {
+		span = &sessionSpan{first: item.Timestamp, last: item.Timestamp}
+		m.spans[item.SessionID] = span
+	}
+	if item.Timestamp.Before(span.first) {
+		span.first = item.Timestamp
+	}
+	if item.Timestamp.After(span.last) {
+		span.last = item.Timestamp
+	}
+	if isMessage(item) {
+		span.messages++
+	}
+}
+
+// sessionInfo describes the session selected in the tree, or the one the
+// latest item came from: when it started, how long it has run and how
+// many messages it has, as "since 14:02 · 1h23m · 312 msgs". With more
+// than one session its project goes first. It's "" before any items.
+func (m *Model) sessionInfo() string {
+	sessionID := m.tree.GetSelectedSession()
+	if m.spans[sessionID] == nil {
+		sessionID = m.latestSession
+	}
+	span := m.spans[sessionID]
+	if span == nil {
+		return ""
+	}
+	first := span.first.Local()
+	start := first.Format("15:04")
+	if first.Format(time.DateOnly) != time.Now().Format(time.DateOnly) {
+		start = first.Format("Jan 2 15:04")
+	}
+	elapsed := "0s"
+	if d := span.last.Sub(span.first); d >= time.Second {
+		elapsed = formatSpan(d)
+	}
+	info := fmt.Sprintf("since %s · %s · %d msgs", start, elapsed, span.messages)
+	if len(m.spans) > 1 {
+		if project := m.tree.SessionProject(sessionID); project != "" {
+			info = filepath.Base(project) + ": " + info
+		}
+	}
+	return info
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestSessionInfo(t *testing.T) {
+	m := treeModel(t)
+	if info := m.sessionInfo(); info != "" {
+		t.Errorf("session info before any items = %q", info)
+	}
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	m.Update(streamItemsMsg{
+		{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "hi", Timestamp: at(0)},
+		{Type: parser.TypeTurnMarker, SessionID: "s1", AgentName: "Main", Timestamp: at(time.Minute)},
+		{Type: parser.TypeToolInput, SessionID: "s1", AgentName: "Main", ToolName: "Bash", ToolID: "b1", Timestamp: at(83 * time.Minute)},
+		// Out of order: it doesn't move the start.
+		{Type: parser.TypeToolOutput, SessionID: "s1", AgentName: "Main", ToolID: "b1", Timestamp: at(30 * time.Second)},
+	})
+	want := "since " + start.Format("15:04") + " · 1h23m · 3 msgs"
+	if info := m.sessionInfo(); info != want {
+		t.Errorf("session info = %q, want %q", info, want)
+	}
+	if header := m.renderHeader(); !strings.Contains(header, want) {
+		t.Errorf("header doesn't show the session info:\n%s", header)
+	}
+
+	// The session selected in the tree, here from an earlier day, shows
+	// with its date and project.
+	earlier := time.Date(2025, 6, 1, 9, 30, 0, 0, time.Local)
+	m.Update(streamItemsMsg{{Type: parser.TypeText, SessionID: "s2", AgentName: "Main", Content: "old", Timestamp: earlier}})
+	for row, node := range m.tree.nodes {
+		if node.Type == NodeTypeSession && node.ID == "s2" {
+			m.tree.MoveTo(row)
+		}
+	}
+	if info := m.sessionInfo(); info != "web: since Jun 1 09:30 · 0s · 1 msgs" {
+		t.Errorf("selected session info = %q", info)
+	}
+}
@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// snoozeIcon marks snoozed sessions and agents in the tree.
+const snoozeIcon = "🔕"
+
+// defaultSnooze is how long M snoozes for; ":snooze <dur>" picks another.
+const defaultSnooze = 10 * time.Minute
+
+// snoozeLeft is how long until a snooze ends, in whole minutes rounded up:
+// "10m", "1h5m".
+func snoozeLeft(until, now time.Time) string {
+	d := until.Sub(now)
+	if d <= 0 {
+		return "0m"
+	}
+	return formatWindow((d + time.Minute - 1).Truncate(time.Minute))
+}
+
+// Snooze disables the selected Session, Main or Agent node until until: a
+// session's Main and agents, or the one agent. It returns the node's name,
+// or "" if the selection can't be snoozed.
+func (t *TreeView) Snooze(until time.Time) string {
+	node := t.GetSelectedNode()
+	if node == nil {
+		return ""
+	}
+	switch node.Type {
+	case NodeTypeSession:
+		node.SnoozedUntil = until
+		for _, child := range node.Children {
+			if child.Type == NodeTypeMain || child.Type == NodeTypeAgent {
+				child.Enabled, child.SnoozedUntil = false, until
+			}
+		}
+	case NodeTypeMain, NodeTypeAgent:
+		node.Enabled, node.SnoozedUntil = false, until
+	default:
+		return ""
+	}
+	return node.Name
+}
+
+// Wake re-enables the snoozed nodes whose snooze ended by now, or every
+// snoozed node under the selection when selection is set, and returns the
+// names of the sessions and agents it woke.
+func (t *TreeView) Wake(now time.Time, selection bool) []string {
+	var selected *TreeNode
+	if selection {
+		selected = t.GetSelectedNode()
+	}
+	var woke []string
+	woken := make(map[*TreeNode]bool)
+	var walk func(node *TreeNode, chosen bool)
+	walk = func(node *TreeNode, chosen bool) {
+		chosen = chosen || node == selected
+		if !node.SnoozedUntil.IsZero() && (chosen || !now.Before(node.SnoozedUntil)) {
+			node.SnoozedUntil = time.Time{}
+			if node.Type != NodeTypeSession {
+				node.Enabled = true
+			}
+			woken[node] = true
+			// A session's agents wake with it: name only the session.
+			if !woken[node.Parent] {
+				woke = append(woke, node.Name)
+			}
+		}
+		for _, child := range node.Children {
+			walk(child, chosen)
+		}
+	}
+	for _, session := range t.Root.Children {
+		walk(session, false)
+	}
+	return woke
+}
+
+// toggleSnooze is M: it snoozes the selected session or agent for the
+// default time, or wakes it if it is snoozed already.
+func (m *Model) toggleSnooze() {
+	node := m.tree.GetSelectedNode()
+	if node == nil {
+		return
+	}
+	if node.SnoozedUntil.IsZero() {
+		m.snoozeSelected(defaultSnooze)
+		return
+	}
+	m.withUndo("wake", func() { m.tree.Wake(time.Now(), true) })
+	m.status = node.Name + " woken up"
+}
+
+// snoozeSelected snoozes the selected session or agent for d.
+func (m *Model) snoozeSelected(d time.Duration) {
+	until := time.Now().Add(d)
+	var name string
+	m.withUndo("snooze", func() { name = m.tree.Snooze(until) })
+	if name == "" {
+		m.status = "select a session or agent to snooze"
+		return
+	}
+	m.status = fmt.Sprintf("%s %s snoozed until %s (M wakes it now)", snoozeIcon, name, until.Format("15:04"))
+}
+
+// wakeSnoozed re-enables sessions and agents whose snooze ended.
+func (m *Model) wakeSnoozed() {
+	woke := m.tree.Wake(time.Now(), false)
+	if len(woke) == 0 {
+		return
+	}
+	m.syncFilters()
+	m.status = fmt.Sprintf("%s back after a snooze", strings.Join(woke, ", "))
+}
+
+// snooze is ":snooze <dur>", which snoozes the selected session or agent
+// for dur; without one it lists what is snoozed.
+func (m *Model) snooze(arg string) (string, error) {
+	if arg == "" {
+		var snoozed []string
+		now := time.Now()
+		var walk func(node *TreeNode)
+		walk = func(node *TreeNode) {
+			if !node.SnoozedUntil.IsZero() && (node.Parent == nil || node.Parent.SnoozedUntil.IsZero()) {
+				snoozed = append(snoozed, node.Name+" "+snoozeLeft(node.SnoozedUntil, now))
+				return
+			}
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+		walk(m.tree.Root)
+		if len(snoozed) == 0 {
+			return "nothing snoozed (M in the tree snoozes for " + formatWindow(defaultSnooze) + ")", nil
+		}
+		return snoozeIcon + " " + strings.Join(snoozed, " │ "), nil
+	}
+	d, err := parsePaletteDuration(arg)
+	if err != nil {
+		return "", err
+	}
+	m.snoozeSelected(d)
+	return m.status, nil
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSnooze(t *testing.T) {
+	m := treeModel(t)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.tree.MoveTo(0) // session s1
+	m.Update(key("M"))
+	if m.tree.IsEnabled("s1", "") || m.tree.IsEnabled("s1", "a1") || !m.tree.IsEnabled("s2", "") {
+		t.Fatal("M didn't snooze just s1")
+	}
+	if !strings.Contains(m.tree.View(), snoozeIcon+" 10m") {
+		t.Errorf("tree lacks the snooze badge:\n%s", m.tree.View())
+	}
+	if st := m.viewState(); len(st.Disabled) != 0 {
+		t.Errorf("snooze was saved: %v", st.Disabled)
+	}
+
+	if woke := m.tree.Wake(time.Now().Add(5*time.Minute), false); len(woke) != 0 {
+		t.Errorf("woke early: %v", woke)
+	}
+	if woke := m.tree.Wake(time.Now().Add(11*time.Minute), false); len(woke) != 1 || woke[0] != "api" {
+		t.Errorf("woke %v, want the session only", woke)
+	}
+	if !m.tree.IsEnabled("s1", "") || !m.tree.IsEnabled("s1", "a1") {
+		t.Error("s1 still disabled after its snooze")
+	}
+
+	// An agent: M again wakes it early; u undoes the wake.
+	m.tree.MoveTo(2)
+	m.Update(key("M"))
+	if m.tree.IsEnabled("s1", "a1") || !m.tree.IsEnabled("s1", "") {
+		t.Fatal("M didn't snooze just the agent")
+	}
+	m.Update(key("M"))
+	if !m.tree.IsEnabled("s1", "a1") || !m.tree.GetSelectedNode().SnoozedUntil.IsZero() {
+		t.Error("second M didn't wake the agent")
+	}
+	m.Update(key("u"))
+	if m.tree.IsEnabled("s1", "a1") || m.tree.GetSelectedNode().SnoozedUntil.IsZero() {
+		t.Error("undo didn't restore the snooze")
+	}
+
+	m.runPalette("snooze")
+	if !strings.Contains(m.status, snoozeIcon) {
+		t.Errorf(":snooze status %q", m.status)
+	}
+	m.runPalette("snooze 1h")
+	if left := time.Until(m.tree.GetSelectedNode().SnoozedUntil); left < 59*time.Minute {
+		t.Errorf(":snooze 1h left %v", left)
+	}
+}
+
+func TestSnoozeLeft(t *testing.T) {
+	now := time.Now()
+	for d, want := range map[time.Duration]string{
+		10 * time.Minute:            "10m",
+		9*time.Minute + time.Second: "10m",
+		30 * time.Second:            "1m",
+		65 * time.Minute:            "1h5m",
+		-time.Second:                "0m",
+	} {
+		if got := snoozeLeft(now.Add(d), now); got != want {
+			t.Errorf("%v: %q, want %q", d, got, want)
+		}
+	}
+}
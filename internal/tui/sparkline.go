@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Each session row in the tree ends in a sparkline of how many items the
+// session produced per minute over the last sparkBuckets minutes, newest on
+// the right. All sessions share one scale, so a row's bars show how busy it
+// is next to the others, not just next to its own past.
+const (
+	sparkBuckets = 10          // minutes shown
+	sparkBucket  = time.Minute // one bar
+	minSpark     = 4           // fewer bars than this aren't worth drawing
+)
+
+// sparkLevels are the bar glyphs, lowest first. A minute with no items is
+// blank, so any activity at all shows at least the lowest bar.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// activity counts one session's items per minute in a ring.
+type activity struct {
+	minutes [sparkBuckets]int64 // minute (Unix/60) each slot holds
+	counts  [sparkBuckets]int
+}
+
+// add counts an item at t. Items older than the slot they'd reuse are
+// dropped; the sparkline only ever shows the last sparkBuckets minutes.
+func (a *activity) add(t time.Time) {
+	m := t.Unix() / int64(sparkBucket/time.Second)
+	i := m % sparkBuckets
+	switch {
+	case a.minutes[i] == m:
+		a.counts[i]++
+	case a.minutes[i] < m:
+		a.minutes[i], a.counts[i] = m, 1
+	}
+}
+
+// values returns the last n per-minute counts up to now, oldest first.
+func (a *activity) values(now time.Time, n int) []int {
+	out := make([]int, n)
+	last := now.Unix() / int64(sparkBucket/time.Second)
+	for k := range n {
+		m := last - int64(n-1-k)
+		if i := m % sparkBuckets; a.minutes[i] == m {
+			out[k] = a.counts[i]
+		}
+	}
+	return out
+}
+
+// peak is the highest per-minute count in the window.
+func (a *activity) peak(now time.Time) int {
+	return slices.Max(a.values(now, sparkBuckets))
+}
+
+// sparkline draws counts scaled to top.
+func sparkline(counts []int, top int) string {
+	var b strings.Builder
+	for _, c := range counts {
+		if c <= 0 || top <= 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		level := (c*len(sparkLevels) + top - 1) / top // ceil, so 1 item still shows
+		b.WriteRune(sparkLevels[min(level, len(sparkLevels))-1])
+	}
+	return b.String()
+}
+
+// RecordActivity counts one item for a session's sparkline. A zero time
+// means now.
+func (t *TreeView) RecordActivity(sessionID string, at time.Time) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if t.activity == nil {
+		t.activity = make(map[string]*activity)
+	}
+	a := t.activity[sessionID]
+	if a == nil {
+		a = &activity{}
+		t.activity[sessionID] = a
+	}
+	a.add(at)
+}
+
+// activityPeak is the busiest minute across all sessions, the shared scale.
+func (t *TreeView) activityPeak(now time.Time) int {
+	top := 0
+	for _, a := range t.activity {
+		top = max(top, a.peak(now))
+	}
+	return top
+}
+
+// sessionSparkline returns up to width bars for a session (the most recent
+// minutes), or "" if there's no room or no recent activity.
+func (t *TreeView) sessionSparkline(sessionID string, now time.Time, width, top int) string {
+	a := t.activity[sessionID]
+	if a == nil || top == 0 || width < minSpark {
+		return ""
+	}
+	counts := a.values(now, min(width, sparkBuckets))
+	if slices.Max(counts) == 0 {
+		return ""
+	}
+	return sparkline(counts, top)
+}
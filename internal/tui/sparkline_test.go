@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivityValues(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	var a activity
+	a.add(now)
+	a.add(now.Add(-time.Minute))
+	a.add(now.Add(-time.Minute))
+	a.add(now.Add(-3 * time.Minute))
+	a.add(now.Add(-sparkBuckets * time.Minute)) // just out of the window
+	if got, want := a.values(now, 4), []int{1, 0, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+	// An old item doesn't overwrite the newer minute sharing its slot.
+	a.add(now.Add(-sparkBuckets * 2 * time.Minute))
+	if got := a.values(now, 1); got[0] != 1 {
+		t.Errorf("newest minute = %d after an old item, want 1", got[0])
+	}
+	// Ten minutes on, everything has scrolled out.
+	if a.peak(now.Add(sparkBuckets*time.Minute)) != 0 {
+		t.Error("stale counts still in the window")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := sparkline([]int{0, 1, 4, 8}, 8), " ▁▄█"; got != want {
+		t.Errorf("sparkline = %q, want %q", got, want)
+	}
+}
+
+func TestTreeSparklineSharedScale(t *testing.T) {
+	tree := NewTreeView()
+	tree.SetSize(40, 10)
+	tree.AddSession("busy", "/src/busy")
+	tree.AddSession("quiet", "/src/quiet")
+	tree.AddSession("idle", "/src/idle")
+	now := time.Now()
+	for range 8 {
+		tree.RecordActivity("busy", now)
+	}
+	tree.RecordActivity("quiet", now)
+
+	rows := strings.Split(stripAnsi(tree.View()), "\n")
+	find := func(name string) string {
+		for _, row := range rows {
+			if strings.Contains(row, name) {
+				return strings.TrimRight(row, " ")
+			}
+		}
+		t.Fatalf("no row for %s in\n%s", name, strings.Join(rows, "\n"))
+		return ""
+	}
+	if row := find("busy"); !strings.HasSuffix(row, "█") {
+		t.Errorf("busy row %q should end in a full bar", row)
+	}
+	if row := find("quiet"); !strings.HasSuffix(row, "▁") {
+		t.Errorf("quiet row %q should end in the lowest bar", row)
+	}
+	if row := find("idle"); strings.ContainsAny(row, string(sparkLevels)) {
+		t.Errorf("idle row %q has a sparkline", row)
+	}
+}
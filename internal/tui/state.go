@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/phiat/claude-esp/internal/uistate"
+)
+
+// minTreeWidth keeps a restored tree pane usable.
+const minTreeWidth = 16
+
+// restoreView applies the saved toggles, layout and focus. Tree selections
+// are applied per node as sessions and agents appear (restoreSession,
+// restoreAgent). It runs from Init, once the watched set is final.
+func (m *Model) restoreView() {
+	if m.stateDir != "" {
+		m.uiState = uistate.New(m.stateDir, m.watchedSet())
+	}
+	st, ok := m.uiState.Load()
+	if !ok {
+		return
+	}
+	m.saved = st
+	m.stream.SetToggles(st.Thinking, st.ToolInput, st.ToolOutput, st.Text, !st.HideHooks)
+	m.stream.SetStderrOnly(st.StderrOnly)
+	m.stream.SetHighlights(st.Highlights)
+	m.stream.SetAutoScroll(st.AutoScroll)
+	m.showTree = st.ShowTree
+	if st.TreeWidth >= minTreeWidth {
+		m.treeWidth = st.TreeWidth
+	}
+	m.showTimeline = st.View == "timeline"
+	m.showStats = st.View == "stats"
+	m.showRecap = st.View == "recap"
+	m.showLongRun = st.View == "long_running"
+	m.showTests = st.View == "tests"
+	m.showFiles = st.View == "files"
+	if st.Focus == "tree" {
+		m.focus = FocusTree
+	}
+	m.tree.SetFilter(st.Filter)
+	m.ignored = slices.Clone(st.Ignored)
+}
+
+// restoreSession re-collapses a session that was collapsed last time and
+// re-disables its main conversation if it was disabled.
+func (m *Model) restoreSession(sessionID string) {
+	if m.saved.IsCollapsed(sessionID) {
+		m.tree.SetCollapsed(sessionID, true)
+	}
+	m.restoreAgent(sessionID, "")
+}
+
+// restoreAgent re-disables an agent that was disabled last time.
+func (m *Model) restoreAgent(sessionID, agentID string) {
+	if m.saved.IsDisabled(sessionID, agentID) {
+		m.tree.SetEnabled(sessionID, agentID, false)
+	}
+}
+
+// viewState captures the current view. Selections for sessions not in the
+// tree this run are carried over from the saved state, so they come back
+// when the session does.
+func (m *Model) viewState() uistate.State {
+	st := uistate.State{
+		Watch:      m.watchedSet(),
+		Thinking:   m.stream.IsThinkingEnabled(),
+		ToolInput:  m.stream.IsToolInputEnabled(),
+		ToolOutput: m.stream.IsToolOutputEnabled(),
+		StderrOnly: m.stream.IsStderrOnly(),
+		Highlights: m.stream.IsHighlights(),
+		Text:       m.stream.IsTextEnabled(),
+		HideHooks:  !m.stream.IsHooksEnabled(),
+		AutoScroll: m.stream.IsAutoScrollEnabled(),
+		ShowTree:   m.showTree,
+		TreeWidth:  m.treeWidth,
+		Focus:      "stream",
+		Filter:     m.tree.Filter(),
+		Ignored:    slices.Sorted(slices.Values(m.ignored)),
+	}
+	switch {
+	case m.showTimeline:
+		st.View = "timeline"
+	case m.showStats:
+		st.View = "stats"
+	case m.showRecap:
+		st.View = "recap"
+	case m.showLongRun:
+		st.View = "long_running"
+	case m.showTests:
+		st.View = "tests"
+	case m.showFiles:
+		st.View = "files"
+	}
+	if m.focus == FocusTree {
+		st.Focus = "tree"
+	}
+
+	present := map[string]bool{}
+	for _, session := range m.tree.Root.Children {
+		if session.Type != NodeTypeSession {
+			continue
+		}
+		present[session.ID] = true
+		if session.Collapsed {
+			st.Collapsed = append(st.Collapsed, session.ID)
+		}
+		for _, child := range session.Children {
+			// A snooze ends on its own, and an agent waiting for + hasn't
+			// been hidden by choice; neither is saved.
+			if child.Enabled || !child.SnoozedUntil.IsZero() || child.Asking {
+				continue
+			}
+			switch child.Type {
+			case NodeTypeMain:
+				st.Disable(session.ID, "")
+			case NodeTypeAgent:
+				st.Disable(session.ID, child.ID)
+			}
+		}
+	}
+	for sessionID, agents := range m.saved.Disabled {
+		if !present[sessionID] {
+			st.Disable(sessionID, agents...)
+		}
+	}
+	for _, sessionID := range m.saved.Collapsed {
+		if !present[sessionID] {
+			st.Collapsed = append(st.Collapsed, sessionID)
+		}
+	}
+	for _, agents := range st.Disabled {
+		slices.Sort(agents)
+	}
+	slices.Sort(st.Collapsed)
+	return st
+}
+
+// saveView writes the view if it changed since the last save.
+func (m *Model) saveView() {
+	if err := m.uiState.Save(m.viewState()); err != nil {
+		m.status = fmt.Sprintf("view not saved: %v", err)
+	}
+}
+
+// watchedSet is the watched set the view is saved under; nil means
+// auto-discovery.
+func (m *Model) watchedSet() []string {
+	if m.sessionFile != "" {
+		return []string{"file:" + m.sessionFile}
+	}
+	return m.sessionIDs
+}
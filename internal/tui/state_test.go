@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestViewSurvivesRestart(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	newModel := func(sessionIDs ...string) *Model {
+		m := NewModel(sessionIDs, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+		m.restoreView() // as Init does, without starting a watcher
+		m.Update(newSessionMsg{SessionID: "s1", ProjectPath: "/work/api"})
+		m.Update(newAgentMsg{SessionID: "s1", AgentID: "a1"})
+		return m
+	}
+	press := func(m *Model, keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "tab" {
+				msg = tea.KeyMsg{Type: tea.KeyTab}
+			}
+			m.Update(msg)
+		}
+	}
+
+	m := newModel()
+	// Hide thinking, switch to stats, focus the tree and disable Main (the
+	// row below the session).
+	press(m, "t", "$", "tab", "j", " ")
+	if m.tree.IsEnabled("s1", "") {
+		t.Fatal("setup: Main still enabled")
+	}
+
+	m = newModel()
+	if m.stream.IsThinkingEnabled() || !m.showStats || m.focus != FocusTree {
+		t.Errorf("toggles/layout not restored: thinking=%v stats=%v focus=%v",
+			m.stream.IsThinkingEnabled(), m.showStats, m.focus)
+	}
+	if m.tree.IsEnabled("s1", "") || !m.tree.IsEnabled("s1", "a1") {
+		t.Error("tree selections not restored")
+	}
+
+	// A different watched set starts fresh; the same set in another order
+	// doesn't.
+	m = newModel("s1", "s2")
+	if !m.stream.IsThinkingEnabled() || m.showStats || !m.tree.IsEnabled("s1", "") {
+		t.Error("view leaked across watched sets")
+	}
+	press(m, "t")
+	if m = newModel("s2", "s1"); m.stream.IsThinkingEnabled() {
+		t.Error("view not shared by the same sessions in another order")
+	}
+}
+
+func TestViewKeepsAbsentSessions(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.saved.Disable("gone", "a9")
+	m.saved.Collapsed = []string{"gone"}
+	st := m.viewState()
+	if !st.IsDisabled("gone", "a9") || !st.IsCollapsed("gone") {
+		t.Errorf("selections for a session not seen this run were dropped: %+v", st)
+	}
+}
@@ -0,0 +1,328 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/cost"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// agentStats is the running usage of one agent (Main or a subagent).
+type agentStats struct {
+	agentID       string
+	name          string
+	inputTokens   int64
+	outputTokens  int64
+	cacheCreation int64
+	cacheRead     int64
+	costUSD       float64
+	toolCalls     int
+	buckets       map[time.Time]*statsBucket // usage per bucketSize, by start
+}
+
+// statsBucket is an agent's usage in one bucketSize slice of time.
+type statsBucket struct {
+	tokens int64
+	tools  int
+}
+
+// bucketSize is the slice of time the stats view charts usage by.
+const bucketSize = 5 * time.Minute
+
+// chartBuckets is how many of a session's latest buckets the chart shows.
+const chartBuckets = 12
+
+// tokens is every token billed to the agent, cache traffic included.
+func (a *agentStats) tokens() int64 {
+	return a.inputTokens + a.outputTokens + a.cacheCreation + a.cacheRead
+}
+
+// sessionStats groups agent usage and Task fan-out for one session.
+type sessionStats struct {
+	sessionID      string
+	agents         []*agentStats   // Main first, then subagents in arrival order
+	openTasks      map[string]bool // Task/Agent tool_use IDs awaiting their result
+	tasksSpawned   int
+	tasksCompleted int
+}
+
+// StatsView breaks token usage, cost and tool calls down per agent within
+// each session, so it is clear whether subagents are driving spend, and
+// reports Task fan-out efficiency (subagent tokens per completed Task).
+type StatsView struct {
+	pane
+	prices   *cost.Table
+	sessions []*sessionStats
+}
+
+// NewStatsView creates an empty stats view that prices usage with prices
+// (nil means the builtin table).
+func NewStatsView(prices *cost.Table) *StatsView {
+	if prices == nil {
+		prices = cost.DefaultTable()
+	}
+	return &StatsView{prices: prices}
+}
+
+// AddItem accumulates the item's usage and tool activity.
+func (s *StatsView) AddItem(item parser.StreamItem) {
+	sess := s.sessionFor(item.SessionID)
+	agent := sess.agentFor(item)
+
+	agent.inputTokens += item.InputTokens
+	agent.outputTokens += item.OutputTokens
+	agent.cacheCreation += item.CacheCreationTokens
+	agent.cacheRead += item.CacheReadTokens
+	agent.costUSD += s.prices.Estimate(item.Model, cost.Usage{
+		InputTokens:         item.InputTokens,
+		OutputTokens:        item.OutputTokens,
+		CacheCreationTokens: item.CacheCreationTokens,
+		CacheReadTokens:     item.CacheReadTokens,
+	})
+
+	bucket := &statsBucket{} // discarded for items without a time
+	if !item.Timestamp.IsZero() {
+		at := item.Timestamp.Truncate(bucketSize)
+		if agent.buckets[at] == nil {
+			agent.buckets[at] = &statsBucket{}
+		}
+		bucket = agent.buckets[at]
+	}
+	bucket.tokens += item.InputTokens + item.OutputTokens + item.CacheCreationTokens + item.CacheReadTokens
+
+	switch item.Type {
+	case parser.TypeToolInput:
+		agent.toolCalls++
+		bucket.tools++
+		if isTaskTool(item.ToolName) && item.ToolID != "" {
+			sess.openTasks[item.ToolID] = true
+			sess.tasksSpawned++
+		}
+	case parser.TypeToolOutput:
+		// Tool results don't carry the tool name; match on the tool_use ID.
+		if sess.openTasks[item.ToolID] {
+			delete(sess.openTasks, item.ToolID)
+			sess.tasksCompleted++
+		}
+	}
+}
+
+// isTaskTool reports whether a tool spawns a subagent. "Task" is the legacy
+// name; "Agent" is current (Claude Code 2.x).
+func isTaskTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+func (s *StatsView) sessionFor(sessionID string) *sessionStats {
+	for _, sess := range s.sessions {
+		if sess.sessionID == sessionID {
+			return sess
+		}
+	}
+	sess := &sessionStats{sessionID: sessionID, openTasks: make(map[string]bool)}
+	s.sessions = append(s.sessions, sess)
+	return sess
+}
+
+func (sess *sessionStats) agentFor(item parser.StreamItem) *agentStats {
+	for _, a := range sess.agents {
+		if a.agentID == item.AgentID {
+			if a.name == "" {
+				a.name = item.AgentName
+			}
+			return a
+		}
+	}
+	a := &agentStats{agentID: item.AgentID, name: item.AgentName, buckets: make(map[time.Time]*statsBucket)}
+	if item.AgentID == "" {
+		sess.agents = append([]*agentStats{a}, sess.agents...)
+	} else {
+		sess.agents = append(sess.agents, a)
+	}
+	return a
+}
+
+// View renders one table per session with visible agents.
+func (s *StatsView) View() string {
+	innerWidth := max(1, s.width-4)
+	innerHeight := max(1, s.height-2)
+
+	// Truncate before styling: runewidth doesn't skip ANSI sequences.
+	fit := func(line string) string { return runewidth.Truncate(line, innerWidth, "…") }
+
+	var lines []string
+	for _, sess := range s.sessions {
+		var agents []*agentStats
+		var total, subTokens int64
+		var totalCost, subCost float64
+		for _, a := range sess.agents {
+			if !s.enabled(sess.sessionID, a.agentID) {
+				continue
+			}
+			agents = append(agents, a)
+			total += a.tokens()
+			totalCost += a.costUSD
+			if a.agentID != "" {
+				subTokens += a.tokens()
+				subCost += a.costUSD
+			}
+		}
+		if len(agents) == 0 || (total == 0 && sess.tasksSpawned == 0) {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, statsHeaderStyle.Render(fit(fmt.Sprintf("Session %s  %s tokens ≈ $%.2f",
+			truncate(sess.sessionID, 12), formatTokenCount(total), totalCost))))
+		lines = append(lines, mutedStyle.Render(fit(fmt.Sprintf("  %-14s %7s %7s %7s %8s %6s %5s",
+			"agent", "in", "out", "cache", "cost", "share", "tools"))))
+
+		// Biggest spender first (Main stays put when it leads anyway).
+		sorted := append([]*agentStats(nil), agents...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].tokens() > sorted[j].tokens() })
+		for _, a := range sorted {
+			share := 0.0
+			if total > 0 {
+				share = float64(a.tokens()) / float64(total) * 100
+			}
+			row := fmt.Sprintf("  %-14s %7s %7s %7s %8s %5.0f%% %5d",
+				truncate(a.name, 14),
+				formatTokenCount(a.inputTokens),
+				formatTokenCount(a.outputTokens),
+				formatTokenCount(a.cacheCreation+a.cacheRead),
+				fmt.Sprintf("$%.2f", a.costUSD),
+				share, a.toolCalls)
+			style := mainAgentStyle
+			if a.agentID != "" {
+				style = subAgentStyle
+			}
+			lines = append(lines, style.Render(fit(row)))
+		}
+
+		if total > 0 {
+			lines = append(lines, fit(fmt.Sprintf("  subagents: %.0f%% of tokens, $%.2f",
+				float64(subTokens)/float64(total)*100, subCost)))
+		}
+		if sess.tasksSpawned > 0 {
+			fanout := fmt.Sprintf("  Task fan-out: %d spawned, %d completed", sess.tasksSpawned, sess.tasksCompleted)
+			if sess.tasksCompleted > 0 {
+				fanout += fmt.Sprintf(" · %s tokens ($%.2f) per completed Task",
+					formatTokenCount(subTokens/int64(sess.tasksCompleted)), subCost/float64(sess.tasksCompleted))
+			}
+			lines = append(lines, fit(fanout))
+		}
+		for _, line := range chartLines(agents, innerWidth) {
+			lines = append(lines, fit(line))
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = []string{mutedStyle.Render("No token usage yet.")}
+	}
+	return padLines(lines, innerHeight)
+}
+
+// chartLines charts agents' tokens and tool calls per bucketSize as bars,
+// one row per bucket from the oldest of the last chartBuckets to the
+// newest, idle ones included, so a session's pace shows: climbing,
+// levelling off, or calls piling up for few tokens. It is empty until
+// the usage spans two buckets.
+func chartLines(agents []*agentStats, width int) []string {
+	sums := make(map[time.Time]statsBucket)
+	var first, last time.Time
+	for _, a := range agents {
+		for at, b := range a.buckets {
+			sum := sums[at]
+			sum.tokens += b.tokens
+			sum.tools += b.tools
+			sums[at] = sum
+			if first.IsZero() || at.Before(first) {
+				first = at
+			}
+			if at.After(last) {
+				last = at
+			}
+		}
+	}
+	if !last.After(first) {
+		return nil
+	}
+	first = later(first, last.Add(-(chartBuckets-1)*bucketSize))
+
+	var rows []statsBucket
+	var maxTokens int64
+	var maxTools int
+	for at := first; !at.After(last); at = at.Add(bucketSize) {
+		b := sums[at]
+		rows = append(rows, b)
+		maxTokens = max(maxTokens, b.tokens)
+		maxTools = max(maxTools, b.tools)
+	}
+	// "  15:04 " + bar + " 12.3k " + bar + " 1234"
+	room := max(6, width-25)
+	tokenWidth := min(40, room*2/3)
+	toolWidth := min(20, room-tokenWidth)
+
+	title := "  tokens and tool calls per 5 minutes"
+	if pace := paceLabel(rows); pace != "" {
+		title += " · " + pace
+	}
+	lines := []string{"", mutedStyle.Render(title)}
+	for i, b := range rows {
+		at := first.Add(time.Duration(i) * bucketSize)
+		lines = append(lines, fmt.Sprintf("  %s %s %6s %s %5d",
+			at.Local().Format("15:04"),
+			sparkStyle.Render(statsBar(float64(b.tokens), float64(maxTokens), tokenWidth)),
+			formatTokenCount(b.tokens),
+			toolInputStyle.Render(statsBar(float64(b.tools), float64(maxTools), toolWidth)),
+			b.tools))
+	}
+	return lines
+}
+
+// statsBar is a bar of v out of top, width cells long when full, padded to
+// width. Anything above zero shows at least one cell.
+func statsBar(v, top float64, width int) string {
+	n := 0
+	if top > 0 && v > 0 {
+		n = max(1, min(width, int(v/top*float64(width)+0.5)))
+	}
+	return strings.Repeat("█", n) + strings.Repeat(" ", width-n)
+}
+
+// paceLabel compares the tokens of the last three buckets with the three
+// before: "accelerating", "slowing down" or "steady" past a 50% change.
+func paceLabel(rows []statsBucket) string {
+	if len(rows) < 6 {
+		return ""
+	}
+	var recent, before int64
+	for _, b := range rows[len(rows)-3:] {
+		recent += b.tokens
+	}
+	for _, b := range rows[len(rows)-6 : len(rows)-3] {
+		before += b.tokens
+	}
+	switch {
+	case before == 0 && recent == 0:
+		return "idle"
+	case float64(recent) > float64(before)*1.5:
+		return "accelerating"
+	case float64(recent) < float64(before)/1.5:
+		return "slowing down"
+	}
+	return "steady"
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
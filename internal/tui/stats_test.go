@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestStats_PerAgentBreakdown(t *testing.T) {
+	sv := NewStatsView(nil)
+	sv.AddItem(newTestItem(parser.TypeToolInput, "s1", "a1", "", withTool("Read", "t1"), withTokens(3000)))
+	sv.AddItem(newTestItem(parser.TypeText, "s1", "", "", withTokens(1000)))
+	sv.AddItem(newTestItem(parser.TypeToolInput, "s1", "a1", "", withTool("Grep", "t2")))
+
+	sess := sv.sessions[0]
+	if len(sess.agents) != 2 || sess.agents[0].agentID != "" {
+		t.Fatalf("expected Main first then subagent, got %+v", sess.agents)
+	}
+	sub := sess.agents[1]
+	if sub.outputTokens != 3000 || sub.toolCalls != 2 {
+		t.Errorf("subagent stats = %+v", sub)
+	}
+	if sub.costUSD <= sess.agents[0].costUSD {
+		t.Errorf("subagent cost %v should exceed main %v", sub.costUSD, sess.agents[0].costUSD)
+	}
+}
+
+func TestStats_TaskFanout(t *testing.T) {
+	sv := NewStatsView(nil)
+	sv.SetSize(100, 20)
+	sv.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}, {SessionID: "s1", AgentID: "a1"}})
+
+	sv.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "", withTool("Agent", "task1"), withTokens(100)))
+	sv.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "", withTool("Task", "task2")))
+	sv.AddItem(newTestItem(parser.TypeText, "s1", "a1", "", withTokens(4000)))
+	sv.AddItem(newTestItem(parser.TypeToolOutput, "s1", "", "", withTool("", "task1")))
+
+	sess := sv.sessions[0]
+	if sess.tasksSpawned != 2 || sess.tasksCompleted != 1 {
+		t.Errorf("spawned/completed = %d/%d, want 2/1", sess.tasksSpawned, sess.tasksCompleted)
+	}
+	view := sv.View()
+	if !strings.Contains(view, "2 spawned, 1 completed") {
+		t.Errorf("view missing fan-out summary:\n%s", view)
+	}
+	if !strings.Contains(view, "4.0k tokens") {
+		t.Errorf("view should report subagent tokens per completed Task:\n%s", view)
+	}
+}
+
+func TestStats_HidesDisabledAgents(t *testing.T) {
+	sv := NewStatsView(nil)
+	sv.SetSize(100, 20)
+	sv.AddItem(newTestItem(parser.TypeText, "s1", "", "", withTokens(1000)))
+	if !strings.Contains(sv.View(), "No token usage yet") {
+		t.Error("agents not in the enabled filters should not be shown")
+	}
+}
+
+func TestStats_Buckets(t *testing.T) {
+	sv := NewStatsView(nil)
+	sv.SetSize(100, 40)
+	sv.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}, {SessionID: "s1", AgentID: "a1"}})
+	t0 := time.Date(2025, 6, 1, 14, 0, 0, 0, time.Local)
+	add := func(minute int, agentID string, tokens int64, tool bool) {
+		item := newTestItem(parser.TypeText, "s1", agentID, "", withTokens(tokens))
+		if tool {
+			item.Type, item.ToolName = parser.TypeToolInput, "Bash"
+		}
+		item.Timestamp = t0.Add(time.Duration(minute) * time.Minute)
+		sv.AddItem(item)
+	}
+	add(1, "", 1000, true)
+	add(3, "a1", 1000, true)
+	// 14:05 and 14:10 idle
+	add(16, "", 1000, false)
+	add(17, "", 1000, false)
+	for range 4 {
+		add(18, "a1", 0, true)
+	}
+
+	lines := strings.Split(sv.View(), "\n")
+	var rows []string
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "14:") {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) != 4 {
+		t.Fatalf("want rows for 14:00 to 14:15, idle ones included, got %d:\n%s", len(rows), strings.Join(lines, "\n"))
+	}
+	for i, want := range []struct {
+		prefix string
+		tools  string
+	}{{"14:00", "2"}, {"14:05", "0"}, {"14:10", "0"}, {"14:15", "4"}} {
+		fields := strings.Fields(rows[i])
+		if fields[0] != want.prefix || fields[len(fields)-1] != want.tools {
+			t.Errorf("row %d = %q, want %s with %s tool calls", i, rows[i], want.prefix, want.tools)
+		}
+	}
+	if !strings.Contains(rows[0], "2.0k") || !strings.Contains(rows[0], "████") || strings.Contains(rows[1], "█") {
+		t.Errorf("bars: busy %q, idle %q", rows[0], rows[1])
+	}
+}
+
+func TestStats_PaceLabel(t *testing.T) {
+	rows := func(tokens ...int64) []statsBucket {
+		var out []statsBucket
+		for _, n := range tokens {
+			out = append(out, statsBucket{tokens: n})
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		rows []statsBucket
+		want string
+	}{
+		{rows(1, 1, 1, 1, 1), ""},
+		{rows(10, 10, 10, 30, 30, 30), "accelerating"},
+		{rows(30, 30, 30, 10, 10, 10), "slowing down"},
+		{rows(10, 10, 10, 12, 9, 11), "steady"},
+		{rows(0, 0, 0, 0, 0, 0), "idle"},
+	} {
+		if got := paceLabel(tc.rows); got != tc.want {
+			t.Errorf("paceLabel(%v) = %q, want %q", tc.rows, got, tc.want)
+		}
+	}
+}
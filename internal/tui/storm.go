@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// When an agent dumps output faster than the screen can sensibly redraw,
+// items are taken from the watcher in batches and the stream is rendered
+// once per batch, at most every minRenderInterval. Nothing is dropped:
+// whatever doesn't fit in a batch waits in the watcher for the next one.
+// While batches come in full, the stream pane shows a fast-forwarding chip.
+const (
+	minRenderInterval = 100 * time.Millisecond // ≤10 renders/sec
+	maxBatchItems     = 1000                   // per render, so one frame can't stall for long
+	fastForwardBatch  = 50                     // a batch this big means we're behind
+	fastForwardLinger = 500 * time.Millisecond // keep the chip up this long after the storm
+	stormWindow       = 50 * time.Millisecond  // how long a storm batch waits for more
+)
+
+// streamItemsMsg is a batch of items received together.
+type streamItemsMsg []parser.StreamItem
+
+// rearmWaitMsg ends the pause between batches in low-power mode.
+type rearmWaitMsg struct{}
+
+// drainItems returns first plus whatever else is already queued, up to
+// maxBatchItems. Once the batch is big enough to mean a storm, it also
+// waits up to stormWindow for the watcher to refill its queue, so that one
+// render covers more than a queueful.
+func drainItems(items <-chan parser.StreamItem, first parser.StreamItem) streamItemsMsg {
+	batch := streamItemsMsg{first}
+	var window <-chan time.Time
+	for len(batch) < maxBatchItems {
+		select {
+		case item := <-items:
+			batch = append(batch, item)
+			continue
+		default:
+		}
+		if len(batch) < fastForwardBatch {
+			return batch
+		}
+		if window == nil {
+			window = time.After(stormWindow)
+		}
+		select {
+		case item := <-items:
+			batch = append(batch, item)
+		case <-window:
+			return batch
+		}
+	}
+	return batch
+}
+
+// addItems adds a batch with a single stream render.
+func (m *Model) addItems(batch streamItemsMsg) {
+	m.lastBatch = time.Now()
+	if len(batch) >= fastForwardBatch {
+		m.fastForward = len(batch)
+	}
+	m.stream.Hold()
+	for _, item := range batch {
+		m.addItem(item)
+	}
+	m.stream.Release()
+}
+
+// catchUp clears the fast-forwarding chip once batches have stayed small
+// for a while.
+func (m *Model) catchUp() {
+	if m.fastForward > 0 && time.Since(m.lastBatch) >= fastForwardLinger {
+		m.fastForward = 0
+	}
+}
+
+// rearmWait arms the blocking wait in low-power mode, no sooner than
+// minRenderInterval after the last batch. The tick paces batches in normal
+// mode.
+func (m *Model) rearmWait() tea.Cmd {
+	if m.wait != nil || m.waitPaused {
+		return nil
+	}
+	if wait := minRenderInterval - time.Since(m.lastBatch); wait > 0 {
+		m.waitPaused = true
+		return tea.Tick(wait, func(time.Time) tea.Msg { return rearmWaitMsg{} })
+	}
+	return m.waitWatcher()
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestDrainItems(t *testing.T) {
+	ch := make(chan parser.StreamItem, maxBatchItems+100)
+	for i := range maxBatchItems + 100 {
+		ch <- parser.StreamItem{Content: fmt.Sprint(i)}
+	}
+	batch := drainItems(ch, parser.StreamItem{Content: "first"})
+	if len(batch) != maxBatchItems || batch[0].Content != "first" || batch[1].Content != "0" {
+		t.Fatalf("batch of %d starting %q, %q", len(batch), batch[0].Content, batch[1].Content)
+	}
+	if len(ch) != 101 {
+		t.Errorf("%d items left queued, want 101", len(ch))
+	}
+	if batch := drainItems(make(chan parser.StreamItem), parser.StreamItem{}); len(batch) != 1 {
+		t.Errorf("batch from an empty channel has %d items", len(batch))
+	}
+}
+
+func TestStreamHoldDefersRender(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 20)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}})
+	s.Hold()
+	s.AddItem(parser.StreamItem{Type: parser.TypeText, SessionID: "s1", Content: "held back", Timestamp: time.Now()})
+	if strings.Contains(s.View(), "held back") {
+		t.Error("rendered while held")
+	}
+	s.Release()
+	if !strings.Contains(s.View(), "held back") {
+		t.Error("not rendered after Release")
+	}
+}
+
+func TestFastForwardChip(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	m.tree.AddSession("s1", "/p")
+	m.syncFilters()
+
+	var batch streamItemsMsg
+	for i := range fastForwardBatch {
+		batch = append(batch, parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", AgentName: "Main", Content: fmt.Sprint("line ", i), Timestamp: time.Now()})
+	}
+	m.Update(batch)
+	if len(m.stream.items) != fastForwardBatch {
+		t.Fatalf("stream has %d items, want %d", len(m.stream.items), fastForwardBatch)
+	}
+	view := m.View()
+	if !strings.Contains(view, "fast-forwarding") || !strings.Contains(view, fmt.Sprint("line ", fastForwardBatch-1)) {
+		t.Fatal("no fast-forward chip, or the batch wasn't rendered")
+	}
+
+	// Once batches stay small, the chip goes.
+	m.lastBatch = time.Now().Add(-fastForwardLinger)
+	m.Update(tickMsg(time.Now()))
+	if strings.Contains(m.View(), "fast-forwarding") {
+		t.Error("chip still shown after the storm")
+	}
+}
+
+func TestLowPowerWaitsBetweenBatches(t *testing.T) {
+	m := &Model{lowPower: true, lastBatch: time.Now()}
+	if cmd := m.rearmWait(); cmd == nil || !m.waitPaused {
+		t.Fatal("wait re-armed right after a batch")
+	}
+	if cmd := m.rearmWait(); cmd != nil {
+		t.Error("second pause scheduled while one is pending")
+	}
+	m.lastBatch = time.Now().Add(-minRenderInterval)
+	m.Update(rearmWaitMsg{})
+	if m.waitPaused {
+		t.Error("pause not lifted once the interval passed")
+	}
+}
@@ -2,12 +2,22 @@ package tui
 
 import (
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
-	"unicode/utf8"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/buildlog"
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/notes"
 	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/testrun"
 )
 
 const (
@@ -26,15 +36,80 @@ type StreamView struct {
 	height      int
 	autoScroll  bool
 	maxLines    int // max lines per item
+	unseen      int // visible items added below the view while auto-scroll is off
 
 	// Filters
 	showThinking   bool
 	showToolInput  bool
 	showToolOutput bool
 	showText       bool
+	showHooks      bool
+	stderrOnly     bool         // tool and command output show only stderr (O)
+	filter         *filter.Expr // -filter expression; nil shows everything
 
 	// Session/Agent filter (from tree)
 	enabledFilters []EnabledFilter
+
+	// Per-session colors while several sessions are enabled; nil otherwise.
+	// gutter also draws a bar in that color beside each item.
+	sessionColors map[string]lipgloss.TerminalColor
+	gutter        bool
+	typeGutter    bool // a column of item type glyphs; see typeLines
+
+	// Item cursor (J/K). selected indexes items, -1 = no selection.
+	// itemStarts maps each rendered item to its first viewport line and is
+	// rebuilt by updateContent.
+	selected   int
+	mark       int // range anchor (m); the range runs from mark to selected
+	itemStarts []itemStart
+
+	notes *notes.Store // inline annotations; nil = none
+
+	// fullOutput shows every item untruncated (Z). expanded holds the
+	// items toggled the other way (z), by notes.ItemKey.
+	fullOutput bool
+	expanded   map[string]bool
+
+	// Task thread view (f): while set, it replaces the tree filters.
+	tasks  *taskIndex
+	thread *threadFilter
+
+	// Batching (Hold/Release): renders are deferred while held.
+	held  bool
+	stale bool
+
+	// testRuns holds the test results read from Bash results, by tool ID
+	// (see internal/testrun); they show as a badge in the header.
+	testRuns map[string]testrun.Result
+	// problems holds the compiler and linter errors read from Bash
+	// results, by tool ID (see internal/buildlog): a badge in the header,
+	// highlighted locations in the output, and e opens them.
+	problems map[string]buildlog.Result
+	// progress holds the latest progress of running tool calls, by tool
+	// ID; it shows under the call until the result arrives.
+	progress map[string]parser.StreamItem
+
+	// Path cursor (l) in the selected item's output: link indexes its
+	// pathLinks, and linkKey (notes.ItemKey) is the item it was set on.
+	link    int
+	linkKey string
+
+	// scrub freezes the stream at an earlier point ([ and ]): only items
+	// before this index are rendered. -1 = live.
+	scrub int
+
+	// highlights shows only high-signal items (*); see isHighlight.
+	// lastText holds each agent's latest text by session/agent, and
+	// narrated the texts a tool call followed, by notes.ItemKey.
+	highlights bool
+	lastText   map[string]string
+	narrated   map[string]bool
+}
+
+// itemStart records where a rendered item begins in the viewport content.
+type itemStart struct {
+	index int // into StreamView.items
+	line  int
 }
 
 // NewStreamView creates a new stream view
@@ -50,10 +125,27 @@ func NewStreamView() *StreamView {
 		showToolInput:  true,
 		showToolOutput: true,
 		showText:       true,
+		showHooks:      true,
 		enabledFilters: []EnabledFilter{},
+		selected:       -1,
+		mark:           -1,
+		scrub:          -1,
+		tasks:          newTaskIndex(),
+		expanded:       make(map[string]bool),
+		testRuns:       make(map[string]testrun.Result),
+		problems:       make(map[string]buildlog.Result),
+		progress:       make(map[string]parser.StreamItem),
+		lastText:       make(map[string]string),
+		narrated:       make(map[string]bool),
 	}
 }
 
+// SetNotes attaches the note store used to render inline annotations.
+func (s *StreamView) SetNotes(store *notes.Store) {
+	s.notes = store
+	s.updateContent()
+}
+
 // SetSize updates dimensions.
 //
 // `width` is the OUTER width of the bordered pane the stream is rendered
@@ -67,6 +159,11 @@ func NewStreamView() *StreamView {
 // right border. That worked OK for ASCII but interacted with the tree
 // pane's over-wide padding to push the whole TUI past its viewport.
 func (s *StreamView) SetSize(width, height int) {
+	// The model re-applies its layout on every frame; only a real resize
+	// needs a re-render.
+	if width == s.width && height == s.height {
+		return
+	}
 	s.width = width
 	s.height = height
 	innerWidth := width - 4
@@ -82,24 +179,112 @@ func (s *StreamView) SetSize(width, height int) {
 	s.updateContent()
 }
 
-// AddItem adds a new item to the stream
-func (s *StreamView) AddItem(item parser.StreamItem) {
+// AddItem adds a new item to the stream. It reports false for duplicates.
+func (s *StreamView) AddItem(item parser.StreamItem) bool {
 	// Deduplicate by (ToolID, Type) so tool input and output
 	// with the same tool_id are both kept
-	if item.ToolID != "" {
-		dedupKey := fmt.Sprintf("%s:%s", item.ToolID, item.Type)
+	if dedupKey, ok := parser.DedupKey(item); ok {
 		if s.seenToolIDs[dedupKey] {
-			return // Skip duplicate
+			return false // Skip duplicate
 		}
 		s.seenToolIDs[dedupKey] = true
 	}
 
+	s.tasks.add(item)
+	s.noteNarration(item)
+	if item.Type == parser.TypeToolOutput && item.ToolID != "" {
+		delete(s.progress, item.ToolID)
+		for _, other := range slices.Backward(s.items) {
+			if other.Type == parser.TypeToolInput && other.ToolID == item.ToolID {
+				if other.ToolName == "Bash" {
+					command := bashCommand(other)
+					if res, ok := testrun.Recognize(command, item.Content); ok {
+						s.testRuns[item.ToolID] = res
+					}
+					if res, ok := buildlog.Recognize(command, item.Content); ok {
+						s.problems[item.ToolID] = res
+					}
+				}
+				break
+			}
+		}
+	}
+	if s.thread != nil && !s.thread.exact {
+		s.thread.resolve(s.tasks)
+	}
+
 	s.items = append(s.items, item)
 	// Keep last MaxStreamItems items to prevent memory issues
 	if len(s.items) > MaxStreamItems {
-		s.items = s.items[len(s.items)-MaxStreamItems:]
+		dropped := len(s.items) - MaxStreamItems
+		s.items = s.items[dropped:]
+		s.selected = shiftIndex(s.selected, dropped)
+		s.mark = shiftIndex(s.mark, dropped)
+		if s.scrub >= 0 {
+			s.scrub = max(0, s.scrub-dropped)
+		}
+	} else if s.scrub >= 0 {
+		return true // behind the scrub point; nothing shown changes
+	}
+	if !s.autoScroll && s.scrub < 0 && s.isVisible(item) {
+		s.unseen++
+	}
+	s.updateContent()
+	return true
+}
+
+// shiftIndex re-bases an item index after dropped items were trimmed from
+// the front, returning -1 if it fell off.
+func shiftIndex(i, dropped int) int {
+	if i < 0 || i < dropped {
+		return -1
 	}
+	return i - dropped
+}
+
+// FollowSelectedTask switches to the thread view for the selected item's
+// Task: the call, its subagent's items and the final result. It reports
+// false when the selection isn't a Task call or result.
+func (s *StreamView) FollowSelectedTask() bool {
+	item, ok := s.SelectedItem()
+	if !ok || item.ToolID == "" {
+		return false
+	}
+	call, ok := s.tasks.call(item.ToolID)
+	if !ok {
+		return false
+	}
+	s.thread = &threadFilter{
+		sessionID: call.sessionID,
+		toolID:    call.toolID,
+		label:     call.description,
+	}
+	s.thread.resolve(s.tasks)
+	s.mark = -1
 	s.updateContent()
+	s.scrollToSelected()
+	return true
+}
+
+// Unfollow leaves the thread view, returning to the tree filters. It
+// reports whether a thread was active.
+func (s *StreamView) Unfollow() bool {
+	if s.thread == nil {
+		return false
+	}
+	s.thread = nil
+	s.mark = -1
+	s.updateContent()
+	s.scrollToSelected()
+	return true
+}
+
+// Following returns the followed Task's label, if in the thread view.
+func (s *StreamView) Following() (string, bool) {
+	if s.thread == nil {
+		return "", false
+	}
+	return s.thread.label, true
 }
 
 // SetEnabledFilters updates which session/agent combos are visible
@@ -108,6 +293,34 @@ func (s *StreamView) SetEnabledFilters(filters []EnabledFilter) {
 	s.updateContent()
 }
 
+// SetSessionColors sets the color of each session's items, or nil to
+// leave them uncolored.
+func (s *StreamView) SetSessionColors(colors map[string]lipgloss.TerminalColor) {
+	if maps.Equal(colors, s.sessionColors) {
+		return
+	}
+	s.sessionColors = colors
+	s.updateContent()
+}
+
+// SetSessionGutter draws a bar in the session's color beside each item
+// while sessions are colored.
+func (s *StreamView) SetSessionGutter(on bool) {
+	s.gutter = on
+	s.updateContent()
+}
+
+// SetTypeGutter shows or hides the column of item type glyphs.
+func (s *StreamView) SetTypeGutter(on bool) {
+	s.typeGutter = on
+	s.updateContent()
+}
+
+// TypeGutter reports whether the type glyph column is shown.
+func (s *StreamView) TypeGutter() bool {
+	return s.typeGutter
+}
+
 // ToggleThinking toggles thinking visibility
 func (s *StreamView) ToggleThinking() {
 	s.showThinking = !s.showThinking
@@ -126,15 +339,66 @@ func (s *StreamView) ToggleToolOutput() {
 	s.updateContent()
 }
 
+// ToggleStderrOnly switches tool and command output between everything
+// and just the error text: stderr, or all of a failed result.
+func (s *StreamView) ToggleStderrOnly() {
+	s.SetStderrOnly(!s.stderrOnly)
+}
+
+// SetStderrOnly turns stderr-only output on or off.
+func (s *StreamView) SetStderrOnly(on bool) {
+	s.stderrOnly = on
+	s.updateContent()
+}
+
+// SetFilter shows only items matching e (nil for all), on top of the
+// toggles and the session/agent filter.
+func (s *StreamView) SetFilter(e *filter.Expr) {
+	s.filter = e
+	s.updateContent()
+}
+
+// IsStderrOnly reports whether output is limited to stderr.
+func (s *StreamView) IsStderrOnly() bool {
+	return s.stderrOnly
+}
+
 // ToggleText toggles text visibility
 func (s *StreamView) ToggleText() {
 	s.showText = !s.showText
 	s.updateContent()
 }
 
+// ToggleHooks toggles hook visibility
+func (s *StreamView) ToggleHooks() {
+	s.showHooks = !s.showHooks
+	s.updateContent()
+}
+
 // ToggleAutoScroll toggles auto-scroll
 func (s *StreamView) ToggleAutoScroll() {
 	s.autoScroll = !s.autoScroll
+	if s.autoScroll {
+		s.unseen = 0
+	}
+}
+
+// SetToggles sets the thinking, tool input, tool output, text and hook
+// toggles at once, e.g. when restoring a saved view.
+func (s *StreamView) SetToggles(thinking, toolInput, toolOutput, text, hooks bool) {
+	s.showThinking = thinking
+	s.showToolInput = toolInput
+	s.showToolOutput = toolOutput
+	s.showText = text
+	s.showHooks = hooks
+	s.updateContent()
+}
+
+// SetAutoScroll turns auto-scroll on or off.
+func (s *StreamView) SetAutoScroll(on bool) {
+	if on != s.autoScroll {
+		s.ToggleAutoScroll()
+	}
 }
 
 // ScrollUp scrolls the viewport up
@@ -146,6 +410,259 @@ func (s *StreamView) ScrollUp(lines int) {
 // ScrollDown scrolls the viewport down
 func (s *StreamView) ScrollDown(lines int) {
 	s.viewport.ScrollDown(lines)
+	if s.viewport.AtBottom() {
+		s.unseen = 0
+	}
+}
+
+// PageSize is how many lines fit in the viewport.
+func (s *StreamView) PageSize() int {
+	return max(1, s.viewport.Height)
+}
+
+// GotoLine scrolls so that line (1-based) is at the top of the view.
+func (s *StreamView) GotoLine(line int) {
+	s.autoScroll = false
+	s.viewport.SetYOffset(line - 1)
+	if s.viewport.AtBottom() {
+		s.unseen = 0
+	}
+}
+
+// JumpToBottom scrolls to the newest item and resumes auto-scroll, back
+// to live if scrubbed.
+func (s *StreamView) JumpToBottom() {
+	if s.scrub >= 0 {
+		s.scrub = -1
+		s.updateContent()
+	}
+	s.autoScroll = true
+	s.unseen = 0
+	s.viewport.GotoBottom()
+}
+
+// Unseen returns how many visible items arrived below the view since
+// auto-scroll was turned off; reaching the bottom resets it.
+func (s *StreamView) Unseen() int {
+	return s.unseen
+}
+
+// Position describes the scroll position as "1234/5678 lines · 43%", where
+// 1234 is the last line in view. It is "" when everything fits.
+func (s *StreamView) Position() string {
+	total := s.viewport.TotalLineCount()
+	if total <= s.viewport.Height {
+		return ""
+	}
+	last := min(total, s.viewport.YOffset+s.viewport.Height)
+	return fmt.Sprintf("%d/%d lines · %d%%", last, total, int(s.viewport.ScrollPercent()*100))
+}
+
+// SelectNext moves the item cursor down. With no selection it starts at the
+// item at the top of the viewport.
+func (s *StreamView) SelectNext() {
+	s.moveSelection(1)
+}
+
+// SelectPrev moves the item cursor up. With no selection it starts at the
+// item at the bottom of the viewport.
+func (s *StreamView) SelectPrev() {
+	s.moveSelection(-1)
+}
+
+func (s *StreamView) moveSelection(delta int) {
+	if len(s.itemStarts) == 0 {
+		return
+	}
+	s.autoScroll = false
+
+	pos := -1
+	for i, st := range s.itemStarts {
+		if st.index == s.selected {
+			pos = i
+			break
+		}
+	}
+	switch {
+	case pos < 0 && delta > 0:
+		pos = s.itemAtLine(s.viewport.YOffset)
+	case pos < 0:
+		pos = s.itemAtLine(s.viewport.YOffset + s.viewport.Height - 1)
+	default:
+		pos = max(0, min(len(s.itemStarts)-1, pos+delta))
+	}
+	s.selected = s.itemStarts[pos].index
+	s.updateContent()
+	s.scrollToSelected()
+}
+
+// itemAtLine returns the position in itemStarts of the item covering line.
+func (s *StreamView) itemAtLine(line int) int {
+	pos := 0
+	for i, st := range s.itemStarts {
+		if st.line > line {
+			break
+		}
+		pos = i
+	}
+	return pos
+}
+
+// SelectAt selects the first shown item from t on and scrolls it to the
+// top of the view. It reports false if every shown item is older.
+func (s *StreamView) SelectAt(t time.Time) bool {
+	for _, st := range s.itemStarts {
+		if !s.items[st.index].Timestamp.Before(t) {
+			s.autoScroll = false
+			s.selected = st.index
+			s.updateContent()
+			s.viewport.SetYOffset(st.line)
+			return true
+		}
+	}
+	return false
+}
+
+// SelectID selects the shown item with id (see deeplink.ItemID) and
+// scrolls it to the top of the view. It reports false if no shown item
+// has it.
+func (s *StreamView) SelectID(id string) bool {
+	for _, st := range s.itemStarts {
+		if deeplink.ItemID(s.items[st.index]) == id {
+			s.autoScroll = false
+			s.selected = st.index
+			s.updateContent()
+			s.viewport.SetYOffset(st.line)
+			return true
+		}
+	}
+	return false
+}
+
+// scrollToSelected brings the selected item's first line into view.
+func (s *StreamView) scrollToSelected() {
+	for _, st := range s.itemStarts {
+		if st.index != s.selected {
+			continue
+		}
+		if st.line < s.viewport.YOffset || st.line >= s.viewport.YOffset+s.viewport.Height {
+			s.viewport.SetYOffset(st.line)
+		}
+		return
+	}
+}
+
+// ClearSelection drops the item cursor and any range mark.
+func (s *StreamView) ClearSelection() {
+	if s.selected < 0 && s.mark < 0 {
+		return
+	}
+	s.selected = -1
+	s.mark = -1
+	s.updateContent()
+}
+
+// ToggleMark anchors a range at the selected item, or clears the anchor if
+// it is already there. It reports false when nothing is selected.
+func (s *StreamView) ToggleMark() bool {
+	if s.selected < 0 {
+		return false
+	}
+	if s.mark == s.selected {
+		s.mark = -1
+	} else {
+		s.mark = s.selected
+	}
+	s.updateContent()
+	return true
+}
+
+// SetFullOutput shows every item untruncated, or truncates them all again.
+// Either way, items toggled one by one go back to the new default.
+func (s *StreamView) SetFullOutput(on bool) {
+	s.fullOutput = on
+	clear(s.expanded)
+	s.updateContent()
+}
+
+// FullOutput reports whether items are shown untruncated.
+func (s *StreamView) FullOutput() bool {
+	return s.fullOutput
+}
+
+// ToggleExpanded shows the selected item's truncated content in full, or
+// truncates it again (the reverse under SetFullOutput, and for agent
+// reports, which show whole). It reports false when nothing is selected.
+func (s *StreamView) ToggleExpanded() bool {
+	if s.selected < 0 {
+		return false
+	}
+	key := notes.ItemKey(s.items[s.selected])
+	if s.expanded[key] {
+		delete(s.expanded, key)
+	} else {
+		s.expanded[key] = true
+	}
+	s.updateContent()
+	return true
+}
+
+// HasRange reports whether a range is marked.
+func (s *StreamView) HasRange() bool {
+	return s.mark >= 0 && s.selected >= 0
+}
+
+// inRange reports whether items[i] lies between the mark and the cursor.
+func (s *StreamView) inRange(i int) bool {
+	if !s.HasRange() {
+		return false
+	}
+	return i >= min(s.mark, s.selected) && i <= max(s.mark, s.selected)
+}
+
+// RangeItems returns the visible items between the mark and the cursor,
+// inclusive, or nil without a range.
+func (s *StreamView) RangeItems() []parser.StreamItem {
+	if !s.HasRange() {
+		return nil
+	}
+	var out []parser.StreamItem
+	for i, item := range s.items {
+		if s.inRange(i) && s.isVisible(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Items returns every item kept, visible or not, oldest first.
+func (s *StreamView) Items() []parser.StreamItem {
+	return slices.Clone(s.items)
+}
+
+// VisibleItems returns every item that passes the current filters, up to
+// the scrub point while scrubbed.
+func (s *StreamView) VisibleItems() []parser.StreamItem {
+	var out []parser.StreamItem
+	for _, item := range s.items[:s.shownItems()] {
+		if s.isVisible(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// SelectedItem returns the item under the cursor, if any.
+func (s *StreamView) SelectedItem() (parser.StreamItem, bool) {
+	if s.selected < 0 || s.selected >= len(s.items) {
+		return parser.StreamItem{}, false
+	}
+	return s.items[s.selected], true
+}
+
+// Refresh re-renders the stream, e.g. after a note changed.
+func (s *StreamView) Refresh() {
+	s.updateContent()
 }
 
 // IsThinkingEnabled returns thinking filter state
@@ -168,45 +685,79 @@ func (s *StreamView) IsTextEnabled() bool {
 	return s.showText
 }
 
+// IsHooksEnabled returns hook filter state
+func (s *StreamView) IsHooksEnabled() bool {
+	return s.showHooks
+}
+
 // IsAutoScrollEnabled returns auto-scroll state
 func (s *StreamView) IsAutoScrollEnabled() bool {
 	return s.autoScroll
 }
 
+// Hold defers re-rendering until Release, so a batch of items costs one
+// render instead of one per item.
+func (s *StreamView) Hold() {
+	s.held = true
+}
+
+// Release ends Hold, re-rendering if anything changed meanwhile.
+func (s *StreamView) Release() {
+	s.held = false
+	if s.stale {
+		s.stale = false
+		s.updateContent()
+	}
+}
+
 func (s *StreamView) updateContent() {
+	if s.held {
+		s.stale = true
+		return
+	}
 	var b strings.Builder
 	contentWidth := s.width - 4 // account for borders and padding
 	if contentWidth < 1 {
 		contentWidth = 1
 	}
 
-	for _, item := range s.items {
-		// Check session/agent filter
-		if !s.isItemEnabled(item) {
+	s.itemStarts = s.itemStarts[:0]
+	line := 0
+	for i, item := range s.items[:s.shownItems()] {
+		if !s.isVisible(item) {
 			continue
 		}
 
-		// Check type filter
-		switch item.Type {
-		case parser.TypeThinking:
-			if !s.showThinking {
-				continue
-			}
-		case parser.TypeToolInput:
-			if !s.showToolInput {
-				continue
-			}
-		case parser.TypeToolOutput:
-			if !s.showToolOutput {
-				continue
-			}
-		case parser.TypeText:
-			if !s.showText {
-				continue
-			}
+		// The cursor, range or session bar goes outside the type glyphs;
+		// each takes its width from the item.
+		glyph, barStyle := "", selectedItemStyle
+		color, colored := s.sessionColors[item.SessionID]
+		switch {
+		case i == s.selected:
+			glyph = "▌"
+		case s.inRange(i):
+			glyph = "┃"
+		case s.gutter && colored:
+			glyph, barStyle = "▎", lipgloss.NewStyle().Foreground(color)
+		}
+		width := contentWidth
+		if glyph != "" {
+			width -= 2
+		}
+		if s.typeGutter {
+			width -= typeGutterWidth
+		}
+		rendered := s.renderItem(item, max(1, width))
+		if s.typeGutter {
+			rendered = typeLines(rendered, item)
+		}
+		if glyph != "" {
+			rendered = markLines(rendered, glyph, barStyle)
 		}
+		s.itemStarts = append(s.itemStarts, itemStart{index: i, line: line})
+		line += strings.Count(rendered, "\n") + 1
 
-		b.WriteString(s.renderItem(item, contentWidth))
+		b.WriteString(rendered)
 		b.WriteString("\n")
 	}
 
@@ -216,6 +767,158 @@ func (s *StreamView) updateContent() {
 	}
 }
 
+// IsVisible reports whether item passes the stream's current filters.
+func (s *StreamView) IsVisible(item parser.StreamItem) bool {
+	return s.isVisible(item)
+}
+
+// isVisible applies the session/agent filter (or the followed Task thread)
+// and the type toggles.
+func (s *StreamView) isVisible(item parser.StreamItem) bool {
+	if !s.filter.Match(item) {
+		return false
+	}
+	if s.thread != nil {
+		if !s.thread.matches(item) {
+			return false
+		}
+	} else if !s.isItemEnabled(item) {
+		return false
+	}
+	if s.highlights {
+		return s.isHighlight(item)
+	}
+	switch item.Type {
+	case parser.TypeThinking:
+		return s.showThinking
+	case parser.TypeToolInput:
+		return s.showToolInput
+	case parser.TypeToolOutput:
+		return s.showToolOutput && (!s.stderrOnly || hasErrorText(item))
+	case parser.TypeText:
+		return s.showText
+	case parser.TypeHookOutput:
+		return s.showHooks
+	case parser.TypeCommand:
+		// Command output (not the command itself) follows stderr-only.
+		return !s.stderrOnly || item.ToolName != "" || hasErrorText(item)
+	}
+	return true
+}
+
+// hasErrorText reports whether an output item has anything for the
+// stderr-only view.
+func hasErrorText(item parser.StreamItem) bool {
+	return item.Stderr != "" || item.IsError
+}
+
+// splitStderr separates the stderr the parser found from the rest of an
+// output's content. Bash results and ! commands put it after stdout. If
+// it can't be found in content, everything counts as stdout.
+func splitStderr(content, stderr string) (stdout, errText string) {
+	if stderr == "" {
+		return content, ""
+	}
+	i := strings.LastIndex(content, stderr)
+	if i < 0 {
+		return content, ""
+	}
+	stdout = strings.TrimRight(content[:i], "\n") + content[i+len(stderr):]
+	return strings.TrimRight(stdout, "\n"), stderr
+}
+
+// renderOutput renders output content with its stderr part in the stderr
+// color, or only the stderr part in stderr-only mode. Locations of
+// recognized build errors, and the selected item's paths, are highlighted.
+func (s *StreamView) renderOutput(item parser.StreamItem, width int, style lipgloss.Style) string {
+	stdout, errText := splitStderr(item.Content, item.Stderr)
+	if s.stderrOnly && errText != "" {
+		stdout = ""
+	}
+	render := func(text string, style lipgloss.Style) string { return style.Render(text) }
+	if refs, current := s.itemRefs(item); len(refs) > 0 {
+		render = func(text string, style lipgloss.Style) string { return highlightRefs(text, style, refs, current) }
+	}
+	var parts []string
+	if stdout != "" {
+		parts = append(parts, render(s.truncateItem(item, stdout, width), style))
+	}
+	if errText != "" {
+		parts = append(parts, render(s.truncateItem(item, errText, width), stderrStyle))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// markLines prefixes every line of a rendered item with a gutter glyph: the
+// cursor (▌), range membership (┃) or the session's bar (▎).
+func markLines(rendered, glyph string, style lipgloss.Style) string {
+	bar := style.Render(glyph) + " "
+	return bar + strings.ReplaceAll(rendered, "\n", "\n"+bar)
+}
+
+// typeGutterWidth is the width of the type glyph column: two cells for
+// the glyph and a space.
+const typeGutterWidth = 3
+
+// typeGlyph is the glyph for an item in the type gutter: its header icon,
+// or ⚠ for a failed tool result. Markers get none.
+func typeGlyph(item parser.StreamItem) string {
+	switch item.Type {
+	case parser.TypeThinking:
+		return thinkingIcon
+	case parser.TypeToolInput:
+		return toolInputIcon
+	case parser.TypeToolOutput:
+		if item.IsError || item.ExitCode != 0 {
+			return diagnosticsIcon
+		}
+		if parser.IsAgentReport(item) {
+			return reportIcon
+		}
+		return toolOutputIcon
+	case parser.TypeText:
+		return textIcon
+	case parser.TypeHookOutput:
+		return hookIcon
+	case parser.TypeCommand:
+		return commandIcon
+	case parser.TypeDiagnostics:
+		return diagnosticsIcon
+	case parser.TypeDebug:
+		return debugIcon
+	case parser.TypeUnknown:
+		return unknownIcon
+	case parser.TypeAPIError:
+		return apiErrorIcon
+	case parser.TypeInterrupt:
+		return interruptIcon
+	case parser.TypeFork:
+		return forkIcon
+	}
+	return ""
+}
+
+// typeLines prefixes a rendered item with the type gutter: the item's
+// glyph on its first line and a line down the rest, so the start of each
+// item stands out when scrolling fast.
+func typeLines(rendered string, item parser.StreamItem) string {
+	lines := strings.Split(rendered, "\n")
+	pad := func(s string) string {
+		return s + strings.Repeat(" ", typeGutterWidth-runewidth.StringWidth(s))
+	}
+	for i := range lines {
+		switch {
+		case i == 0:
+			lines[i] = pad(typeGlyph(item)) + lines[i]
+		case i == len(lines)-1:
+			lines[i] = separatorStyle.Render(pad("╵")) + lines[i]
+		default:
+			lines[i] = separatorStyle.Render(pad("│")) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (s *StreamView) isItemEnabled(item parser.StreamItem) bool {
 	for _, f := range s.enabledFilters {
 		if f.SessionID == item.SessionID && f.AgentID == item.AgentID {
@@ -244,6 +947,12 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 	if item.Type == parser.TypePRLink {
 		return mutedStyle.Render(fmt.Sprintf("── %s ──", item.Content))
 	}
+	if item.Type == parser.TypeModelSwitch {
+		return switchStyle.Render(fmt.Sprintf("── %s %s: %s ──", switchIcon, item.AgentName, item.Content))
+	}
+	if item.Type == parser.TypeFork {
+		return forkStyle.Render(fmt.Sprintf("── %s %s ──", forkIcon, item.Content))
+	}
 
 	var b strings.Builder
 
@@ -261,22 +970,28 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 	case parser.TypeThinking:
 		header := thinkingStyle.Render(thinkingIcon + " Thinking")
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
-		content := s.truncateContent(item.Content, width)
+		content := s.truncateItem(item, item.Content, width)
 		b.WriteString(thinkingContentStyle.Render(content))
 
 	case parser.TypeToolInput:
 		toolName := toolInputStyle.Render(toolInputIcon + " " + item.ToolName)
+		if item.ToolName == "Bash" && item.Cwd != "" {
+			toolName += mutedStyle.Render(" in " + shortDir(item.Cwd))
+		}
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, toolName))
-		content := s.truncateContent(item.Content, width)
+		content := s.truncateItem(item, item.Content, width)
 		b.WriteString(toolInputContentStyle.Render(content))
+		if p, ok := s.progress[item.ToolID]; ok {
+			b.WriteString("\n" + renderProgress(p, width))
+		}
 
 	case parser.TypeToolOutput:
-		// Look up tool name from matching ToolInput
-		toolName := ""
+		// Look up tool name (and where it ran) from matching ToolInput
+		toolName, ranIn := "", ""
 		if item.ToolID != "" {
 			for _, other := range s.items {
 				if other.Type == parser.TypeToolInput && other.ToolID == item.ToolID {
-					toolName = other.ToolName
+					toolName, ranIn = other.ToolName, other.Cwd
 					break
 				}
 			}
@@ -290,31 +1005,56 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 		if item.DurationMs > 0 {
 			outputLabel += " " + formatDuration(item.DurationMs)
 		}
-		header := toolOutputStyle.Render(outputLabel)
+		headerStyle, contentStyle := toolOutputStyle, toolOutputContentStyle
+		if item.ExitCode != 0 {
+			outputLabel += fmt.Sprintf(" exit %d", item.ExitCode)
+			headerStyle, contentStyle = failedOutputStyle, failedOutputContentStyle
+		}
+		if parser.IsAgentReport(item) && item.ExitCode == 0 {
+			outputLabel, headerStyle = reportLabel(item), reportStyle
+		}
+		header := headerStyle.Render(outputLabel)
+		if res, ok := s.testRuns[item.ToolID]; ok {
+			header += " " + testBadge(res)
+		}
+		if res, ok := s.problems[item.ToolID]; ok {
+			header += " " + problemsBadge(res)
+		}
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
-		content := s.truncateContent(item.Content, width)
-		b.WriteString(toolOutputContentStyle.Render(content))
+		b.WriteString(s.renderOutput(item, width, contentStyle))
+		if toolName == "Bash" && ranIn != "" && item.Cwd != "" && item.Cwd != ranIn {
+			b.WriteString("\n" + cwdStyle.Render(s.truncateContent(cwdIcon+" cwd → "+shortDir(item.Cwd), width)))
+		}
 
 	case parser.TypeText:
 		header := textStyle.Render(textIcon + " Response")
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
-		content := s.truncateContent(item.Content, width)
+		content := s.truncateItem(item, item.Content, width)
 		b.WriteString(content)
 
 	case parser.TypeHookOutput:
-		label := hookIcon + " Hook"
-		if item.ToolName != "" {
-			label += " " + item.ToolName
-		}
-		if item.DurationMs > 0 {
-			label += " " + formatDuration(item.DurationMs)
+		label := hookLabel(item)
+		style, contentStyle := hookStyle, hookContentStyle
+		if item.IsError {
+			style, contentStyle = failedOutputStyle, failedOutputContentStyle
 		}
-		header := hookStyle.Render(label)
-		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
+		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, style.Render(label)))
 		if item.Content != "" {
-			content := s.truncateContent(item.Content, width)
-			b.WriteString(hookContentStyle.Render(content))
+			content := item.Content
+			if item.HookStatus == parser.HookFired {
+				content = "$ " + content
+			}
+			b.WriteString(contentStyle.Render(s.truncateItem(item, content, width)))
+		}
+
+	case parser.TypeCommand:
+		label := commandIcon + " Command output"
+		if item.ToolName != "" {
+			label = commandIcon + " " + item.ToolName
 		}
+		header := commandStyle.Render(label)
+		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
+		b.WriteString(s.renderOutput(item, width, commandContentStyle))
 
 	case parser.TypeDiagnostics:
 		label := diagnosticsIcon + " Diagnostics"
@@ -324,7 +1064,7 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 		header := diagnosticsStyle.Render(label)
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
 		if item.Content != "" {
-			content := s.truncateContent(item.Content, width)
+			content := s.truncateItem(item, item.Content, width)
 			b.WriteString(diagnosticsContentStyle.Render(content))
 		}
 
@@ -336,9 +1076,30 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 		header := debugStyle.Render(label)
 		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
 		if item.Content != "" {
-			content := s.truncateContent(item.Content, width)
+			content := s.truncateItem(item, item.Content, width)
 			b.WriteString(debugContentStyle.Render(content))
 		}
+
+	case parser.TypeUnknown:
+		header := unknownStyle.Render(unknownIcon + " Unreadable line: " + item.ToolName)
+		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
+		if item.Content != "" {
+			content := s.truncateItem(item, item.Content, width)
+			b.WriteString(debugContentStyle.Render(content))
+		}
+
+	case parser.TypeAPIError:
+		header := apiErrorBannerStyle.Render(apiErrorIcon + " API " + item.ToolName)
+		b.WriteString(fmt.Sprintf("%s%s%s\n", agentName, sep, header))
+		content := s.truncateItem(item, item.Content, width)
+		b.WriteString(failedOutputContentStyle.Render(content))
+
+	case parser.TypeInterrupt:
+		b.WriteString(fmt.Sprintf("%s%s%s", agentName, sep, renderInterrupt(item)))
+	}
+
+	if note := s.notes.Item(item); note != "" {
+		b.WriteString("\n" + noteStyle.Render(s.truncateContent(noteIcon+" "+note, width)))
 	}
 
 	// Add separator line
@@ -346,56 +1107,90 @@ func (s *StreamView) renderItem(item parser.StreamItem, width int) string {
 	if sepWidth < 0 {
 		sepWidth = 0
 	}
-	b.WriteString("\n" + separatorStyle.Render(strings.Repeat("─", sepWidth)))
+	sepStyle := separatorStyle
+	if color, ok := s.sessionColors[item.SessionID]; ok {
+		sepStyle = lipgloss.NewStyle().Foreground(color)
+	}
+	b.WriteString("\n" + sepStyle.Render(strings.Repeat("─", sepWidth)))
 
 	return b.String()
 }
 
+// truncateContent wraps content to width and cuts it to maxLines lines.
 func (s *StreamView) truncateContent(content string, width int) string {
-	lines := strings.Split(content, "\n")
+	return wrapLines(content, width, s.maxLines)
+}
 
-	// Truncate number of lines
-	if len(lines) > s.maxLines {
-		remaining := len(lines) - s.maxLines
-		lines = lines[:s.maxLines]
-		lines = append(lines, mutedStyle.Render(fmt.Sprintf("... (%d more lines)", remaining)))
+// truncateItem is truncateContent for a part of item, which is left whole
+// in full-output mode (Z) or if expanded (z), but not both. Agent reports
+// are whole unless collapsed (z).
+func (s *StreamView) truncateItem(item parser.StreamItem, content string, width int) string {
+	whole := s.fullOutput || parser.IsAgentReport(item)
+	if whole != s.expanded[notes.ItemKey(item)] {
+		return wrapLines(content, width, 0)
 	}
+	return s.truncateContent(content, width)
+}
 
-	// Word wrap each line using display width (handles CJK/emoji correctly)
+// wrapLines word-wraps content to width display columns (so CJK and emoji
+// count right) and keeps the first limit of the wrapped lines, 0 meaning
+// all of them. Cutting after wrapping makes an item's height predictable
+// and the hidden-line count in the marker the number of lines expanding it
+// adds.
+func wrapLines(content string, width, limit int) string {
 	var wrapped []string
-	for _, line := range lines {
-		lineWidth := runewidth.StringWidth(line)
-		if lineWidth > width && width > 0 {
-			for runewidth.StringWidth(line) > width {
-				col := 0
-				splitAt := 0
-				for i, r := range line {
-					cw := runewidth.RuneWidth(r)
-					if col+cw > width {
-						break
-					}
-					col += cw
-					splitAt = i + len(string(r))
-				}
-				if splitAt == 0 {
-					// Single char wider than width — force advance past first rune
-					_, size := utf8.DecodeRuneInString(line)
-					splitAt = size
-				}
-				wrapped = append(wrapped, line[:splitAt])
-				line = line[splitAt:]
-			}
-			if len(line) > 0 {
-				wrapped = append(wrapped, line)
-			}
-		} else {
-			wrapped = append(wrapped, line)
+	hidden := 0
+	for _, line := range strings.Split(content, "\n") {
+		if limit > 0 && len(wrapped) >= limit {
+			hidden += len(wrapLine(line, width))
+			continue
 		}
+		wrapped = append(wrapped, wrapLine(line, width)...)
+	}
+	if limit > 0 && len(wrapped) > limit {
+		hidden += len(wrapped) - limit
+		wrapped = wrapped[:limit]
+	}
+	if hidden > 0 {
+		wrapped = append(wrapped, mutedStyle.Render(fmt.Sprintf("... (%d more lines, z expands)", hidden)))
 	}
-
 	return strings.Join(wrapped, "\n")
 }
 
+// wrapLine breaks line into pieces of at most width columns. A character
+// wider than width gets a piece of its own.
+func wrapLine(line string, width int) []string {
+	if width <= 0 || runewidth.StringWidth(line) <= width {
+		return []string{line}
+	}
+	var pieces []string
+	start, col := 0, 0
+	for i, r := range line {
+		cw := runewidth.RuneWidth(r)
+		if col+cw > width && i > start {
+			pieces = append(pieces, line[start:i])
+			start, col = i, 0
+		}
+		col += cw
+	}
+	return append(pieces, line[start:])
+}
+
+// shortDir abbreviates the home directory in a path to "~".
+func shortDir(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return dir
+}
+
 // formatDuration formats a duration in milliseconds to a human-readable string
 func formatDuration(ms int64) string {
 	if ms < 1000 {
@@ -1,16 +1,21 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/notes"
 	"github.com/phiat/claude-esp/internal/parser"
 )
 
-func newTestItem(typ parser.StreamItemType, sessionID, agentID, content string) parser.StreamItem {
-	return parser.StreamItem{
+func newTestItem(typ parser.StreamItemType, sessionID, agentID, content string, opts ...itemOption) parser.StreamItem {
+	item := parser.StreamItem{
 		Type:      typ,
 		SessionID: sessionID,
 		AgentID:   agentID,
@@ -18,6 +23,33 @@ func newTestItem(typ parser.StreamItemType, sessionID, agentID, content string)
 		Content:   content,
 		Timestamp: time.Now(),
 	}
+	for _, opt := range opts {
+		opt(&item)
+	}
+	return item
+}
+
+// An itemOption sets a field of a newTestItem beyond the common ones.
+type itemOption func(*parser.StreamItem)
+
+func withTool(name, id string) itemOption {
+	return func(item *parser.StreamItem) { item.ToolName, item.ToolID = name, id }
+}
+
+func withTime(at time.Time) itemOption {
+	return func(item *parser.StreamItem) { item.Timestamp = at }
+}
+
+func withName(name string) itemOption {
+	return func(item *parser.StreamItem) { item.AgentName = name }
+}
+
+// withTokens bills out output tokens at Sonnet's $15/MTok.
+func withTokens(out int64) itemOption {
+	return func(item *parser.StreamItem) {
+		item.Model = "claude-sonnet-4-5"
+		item.OutputTokens = out
+	}
 }
 
 func TestStreamView_AddItem(t *testing.T) {
@@ -212,6 +244,78 @@ func TestTruncateContent(t *testing.T) {
 	}
 }
 
+func TestTruncateContent_CountsWrappedLines(t *testing.T) {
+	s := NewStreamView()
+	s.maxLines = 4
+
+	// Three 25-column lines wrap to three rows each at width 10: nine rows,
+	// of which four show and five are hidden.
+	content := strings.Repeat(strings.Repeat("x", 25)+"\n", 2) + strings.Repeat("x", 25)
+	lines := strings.Split(stripAnsi(s.truncateContent(content, 10)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 4 and the marker:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[4], "(5 more lines") {
+		t.Errorf("marker = %q, want 5 more lines", lines[4])
+	}
+}
+
+func TestStreamView_ToggleExpanded(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 200)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	var lines []string
+	for i := range 60 {
+		lines = append(lines, fmt.Sprintf("row %d", i))
+	}
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", strings.Join(lines, "\n")))
+
+	if s.ToggleExpanded() {
+		t.Fatal("ToggleExpanded with nothing selected")
+	}
+	s.SelectNext()
+	if view := stripAnsi(s.View()); strings.Contains(view, "row 59") || !strings.Contains(view, "10 more lines") {
+		t.Fatalf("truncated view:\n%s", view)
+	}
+	if !s.ToggleExpanded() {
+		t.Fatal("ToggleExpanded with a selection")
+	}
+	if view := stripAnsi(s.View()); !strings.Contains(view, "row 59") || strings.Contains(view, "more lines") {
+		t.Errorf("expanded view:\n%s", view)
+	}
+	s.ToggleExpanded()
+	if view := stripAnsi(s.View()); strings.Contains(view, "row 59") {
+		t.Errorf("collapsed again:\n%s", view)
+	}
+}
+
+func TestStreamView_FullOutput(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 400)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	for _, tail := range []string{"first end", "second end"} {
+		s.AddItem(newTestItem(parser.TypeText, "sess1", "", strings.Repeat("row\n", 60)+tail))
+	}
+
+	s.SetFullOutput(true)
+	view := stripAnsi(s.View())
+	if !strings.Contains(view, "first end") || !strings.Contains(view, "second end") {
+		t.Fatalf("full output view:\n%s", view)
+	}
+	// z now truncates the selected item only.
+	s.SelectNext()
+	s.ToggleExpanded()
+	view = stripAnsi(s.View())
+	if strings.Contains(view, "first end") || !strings.Contains(view, "second end") {
+		t.Errorf("item collapsed under full output:\n%s", view)
+	}
+	// Leaving full output forgets per-item choices.
+	s.SetFullOutput(false)
+	if view := stripAnsi(s.View()); strings.Contains(view, "first end") || strings.Contains(view, "second end") {
+		t.Errorf("truncated view:\n%s", view)
+	}
+}
+
 func TestTruncateContent_CJK(t *testing.T) {
 	s := NewStreamView()
 
@@ -294,3 +398,285 @@ func TestStreamView_ToggleStates(t *testing.T) {
 		t.Error("tool output should be disabled after toggle")
 	}
 }
+
+func TestStreamView_Selection(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 200)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	for _, c := range []string{"one", "two", "three"} {
+		s.AddItem(newTestItem(parser.TypeText, "sess1", "", c))
+	}
+	s.AddItem(newTestItem(parser.TypeText, "other", "", "hidden"))
+
+	if _, ok := s.SelectedItem(); ok {
+		t.Fatal("no item should be selected initially")
+	}
+	s.SelectNext() // starts at the top of the viewport
+	if item, _ := s.SelectedItem(); item.Content != "one" {
+		t.Errorf("first SelectNext selected %q, want one", item.Content)
+	}
+	s.SelectNext()
+	s.SelectNext()
+	s.SelectNext() // clamps at the last visible item; "hidden" is filtered
+	if item, _ := s.SelectedItem(); item.Content != "three" {
+		t.Errorf("selected %q, want three", item.Content)
+	}
+	if !strings.Contains(s.View(), "▌") {
+		t.Error("selected item should render the cursor bar")
+	}
+	s.ClearSelection()
+	if _, ok := s.SelectedItem(); ok {
+		t.Error("ClearSelection should drop the cursor")
+	}
+}
+
+func TestStreamView_RendersNotes(t *testing.T) {
+	store := notes.New(t.TempDir())
+	s := NewStreamView()
+	s.SetSize(80, 40)
+	s.SetNotes(store)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+
+	item := newTestItem(parser.TypeThinking, "sess1", "", "hmm")
+	s.AddItem(item)
+	if err := store.SetItem(item, "this is where it went wrong"); err != nil {
+		t.Fatal(err)
+	}
+	s.Refresh()
+	if !strings.Contains(s.View(), "this is where it went wrong") {
+		t.Errorf("note not rendered inline:\n%s", s.View())
+	}
+}
+
+func TestStreamView_BashCwd(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	s := NewStreamView()
+	s.SetSize(100, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+
+	call := newTestItem(parser.TypeToolInput, "sess1", "", "$ cd web && npm test")
+	call.ToolName, call.ToolID, call.Cwd = "Bash", "t1", "/home/dev/app"
+	result := newTestItem(parser.TypeToolOutput, "sess1", "", "ok")
+	result.ToolID, result.Cwd = "t1", "/home/dev/app/web"
+	s.AddItem(call)
+	s.AddItem(result)
+
+	view := stripAnsi(s.View())
+	if !strings.Contains(view, "Bash in ~/app") {
+		t.Errorf("Bash call doesn't show its directory:\n%s", view)
+	}
+	if !strings.Contains(view, "cwd → ~/app/web") {
+		t.Errorf("directory change not shown:\n%s", view)
+	}
+
+	// A command that stays put gets no note.
+	call2 := newTestItem(parser.TypeToolInput, "sess1", "", "$ ls")
+	call2.ToolName, call2.ToolID, call2.Cwd = "Bash", "t2", "/home/dev/app/web"
+	result2 := newTestItem(parser.TypeToolOutput, "sess1", "", "src")
+	result2.ToolID, result2.Cwd = "t2", "/home/dev/app/web"
+	s.AddItem(call2)
+	s.AddItem(result2)
+	if n := strings.Count(stripAnsi(s.View()), "cwd →"); n != 1 {
+		t.Errorf("%d directory-change notes, want 1", n)
+	}
+}
+
+func TestStreamView_FailedCommand(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(100, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	call := newTestItem(parser.TypeToolInput, "sess1", "", "$ make")
+	call.ToolName, call.ToolID = "Bash", "t1"
+	result := newTestItem(parser.TypeToolOutput, "sess1", "", "Exit code 2\nmake: *** [all] Error 1")
+	result.ToolID, result.IsError, result.ExitCode = "t1", true, 2
+	s.AddItem(call)
+	s.AddItem(result)
+
+	if !strings.Contains(stripAnsi(s.View()), "Bash result exit 2") {
+		t.Errorf("exit status not in the header:\n%s", stripAnsi(s.View()))
+	}
+	if got, want := s.renderItem(result, 80), failedOutputStyle.Render("📤 Bash result exit 2"); !strings.Contains(got, want) {
+		t.Errorf("header not styled as a failure: %q", got)
+	}
+}
+
+func TestStreamView_StderrOnly(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(100, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	noisy := newTestItem(parser.TypeToolOutput, "sess1", "", "compiling 1/300\ncompiling 300/300\nwarning: unused variable x")
+	noisy.ToolID, noisy.Stderr = "t1", "warning: unused variable x"
+	quiet := newTestItem(parser.TypeToolOutput, "sess1", "", "all good")
+	quiet.ToolID = "t2"
+	s.AddItem(noisy)
+	s.AddItem(quiet)
+
+	if stdout, errText := splitStderr(noisy.Content, noisy.Stderr); stdout != "compiling 1/300\ncompiling 300/300" || errText != noisy.Stderr {
+		t.Errorf("split = %q, %q", stdout, errText)
+	}
+	if !strings.Contains(s.renderItem(noisy, 80), stderrStyle.Render("warning: unused variable x")) {
+		t.Error("stderr not rendered in the stderr style")
+	}
+
+	s.ToggleStderrOnly()
+	view := stripAnsi(s.View())
+	if strings.Contains(view, "compiling") || strings.Contains(view, "all good") {
+		t.Errorf("stdout shown in stderr-only mode:\n%s", view)
+	}
+	if !strings.Contains(view, "warning: unused variable x") {
+		t.Errorf("stderr hidden in stderr-only mode:\n%s", view)
+	}
+}
+
+func TestStreamView_Filter(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(100, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	read := newTestItem(parser.TypeToolInput, "sess1", "", "main.go")
+	read.ToolName, read.ToolID = "Read", "t1"
+	bash := newTestItem(parser.TypeToolInput, "sess1", "", "go test ./...")
+	bash.ToolName, bash.ToolID = "Bash", "t2"
+	s.AddItem(read)
+	s.AddItem(bash)
+	s.AddItem(newTestItem(parser.TypeThinking, "sess1", "", "pondering"))
+
+	e, err := filter.Parse("type in (tool_input, tool_output) and tool != Read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetFilter(e)
+	view := stripAnsi(s.View())
+	if strings.Contains(view, "main.go") || strings.Contains(view, "pondering") || !strings.Contains(view, "go test") {
+		t.Errorf("filtered view:\n%s", view)
+	}
+	if items := s.VisibleItems(); len(items) != 1 || items[0].ToolName != "Bash" {
+		t.Errorf("exportable items = %+v", items)
+	}
+}
+
+func TestStreamView_RangeItems(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 200)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", "a"))
+	s.AddItem(newTestItem(parser.TypeThinking, "sess1", "", "b"))
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", "c"))
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", "d"))
+
+	if s.ToggleMark() {
+		t.Fatal("ToggleMark without a selection should fail")
+	}
+	s.SelectPrev() // bottom of viewport: "d"
+	s.ToggleMark()
+	s.SelectPrev()
+	s.SelectPrev() // "b"
+	got := s.RangeItems()
+	if len(got) != 3 || got[0].Content != "b" || got[2].Content != "d" {
+		t.Fatalf("RangeItems = %+v, want b..d", got)
+	}
+
+	// Hidden items inside the range are not exported.
+	s.ToggleThinking()
+	if got := s.RangeItems(); len(got) != 2 {
+		t.Errorf("RangeItems with thinking hidden = %d items, want 2", len(got))
+	}
+
+	s.ClearSelection()
+	if s.HasRange() || s.RangeItems() != nil {
+		t.Error("ClearSelection should drop the range")
+	}
+}
+
+func TestStreamView_UnseenAndPosition(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 7) // 5 content lines
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	for range 10 {
+		s.AddItem(newTestItem(parser.TypeText, "sess1", "", "line"))
+	}
+	if s.Unseen() != 0 {
+		t.Errorf("unseen = %d while auto-scrolling", s.Unseen())
+	}
+	total := s.viewport.TotalLineCount()
+	if got, want := s.Position(), fmt.Sprintf("%d/%d lines · 100%%", total, total); got != want {
+		t.Errorf("Position at bottom = %q, want %q", got, want)
+	}
+
+	s.ScrollUp(5)
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", "new"))
+	s.AddItem(newTestItem(parser.TypeThinking, "sess2", "", "filtered out"))
+	if s.Unseen() != 1 {
+		t.Errorf("unseen = %d, want 1 (hidden items don't count)", s.Unseen())
+	}
+	last := s.viewport.YOffset + s.viewport.Height
+	if got, want := s.Position(), fmt.Sprintf("%d/%d lines", last, s.viewport.TotalLineCount()); !strings.HasPrefix(got, want) {
+		t.Errorf("Position after scrolling up = %q, want prefix %q", got, want)
+	}
+
+	s.ScrollDown(9999)
+	if s.Unseen() != 0 {
+		t.Errorf("reaching the bottom should clear unseen, got %d", s.Unseen())
+	}
+
+	s.SetSize(80, 100)
+	if got := s.Position(); got != "" {
+		t.Errorf("Position when everything fits = %q", got)
+	}
+}
+
+func TestLabeledBottomBorder(t *testing.T) {
+	border := streamBorderStyle
+	pane := border.Width(40).Height(3).Render("x")
+	want := lipgloss.Width(strings.Split(pane, "\n")[0])
+	for _, label := range []string{"[5/11 lines · 45%]", strings.Repeat("x", 60)} {
+		if got := lipgloss.Width(labeledBottomBorder(border, 42, label)); got != want {
+			t.Errorf("label %q: width %d, want %d", label, got, want)
+		}
+	}
+}
+
+func TestStreamView_SessionGutter(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}, {SessionID: "sess2"}})
+	s.AddItem(newTestItem(parser.TypeText, "sess1", "", "from one"))
+	s.AddItem(newTestItem(parser.TypeText, "sess2", "", "from two"))
+	if strings.Contains(stripAnsi(s.View()), "▎") {
+		t.Fatal("gutter drawn without session colors")
+	}
+
+	s.SetSessionGutter(true)
+	s.SetSessionColors(map[string]lipgloss.TerminalColor{"sess1": sessionColors[0], "sess2": sessionColors[1]})
+	view := stripAnsi(s.View())
+	for _, want := range []string{"▎ from one", "▎ from two"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestStreamView_TypeGutter(t *testing.T) {
+	s := NewStreamView()
+	s.SetSize(80, 40)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "sess1"}})
+	s.AddItem(newTestItem(parser.TypeThinking, "sess1", "", "line one\nline two"))
+	failed := newTestItem(parser.TypeToolOutput, "sess1", "", "boom")
+	failed.ToolName, failed.ToolID, failed.ExitCode = "Bash", "t1", 1
+	s.AddItem(failed)
+	s.SetTypeGutter(true)
+
+	lines := strings.Split(stripAnsi(s.View()), "\n")
+	var glyphs []string
+	for _, l := range lines {
+		l = strings.TrimLeft(l, "│ ")
+		for _, g := range []string{thinkingIcon, diagnosticsIcon, "╵"} {
+			if strings.HasPrefix(l, g) {
+				glyphs = append(glyphs, g)
+			}
+		}
+	}
+	want := []string{thinkingIcon, "╵", diagnosticsIcon, "╵"}
+	if !slices.Equal(glyphs, want) {
+		t.Errorf("gutter glyphs = %q, want %q\n%s", glyphs, want, strings.Join(lines, "\n"))
+	}
+}
@@ -4,12 +4,12 @@ import "github.com/charmbracelet/lipgloss"
 
 var (
 	// Colors
-	primaryColor   = lipgloss.Color("#7C3AED") // Purple
-	secondaryColor = lipgloss.Color("#10B981") // Green
-	warningColor   = lipgloss.Color("#F59E0B") // Yellow/Orange
-	errorColor     = lipgloss.Color("#EF4444") // Red
-	mutedColor     = lipgloss.Color("#6B7280") // Gray
-	bgColor        = lipgloss.Color("#1F2937") // Dark gray
+	primaryColor   = hexColor("#7C3AED") // Purple
+	secondaryColor = hexColor("#10B981") // Green
+	warningColor   = hexColor("#F59E0B") // Yellow/Orange
+	errorColor     = hexColor("#EF4444") // Red
+	mutedColor     = hexColor("#6B7280") // Gray
+	bgColor        = hexColor("#1F2937") // Dark gray
 
 	// Thinking style - purple
 	thinkingIcon  = "🧠"
@@ -17,7 +17,7 @@ var (
 			Foreground(primaryColor).
 			Bold(true)
 	thinkingContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#A78BFA"))
+				Foreground(hexColor("#A78BFA"))
 
 	// Tool input style - yellow
 	toolInputIcon  = "🔧"
@@ -25,7 +25,7 @@ var (
 			Foreground(warningColor).
 			Bold(true)
 	toolInputContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FCD34D"))
+				Foreground(hexColor("#FCD34D"))
 
 	// Tool output style - green
 	toolOutputIcon  = "📤"
@@ -33,52 +33,86 @@ var (
 			Foreground(secondaryColor).
 			Bold(true)
 	toolOutputContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#6EE7B7"))
+				Foreground(hexColor("#6EE7B7"))
+
+	// Stderr part of tool and command output - orange
+	stderrStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#FB923C"))
+
+	// Failed command output (non-zero exit) - red
+	failedOutputStyle = lipgloss.NewStyle().
+				Foreground(errorColor).
+				Bold(true)
+	failedOutputContentStyle = lipgloss.NewStyle().
+					Foreground(hexColor("#FCA5A5"))
 
 	// Text style - white (but we probably won't show this)
 	textIcon  = "💬"
 	textStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F9FAFB"))
+			Foreground(hexColor("#F9FAFB"))
 
 	// Hook style - cyan (system-injected output, distinct from tool calls)
 	hookIcon  = "🪝"
 	hookStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#06B6D4")).
+			Foreground(hexColor("#06B6D4")).
 			Bold(true)
 	hookContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#67E8F9"))
+				Foreground(hexColor("#67E8F9"))
+
+	// Command style - blue (slash and ! commands typed by the user)
+	commandIcon  = "❯"
+	commandStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#818CF8")).
+			Bold(true)
+	commandContentStyle = lipgloss.NewStyle().
+				Foreground(hexColor("#C7D2FE"))
 
 	// Diagnostics style - red-ish (LSP findings after edits)
 	diagnosticsIcon  = "⚠"
 	diagnosticsStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#F87171")).
+				Foreground(hexColor("#F87171")).
 				Bold(true)
 	diagnosticsContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FCA5A5"))
+				Foreground(hexColor("#FCA5A5"))
 
 	// Debug style - dim grey/orange, used for -D flag
 	debugIcon  = "🔍"
 	debugStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#9CA3AF")).
+			Foreground(hexColor("#9CA3AF")).
 			Bold(true)
 	debugContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#9CA3AF"))
+				Foreground(hexColor("#9CA3AF"))
+
+	// Unknown style - lines the parser couldn't read, used for -lenient
+	unknownIcon  = "?"
+	unknownStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#FBBF24")).
+			Bold(true)
+
+	// API error style - a red banner, so throttling and overload stand out
+	// from an agent that is merely slow
+	apiErrorIcon        = "⛔"
+	apiErrorBannerStyle = lipgloss.NewStyle().
+				Background(errorColor).
+				Foreground(headerFgColor).
+				Bold(true).
+				Padding(0, 1)
 
 	// Agent name styles
 	mainAgentStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#60A5FA")).
+			Foreground(hexColor("#60A5FA")).
 			Bold(true)
 	subAgentStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F472B6")).
+			Foreground(hexColor("#F472B6")).
 			Bold(true)
 
 	// Tree styles
 	treeSelectedStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#374151")).
-				Foreground(lipgloss.Color("#F9FAFB")).
+				Background(hexColor("#374151")).
+				Foreground(hexColor("#F9FAFB")).
 				Bold(true)
 	treeNormalStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#D1D5DB"))
+			Foreground(hexColor("#D1D5DB"))
 
 	// Border styles
 	treeBorderStyle = lipgloss.NewStyle().
@@ -91,9 +125,17 @@ var (
 				BorderForeground(mutedColor).
 				Padding(0, 1)
 
+	// Pane titles, set into the top borders
+	paneTitleStyle        = lipgloss.NewStyle().Foreground(mutedColor)
+	focusedPaneTitleStyle = lipgloss.NewStyle().
+				Background(primaryColor).
+				Foreground(hexColor("#F9FAFB")).
+				Bold(true).
+				Padding(0, 1)
+
 	// Header/toggle bar
-	headerBgColor = lipgloss.Color("#374151")
-	headerFgColor = lipgloss.Color("#F9FAFB")
+	headerBgColor = hexColor("#374151")
+	headerFgColor = hexColor("#F9FAFB")
 
 	headerStyle = lipgloss.NewStyle().
 			Background(headerBgColor).
@@ -119,6 +161,113 @@ var (
 	separatorStyle = lipgloss.NewStyle().
 			Foreground(mutedColor)
 
+	// Session colors tell sessions apart when several share the stream:
+	// they tint each item's separator (and gutter bar, if enabled) and the
+	// session's arrow in the tree. Sessions take them in tree order.
+	sessionColors = []lipgloss.TerminalColor{
+		hexColor("#60A5FA"), // Blue
+		hexColor("#F472B6"), // Pink
+		hexColor("#34D399"), // Green
+		hexColor("#FBBF24"), // Amber
+		hexColor("#A78BFA"), // Violet
+		hexColor("#F87171"), // Red
+		hexColor("#22D3EE"), // Cyan
+		hexColor("#FB923C"), // Orange
+	}
+
+	// Stream item cursor bar (J/K selection)
+	selectedItemStyle = lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true)
+
+	// User annotations (see internal/notes)
+	noteIcon  = "✎"
+	noteStyle = lipgloss.NewStyle().
+			Foreground(warningColor).
+			Italic(true)
+
+	// Loop warning badge on tree nodes (see internal/loops)
+	loopIcon  = "⚠"
+	loopStyle = lipgloss.NewStyle().
+			Foreground(warningColor).
+			Bold(true)
+
+	// Subagent report badge on tree nodes and header in the stream
+	reportIcon  = "📨"
+	reportStyle = lipgloss.NewStyle().
+			Foreground(secondaryColor).
+			Bold(true)
+
+	// Model/thinking switch badge on tree nodes and marker in the stream
+	// (see internal/modelswitch)
+	switchIcon  = "⇄"
+	switchStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#A78BFA")).
+			Bold(true)
+
+	// Fork badge on session nodes and marker in the stream (see
+	// internal/fork)
+	forkIcon  = "⑂"
+	forkStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#60A5FA")).
+			Bold(true)
+
+	// "↓ N new" chip in the stream border
+	newItemsChipStyle = lipgloss.NewStyle().
+				Background(secondaryColor).
+				Foreground(bgColor).
+				Bold(true).
+				Padding(0, 1)
+
+	// "⏩ fast-forwarding…" chip while output arrives faster than it is
+	// rendered (see storm.go)
+	fastForwardStyle = newItemsChipStyle.Background(warningColor)
+
+	// The stream frozen at an earlier point ([ and ]).
+	scrubStyle = newItemsChipStyle.Background(primaryColor).Foreground(headerFgColor)
+
+	// Test run badges on Bash results (see internal/testrun)
+	testPassStyle = newItemsChipStyle
+	testFailStyle = newItemsChipStyle.Background(errorColor).Foreground(headerFgColor)
+
+	// Build errors read from a Bash result: a chip in its header.
+	buildErrorStyle   = testFailStyle
+	buildWarningStyle = newItemsChipStyle.Background(warningColor)
+
+	// Debug footer (:queues) once the watcher dropped something.
+	droppedStyle = helpStyle.Foreground(errorColor).Bold(true)
+
+	// Locations in output: build errors' file:line, and the selected
+	// item's paths, one of them under the path cursor (l).
+	linkStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#93C5FD")).
+			Underline(true)
+	currentLinkStyle = linkStyle.Reverse(true)
+
+	// Badge on sessions discovered while running; it blinks between the
+	// two styles for a while (see TreeView.Flash).
+	newSessionBadge      = "✦ new"
+	askBadge             = "✦ new · + shows"
+	newSessionStyle      = newItemsChipStyle.Padding(0)
+	newSessionBlinkStyle = lipgloss.NewStyle().
+				Foreground(secondaryColor).
+				Bold(true)
+
+	// Working-directory change after a Bash command (cd, shell reset)
+	cwdIcon  = "📂"
+	cwdStyle = lipgloss.NewStyle().
+			Foreground(hexColor("#93C5FD")).
+			Italic(true)
+
+	// Per-session activity sparkline in the tree (see sparkline.go)
+	sparkStyle = lipgloss.NewStyle().
+			Foreground(secondaryColor)
+
+	// Section heading inside the stats pane
+	statsHeaderStyle = lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Bold(true)
+
 	// Muted text style (for truncation messages etc)
 	mutedStyle = lipgloss.NewStyle().
 			Foreground(mutedColor)
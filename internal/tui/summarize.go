@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/summarize"
+)
+
+// summaryMsg carries a summary back from the summarizer.
+type summaryMsg struct {
+	title   string
+	summary string
+	err     error
+}
+
+// summarizeSession sends the last summarizeWindow of the selected session
+// (the one in the tree, else the selected item's, else the latest item's)
+// to the configured summarizer. The summary opens in the pager when it
+// comes back.
+func (m *Model) summarizeSession() tea.Cmd {
+	if m.summarizer == nil {
+		m.status = "no summarizer: set command or model under [summarize] in the config"
+		return nil
+	}
+	if m.summarizing {
+		m.status = "already summarizing…"
+		return nil
+	}
+	sessionID := ""
+	if m.focus == FocusTree {
+		sessionID = m.tree.GetSelectedSession()
+	}
+	items := m.stream.Items()
+	if sessionID == "" {
+		if item, ok := m.stream.SelectedItem(); ok {
+			sessionID = item.SessionID
+		} else if len(items) > 0 {
+			sessionID = items[len(items)-1].SessionID
+		}
+	}
+	since := time.Now().Add(-m.summarizeWindow)
+	var window []parser.StreamItem
+	for _, item := range items {
+		if item.SessionID == sessionID && !item.Timestamp.Before(since) {
+			window = append(window, item)
+		}
+	}
+	if len(window) == 0 {
+		m.status = fmt.Sprintf("nothing to summarize in the last %s", formatWindow(m.summarizeWindow))
+		return nil
+	}
+
+	req := summarize.Request{SessionID: sessionID, Items: window}
+	for _, node := range m.tree.Root.Children {
+		if node.ID == sessionID {
+			req.Project = node.Name
+		}
+	}
+	title := fmt.Sprintf("✦ Summary · %s · last %s", cmp.Or(req.Project, truncate(sessionID, 12)), formatWindow(m.summarizeWindow))
+	m.summarizing = true
+	m.status = fmt.Sprintf("summarizing %d items…", len(window))
+	s := m.summarizer
+	return func() tea.Msg {
+		summary, err := s.Summarize(context.Background(), req)
+		return summaryMsg{title: title, summary: summary, err: err}
+	}
+}
+
+// showSummary opens a returned summary in the pager.
+func (m *Model) showSummary(msg summaryMsg) {
+	m.summarizing = false
+	if msg.err != nil {
+		m.status = fmt.Sprintf("summarize: %v", msg.err)
+		return
+	}
+	m.status = ""
+	// The pager cuts long lines; a summary is prose, so wrap it to the pane.
+	width := m.width - 2
+	if m.showTree {
+		width = m.width - m.treeWidth - 5
+	}
+	m.openPager(newTextPager(msg.title, wrapLines(msg.summary, max(20, width-4), 0)))
+}
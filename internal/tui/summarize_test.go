@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/summarize"
+)
+
+func TestSummarizeSession(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	m.tree.AddSession("s1", "/work/api")
+	m.tree.AddSession("s2", "/work/web")
+	m.syncFilters()
+	press := func() tea.Cmd { return m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")}) }
+
+	if press() != nil || !strings.HasPrefix(m.status, "no summarizer") {
+		t.Fatalf("unconfigured: status %q", m.status)
+	}
+
+	// The command echoes the transcript: the old item and the other
+	// session's are left out.
+	m.summarizer = &summarize.Summarizer{Command: `echo "session $ESP_SESSION"; grep -e recent -e latest -e ago -e elsewhere`}
+	m.summarizeWindow = time.Hour
+	add := func(session, text string, at time.Time) {
+		m.Update(streamItemMsg(parser.StreamItem{Type: parser.TypeText, SessionID: session, AgentName: "Main", Content: text, Timestamp: at}))
+	}
+	add("s1", "long ago", time.Now().Add(-2*time.Hour))
+	add("s1", "recent", time.Now().Add(-time.Minute))
+	add("s2", "elsewhere", time.Now())
+	add("s1", "latest", time.Now())
+
+	cmd := press()
+	if cmd == nil || !m.summarizing {
+		t.Fatalf("no summary started: %q", m.status)
+	}
+	if press() != nil || m.status != "already summarizing…" {
+		t.Errorf("second S: status %q", m.status)
+	}
+	m.Update(cmd())
+	if m.pager == nil || m.summarizing {
+		t.Fatalf("summary not shown: %q", m.status)
+	}
+	view := m.pager.View()
+	for _, want := range []string{"✦ Summary · api · last 1h", "session s1", "recent", "latest"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("pager lacks %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "ago") || strings.Contains(view, "elsewhere") {
+		t.Errorf("pager shows items outside the window or session:\n%s", view)
+	}
+}
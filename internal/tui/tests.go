@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/testrun"
+)
+
+// testsIcon leads the tests panel and its header.
+const testsIcon = "🧪"
+
+// testsHistory is how many runs per session the tests panel remembers.
+const testsHistory = 8
+
+// testRun is one recognized test run.
+type testRun struct {
+	at        time.Time
+	agentID   string
+	agentName string
+	command   string
+	cwd       string
+	result    testrun.Result
+}
+
+// sessionTests are a session's latest test runs, oldest first.
+type sessionTests struct {
+	sessionID string
+	runs      []testRun
+}
+
+// TestsView shows, per session, the latest test run of its enabled agents
+// and how the runs before it went, read from Bash results by the testrun
+// recognizers.
+type TestsView struct {
+	pane
+	sessions []*sessionTests // in order of first run
+	calls    map[string]string
+	now      func() time.Time
+}
+
+// NewTestsView creates an empty tests panel.
+func NewTestsView() *TestsView {
+	return &TestsView{calls: make(map[string]string), now: time.Now}
+}
+
+// AddItem records a Bash call's command, and the test run its result
+// shows, if any.
+func (t *TestsView) AddItem(item parser.StreamItem) {
+	switch item.Type {
+	case parser.TypeToolInput:
+		if item.ToolName == "Bash" && item.ToolID != "" {
+			t.calls[item.ToolID] = bashCommand(item)
+		}
+	case parser.TypeToolOutput:
+		command, ok := t.calls[item.ToolID]
+		if !ok {
+			return
+		}
+		delete(t.calls, item.ToolID)
+		result, ok := testrun.Recognize(command, item.Content)
+		if !ok {
+			return
+		}
+		i := slices.IndexFunc(t.sessions, func(s *sessionTests) bool { return s.sessionID == item.SessionID })
+		if i < 0 {
+			t.sessions = append(t.sessions, &sessionTests{sessionID: item.SessionID})
+			i = len(t.sessions) - 1
+		}
+		s := t.sessions[i]
+		s.runs = append(s.runs, testRun{at: item.Timestamp, agentID: item.AgentID, agentName: item.AgentName, command: command, cwd: item.Cwd, result: result})
+		if len(s.runs) > testsHistory {
+			s.runs = s.runs[len(s.runs)-testsHistory:]
+		}
+	}
+}
+
+// enabledRuns returns a session's runs by enabled agents.
+func (t *TestsView) enabledRuns(s *sessionTests) []testRun {
+	return slices.DeleteFunc(slices.Clone(s.runs), func(r testRun) bool {
+		return !t.enabled(s.sessionID, r.agentID)
+	})
+}
+
+// View renders one block per session: the latest run's badge, counts and
+// command, then a ✓/✗ trail of the runs before it.
+func (t *TestsView) View() string {
+	innerWidth := max(1, t.width-4)
+	innerHeight := max(1, t.height-2)
+	fit := func(line string) string { return runewidth.Truncate(line, innerWidth, "…") }
+	now := t.now()
+
+	lines := []string{statsHeaderStyle.Render(fit(testsIcon + " Tests"))}
+	for _, s := range slices.Backward(t.sessions) {
+		runs := t.enabledRuns(s)
+		if len(runs) == 0 {
+			continue
+		}
+		last := runs[len(runs)-1]
+		label := "Session " + truncate(s.sessionID, 12)
+		if last.cwd != "" {
+			label += " · " + filepath.Base(last.cwd)
+		}
+		lines = append(lines, "", fit(label))
+		style := toolOutputStyle
+		if !last.result.OK() {
+			style = failedOutputStyle
+		}
+		lines = append(lines,
+			style.Render(fit(fmt.Sprintf("  %s %s  %s", testMark(last.result), last.result.Runner, last.result.Summary()))),
+			mutedStyle.Render(fit(fmt.Sprintf("    %s · %s · %s", last.command, cmp.Or(last.agentName, "Main"), recapAgo(now, last.at)))))
+		if len(runs) > 1 {
+			var trail []string
+			for _, r := range runs {
+				trail = append(trail, testMark(r.result))
+			}
+			lines = append(lines, mutedStyle.Render(fit("    runs: "+strings.Join(trail, " "))))
+		}
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "", mutedStyle.Render(fit("  No test runs recognized yet (go test, pytest, jest, vitest, cargo).")))
+	}
+	return padLines(lines, innerHeight)
+}
+
+// testMark is ✓ for a passing run, ✗ for a failing one.
+func testMark(r testrun.Result) string {
+	if r.OK() {
+		return "✓"
+	}
+	return "✗"
+}
+
+// testBadge is the colored chip after a Bash result's header:
+// "✓ go test 40 passed".
+func testBadge(r testrun.Result) string {
+	style := testPassStyle
+	if !r.OK() {
+		style = testFailStyle
+	}
+	return style.Render(fmt.Sprintf("%s %s %s", testMark(r), r.Runner, r.Summary()))
+}
+
+// bashCommand returns a Bash call's command.
+func bashCommand(item parser.StreamItem) string {
+	var in struct {
+		Command string `json:"command"`
+	}
+	json.Unmarshal(item.ToolInput, &in)
+	return strings.TrimSpace(in.Command)
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestTestsPanel(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 140, Height: 40})
+	m.tree.AddSession("s1", "home/user/app")
+	m.syncFilters()
+
+	run := func(id, command, output string) {
+		input, _ := json.Marshal(map[string]string{"command": command})
+		now := time.Now()
+		m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolName: "Bash", ToolID: id, ToolInput: input, Content: command, Timestamp: now})
+		m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolName: "Bash", ToolID: id, Content: output, Timestamp: now})
+	}
+	run("b1", "go test ./...", "--- FAIL: TestX (0.00s)\nFAIL\nFAIL\tgithub.com/x/a\t0.01s\nok  \tgithub.com/x/b\t0.02s\n")
+	run("b2", "ls", "go.mod\nmain.go\n")
+	run("b3", "pytest -q", "........\n8 passed in 0.12s\n")
+
+	if stream := m.stream.View(); !strings.Contains(stream, "✗ go test 1 failed, 1 passed (packages)") || !strings.Contains(stream, "✓ pytest 8 passed") {
+		t.Errorf("stream lacks the test badges:\n%s", stream)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if !m.showTests {
+		t.Fatal("T didn't open the tests panel")
+	}
+	view := m.streamPaneView()
+	for _, want := range []string{testsIcon + " Tests", "✓ pytest  8 passed", "pytest -q · Main", "runs: ✗ ✓"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("panel lacks %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if m.showTests || m.panelShown() {
+		t.Error("T didn't close the tests panel")
+	}
+}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"sort"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// taskCall is one Task/Agent tool_use seen in the stream.
+type taskCall struct {
+	sessionID   string
+	toolID      string
+	description string
+	at          time.Time
+	seq         int    // arrival order, to break timestamp ties
+	agentID     string // from the result's toolUseResult.agentId, once known
+}
+
+// taskIndex links Task/Agent tool_use IDs to the subagent that ran them.
+// The tool result names the subagent exactly; until it arrives, calls are
+// matched to subagents by start time: in call order, each call takes the
+// earliest unclaimed subagent that appeared at or after it.
+type taskIndex struct {
+	calls     map[string]*taskCall            // toolID -> call
+	firstSeen map[string]map[string]time.Time // sessionID -> agentID -> first item
+	seq       int
+}
+
+func newTaskIndex() *taskIndex {
+	return &taskIndex{
+		calls:     make(map[string]*taskCall),
+		firstSeen: make(map[string]map[string]time.Time),
+	}
+}
+
+func (x *taskIndex) add(item parser.StreamItem) {
+	if item.AgentID != "" && !item.Timestamp.IsZero() {
+		agents := x.firstSeen[item.SessionID]
+		if agents == nil {
+			agents = make(map[string]time.Time)
+			x.firstSeen[item.SessionID] = agents
+		}
+		if t, ok := agents[item.AgentID]; !ok || item.Timestamp.Before(t) {
+			agents[item.AgentID] = item.Timestamp
+		}
+	}
+
+	switch item.Type {
+	case parser.TypeToolInput:
+		if isTaskTool(item.ToolName) && item.ToolID != "" {
+			if _, ok := x.calls[item.ToolID]; !ok {
+				x.seq++
+				x.calls[item.ToolID] = &taskCall{
+					sessionID:   item.SessionID,
+					toolID:      item.ToolID,
+					description: firstLine(item.Content),
+					at:          item.Timestamp,
+					seq:         x.seq,
+				}
+			}
+		}
+	case parser.TypeToolOutput:
+		if c, ok := x.calls[item.ToolID]; ok && item.SpawnedAgentID != "" {
+			c.agentID = item.SpawnedAgentID
+		}
+	}
+}
+
+// call returns the Task call for toolID, if it is one.
+func (x *taskIndex) call(toolID string) (*taskCall, bool) {
+	c, ok := x.calls[toolID]
+	return c, ok
+}
+
+// agentFor returns the subagent that ran the Task call toolID, or "".
+func (x *taskIndex) agentFor(toolID string) string {
+	target, ok := x.calls[toolID]
+	if !ok {
+		return ""
+	}
+	if target.agentID != "" {
+		return target.agentID
+	}
+
+	var calls []*taskCall
+	claimed := map[string]bool{}
+	for _, c := range x.calls {
+		if c.sessionID != target.sessionID {
+			continue
+		}
+		if c.agentID != "" {
+			claimed[c.agentID] = true
+		} else {
+			calls = append(calls, c)
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if !calls[i].at.Equal(calls[j].at) {
+			return calls[i].at.Before(calls[j].at)
+		}
+		return calls[i].seq < calls[j].seq
+	})
+
+	type seen struct {
+		id string
+		at time.Time
+	}
+	var agents []seen
+	for id, at := range x.firstSeen[target.sessionID] {
+		if !claimed[id] {
+			agents = append(agents, seen{id, at})
+		}
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if !agents[i].at.Equal(agents[j].at) {
+			return agents[i].at.Before(agents[j].at)
+		}
+		return agents[i].id < agents[j].id
+	})
+
+	for _, c := range calls {
+		for i, a := range agents {
+			if a.id == "" || a.at.Before(c.at) {
+				continue
+			}
+			if c == target {
+				return a.id
+			}
+			agents[i].id = "" // claimed by an earlier call
+			break
+		}
+	}
+	return ""
+}
+
+// threadFilter narrows the stream to one Task: the originating call, its
+// result, and everything its subagent did.
+type threadFilter struct {
+	sessionID string
+	toolID    string
+	agentID   string // "" until the subagent is known
+	exact     bool   // agentID came from the call's result, not a guess
+	label     string
+}
+
+// resolve looks up the thread's subagent again. Until the call's result
+// names it, agentID is agentFor's guess by start time, which a later
+// result (of this call or a parallel one) can overturn.
+func (f *threadFilter) resolve(x *taskIndex) {
+	f.agentID = x.agentFor(f.toolID)
+	if c, ok := x.call(f.toolID); ok && c.agentID != "" {
+		f.exact = true
+	}
+}
+
+func (f *threadFilter) matches(item parser.StreamItem) bool {
+	if item.SessionID != f.sessionID {
+		return false
+	}
+	if item.ToolID == f.toolID {
+		return true
+	}
+	return f.agentID != "" && item.AgentID == f.agentID
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestTaskIndex_MatchesByStartTime(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	x := newTaskIndex()
+	// Two parallel Tasks from one message; subagents start in call order.
+	x.add(newTestItem(parser.TypeToolInput, "s1", "", "explore", withTool("Agent", "task1"), withTime(t0)))
+	x.add(newTestItem(parser.TypeToolInput, "s1", "", "review", withTool("Agent", "task2"), withTime(t0)))
+	x.add(newTestItem(parser.TypeThinking, "s1", "old", "", withTime(t0.Add(-time.Minute))))
+	x.add(newTestItem(parser.TypeThinking, "s1", "a1", "", withTime(t0.Add(time.Second))))
+	x.add(newTestItem(parser.TypeThinking, "s1", "a2", "", withTime(t0.Add(2*time.Second))))
+
+	if got := x.agentFor("task1"); got != "a1" {
+		t.Errorf("task1 -> %q, want a1", got)
+	}
+	if got := x.agentFor("task2"); got != "a2" {
+		t.Errorf("task2 -> %q, want a2", got)
+	}
+
+	// The result's agentId is authoritative and frees the heuristic.
+	x.add(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolID: "task1", SpawnedAgentID: "a2"})
+	if got := x.agentFor("task1"); got != "a2" {
+		t.Errorf("task1 after result -> %q, want a2", got)
+	}
+	if got := x.agentFor("task2"); got != "a1" {
+		t.Errorf("task2 after task1 result -> %q, want a1", got)
+	}
+	if got := x.agentFor("nope"); got != "" {
+		t.Errorf("unknown tool -> %q, want empty", got)
+	}
+}
+
+func TestStreamView_FollowTask(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStreamView()
+	s.SetSize(100, 200)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}})
+
+	s.AddItem(newTestItem(parser.TypeText, "s1", "", "before", withTime(t0)))
+	s.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "explore repo", withTool("Task", "task1"), withTime(t0.Add(time.Second))))
+	s.AddItem(newTestItem(parser.TypeThinking, "s1", "a1", "subagent thinking", withTime(t0.Add(2*time.Second))))
+	s.AddItem(newTestItem(parser.TypeText, "s1", "", "unrelated main", withTime(t0.Add(3*time.Second))))
+	s.AddItem(newTestItem(parser.TypeToolOutput, "s1", "", "task result", withTool("", "task1"), withTime(t0.Add(4*time.Second))))
+
+	s.SelectNext()
+	if s.FollowSelectedTask() {
+		t.Fatal("plain text item should not be followable")
+	}
+	s.SelectNext() // the Task call
+	if !s.FollowSelectedTask() {
+		t.Fatal("expected to follow the Task call")
+	}
+	if label, ok := s.Following(); !ok || label != "explore repo" {
+		t.Errorf("Following = %q, %v", label, ok)
+	}
+
+	view := s.View()
+	for _, want := range []string{"explore repo", "subagent thinking", "task result"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("thread view missing %q", want)
+		}
+	}
+	for _, hidden := range []string{"before", "unrelated main"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("thread view should hide %q", hidden)
+		}
+	}
+
+	if !s.Unfollow() {
+		t.Fatal("Unfollow should report an active thread")
+	}
+	if !strings.Contains(s.View(), "unrelated main") {
+		t.Error("Unfollow should restore the tree filters")
+	}
+}
+
+func TestStreamView_FollowTaskCorrectsGuess(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStreamView()
+	s.SetSize(100, 200)
+	s.SetEnabledFilters([]EnabledFilter{{SessionID: "s1"}})
+
+	// Two parallel Tasks whose subagents started out of call order.
+	s.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "explore", withTool("Task", "task1"), withTime(t0)))
+	s.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "review", withTool("Task", "task2"), withTime(t0)))
+	s.AddItem(newTestItem(parser.TypeThinking, "s1", "a1", "reviewing", withTime(t0.Add(time.Second))))
+	s.AddItem(newTestItem(parser.TypeThinking, "s1", "a2", "exploring", withTime(t0.Add(2*time.Second))))
+
+	s.SelectNext() // task1
+	if !s.FollowSelectedTask() {
+		t.Fatal("expected to follow the Task call")
+	}
+	if s.thread.agentID != "a1" {
+		t.Fatalf("guessed %q, want a1", s.thread.agentID)
+	}
+
+	// task2's result claims a1, so task1's guess moves to a2.
+	out := newTestItem(parser.TypeToolOutput, "s1", "", "review done", withTool("", "task2"), withTime(t0.Add(3*time.Second)))
+	out.SpawnedAgentID = "a1"
+	s.AddItem(out)
+	if s.thread.agentID != "a2" || s.thread.exact {
+		t.Errorf("after task2's result: agent %q (exact %v), want a2 still a guess", s.thread.agentID, s.thread.exact)
+	}
+
+	// task1's own result settles it.
+	out = newTestItem(parser.TypeToolOutput, "s1", "", "explore done", withTool("", "task1"), withTime(t0.Add(4*time.Second)))
+	out.SpawnedAgentID = "a2"
+	s.AddItem(out)
+	if s.thread.agentID != "a2" || !s.thread.exact {
+		t.Errorf("after task1's result: agent %q (exact %v), want a2 for good", s.thread.agentID, s.thread.exact)
+	}
+	view := s.View()
+	if !strings.Contains(view, "exploring") || strings.Contains(view, "reviewing") {
+		t.Errorf("thread view shows the wrong subagent:\n%s", view)
+	}
+}
@@ -0,0 +1,306 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+const (
+	// MaxTimelineSegments caps the segments kept per lane. Adjacent segments
+	// of the same kind are coalesced, so this is only hit by very long and
+	// very choppy sessions; the oldest segments are dropped first.
+	MaxTimelineSegments = 5000
+	// timelineLabelWidth is the width of the lane-name column.
+	timelineLabelWidth = 12
+	// timelineLiveWindow is how recent a lane's last event must be for its
+	// open state (running tool, thinking) to be extended up to "now".
+	timelineLiveWindow = 5 * time.Minute
+)
+
+// SegmentKind classifies what an agent was doing over a span of time.
+type SegmentKind int
+
+const (
+	SegmentIdle     SegmentKind = iota // waiting on the user / nothing in flight
+	SegmentThinking                    // model generating (thinking, composing tool calls)
+	SegmentTool                        // at least one tool_use is awaiting its result
+)
+
+// timelineSegment is one contiguous span of a single kind.
+type timelineSegment struct {
+	kind  SegmentKind
+	start time.Time
+	end   time.Time
+}
+
+// timelineLane is the per-agent state machine that turns stream items into
+// segments. The gap between two consecutive items is attributed to whatever
+// the lane was doing when the first of them arrived.
+type timelineLane struct {
+	sessionID string
+	agentID   string
+	name      string
+	segments  []timelineSegment
+	first     time.Time
+	last      time.Time
+	busy      bool                 // model is working (after thinking / a tool result)
+	pending   map[string]time.Time // open tool_use ToolID -> start
+}
+
+// TimelineView renders each agent as a horizontal lane of colored segments
+// (thinking / tool execution / idle) over the session's wall-clock time.
+type TimelineView struct {
+	pane
+	lanes []*timelineLane
+}
+
+// NewTimelineView creates an empty timeline.
+func NewTimelineView() *TimelineView {
+	return &TimelineView{}
+}
+
+// AddItem advances the lane state machine for the item's agent.
+func (t *TimelineView) AddItem(item parser.StreamItem) {
+	if item.Timestamp.IsZero() {
+		return
+	}
+	lane := t.laneFor(item)
+	ts := item.Timestamp
+
+	if lane.first.IsZero() {
+		lane.first = ts
+	}
+	if !lane.last.IsZero() && ts.After(lane.last) {
+		lane.addSegment(lane.currentKind(), lane.last, ts)
+	}
+	if ts.After(lane.last) {
+		lane.last = ts
+	}
+
+	switch item.Type {
+	case parser.TypeToolInput:
+		if item.ToolID != "" {
+			lane.pending[item.ToolID] = ts
+		}
+		lane.busy = true
+	case parser.TypeToolOutput:
+		delete(lane.pending, item.ToolID)
+		lane.busy = true
+	case parser.TypeThinking:
+		lane.busy = true
+	case parser.TypeText, parser.TypeTurnMarker, parser.TypeInterrupt:
+		// A response (or an explicit turn end, or an interrupt) hands
+		// control back to the user; whatever follows is idle until the
+		// next model event.
+		lane.busy = false
+	}
+}
+
+func (t *TimelineView) laneFor(item parser.StreamItem) *timelineLane {
+	for _, l := range t.lanes {
+		if l.sessionID == item.SessionID && l.agentID == item.AgentID {
+			if l.name == "" {
+				l.name = item.AgentName
+			}
+			return l
+		}
+	}
+	l := &timelineLane{
+		sessionID: item.SessionID,
+		agentID:   item.AgentID,
+		name:      item.AgentName,
+		pending:   make(map[string]time.Time),
+	}
+	t.lanes = append(t.lanes, l)
+	return l
+}
+
+// currentKind is what the lane is doing right now, given its state.
+func (l *timelineLane) currentKind() SegmentKind {
+	switch {
+	case len(l.pending) > 0:
+		return SegmentTool
+	case l.busy:
+		return SegmentThinking
+	default:
+		return SegmentIdle
+	}
+}
+
+func (l *timelineLane) addSegment(kind SegmentKind, start, end time.Time) {
+	if n := len(l.segments); n > 0 {
+		prev := &l.segments[n-1]
+		if prev.kind == kind && !prev.end.Before(start) {
+			if end.After(prev.end) {
+				prev.end = end
+			}
+			return
+		}
+	}
+	l.segments = append(l.segments, timelineSegment{kind: kind, start: start, end: end})
+	if len(l.segments) > MaxTimelineSegments {
+		l.segments = l.segments[len(l.segments)-MaxTimelineSegments:]
+	}
+}
+
+// segmentsUntil returns the lane's segments, extended with its open state
+// up to now when the lane is still live.
+func (l *timelineLane) segmentsUntil(now time.Time) []timelineSegment {
+	if l.last.IsZero() || !now.After(l.last) || now.Sub(l.last) > timelineLiveWindow {
+		return l.segments
+	}
+	segs := make([]timelineSegment, len(l.segments), len(l.segments)+1)
+	copy(segs, l.segments)
+	return append(segs, timelineSegment{kind: l.currentKind(), start: l.last, end: now})
+}
+
+// View renders the timeline at the current wall-clock time.
+func (t *TimelineView) View() string {
+	return t.render(time.Now())
+}
+
+func (t *TimelineView) render(now time.Time) string {
+	innerWidth := max(1, t.width-4)
+	innerHeight := max(1, t.height-2)
+
+	var lanes []*timelineLane
+	sessions := map[string]bool{}
+	var start, end time.Time
+	for _, l := range t.lanes {
+		if !t.enabled(l.sessionID, l.agentID) || l.first.IsZero() {
+			continue
+		}
+		lanes = append(lanes, l)
+		sessions[l.sessionID] = true
+		if start.IsZero() || l.first.Before(start) {
+			start = l.first
+		}
+		segs := l.segmentsUntil(now)
+		laneEnd := l.last
+		if len(segs) > 0 && segs[len(segs)-1].end.After(laneEnd) {
+			laneEnd = segs[len(segs)-1].end
+		}
+		if laneEnd.After(end) {
+			end = laneEnd
+		}
+	}
+
+	if len(lanes) == 0 {
+		return padLines([]string{mutedStyle.Render("No activity to chart yet.")}, innerHeight)
+	}
+
+	span := end.Sub(start)
+	barWidth := max(1, innerWidth-timelineLabelWidth-1)
+	perCol := span / time.Duration(barWidth)
+	if perCol <= 0 {
+		perCol = time.Second
+	}
+
+	axis := fmt.Sprintf("%s → %s  (%s, %s/col)",
+		start.Local().Format("15:04:05"), end.Local().Format("15:04:05"),
+		formatSpan(span), formatSpan(perCol))
+	lines := []string{
+		mutedStyle.Render(runewidth.Truncate(axis, innerWidth, "…")),
+		fmt.Sprintf("%s thinking  %s tool  %s idle",
+			thinkingStyle.Render("▓"), toolInputStyle.Render("█"), mutedStyle.Render("·")),
+	}
+
+	for _, l := range lanes {
+		if len(lines) >= innerHeight {
+			break
+		}
+		name := l.name
+		if len(sessions) > 1 {
+			name = l.sessionID[:min(4, len(l.sessionID))] + "·" + name
+		}
+		label := fmt.Sprintf("%-*s", timelineLabelWidth, truncate(name, timelineLabelWidth))
+		agentStyle := mainAgentStyle
+		if l.agentID != "" {
+			agentStyle = subAgentStyle
+		}
+		lines = append(lines, agentStyle.Render(label)+" "+renderLaneBar(l.segmentsUntil(now), start, perCol, barWidth))
+	}
+
+	return padLines(lines, innerHeight)
+}
+
+// renderLaneBar draws one column per perCol-sized bucket, coloring each by
+// the kind that occupied most of the bucket. Buckets with no coverage are
+// blank (before the lane's first event).
+func renderLaneBar(segs []timelineSegment, start time.Time, perCol time.Duration, width int) string {
+	var b strings.Builder
+	si := 0
+	for col := 0; col < width; col++ {
+		bStart := start.Add(perCol * time.Duration(col))
+		bEnd := bStart.Add(perCol)
+		var cover [3]time.Duration
+		for si < len(segs) && !segs[si].end.After(bStart) {
+			si++
+		}
+		for j := si; j < len(segs) && segs[j].start.Before(bEnd); j++ {
+			s, e := segs[j].start, segs[j].end
+			if s.Before(bStart) {
+				s = bStart
+			}
+			if e.After(bEnd) {
+				e = bEnd
+			}
+			if e.After(s) {
+				cover[segs[j].kind] += e.Sub(s)
+			}
+		}
+		best, bestDur := SegmentIdle, time.Duration(0)
+		for k, d := range cover {
+			if d > bestDur {
+				best, bestDur = SegmentKind(k), d
+			}
+		}
+		if bestDur == 0 {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(segmentGlyph(best))
+	}
+	return b.String()
+}
+
+func segmentGlyph(kind SegmentKind) string {
+	switch kind {
+	case SegmentThinking:
+		return thinkingStyle.Render("▓")
+	case SegmentTool:
+		return toolInputStyle.Render("█")
+	default:
+		return mutedStyle.Render("·")
+	}
+}
+
+// formatSpan renders a duration compactly: 850ms, 12s, 4m10s, 2h05m.
+func formatSpan(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// padLines joins lines, padding with blanks (or clipping) to exactly height rows.
+func padLines(lines []string, height int) string {
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestTimeline_ClassifiesGaps(t *testing.T) {
+	tv := NewTimelineView()
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "", "", withTime(t0)))
+	tv.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "", withTool("", "toolu_1"), withTime(t0.Add(10*time.Second))))
+	tv.AddItem(newTestItem(parser.TypeToolOutput, "s1", "", "", withTool("", "toolu_1"), withTime(t0.Add(40*time.Second))))
+	tv.AddItem(newTestItem(parser.TypeText, "s1", "", "", withTime(t0.Add(50*time.Second))))
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "", "", withTime(t0.Add(110*time.Second))))
+
+	if len(tv.lanes) != 1 {
+		t.Fatalf("expected 1 lane, got %d", len(tv.lanes))
+	}
+	got := tv.lanes[0].segments
+	want := []struct {
+		kind SegmentKind
+		dur  time.Duration
+	}{
+		{SegmentThinking, 10 * time.Second},
+		{SegmentTool, 30 * time.Second},
+		{SegmentThinking, 10 * time.Second},
+		{SegmentIdle, 60 * time.Second},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d segments, got %d: %+v", len(want), len(got), got)
+	}
+	for i, w := range want {
+		if got[i].kind != w.kind || got[i].end.Sub(got[i].start) != w.dur {
+			t.Errorf("segment %d = kind %d dur %s, want kind %d dur %s",
+				i, got[i].kind, got[i].end.Sub(got[i].start), w.kind, w.dur)
+		}
+	}
+}
+
+func TestTimeline_LanePerAgent(t *testing.T) {
+	tv := NewTimelineView()
+	t0 := time.Now()
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "", "", withTime(t0)))
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "agent1", "", withTime(t0.Add(time.Second))))
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "", "", withTime(t0.Add(2*time.Second))))
+
+	if len(tv.lanes) != 2 {
+		t.Fatalf("expected 2 lanes (Main + agent), got %d", len(tv.lanes))
+	}
+}
+
+func TestTimeline_RenderRespectsFilters(t *testing.T) {
+	tv := NewTimelineView()
+	tv.SetSize(80, 10)
+	t0 := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tv.AddItem(newTestItem(parser.TypeToolInput, "s1", "", "", withTool("", "toolu_1"), withTime(t0)))
+	tv.AddItem(newTestItem(parser.TypeToolOutput, "s1", "", "", withTool("", "toolu_1"), withTime(t0.Add(time.Minute))))
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "agent1", "", withName("Explore"), withTime(t0)))
+	tv.AddItem(newTestItem(parser.TypeText, "s1", "agent1", "", withName("Explore"), withTime(t0.Add(time.Minute))))
+
+	// Nothing enabled → placeholder.
+	if out := tv.render(t0.Add(time.Hour)); !strings.Contains(out, "No activity") {
+		t.Errorf("expected placeholder with no enabled lanes, got %q", out)
+	}
+
+	tv.SetEnabledFilters([]EnabledFilter{{SessionID: "s1", AgentID: ""}})
+	out := tv.render(t0.Add(time.Hour))
+	if !strings.Contains(out, "Main") {
+		t.Error("Main lane should be rendered")
+	}
+	if strings.Contains(out, "Explore") {
+		t.Error("disabled agent lane should not be rendered")
+	}
+	if !strings.Contains(out, "█") {
+		t.Error("tool segment glyph should be rendered")
+	}
+	if lines := strings.Split(out, "\n"); len(lines) != 8 {
+		t.Errorf("expected output padded to inner height 8, got %d lines", len(lines))
+	}
+}
+
+func TestTimeline_NarrowDoesNotPanic(t *testing.T) {
+	tv := NewTimelineView()
+	tv.SetEnabledFilters([]EnabledFilter{{SessionID: "s1", AgentID: ""}})
+	tv.AddItem(newTestItem(parser.TypeThinking, "s1", "", ""))
+	for _, w := range []int{-5, 0, 1, 4, 10} {
+		tv.SetSize(w, 3)
+		_ = tv.View()
+	}
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/heartbeat"
+)
+
+// With [terminal] title on, the terminal title follows the most recently
+// active session, so what the agents are doing shows in the tab bar even
+// while claude-esp's window is hidden.
+
+// titlePrefix starts every title, to tell claude-esp's tab apart from the
+// claude session it watches.
+const titlePrefix = "esp: "
+
+// windowTitle summarises the most recently active session, e.g.
+// "esp: claude-esp ⚙ running Bash (+2 working)".
+func (m *Model) windowTitle(now time.Time) string {
+	beats := m.beats.Snapshot(now, heartbeat.DefaultStallAfter)
+	if len(beats) == 0 {
+		return titlePrefix + "watching"
+	}
+	hb := beats[0]
+	project := filepath.Base(m.tree.SessionProject(hb.SessionID))
+	if project == "." || project == "/" {
+		project = truncate(hb.SessionID, 8)
+	}
+	title := titlePrefix + project + " " + stateLabel(hb)
+	others := 0
+	for _, b := range beats[1:] {
+		if b.State == heartbeat.StateWorking {
+			others++
+		}
+	}
+	if others > 0 {
+		title += fmt.Sprintf(" (+%d working)", others)
+	}
+	return title
+}
+
+// stateLabel is a session's state as shown in the title.
+func stateLabel(hb heartbeat.Heartbeat) string {
+	switch {
+	case hb.State == heartbeat.StateStalled:
+		return "⚠ stalled"
+	case hb.State == heartbeat.StateIdle:
+		return "✓ idle"
+	case hb.CurrentTool != nil:
+		return "⚙ running " + hb.CurrentTool.Name
+	default:
+		return "● working"
+	}
+}
+
+// updateTitle sets the terminal title if it changed. nil when titles are
+// off.
+func (m *Model) updateTitle() tea.Cmd {
+	if m.beats == nil {
+		return nil
+	}
+	title := m.windowTitle(time.Now())
+	if title == m.title {
+		return nil
+	}
+	m.title = title
+	return tea.SetWindowTitle(title)
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestWindowTitle(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	cfg := config.Default()
+	cfg.Terminal.Title = true
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, cfg)
+	m.tree.AddSession("s1", "/src/claude-esp")
+	m.tree.AddSession("s2", "/src/other")
+
+	if got := m.windowTitle(time.Now()); got != "esp: watching" {
+		t.Errorf("before any activity: %q", got)
+	}
+	now := time.Now()
+	m.addItem(parser.StreamItem{Type: parser.TypeThinking, SessionID: "s2", Timestamp: now.Add(-2 * time.Second)})
+	m.addItem(parser.StreamItem{Type: parser.TypeToolInput, SessionID: "s1", ToolID: "t1", ToolName: "Bash", Timestamp: now.Add(-time.Second)})
+	if got, want := m.windowTitle(now), "esp: claude-esp ⚙ running Bash (+1 working)"; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+
+	m.addItem(parser.StreamItem{Type: parser.TypeToolOutput, SessionID: "s1", ToolID: "t1", Timestamp: now})
+	m.addItem(parser.StreamItem{Type: parser.TypeTurnMarker, SessionID: "s1", Timestamp: now})
+	if got, want := m.windowTitle(now), "esp: claude-esp ✓ idle (+1 working)"; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+
+	// The title is only sent when it changes.
+	if m.updateTitle() == nil {
+		t.Fatal("first title not set")
+	}
+	if m.updateTitle() != nil {
+		t.Error("unchanged title sent again")
+	}
+}
+
+func TestWindowTitleOff(t *testing.T) {
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	if m.updateTitle() != nil || m.title != "" {
+		t.Error("title set with [terminal] title off")
+	}
+}
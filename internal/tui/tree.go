@@ -2,9 +2,15 @@ package tui
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+	"github.com/phiat/claude-esp/internal/watcher"
 )
 
 // NodeType indicates the type of tree node
@@ -16,6 +22,8 @@ const (
 	NodeTypeMain                    // Main conversation within a session
 	NodeTypeAgent                   // A subagent within a session
 	NodeTypeBackgroundTask          // A background task (tool running in background)
+	NodeTypeTodos                   // An agent's todo list (~/.claude/todos)
+	NodeTypeArtifact                // A file under ~/.claude an agent's tools referenced
 
 	// AgentIDDisplayLength is how many chars of agent ID to show in display name
 	AgentIDDisplayLength = 7
@@ -34,9 +42,9 @@ type TreeNode struct {
 	Children  []*TreeNode
 	Parent    *TreeNode
 
-	// Background task specific fields
+	// Background task (and artifact) specific fields
 	ParentAgentID string // which agent spawned this task (empty = main)
-	OutputPath    string // path to tool-results file
+	OutputPath    string // path to tool-results file (or the artifact)
 	IsComplete    bool   // whether the task has finished
 
 	// Per-agent context size (Main/Agent nodes only). ContextTokens is the
@@ -52,6 +60,65 @@ type TreeNode struct {
 	// the session wakes up again.
 	Collapsed bool
 	Pinned    bool
+
+	// HasNote marks sessions with a user annotation (see internal/notes).
+	HasNote bool
+
+	// ProjectPath is a session's full project path; Name is shortened.
+	ProjectPath string
+
+	// Host is the machine a session was pushed from to the daemon this
+	// instance is attached to; "" for local sessions. Shown before Name.
+	Host string
+
+	// Warning is the latest loop warning for a Main/Agent node (see
+	// internal/loops); "" when there is none. Shown as a ⚠ badge.
+	Warning string
+
+	// LongRunning describes the tool call a Main/Agent node has waited on
+	// past its limit (see internal/longrun); "" when there is none. Shown
+	// as a ⏱ badge.
+	LongRunning string
+
+	// Interrupted describes how a Main/Agent node's turn was interrupted
+	// ("interrupted by user at 15:04") until it resumes; "" otherwise.
+	// Shown as a ⛔ badge.
+	Interrupted string
+
+	// Switched describes a Main/Agent node's latest model or thinking
+	// settings change (see internal/modelswitch); "" when there was none.
+	// Shown as a ⇄ badge.
+	Switched string
+
+	// Forked describes where a Session node forked from another session
+	// ("forked from session 1a2b3c4d at 15:04", see internal/fork); ""
+	// when it didn't. Shown as a ⑂ badge.
+	Forked string
+
+	// Todos is a Todos node's list, shown as "Todos 3/7".
+	Todos watcher.Todos
+
+	// FlashUntil marks a session discovered while running; it shows a
+	// blinking "new" badge until then.
+	FlashUntil time.Time
+
+	// SnoozedUntil is when a snoozed (M) Session/Main/Agent node is
+	// re-enabled; zero when it isn't snoozed. Shown as a 🔕 badge.
+	SnoozedUntil time.Time
+
+	// ReadError says why a Main/Agent node's transcript can't be read
+	// ("permission denied: /path"); "" when it can. Shown in red after
+	// the name.
+	ReadError string
+
+	// Report is an Agent node's final report: the result of the Task/Agent
+	// call that ran it; "" until the subagent finishes. Shown as a 📨
+	// badge; enter reads it.
+	Report string
+
+	// Asking marks an agent that appeared under new_agents = "ask": it
+	// stays disabled, with a blinking badge, until + or space shows it.
+	Asking bool
 }
 
 // TreeView manages the tree of sessions and agents
@@ -61,6 +128,18 @@ type TreeView struct {
 	cursor int
 	width  int
 	height int
+	filter string // "/" query; "" shows everything
+
+	// pendingTodos holds lists for agents not in the tree yet.
+	pendingTodos map[EnabledFilter]watcher.Todos
+	// pendingReadErrors holds read errors for agents not in the tree yet.
+	pendingReadErrors map[EnabledFilter]string
+	// pendingReports holds reports for agents not in the tree yet.
+	pendingReports map[EnabledFilter]string
+
+	// activity counts items per session for the sparklines; see
+	// sparkline.go.
+	activity map[string]*activity
 }
 
 // NewTreeView creates a new tree view with a hidden root
@@ -97,12 +176,13 @@ func (t *TreeView) AddSession(sessionID, projectPath string) *TreeNode {
 	}
 
 	session := &TreeNode{
-		Type:     NodeTypeSession,
-		ID:       sessionID,
-		Name:     displayName,
-		Enabled:  true,
-		IsActive: true,
-		Parent:   t.Root,
+		Type:        NodeTypeSession,
+		ID:          sessionID,
+		Name:        displayName,
+		ProjectPath: projectPath,
+		Enabled:     true,
+		IsActive:    true,
+		Parent:      t.Root,
 	}
 
 	// Add Main node under the session
@@ -115,16 +195,18 @@ func (t *TreeView) AddSession(sessionID, projectPath string) *TreeNode {
 		Parent:    session,
 	}
 	session.Children = append(session.Children, main)
+	t.applyPendingTodos(main)
+	t.applyPendingReadError(main)
 
 	t.Root.Children = append(t.Root.Children, session)
 	t.rebuildNodeList()
 	return session
 }
 
-// AddAgent adds a subagent under a session.
+// AddAgent adds a subagent under a session and reports whether it is new.
 // If agentType is non-empty, it is used as the display name.
 // For compound types like "feature-dev:code-reviewer", only the part after ":" is used.
-func (t *TreeView) AddAgent(sessionID, agentID, agentType string) {
+func (t *TreeView) AddAgent(sessionID, agentID, agentType string) bool {
 	// Find the session node
 	var session *TreeNode
 	for _, child := range t.Root.Children {
@@ -135,13 +217,13 @@ func (t *TreeView) AddAgent(sessionID, agentID, agentType string) {
 	}
 
 	if session == nil {
-		return // Session not found
+		return false // Session not found
 	}
 
 	// Check if agent already exists
 	for _, child := range session.Children {
 		if child.Type == NodeTypeAgent && child.ID == agentID {
-			return
+			return false
 		}
 	}
 
@@ -165,7 +247,11 @@ func (t *TreeView) AddAgent(sessionID, agentID, agentType string) {
 		Parent:    session,
 	}
 	session.Children = append(session.Children, node)
+	t.applyPendingTodos(node)
+	t.applyPendingReadError(node)
+	t.applyPendingReport(node)
 	t.rebuildNodeList()
+	return true
 }
 
 // AddBackgroundTask adds a background task under the appropriate agent/main node
@@ -214,6 +300,13 @@ func (t *TreeView) AddBackgroundTask(sessionID, parentAgentID, toolID, toolName,
 		displayName = displayName[:25] + "..."
 	}
 
+	// It may already be listed as an artifact an agent's output pointed at.
+	for _, agent := range session.Children {
+		agent.Children = slices.DeleteFunc(agent.Children, func(c *TreeNode) bool {
+			return c.Type == NodeTypeArtifact && c.OutputPath == outputPath
+		})
+	}
+
 	node := &TreeNode{
 		Type:          NodeTypeBackgroundTask,
 		ID:            toolID,
@@ -230,6 +323,114 @@ func (t *TreeView) AddBackgroundTask(sessionID, parentAgentID, toolID, toolName,
 	t.rebuildNodeList()
 }
 
+// SetTodos shows an agent's todo list as a "Todos" node under its Main or
+// Agent node, or removes the node when the list is empty. Lists for agents
+// not in the tree yet are kept until they're added.
+func (t *TreeView) SetTodos(sessionID, agentID string, todos watcher.Todos) {
+	parent := t.findAgentNode(sessionID, agentID)
+	if parent == nil {
+		if t.pendingTodos == nil {
+			t.pendingTodos = make(map[EnabledFilter]watcher.Todos)
+		}
+		t.pendingTodos[EnabledFilter{sessionID, agentID}] = todos
+		return
+	}
+	t.setTodos(parent, todos)
+}
+
+func (t *TreeView) applyPendingTodos(parent *TreeNode) {
+	key := EnabledFilter{parent.SessionID, parent.ID}
+	if todos, ok := t.pendingTodos[key]; ok {
+		delete(t.pendingTodos, key)
+		t.setTodos(parent, todos)
+	}
+}
+
+func (t *TreeView) setTodos(parent *TreeNode, todos watcher.Todos) {
+	i := slices.IndexFunc(parent.Children, func(c *TreeNode) bool { return c.Type == NodeTypeTodos })
+	if len(todos) == 0 {
+		if i >= 0 {
+			parent.Children = slices.Delete(parent.Children, i, i+1)
+			t.rebuildNodeList()
+		}
+		return
+	}
+	if i < 0 {
+		// First child, above any background tasks.
+		node := &TreeNode{
+			Type:          NodeTypeTodos,
+			SessionID:     parent.SessionID,
+			Enabled:       true,
+			Parent:        parent,
+			ParentAgentID: parent.ID,
+		}
+		parent.Children = slices.Insert(parent.Children, 0, node)
+		i = 0
+		defer t.rebuildNodeList()
+	}
+	node := parent.Children[i]
+	node.Todos = todos
+	node.Name = fmt.Sprintf("Todos %d/%d", todos.Done(), len(todos))
+	node.IsActive = todos.Current() != ""
+}
+
+// AddArtifact adds a file an agent's tools referenced (a saved large
+// output, a shell snapshot) under its Main or Agent node, unless the agent
+// already lists it, as an artifact or a background task.
+func (t *TreeView) AddArtifact(sessionID, agentID, path string) {
+	parent := t.findAgentNode(sessionID, agentID)
+	if parent == nil {
+		return
+	}
+	for _, agent := range parent.Parent.Children {
+		for _, child := range agent.Children {
+			if child.OutputPath == path {
+				return
+			}
+		}
+	}
+	parent.Children = append(parent.Children, &TreeNode{
+		Type:          NodeTypeArtifact,
+		ID:            path,
+		SessionID:     sessionID,
+		Name:          artifactName(path),
+		Enabled:       true,
+		Parent:        parent,
+		ParentAgentID: agentID,
+		OutputPath:    path,
+	})
+	t.rebuildNodeList()
+}
+
+// artifactName shortens an artifact path for the tree: "bash snapshot"
+// for shell-snapshots/snapshot-bash-<time>-<id>.sh, the file name
+// otherwise.
+func artifactName(path string) string {
+	name := filepath.Base(path)
+	if strings.Contains(path, "/shell-snapshots/") {
+		if shell, _, ok := strings.Cut(strings.TrimPrefix(name, "snapshot-"), "-"); ok {
+			return shell + " snapshot"
+		}
+	}
+	return name
+}
+
+// findAgentNode returns a session's Main node (agentID "") or Agent node.
+func (t *TreeView) findAgentNode(sessionID, agentID string) *TreeNode {
+	for _, session := range t.Root.Children {
+		if session.Type != NodeTypeSession || session.ID != sessionID {
+			continue
+		}
+		for _, child := range session.Children {
+			if (agentID == "" && child.Type == NodeTypeMain) ||
+				(child.Type == NodeTypeAgent && child.ID == agentID) {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
 // UpdateBackgroundTaskStatus updates a background task's completion status
 func (t *TreeView) UpdateBackgroundTaskStatus(sessionID, toolID string, isComplete bool) {
 	for _, session := range t.Root.Children {
@@ -260,7 +461,11 @@ func (t *TreeView) GetSelectedNode() *TreeNode {
 func (t *TreeView) rebuildNodeList() {
 	t.nodes = nil
 	for _, child := range t.Root.Children {
-		t.flattenNode(child, 0)
+		if t.filter != "" {
+			t.flattenMatching(child, 0)
+		} else {
+			t.flattenNode(child, 0)
+		}
 	}
 	// Ensure cursor is valid
 	if t.cursor >= len(t.nodes) {
@@ -294,6 +499,86 @@ func (t *TreeView) MoveDown() {
 	}
 }
 
+// flattenMatching is flattenNode narrowed by the filter: a matching node
+// brings its whole subtree, and ancestors are kept for matching
+// descendants, even inside collapsed sessions. It reports whether anything
+// was added.
+func (t *TreeView) flattenMatching(node *TreeNode, depth int) bool {
+	if fuzzyMatch(t.filter, nodeLabel(node)) {
+		t.flattenNode(node, depth)
+		return true
+	}
+	at := len(t.nodes)
+	t.nodes = append(t.nodes, node)
+	found := false
+	for _, child := range node.Children {
+		if t.flattenMatching(child, depth+1) {
+			found = true
+		}
+	}
+	if !found {
+		t.nodes = t.nodes[:at]
+	}
+	return found
+}
+
+// nodeLabel is the text the filter matches against.
+func nodeLabel(node *TreeNode) string {
+	if node.Type == NodeTypeSession {
+		return node.Host + " " + node.Name + " " + node.ProjectPath + " " + node.ID
+	}
+	return node.Name
+}
+
+// fuzzyMatch reports whether query's runes appear in order in s, ignoring
+// case ("cesp" matches "claude-esp").
+func fuzzyMatch(query, s string) bool {
+	rest := strings.ToLower(s)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// SetFilter narrows the tree to nodes fuzzy-matching query. Like collapsing,
+// this also hides filtered-out agents from the stream. The cursor stays on
+// the selected node if it is still shown.
+func (t *TreeView) SetFilter(query string) {
+	selected := t.GetSelectedNode()
+	t.filter = strings.TrimSpace(query)
+	t.rebuildNodeList()
+	t.cursor = 0
+	for i, node := range t.nodes {
+		if node == selected {
+			t.cursor = i
+		}
+	}
+}
+
+// Filter returns the current "/" query.
+func (t *TreeView) Filter() string {
+	return t.filter
+}
+
+// MoveBy moves the cursor delta rows, stopping at either end.
+func (t *TreeView) MoveBy(delta int) {
+	t.cursor = max(0, min(len(t.nodes)-1, t.cursor+delta))
+}
+
+// MoveTo puts the cursor on row (0-based), clamped to the tree.
+func (t *TreeView) MoveTo(row int) {
+	t.cursor = max(0, min(len(t.nodes)-1, row))
+}
+
+// PageSize is how many rows fit in the pane.
+func (t *TreeView) PageSize() int {
+	return max(1, t.height)
+}
+
 // Toggle toggles the current node's visibility.
 //
 // On a session node, space collapses/expands (hides children in the tree and
@@ -316,6 +601,8 @@ func (t *TreeView) Toggle() {
 		return
 	}
 	node.Enabled = !node.Enabled
+	node.SnoozedUntil = time.Time{}
+	node.Asking = false
 }
 
 // Solo isolates the selected node: disables all others, enables only this one.
@@ -400,7 +687,7 @@ func (t *TreeView) GetSelectedSession() string {
 	switch node.Type {
 	case NodeTypeSession:
 		return node.ID
-	case NodeTypeMain, NodeTypeAgent, NodeTypeBackgroundTask:
+	case NodeTypeMain, NodeTypeAgent, NodeTypeBackgroundTask, NodeTypeTodos, NodeTypeArtifact:
 		return node.SessionID
 	}
 	return ""
@@ -449,6 +736,23 @@ func (t *TreeView) SetPinned(sessionID string, pinned bool) {
 	}
 }
 
+// SetEnabled enables or disables a Main (agentID == "") or Agent node.
+func (t *TreeView) SetEnabled(sessionID, agentID string, enabled bool) {
+	for _, session := range t.Root.Children {
+		if session.Type != NodeTypeSession || session.ID != sessionID {
+			continue
+		}
+		for _, child := range session.Children {
+			if (agentID == "" && child.Type == NodeTypeMain) ||
+				(agentID != "" && child.Type == NodeTypeAgent && child.ID == agentID) {
+				child.Enabled = enabled
+				return
+			}
+		}
+		return
+	}
+}
+
 // nodeInSubtree returns true if needle appears anywhere in root's subtree.
 func (t *TreeView) nodeInSubtree(needle, root *TreeNode) bool {
 	if root == needle {
@@ -482,15 +786,150 @@ func (t *TreeView) SetSessionTitle(sessionID, title string) {
 	}
 }
 
-// RemoveSession removes a session and all its children from the tree
-func (t *TreeView) RemoveSession(sessionID string) {
+// SessionProject returns a session's project path ("" if unknown).
+func (t *TreeView) SessionProject(sessionID string) string {
+	for _, session := range t.Root.Children {
+		if session.Type == NodeTypeSession && session.ID == sessionID {
+			return session.ProjectPath
+		}
+	}
+	return ""
+}
+
+// flashPeriod is how long each phase of the "new" badge blink lasts.
+const flashPeriod = 500 * time.Millisecond
+
+// Flash shows a blinking "new" badge on a session until the given time.
+func (t *TreeView) Flash(sessionID string, until time.Time) {
+	for _, session := range t.Root.Children {
+		if session.Type == NodeTypeSession && session.ID == sessionID {
+			session.FlashUntil = until
+			return
+		}
+	}
+}
+
+// SetSessionNote flags whether a session has a note, shown as ✎ in the tree.
+func (t *TreeView) SetSessionNote(sessionID string, hasNote bool) {
+	for _, child := range t.Root.Children {
+		if child.Type == NodeTypeSession && child.ID == sessionID {
+			child.HasNote = hasNote
+			return
+		}
+	}
+}
+
+// SetForked marks a session as forked from another, described by note.
+func (t *TreeView) SetForked(sessionID, note string) {
+	for _, child := range t.Root.Children {
+		if child.Type == NodeTypeSession && child.ID == sessionID {
+			child.Forked = note
+			return
+		}
+	}
+}
+
+// SetSessionHost labels a session with the host it was pushed from.
+func (t *TreeView) SetSessionHost(sessionID, host string) {
+	for _, child := range t.Root.Children {
+		if child.Type == NodeTypeSession && child.ID == sessionID {
+			child.Host = host
+			return
+		}
+	}
+}
+
+// SetWarnings sets the loop warnings of a session's Main/Agent nodes from
+// a map of agent ID ("" for Main) to warning; nodes not in it are cleared.
+func (t *TreeView) SetWarnings(sessionID string, warnings map[string]string) {
+	for _, session := range t.Root.Children {
+		if session.Type != NodeTypeSession || session.ID != sessionID {
+			continue
+		}
+		for _, child := range session.Children {
+			switch child.Type {
+			case NodeTypeMain:
+				child.Warning = warnings[""]
+			case NodeTypeAgent:
+				child.Warning = warnings[child.ID]
+			}
+		}
+		return
+	}
+}
+
+// SetLongRunning sets what each Main/Agent node is waiting on past its
+// limit, keyed by session and agent; nodes not in waiting are cleared.
+func (t *TreeView) SetLongRunning(waiting map[EnabledFilter]string) {
+	for _, session := range t.Root.Children {
+		for _, child := range session.Children {
+			if child.Type == NodeTypeMain || child.Type == NodeTypeAgent {
+				child.LongRunning = waiting[EnabledFilter{session.ID, child.ID}]
+			}
+		}
+	}
+}
+
+// RemoveSession removes a session and all its children from the tree. It
+// returns the removed node and its position for RestoreSession, or nil.
+func (t *TreeView) RemoveSession(sessionID string) (*TreeNode, int) {
 	// Find and remove the session from root's children
 	for i, child := range t.Root.Children {
 		if child.Type == NodeTypeSession && child.ID == sessionID {
 			t.Root.Children = append(t.Root.Children[:i], t.Root.Children[i+1:]...)
-			break
+			t.rebuildNodeList()
+			return child, i
+		}
+	}
+	t.rebuildNodeList()
+	return nil, -1
+}
+
+// RestoreSession puts back a session removed by RemoveSession, children and
+// flags intact, and selects it.
+func (t *TreeView) RestoreSession(session *TreeNode, index int) {
+	for _, child := range t.Root.Children {
+		if child.ID == session.ID {
+			return // rediscovered meanwhile
+		}
+	}
+	index = min(max(index, 0), len(t.Root.Children))
+	t.Root.Children = slices.Insert(t.Root.Children, index, session)
+	t.rebuildNodeList()
+	if i := slices.Index(t.nodes, session); i >= 0 {
+		t.cursor = i
+	}
+}
+
+// nodeFlags is the enabled/collapsed/pinned state of one node.
+type nodeFlags struct {
+	node                               *TreeNode
+	enabled, collapsed, pinned, asking bool
+	snoozedUntil                       time.Time
+}
+
+// flags records the enabled/collapsed/pinned/snoozed state of every node,
+// so a toggle, solo, snooze or + can be undone with setFlags.
+func (t *TreeView) flags() []nodeFlags {
+	var out []nodeFlags
+	var walk func(node *TreeNode)
+	walk = func(node *TreeNode) {
+		out = append(out, nodeFlags{node, node.Enabled, node.Collapsed, node.Pinned, node.Asking, node.SnoozedUntil})
+		for _, child := range node.Children {
+			walk(child)
 		}
 	}
+	for _, session := range t.Root.Children {
+		walk(session)
+	}
+	return out
+}
+
+// setFlags restores state recorded by flags. Nodes added since keep theirs.
+func (t *TreeView) setFlags(flags []nodeFlags) {
+	for _, f := range flags {
+		f.node.Enabled, f.node.Collapsed, f.node.Pinned, f.node.Asking, f.node.SnoozedUntil = f.enabled, f.collapsed, f.pinned, f.asking, f.snoozedUntil
+	}
 	t.rebuildNodeList()
 }
 
@@ -520,6 +959,22 @@ func (t *TreeView) UpdateContext(sessionID, agentID string, tokens, window int64
 	}
 }
 
+// SetSwitched records an agent's latest model or thinking settings change.
+func (t *TreeView) SetSwitched(sessionID, agentID, change string) {
+	for _, session := range t.Root.Children {
+		if session.Type != NodeTypeSession || session.ID != sessionID {
+			continue
+		}
+		for _, child := range session.Children {
+			if (agentID == "" && child.Type == NodeTypeMain) || (agentID != "" && child.Type == NodeTypeAgent && child.ID == agentID) {
+				child.Switched = change
+				return
+			}
+		}
+		return
+	}
+}
+
 // UpdateActivity updates the active status of nodes and re-sorts them
 func (t *TreeView) UpdateActivity(sessionID, agentID string, isActive bool) {
 	// Find the session
@@ -610,6 +1065,29 @@ func (t *TreeView) GetEnabledFilters() []EnabledFilter {
 	return filters
 }
 
+// SessionColors assigns each session a color from sessionColors, in tree
+// order, once items from more than one session are enabled. With a single
+// session there is nothing to tell apart and it returns nil.
+func (t *TreeView) SessionColors() map[string]lipgloss.TerminalColor {
+	enabled := make(map[string]bool)
+	for _, f := range t.GetEnabledFilters() {
+		enabled[f.SessionID] = true
+	}
+	if len(enabled) < 2 {
+		return nil
+	}
+	colors := make(map[string]lipgloss.TerminalColor)
+	n := 0
+	for _, node := range t.Root.Children {
+		if node.Type != NodeTypeSession {
+			continue
+		}
+		colors[node.ID] = sessionColors[n%len(sessionColors)]
+		n++
+	}
+	return colors
+}
+
 // IsEnabled checks if a session+agent combo is enabled
 func (t *TreeView) IsEnabled(sessionID, agentID string) bool {
 	for _, node := range t.nodes {
@@ -635,11 +1113,17 @@ func (t *TreeView) SetSize(width, height int) {
 // View renders the tree
 func (t *TreeView) View() string {
 	if len(t.nodes) == 0 {
+		if t.filter != "" {
+			return mutedStyle.Render(fmt.Sprintf("No sessions match /%s", t.filter))
+		}
 		return mutedStyle.Render("Waiting for Claude Code sessions...")
 	}
 
 	var b strings.Builder
+	now := time.Now()
+	top := t.activityPeak(now)
 
+	colors := t.SessionColors()
 	for i, node := range t.nodes {
 		// Determine indent (sessions are depth 0, main/agents are depth 1)
 		depth := t.getDepth(node) - 1 // -1 because we skip the hidden root
@@ -666,6 +1150,9 @@ func (t *TreeView) View() string {
 			if node.Collapsed {
 				arrow = "▸"
 			}
+			if color, ok := colors[node.ID]; ok {
+				arrow = lipgloss.NewStyle().Foreground(color).Render(arrow)
+			}
 			if node.IsActive {
 				icon = "📁" + arrow + " "
 			} else {
@@ -689,6 +1176,10 @@ func (t *TreeView) View() string {
 			} else {
 				icon = "⏳ "
 			}
+		case NodeTypeTodos:
+			icon = "📋 "
+		case NodeTypeArtifact:
+			icon = "📎 "
 		}
 
 		// Build line with name (muted if inactive)
@@ -706,9 +1197,49 @@ func (t *TreeView) View() string {
 				name = fmt.Sprintf("%s (+%d)", name, agents)
 			}
 		}
+		if node.Host != "" {
+			name = mutedStyle.Render(node.Host+":") + name
+		}
+		if node.HasNote {
+			name += " ✎"
+		}
+		if node.Forked != "" {
+			name += " " + forkStyle.Render(forkIcon)
+		}
 		if !node.IsActive && node.Type != NodeTypeSession {
 			name = mutedStyle.Render(node.Name)
 		}
+		if node.Warning != "" {
+			name += " " + loopStyle.Render(loopIcon)
+		}
+		if node.LongRunning != "" {
+			name += " " + loopStyle.Render(longRunIcon)
+		}
+		if node.Interrupted != "" {
+			name += " " + failedOutputStyle.Render(interruptIcon)
+		}
+		if node.Switched != "" {
+			name += " " + switchStyle.Render(switchIcon)
+		}
+		if node.Report != "" {
+			name += " " + reportStyle.Render(reportIcon)
+		}
+		if !node.SnoozedUntil.IsZero() {
+			name += " " + mutedStyle.Render(snoozeIcon+" "+snoozeLeft(node.SnoozedUntil, now))
+		}
+		if node.ReadError != "" {
+			name += " " + failedOutputStyle.Render(readErrorIcon+" "+node.ReadError)
+		}
+		if now.Before(node.FlashUntil) || node.Asking {
+			style, badge := newSessionStyle, newSessionBadge
+			if node.Asking {
+				badge = askBadge
+			}
+			if now.UnixMilli()/flashPeriod.Milliseconds()%2 == 1 {
+				style = newSessionBlinkStyle
+			}
+			name += " " + style.Render(badge)
+		}
 
 		line := fmt.Sprintf("%s%s%s%s",
 			indent,
@@ -717,6 +1248,15 @@ func (t *TreeView) View() string {
 			name,
 		)
 
+		// Activity sparkline, right-aligned on session rows when there's
+		// room for at least a few minutes of it.
+		if node.Type == NodeTypeSession && t.width > 0 {
+			room := max(t.width-4, 1) - lipglossWidth(line) - 1
+			if spark := t.sessionSparkline(node.ID, now, room, top); spark != "" {
+				line += strings.Repeat(" ", room+1-lipglossWidth(spark)) + sparkStyle.Render(spark)
+			}
+		}
+
 		// Context-size suffix for Main/Agent nodes (e.g. "  142k/1M").
 		// Right-aligned when the line fits; appended otherwise. Truncation
 		// of over-wide lines (below) handles the worst case.
@@ -3,6 +3,9 @@ package tui
 import (
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/watcher"
 )
 
 func TestTreeView_AddSession(t *testing.T) {
@@ -440,3 +443,137 @@ func TestTreeView_ProjectNameTruncation(t *testing.T) {
 		t.Errorf("session name length = %d, want <= 15", len(session.Name))
 	}
 }
+
+func TestTreeView_Filter(t *testing.T) {
+	tv := NewTreeView()
+	tv.AddSession("aaa111", "home/user/claude-esp")
+	tv.AddSession("bbb222", "home/user/webapp")
+	tv.AddAgent("bbb222", "agent1", "code-reviewer")
+	tv.AddSession("ccc333", "home/user/infra")
+
+	names := func() []string {
+		var out []string
+		for _, n := range tv.nodes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	tv.SetFilter("cesp")
+	if got := names(); strings.Join(got, ",") != "claude-esp,Main" {
+		t.Errorf("cesp: %v, want the claude-esp session with its children", got)
+	}
+
+	// An agent match keeps its session but not its siblings.
+	tv.SetFilter("REVIEW")
+	if got := names(); strings.Join(got, ",") != "webapp,code-reviewer" {
+		t.Errorf("REVIEW: %v", got)
+	}
+	filters := tv.GetEnabledFilters()
+	if len(filters) != 1 || filters[0].AgentID != "agent1" {
+		t.Errorf("stream filters follow the narrowed tree, got %+v", filters)
+	}
+
+	tv.SetFilter("ccc3") // session ID
+	if got := names(); len(got) != 2 || got[0] != "infra" {
+		t.Errorf("ccc3: %v", got)
+	}
+
+	tv.SetFilter("zzz")
+	if len(tv.nodes) != 0 || !strings.Contains(tv.View(), "No sessions match /zzz") {
+		t.Errorf("zzz: %v", names())
+	}
+	tv.SetFilter("")
+	if len(tv.nodes) != 7 {
+		t.Errorf("clearing the filter: %d nodes, want 7", len(tv.nodes))
+	}
+}
+
+func TestTreeView_FlashNewSession(t *testing.T) {
+	tv := NewTreeView()
+	tv.AddSession("s1", "/work/api")
+	tv.SetSize(40, 10)
+	if strings.Contains(tv.View(), newSessionBadge) {
+		t.Fatal("badge shown before Flash")
+	}
+	tv.Flash("s1", time.Now().Add(time.Minute))
+	if !strings.Contains(tv.View(), newSessionBadge) {
+		t.Error("flashing session has no badge")
+	}
+	tv.Flash("s1", time.Now().Add(-time.Second))
+	if strings.Contains(tv.View(), newSessionBadge) {
+		t.Error("badge outlived the flash")
+	}
+}
+
+func TestTreeView_SetTodos(t *testing.T) {
+	tv := NewTreeView()
+	tv.AddSession("s1", "/work/api")
+	todos := watcher.Todos{
+		{Content: "Write tests", Status: "completed"},
+		{Content: "Fix bug", Status: "in_progress", ActiveForm: "Fixing bug"},
+		{Content: "Ship", Status: "pending"},
+	}
+	tv.SetTodos("s1", "", todos)
+	tv.SetTodos("s1", "a1", todos[:1]) // agent not added yet
+
+	main := tv.Root.Children[0].Children[0]
+	if len(main.Children) != 1 || main.Children[0].Name != "Todos 1/3" || !main.Children[0].IsActive {
+		t.Fatalf("Main children = %+v", main.Children)
+	}
+	tv.AddAgent("s1", "a1", "")
+	agent := tv.Root.Children[0].Children[1]
+	if len(agent.Children) != 1 || agent.Children[0].Name != "Todos 1/1" || agent.Children[0].IsActive {
+		t.Errorf("pending todos not applied to the new agent: %+v", agent.Children)
+	}
+
+	tv.SetTodos("s1", "", nil)
+	if len(main.Children) != 0 {
+		t.Error("cleared list left its node")
+	}
+}
+
+func TestTreeView_AddArtifact(t *testing.T) {
+	tv := NewTreeView()
+	tv.AddSession("s1", "/work/api")
+	snapshot := "/home/u/.claude/shell-snapshots/snapshot-zsh-1700000000-abc.sh"
+	saved := "/home/u/.claude/projects/-work-api/s1/tool-results/toolu_01.txt"
+	tv.AddArtifact("s1", "", snapshot)
+	tv.AddArtifact("s1", "", snapshot)
+	tv.AddArtifact("s1", "", saved)
+
+	main := tv.Root.Children[0].Children[0]
+	if len(main.Children) != 2 || main.Children[0].Name != "zsh snapshot" || main.Children[1].Name != "toolu_01.txt" {
+		t.Fatalf("Main children = %+v", main.Children)
+	}
+
+	// Discovered as a background task too: listed once.
+	tv.AddBackgroundTask("s1", "", "toolu_01", "Bash: make", saved, true)
+	if len(main.Children) != 2 || main.Children[1].Type != NodeTypeBackgroundTask {
+		t.Errorf("Main children = %+v", main.Children)
+	}
+	tv.AddArtifact("s1", "", saved)
+	if len(main.Children) != 2 {
+		t.Error("artifact re-added next to its background task")
+	}
+}
+
+func TestTreeView_SessionColors(t *testing.T) {
+	tv := NewTreeView()
+	tv.AddSession("sess1", "project-a")
+	if colors := tv.SessionColors(); colors != nil {
+		t.Errorf("one session: colors = %v, want nil", colors)
+	}
+
+	tv.AddSession("sess2", "project-b")
+	colors := tv.SessionColors()
+	if colors["sess1"] != sessionColors[0] || colors["sess2"] != sessionColors[1] {
+		t.Errorf("colors = %v, want sessions in tree order", colors)
+	}
+
+	tv.cursor = 0
+	tv.Solo()
+	if colors := tv.SessionColors(); colors != nil {
+		t.Errorf("one session enabled: colors = %v, want nil", colors)
+	}
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// maxUndo bounds the undo stack.
+const maxUndo = 50
+
+// undoEntry reverts one tree action: a session removal (d), a toggle
+// (space/enter) or a solo (s).
+type undoEntry struct {
+	what   string
+	revert func(m *Model)
+}
+
+func (m *Model) pushUndo(what string, revert func(m *Model)) {
+	if len(m.undoStack) == maxUndo {
+		m.undoStack = m.undoStack[1:]
+	}
+	m.undoStack = append(m.undoStack, undoEntry{what, revert})
+}
+
+// undo reverts the most recent tree action.
+func (m *Model) undo() {
+	if len(m.undoStack) == 0 {
+		m.status = "nothing to undo"
+		return
+	}
+	e := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	e.revert(m)
+	m.syncFilters()
+	m.status = "undid " + e.what
+}
+
+// withUndo runs a toggle or solo so that u puts every node's enabled,
+// collapsed and pinned state back as it was.
+func (m *Model) withUndo(what string, action func()) {
+	before := m.tree.flags()
+	action()
+	m.pushUndo(what, func(m *Model) { m.tree.setFlags(before) })
+	m.syncFilters()
+}
+
+// removeSelectedSession stops watching the selected session and drops it
+// from the tree; u brings it back. With forever, it also stays out of
+// auto-discovery in later runs (the saved view remembers it).
+func (m *Model) removeSelectedSession(forever bool) {
+	sessionID := m.tree.GetSelectedSession()
+	if sessionID == "" {
+		return
+	}
+	node, index := m.tree.RemoveSession(sessionID)
+	if node == nil {
+		return
+	}
+	// Keep the watcher's session, with its files, to restore from.
+	var session *watcher.Session
+	if m.watcher != nil {
+		session = m.watcher.GetSessions()[sessionID]
+		m.watcher.RemoveSession(sessionID)
+	}
+	if forever {
+		m.ignored = append(m.ignored, sessionID)
+	}
+	m.pushUndo("removing "+node.Name, func(m *Model) {
+		if forever {
+			m.ignored = slices.DeleteFunc(m.ignored, func(id string) bool { return id == sessionID })
+		}
+		if m.watcher != nil && session != nil {
+			m.watcher.RestoreSession(session)
+		}
+		m.tree.RestoreSession(node, index)
+	})
+	m.syncFilters()
+	if forever {
+		m.status = fmt.Sprintf("hid %s for good (:unignore to undo later) │ u: undo", node.Name)
+	} else {
+		m.status = fmt.Sprintf("removed %s │ u: undo", node.Name)
+	}
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func treeModel(t *testing.T) *Model {
+	t.Helper()
+	t.Setenv("CLAUDE_ESP_HOME", t.TempDir())
+	m := NewModel(nil, false, 500*time.Millisecond, 5*time.Minute, 0, 0, nil)
+	m.Update(newSessionMsg{SessionID: "s1", ProjectPath: "/work/api"})
+	m.Update(newSessionMsg{SessionID: "s2", ProjectPath: "/work/web"})
+	m.Update(newAgentMsg{SessionID: "s1", AgentID: "a1"})
+	m.focus = FocusTree
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestUndoRemoveSession(t *testing.T) {
+	m := treeModel(t)
+	m.Update(key("x"))
+	if len(m.tree.Root.Children) != 1 || m.tree.Root.Children[0].ID != "s2" {
+		t.Fatalf("x did not remove s1: %d sessions left", len(m.tree.Root.Children))
+	}
+	if !m.stream.IsTextEnabled() {
+		t.Error("x in the tree also toggled text")
+	}
+
+	m.Update(key("u"))
+	if len(m.tree.Root.Children) != 2 || m.tree.GetSelectedSession() != "s1" {
+		t.Fatalf("undo did not bring s1 back selected (selected %q)", m.tree.GetSelectedSession())
+	}
+	if !m.tree.IsEnabled("s1", "a1") {
+		t.Error("restored session lost its agent")
+	}
+	m.Update(key("u"))
+	if m.status != "nothing to undo" {
+		t.Errorf("status = %q", m.status)
+	}
+}
+
+func TestUndoSolo(t *testing.T) {
+	m := treeModel(t)
+	m.tree.MoveTo(1) // s1's Main
+	m.Update(key("s"))
+	if m.tree.IsEnabled("s1", "a1") || m.tree.IsEnabled("s2", "") {
+		t.Fatal("setup: solo left other agents enabled")
+	}
+	m.Update(key("u"))
+	for _, f := range []EnabledFilter{{"s1", ""}, {"s1", "a1"}, {"s2", ""}} {
+		if !m.tree.IsEnabled(f.SessionID, f.AgentID) {
+			t.Errorf("%v still disabled after undo", f)
+		}
+	}
+	if len(m.stream.enabledFilters) != 3 {
+		t.Errorf("stream filters not resynced: %v", m.stream.enabledFilters)
+	}
+}
+
+func TestHideSessionForGood(t *testing.T) {
+	m := treeModel(t)
+	m.Update(key("D"))
+	if got := m.viewState().Ignored; len(got) != 1 || got[0] != "s1" {
+		t.Fatalf("Ignored = %v, want [s1]", got)
+	}
+	m.Update(key("u"))
+	if len(m.ignored) != 0 || len(m.tree.Root.Children) != 2 {
+		t.Errorf("undo left ignored = %v, %d sessions", m.ignored, len(m.tree.Root.Children))
+	}
+
+	m.Update(key("D"))
+	m.runPalette("unignore s")
+	if len(m.ignored) != 0 || m.status == "" {
+		t.Errorf("unignore: ignored = %v, status %q", m.ignored, m.status)
+	}
+	m.runPalette("unignore s")
+	if m.status != `unignore: no hidden session matches "s"` {
+		t.Errorf("status = %q", m.status)
+	}
+}
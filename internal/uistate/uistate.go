@@ -0,0 +1,154 @@
+// Package uistate remembers the TUI's view settings — stream toggles,
+// layout, focus and which tree nodes are disabled or collapsed — between
+// runs. State is kept per watched set, so "claude-esp" and
+// "claude-esp -s <id>" each come back the way they were left.
+package uistate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MainAgent stands for a session's main conversation in Disabled lists.
+const MainAgent = "main"
+
+// State is one saved view.
+type State struct {
+	Watch []string `json:"watch,omitempty"` // the watched set, for humans reading the file
+
+	// Stream toggles (t, i, o, O, *, x, H, a). Hooks are saved hidden rather
+	// than shown, so states from before the toggle keep showing them.
+	Thinking   bool `json:"thinking"`
+	ToolInput  bool `json:"tool_input"`
+	ToolOutput bool `json:"tool_output"`
+	StderrOnly bool `json:"stderr_only,omitempty"`
+	Highlights bool `json:"highlights,omitempty"`
+	Text       bool `json:"text"`
+	HideHooks  bool `json:"hide_hooks,omitempty"`
+	AutoScroll bool `json:"auto_scroll"`
+
+	// Layout
+	ShowTree  bool   `json:"show_tree"`
+	TreeWidth int    `json:"tree_width,omitempty"`
+	View      string `json:"view,omitempty"`  // "", "timeline", "stats", "recap", "long_running", "tests" or "files"
+	Focus     string `json:"focus,omitempty"` // "tree" or "stream"
+	Filter    string `json:"filter,omitempty"`
+
+	// Tree selections. Disabled maps a session ID to its disabled agents
+	// (MainAgent for the main conversation); Collapsed lists collapsed
+	// sessions.
+	Disabled  map[string][]string `json:"disabled,omitempty"`
+	Collapsed []string            `json:"collapsed,omitempty"`
+
+	// Ignored lists sessions hidden for good; auto-discovery skips them.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// Disable records agents of a session as disabled; "" is the main
+// conversation.
+func (s *State) Disable(sessionID string, agentIDs ...string) {
+	if s.Disabled == nil {
+		s.Disabled = make(map[string][]string)
+	}
+	for _, id := range agentIDs {
+		if id == "" {
+			id = MainAgent
+		}
+		if !slices.Contains(s.Disabled[sessionID], id) {
+			s.Disabled[sessionID] = append(s.Disabled[sessionID], id)
+		}
+	}
+}
+
+// IsDisabled reports whether the agent was disabled.
+func (s *State) IsDisabled(sessionID, agentID string) bool {
+	if agentID == "" {
+		agentID = MainAgent
+	}
+	return slices.Contains(s.Disabled[sessionID], agentID)
+}
+
+// IsCollapsed reports whether the session was collapsed.
+func (s *State) IsCollapsed(sessionID string) bool {
+	return slices.Contains(s.Collapsed, sessionID)
+}
+
+// Key is a stable name for a watched set: the same session IDs in any
+// order give the same key. An empty set means auto-discovery.
+func Key(sessionIDs []string) string {
+	ids := slices.Clone(sessionIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		ids = []string{"*"}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Store reads and writes one state file. A nil *Store is valid and
+// remembers nothing.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	last []byte // contents last read or written, to skip no-op saves
+}
+
+// New returns the store for a watched set under dir. The directory is
+// created on first write.
+func New(dir string, sessionIDs []string) *Store {
+	return &Store{path: filepath.Join(dir, Key(sessionIDs)+".json")}
+}
+
+// Load reads the saved state. ok is false when nothing was saved yet or
+// the file is unreadable.
+func (s *Store) Load() (st State, ok bool) {
+	if s == nil {
+		return State{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.path)
+	if err != nil || json.Unmarshal(data, &st) != nil {
+		return State{}, false
+	}
+	s.last = data
+	return st, true
+}
+
+// Save writes st atomically, unless it is unchanged since the last Load or
+// Save.
+func (s *Store) Save(st State) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(data, s.last) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.last = data
+	return nil
+}
@@ -0,0 +1,85 @@
+package uistate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeyStable(t *testing.T) {
+	if Key([]string{"b", "a"}) != Key([]string{"a", "b", "a"}) {
+		t.Error("key depends on order or duplicates")
+	}
+	if Key(nil) == Key([]string{"a"}) {
+		t.Error("auto-discovery and a single session share a key")
+	}
+	if got := len(Key(nil)); got != 16 {
+		t.Errorf("key length = %d", got)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	s := New(dir, []string{"s1"})
+	if _, ok := s.Load(); ok {
+		t.Fatal("Load before any Save reported state")
+	}
+
+	want := State{
+		Watch:     []string{"s1"},
+		Thinking:  true,
+		ShowTree:  true,
+		TreeWidth: 30,
+		View:      "stats",
+		Focus:     "tree",
+		Disabled:  map[string][]string{"s1": {MainAgent, "a1"}},
+		Collapsed: []string{"s2"},
+	}
+	if err := s.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := New(dir, []string{"s1"}).Load()
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load = %+v, %v; want %+v", got, ok, want)
+	}
+	if !got.IsDisabled("s1", "") || !got.IsDisabled("s1", "a1") || got.IsDisabled("s1", "a2") {
+		t.Error("IsDisabled mismatch")
+	}
+	if !got.IsCollapsed("s2") || got.IsCollapsed("s1") {
+		t.Error("IsCollapsed mismatch")
+	}
+
+	// Other watched sets don't see it.
+	if _, ok := New(dir, nil).Load(); ok {
+		t.Error("state leaked to another watched set")
+	}
+}
+
+func TestSaveSkipsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir, nil)
+	if err := s.Save(State{ShowTree: true}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, Key(nil)+".json")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(State{ShowTree: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("unchanged state was rewritten")
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	if _, ok := s.Load(); ok {
+		t.Error("nil store loaded state")
+	}
+	if err := s.Save(State{}); err != nil {
+		t.Error(err)
+	}
+}
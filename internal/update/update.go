@@ -0,0 +1,281 @@
+// Package update checks GitHub releases for a newer claude-esp and
+// replaces the running binary with it. Release binaries are verified
+// against the release's checksums.txt (sha256sum format) before they are
+// swapped in.
+package update
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository releases come from.
+const Repo = "phiat/claude-esp"
+
+// ChecksumsAsset is the release asset listing every binary's SHA-256.
+const ChecksumsAsset = "checksums.txt"
+
+// CheckInterval is how often the background check asks GitHub.
+const CheckInterval = 24 * time.Hour
+
+// stateFile remembers the last background check in the state directory.
+const stateFile = "update-check.json"
+
+// Release is a GitHub release.
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release's version without the leading "v".
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+func (r Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Client talks to the GitHub API.
+type Client struct {
+	// API is the GitHub API base URL.
+	API  string
+	HTTP *http.Client
+}
+
+// NewClient returns a client for api.github.com.
+func NewClient() *Client {
+	return &Client{API: "https://api.github.com", HTTP: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Latest returns the newest published release.
+func (c *Client) Latest(ctx context.Context) (Release, error) {
+	var rel Release
+	body, err := c.get(ctx, c.API+"/repos/"+Repo+"/releases/latest")
+	if err != nil {
+		return rel, err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&rel); err != nil {
+		return rel, fmt.Errorf("bad release response: %w", err)
+	}
+	if rel.Tag == "" {
+		return rel, errors.New("bad release response: no tag")
+	}
+	return rel, nil
+}
+
+func (c *Client) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// AssetName is the release binary for an OS and architecture, as built by
+// the release workflow.
+func AssetName(goos, goarch string) string {
+	name := "claude-esp-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Newer reports whether version latest is newer than current. Both are
+// dotted numbers with an optional leading "v"; a suffix such as "-rc1"
+// is ignored. A current version that doesn't parse (a dev build) is
+// never considered outdated.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range max(len(l), len(c)) {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	if v == "" {
+		return nil, false
+	}
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// Install downloads rel's binary for this platform, checks it against the
+// release's checksums and replaces the executable at exe with it. The
+// new binary is written next to exe first, so a failed download never
+// leaves exe broken.
+func (c *Client) Install(ctx context.Context, rel Release, exe string) error {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	bin, ok := rel.asset(name)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s", rel.Tag, runtime.GOOS, runtime.GOARCH)
+	}
+	sums, ok := rel.asset(ChecksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s to verify against", rel.Tag, ChecksumsAsset)
+	}
+	want, err := c.checksum(ctx, sums.URL, name)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.get(ctx, bin.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".claude-esp-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("download %s: %w", name, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return replace(exe, tmp.Name())
+}
+
+// checksum finds name's SHA-256 in a sha256sum-style checksums file.
+func (c *Client) checksum(ctx context.Context, url, name string) (string, error) {
+	body, err := c.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	sc := bufio.NewScanner(body)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", ChecksumsAsset, name)
+}
+
+// replace moves newPath over exe. A running executable can't be
+// overwritten on Windows but can be renamed, so the old one is moved
+// aside first and removed when possible.
+func replace(exe, newPath string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(newPath, exe)
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	os.Remove(old) // fails while the old binary is running; cleaned next time
+	return nil
+}
+
+// checkState is what the background check remembers between runs.
+type checkState struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+}
+
+// CheckDaily returns the newest release's version when it is newer than
+// current. GitHub is asked at most once per CheckInterval; in between,
+// the answer saved in stateDir is reused.
+func (c *Client) CheckDaily(ctx context.Context, stateDir, current string) (string, error) {
+	path := filepath.Join(stateDir, stateFile)
+	var st checkState
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &st)
+	}
+	if time.Since(st.Checked) >= CheckInterval {
+		rel, err := c.Latest(ctx)
+		if err != nil {
+			return "", err
+		}
+		st = checkState{Checked: time.Now(), Latest: rel.Version()}
+		if data, err := json.Marshal(st); err == nil {
+			os.MkdirAll(stateDir, 0o755)
+			os.WriteFile(path, data, 0o644)
+		}
+	}
+	if Newer(st.Latest, current) {
+		return st.Latest, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,127 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	for _, tt := range []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v0.8.0", "0.7.2", true},
+		{"0.7.10", "0.7.9", true},
+		{"v0.7.2", "0.7.2", false},
+		{"0.7", "0.7.1", false},
+		{"1.0.0-rc1", "0.9.9", true},
+		{"v0.6.0", "0.7.2", false},
+		{"v0.8.0", "dev", false},
+		{"garbage", "0.7.2", false},
+	} {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+// fakeGitHub serves a release whose binary is bin and whose checksums.txt
+// lists sum for it. It counts API calls.
+func fakeGitHub(t *testing.T, bin []byte, sum string) (*Client, *int) {
+	t.Helper()
+	calls := 0
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/repos/"+Repo+"/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(Release{Tag: "v9.0.0", Assets: []Asset{
+			{Name: name, URL: srv.URL + "/dl/bin"},
+			{Name: ChecksumsAsset, URL: srv.URL + "/dl/sums"},
+		}})
+	})
+	mux.HandleFunc("/dl/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(bin) })
+	mux.HandleFunc("/dl/sums", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0000  claude-esp-other-arch\n" + sum + "  " + name + "\n"))
+	})
+	return &Client{API: srv.URL, HTTP: srv.Client()}, &calls
+}
+
+func TestInstall(t *testing.T) {
+	bin := []byte("new binary")
+	h := sha256.Sum256(bin)
+	c, _ := fakeGitHub(t, bin, hex.EncodeToString(h[:]))
+	exe := filepath.Join(t.TempDir(), "claude-esp")
+	os.WriteFile(exe, []byte("old binary"), 0o755)
+
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version() != "9.0.0" {
+		t.Errorf("Version() = %q", rel.Version())
+	}
+	if err := c.Install(context.Background(), rel, exe); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new binary" {
+		t.Errorf("exe = %q after install", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("left %d files behind", len(entries)-1)
+	}
+}
+
+func TestInstallChecksumMismatch(t *testing.T) {
+	c, _ := fakeGitHub(t, []byte("tampered"), strings.Repeat("ab", 32))
+	exe := filepath.Join(t.TempDir(), "claude-esp")
+	os.WriteFile(exe, []byte("old binary"), 0o755)
+
+	rel, _ := c.Latest(context.Background())
+	err := c.Install(context.Background(), rel, exe)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("err = %v, want checksum mismatch", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+		t.Errorf("exe = %q after a failed install", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(exe)); len(entries) != 1 {
+		t.Errorf("left %d files behind", len(entries)-1)
+	}
+}
+
+func TestCheckDaily(t *testing.T) {
+	c, calls := fakeGitHub(t, nil, "")
+	dir := t.TempDir()
+
+	for range 2 {
+		latest, err := c.CheckDaily(context.Background(), dir, "0.7.2")
+		if err != nil || latest != "9.0.0" {
+			t.Fatalf("CheckDaily = %q, %v", latest, err)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("asked GitHub %d times within a day", *calls)
+	}
+	if latest, _ := c.CheckDaily(context.Background(), dir, "9.0.0"); latest != "" {
+		t.Errorf("up to date, but CheckDaily = %q", latest)
+	}
+
+	stale, _ := json.Marshal(checkState{Checked: time.Now().Add(-CheckInterval), Latest: "9.0.0"})
+	os.WriteFile(filepath.Join(dir, stateFile), stale, 0o644)
+	c.CheckDaily(context.Background(), dir, "0.7.2")
+	if *calls != 2 {
+		t.Errorf("stale check not refreshed (%d calls)", *calls)
+	}
+}
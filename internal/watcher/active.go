@@ -0,0 +1,199 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// Strategies for telling which sessions are active, the [watch] active
+// setting.
+const (
+	// ActiveMtime counts a transcript as active when it was written
+	// recently: within the active window to be discovered, within the
+	// activity threshold to show as active in the tree.
+	ActiveMtime = "mtime"
+	// ActiveWork counts a transcript as active when its last records show
+	// work in progress - a tool call waiting on its result, a prompt or
+	// result the model hasn't answered yet, a request being retried - and
+	// it was written within the active window. A session whose turn
+	// ended, or that was only compacted, isn't active however recently it
+	// was written; one waiting on a long tool call stays active.
+	ActiveWork = "work"
+)
+
+// ActiveStrategies are the values Active may take.
+var ActiveStrategies = []string{ActiveMtime, ActiveWork}
+
+// Active is the strategy used for discovery, the tree's activity and
+// session listings. Set it once at startup, like PathMap.
+var Active = ActiveMtime
+
+// tailBytes is how much of the end of a transcript ActiveWork reads.
+const tailBytes = 64 * 1024
+
+// isActive reports whether the transcript at path counts as active under
+// the Active strategy: written within recent, or for ActiveWork, within
+// window and with work in progress.
+func isActive(path string, info fs.FileInfo, recent, window time.Duration, now time.Time) bool {
+	age := now.Sub(info.ModTime())
+	if Active != ActiveWork {
+		return age <= recent
+	}
+	return age <= window && works.inProgress(path, info)
+}
+
+// workCache remembers what each transcript's tail said, until it changes.
+type workCache struct {
+	mu    sync.Mutex
+	files map[string]workState
+}
+
+type workState struct {
+	mod  time.Time
+	size int64
+	busy bool
+}
+
+var works = workCache{files: make(map[string]workState)}
+
+// inProgress reports whether the transcript's last records show work in
+// progress, reading its tail only if it changed since last asked.
+func (c *workCache) inProgress(path string, info fs.FileInfo) bool {
+	c.mu.Lock()
+	st, ok := c.files[path]
+	c.mu.Unlock()
+	if ok && st.mod.Equal(info.ModTime()) && st.size == info.Size() {
+		return st.busy
+	}
+	st = workState{mod: info.ModTime(), size: info.Size(), busy: tailInProgress(path)}
+	c.mu.Lock()
+	c.files[path] = st
+	c.mu.Unlock()
+	return st.busy
+}
+
+// tailInProgress reads the end of a transcript and judges it by the last
+// record that says whether a turn is under way. With none to go by, or an
+// unreadable file, it counts as in progress and recent writes decide.
+func tailInProgress(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	start := max(0, info.Size()-tailBytes)
+	tail := make([]byte, info.Size()-start)
+	if _, err := f.ReadAt(tail, start); err != nil && err != io.EOF {
+		return true
+	}
+	lines := bytes.Split(tail, []byte("\n"))
+	if start > 0 {
+		lines = lines[1:] // cut mid-line
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if busy, ok := recordInProgress(lines[i]); ok {
+			return busy
+		}
+	}
+	return true
+}
+
+// tailRecord is as much of a transcript line as recordInProgress needs.
+type tailRecord struct {
+	Type              string `json:"type"`
+	Subtype           string `json:"subtype"`
+	IsMeta            bool   `json:"isMeta"`
+	IsCompactSummary  bool   `json:"isCompactSummary"`
+	IsAPIErrorMessage bool   `json:"isApiErrorMessage"`
+	Message           struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// recordInProgress judges one transcript line: busy if a turn is under
+// way after it, and ok false for lines that don't tell (snapshots,
+// titles, hook output, unreadable lines).
+func recordInProgress(line []byte) (busy, ok bool) {
+	var r tailRecord
+	if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &r) != nil {
+		return false, false
+	}
+	switch r.Type {
+	case "progress":
+		return true, true
+	case "system":
+		switch r.Subtype {
+		case "api_error":
+			return true, true // retrying
+		case "turn_duration", "compact_boundary", "local_command":
+			return false, true
+		}
+	case "assistant":
+		if r.IsAPIErrorMessage {
+			return false, true // gave up
+		}
+		var blocks []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(r.Message.Content, &blocks) != nil || len(blocks) == 0 {
+			return false, false
+		}
+		// A tool call waits on its result, thinking on what follows it;
+		// text ends the turn.
+		return blocks[len(blocks)-1].Type != "text", true
+	case "user":
+		if r.IsCompactSummary {
+			return false, true
+		}
+		if r.IsMeta {
+			return false, false
+		}
+		return userInProgress(r.Message.Content)
+	}
+	return false, false
+}
+
+// userInProgress judges a user message: tool results and prompts wait on
+// the model; interrupts and commands run locally don't.
+func userInProgress(content json.RawMessage) (busy, ok bool) {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return !parser.IsInterrupt(text) && !isLocalCommand(text), true
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(content, &blocks) != nil || len(blocks) == 0 {
+		return false, false
+	}
+	for _, b := range blocks {
+		if b.Type == "text" && parser.IsInterrupt(b.Text) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// isLocalCommand reports whether a user message records a slash or !
+// command, or its output, rather than a prompt.
+func isLocalCommand(text string) bool {
+	for _, tag := range []string{"<command-name>", "<local-command-stdout>", "<bash-input>", "<bash-stdout>"} {
+		if strings.Contains(text, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActiveWork(t *testing.T) {
+	const (
+		prompt   = `{"type":"user","message":{"role":"user","content":"fix the build"}}`
+		toolUse  = `{"type":"assistant","message":{"content":[{"type":"text","text":"Running it."},{"type":"tool_use","id":"t1","name":"Bash"}]}}`
+		result   = `{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1"}]}}`
+		reply    = `{"type":"assistant","message":{"content":[{"type":"text","text":"Fixed."}]}}`
+		ended    = `{"type":"system","subtype":"turn_duration","durationMs":5000}`
+		boundary = `{"type":"system","subtype":"compact_boundary"}`
+		summary  = `{"type":"user","isCompactSummary":true,"message":{"content":"This session is being continued..."}}`
+		snapshot = `{"type":"file-history-snapshot","snapshot":{}}`
+		meta     = `{"type":"user","isMeta":true,"message":{"content":"<local-command-caveat>"}}`
+		command  = `{"type":"user","message":{"content":"<command-name>/clear</command-name>"}}`
+		stopped  = `{"type":"user","message":{"content":[{"type":"text","text":"[Request interrupted by user]"}]}}`
+		retrying = `{"type":"system","subtype":"api_error","retryAttempt":1}`
+	)
+	dir := t.TempDir()
+	now := time.Now()
+	for name, tc := range map[string]struct {
+		lines []string
+		busy  bool
+	}{
+		"prompt awaiting a reply": {[]string{prompt}, true},
+		"pending tool call":       {[]string{prompt, toolUse}, true},
+		"result awaiting a reply": {[]string{prompt, toolUse, result, snapshot}, true},
+		"turn ended":              {[]string{prompt, toolUse, result, reply, ended}, false},
+		"reply without duration":  {[]string{prompt, reply, snapshot}, false},
+		"compacted while idle":    {[]string{reply, ended, command, boundary, summary, meta}, false},
+		"interrupted":             {[]string{prompt, toolUse, stopped}, false},
+		"retrying a request":      {[]string{prompt, retrying}, true},
+		"nothing to go by":        {[]string{snapshot}, true},
+	} {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".jsonl")
+		os.WriteFile(path, []byte(strings.Join(tc.lines, "\n")+"\n"), 0644)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tailInProgress(path); got != tc.busy {
+			t.Errorf("%s: in progress = %v, want %v", name, got, tc.busy)
+		}
+
+		Active = ActiveWork
+		if got := isActive(path, info, time.Second, time.Hour, now); got != tc.busy {
+			t.Errorf("%s: work active = %v, want %v", name, got, tc.busy)
+		}
+		if isActive(path, info, time.Second, time.Hour, now.Add(2*time.Hour)) {
+			t.Errorf("%s: active outside the window", name)
+		}
+		Active = ActiveMtime
+		if !isActive(path, info, time.Minute, time.Minute, now) {
+			t.Errorf("%s: mtime strategy ignored a fresh write", name)
+		}
+	}
+}
+
+func TestActiveWorkReadsOnlyTheTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	// A long idle history, then a tool call still running: the first line
+	// is cut by the tail and must not decide.
+	big := `{"type":"assistant","message":{"content":[{"type":"text","text":"` + strings.Repeat("x", tailBytes) + `"}]}}`
+	pending := `{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash"}]}}`
+	os.WriteFile(path, []byte(big+"\n"+pending+"\n"), 0644)
+	if !tailInProgress(path) {
+		t.Error("pending tool call after a large record not in progress")
+	}
+
+	// The verdict is cached until the file changes.
+	info, _ := os.Stat(path)
+	if !works.inProgress(path, info) {
+		t.Fatal("pending tool call not in progress")
+	}
+	os.WriteFile(path, []byte(big+"\n"+pending+"\n"+`{"type":"system","subtype":"turn_duration"}`+"\n"), 0644)
+	info, _ = os.Stat(path)
+	if works.inProgress(path, info) {
+		t.Error("cached verdict kept after the turn ended")
+	}
+}
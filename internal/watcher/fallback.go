@@ -0,0 +1,131 @@
+package watcher
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// watchMode is how one path is watched in fsnotify mode.
+type watchMode uint8
+
+const (
+	watchNotify watchMode = iota // an inotify watch
+	watchPoll                    // polled: the inotify watch limit was reached
+)
+
+// WatchLimitFix is the command that raises the inotify watch limit.
+const WatchLimitFix = "sudo sysctl fs.inotify.max_user_watches=524288"
+
+// setWatchMode records how path is watched.
+func (w *Watcher) setWatchMode(path string, mode watchMode) {
+	w.modeMu.Lock()
+	defer w.modeMu.Unlock()
+	if mode == watchNotify {
+		delete(w.watchModes, path)
+		return
+	}
+	w.watchModes[path] = mode
+}
+
+// PolledPaths returns the paths polled because they couldn't get an
+// inotify watch, sorted.
+func (w *Watcher) PolledPaths() []string {
+	w.modeMu.Lock()
+	defer w.modeMu.Unlock()
+	var paths []string
+	for path, mode := range w.watchModes {
+		if mode == watchPoll {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// watchLimitReached switches path to polling and, the first time, warns
+// through Warnings with the fix.
+func (w *Watcher) watchLimitReached(path string) {
+	w.setWatchMode(path, watchPoll)
+	if w.limitWarned.Swap(true) {
+		return
+	}
+	slog.Error("inotify watch limit reached; polling what can't be watched", "path", path, "watches", len(w.fsWatcher.WatchList()), "fix", WatchLimitFix)
+	w.warn(fmt.Sprintf("inotify watch limit reached, polling what can't be watched: %s (claude-esp doctor)", WatchLimitFix))
+}
+
+// warn sends msg on Warnings unless it is full.
+func (w *Watcher) warn(msg string) {
+	select {
+	case w.Warnings <- msg:
+	default:
+		slog.Warn("warnings channel full; warning dropped", "warning", msg)
+	}
+}
+
+// pollFallback runs on the poll interval in fsnotify mode while paths are
+// polled. Polled files that grew are read as if written; if a directory is
+// polled, the discovery a polling watcher does runs too, and the files it
+// finds are watched (or polled) like any other.
+func (w *Watcher) pollFallback() {
+	paths := w.PolledPaths()
+	if len(paths) == 0 {
+		return
+	}
+	dirs := false
+	for _, path := range paths {
+		w.fileCtxMu.RLock()
+		_, isFile := w.fileContexts[path]
+		w.fileCtxMu.RUnlock()
+		if !isFile {
+			dirs = true
+			continue
+		}
+		info, err := os.Stat(path)
+		w.filePosMu.RLock()
+		pos := w.filePositions[path]
+		w.filePosMu.RUnlock()
+		if err == nil && info.Size() != pos {
+			w.handleFsWrite(path)
+		}
+	}
+	if !dirs {
+		return
+	}
+	if w.watchActive.Load() {
+		w.checkForNewSessions()
+	}
+	for _, session := range w.getSessionsSnapshot() {
+		w.checkForNewSubagents(session)
+		w.checkForBackgroundTasks(session)
+		w.checkSessionTodos(session)
+		for _, path := range w.unwatchedFiles(session) {
+			w.handleFsWrite(path)
+		}
+	}
+}
+
+// unwatchedFiles registers the files of session that discovery found
+// without a watch, and returns them.
+func (w *Watcher) unwatchedFiles(session *Session) []string {
+	files := map[string]string{session.MainFile: ""}
+	session.mu.RLock()
+	for agentID, path := range session.Subagents {
+		if path != "" {
+			files[path] = agentID
+		}
+	}
+	session.mu.RUnlock()
+	var added []string
+	for path, agentID := range files {
+		w.fileCtxMu.RLock()
+		_, known := w.fileContexts[path]
+		w.fileCtxMu.RUnlock()
+		if !known {
+			w.addFileWatch(path, session.ID, agentID)
+			added = append(added, path)
+		}
+	}
+	return added
+}
@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchLimitFallsBackToPolling(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-test-project")
+	subagentDir := filepath.Join(projectDir, "sess003", "subagents")
+	os.MkdirAll(subagentDir, 0755)
+	sessionFile := filepath.Join(projectDir, "sess003.jsonl")
+	os.WriteFile(sessionFile, nil, 0644)
+
+	w := newTestWatcher(t, tmpDir, true)
+	session := &Session{
+		ID:              "sess003",
+		MainFile:        sessionFile,
+		Subagents:       make(map[string]string),
+		SubagentTypes:   make(map[string]string),
+		BackgroundTasks: make(map[string]*BackgroundTask),
+	}
+	w.sessions[session.ID] = session
+	// As if inotify refused the transcript and the subagents directory.
+	w.fileContexts[sessionFile] = fileCtx{sessionID: session.ID}
+	w.watchLimitReached(sessionFile)
+	w.watchLimitReached(subagentDir)
+
+	select {
+	case msg := <-w.Warnings:
+		if !strings.Contains(msg, WatchLimitFix) {
+			t.Errorf("warning %q lacks the fix", msg)
+		}
+	default:
+		t.Fatal("no warning")
+	}
+	if len(w.Warnings) != 0 {
+		t.Error("warned more than once")
+	}
+	if got := w.PolledPaths(); len(got) != 2 {
+		t.Errorf("polled %v", got)
+	}
+
+	line := `{"type":"assistant","message":{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"thinking","thinking":"polled"}],"model":"claude-sonnet-4-20250514","usage":{"input_tokens":1,"output_tokens":1}}}` + "\n"
+	os.WriteFile(sessionFile, []byte(line), 0644)
+	os.WriteFile(filepath.Join(subagentDir, "agent-a1.jsonl"), []byte(line), 0644)
+	w.pollFallback()
+
+	select {
+	case msg := <-w.NewAgent:
+		if msg.AgentID != "a1" {
+			t.Errorf("new agent %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("agent in a polled directory not found")
+	}
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case item := <-w.Items:
+			got[item.AgentID] = true
+		case <-time.After(time.Second):
+			t.Fatalf("items from polled paths: got agents %v", got)
+		}
+	}
+}
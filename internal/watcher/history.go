@@ -0,0 +1,69 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// FindSession returns the most recent session whose ID starts with prefix.
+func FindSession(prefix string) (SessionInfo, error) {
+	sessions, err := ListSessions(0)
+	if err != nil {
+		return SessionInfo{}, err
+	}
+	for _, s := range sessions {
+		if strings.HasPrefix(s.ID, prefix) {
+			return s, nil
+		}
+	}
+	return SessionInfo{}, fmt.Errorf("session %s not found", prefix)
+}
+
+// ReadSession parses a whole session from disk, main transcript and
+// subagents, without watching it. Items are labelled as the watcher labels
+// them and sorted by timestamp; lines that fail to parse are skipped.
+func ReadSession(info SessionInfo) ([]parser.StreamItem, error) {
+	session, err := buildSession(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	// Read the transcripts in parallel; the main one must be readable, a
+	// subagent file may vanish while we read.
+	transcripts := sessionTranscripts([]*Session{session})
+	perFile := make([][]parser.StreamItem, len(transcripts))
+	errs := make([]error, len(transcripts))
+	forEachParallel(len(transcripts), func(i int) {
+		perFile[i], errs[i] = readAll(transcripts[i])
+	})
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	var items []parser.StreamItem
+	for _, list := range perFile {
+		items = append(items, list...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Timestamp.Before(items[j].Timestamp)
+	})
+	return items, nil
+}
+
+func readAll(t transcript) ([]parser.StreamItem, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dec := parser.NewDecoder(file)
+	var out []parser.StreamItem
+	for item := range dec.All() {
+		labelItem(&item, t.sessionID, t.agentID, t.agentType)
+		out = append(out, item)
+	}
+	return out, dec.Err()
+}
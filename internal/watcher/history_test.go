@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestReadSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CLAUDE_HOME", tmpDir)
+	projectDir := filepath.Join(tmpDir, "projects", "-test-project")
+	subagentDir := filepath.Join(projectDir, "sess003", "subagents")
+	os.MkdirAll(subagentDir, 0755)
+
+	main := `{"type":"assistant","timestamp":"2025-01-01T12:00:00Z","message":{"role":"assistant","content":[{"type":"text","text":"first"}]}}
+not json
+{"type":"assistant","timestamp":"2025-01-01T12:00:02Z","message":{"role":"assistant","content":[{"type":"text","text":"third"}]}}
+`
+	agent := `{"type":"assistant","agentId":"abc1234567","timestamp":"2025-01-01T12:00:01Z","message":{"role":"assistant","content":[{"type":"text","text":"second"}]}}
+`
+	os.WriteFile(filepath.Join(projectDir, "sess003.jsonl"), []byte(main), 0644)
+	os.WriteFile(filepath.Join(subagentDir, "agent-abc1234567.jsonl"), []byte(agent), 0644)
+
+	info, err := FindSession("sess0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := ReadSession(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if items[i].Content != want || items[i].SessionID != "sess003" {
+			t.Errorf("item %d = %q in %q, want %q in sess003", i, items[i].Content, items[i].SessionID, want)
+		}
+	}
+	if got := items[1]; got.AgentID != "abc1234567" || got.AgentName != "Agent-abc1234" || got.Type != parser.TypeText {
+		t.Errorf("subagent item = %+v", got)
+	}
+
+	if _, err := FindSession("nope"); err == nil {
+		t.Error("expected an error for an unknown session")
+	}
+}
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ProjectFilter matches project paths against glob patterns, to keep
+// noisy projects out of auto-discovery. In a pattern, * matches any run of
+// characters including /, and ? matches one character; the whole path
+// must match. Paths are compared with a leading /, so "*/scratch*" matches
+// every project whose last directories start with "scratch". A nil
+// *ProjectFilter matches nothing.
+type ProjectFilter struct {
+	patterns []*regexp.Regexp
+}
+
+// NewProjectFilter compiles patterns. It returns nil when there are none.
+func NewProjectFilter(patterns []string) (*ProjectFilter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	f := &ProjectFilter{}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("empty ignore pattern")
+		}
+		var b strings.Builder
+		b.WriteString("^")
+		for _, r := range p {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		re, err := regexp.Compile(b.String())
+		if err != nil {
+			return nil, fmt.Errorf("bad ignore pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// Match reports whether projectPath matches any pattern.
+func (f *ProjectFilter) Match(projectPath string) bool {
+	if f == nil {
+		return false
+	}
+	projectPath = "/" + strings.TrimPrefix(projectPath, "/")
+	for _, re := range f.patterns {
+		if re.MatchString(projectPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectFilter(t *testing.T) {
+	f, err := NewProjectFilter([]string{"*/scratch*", "/home/me/tmp?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"home/me/scratch":         true,
+		"/home/me/work/scratch-2": true,
+		"home/me/tmp1":            true,
+		"home/me/tmp12":           false,
+		"home/me/api":             false,
+		"home/me/scratchpad/api":  true, // * crosses directories
+	} {
+		if got := f.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	var none *ProjectFilter
+	if none.Match("anything") {
+		t.Error("nil filter matched")
+	}
+	if _, err := NewProjectFilter([]string{" "}); err == nil {
+		t.Error("blank pattern accepted")
+	}
+}
+
+func TestProjectFilterKeepsSessionsOut(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"-home-me-scratch", "-home-me-api"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+		os.WriteFile(filepath.Join(tmpDir, dir, dir[9:]+"1.jsonl"), []byte(""), 0644)
+	}
+
+	w := newTestWatcher(t, tmpDir, false)
+	f, _ := NewProjectFilter([]string{"*/scratch*"})
+	w.SetProjectFilter(f)
+	w.checkForNewSessions()
+	sessions := w.GetSessions()
+	if len(sessions) != 1 || sessions["api1"] == nil {
+		t.Errorf("sessions = %v, want only api1", sessions)
+	}
+}
@@ -0,0 +1,266 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// indexVersion is bumped when SessionMeta changes meaning; an index of
+// another version is thrown away and rebuilt.
+const indexVersion = 1
+
+// IndexPath, when set, is where listings keep per-session metadata and
+// resolved project paths between runs, so only transcripts that grew since
+// the last listing are read, and only from where the index left off. Set
+// it once at startup; empty keeps the index in memory for this process.
+var IndexPath string
+
+// SessionMeta is what the index knows about a transcript.
+type SessionMeta struct {
+	GitBranch string    `json:"gitBranch,omitempty"` // branch on the latest line that had one
+	First     time.Time `json:"first,omitzero"`      // earliest line timestamp
+	Last      time.Time `json:"last,omitzero"`       // latest line timestamp
+	Lines     int       `json:"lines"`
+	Prompts   int       `json:"prompts"`   // user lines typed as text (not tool results)
+	ToolCalls int       `json:"toolCalls"` // tool_use blocks
+
+	// Size is how many bytes of complete lines have been indexed, and
+	// ModTime the file's modification time when they were.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+type index struct {
+	Version  int                     `json:"version"`
+	Projects map[string]string       `json:"projects"` // encoded project dir → resolved path
+	Cwds     map[string]string       `json:"cwds"`     // transcript path → directory Claude Code started in
+	Sessions map[string]*SessionMeta `json:"sessions"` // transcript path → metadata
+
+	path  string
+	dirty bool
+}
+
+// sessionIndex is the process's copy of the index, loaded on first use.
+var sessionIndex struct {
+	sync.Mutex
+	ix *index
+}
+
+// withIndex runs fn with the index locked, loading it first if IndexPath
+// changed, and saves it afterwards if fn changed it.
+func withIndex(fn func(ix *index)) {
+	sessionIndex.Lock()
+	defer sessionIndex.Unlock()
+	if sessionIndex.ix == nil || sessionIndex.ix.path != IndexPath {
+		sessionIndex.ix = loadIndex(IndexPath)
+	}
+	fn(sessionIndex.ix)
+	sessionIndex.ix.save()
+}
+
+// loadIndex reads the index at path. A missing, unreadable or outdated
+// index starts out empty.
+func loadIndex(path string) *index {
+	ix := &index{}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, ix)
+		}
+	}
+	if ix.Version != indexVersion {
+		*ix = index{Version: indexVersion}
+	}
+	if ix.Projects == nil {
+		ix.Projects = make(map[string]string)
+	}
+	if ix.Sessions == nil {
+		ix.Sessions = make(map[string]*SessionMeta)
+	}
+	if ix.Cwds == nil {
+		ix.Cwds = make(map[string]string)
+	}
+	ix.path = path
+	return ix
+}
+
+// save writes the index if it changed. It is only a cache, so a failed
+// write is not an error.
+func (ix *index) save() {
+	if !ix.dirty || ix.path == "" {
+		return
+	}
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0o755); err != nil {
+		return
+	}
+	tmp := ix.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	if os.Rename(tmp, ix.path) == nil {
+		ix.dirty = false
+	}
+}
+
+// project resolves an encoded project directory name, remembering the
+// answer: resolveProjectPath stats a path per dash in the name. A guess
+// for a directory that doesn't exist (yet) is not remembered, and neither
+// is a path from PathMap, which may change.
+func (ix *index) project(encoded string) string {
+	if p, ok := resolveMapped(encoded); ok {
+		return p
+	}
+	if p, ok := ix.Projects[encoded]; ok {
+		return p
+	}
+	p := resolveProjectPath(encoded)
+	if _, err := os.Stat("/" + p); err == nil {
+		ix.Projects[encoded] = p
+		ix.dirty = true
+	}
+	return p
+}
+
+// sessionProject returns the project path of the transcript at path. The
+// working directory recorded in the transcript is authoritative: unlike
+// the directory name, it is exact for paths with dashes and doesn't depend
+// on the project existing on this machine. Transcripts without one yet
+// fall back to the directory name.
+func (ix *index) sessionProject(path string) string {
+	cwd, ok := ix.Cwds[path]
+	if !ok {
+		if cwd = transcriptCwd(path); cwd != "" {
+			ix.Cwds[path] = cwd
+			ix.dirty = true
+		}
+	}
+	if cwd != "" {
+		return cwdProject(cwd)
+	}
+	return ix.project(filepath.Base(filepath.Dir(path)))
+}
+
+// session returns the metadata of the transcript at path, reading only
+// what was appended since it was last indexed. A file that shrank is
+// reindexed from the start.
+func (ix *index) session(path string, info fs.FileInfo) SessionMeta {
+	m := ix.Sessions[path]
+	if m == nil || info.Size() < m.Size {
+		m = &SessionMeta{}
+		ix.Sessions[path] = m
+	}
+	if info.Size() == m.Size && info.ModTime().Equal(m.ModTime) {
+		return *m
+	}
+	if m.update(path) {
+		m.ModTime = info.ModTime()
+		ix.dirty = true
+	}
+	return *m
+}
+
+// prune drops sessions that weren't seen in a full listing.
+func (ix *index) prune(seen map[string]bool) {
+	for path := range ix.Sessions {
+		if !seen[path] {
+			delete(ix.Sessions, path)
+			ix.dirty = true
+		}
+	}
+	for path := range ix.Cwds {
+		if !seen[path] {
+			delete(ix.Cwds, path)
+			ix.dirty = true
+		}
+	}
+}
+
+// sessionProjectPath returns the project path of the transcript at path
+// through the index.
+func sessionProjectPath(path string) string {
+	var p string
+	withIndex(func(ix *index) { p = ix.sessionProject(path) })
+	return p
+}
+
+// update indexes the complete lines after m.Size. A final line without a
+// newline is left for next time: Claude may be writing it. It reports
+// whether anything was read.
+func (m *SessionMeta) update(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if _, err := f.Seek(m.Size, io.SeekStart); err != nil {
+		return false
+	}
+	start := m.Size
+	r := bufio.NewReaderSize(f, ScannerInitBufferSize)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		m.Size += int64(len(line))
+		m.add(line)
+	}
+	return m.Size != start || m.ModTime.IsZero()
+}
+
+// add counts one transcript line.
+func (m *SessionMeta) add(line []byte) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	m.Lines++
+	var l struct {
+		Type      string `json:"type"`
+		Timestamp string `json:"timestamp"`
+		GitBranch string `json:"gitBranch"`
+		Message   struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(line, &l) != nil {
+		return
+	}
+	if l.GitBranch != "" {
+		m.GitBranch = l.GitBranch
+	}
+	if ts, err := time.Parse(time.RFC3339, l.Timestamp); err == nil {
+		if m.First.IsZero() || ts.Before(m.First) {
+			m.First = ts
+		}
+		if ts.After(m.Last) {
+			m.Last = ts
+		}
+	}
+	switch l.Type {
+	case "user":
+		var text string
+		if json.Unmarshal(l.Message.Content, &text) == nil {
+			m.Prompts++
+		}
+	case "assistant":
+		var blocks []struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(l.Message.Content, &blocks)
+		for _, b := range blocks {
+			if b.Type == "tool_use" {
+				m.ToolCalls++
+			}
+		}
+	}
+}
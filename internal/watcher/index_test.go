@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useIndex points the session index at a fresh file for the test.
+func useIndex(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	prev := IndexPath
+	IndexPath = path
+	t.Cleanup(func() { IndexPath = prev })
+	return path
+}
+
+func listOne(t *testing.T) SessionInfo {
+	t.Helper()
+	sessions, err := ListSessions(0)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions = %v, %v", sessions, err)
+	}
+	LoadMeta(sessions)
+	if sessions[0].Meta == nil {
+		t.Fatal("no metadata")
+	}
+	return sessions[0]
+}
+
+func TestLoadMeta(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CLAUDE_HOME", tmpDir)
+	indexPath := useIndex(t)
+	projectDir := filepath.Join(tmpDir, "projects", "-test-project")
+	os.MkdirAll(projectDir, 0755)
+	path := filepath.Join(projectDir, "sess008.jsonl")
+
+	prompt := `{"type":"user","gitBranch":"main","timestamp":"2025-01-01T12:00:00Z","message":{"role":"user","content":"fix the tests"}}` + "\n"
+	toolUse := `{"type":"assistant","gitBranch":"fix-tests","timestamp":"2025-01-01T12:00:05Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{}}]}}` + "\n"
+	result := `{"type":"user","timestamp":"2025-01-01T12:00:07Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}` + "\n"
+	os.WriteFile(path, []byte(prompt+toolUse[:40]), 0644)
+
+	m := listOne(t).Meta
+	if m.Lines != 1 || m.Prompts != 1 || m.GitBranch != "main" || m.Size != int64(len(prompt)) {
+		t.Errorf("meta = %+v; the partial line should wait", m)
+	}
+
+	// The line is finished and another appended; only they are read.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(toolUse[40:] + result)
+	f.Close()
+	m = listOne(t).Meta
+	if m.Lines != 3 || m.Prompts != 1 || m.ToolCalls != 1 || m.GitBranch != "fix-tests" {
+		t.Errorf("meta = %+v", m)
+	}
+	if m.First.Second() != 0 || m.Last.Second() != 7 || m.Size != int64(len(prompt+toolUse+result)) {
+		t.Errorf("first %v, last %v, size %d", m.First, m.Last, m.Size)
+	}
+
+	// Another run picks the index up from disk.
+	sessionIndex.ix = nil
+	if ix := loadIndex(indexPath); ix.Sessions[path] == nil || ix.Sessions[path].Lines != 3 {
+		t.Fatalf("index on disk = %+v", ix.Sessions)
+	}
+
+	// A rewritten, shorter transcript is indexed from scratch.
+	os.WriteFile(path, []byte(result), 0644)
+	if m := listOne(t).Meta; m.Lines != 1 || m.Prompts != 0 || m.ToolCalls != 0 {
+		t.Errorf("meta after rewrite = %+v", m)
+	}
+
+	// Deleted sessions are dropped from the index.
+	os.Remove(path)
+	ListSessions(0)
+	if ix := loadIndex(indexPath); len(ix.Sessions) != 0 {
+		t.Errorf("index still has %v", ix.Sessions)
+	}
+}
@@ -0,0 +1,149 @@
+package watcher
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// MaxIngestWorkers caps how many transcripts are read at once when
+// catching up on history.
+const MaxIngestWorkers = 8
+
+// transcript is one JSONL file of a session and how to label its items.
+type transcript struct {
+	path      string
+	sessionID string
+	agentID   string // empty for the main transcript
+	agentType string
+}
+
+// sessionTranscripts lists the main and subagent transcripts of sessions.
+func sessionTranscripts(sessions []*Session) []transcript {
+	var out []transcript
+	for _, session := range sessions {
+		out = append(out, transcript{path: session.MainFile, sessionID: session.ID})
+		session.mu.RLock()
+		for agentID, path := range session.Subagents {
+			out = append(out, transcript{path, session.ID, agentID, session.SubagentTypes[agentID]})
+		}
+		session.mu.RUnlock()
+	}
+	return out
+}
+
+// forEachParallel calls fn for every index below n on a pool of up to
+// MaxIngestWorkers goroutines, and returns when all calls have.
+func forEachParallel(n int, fn func(i int)) {
+	workers := min(n, runtime.GOMAXPROCS(0), MaxIngestWorkers)
+	if workers <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// readHistory catches up on transcripts from their last known positions.
+// Each file is parsed by a worker of its own; their items are then sent
+// merged in timestamp order, so a session's subagents interleave with its
+// main conversation as they happened. A file's position only moves past
+// items once they are sent, so Positions never covers one still unsent.
+func (w *Watcher) readHistory(transcripts []transcript) {
+	w.reading.RLock()
+	defer w.reading.RUnlock()
+	files := make([]historyFile, len(transcripts))
+	forEachParallel(len(transcripts), func(i int) {
+		f := &files[i]
+		f.end, f.read = w.decodeFrom(transcripts[i], func(item parser.StreamItem, lineStart int64) bool {
+			f.items = append(f.items, item)
+			f.lineStarts = append(f.lineStarts, lineStart)
+			return true
+		})
+	})
+	perFile := make([][]parser.StreamItem, len(files))
+	for i, f := range files {
+		perFile[i] = f.items
+		if f.read && len(f.items) == 0 {
+			w.setPosition(transcripts[i].path, f.end)
+		}
+	}
+	sent := make([]int, len(files))
+	for i, item := range mergeByTime(perFile) {
+		if !w.sendItem(item) {
+			return
+		}
+		// The next item's line may hold more items than this one, so the
+		// position stops at its start; past the last item it is the end.
+		f := &files[i]
+		sent[i]++
+		if sent[i] < len(f.items) {
+			w.setPosition(transcripts[i].path, f.lineStarts[sent[i]])
+		} else if f.read {
+			w.setPosition(transcripts[i].path, f.end)
+		}
+	}
+}
+
+// historyFile is one transcript as read by readHistory: its items, the
+// offset of the line each came from, and the offset it was read up to.
+type historyFile struct {
+	items      []parser.StreamItem
+	lineStarts []int64
+	end        int64
+	read       bool // end is valid: the file was read through
+}
+
+// setPosition records how far path has been delivered.
+func (w *Watcher) setPosition(path string, pos int64) {
+	w.filePosMu.Lock()
+	w.filePositions[path] = pos
+	w.filePosMu.Unlock()
+}
+
+// mergeByTime merges lists that are each in file order into one sequence
+// by timestamp, yielding each item with the index of its list. Each list
+// keeps its own order, and on equal timestamps the earlier list goes
+// first.
+func mergeByTime(lists [][]parser.StreamItem) iter.Seq2[int, parser.StreamItem] {
+	return func(yield func(int, parser.StreamItem) bool) {
+		next := make([]int, len(lists))
+		for {
+			best := -1
+			for i, list := range lists {
+				if next[i] == len(list) {
+					continue
+				}
+				if best < 0 || list[next[i]].Timestamp.Before(lists[best][next[best]].Timestamp) {
+					best = i
+				}
+			}
+			if best < 0 {
+				return
+			}
+			item := lists[best][next[best]]
+			next[best]++
+			if !yield(best, item) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestForEachParallel(t *testing.T) {
+	var calls [100]atomic.Int32
+	forEachParallel(len(calls), func(i int) { calls[i].Add(1) })
+	for i := range calls {
+		if n := calls[i].Load(); n != 1 {
+			t.Fatalf("index %d called %d times", i, n)
+		}
+	}
+	forEachParallel(0, func(int) { t.Fatal("called for n = 0") })
+}
+
+func TestMergeByTime(t *testing.T) {
+	at := func(sec int, content string) parser.StreamItem {
+		return parser.StreamItem{Timestamp: time.Unix(int64(sec), 0), Content: content}
+	}
+	lists := [][]parser.StreamItem{
+		{at(1, "a"), at(3, "c"), at(3, "d")},
+		nil,
+		{at(2, "b"), at(3, "e"), at(0, "f")}, // out of order: stays after e
+	}
+	var got []string
+	for _, item := range mergeByTime(lists) {
+		got = append(got, item.Content)
+	}
+	if want := []string{"a", "b", "c", "d", "e", "f"}; !slices.Equal(got, want) {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+}
+
+func TestReadHistoryInterleavesTranscripts(t *testing.T) {
+	tmpDir := t.TempDir()
+	w := newTestWatcher(t, tmpDir, false)
+	line := func(sec int, text string) string {
+		ts := time.Date(2025, 1, 1, 12, 0, sec, 0, time.UTC).Format(time.RFC3339)
+		return `{"type":"assistant","timestamp":"` + ts + `","message":{"role":"assistant","content":[{"type":"text","text":"` + text + `"}]}}` + "\n"
+	}
+	mainFile := filepath.Join(tmpDir, "sess009.jsonl")
+	agentFile := filepath.Join(tmpDir, "agent-abc1234567.jsonl")
+	os.WriteFile(mainFile, []byte(line(0, "main 1")+line(3, "main 2")), 0644)
+	os.WriteFile(agentFile, []byte(line(1, "agent 1")+line(2, "agent 2")), 0644)
+	session := &Session{
+		ID:            "sess009",
+		MainFile:      mainFile,
+		Subagents:     map[string]string{"abc1234567": agentFile},
+		SubagentTypes: map[string]string{},
+	}
+
+	w.readHistory(sessionTranscripts([]*Session{session}))
+	var got []string
+	for len(w.Items) > 0 {
+		got = append(got, (<-w.Items).Content)
+	}
+	if want := "main 1, agent 1, agent 2, main 2"; strings.Join(got, ", ") != want {
+		t.Errorf("items = %v, want %s", got, want)
+	}
+	for _, path := range []string{mainFile, agentFile} {
+		info, _ := os.Stat(path)
+		if w.filePositions[path] != info.Size() {
+			t.Errorf("%s: position %d, want %d", filepath.Base(path), w.filePositions[path], info.Size())
+		}
+	}
+}
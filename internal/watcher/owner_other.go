@@ -0,0 +1,8 @@
+//go:build !unix
+
+package watcher
+
+// Without Unix ownership, permission errors don't name an owner.
+func fileOwner(path string) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package watcher
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner returns the UID owning path.
+func fileOwner(path string) (int, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Uid), true
+}
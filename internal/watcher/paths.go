@@ -0,0 +1,126 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathRule maps a project path as Claude Code recorded it (inside a
+// container, through a bind mount or symlink) to where the project lives
+// on this machine.
+type PathRule struct {
+	From string // absolute path prefix as recorded
+	To   string // absolute local path
+}
+
+// PathMap rewrites every project path with the first rule that applies.
+// Set it once at startup, like IndexPath.
+var PathMap []PathRule
+
+// ParsePathRule parses a rule written "FROM -> TO". A leading ~ in either
+// path is the home directory.
+func ParsePathRule(s string) (PathRule, error) {
+	from, to, ok := strings.Cut(s, "->")
+	from, to = expandHome(strings.TrimSpace(from)), expandHome(strings.TrimSpace(to))
+	if !ok || !filepath.IsAbs(from) || !filepath.IsAbs(to) {
+		return PathRule{}, fmt.Errorf("bad path_map rule %q: want \"/from -> /to\"", s)
+	}
+	return PathRule{From: filepath.Clean(from), To: filepath.Clean(to)}, nil
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
+
+// mapPath rewrites an absolute path by the first rule whose From is the
+// path or one of its parents.
+func mapPath(p string) string {
+	for _, r := range PathMap {
+		if p == r.From {
+			return r.To
+		}
+		if rest, ok := strings.CutPrefix(p, r.From+"/"); ok {
+			return filepath.Join(r.To, rest)
+		}
+	}
+	return p
+}
+
+// cwdProject returns the project path (without the leading /, like
+// resolveProjectPath) for a working directory recorded in a transcript.
+func cwdProject(cwd string) string {
+	if cwd == "" {
+		return ""
+	}
+	return strings.TrimPrefix(mapPath(filepath.Clean(cwd)), "/")
+}
+
+// encodeProjectDir is how Claude Code names a project's directory under
+// projects/: every character other than a letter or digit becomes a dash.
+func encodeProjectDir(path string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, path)
+}
+
+// resolveMapped decodes a project directory name whose path falls under a
+// PathRule, resolving the rest of the name under the rule's local path.
+func resolveMapped(encoded string) (string, bool) {
+	for _, r := range PathMap {
+		prefix := encodeProjectDir(r.From)
+		if encoded == prefix {
+			return strings.TrimPrefix(r.To, "/"), true
+		}
+		if rest, ok := strings.CutPrefix(encoded, prefix+"-"); ok && rest != "" {
+			return resolveUnder(r.To, rest), true
+		}
+	}
+	return "", false
+}
+
+// maxCwdScan bounds how much of a transcript transcriptCwd reads: the
+// first user line, which carries the cwd, comes early.
+const maxCwdScan = 4 << 20
+
+// transcriptCwd returns the working directory recorded on the first line
+// of a transcript that has one: the directory Claude Code was started in.
+// It returns "" if none of the first lines do, e.g. for a new session
+// that hasn't written its first prompt yet.
+func transcriptCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(io.LimitReader(f, maxCwdScan), ScannerInitBufferSize)
+	for {
+		line, err := r.ReadBytes('\n')
+		if bytes.Contains(line, []byte(`"cwd"`)) {
+			var l struct {
+				Cwd string `json:"cwd"`
+			}
+			if json.Unmarshal(line, &l) == nil && l.Cwd != "" {
+				return l.Cwd
+			}
+		}
+		if err != nil {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// usePathMap sets PathMap for the test.
+func usePathMap(t *testing.T, rules ...string) {
+	t.Helper()
+	prev := PathMap
+	PathMap = nil
+	for _, s := range rules {
+		r, err := ParsePathRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		PathMap = append(PathMap, r)
+	}
+	t.Cleanup(func() { PathMap = prev })
+}
+
+func TestParsePathRule(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	r, err := ParsePathRule(" /workspace/ ->  ~/code/foo ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.From != "/workspace" || r.To != filepath.Join(home, "code/foo") {
+		t.Errorf("rule = %+v", r)
+	}
+	for _, bad := range []string{"/workspace", "workspace -> /code", "/workspace -> code", " -> /code"} {
+		if _, err := ParsePathRule(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestCwdProject(t *testing.T) {
+	usePathMap(t, "/workspace -> /home/me/code/foo")
+	tests := map[string]string{
+		"":                    "",
+		"/workspace":          "home/me/code/foo",
+		"/workspace/api/":     "home/me/code/foo/api",
+		"/workspace-2/api":    "workspace-2/api",
+		"/home/me/claude-esp": "home/me/claude-esp",
+	}
+	for cwd, want := range tests {
+		if got := cwdProject(cwd); got != want {
+			t.Errorf("cwdProject(%q) = %q, want %q", cwd, got, want)
+		}
+	}
+}
+
+func TestResolveMapped(t *testing.T) {
+	local := t.TempDir()
+	os.MkdirAll(filepath.Join(local, "my-app"), 0o755)
+	usePathMap(t, "/work_space -> "+local)
+
+	if p, ok := resolveMapped("-work-space"); !ok || "/"+p != local {
+		t.Errorf("root of the rule = %q, %v", p, ok)
+	}
+	if p, ok := resolveMapped("-work-space-my-app"); !ok || "/"+p != filepath.Join(local, "my-app") {
+		t.Errorf("dashed project under the rule = %q, %v", p, ok)
+	}
+	if p, ok := resolveMapped("-work-space-other-dir"); !ok || "/"+p != filepath.Join(local, "other/dir") {
+		t.Errorf("missing project under the rule = %q, %v", p, ok)
+	}
+	if _, ok := resolveMapped("-work-spaces-x"); ok {
+		t.Error("rule applied to a sibling directory")
+	}
+}
+
+func TestSessionProjectFromCwd(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("CLAUDE_HOME", tmpDir)
+	useIndex(t)
+	usePathMap(t)
+	projectDir := filepath.Join(tmpDir, "projects", "-srv-claude-esp-rs")
+	os.MkdirAll(projectDir, 0o755)
+	path := filepath.Join(projectDir, "sess010.jsonl")
+
+	// No line with a cwd yet: the directory name is all there is.
+	os.WriteFile(path, []byte(`{"type":"summary","summary":"x"}`+"\n"), 0o644)
+	if p := listOne(t).ProjectPath; p != "srv/claude/esp/rs" {
+		t.Errorf("project from the directory name = %q", p)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"type":"user","cwd":"/srv/claude-esp-rs","message":{"role":"user","content":"hi"}}` + "\n")
+	f.WriteString(`{"type":"user","cwd":"/srv/claude-esp-rs/sub","message":{"role":"user","content":"cd"}}` + "\n")
+	f.Close()
+	if p := listOne(t).ProjectPath; p != "srv/claude-esp-rs" {
+		t.Errorf("project from the transcript's cwd = %q", p)
+	}
+	session, err := buildSession(path)
+	if err != nil || session.ProjectPath != "srv/claude-esp-rs" {
+		t.Errorf("watched session project = %q, %v", session.ProjectPath, err)
+	}
+
+	// Mapping applies to the remembered cwd, so a new rule takes effect.
+	usePathMap(t, "/srv -> /home/me/src")
+	if p := listOne(t).ProjectPath; p != "home/me/src/claude-esp-rs" {
+		t.Errorf("mapped project = %q", p)
+	}
+}
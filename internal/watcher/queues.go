@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+// QueueStats is a snapshot of the traffic through Items or Errors.
+type QueueStats struct {
+	Queued    int64 // sent on the channel
+	Delivered int64 // taken off it by the reader: Queued - Depth
+	Dropped   int64 // not sent because the channel was full
+	Waited    int64 // sends that found the channel full and waited for room
+	Depth     int   // waiting in the channel now
+	Cap       int
+}
+
+// queueCounter counts the traffic through one channel.
+type queueCounter struct {
+	queued, dropped, waited atomic.Int64
+}
+
+func (c *queueCounter) stats(depth, capacity int) QueueStats {
+	queued := c.queued.Load()
+	return QueueStats{
+		Queued:    queued,
+		Delivered: max(0, queued-int64(depth)),
+		Dropped:   c.dropped.Load(),
+		Waited:    c.waited.Load(),
+		Depth:     depth,
+		Cap:       capacity,
+	}
+}
+
+// Queues returns the traffic through Items and Errors so far. Items are
+// never dropped: a full Items channel makes the reading goroutine wait,
+// which Waited counts. Errors are dropped when the reader falls behind.
+func (w *Watcher) Queues() (items, errs QueueStats) {
+	return w.itemQueue.stats(len(w.Items), cap(w.Items)), w.errorQueue.stats(len(w.Errors), cap(w.Errors))
+}
+
+// sendItem sends item on Items, waiting for room if it is full. It reports
+// false if the watcher stopped first.
+func (w *Watcher) sendItem(item parser.StreamItem) bool {
+	select {
+	case w.Items <- item:
+		w.itemQueue.queued.Add(1)
+		return true
+	default:
+	}
+	w.itemQueue.waited.Add(1)
+	select {
+	case w.Items <- item:
+		w.itemQueue.queued.Add(1)
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// sendError sends err on Errors unless it is full, in which case it is
+// dropped with a warning.
+func (w *Watcher) sendError(err error) {
+	select {
+	case w.Errors <- err:
+		w.errorQueue.queued.Add(1)
+	default:
+		dropped := w.errorQueue.dropped.Add(1)
+		slog.Warn("errors channel full; error dropped", "err", err, "dropped", dropped, "cap", cap(w.Errors))
+	}
+}
@@ -0,0 +1,39 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/phiat/claude-esp/internal/parser"
+)
+
+func TestQueues(t *testing.T) {
+	w := newTestWatcher(t, t.TempDir(), false)
+	for range 3 {
+		w.sendItem(parser.StreamItem{Type: parser.TypeText})
+	}
+	<-w.Items
+	for range ErrorChannelBuffer + 2 {
+		w.reportError(errors.New("boom"))
+	}
+
+	items, errs := w.Queues()
+	if want := (QueueStats{Queued: 3, Delivered: 1, Depth: 2, Cap: ItemChannelBuffer}); items != want {
+		t.Errorf("items = %+v, want %+v", items, want)
+	}
+	if want := (QueueStats{Queued: ErrorChannelBuffer, Dropped: 2, Depth: ErrorChannelBuffer, Cap: ErrorChannelBuffer}); errs != want {
+		t.Errorf("errors = %+v, want %+v", errs, want)
+	}
+
+	// A full Items channel waits rather than drops, until the watcher stops.
+	for len(w.Items) < cap(w.Items) {
+		w.sendItem(parser.StreamItem{})
+	}
+	w.Stop()
+	if w.sendItem(parser.StreamItem{}) {
+		t.Error("sent on a full channel after Stop")
+	}
+	if items, _ := w.Queues(); items.Waited != 1 || items.Dropped != 0 {
+		t.Errorf("items after a full channel = %+v", items)
+	}
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// FileErrorMsg reports a transcript that exists but can't be read (root
+// owned, written from a container under another UID), or with Err nil
+// that it can be read again.
+type FileErrorMsg struct {
+	SessionID string
+	AgentID   string // empty for the main session file
+	Path      string
+	Err       error
+}
+
+// Reason describes Err for the tree, e.g. "permission denied:
+// /path/s1.jsonl (owned by uid 0, you are 1000)".
+func (m FileErrorMsg) Reason() string {
+	if m.Err == nil {
+		return ""
+	}
+	return DescribeReadError(m.Path, m.Err)
+}
+
+// DescribeReadError says why path couldn't be read, naming its owner
+// when a permission error comes from it being someone else's file.
+func DescribeReadError(path string, err error) string {
+	if !errors.Is(err, fs.ErrPermission) {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return fmt.Sprintf("%v: %s", pathErr.Err, path)
+		}
+		return err.Error()
+	}
+	reason := "permission denied: " + path
+	if uid, ok := fileOwner(path); ok && uid != os.Getuid() {
+		reason += fmt.Sprintf(" (owned by uid %d, you are %d)", uid, os.Getuid())
+	}
+	return reason
+}
+
+// noteOpen reports a change in whether a transcript can be opened: err
+// from opening it, nil once it opened. A file that is gone isn't an
+// error; sessions come and go.
+func (w *Watcher) noteOpen(t transcript, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	failing := err != nil
+	w.unreadableMu.Lock()
+	defer w.unreadableMu.Unlock()
+	if w.unreadable[t.path] == failing {
+		return
+	}
+	select {
+	case w.FileErrors <- FileErrorMsg{SessionID: t.sessionID, AgentID: t.agentID, Path: t.path, Err: err}:
+		// Only once it is sent, so a full channel means trying again on
+		// the next read.
+		w.unreadable[t.path] = failing
+	default:
+	}
+}
+
+// checkReadable reports a transcript that can't be read when it is first
+// watched: inotify can't watch it either, so no read would find out.
+func (w *Watcher) checkReadable(t transcript) {
+	file, err := os.Open(t.path)
+	if err == nil {
+		file.Close()
+	}
+	w.noteOpen(t, err)
+}
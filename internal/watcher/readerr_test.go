@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNoteOpenReportsChanges(t *testing.T) {
+	w := newTestWatcher(t, t.TempDir(), false)
+	tr := transcript{path: "/p/s1/subagents/agent-a1.jsonl", sessionID: "s1", agentID: "a1"}
+	denied := &fs.PathError{Op: "open", Path: tr.path, Err: fs.ErrPermission}
+
+	w.noteOpen(tr, denied)
+	w.noteOpen(tr, denied) // still failing: not reported again
+	w.noteOpen(tr, &fs.PathError{Op: "open", Path: tr.path, Err: fs.ErrNotExist})
+	w.noteOpen(tr, nil)
+	w.noteOpen(tr, nil)
+
+	var got []string
+	for len(w.FileErrors) > 0 {
+		msg := <-w.FileErrors
+		if msg.SessionID != "s1" || msg.AgentID != "a1" {
+			t.Errorf("msg for %s/%s", msg.SessionID, msg.AgentID)
+		}
+		got = append(got, msg.Reason())
+	}
+	want := []string{"permission denied: " + tr.path, ""}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("reasons = %q, want %q", got, want)
+	}
+}
+
+func TestDescribeReadErrorNamesOwner(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() != 0 {
+		t.Skip("needs root to give a file away")
+	}
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	os.WriteFile(path, nil, 0o600)
+	if err := os.Chown(path, 4242, 4242); err != nil {
+		t.Skip(err)
+	}
+	got := DescribeReadError(path, &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission})
+	if !strings.HasSuffix(got, "(owned by uid 4242, you are 0)") {
+		t.Errorf("reason = %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+// remoteRetry is how long an attached watcher waits before reconnecting
+// after losing the stream.
+const remoteRetry = 2 * time.Second
+
+// Attach creates a watcher fed by another claude-esp's HTTP stream
+// (GET <base>/api/items) instead of the transcript files, so a second
+// instance doesn't read them all again. Only items published after
+// connecting arrive, and sessions and agents appear with their first
+// item. A dropped connection is retried until Stop.
+func Attach(base string) (*Watcher, error) {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("cannot attach to %q: want a URL like http://127.0.0.1:7777", base)
+	}
+	w := newWatcher("", 0, 0, 0)
+	w.remote = strings.TrimRight(base, "/")
+	w.remoteSeen = make(map[fileCtx]time.Time)
+	w.watchActive.Store(true)
+	return w, nil
+}
+
+// Remote returns the URL given to Attach, or "" for a watcher that reads
+// files.
+func (w *Watcher) Remote() string {
+	return w.remote
+}
+
+// watchRemote reads the stream until Stop, reconnecting as needed.
+func (w *Watcher) watchRemote() {
+	for {
+		err := w.readRemote()
+		if w.ctx.Err() != nil {
+			return
+		}
+		w.reportError(fmt.Errorf("attached to %s: %w", w.remote, err))
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(remoteRetry):
+		}
+	}
+}
+
+// readRemote reads one connection's worth of items.
+func (w *Watcher) readRemote() error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodGet, w.remote+"/api/items", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := sink.HTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET /api/items: %s", resp.Status)
+	}
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var it sink.Item
+		if err := dec.Decode(&it); err != nil {
+			return err
+		}
+		w.addRemote(it)
+	}
+}
+
+// addRemote registers the item's session and agent if they are new, then
+// queues it.
+func (w *Watcher) addRemote(it sink.Item) {
+	item := it.StreamItem()
+	w.sessionsMu.Lock()
+	session := w.sessions[item.SessionID]
+	isNew := session == nil
+	if isNew {
+		session = &Session{
+			ID:              item.SessionID,
+			ProjectPath:     remoteProject(item),
+			Host:            item.Host,
+			Subagents:       make(map[string]string),
+			SubagentTypes:   make(map[string]string),
+			BackgroundTasks: make(map[string]*BackgroundTask),
+		}
+		if w.skipDiscovered(session) || !w.watchActive.Load() {
+			w.sessionsMu.Unlock()
+			return
+		}
+		w.sessions[session.ID] = session
+	}
+	w.remoteSeen[fileCtx{sessionID: item.SessionID, agentID: item.AgentID}] = time.Now()
+	w.sessionsMu.Unlock()
+
+	if isNew {
+		select {
+		case w.NewSession <- NewSessionMsg{SessionID: session.ID, ProjectPath: session.ProjectPath, Host: session.Host}:
+		default:
+		}
+	}
+	if item.AgentID != "" {
+		agentType := item.AgentName
+		if strings.HasPrefix(agentType, "Agent-") {
+			agentType = ""
+		}
+		session.mu.Lock()
+		_, known := session.Subagents[item.AgentID]
+		if !known {
+			session.Subagents[item.AgentID] = ""
+			session.SubagentTypes[item.AgentID] = agentType
+		}
+		session.mu.Unlock()
+		if !known {
+			select {
+			case w.NewAgent <- NewAgentMsg{SessionID: session.ID, AgentID: item.AgentID, AgentType: agentType}:
+			default:
+			}
+		}
+	}
+	w.sendItem(item)
+}
+
+// remoteProject is the project path of an item's session. Paths pushed
+// from another host are that machine's, which PathMap doesn't describe.
+func remoteProject(item parser.StreamItem) string {
+	if item.Host == "" {
+		return cwdProject(item.Cwd)
+	}
+	if item.Cwd == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Clean(item.Cwd), "/")
+}
+
+// remoteActivity is GetActivityInfo for an attached watcher: with no files
+// to stat, an agent's last item stands in for its file's mod time.
+// Caller holds sessionsMu.
+func (w *Watcher) remoteActivity(activeWithin time.Duration) []ActivityInfo {
+	var info []ActivityInfo
+	now := time.Now()
+	for c, seen := range w.remoteSeen {
+		if _, ok := w.sessions[c.sessionID]; !ok {
+			continue
+		}
+		info = append(info, ActivityInfo{
+			SessionID:    c.sessionID,
+			AgentID:      c.agentID,
+			IsActive:     now.Sub(seen) < activeWithin,
+			LastModified: seen,
+		})
+	}
+	return info
+}
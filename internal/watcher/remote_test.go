@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/sink"
+)
+
+func TestAttach(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/items" {
+			http.NotFound(rw, r)
+			return
+		}
+		for _, it := range []parser.StreamItem{
+			{Type: parser.TypeText, SessionID: "s1", AgentName: "Main", Content: "hi", Timestamp: at, Cwd: "/work/app"},
+			{Type: parser.TypeToolInput, SessionID: "s1", AgentID: "abc1234567", AgentName: "Explore", ToolName: "Bash", Content: "ls", Timestamp: at},
+			{Type: parser.TypeText, SessionID: "s2", AgentName: "Main", Content: "from CI", Timestamp: at, Cwd: "/build/app", Host: "ci-1"},
+		} {
+			line, _ := sink.MarshalLine(it)
+			rw.Write(line)
+		}
+		rw.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	if _, err := Attach("localhost:7777"); err == nil {
+		t.Error("Attach accepted a URL without a scheme")
+	}
+	w, err := Attach(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	receive := func() parser.StreamItem {
+		select {
+		case item := <-w.Items:
+			return item
+		case <-time.After(5 * time.Second):
+			t.Fatal("no item from the attached stream")
+		}
+		return parser.StreamItem{}
+	}
+	if item := receive(); item.Content != "hi" || !item.Timestamp.Equal(at) {
+		t.Errorf("first item = %+v", item)
+	}
+	if s := <-w.NewSession; s.SessionID != "s1" || s.ProjectPath != "work/app" {
+		t.Errorf("new session = %+v", s)
+	}
+	if item := receive(); item.AgentID != "abc1234567" {
+		t.Errorf("second item = %+v", item)
+	}
+	if a := <-w.NewAgent; a.AgentID != "abc1234567" || a.AgentType != "Explore" {
+		t.Errorf("new agent = %+v", a)
+	}
+	if item := receive(); item.Host != "ci-1" {
+		t.Errorf("third item = %+v", item)
+	}
+	if s := <-w.NewSession; s.SessionID != "s2" || s.Host != "ci-1" || s.ProjectPath != "build/app" {
+		t.Errorf("pushed session = %+v", s)
+	}
+	if info := w.GetActivityInfo(time.Minute); len(info) != 3 || !info[0].IsActive {
+		t.Errorf("activity = %+v", info)
+	}
+}
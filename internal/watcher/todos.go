@@ -0,0 +1,155 @@
+package watcher
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TodoItem is one entry of an agent's TodoWrite list.
+type TodoItem struct {
+	Content    string `json:"content"`
+	Status     string `json:"status"` // "pending", "in_progress" or "completed"
+	ActiveForm string `json:"activeForm,omitempty"`
+}
+
+// Todos is an agent's current todo list, in Claude's order.
+type Todos []TodoItem
+
+// Done returns how many items are completed.
+func (t Todos) Done() int {
+	n := 0
+	for _, item := range t {
+		if item.Status == "completed" {
+			n++
+		}
+	}
+	return n
+}
+
+// Current returns the item being worked on, preferring its active form
+// ("Running tests"), or "" when nothing is in progress.
+func (t Todos) Current() string {
+	for _, item := range t {
+		if item.Status == "in_progress" {
+			if item.ActiveForm != "" {
+				return item.ActiveForm
+			}
+			return item.Content
+		}
+	}
+	return ""
+}
+
+// TodosMsg carries an agent's todo list whenever its file changes. An
+// empty list means the agent cleared it.
+type TodosMsg struct {
+	SessionID string
+	AgentID   string // empty for main
+	Todos     Todos
+}
+
+// todoFileIDs splits a todo file name, "<session>-agent-<agent>.json", into
+// its session and agent. The main conversation's file names the session
+// twice; it maps to agent "".
+func todoFileIDs(name string) (sessionID, agentID string, ok bool) {
+	base, found := strings.CutSuffix(name, ".json")
+	if !found {
+		return "", "", false
+	}
+	sessionID, agentID, found = strings.Cut(base, "-agent-")
+	if !found || sessionID == "" || agentID == "" {
+		return "", "", false
+	}
+	if agentID == sessionID {
+		agentID = ""
+	}
+	return sessionID, agentID, true
+}
+
+// checkTodos reads the todo files of every watched session.
+func (w *Watcher) checkTodos() {
+	for _, session := range w.getSessionsSnapshot() {
+		w.checkSessionTodos(session)
+	}
+}
+
+// checkSessionTodos reads a session's todo files, its main conversation's
+// and its subagents'.
+func (w *Watcher) checkSessionTodos(session *Session) {
+	if w.todosDir == "" {
+		return
+	}
+	matches, _ := filepath.Glob(filepath.Join(w.todosDir, session.ID+"-agent-*.json"))
+	for _, path := range matches {
+		w.readTodoFile(path)
+	}
+}
+
+// readTodoFile sends a TodosMsg for a todo file of a watched session if it
+// changed since it was last sent. A file caught mid-write fails to parse
+// and is read again on its next change.
+func (w *Watcher) readTodoFile(path string) {
+	sessionID, agentID, ok := todoFileIDs(filepath.Base(path))
+	if !ok {
+		return
+	}
+	w.sessionsMu.RLock()
+	_, watched := w.sessions[sessionID]
+	w.sessionsMu.RUnlock()
+	if !watched {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	w.todoMu.Lock()
+	defer w.todoMu.Unlock()
+	if w.todoModTimes[path].Equal(info.ModTime()) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var todos Todos
+	if err := json.Unmarshal(data, &todos); err != nil {
+		return
+	}
+
+	select {
+	case w.Todos <- TodosMsg{SessionID: sessionID, AgentID: agentID, Todos: todos}:
+		w.todoModTimes[path] = info.ModTime()
+	default:
+		// Channel full; try again on the next change or poll.
+	}
+}
+
+// isTodoFile reports whether path is a file in the todos directory.
+func (w *Watcher) isTodoFile(path string) bool {
+	return w.todosDir != "" && filepath.Dir(path) == w.todosDir && strings.HasSuffix(path, ".json")
+}
+
+// todoModTimes entries outlive their files; drop the ones whose session
+// is gone.
+func (w *Watcher) cleanupTodoModTimes() {
+	w.todoMu.Lock()
+	defer w.todoMu.Unlock()
+	for path := range w.todoModTimes {
+		sessionID, _, _ := todoFileIDs(filepath.Base(path))
+		w.sessionsMu.RLock()
+		_, watched := w.sessions[sessionID]
+		w.sessionsMu.RUnlock()
+		if !watched {
+			delete(w.todoModTimes, path)
+		}
+	}
+}
+
+// todosDirFor returns ~/.claude/todos for a projects directory.
+func todosDirFor(claudeDir string) string {
+	return filepath.Join(filepath.Dir(claudeDir), "todos")
+}
@@ -0,0 +1,64 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTodoFileIDs(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"s1-agent-s1.json":   {"s1", ""},
+		"s1-agent-a7f3.json": {"s1", "a7f3"},
+	} {
+		sid, aid, ok := todoFileIDs(name)
+		if !ok || sid != want[0] || aid != want[1] {
+			t.Errorf("todoFileIDs(%q) = %q, %q, %v", name, sid, aid, ok)
+		}
+	}
+	for _, name := range []string{"s1.json", "s1-agent-s1.jsonl", "-agent-a1.json"} {
+		if _, _, ok := todoFileIDs(name); ok {
+			t.Errorf("todoFileIDs(%q) accepted", name)
+		}
+	}
+}
+
+func TestCheckTodos(t *testing.T) {
+	root := t.TempDir()
+	projects := filepath.Join(root, "projects")
+	w := newTestWatcher(t, projects, false)
+	w.todosDir = todosDirFor(projects)
+	os.MkdirAll(w.todosDir, 0755)
+	w.sessions["s1"] = &Session{ID: "s1"}
+
+	write := func(name, body string) {
+		os.WriteFile(filepath.Join(w.todosDir, name), []byte(body), 0644)
+	}
+	write("s1-agent-s1.json", `[
+		{"content":"Write tests","status":"completed","activeForm":"Writing tests"},
+		{"content":"Fix bug","status":"in_progress","activeForm":"Fixing bug"},
+		{"content":"Ship","status":"pending","activeForm":"Shipping"}]`)
+	write("other-agent-other.json", `[{"content":"x","status":"pending"}]`)
+
+	w.checkTodos()
+	select {
+	case msg := <-w.Todos:
+		if msg.SessionID != "s1" || msg.AgentID != "" || len(msg.Todos) != 3 {
+			t.Fatalf("msg = %+v", msg)
+		}
+		if msg.Todos.Done() != 1 || msg.Todos.Current() != "Fixing bug" {
+			t.Errorf("Done = %d, Current = %q", msg.Todos.Done(), msg.Todos.Current())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no TodosMsg")
+	}
+
+	// Unchanged files aren't resent; unwatched sessions never are.
+	w.checkTodos()
+	select {
+	case msg := <-w.Todos:
+		t.Errorf("unexpected %+v", msg)
+	default:
+	}
+}
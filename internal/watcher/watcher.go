@@ -4,24 +4,35 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/phiat/claude-esp/internal/crash"
 	"github.com/phiat/claude-esp/internal/parser"
 )
 
 const (
 	// DefaultPollInterval is how often to check for new content
 	DefaultPollInterval = 500 * time.Millisecond
+	// MinPollInterval is the shortest poll interval accepted
+	MinPollInterval = 100 * time.Millisecond
 	// DefaultActiveWindow is how recent a session must be modified to be considered active
 	DefaultActiveWindow = 5 * time.Minute
+	// DefaultActivityThreshold is how recent a file write must be for its
+	// session or agent to show as active in the tree
+	DefaultActivityThreshold = 30 * time.Second
 	// ItemChannelBuffer is the buffer size for the Items channel
 	ItemChannelBuffer = 100
 	// ErrorChannelBuffer is the buffer size for error channels
@@ -40,7 +51,7 @@ const (
 	// ScannerMaxBufferSize is the max buffer for JSON line scanner (10MB)
 	// Large because JSONL lines can contain base64-encoded images (screenshots).
 	// The scanner starts at ScannerInitBufferSize and only grows on demand.
-	ScannerMaxBufferSize = 10 * 1024 * 1024
+	ScannerMaxBufferSize = parser.MaxLineSize
 	// AgentIDDisplayLength is how many chars of agent ID to show in display name
 	AgentIDDisplayLength = 7
 	// RecentActivityThreshold is how recent a session must be to show as "active" in listings
@@ -62,6 +73,12 @@ func getClaudeProjectsDir() (string, error) {
 	return filepath.Join(homeDir, ".claude", "projects"), nil
 }
 
+// ProjectsDir returns the directory Claude keeps session transcripts in
+// (~/.claude/projects, or $CLAUDE_HOME/projects).
+func ProjectsDir() (string, error) {
+	return getClaudeProjectsDir()
+}
+
 // resolveProjectPath converts an encoded directory name back to a real path.
 // The encoded name like "-home-user-project-name" needs smart conversion because
 // directory names can contain dashes (e.g., "claude-esp-rs" should not become "claude/esp/rs").
@@ -71,26 +88,30 @@ func resolveProjectPath(encoded string) string {
 	if encoded == "" {
 		return ""
 	}
+	return resolveUnder("", encoded)
+}
 
+// resolveUnder decodes encoded (without its leading dash) as a path under
+// root, "" for /, and returns it without the leading /.
+func resolveUnder(root, encoded string) string {
 	parts := strings.Split(encoded, "-")
-	if len(parts) == 0 {
-		return encoded
-	}
 
 	// Try progressively joining segments from the right with dashes
 	// to find the actual directory name
-	for joinFrom := len(parts) - 1; joinFrom >= 1; joinFrom-- {
-		pathPart := strings.Join(parts[:joinFrom], "/")
-		dirPart := strings.Join(parts[joinFrom:], "-")
-		testPath := "/" + pathPart + "/" + dirPart
+	for joinFrom := len(parts) - 1; joinFrom >= 0; joinFrom-- {
+		dir := root
+		if joinFrom > 0 {
+			dir += "/" + strings.Join(parts[:joinFrom], "/")
+		}
+		testPath := dir + "/" + strings.Join(parts[joinFrom:], "-")
 
 		if _, err := os.Stat(testPath); err == nil {
-			return pathPart + "/" + dirPart
+			return strings.TrimPrefix(testPath, "/")
 		}
 	}
 
 	// Fallback to naive conversion
-	return strings.ReplaceAll(encoded, "-", "/")
+	return strings.TrimPrefix(root+"/"+strings.ReplaceAll(encoded, "-", "/"), "/")
 }
 
 // isMainSessionFile returns true if the path is a main session JSONL file
@@ -116,6 +137,7 @@ func isMainSessionFile(path string, info os.FileInfo) bool {
 type Session struct {
 	ID              string
 	ProjectPath     string
+	Host            string // machine that pushed the session to the daemon attached to ("" = this one)
 	MainFile        string
 	Subagents       map[string]string          // agentID -> file path
 	SubagentTypes   map[string]string          // agentID -> agentType from .meta.json
@@ -143,6 +165,7 @@ type NewAgentMsg struct {
 type NewSessionMsg struct {
 	SessionID   string
 	ProjectPath string
+	Host        string
 }
 
 // NewBackgroundTaskMsg signals when a new background task is discovered
@@ -164,22 +187,39 @@ type fileCtx struct {
 // Watcher monitors Claude session files for new content
 type Watcher struct {
 	claudeDir         string
-	pollInterval      time.Duration
+	pollInterval      atomic.Int64  // time.Duration; adjustable while running
+	pollReset         chan struct{} // signals the polling loop to pick up a new interval
 	sessions          map[string]*Session
-	sessionsMu        sync.RWMutex     // protects sessions map
+	removed           map[string]bool  // sessions dropped with RemoveSession; not rediscovered
+	ignore            *ProjectFilter   // projects kept out of discovery
+	sessionsMu        sync.RWMutex     // protects sessions, removed and ignore
 	filePositions     map[string]int64 // track read position per file
 	filePosMu         sync.RWMutex     // protects filePositions map
+	reading           sync.RWMutex     // held shared by every read until its items are sent; see Positions
+	resume            map[string]int64 // ResumeAt positions, taken once at startup
 	Items             chan parser.StreamItem
 	Errors            chan error
 	NewAgent          chan NewAgentMsg
 	NewSession        chan NewSessionMsg
 	NewBackgroundTask chan NewBackgroundTaskMsg
+	Todos             chan TodosMsg
+	Warnings          chan string       // problems worth showing that don't stop watching
+	FileErrors        chan FileErrorMsg // transcripts that exist but can't be read; see readerr.go
+	itemQueue         queueCounter      // traffic through Items; see Queues
+	errorQueue        queueCounter      // traffic through Errors
 	ctx               context.Context
 	cancel            context.CancelFunc
-	watchActive       atomic.Bool   // if true, only watch recently modified sessions
-	activeWindow      time.Duration // how recent is "active"
-	maxSessions       int           // max sessions to track (0=unlimited)
-	skipHistory       atomic.Bool   // if true, start from end of files (live only)
+	watchActive       atomic.Bool           // if true, only watch recently modified sessions
+	activeWindow      atomic.Int64          // time.Duration; how recent is "active"
+	maxSessions       int                   // max sessions to track (0=unlimited)
+	skipHistory       atomic.Bool           // if true, start from end of files (live only)
+	fullHistory       atomic.Bool           // if true, never auto-skip long history (-at, -goto)
+	sessionFile       string                // set by OpenFile: one fixed session, history always replayed
+	remote            string                // set by Attach: base URL of another instance's stream
+	remoteSeen        map[fileCtx]time.Time // Attach: when each agent's last item arrived; protected by sessionsMu
+	todosDir          string                // ~/.claude/todos; "" for OpenFile
+	todoModTimes      map[string]time.Time  // todo file -> mod time last sent
+	todoMu            sync.Mutex            // protects todoModTimes
 
 	// fsnotify fields
 	fsWatcher      *fsnotify.Watcher      // nil if using polling fallback
@@ -188,18 +228,88 @@ type Watcher struct {
 	fileCtxMu      sync.RWMutex           // protects fileContexts
 	debounceTimers map[string]*time.Timer // per-file write debounce timers
 	debounceMu     sync.Mutex             // protects debounceTimers
+	watchModes     map[string]watchMode   // paths not watched by inotify; see fallback.go
+	modeMu         sync.Mutex             // protects watchModes
+	limitWarned    atomic.Bool            // the watch limit warning was sent
+	unreadable     map[string]bool        // transcripts reported on FileErrors as unreadable
+	unreadableMu   sync.Mutex             // protects unreadable
 }
 
-// New creates a new watcher for active sessions.
+// New creates a new watcher for the given sessions, or for all active
+// sessions if sessionIDs is empty.
 // If pollInterval is 0, DefaultPollInterval is used.
 // If activeWindow is 0, DefaultActiveWindow is used.
 // If maxSessions is 0, no limit is applied.
-func New(sessionID string, pollInterval time.Duration, activeWindow time.Duration, maxSessions int) (*Watcher, error) {
+func New(sessionIDs []string, pollInterval time.Duration, activeWindow time.Duration, maxSessions int) (*Watcher, error) {
 	claudeDir, err := getClaudeProjectsDir()
 	if err != nil {
 		return nil, err
 	}
 
+	w := newWatcher(claudeDir, pollInterval, activeWindow, maxSessions)
+	w.todosDir = todosDirFor(claudeDir)
+
+	// Try to initialize fsnotify; fall back to polling on failure
+	if fsw, err := fsnotify.NewWatcher(); err == nil {
+		w.fsWatcher = fsw
+		w.useFsnotify = true
+	} else {
+		slog.Warn("fsnotify unavailable, polling instead", "err", err)
+	}
+	w.watchActive.Store(len(sessionIDs) == 0) // watch all active if no specific session
+
+	if len(sessionIDs) > 0 {
+		// Watch specific sessions (graceful — don't crash if not found yet)
+		for _, sessionID := range sessionIDs {
+			session, err := w.findSession(sessionID)
+			if err == nil {
+				w.sessions[session.ID] = session
+			}
+		}
+		// If not found, watch loops will discover it
+	} else {
+		// Find all active sessions (ignore errors — dir may not exist yet)
+		_ = w.discoverActiveSessions()
+	}
+
+	return w, nil
+}
+
+// OpenFile creates a watcher for one session transcript at any path, e.g.
+// copied from another machine, along with the subagent files next to it.
+// The whole file is replayed regardless of its length, nothing else is
+// discovered, and appended lines are picked up by polling.
+func OpenFile(path string, pollInterval time.Duration) (*Watcher, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+		return nil, fmt.Errorf("%s is not a session .jsonl file", path)
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	session, err := buildSession(path)
+	if err != nil {
+		return nil, err
+	}
+	// Outside ~/.claude/projects the parent directory isn't an encoded
+	// project path; show where the file is instead.
+	if dir := filepath.Dir(path); !strings.HasPrefix(filepath.Base(dir), "-") && transcriptCwd(path) == "" {
+		session.ProjectPath = dir
+	}
+
+	w := newWatcher(filepath.Dir(path), pollInterval, 0, 0)
+	w.sessionFile = path
+	w.sessions[session.ID] = session
+	return w, nil
+}
+
+// newWatcher returns a polling watcher with no sessions and auto-discovery
+// off.
+func newWatcher(claudeDir string, pollInterval time.Duration, activeWindow time.Duration, maxSessions int) *Watcher {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	if pollInterval <= 0 {
@@ -211,42 +321,66 @@ func New(sessionID string, pollInterval time.Duration, activeWindow time.Duratio
 
 	w := &Watcher{
 		claudeDir:         claudeDir,
-		pollInterval:      pollInterval,
 		sessions:          make(map[string]*Session),
+		removed:           make(map[string]bool),
 		filePositions:     make(map[string]int64),
 		Items:             make(chan parser.StreamItem, ItemChannelBuffer),
 		Errors:            make(chan error, ErrorChannelBuffer),
 		NewAgent:          make(chan NewAgentMsg, ErrorChannelBuffer),
 		NewSession:        make(chan NewSessionMsg, ErrorChannelBuffer),
 		NewBackgroundTask: make(chan NewBackgroundTaskMsg, ErrorChannelBuffer),
+		Todos:             make(chan TodosMsg, ErrorChannelBuffer),
+		Warnings:          make(chan string, ErrorChannelBuffer),
+		FileErrors:        make(chan FileErrorMsg, ErrorChannelBuffer),
+		pollReset:         make(chan struct{}, 1),
 		ctx:               ctx,
 		cancel:            cancel,
-		activeWindow:      activeWindow,
 		maxSessions:       maxSessions,
 		fileContexts:      make(map[string]fileCtx),
+		todoModTimes:      make(map[string]time.Time),
 		debounceTimers:    make(map[string]*time.Timer),
+		watchModes:        make(map[string]watchMode),
+		unreadable:        make(map[string]bool),
 	}
+	w.pollInterval.Store(int64(pollInterval))
+	w.activeWindow.Store(int64(activeWindow))
+	return w
+}
 
-	// Try to initialize fsnotify; fall back to polling on failure
-	if fsw, err := fsnotify.NewWatcher(); err == nil {
-		w.fsWatcher = fsw
-		w.useFsnotify = true
-	}
-	w.watchActive.Store(sessionID == "") // watch all active if no specific session
+// SessionFile returns the path given to OpenFile, or "" for a watcher
+// made by New.
+func (w *Watcher) SessionFile() string {
+	return w.sessionFile
+}
 
-	if sessionID != "" {
-		// Watch a specific session (graceful — don't crash if not found yet)
-		session, err := w.findSession(sessionID)
-		if err == nil {
-			w.sessions[session.ID] = session
-		}
-		// If not found, watch loops will discover it
-	} else {
-		// Find all active sessions (ignore errors — dir may not exist yet)
-		_ = w.discoverActiveSessions()
+// PollInterval returns how often the polling fallback checks for new content.
+func (w *Watcher) PollInterval() time.Duration {
+	return time.Duration(w.pollInterval.Load())
+}
+
+// SetPollInterval changes the poll interval of a running watcher. Values
+// below MinPollInterval are raised to it. With fsnotify the interval is
+// unused.
+func (w *Watcher) SetPollInterval(d time.Duration) {
+	w.pollInterval.Store(int64(max(d, MinPollInterval)))
+	select {
+	case w.pollReset <- struct{}{}:
+	default:
 	}
+}
 
-	return w, nil
+// ActiveWindow returns how recently a session must have been modified to be
+// discovered.
+func (w *Watcher) ActiveWindow() time.Duration {
+	return time.Duration(w.activeWindow.Load())
+}
+
+// SetActiveWindow changes the active window for subsequent discovery.
+// Sessions already being watched are kept.
+func (w *Watcher) SetActiveWindow(d time.Duration) {
+	if d > 0 {
+		w.activeWindow.Store(int64(d))
+	}
 }
 
 // GetSessions returns a copy of all watched sessions
@@ -309,20 +443,16 @@ func (w *Watcher) findSession(sessionID string) (*Session, error) {
 		mainFile = jsonlFiles[0]
 	}
 
-	return w.buildSession(mainFile)
+	return buildSession(mainFile)
 }
 
-func (w *Watcher) buildSession(mainFile string) (*Session, error) {
+func buildSession(mainFile string) (*Session, error) {
 	base := filepath.Base(mainFile)
 	id := strings.TrimSuffix(base, ".jsonl")
 
-	// Extract project path from parent directory name
-	projectDir := filepath.Base(filepath.Dir(mainFile))
-	projectPath := resolveProjectPath(projectDir)
-
 	session := &Session{
 		ID:              id,
-		ProjectPath:     projectPath,
+		ProjectPath:     sessionProjectPath(mainFile),
 		MainFile:        mainFile,
 		Subagents:       make(map[string]string),
 		SubagentTypes:   make(map[string]string),
@@ -358,23 +488,30 @@ func (w *Watcher) discoverActiveSessions() error {
 
 	var discovered []discoveredSession
 
-	err := filepath.Walk(w.claudeDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	err := filepath.WalkDir(w.claudeDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
 			return nil
 		}
-		if !isMainSessionFile(path, info) {
+		info, err := d.Info()
+		if err != nil || !isMainSessionFile(path, info) {
 			return nil
 		}
 
-		// Check if recently modified
-		if now.Sub(info.ModTime()) > w.activeWindow {
+		// Check if recently modified, or working, as Active has it
+		if !isActive(path, info, w.ActiveWindow(), w.ActiveWindow(), now) {
 			return nil
 		}
 
-		session, err := w.buildSession(path)
+		session, err := buildSession(path)
 		if err != nil {
 			return nil
 		}
+		w.sessionsMu.RLock()
+		skip := w.skipDiscovered(session)
+		w.sessionsMu.RUnlock()
+		if skip {
+			return nil
+		}
 
 		discovered = append(discovered, discoveredSession{session: session, modTime: info.ModTime()})
 		return nil
@@ -402,15 +539,94 @@ func (w *Watcher) SetSkipHistory(skip bool) {
 	w.skipHistory.Store(skip)
 }
 
-// RemoveSession removes a session from being watched
+// SetFullHistory makes the watcher replay every transcript from the start,
+// even past AutoSkipLineThreshold lines, for -at and -goto, which open the
+// stream at a moment or item anywhere in it. Call before Start.
+func (w *Watcher) SetFullHistory(full bool) {
+	w.fullHistory.Store(full)
+}
+
+// ResumeAt makes the watcher continue the given transcripts (path →
+// offset, as from Positions) where a previous process stopped, instead
+// of reading or skipping their history. Transcripts it doesn't list are
+// started as usual. Call before Start.
+func (w *Watcher) ResumeAt(positions map[string]int64) {
+	w.resume = positions
+}
+
+// Positions returns how far each transcript has been read. After Stop it
+// first waits out reads in progress, so the positions cover exactly the
+// items sent on Items.
+func (w *Watcher) Positions() map[string]int64 {
+	w.reading.Lock()
+	defer w.reading.Unlock()
+	w.filePosMu.RLock()
+	defer w.filePosMu.RUnlock()
+	return maps.Clone(w.filePositions)
+}
+
+// RemoveSession removes a session from being watched. It is not
+// rediscovered until RestoreSession.
 func (w *Watcher) RemoveSession(sessionID string) {
 	w.sessionsMu.Lock()
 	delete(w.sessions, sessionID)
+	w.removed[sessionID] = true
 	w.sessionsMu.Unlock()
 }
 
-// ToggleAutoDiscovery toggles automatic discovery of new sessions
+// RestoreSession resumes watching a session dropped with RemoveSession.
+// Reading picks up where it stopped, so lines written in the meantime
+// arrive on the next write.
+func (w *Watcher) RestoreSession(session *Session) {
+	w.sessionsMu.Lock()
+	delete(w.removed, session.ID)
+	w.sessions[session.ID] = session
+	w.sessionsMu.Unlock()
+	if w.useFsnotify {
+		w.registerSessionWatches(session)
+	}
+}
+
+// isRemoved reports whether sessionID was dropped with RemoveSession.
+func (w *Watcher) isRemoved(sessionID string) bool {
+	w.sessionsMu.RLock()
+	defer w.sessionsMu.RUnlock()
+	return w.removed[sessionID]
+}
+
+// ForgetRemoved lets a session dropped with RemoveSession be discovered
+// again.
+func (w *Watcher) ForgetRemoved(sessionID string) {
+	w.sessionsMu.Lock()
+	delete(w.removed, sessionID)
+	w.sessionsMu.Unlock()
+}
+
+// SetProjectFilter keeps sessions of matching projects out of discovery and
+// drops any already found.
+func (w *Watcher) SetProjectFilter(f *ProjectFilter) {
+	w.sessionsMu.Lock()
+	defer w.sessionsMu.Unlock()
+	w.ignore = f
+	for id, session := range w.sessions {
+		if f.Match(session.ProjectPath) {
+			delete(w.sessions, id)
+		}
+	}
+}
+
+// skipDiscovered reports whether a newly found session should be left
+// alone. Caller holds sessionsMu.
+func (w *Watcher) skipDiscovered(session *Session) bool {
+	return w.removed[session.ID] || w.ignore.Match(session.ProjectPath)
+}
+
+// ToggleAutoDiscovery toggles automatic discovery of new sessions. It has
+// no effect on a watcher made by OpenFile.
 func (w *Watcher) ToggleAutoDiscovery() {
+	if w.sessionFile != "" {
+		return
+	}
 	current := w.watchActive.Load()
 	w.watchActive.Store(!current)
 }
@@ -430,12 +646,18 @@ type ActivityInfo struct {
 
 // GetActivityInfo returns activity status for all watched sessions and agents
 // An agent is considered active if its file was modified within the given duration
+// (or, with the ActiveWork strategy, is working and was modified within
+// the active window).
 func (w *Watcher) GetActivityInfo(activeWithin time.Duration) []ActivityInfo {
 	var info []ActivityInfo
 	now := time.Now()
+	window := max(activeWithin, w.ActiveWindow())
 
 	w.sessionsMu.RLock()
 	defer w.sessionsMu.RUnlock()
+	if w.remote != "" {
+		return w.remoteActivity(activeWithin)
+	}
 
 	for _, session := range w.sessions {
 		// Check main file
@@ -443,7 +665,7 @@ func (w *Watcher) GetActivityInfo(activeWithin time.Duration) []ActivityInfo {
 			info = append(info, ActivityInfo{
 				SessionID:    session.ID,
 				AgentID:      "",
-				IsActive:     now.Sub(fi.ModTime()) < activeWithin,
+				IsActive:     isActive(session.MainFile, fi, activeWithin, window, now),
 				LastModified: fi.ModTime(),
 			})
 		}
@@ -455,7 +677,7 @@ func (w *Watcher) GetActivityInfo(activeWithin time.Duration) []ActivityInfo {
 				info = append(info, ActivityInfo{
 					SessionID:    session.ID,
 					AgentID:      agentID,
-					IsActive:     now.Sub(fi.ModTime()) < activeWithin,
+					IsActive:     isActive(path, fi, activeWithin, window, now),
 					LastModified: fi.ModTime(),
 				})
 			}
@@ -468,7 +690,9 @@ func (w *Watcher) GetActivityInfo(activeWithin time.Duration) []ActivityInfo {
 
 // Start begins watching for new content
 func (w *Watcher) Start() {
-	if w.useFsnotify {
+	if w.remote != "" {
+		go w.watchRemote()
+	} else if w.useFsnotify {
 		go w.watchLoopFsnotify()
 	} else {
 		go w.watchLoopPolling()
@@ -507,21 +731,35 @@ func (w *Watcher) getSessionsSnapshot() []*Session {
 
 // initializeSessionReading reads or skips existing session content at startup
 func (w *Watcher) initializeSessionReading(sessions []*Session) {
+	transcripts := sessionTranscripts(sessions)
+	if w.resume != nil {
+		var resumed, rest []transcript
+		w.filePosMu.Lock()
+		for _, t := range transcripts {
+			if pos, ok := w.resume[t.path]; ok {
+				w.filePositions[t.path] = pos
+				resumed = append(resumed, t)
+			} else {
+				rest = append(rest, t)
+			}
+		}
+		w.filePosMu.Unlock()
+		w.resume = nil
+		// Catch up on what was written while no process was watching.
+		w.readHistory(resumed)
+		transcripts = rest
+	}
 	shouldSkip := w.skipHistory.Load()
-	if !shouldSkip {
+	if !shouldSkip && w.sessionFile == "" && !w.fullHistory.Load() {
 		// Auto-skip if total line count exceeds threshold
-		totalLines := w.countTotalLines(sessions)
+		totalLines := countTotalLines(transcripts)
 		shouldSkip = totalLines > AutoSkipLineThreshold
 	}
 
 	if shouldSkip {
-		for _, session := range sessions {
-			w.skipToEndOfFiles(session)
-		}
+		w.skipToEndOfFiles(transcripts)
 	} else {
-		for _, session := range sessions {
-			w.readSessionFiles(session)
-		}
+		w.readHistory(transcripts)
 	}
 }
 
@@ -534,6 +772,7 @@ func (w *Watcher) handlePollTick() {
 	for _, session := range w.getSessionsSnapshot() {
 		w.checkForNewSubagents(session)
 		w.checkForBackgroundTasks(session)
+		w.checkSessionTodos(session)
 		w.readSessionFiles(session)
 	}
 }
@@ -767,13 +1006,15 @@ func (w *Watcher) fileContainsToolResult(path string, toolID string) bool {
 
 // watchLoopPolling is the original polling-based watch loop, used as fallback
 func (w *Watcher) watchLoopPolling() {
-	ticker := time.NewTicker(w.pollInterval)
+	defer crash.Recover()
+	ticker := time.NewTicker(w.PollInterval())
 	defer ticker.Stop()
 
 	cleanupTicker := time.NewTicker(CleanupInterval)
 	defer cleanupTicker.Stop()
 
 	w.initializeSessionReading(w.getSessionsSnapshot())
+	w.checkTodos()
 
 	for {
 		select {
@@ -781,6 +1022,9 @@ func (w *Watcher) watchLoopPolling() {
 			return
 		case <-cleanupTicker.C:
 			w.cleanupFilePositions()
+			w.cleanupTodoModTimes()
+		case <-w.pollReset:
+			ticker.Reset(w.PollInterval())
 		case <-ticker.C:
 			w.handlePollTick()
 		}
@@ -789,8 +1033,12 @@ func (w *Watcher) watchLoopPolling() {
 
 // watchLoopFsnotify uses OS-native filesystem notifications for real-time streaming
 func (w *Watcher) watchLoopFsnotify() {
+	defer crash.Recover()
 	cleanupTicker := time.NewTicker(CleanupInterval)
 	defer cleanupTicker.Stop()
+	// Paths past the inotify watch limit are polled (see pollFallback).
+	fallback := time.NewTicker(w.PollInterval())
+	defer fallback.Stop()
 
 	// Set up directory watches for discovery
 	if _, err := os.Stat(w.claudeDir); err == nil {
@@ -805,6 +1053,11 @@ func (w *Watcher) watchLoopFsnotify() {
 	for _, session := range sessions {
 		w.registerSessionWatches(session)
 	}
+	// Todo files live outside the projects directory.
+	if w.todosDir != "" {
+		w.addWatch(w.todosDir)
+		w.checkTodos()
+	}
 
 	for {
 		select {
@@ -821,13 +1074,17 @@ func (w *Watcher) watchLoopFsnotify() {
 			if !ok {
 				return
 			}
-			select {
-			case w.Errors <- fmt.Errorf("fsnotify: %w", err):
-			default:
-			}
+			w.reportError(fmt.Errorf("fsnotify: %w", err))
+
+		case <-w.pollReset:
+			fallback.Reset(w.PollInterval())
+
+		case <-fallback.C:
+			w.pollFallback()
 
 		case <-cleanupTicker.C:
 			w.cleanupFilePositions()
+			w.cleanupTodoModTimes()
 		}
 	}
 }
@@ -841,7 +1098,7 @@ func (w *Watcher) watchAncestorDirectory(target string) {
 			break
 		}
 		if _, err := os.Stat(parent); err == nil {
-			w.fsWatcher.Add(parent)
+			w.addWatch(parent)
 			return
 		}
 		dir = parent
@@ -855,7 +1112,7 @@ func (w *Watcher) addDirectoryWatches(root string) {
 			return nil
 		}
 		if info.IsDir() {
-			w.fsWatcher.Add(path)
+			w.addWatch(path)
 		}
 		return nil
 	})
@@ -874,9 +1131,33 @@ func (w *Watcher) registerSessionWatches(session *Session) {
 	}
 }
 
+// addWatch adds an fsnotify watch on a file or directory. Failures are
+// logged; past the inotify watch limit the path is polled instead, with a
+// warning the first time, since otherwise new activity silently stops
+// showing up.
+func (w *Watcher) addWatch(path string) {
+	err := w.fsWatcher.Add(path)
+	switch {
+	case err == nil:
+		w.setWatchMode(path, watchNotify)
+	case errors.Is(err, syscall.ENOSPC):
+		w.watchLimitReached(path)
+	default:
+		slog.Warn("cannot watch", "path", path, "err", err)
+	}
+}
+
+// reportError logs err and passes it on through Errors unless that is
+// full (see sendError).
+func (w *Watcher) reportError(err error) {
+	slog.Error("watcher", "err", err)
+	w.sendError(err)
+}
+
 // addFileWatch adds an fsnotify watch on a file and registers its context
 func (w *Watcher) addFileWatch(path, sessionID, agentID string) {
-	w.fsWatcher.Add(path)
+	w.addWatch(path)
+	w.checkReadable(transcript{path: path, sessionID: sessionID, agentID: agentID})
 
 	w.fileCtxMu.Lock()
 	w.fileContexts[path] = fileCtx{sessionID: sessionID, agentID: agentID}
@@ -887,6 +1168,13 @@ func (w *Watcher) addFileWatch(path, sessionID, agentID string) {
 func (w *Watcher) handleFsEvent(event fsnotify.Event) {
 	path := event.Name
 
+	if w.isTodoFile(path) {
+		if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+			w.readTodoFile(path)
+		}
+		return
+	}
+
 	if event.Has(fsnotify.Create) {
 		w.handleFsCreate(path)
 	}
@@ -905,7 +1193,7 @@ func (w *Watcher) handleFsCreate(path string) {
 
 	// New directory — add a watch so we catch files created inside it
 	if info.IsDir() {
-		w.fsWatcher.Add(path)
+		w.addWatch(path)
 		// Scan for files created before the watch was established.
 		// In-process agents (Agent Teams) create the subagents/ directory and
 		// write .jsonl files nearly simultaneously, so the file CREATE event
@@ -960,7 +1248,7 @@ func (w *Watcher) scanNewDirectory(path string) {
 		if entry.IsDir() {
 			// Add a watch and recurse: the CREATE event for this subdirectory
 			// may have been lost if it was created before the parent was watched.
-			w.fsWatcher.Add(fullPath)
+			w.addWatch(fullPath)
 			w.scanNewDirectory(fullPath)
 			continue
 		}
@@ -979,7 +1267,7 @@ func (w *Watcher) handleFsWrite(path string) {
 	w.fileCtxMu.RLock()
 	ctx, ok := w.fileContexts[path]
 	w.fileCtxMu.RUnlock()
-	if !ok {
+	if !ok || w.isRemoved(ctx.sessionID) {
 		return // not a file we're tracking
 	}
 
@@ -1012,13 +1300,13 @@ func (w *Watcher) handleNewSessionFile(path string) {
 		return
 	}
 
-	session, err := w.buildSession(path)
+	session, err := buildSession(path)
 	if err != nil {
 		return
 	}
 
 	w.sessionsMu.Lock()
-	if _, exists := w.sessions[session.ID]; exists {
+	if _, exists := w.sessions[session.ID]; exists || w.skipDiscovered(session) {
 		w.sessionsMu.Unlock()
 		return
 	}
@@ -1031,6 +1319,7 @@ func (w *Watcher) handleNewSessionFile(path string) {
 	case w.NewSession <- NewSessionMsg{SessionID: session.ID, ProjectPath: session.ProjectPath}:
 	default:
 	}
+	w.checkSessionTodos(session)
 
 	// buildSession may have found subagents that already existed on disk.
 	// Emit NewAgentMsg for each so the TUI shows them. Without this, the
@@ -1184,7 +1473,7 @@ func (w *Watcher) checkForNewSessions() {
 	// Collect candidates first, then decide which to add
 	var candidates []discoveredSession
 
-	filepath.Walk(w.claudeDir, func(path string, info os.FileInfo, err error) error {
+	filepath.WalkDir(w.claudeDir, func(path string, d fs.DirEntry, err error) error {
 		// Check for context cancellation to avoid goroutine leak
 		select {
 		case <-w.ctx.Done():
@@ -1192,15 +1481,16 @@ func (w *Watcher) checkForNewSessions() {
 		default:
 		}
 
-		if err != nil {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
 			return nil
 		}
-		if !isMainSessionFile(path, info) {
+		info, err := d.Info()
+		if err != nil || !isMainSessionFile(path, info) {
 			return nil
 		}
 
-		// Check if recently modified
-		if now.Sub(info.ModTime()) > w.activeWindow {
+		// Check if recently modified, or working, as Active has it
+		if !isActive(path, info, w.ActiveWindow(), w.ActiveWindow(), now) {
 			return nil
 		}
 
@@ -1209,13 +1499,14 @@ func (w *Watcher) checkForNewSessions() {
 
 		w.sessionsMu.RLock()
 		_, exists := w.sessions[id]
+		removed := w.removed[id]
 		w.sessionsMu.RUnlock()
 
-		if exists {
+		if exists || removed {
 			return nil
 		}
 
-		session, err := w.buildSession(path)
+		session, err := buildSession(path)
 		if err != nil {
 			return nil
 		}
@@ -1240,7 +1531,7 @@ func (w *Watcher) checkForNewSessions() {
 			break
 		}
 
-		if _, exists := w.sessions[c.session.ID]; exists {
+		if _, exists := w.sessions[c.session.ID]; exists || w.skipDiscovered(c.session) {
 			continue
 		}
 
@@ -1293,17 +1584,12 @@ func (w *Watcher) checkForNewSubagents(session *Session) {
 	}
 }
 
-func (w *Watcher) countTotalLines(sessions []*Session) int {
-	var total int
-	for _, session := range sessions {
-		total += countFileLines(session.MainFile)
-		session.mu.RLock()
-		for _, path := range session.Subagents {
-			total += countFileLines(path)
-		}
-		session.mu.RUnlock()
-	}
-	return total
+func countTotalLines(transcripts []transcript) int {
+	var total atomic.Int64
+	forEachParallel(len(transcripts), func(i int) {
+		total.Add(int64(countFileLines(transcripts[i].path)))
+	})
+	return int(total.Load())
 }
 
 // countFileLines counts newlines in a file without parsing content
@@ -1330,28 +1616,18 @@ func countFileLines(path string) int {
 	return count
 }
 
-func (w *Watcher) skipToEndOfFiles(session *Session) {
-	// Set position to near end of main file, keeping last N lines
-	mainPos := findPositionForLastNLines(session.MainFile, KeepRecentLines)
-
-	// Get subagent positions
-	session.mu.RLock()
-	subagentPaths := make([]string, 0, len(session.Subagents))
-	for _, path := range session.Subagents {
-		subagentPaths = append(subagentPaths, path)
-	}
-	session.mu.RUnlock()
-
-	subagentPositions := make(map[string]int64, len(subagentPaths))
-	for _, path := range subagentPaths {
-		subagentPositions[path] = findPositionForLastNLines(path, KeepRecentLines)
-	}
+// skipToEndOfFiles positions each transcript to keep only its last
+// KeepRecentLines lines.
+func (w *Watcher) skipToEndOfFiles(transcripts []transcript) {
+	positions := make([]int64, len(transcripts))
+	forEachParallel(len(transcripts), func(i int) {
+		positions[i] = findPositionForLastNLines(transcripts[i].path, KeepRecentLines)
+	})
 
 	// Write all positions under lock
 	w.filePosMu.Lock()
-	w.filePositions[session.MainFile] = mainPos
-	for path, pos := range subagentPositions {
-		w.filePositions[path] = pos
+	for i, t := range transcripts {
+		w.filePositions[t.path] = positions[i]
 	}
 	w.filePosMu.Unlock()
 }
@@ -1413,77 +1689,99 @@ func (w *Watcher) readSessionFiles(session *Session) {
 }
 
 func (w *Watcher) readFile(path string, sessionID string, agentID string, agentType string) {
-	file, err := os.Open(path)
+	w.readFrom(transcript{path, sessionID, agentID, agentType}, w.sendItem)
+}
+
+// readFrom decodes a transcript from its last known position, passing each
+// labelled item to yield, and saves the new position. If yield stops
+// early, the position is the start of the line it refused, so nothing
+// yield didn't take is skipped.
+func (w *Watcher) readFrom(t transcript, yield func(parser.StreamItem) bool) {
+	w.reading.RLock()
+	defer w.reading.RUnlock()
+	pos, done := w.decodeFrom(t, func(item parser.StreamItem, lineStart int64) bool {
+		if !yield(item) {
+			w.filePosMu.Lock()
+			w.filePositions[t.path] = lineStart
+			w.filePosMu.Unlock()
+			return false
+		}
+		return true
+	})
+	if done {
+		w.filePosMu.Lock()
+		w.filePositions[t.path] = pos
+		w.filePosMu.Unlock()
+	}
+}
+
+// decodeFrom decodes a transcript from its last known position, passing
+// each labelled item to yield with the offset of the line it came from.
+// It reports the offset it read up to, and false if the file couldn't be
+// opened, the watcher stopped, or yield stopped early. It saves no
+// position; callers hold w.reading and save one for what they delivered.
+func (w *Watcher) decodeFrom(t transcript, yield func(item parser.StreamItem, lineStart int64) bool) (int64, bool) {
+	if w.ctx.Err() != nil {
+		return 0, false
+	}
+	file, err := os.Open(t.path)
+	w.noteOpen(t, err)
 	if err != nil {
-		return
+		return 0, false
 	}
 	defer file.Close()
 
 	// Seek to last known position
 	w.filePosMu.RLock()
-	pos, exists := w.filePositions[path]
+	pos, exists := w.filePositions[t.path]
 	w.filePosMu.RUnlock()
 	if exists {
 		file.Seek(pos, 0)
 	}
 
-	scanner := bufio.NewScanner(file)
-	// Increase buffer size for large JSON lines
-	buf := make([]byte, 0, ScannerInitBufferSize)
-	scanner.Buffer(buf, ScannerMaxBufferSize)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		items, err := parser.ParseLine(line)
-		if err != nil {
-			select {
-			case w.Errors <- err:
-			default:
-			}
-			continue
+	// Follow, so a line Claude is halfway through writing is read whole
+	// on the next pass instead of being lost.
+	dec := parser.NewDecoder(file)
+	dec.Follow()
+	var lineStart, lineEnd int64 // the line the current item came from
+	for item := range dec.All() {
+		if dec.Offset() != lineEnd {
+			lineStart, lineEnd = lineEnd, dec.Offset()
 		}
-
-		for _, item := range items {
-			// Set session ID
-			item.SessionID = sessionID
-
-			// Set agent ID and name from context
-			if agentID != "" {
-				if item.AgentID == "" {
-					item.AgentID = agentID
-				}
-				if agentType != "" {
-					if idx := strings.LastIndex(agentType, ":"); idx >= 0 && idx < len(agentType)-1 {
-						item.AgentName = agentType[idx+1:]
-					} else {
-						item.AgentName = agentType
-					}
-				} else if item.AgentName == "" || strings.HasPrefix(item.AgentName, "Agent-") {
-					item.AgentName = fmt.Sprintf("Agent-%s", agentID[:min(AgentIDDisplayLength, len(agentID))])
-				}
-			}
-
-			select {
-			case w.Items <- item:
-			case <-w.ctx.Done():
-				return
-			}
+		labelItem(&item, t.sessionID, t.agentID, t.agentType)
+		crash.Record(item)
+		if !yield(item, pos+lineStart) {
+			return pos + lineStart, false
 		}
 	}
+	if n := dec.Skipped(); n > 0 {
+		slog.Warn("skipped unparseable lines", "file", t.path, "count", n)
+	}
+	if err := dec.Err(); err != nil {
+		w.reportError(fmt.Errorf("error reading %s: %w", t.path, err))
+	}
+	return pos + dec.Offset(), true
+}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		select {
-		case w.Errors <- fmt.Errorf("scanner error reading %s: %w", path, err):
-		default:
+// labelItem sets the session ID and, for subagent files, the agent ID and
+// display name that the transcript lines themselves don't carry.
+func labelItem(item *parser.StreamItem, sessionID, agentID, agentType string) {
+	item.SessionID = sessionID
+	if agentID == "" {
+		return
+	}
+	if item.AgentID == "" {
+		item.AgentID = agentID
+	}
+	if agentType != "" {
+		if idx := strings.LastIndex(agentType, ":"); idx >= 0 && idx < len(agentType)-1 {
+			item.AgentName = agentType[idx+1:]
+		} else {
+			item.AgentName = agentType
 		}
+	} else if item.AgentName == "" || strings.HasPrefix(item.AgentName, "Agent-") {
+		item.AgentName = fmt.Sprintf("Agent-%s", agentID[:min(AgentIDDisplayLength, len(agentID))])
 	}
-
-	// Update position
-	newPos, _ := file.Seek(0, 1)
-	w.filePosMu.Lock()
-	w.filePositions[path] = newPos
-	w.filePosMu.Unlock()
 }
 
 // cleanupFilePositions removes entries for files that no longer exist
@@ -1516,32 +1814,35 @@ func listSessionsFiltered(limit int, activeWithin time.Duration) ([]SessionInfo,
 	var sessions []SessionInfo
 	now := time.Now()
 
-	err = filepath.Walk(claudeDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !isMainSessionFile(path, info) {
-			return nil
-		}
+	withIndex(func(ix *index) {
+		seen := make(map[string]bool)
+		err = filepath.WalkDir(claudeDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || !isMainSessionFile(path, info) {
+				return nil
+			}
+			seen[path] = true
 
-		// If filtering by active time, skip old sessions
-		if activeWithin > 0 && now.Sub(info.ModTime()) > activeWithin {
-			return nil
-		}
+			// If filtering by active time, skip old sessions
+			if activeWithin > 0 && !isActive(path, info, activeWithin, activeWithin, now) {
+				return nil
+			}
 
-		// Extract project path from parent directory name
-		basename := filepath.Base(path)
-		projectDir := filepath.Base(filepath.Dir(path))
-		projectPath := resolveProjectPath(projectDir)
-
-		sessions = append(sessions, SessionInfo{
-			ID:          strings.TrimSuffix(basename, ".jsonl"),
-			Path:        path,
-			ProjectPath: projectPath,
-			Modified:    info.ModTime(),
-			IsActive:    now.Sub(info.ModTime()) < RecentActivityThreshold,
+			sessions = append(sessions, SessionInfo{
+				ID:          strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+				Path:        path,
+				ProjectPath: ix.sessionProject(path),
+				Modified:    info.ModTime(),
+				IsActive:    isActive(path, info, RecentActivityThreshold, DefaultActiveWindow, now),
+			})
+			return nil
 		})
-		return nil
+		if err == nil {
+			ix.prune(seen)
+		}
 	})
 	if err != nil {
 		return nil, err
@@ -1559,6 +1860,22 @@ func listSessionsFiltered(limit int, activeWithin time.Duration) ([]SessionInfo,
 	return sessions, nil
 }
 
+// LoadMeta fills in each session's Meta from the session index, reading
+// only what the transcripts gained since they were last indexed. Sessions
+// whose transcript can't be read are left without.
+func LoadMeta(sessions []SessionInfo) {
+	withIndex(func(ix *index) {
+		for i := range sessions {
+			info, err := os.Stat(sessions[i].Path)
+			if err != nil {
+				continue
+			}
+			meta := ix.session(sessions[i].Path, info)
+			sessions[i].Meta = &meta
+		}
+	})
+}
+
 // SessionInfo contains basic info about a session
 type SessionInfo struct {
 	ID          string
@@ -1566,4 +1883,5 @@ type SessionInfo struct {
 	ProjectPath string
 	Modified    time.Time
 	IsActive    bool
+	Meta        *SessionMeta // nil until LoadMeta
 }
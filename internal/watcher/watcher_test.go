@@ -2,8 +2,10 @@ package watcher
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -218,6 +220,29 @@ func TestGetClaudeProjectsDirDefault(t *testing.T) {
 	}
 }
 
+func TestNewWatchesListedSessions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAUDE_HOME", home)
+	projectDir := filepath.Join(home, "projects", "-test-project")
+	os.MkdirAll(projectDir, 0755)
+	for _, id := range []string{"aaa111", "bbb222", "ccc333"} {
+		os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(""), 0644)
+	}
+
+	w, err := New([]string{"aaa111", "ccc333"}, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	sessions := w.GetSessions()
+	if len(sessions) != 2 || sessions["aaa111"] == nil || sessions["ccc333"] == nil {
+		t.Errorf("sessions = %v, want aaa111 and ccc333", sessions)
+	}
+	if w.IsAutoDiscoveryEnabled() {
+		t.Error("auto-discovery on with listed sessions")
+	}
+}
+
 // mockFileInfo implements os.FileInfo for testing
 type mockFileInfo struct {
 	name  string
@@ -240,21 +265,30 @@ func newTestWatcher(t *testing.T, claudeDir string, useFsnotify bool) *Watcher {
 
 	w := &Watcher{
 		claudeDir:         claudeDir,
-		pollInterval:      100 * time.Millisecond,
 		sessions:          make(map[string]*Session),
+		removed:           make(map[string]bool),
 		filePositions:     make(map[string]int64),
 		Items:             make(chan parser.StreamItem, ItemChannelBuffer),
 		Errors:            make(chan error, ErrorChannelBuffer),
 		NewAgent:          make(chan NewAgentMsg, ErrorChannelBuffer),
 		NewSession:        make(chan NewSessionMsg, ErrorChannelBuffer),
 		NewBackgroundTask: make(chan NewBackgroundTaskMsg, ErrorChannelBuffer),
+		Todos:             make(chan TodosMsg, ErrorChannelBuffer),
+		Warnings:          make(chan string, ErrorChannelBuffer),
+		FileErrors:        make(chan FileErrorMsg, ErrorChannelBuffer),
+		pollReset:         make(chan struct{}, 1),
 		ctx:               ctx,
 		cancel:            cancel,
-		activeWindow:      DefaultActiveWindow,
 		fileContexts:      make(map[string]fileCtx),
 		debounceTimers:    make(map[string]*time.Timer),
+		todoModTimes:      make(map[string]time.Time),
+		watchModes:        make(map[string]watchMode),
+		unreadable:        make(map[string]bool),
 	}
 
+	w.pollInterval.Store(int64(100 * time.Millisecond))
+	w.activeWindow.Store(int64(DefaultActiveWindow))
+
 	if useFsnotify {
 		fsw, err := fsnotify.NewWatcher()
 		if err != nil {
@@ -521,6 +555,75 @@ func TestStopCancelsDebounceTimers(t *testing.T) {
 	// (we just verify Stop() doesn't panic with active timers)
 }
 
+func TestRemovedSessionNotRediscovered(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-test-project")
+	os.MkdirAll(projectDir, 0755)
+	os.WriteFile(filepath.Join(projectDir, "sess005.jsonl"), []byte(""), 0644)
+
+	w := newTestWatcher(t, tmpDir, false)
+	w.checkForNewSessions()
+	session := w.GetSessions()["sess005"]
+	if session == nil {
+		t.Fatal("session not discovered")
+	}
+	<-w.NewSession
+
+	w.RemoveSession("sess005")
+	w.checkForNewSessions()
+	if _, ok := w.GetSessions()["sess005"]; ok {
+		t.Fatal("removed session was rediscovered")
+	}
+
+	w.RestoreSession(session)
+	if w.GetSessions()["sess005"] != session {
+		t.Fatal("RestoreSession did not resume watching")
+	}
+	select {
+	case msg := <-w.NewSession:
+		t.Errorf("restore announced session %q as new", msg.SessionID)
+	default:
+	}
+}
+
+func TestOpenFileReplaysWholeTranscript(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+	os.MkdirAll(filepath.Join(dir, "sess006", "subagents"), 0755)
+	jsonLine := `{"type":"assistant","message":{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"thinking","thinking":"replay"}],"model":"claude-sonnet-4-20250514","stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}}` + "\n"
+	path := filepath.Join(dir, "sess006.jsonl")
+	// More than AutoSkipLineThreshold lines: a live watcher would skip them.
+	os.WriteFile(path, []byte(strings.Repeat(jsonLine, AutoSkipLineThreshold+20)), 0644)
+	os.WriteFile(filepath.Join(dir, "sess006", "subagents", "agent-a1.jsonl"), []byte(jsonLine), 0644)
+
+	w, err := OpenFile(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := w.GetSessions()["sess006"]
+	if session == nil || session.ProjectPath != dir || session.Subagents["a1"] == "" {
+		t.Fatalf("session = %+v", session)
+	}
+	w.ToggleAutoDiscovery()
+	if w.IsAutoDiscoveryEnabled() {
+		t.Error("auto-discovery turned on for an opened file")
+	}
+
+	w.Start()
+	defer w.Stop()
+	want := AutoSkipLineThreshold + 21
+	for got := 0; got < want; got++ {
+		select {
+		case <-w.Items:
+		case <-time.After(time.Second):
+			t.Fatalf("got %d items, want %d", got, want)
+		}
+	}
+
+	if _, err := OpenFile(dir, 0); err == nil {
+		t.Error("OpenFile accepted a directory")
+	}
+}
+
 func TestPollingFallbackStillWorks(t *testing.T) {
 	// Create a fake Claude dir structure with a session
 	tmpDir := t.TempDir()
@@ -554,6 +657,56 @@ func TestPollingFallbackStillWorks(t *testing.T) {
 	}
 }
 
+func TestSetPollIntervalWhileRunning(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-test-project")
+	os.MkdirAll(projectDir, 0755)
+	line := func(text string) string {
+		return `{"type":"assistant","message":{"id":"msg_` + text + `","type":"message","role":"assistant","content":[{"type":"thinking","thinking":"` + text + `"}],"model":"claude-sonnet-4-20250514","stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}}` + "\n"
+	}
+	sessionFile := filepath.Join(projectDir, "sess005.jsonl")
+	os.WriteFile(sessionFile, []byte(line("first")), 0644)
+
+	w := newTestWatcher(t, tmpDir, false)
+	w.pollInterval.Store(int64(time.Hour))
+	w.sessions["sess005"] = &Session{
+		ID:              "sess005",
+		MainFile:        sessionFile,
+		Subagents:       make(map[string]string),
+		BackgroundTasks: make(map[string]*BackgroundTask),
+	}
+	go w.watchLoopPolling()
+
+	// The first line arrives from the initial read, before any tick.
+	select {
+	case <-w.Items:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the initial read")
+	}
+
+	w.SetPollInterval(time.Millisecond)
+	if got := w.PollInterval(); got != MinPollInterval {
+		t.Errorf("PollInterval = %v, want clamped to %v", got, MinPollInterval)
+	}
+	f, _ := os.OpenFile(sessionFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(line("second"))
+	f.Close()
+
+	select {
+	case item := <-w.Items:
+		if item.Content != "second" {
+			t.Errorf("got %q, want the appended line", item.Content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("an hour-long ticker was not reset by SetPollInterval")
+	}
+
+	w.SetActiveWindow(0)
+	if got := w.ActiveWindow(); got != DefaultActiveWindow {
+		t.Errorf("SetActiveWindow(0) changed the window to %v", got)
+	}
+}
+
 func TestNewBackgroundTaskViaFsnotify(t *testing.T) {
 	tmpDir := t.TempDir()
 	projectDir := filepath.Join(tmpDir, "-test-project")
@@ -629,3 +782,115 @@ func TestConcurrentDebounce(t *testing.T) {
 		t.Errorf("got %d debounce timers, want at most %d", count, len(paths))
 	}
 }
+
+func TestReadFileResumesPartialLine(t *testing.T) {
+	w := newTestWatcher(t, t.TempDir(), false)
+	path := filepath.Join(t.TempDir(), "sess007.jsonl")
+	jsonLine := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"interrupted"}]}}` + "\n"
+
+	// Claude is halfway through writing the line.
+	os.WriteFile(path, []byte(jsonLine[:30]), 0644)
+	w.readFile(path, "sess007", "", "")
+	if len(w.Items) != 0 {
+		t.Fatalf("got %d items from a partial line", len(w.Items))
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(jsonLine[30:])
+	f.Close()
+	w.readFile(path, "sess007", "", "")
+	if len(w.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(w.Items))
+	}
+	if item := <-w.Items; item.Content != "interrupted" {
+		t.Errorf("content = %q", item.Content)
+	}
+	if pos := w.filePositions[path]; pos != int64(len(jsonLine)) {
+		t.Errorf("position = %d, want %d", pos, len(jsonLine))
+	}
+}
+
+func TestResumeAt(t *testing.T) {
+	dir := t.TempDir()
+	line := func(text string) string {
+		return `{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"` + text + `"}]}}` + "\n"
+	}
+	resumed, fresh := filepath.Join(dir, "sess008.jsonl"), filepath.Join(dir, "sess009.jsonl")
+	os.WriteFile(resumed, []byte(line("shown before")+line("written during the restart")), 0644)
+	os.WriteFile(fresh, []byte(line("history")), 0644)
+
+	w := newTestWatcher(t, dir, false)
+	w.ResumeAt(map[string]int64{resumed: int64(len(line("shown before")))})
+	w.initializeSessionReading([]*Session{
+		{ID: "sess008", MainFile: resumed},
+		{ID: "sess009", MainFile: fresh},
+	})
+
+	var got []string
+	for len(w.Items) > 0 {
+		got = append(got, (<-w.Items).Content)
+	}
+	slices.Sort(got)
+	if want := []string{"history", "written during the restart"}; !slices.Equal(got, want) {
+		t.Errorf("items = %q, want %q", got, want)
+	}
+	if pos := w.Positions()[resumed]; pos != int64(len(line("shown before")+line("written during the restart"))) {
+		t.Errorf("position = %d", pos)
+	}
+}
+
+func TestPositionsCoverOnlySentHistory(t *testing.T) {
+	dir := t.TempDir()
+	line := func(text string) string {
+		return `{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"` + text + `"}]}}` + "\n"
+	}
+	path := filepath.Join(dir, "sess010.jsonl")
+	lines := []string{line("one"), line("two"), line("three")}
+	os.WriteFile(path, []byte(strings.Join(lines, "")), 0644)
+
+	w := newTestWatcher(t, dir, false)
+	w.Items = make(chan parser.StreamItem, 1) // history outruns the reader
+	done := make(chan struct{})
+	go func() {
+		w.readHistory([]transcript{{path: path, sessionID: "sess010"}})
+		close(done)
+	}()
+	<-w.Items
+	w.cancel() // a :restart during catch-up
+	<-done
+
+	// Whatever wasn't taken yet is still queued; the position must stop
+	// right after the last item that made it onto Items.
+	delivered := 1 + len(w.Items)
+	if pos, want := w.Positions()[path], int64(len(strings.Join(lines[:delivered], ""))); pos != want {
+		t.Errorf("position = %d after %d delivered items, want %d", pos, delivered, want)
+	}
+}
+
+func TestFullHistoryOverridesAutoSkip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sess011.jsonl")
+	var b strings.Builder
+	for i := range AutoSkipLineThreshold + 50 {
+		fmt.Fprintf(&b, `{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"step %d"}]}}`+"\n", i)
+	}
+	os.WriteFile(path, []byte(b.String()), 0644)
+	sessions := []*Session{{ID: "sess011", MainFile: path}}
+
+	w := newTestWatcher(t, dir, false)
+	w.initializeSessionReading(sessions)
+	if len(w.Items) != 0 || w.Positions()[path] == 0 {
+		t.Errorf("history of %d lines wasn't auto-skipped", AutoSkipLineThreshold+50)
+	}
+
+	w = newTestWatcher(t, dir, false)
+	w.Items = make(chan parser.StreamItem, 2*AutoSkipLineThreshold)
+	w.SetFullHistory(true)
+	w.initializeSessionReading(sessions)
+	if got := len(w.Items); got != AutoSkipLineThreshold+50 {
+		t.Fatalf("full history gave %d items, want %d", got, AutoSkipLineThreshold+50)
+	}
+	if item := <-w.Items; item.Content != "step 0" {
+		t.Errorf("first item = %q, want step 0", item.Content)
+	}
+}
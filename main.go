@@ -5,21 +5,53 @@
 //
 //	claude-esp              # Watch all active sessions
 //	claude-esp -n           # Skip history, live only
-//	claude-esp -s <ID>      # Watch a specific session
+//	claude-esp -s <ID>      # Watch a specific session (repeatable)
 //	claude-esp -a           # List active sessions
 //	claude-esp -l           # List recent sessions
+//	claude-esp models       # Show the model pricing table
+//	claude-esp serve        # Headless: serve the stream over HTTP
+//	claude-esp push -to h:p # Send this machine's stream to a serve daemon
+//	claude-esp mcp          # MCP server exposing session tools on stdio
+//	claude-esp status       # Session heartbeats (state, idle time, tool)
+//	claude-esp open <file>  # Browse a session .jsonl from any path
+//	claude-esp update       # Install the latest release
+//	claude-esp doctor       # Check the environment when nothing shows up
+//	claude-esp bench <file> # Measure parser and renderer throughput
+//	claude-esp export <ID>  # Write a session as Markdown or CSV
 //
 // See https://github.com/phiat/claude-esp for full documentation.
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/phiat/claude-esp/internal/config"
+	"github.com/phiat/claude-esp/internal/crash"
+	"github.com/phiat/claude-esp/internal/deeplink"
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/handoff"
+	"github.com/phiat/claude-esp/internal/instance"
+	"github.com/phiat/claude-esp/internal/logging"
+	"github.com/phiat/claude-esp/internal/osc"
 	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/schema"
+	"github.com/phiat/claude-esp/internal/server"
+	"github.com/phiat/claude-esp/internal/share"
+	"github.com/phiat/claude-esp/internal/sink"
+	"github.com/phiat/claude-esp/internal/trigger"
 	"github.com/phiat/claude-esp/internal/tui"
 	"github.com/phiat/claude-esp/internal/watcher"
 )
@@ -29,22 +61,81 @@ var (
 )
 
 func main() {
+	// Diagnostics stay off the terminal until startLogging points them at
+	// the log file.
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	useDirs()
+
+	// Subcommands come before flag parsing so they can own their flags.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "models":
+			os.Exit(runModels(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "push":
+			os.Exit(runPush(os.Args[2:]))
+		case "mcp":
+			os.Exit(runMCP(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "open":
+			os.Exit(runOpen(os.Args[2:]))
+		case "update":
+			os.Exit(runUpdate(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		}
+	}
+
 	// Flags
-	sessionID := flag.String("s", "", "Watch a specific session by ID")
+	var sessionIDs stringList
+	flag.Var(&sessionIDs, "s", "Watch a specific session by ID (repeatable)")
+	sessionsFile := flag.String("sessions-file", "", "Watch the sessions listed in this file, one ID per line")
 	listSessions := flag.Bool("l", false, "List recent sessions")
-	listActive := flag.Bool("a", false, "List active sessions (modified in last 5 min)")
+	listActive := flag.Bool("a", false, "List active sessions (modified within the active window)")
 	skipHistory := flag.Bool("n", false, "Start from newest (skip history, live only)")
-	pollMs := flag.Int("p", 500, "Poll interval in milliseconds (min 100)")
-	activeWindowStr := flag.String("w", "5m", "Active window duration (e.g. 30s, 2m, 5m)")
+	atStr := flag.String("at", "", "Open the stream at this moment: a time (2025-06-01T14:03:00Z, 14:03) or a duration ago (-15m)")
+	gotoStr := flag.String("goto", "", "Open the stream at the item with this ID (the id in JSON output and exports)")
+	pollMs := flag.Int("p", 0, "Poll interval in milliseconds (default 500, min 100)")
+	pollIntervalStr := flag.String("poll-interval", "", "Poll interval when fsnotify is unavailable (default 500ms, min 100ms)")
+	var activeWindowStr string
+	flag.StringVar(&activeWindowStr, "w", "", "Active window duration (default 5m, e.g. 30s, 2m)")
+	flag.StringVar(&activeWindowStr, "active-window", "", "Same as -w")
+	activityThresholdStr := flag.String("activity-threshold", "", "How recently a session or agent must write to show as active (default 30s)")
+	lowPower := flag.Bool("low-power", false, "Battery-friendly mode: slower polling, fewer wakeups, paused while the terminal is unfocused")
 	maxSessions := flag.Int("m", 0, "Max sessions to show in tree (0=unlimited)")
 	collapseAfterStr := flag.String("c", "0", "Auto-collapse sessions inactive ≥ this duration (0=disabled, e.g. 2m)")
+	configPath := flag.String("config", "", "Config file (default config.toml in the config directory)")
+	filterExpr := flag.String("filter", "", "Show only items matching this expression, e.g. 'type in (tool_input, tool_output) and tool != Read'")
+	fullOutput := flag.Bool("full", false, "Show items in full instead of truncating long ones (Z toggles)")
+	applyASCII := asciiFlag(flag.CommandLine)
+	colors := colorsFlag(flag.CommandLine)
+	screenReader := flag.Bool("screen-reader", false, "Instead of the TUI, print the stream as labelled plain lines for screen readers (also [accessibility] screen_reader)")
+	verbosity := flag.String("verbosity", "", "How much of each item -screen-reader reads out: brief, normal (default) or full")
+	pipeCmd := flag.String("pipe", "", "Pipe the filtered stream as plain text to this shell command's stdin")
+	var sinkSpecs stringList
+	flag.Var(&sinkSpecs, "sink", "Publish items as NDJSON to unix://<socket> or a FIFO path, ?feed=edits for edit events (repeatable)")
+	httpAddr := flag.String("http", "", "Also serve the stream over HTTP on this address (e.g. 127.0.0.1:7777)")
+	shareAddr := flag.String("share", "", "Mirror the TUI read-only to telnet viewers on this address (e.g. :2222)")
+	takeover := flag.Bool("takeover", false, "If another claude-esp is watching, stop it and watch here instead of asking")
+	attachURL := flag.String("attach", "", "Show the stream of a claude-esp serving -http at this URL instead of reading transcripts")
+	logLevel := logLevelFlag(flag.CommandLine)
+	applyDirs := dirFlags(flag.CommandLine)
 	debugAll := flag.Bool("D", false, "Debug: surface raw type:subtype for every JSONL line type the parser would otherwise drop")
+	lenient := flag.Bool("lenient", false, "Show lines the parser can't read (malformed JSON, unexpected message shape) as items instead of dropping them")
 	showVersion := flag.Bool("v", false, "Show version")
+	showSchema := flag.Bool("schema", false, "Print the JSON Schema of the NDJSON, HTTP and hook output and exit")
 	showHelp := flag.Bool("h", false, "Show help")
 
 	flag.Parse()
 
 	parser.DebugAll = *debugAll
+	parser.Lenient = *lenient
 
 	if *showHelp {
 		printHelp()
@@ -55,13 +146,78 @@ func main() {
 		fmt.Printf("claude-esp v%s\n", version)
 		return
 	}
+	if *showSchema {
+		os.Stdout.Write(schema.JSON())
+		return
+	}
+	applyDirs()
+
+	stopLogging, err := startLogging(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer stopLogging()
+	defer crash.Recover()
 
-	// Parse active window duration
-	activeWindow, err := time.ParseDuration(*activeWindowStr)
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Terminal.Colors = cmp.Or(*colors, cfg.Terminal.Colors)
+	if err := tui.SetColors(cfg.Terminal.Colors); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if flagGiven(flag.CommandLine, "screen-reader") {
+		cfg.Accessibility.ScreenReader = *screenReader
+	}
+	cfg.Accessibility.Verbosity = cmp.Or(*verbosity, cfg.Accessibility.Verbosity, "normal")
+	if !slices.Contains(config.Verbosities, cfg.Accessibility.Verbosity) {
+		fmt.Fprintf(os.Stderr, "Error: unknown verbosity %q (want %s)\n", cfg.Accessibility.Verbosity, strings.Join(config.Verbosities, ", "))
+		os.Exit(1)
+	}
+	watcher.PathMap = cfg.PathMap()
+	watcher.Active = cfg.ActiveStrategy()
+	parser.ToolFormats = cfg.ToolFormatTable()
+	parser.ToolTemplates = cfg.ToolTemplates()
+	if err := applyWatchFlags(&cfg.Watch, *pollMs, *pollIntervalStr, activeWindowStr, *activityThresholdStr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *lowPower {
+		cfg.Watch.LowPower = true
+	}
+	pollInterval := cfg.PollInterval()
+	activeWindow := cfg.ActiveWindow()
+	itemFilter, err := filter.Parse(*filterExpr)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid active window duration %q: %v\n", *activeWindowStr, err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	var at time.Time
+	if *atStr != "" {
+		if *skipHistory {
+			fmt.Fprintln(os.Stderr, "Error: -at opens the stream in its history; it can't be used with -n")
+			os.Exit(1)
+		}
+		if at, err = deeplink.ParseAt(*atStr, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var gotoID string
+	if *gotoStr != "" {
+		if *skipHistory || *atStr != "" {
+			fmt.Fprintln(os.Stderr, "Error: -goto opens the stream at an item in its history; it can't be used with -n or -at")
+			os.Exit(1)
+		}
+		if gotoID, err = deeplink.ParseItemID(*gotoStr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Parse collapse-after duration (0 = disabled)
 	var collapseAfter time.Duration
@@ -83,13 +239,14 @@ func main() {
 			fmt.Printf("No active sessions (none modified in last %s)\n", activeWindow)
 			return
 		}
+		watcher.LoadMeta(sessions)
 		fmt.Println("Active sessions:")
 		for _, s := range sessions {
 			status := "  "
 			if s.IsActive {
 				status = "● "
 			}
-			fmt.Printf("  %s%s  %s\n", status, s.ID[:min(12, len(s.ID))], truncatePath(s.ProjectPath, 40))
+			fmt.Printf("  %s%s  %-40s  %s\n", status, s.ID[:min(12, len(s.ID))], truncatePath(s.ProjectPath, 40), describeMeta(s.Meta))
 		}
 		return
 	}
@@ -100,33 +257,413 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		watcher.LoadMeta(sessions)
 		fmt.Println("Recent sessions:")
 		for _, s := range sessions {
 			status := "  "
 			if s.IsActive {
 				status = "● "
 			}
-			fmt.Printf("  %s%s  %s  %s\n", status, s.Modified.Format("15:04:05"), s.ID[:min(12, len(s.ID))], truncatePath(s.ProjectPath, 30))
+			fmt.Printf("  %s%s  %s  %-30s  %s\n", status, s.Modified.Format("15:04:05"), s.ID[:min(12, len(s.ID))], truncatePath(s.ProjectPath, 30), describeMeta(s.Meta))
 		}
 		return
 	}
 
-	// Validate poll interval
-	pollInterval := time.Duration(*pollMs) * time.Millisecond
-	if pollInterval < 100*time.Millisecond {
-		pollInterval = 100 * time.Millisecond
+	sessions, err := sessionList(sessionIDs, *sessionsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Run TUI
-	model := tui.NewModel(*sessionID, *skipHistory, pollInterval, activeWindow, *maxSessions, collapseAfter)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	attach := *attachURL
+	var lock *instance.Lock
+	if attach == "" {
+		lock, attach, err = claimClaudeDir("tui", *takeover)
+		if errors.Is(err, errQuit) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if lock != nil {
+		defer lock.Release()
+	}
+
+	if cfg.Accessibility.ScreenReader {
+		if attach != "" {
+			fmt.Fprintln(os.Stderr, "Error: -screen-reader reads the transcripts itself and can't attach to another claude-esp")
+			os.Exit(1)
+		}
+		if gotoID != "" {
+			fmt.Fprintln(os.Stderr, "Error: -screen-reader reads the stream in order; use -at to start at a moment instead of -goto")
+			os.Exit(1)
+		}
+		err := runScreenReader(os.Stdout, screenReaderOptions{
+			sessions:     sessions,
+			pollInterval: pollInterval,
+			activeWindow: activeWindow,
+			skipHistory:  *skipHistory,
+			from:         at,
+			filter:       itemFilter,
+			verbosity:    export.Verbosity(cfg.Accessibility.Verbosity),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return
+	}
 
-	if _, err := p.Run(); err != nil {
+	// Run TUI
+	model := tui.NewModel(sessions, *skipHistory, pollInterval, activeWindow, *maxSessions, collapseAfter, cfg)
+	if attach != "" {
+		model.SetAttach(attach)
+	}
+	model.WatchConfig(*configPath)
+	if path := os.Getenv(handoff.EnvVar); path != "" {
+		// Restarted by :restart; the variable isn't for our own children.
+		os.Unsetenv(handoff.EnvVar)
+		if st, err := handoff.Take(path); err != nil {
+			slog.Warn("no handoff from the previous process", "err", err)
+		} else {
+			model.SetHandoff(st)
+		}
+	}
+	model.SetFilter(itemFilter)
+	model.SetFullOutput(*fullOutput)
+	if !at.IsZero() {
+		model.SetAt(at)
+	}
+	if gotoID != "" {
+		model.SetGoto(gotoID)
+	}
+	applyASCII(model)
+	if cfg.Update.Check {
+		model.CheckForUpdates(version)
+	}
+	var pipe *sink.Pipe
+	if *pipeCmd != "" {
+		pipe = sink.NewPipe(*pipeCmd)
+		pipe.Start()
+		model.SetPipe(pipe)
+	}
+	var sinks []sink.Publisher
+	for _, spec := range sinkSpecs {
+		pub, err := sink.Open(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, pub)
+		model.AddSink(pub)
+	}
+	// An attached TUI leaves triggers to the instance it attaches to, so
+	// they don't run twice.
+	if specs := cfg.TriggerSpecs(); len(specs) > 0 && attach == "" {
+		triggers, err := trigger.New(specs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, triggers)
+		model.AddSink(triggers)
+	}
+	if *httpAddr != "" {
+		srv, err := server.Listen(*httpAddr, server.Options{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, srv)
+		model.AddSink(srv)
+		if lock != nil {
+			lock.SetHTTP(srv.Addr(), false)
+		}
+	}
+	var shared *share.Server
+	if *shareAddr != "" {
+		shared, err = share.Listen(*shareAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		model.SetShare(shared)
+	}
+	err = runProgram(model, cfg.Terminal)
+	if shared != nil {
+		shared.Close()
+	}
+	if pipe != nil {
+		pipe.Stop()
+	}
+	for _, pub := range sinks {
+		pub.Close()
+	}
+	if path := model.RestartPath(); path != "" && err == nil {
+		if lock != nil {
+			lock.Release()
+		}
+		err = restart(path)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// restart execs the binary now on disk, which may be a newer one, in this
+// process's place, pointing it at the handoff the TUI wrote.
+func restart(handoffPath string) error {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return fmt.Errorf("restart: cannot locate the binary: %w", err)
+	}
+	os.Setenv(handoff.EnvVar, handoffPath)
+	if err := execSelf(exe); err != nil {
+		return fmt.Errorf("restart: %w", err)
+	}
+	return nil
+}
+
+// applyWatchFlags overrides the [watch] config section with the timing
+// flags that were given and validates the result. -p keeps its historical
+// behaviour of raising values below the minimum instead of rejecting them.
+func applyWatchFlags(w *config.Watch, pollMs int, poll, window, threshold string) error {
+	if pollMs > 0 {
+		w.PollInterval = max(time.Duration(pollMs)*time.Millisecond, watcher.MinPollInterval)
+	}
+	for _, f := range []struct {
+		name, value string
+		dst         *time.Duration
+	}{
+		{"poll-interval", poll, &w.PollInterval},
+		{"active-window", window, &w.ActiveWindow},
+		{"activity-threshold", threshold, &w.ActivityThreshold},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid -%s %q: want a positive duration like 30s or 5m", f.name, f.value)
+		}
+		*f.dst = d
+	}
+	return w.Validate()
+}
+
+// sessionList merges -s session IDs with those listed in a -sessions-file:
+// one per line, blank lines and # comments ignored.
+func sessionList(ids []string, file string) ([]string, error) {
+	if file == "" {
+		return ids, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" {
+			ids = append(ids, line)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s lists no sessions", file)
+	}
+	return ids, nil
+}
+
+// logLevelFlag registers -log-level on fs.
+func logLevelFlag(fs *flag.FlagSet) *string {
+	return fs.String("log-level", "info", "Diagnostics log level: debug, info, warn, error or off (claude-esp.log in the state directory)")
+}
+
+// colorsFlag registers -colors on fs, which overrides [terminal] colors.
+func colorsFlag(fs *flag.FlagSet) *string {
+	return fs.String("colors", "", "Colors to draw with: auto, truecolor, 256, 16 or none (default [terminal] colors, else auto, which honours NO_COLOR)")
+}
+
+// asciiFlag registers -ascii on fs. The function it returns, called once
+// fs is parsed, applies it to the TUI, or without it whether the terminal
+// looks like it can't draw emoji and box drawing.
+func asciiFlag(fs *flag.FlagSet) func(*tui.Model) {
+	ascii := fs.Bool("ascii", false, "Draw borders and icons in ASCII, for consoles and fonts without box drawing or emoji (default: on for limited terminals)")
+	return func(m *tui.Model) {
+		on := tui.DetectASCII(os.Getenv)
+		if flagGiven(fs, "ascii") {
+			on = *ascii
+		}
+		m.SetASCII(on)
+	}
+}
+
+// flagGiven reports whether the flag name was set on fs's command line.
+func flagGiven(fs *flag.FlagSet, name string) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) { given = given || f.Name == name })
+	return given
+}
+
+// dirFlags registers -config-dir, -state-dir and -cache-dir on fs; call
+// the function it returns once fs is parsed. It moves ~/.claude-esp into
+// those directories, so only commands that use them should call it.
+func dirFlags(fs *flag.FlagSet) func() {
+	configDir := fs.String("config-dir", "", "Directory for config.toml (default ~/.config/claude-esp, or $XDG_CONFIG_HOME)")
+	stateDir := fs.String("state-dir", "", "Directory for notes, saved views, the log and crash reports (default ~/.local/state/claude-esp, or $XDG_STATE_HOME)")
+	cacheDir := fs.String("cache-dir", "", "Directory for the session index (default ~/.cache/claude-esp, or $XDG_CACHE_HOME)")
+	return func() {
+		config.SetDirs(*configDir, *stateDir, *cacheDir)
+		migrateDirs()
+		useDirs()
+	}
+}
+
+// useDirs points session listings at the index in the cache directory.
+func useDirs() {
+	if dir, err := config.CacheDir(); err == nil {
+		watcher.IndexPath = filepath.Join(dir, config.IndexFileName)
+	}
+}
+
+// migrateDirs moves ~/.claude-esp, where claude-esp kept its files before
+// following the XDG base directories, into them, saying so on stderr. A
+// move that fails is tried again on the next run.
+func migrateDirs() {
+	moved, err := config.Migrate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "claude-esp: moving ~/.claude-esp to the XDG directories: %v\n", err)
+		return
+	}
+	if len(moved) == 0 {
+		return
+	}
+	configDir, _ := config.ConfigDir()
+	stateDir, _ := config.StateDir()
+	cacheDir, _ := config.CacheDir()
+	fmt.Fprintf(os.Stderr, "claude-esp: moved ~/.claude-esp to %s, %s and %s\n", configDir, stateDir, cacheDir)
+}
+
+// startLogging sends claude-esp's own diagnostics to the rotating log in
+// its state directory, and crash reports to its crashes/ directory. Only
+// a bad level is an error: if the log can't be opened, diagnostics are
+// dropped rather than keeping claude-esp from starting.
+func startLogging(level string) (stop func(), err error) {
+	lvl, err := logging.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := config.StateDir()
+	if err != nil {
+		return func() {}, nil
+	}
+	crash.Setup(dir, version)
+	c, err := logging.Open(dir, lvl)
+	if err != nil {
+		return func() {}, nil
+	}
+	slog.Info("claude-esp started", "version", version, "args", os.Args[1:])
+	return func() { c.Close() }, nil
+}
+
+// claimClaudeDir takes the lock that makes this process the one watching
+// the Claude projects directory, so two instances don't both poll it. If
+// another holds it, -takeover stops that one; otherwise the user is asked
+// whether to take over, attach to its stream (when it serves one), run
+// alongside it, or quit. It returns the lock, nil if running without one,
+// or the URL to attach to; errQuit if the user declined. Without a
+// terminal to ask on, a held lock is an error.
+func claimClaudeDir(mode string, takeover bool) (lock *instance.Lock, attach string, err error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return nil, "", nil
+	}
+	claudeDir, err := watcher.ProjectsDir()
+	if err != nil {
+		return nil, "", nil
+	}
+	info := instance.Info{PID: os.Getpid(), Mode: mode, ClaudeDir: claudeDir, Started: time.Now()}
+	lock, err = instance.Acquire(dir, info)
+	var held *instance.HeldError
+	if !errors.As(err, &held) {
+		return lock, "", err
+	}
+	holder := held.Holder
+	// Only the TUI has anything to show another instance's stream on.
+	attachTo := ""
+	if mode == "tui" {
+		attachTo = holder.URL()
+	}
+	if takeover {
+		lock, err = instance.Takeover(dir, info, holder, 5*time.Second)
+		return lock, "", err
+	}
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		if attachTo != "" {
+			return nil, "", fmt.Errorf("%w; pass -takeover, or -attach %s", held, attachTo)
+		}
+		return nil, "", fmt.Errorf("%w; pass -takeover to replace it", held)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s", held)
+	if !holder.Started.IsZero() {
+		fmt.Fprintf(os.Stderr, ", running since %s", holder.Started.Local().Format("Jan 2 15:04"))
+	}
+	fmt.Fprintln(os.Stderr, ".")
+	fmt.Fprintln(os.Stderr, "  [t] take over: stop it and watch here")
+	if attachTo != "" {
+		fmt.Fprintf(os.Stderr, "  [a] attach: show its stream from %s\n", attachTo)
+	}
+	fmt.Fprintln(os.Stderr, "  [r] run anyway: both read the transcripts")
+	fmt.Fprintln(os.Stderr, "  [q] quit")
+	fmt.Fprint(os.Stderr, "Choice [q]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "t":
+		lock, err = instance.Takeover(dir, info, holder, 5*time.Second)
+		return lock, "", err
+	case "a":
+		if attachTo != "" {
+			return nil, attachTo, nil
+		}
+	case "r":
+		return nil, "", nil
+	}
+	return nil, "", errQuit
+}
+
+// errQuit is claimClaudeDir's answer when the user chose to leave the
+// other instance be.
+var errQuit = errors.New("quit")
+
+// runProgram runs the TUI. A panic anywhere hands the terminal back before
+// its crash report is written.
+func runProgram(model tea.Model, term config.Terminal) error {
+	if term.Title {
+		// The model sets the title as sessions change; put the user's
+		// back afterwards.
+		fmt.Print(osc.PushTitle)
+		defer fmt.Print(osc.PopTitle)
+	}
+	// Focus reports let low-power mode pause while the terminal is in the
+	// background; terminals that don't send them are treated as focused.
+	p := tea.NewProgram(model, tea.WithOutput(tui.Terminal), tea.WithAltScreen(), tea.WithReportFocus())
+	crash.SetRestore(func() { p.ReleaseTerminal() })
+	defer crash.SetRestore(nil)
+	_, err := p.Run()
+	return err
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
 func truncatePath(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -137,6 +674,23 @@ func truncatePath(s string, max int) string {
 	return "..." + s[len(s)-max+3:]
 }
 
+// describeMeta summarizes a listed session: its git branch and how much
+// happened in it.
+func describeMeta(m *watcher.SessionMeta) string {
+	if m == nil {
+		return ""
+	}
+	var parts []string
+	if m.GitBranch != "" {
+		parts = append(parts, m.GitBranch)
+	}
+	parts = append(parts, fmt.Sprintf("%d prompts, %d tool calls", m.Prompts, m.ToolCalls))
+	if !m.First.IsZero() {
+		parts = append(parts, "started "+m.First.Local().Format("Jan 2 15:04"))
+	}
+	return strings.Join(parts, "  ")
+}
+
 func printHelp() {
 	fmt.Printf(`claude-esp v%s
 
@@ -145,35 +699,220 @@ to a separate terminal.
 
 USAGE:
     claude-esp [OPTIONS]
+    claude-esp <COMMAND> [ARGS]
+
+COMMANDS:
+    models [-config <f>] [model...]
+                Show the pricing table and validate config overrides
+    serve [-http <addr>] [-s <ID>]... [-n] [-filter <expr>] [-sink <s>]... [-takeover]
+          [-accept-push] [-token-file <f>] [-tls-cert <f> -tls-key <f>]
+          [-tls-self-signed] [-retain <dur>] [-config <f>] [-log-level <l>]
+                Run without the TUI, serving the stream over HTTP
+                (default 127.0.0.1:7777) and any -sink outputs;
+                -accept-push adds what other hosts push, -token-file
+                requires read/admin tokens, -tls-* serve HTTPS; runs
+                the config's [[triggers]]
+    push -to <host:port> [-host <name>] [-s <ID>]... [-n] [-filter <expr>]
+                Send this machine's stream to a serve -accept-push daemon,
+                whose tree labels the sessions with the host name
+    mcp [-config <f>] [-log-level <l>]
+                MCP server on stdio with list_sessions, get_recent_activity,
+                search_history and get_session_stats tools
+    status [-json] [-s <ID>] [-w <dur>] [-stall <dur>]
+                Heartbeat per recent session: working/idle/stalled, idle
+                time and running tool (also GET /api/status with -http)
+    open [-config <f>] [-log-level <l>] [-full] [-ascii] [-colors <m>] <file>
+                Browse a session transcript from any path (copied from
+                another machine, unpacked from a bundle); replays the
+                whole file
+    export [-format md|csv] [-filter <expr>] [-o <file>] <file | ID>...
+                Write whole sessions (with subagents) as a Markdown
+                transcript, or as CSV with one row per item (timestamp,
+                session, agent, type, tool, duration, tokens, size,
+                is_error, id) for spreadsheets and pandas
+    update [-check]
+                Download the latest release for this platform, verify its
+                checksum and replace this binary; -check only reports
+    doctor [-w <dur>] [-sessions <N>] [-lines <N>]
+                Check the projects directory, sessions, transcript
+                permissions, inotify limits and recent transcript lines,
+                with a fix for each problem
+    bench [-width <N>] [-height <N>] [-lines <N>] [-n <N>] [-json] <file | ID>
+                Replay a transcript (first 200 lines by default) through the
+                parser and an offscreen stream view; prints items/s and
+                allocations per item
 
 OPTIONS:
-    -s <ID>     Watch a specific session by ID
+    -s <ID>     Watch a specific session by ID; repeat to watch several
+                together
+    -sessions-file <f>
+                Watch the sessions listed in a file, one ID per line
+                (# comments allowed); combines with -s
     -l          List recent sessions
     -a          List active sessions
     -n          Start from newest (skip history, live only)
+    -at <time>  Open the stream at a moment: 2025-06-01T14:03:00Z,
+                "2025-06-01 14:03", 14:03 (today) or -15m (ago). Exports
+                and notifications carry "claude-esp -s <id> -at <time>"
+                links to their moment
+    -goto <id>  Open the stream at the item with this ID: the "id" in
+                JSON output, CSV exports and Markdown headings. ":goto"
+                copies the selected item's "claude-esp -s <id> -goto <id>"
     -p <ms>     Poll interval in ms, fallback mode only (default 500, min 100)
-    -w <dur>    Active window duration (default 5m, e.g. 30s, 2m, 10m)
+    -poll-interval <dur>
+                Same as -p as a duration (e.g. 250ms); values below
+                100ms are rejected
+    -w, -active-window <dur>
+                Active window duration (default 5m, e.g. 30s, 2m, 10m)
+    -activity-threshold <dur>
+                How recently a session or agent must have written to
+                show as active in the tree (default 30s)
+    -low-power  Battery-friendly: poll every 2s or more, refresh activity
+                every 10s, and pause while the terminal is unfocused
+                (new events still show immediately)
     -m <N>      Max sessions to show in tree (default 0=unlimited)
     -c <dur>    Auto-collapse sessions inactive ≥ dur (0=disabled, e.g. 2m, 30s)
     -D          Debug: show raw type:subtype for every JSONL line we'd drop
+    -lenient    Show lines the parser can't read (malformed JSON, e.g. an
+                interrupted write, or an unexpected message shape) as
+                "? Unreadable line" items with what could be salvaged
+    -config <f> Config file (default ~/.config/claude-esp/config.toml)
+    -config-dir, -state-dir, -cache-dir <dir>
+                Where claude-esp keeps its config, its state (notes, views,
+                log, crash reports) and its cache (session index); default
+                claude-esp under $XDG_CONFIG_HOME (~/.config),
+                $XDG_STATE_HOME (~/.local/state) and $XDG_CACHE_HOME
+                (~/.cache). Files from ~/.claude-esp move there once
+    -filter <expr>
+                Show only matching items (also what -pipe and exports get):
+                fields type, tool, agent, agent_id, session (ID prefix),
+                model, cwd, host, content, error; operators = != ~ !~ (contains)
+                and in (...); combine with not, and, or, parentheses. E.g.
+                "type in (tool_input, tool_output) and tool != Read"
+    -screen-reader
+                Instead of the TUI, print the stream as plain lines labelled
+                "Thinking from Main at 12:03:45:", for screen readers
+    -verbosity <v>
+                How much of each item -screen-reader reads: brief (label and
+                first line), normal (default; up to 10 lines) or full
+    -ascii      Draw borders and icons in ASCII, for serial consoles, old
+                PuTTY and fonts without emoji (on by default for the Linux
+                console, VT terminals and non-UTF-8 locales; -ascii=false
+                turns it off)
+    -colors <m> Colors to draw with: auto (default; none with NO_COLOR),
+                truecolor, 256, 16 or none
+    -full       Show items in full instead of cutting them at 50 lines
+                (Z toggles while running, z per item)
+    -takeover   If another claude-esp is watching the same Claude directory,
+                stop it and watch here instead of asking
+    -attach <url>
+                Show the stream of a claude-esp serving -http at url
+                (e.g. http://127.0.0.1:7777) instead of reading transcripts
+    -log-level <l>
+                Diagnostics written to ~/.local/state/claude-esp/claude-esp.log:
+                debug, info (default), warn, error or off
+    -pipe <cmd> Pipe the filtered stream as plain text to a shell command
+                (restarted if it exits; status shown in the footer)
+    -sink <s>   Publish every item as NDJSON: unix:///path.sock (clients
+                connect any time) or a FIFO path (created if missing);
+                append ?feed=edits for file-edit events only; repeatable
+    -http <a>   Serve the stream and file-edit events over HTTP on
+                host:port (/api/items, /api/edits, /api/edits/recent,
+                /api/status)
+    -share <a>  Mirror the TUI read-only to viewers on host:port; watch
+                with "telnet <host> <port>" (no auth: prefer 127.0.0.1
+                plus an ssh tunnel)
+    -schema     Print the JSON Schema (version 1) of everything written
+                for programs: -sink and HTTP items, edit events, status
+                heartbeats and notify hook events
     -v          Show version
     -h          Show this help
 
 ENVIRONMENT:
     CLAUDE_HOME     Override Claude config directory (default: ~/.claude)
+    CLAUDE_ESP_CONFIG_DIR, CLAUDE_ESP_STATE_DIR, CLAUDE_ESP_CACHE_DIR
+                    Same as -config-dir, -state-dir and -cache-dir
+    CLAUDE_ESP_HOME Keep all of claude-esp's files in this one directory
+    NO_COLOR        Draw without colors (unless -colors says otherwise)
+    CLAUDE_ESP_TOKEN
+                    Token push and -attach send to a serve -token-file
+    CLAUDE_ESP_CERT_SHA256
+                    Trust the serve -tls-self-signed certificate with this
+                    fingerprint
 
 KEYBINDINGS:
     t           Toggle thinking visibility
     i           Toggle tool input visibility
     o           Toggle tool output visibility
+    O           Show only stderr (and failed results) in tool output
+    *           Highlights: typed commands, final responses, errors,
+                file edits and Task calls/reports only
+    1-4         View preset N (1 thinking only, 2 tools only, 3 errors
+                only, 4 everything; see [view]); before a motion, a count
+    p           Next view preset; <N>p also picks preset N
     a           Toggle auto-scroll
+    v           Toggle timeline view (thinking/tool/idle lanes per agent)
+    $           Toggle stats view (tokens/cost/tools per agent, Task fan-out,
+                bars of tokens and tool calls per 5 minutes)
+    r           Toggle recap of the last 15m: files edited, commands, tests,
+                errors, todos (palette "recap 1h" looks further back)
+    L           Toggle the list of tool calls running past their limit
+    T           Toggle the tests panel (latest test run per session)
     h           Hide/show tree pane
     A           Toggle auto-discovery of new sessions
-    x/d         Remove selected session (in tree)
-    tab         Switch focus between tree and stream
+    x           Toggle text/response visibility (in stream)
+    H           Toggle hook visibility (hooks firing, output, failures)
+    x/d         Remove selected session (in tree; u undoes)
+    D           Hide selected session for good, also in later runs
+                (":unignore" lists, ":unignore <id>" restores)
+    M           Snooze selected session/agent for 10m, M again wakes it
+                (":snooze 1h" for another length, ":snooze" lists)
+    +           Show new agents held by new_agents = "ask"
+    u           Undo the last session removal, toggle, solo, snooze or +
+    tab         Switch focus between tree and stream (thick border on the
+                focused pane; "focus-follows on" in the palette, or
+                focus_follows under [view], moves it by key and activity)
+    /           Filter the tree by project, title, session or agent (fuzzy)
     j/k         Navigate (tree) or scroll (stream)
+    ctrl+d/u    Half page down/up
+    ctrl+f/b    Full page down/up (also pgdn/pgup)
+    <count>     Repeat a motion (5j, 3ctrl+d); with gg/G, go to line/row N
+    J/K         Select next/previous stream item (esc clears)
+    [ / ]       Scrub the stream back/forward in time (20[: twenty items)
+                while live items keep arriving; G or esc returns to live
+    f           Follow the selected Task as a thread (esc returns)
+    z           Expand the selected item's truncated content in place (toggle;
+                agent reports start whole, so z collapses them)
+    Z           Show all items in full / truncated again
+    m           Mark range start at the selected item
+    E           Export marked range (or visible stream) to Markdown
+    ctrl+e      Export, then open the file in $VISUAL/$EDITOR
+    e           Open the selected result's build/lint error in $EDITOR at its
+                line (<N>e: the Nth); editors get +line, or --goto for VS Code.
+                With a path picked by l, opens that path; in the files panel,
+                the file under the cursor
+    l           Pick the next file path in the selected output
+    F           Toggle the files-touched panel, hottest first with churn
+                flagged (J/K move); reveals the path picked by l
+    y           Copy marked range (or selected item) to clipboard
+    n           Note on selected item (stream) or session (tree)
+    :           Command palette (e.g. "poll-interval 250ms"; "help" lists;
+                "restart" switches to the binary on disk, keeping the stream;
+                "queues on" shows watcher queue and dropped-item counters;
+                "ascii toggle" switches ASCII drawing; "filter <expr>"
+                replaces -filter). In prompts: tab completes, up/down
+                recall history, emacs keys edit
     space       On agent: toggle visibility · On session: collapse/expand (pins on manual expand)
-    g/G         Go to top/bottom of stream
+    gg/G        Go to top/bottom of the focused pane (G resumes auto-scroll)
+    enter       On background task/artifact/todos: show it; on an agent with a
+                report: read it · In stream: jump to new items
+                ("↓ N new" chip), else page through the selected item
+                (pager: / search, n/N, F follow, q/esc close)
+    S           Summarize the selected session's last hour with the
+                summarizer set under [summarize] in the config
+    Q           Record a macro until the next Q, then bind it to the key
+                pressed next; saved as [[macros]] in the config file
+    ctrl+z      Suspend (resume with fg)
     q           Quit
 
 USAGE:
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// execSelf replaces this process with exe, run with the same arguments
+// and environment. It only returns on failure.
+func execSelf(exe string) error {
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
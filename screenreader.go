@@ -0,0 +1,118 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/phiat/claude-esp/internal/export"
+	"github.com/phiat/claude-esp/internal/filter"
+	"github.com/phiat/claude-esp/internal/fork"
+	"github.com/phiat/claude-esp/internal/modelswitch"
+	"github.com/phiat/claude-esp/internal/parser"
+	"github.com/phiat/claude-esp/internal/watcher"
+)
+
+// screenReaderOptions are what -screen-reader watches and how much it says.
+type screenReaderOptions struct {
+	sessions     []string
+	pollInterval time.Duration
+	activeWindow time.Duration
+	skipHistory  bool
+	from         time.Time // -at: history before it is left out
+	filter       *filter.Expr
+	verbosity    export.Verbosity
+}
+
+// runScreenReader is -screen-reader: instead of the TUI, the stream is
+// written to out as it arrives, one labelled item after another in plain
+// lines ("Thinking from Main at 12:03:45:"), with no borders, colors,
+// symbols or redrawing for a screen reader to trip over. New sessions and
+// agents and watcher warnings are announced the same way.
+func runScreenReader(out io.Writer, opts screenReaderOptions) error {
+	w, err := watcher.New(opts.sessions, opts.pollInterval, opts.activeWindow, 0)
+	if err != nil {
+		return err
+	}
+	if opts.skipHistory {
+		w.SetSkipHistory(true)
+	}
+	if !opts.from.IsZero() {
+		w.SetFullHistory(true) // -at may be anywhere in the history
+	}
+	projects := make(map[string]string)
+	for _, s := range w.GetSessions() {
+		projects[s.ID] = filepath.Base(s.ProjectPath)
+	}
+	w.Start()
+	defer w.Stop()
+	fmt.Fprintf(out, "claude-esp screen reader mode, %s verbosity. Watching %s. Press Ctrl+C to stop.\n", opts.verbosity, countSessions(len(projects)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Same tool-ID dedupe as the stream view.
+	seen := make(map[string]bool)
+	switches := modelswitch.New()
+	forks := fork.New()
+	say := func(item parser.StreamItem) {
+		if !opts.filter.Match(item) || item.Timestamp.Before(opts.from) {
+			return
+		}
+		// Name the session only when there is more than one to tell apart.
+		var session string
+		if len(projects) > 1 {
+			session = projects[item.SessionID]
+		}
+		fmt.Fprintln(out, strings.Join(export.Announce(item, opts.verbosity, session), "\n"))
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-w.Items:
+			if item.Type == parser.TypeProgress {
+				continue // updates in place don't read well as lines
+			}
+			if key, ok := parser.DedupKey(item); ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			for _, item := range forks.Add(item) {
+				if sw, ok := switches.Add(item); ok {
+					say(sw)
+				}
+				say(item)
+			}
+		case s := <-w.NewSession:
+			projects[s.SessionID] = filepath.Base(s.ProjectPath)
+			fmt.Fprintf(out, "New session in %s. Watching %s.\n", projects[s.SessionID], countSessions(len(projects)))
+		case a := <-w.NewAgent:
+			fmt.Fprintf(out, "New agent %s in %s.\n", cmp.Or(a.AgentType, a.AgentID), projects[a.SessionID])
+		case err := <-w.Errors:
+			fmt.Fprintf(out, "Warning: %v\n", err)
+		case warning := <-w.Warnings:
+			fmt.Fprintf(out, "Warning: %s\n", warning)
+		case fe := <-w.FileErrors:
+			if fe.Err != nil {
+				fmt.Fprintf(out, "Warning: can't read a transcript, %s\n", fe.Reason())
+			}
+		}
+	}
+}
+
+// countSessions is "1 session" or "3 sessions".
+func countSessions(n int) string {
+	if n == 1 {
+		return "1 session"
+	}
+	return fmt.Sprintf("%d sessions", n)
+}